@@ -171,6 +171,11 @@ type StoreSpec struct {
 	// ExtraOptions is a serialized protobuf set by Go CCL code and passed through
 	// to C CCL code.
 	ExtraOptions []byte
+	// RaftPath, if set, is the directory of a dedicated engine holding the
+	// store's Raft log, which may be on a separate device. Once a store has
+	// been started with a dedicated Raft engine, it must continue to use it;
+	// it refuses to start otherwise.
+	RaftPath string
 }
 
 // String returns a fully parsable version of the store spec.
@@ -179,6 +184,9 @@ func (ss StoreSpec) String() string {
 	if len(ss.Path) != 0 {
 		fmt.Fprintf(&buffer, "path=%s,", ss.Path)
 	}
+	if len(ss.RaftPath) != 0 {
+		fmt.Fprintf(&buffer, "raft-path=%s,", ss.RaftPath)
+	}
 	if ss.InMemory {
 		fmt.Fprint(&buffer, "type=mem,")
 	}
@@ -235,6 +243,8 @@ var fractionRegex = regexp.MustCompile(`^([-]?([0-9]+\.[0-9]*|[0-9]*\.[0-9]+|[0-
 //   - 20%             -> 20% of the available space
 //   - 0.2             -> 20% of the available space
 // - attrs=xxx:yyy:zzz A colon separated list of optional attributes.
+// - raft-path=xxx The optional directory of a dedicated rocks db instance
+//   holding the store's Raft log, which may be on a separate device.
 // Note that commas are forbidden within any field name or value.
 func NewStoreSpec(value string) (StoreSpec, error) {
 	const pathField = "path"
@@ -310,6 +320,12 @@ func NewStoreSpec(value string) (StoreSpec, error) {
 			}
 		case "rocksdb":
 			ss.RocksDBOptions = value
+		case "raft-path":
+			var err error
+			ss.RaftPath, err = GetAbsoluteStorePath(field, value)
+			if err != nil {
+				return StoreSpec{}, err
+			}
 		default:
 			return StoreSpec{}, fmt.Errorf("%s is not a valid store field", field)
 		}
@@ -319,11 +335,16 @@ func NewStoreSpec(value string) (StoreSpec, error) {
 		if ss.Path != "" {
 			return StoreSpec{}, fmt.Errorf("path specified for in memory store")
 		}
+		if ss.RaftPath != "" {
+			return StoreSpec{}, fmt.Errorf("raft-path specified for in memory store")
+		}
 		if ss.Size.Percent == 0 && ss.Size.InBytes == 0 {
 			return StoreSpec{}, fmt.Errorf("size must be specified for an in memory store")
 		}
 	} else if ss.Path == "" {
 		return StoreSpec{}, fmt.Errorf("no path specified")
+	} else if ss.RaftPath == ss.Path {
+		return StoreSpec{}, fmt.Errorf("raft-path must differ from path")
 	}
 	return ss, nil
 }
@@ -101,6 +101,10 @@ type TestServerArgs struct {
 	// If set, web session authentication will be disabled, even if the server
 	// is running in secure mode.
 	DisableWebSessionAuthentication bool
+
+	// If set, in-memory stores are backed by the pure-Go engine.GoInMem rather
+	// than by RocksDB.
+	UseGoEngine bool
 }
 
 // TestClusterArgs contains the parameters one can set when creating a test
@@ -507,6 +507,9 @@ func (m *pgDumpReader) readFile(
 			if !i.Stdin {
 				return errors.New("expected STDIN option on COPY FROM")
 			}
+			if i.Options != (tree.CopyOptions{}) {
+				return errors.Errorf("unsupported options on COPY FROM: %s", i)
+			}
 			name, err := getTableName(&i.Table)
 			if err != nil {
 				return errors.Wrapf(err, "%s", i)
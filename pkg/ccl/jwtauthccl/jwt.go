@@ -0,0 +1,176 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package jwtauthccl
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/utilccl"
+	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/server"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/hba"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/pkg/errors"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+const authTypeCleartextPassword int32 = 3
+
+// verifyToken verifies a signed JSON Web Token (RFC 7519) against the
+// audience, issuers and keys configured in the cluster settings, and returns
+// the SQL user it authenticates. A token must be signed by one of the keys of
+// its issuer, and must have an expiration time.
+func verifyToken(sv *settings.Values, token string, now time.Time) (string, error) {
+	aud := jwtAudience.Get(sv)
+	if aud == "" {
+		return "", errors.New("the token audience (server.jwt_authentication.audience) is not configured")
+	}
+	issuerKeys, err := parseIssuerKeys(jwtJWKS.Get(sv))
+	if err != nil {
+		return "", err
+	}
+	if len(issuerKeys) == 0 {
+		return "", errors.New("no keys are configured to verify tokens")
+	}
+
+	tok, err := jwt.ParseSigned(token)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid token")
+	}
+	if len(tok.Headers) != 1 {
+		return "", errors.New("invalid token: expected exactly one signature")
+	}
+	// The issuer is read before the signature is verified to find its keys;
+	// the signature then binds the token to that issuer.
+	var unverified jwt.Claims
+	if err := tok.UnsafeClaimsWithoutVerification(&unverified); err != nil {
+		return "", errors.Wrap(err, "invalid token")
+	}
+	jwks, ok := issuerKeys[unverified.Issuer]
+	if !ok {
+		return "", errors.Errorf("invalid token: issuer %q is not accepted", unverified.Issuer)
+	}
+	keys := jwks.Keys
+	if kid := tok.Headers[0].KeyID; kid != "" {
+		if keys = jwks.Key(kid); len(keys) == 0 {
+			return "", errors.Errorf("invalid token: unknown key %q for issuer %q", kid, unverified.Issuer)
+		}
+	}
+	var claims jwt.Claims
+	var allClaims map[string]interface{}
+	verified := false
+	for i := range keys {
+		if err := tok.Claims(&keys[i], &claims, &allClaims); err == nil {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return "", errors.New("invalid token: signature verification failed")
+	}
+
+	if claims.Expiry == nil {
+		return "", errors.New("invalid token: missing expiration time")
+	}
+	if err := claims.Validate(jwt.Expected{
+		Issuer:   unverified.Issuer,
+		Audience: jwt.Audience{aud},
+		Time:     now,
+	}); err != nil {
+		return "", errors.Wrap(err, "invalid token")
+	}
+
+	claim := jwtClaim.Get(sv)
+	user, ok := allClaims[claim].(string)
+	if !ok || user == "" {
+		return "", errors.Errorf("invalid token: missing %q claim", claim)
+	}
+	return tree.Name(user).Normalize(), nil
+}
+
+// authJWT performs JWT authentication. The client sends the token in place of
+// a cleartext password, and the user it authenticates must be the requested
+// user.
+func authJWT(
+	c pgwire.AuthConn,
+	tlsState tls.ConnectionState,
+	insecure bool,
+	hashedPassword []byte,
+	execCfg *sql.ExecutorConfig,
+	entry *hba.Entry,
+) (security.UserAuthHook, error) {
+	if err := c.SendAuthRequest(authTypeCleartextPassword, nil /* data */); err != nil {
+		return nil, err
+	}
+	pwdData, err := c.GetPwdData()
+	if err != nil {
+		return nil, err
+	}
+	if len(pwdData) == 0 || bytes.IndexByte(pwdData, 0) != len(pwdData)-1 {
+		return nil, errors.New("expected 0-terminated byte array")
+	}
+	token := string(pwdData[:len(pwdData)-1])
+
+	return func(requestedUser string, clientConnection bool) error {
+		if len(requestedUser) == 0 {
+			return errors.New("user is missing")
+		}
+		if !clientConnection {
+			return errors.New("JWT authentication is only available for client connections")
+		}
+		if requestedUser == security.RootUser {
+			return errors.Errorf("user %s must use certificate authentication instead of JWT authentication", security.RootUser)
+		}
+		tokenUser, err := verifyToken(&execCfg.Settings.SV, token, execCfg.Clock.PhysicalTime())
+		if err != nil {
+			return errors.Wrapf(err, "JWT authentication failed for user %s", requestedUser)
+		}
+		if tokenUser != requestedUser {
+			return errors.Errorf("JWT authentication failed for user %s: token is for user %s", requestedUser, tokenUser)
+		}
+		return utilccl.CheckEnterpriseEnabled(execCfg.Settings, execCfg.ClusterID(), execCfg.Organization(), "JWT authentication")
+	}, nil
+}
+
+// verifyLoginToken implements server.VerifyLoginToken.
+func verifyLoginToken(
+	ctx context.Context, execCfg *sql.ExecutorConfig, token string,
+) (string, error) {
+	if !jwtWebLoginEnabled.Get(&execCfg.Settings.SV) {
+		return "", errors.New("JWT authentication is not enabled for web logins")
+	}
+	user, err := verifyToken(&execCfg.Settings.SV, token, execCfg.Clock.PhysicalTime())
+	if err != nil {
+		return "", err
+	}
+	if err := utilccl.CheckEnterpriseEnabled(
+		execCfg.Settings, execCfg.ClusterID(), execCfg.Organization(), "JWT authentication",
+	); err != nil {
+		return "", err
+	}
+	return user, nil
+}
+
+func checkEntry(entry hba.Entry) error {
+	if len(entry.Options) > 0 {
+		return errors.Errorf("unsupported option %s", entry.Options[0][0])
+	}
+	return nil
+}
+
+func init() {
+	pgwire.RegisterAuthMethod("jwt", authJWT, checkEntry)
+	server.VerifyLoginToken = verifyLoginToken
+}
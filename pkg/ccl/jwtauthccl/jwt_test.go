@@ -0,0 +1,340 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package jwtauthccl
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	gosql "database/sql"
+	"encoding/json"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/server"
+	"github.com/cockroachdb/cockroach/pkg/server/serverpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/httputil"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	_ "github.com/lib/pq"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+const (
+	testIssuer   = "https://issuer.example.com"
+	testAudience = "cockroach"
+)
+
+type testKey struct {
+	kid  string
+	priv *ecdsa.PrivateKey
+}
+
+func makeTestKey(t *testing.T, kid string) testKey {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testKey{kid: kid, priv: priv}
+}
+
+// jwks returns a JSON Web Key Set with the public keys.
+func jwks(t *testing.T, keys ...testKey) string {
+	var set jose.JSONWebKeySet
+	for _, k := range keys {
+		set.Keys = append(set.Keys, jose.JSONWebKey{
+			Key: &k.priv.PublicKey, KeyID: k.kid, Algorithm: string(jose.ES256), Use: "sig",
+		})
+	}
+	b, err := json.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+// issuerKeys returns the value of server.jwt_authentication.jwks which binds
+// the issuers to the JSON Web Key Sets.
+func issuerKeys(t *testing.T, jwksByIssuer map[string]string) string {
+	raw := make(map[string]json.RawMessage, len(jwksByIssuer))
+	for iss, jwks := range jwksByIssuer {
+		raw[iss] = json.RawMessage(jwks)
+	}
+	b, err := json.Marshal(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+// sign returns a token with the claims, signed with the key.
+func (k testKey) sign(t *testing.T, claims map[string]interface{}) string {
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.ES256, Key: jose.JSONWebKey{Key: k.priv, KeyID: k.kid}},
+		(&jose.SignerOptions{}).WithType("JWT"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := jwt.Signed(signer).Claims(claims).CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestVerifyToken(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	key1, key2 := makeTestKey(t, "key1"), makeTestKey(t, "key2")
+	unknownKey := makeTestKey(t, "key3")
+	otherIssuerKey := makeTestKey(t, "other")
+	const otherIssuer = "https://other.example.com"
+	now := timeutil.Unix(1560000000, 0)
+	claims := func(overrides map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{
+			"iss": testIssuer,
+			"aud": testAudience,
+			"sub": "Carl",
+			"exp": now.Add(time.Hour).Unix(),
+		}
+		for k, v := range overrides {
+			if v == nil {
+				delete(c, k)
+			} else {
+				c[k] = v
+			}
+		}
+		return c
+	}
+
+	st := cluster.MakeTestingClusterSettings()
+	u := st.MakeUpdater()
+	for key, value := range map[string]string{
+		"server.jwt_authentication.audience": testAudience,
+		"server.jwt_authentication.jwks": issuerKeys(t, map[string]string{
+			testIssuer:  jwks(t, key1, key2),
+			otherIssuer: jwks(t, otherIssuerKey),
+		}),
+	} {
+		if err := u.Set(key, value, jwtJWKS.Typ()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testCases := []struct {
+		name  string
+		token string
+		user  string
+		err   string
+	}{
+		{"valid", key1.sign(t, claims(nil)), "carl", ""},
+		{"second key", key2.sign(t, claims(nil)), "carl", ""},
+		{"no key ID", testKey{priv: key2.priv}.sign(t, claims(nil)), "carl", ""},
+		{"garbage", "not.a.token", "", "invalid token"},
+		{"unknown key", unknownKey.sign(t, claims(nil)), "", `unknown key "key3"`},
+		{"other issuer", otherIssuerKey.sign(t, claims(map[string]interface{}{"iss": otherIssuer})), "carl", ""},
+		// A key can only sign the tokens of its issuer.
+		{"key of other issuer", otherIssuerKey.sign(t, claims(nil)), "",
+			`unknown key "other" for issuer "https://issuer.example.com"`},
+		{"key of other issuer without key ID", testKey{priv: otherIssuerKey.priv}.sign(t, claims(nil)), "",
+			"signature verification failed"},
+		{"key of other issuer with its key ID", testKey{kid: "key1", priv: otherIssuerKey.priv}.sign(t, claims(nil)), "",
+			"signature verification failed"},
+		{"wrong key", testKey{kid: "key1", priv: unknownKey.priv}.sign(t, claims(nil)), "",
+			"signature verification failed"},
+		{"wrong issuer", key1.sign(t, claims(map[string]interface{}{"iss": "https://evil.example.com"})), "",
+			`issuer "https://evil.example.com" is not accepted`},
+		{"wrong audience", key1.sign(t, claims(map[string]interface{}{"aud": "other"})), "",
+			"invalid audience"},
+		{"no audience", key1.sign(t, claims(map[string]interface{}{"aud": nil})), "",
+			"invalid audience"},
+		{"expired", key1.sign(t, claims(map[string]interface{}{"exp": now.Add(-time.Hour).Unix()})), "",
+			"token is expired"},
+		{"not valid yet", key1.sign(t, claims(map[string]interface{}{"nbf": now.Add(time.Hour).Unix()})), "",
+			"token not valid yet"},
+		{"no expiration", key1.sign(t, claims(map[string]interface{}{"exp": nil})), "",
+			"missing expiration time"},
+		{"no subject", key1.sign(t, claims(map[string]interface{}{"sub": nil})), "",
+			`missing "sub" claim`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			user, err := verifyToken(&st.SV, tc.token, now)
+			if !testutils.IsError(err, tc.err) {
+				t.Fatalf("expected error %q, got %v", tc.err, err)
+			}
+			if user != tc.user {
+				t.Fatalf("expected user %q, got %q", tc.user, user)
+			}
+		})
+	}
+
+	t.Run("claim", func(t *testing.T) {
+		if err := u.Set("server.jwt_authentication.claim", "email", jwtClaim.Typ()); err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			if err := u.Set("server.jwt_authentication.claim", "sub", jwtClaim.Typ()); err != nil {
+				t.Fatal(err)
+			}
+		}()
+		token := key1.sign(t, claims(map[string]interface{}{"email": "dana@example.com"}))
+		if user, err := verifyToken(&st.SV, token, now); err != nil || user != "dana@example.com" {
+			t.Fatalf("expected user dana@example.com, got %q (%v)", user, err)
+		}
+	})
+
+	t.Run("audience required", func(t *testing.T) {
+		if err := u.Set("server.jwt_authentication.audience", "", jwtAudience.Typ()); err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			if err := u.Set("server.jwt_authentication.audience", testAudience, jwtAudience.Typ()); err != nil {
+				t.Fatal(err)
+			}
+		}()
+		for _, c := range []map[string]interface{}{claims(nil), claims(map[string]interface{}{"aud": nil})} {
+			const expected = "audience (server.jwt_authentication.audience) is not configured"
+			if _, err := verifyToken(&st.SV, key1.sign(t, c), now); !testutils.IsError(err, expected) {
+				t.Fatalf("expected error %q, got %v", expected, err)
+			}
+		}
+	})
+}
+
+func TestJWKSSetting(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	key := makeTestKey(t, "key1")
+	private, err := json.Marshal(jose.JSONWebKey{Key: key.priv, KeyID: "private"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		jwks string
+		err  string
+	}{
+		{"", ""},
+		{issuerKeys(t, map[string]string{testIssuer: jwks(t, key)}), ""},
+		{"{", "invalid issuer keys"},
+		{jwks(t, key), `invalid JSON Web Key Set for issuer "keys"`},
+		{issuerKeys(t, map[string]string{"": jwks(t, key)}), "empty issuer"},
+		{issuerKeys(t, map[string]string{testIssuer: `{"keys": [` + string(private) + `]}`}),
+			`key "private" for issuer "https://issuer.example.com" is not a public key`},
+	}
+	for i, tc := range testCases {
+		if err := jwtJWKS.Validate(nil, tc.jwks); !testutils.IsError(err, tc.err) {
+			t.Fatalf("%d: expected error %q, got %v", i, tc.err, err)
+		}
+	}
+}
+
+func TestJWTAuthentication(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	s, conn, _ := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(context.TODO())
+	db := sqlutils.MakeSQLRunner(conn)
+
+	key := makeTestKey(t, "key1")
+	db.Exec(t, `CREATE USER carl`)
+	db.Exec(t, `SET CLUSTER SETTING server.jwt_authentication.audience = $1`, testAudience)
+	db.Exec(t, `SET CLUSTER SETTING server.jwt_authentication.jwks = $1`,
+		issuerKeys(t, map[string]string{testIssuer: jwks(t, key)}))
+	db.Exec(t, `SET CLUSTER SETTING server.host_based_authentication.configuration = 'host all all all jwt'`)
+
+	// Sign the tokens with the time of the server.
+	now := s.Clock().PhysicalTime()
+	token := func(user string) string {
+		return key.sign(t, map[string]interface{}{
+			"iss": testIssuer, "aud": testAudience, "sub": user, "exp": now.Add(time.Hour).Unix(),
+		})
+	}
+
+	t.Run("sql", func(t *testing.T) {
+		host, port, err := net.SplitHostPort(s.ServingAddr())
+		if err != nil {
+			t.Fatal(err)
+		}
+		login := func(user, password string) error {
+			pgURL := url.URL{
+				Scheme:   "postgres",
+				User:     url.UserPassword(user, password),
+				Host:     net.JoinHostPort(host, port),
+				RawQuery: "sslmode=require",
+			}
+			userDB, err := gosql.Open("postgres", pgURL.String())
+			if err != nil {
+				return err
+			}
+			defer userDB.Close()
+			_, err = userDB.Exec("SELECT 1")
+			return err
+		}
+
+		if err := login("carl", token("carl")); err != nil {
+			t.Fatal(err)
+		}
+		if err := login("carl", token("dana")); !testutils.IsError(err, "token is for user dana") {
+			t.Fatalf("expected user mismatch, got %v", err)
+		}
+		if err := login("carl", "password"); !testutils.IsError(err, "JWT authentication failed for user carl") {
+			t.Fatalf("expected invalid token, got %v", err)
+		}
+	})
+
+	t.Run("web", func(t *testing.T) {
+		ts := s.(*server.TestServer)
+		login := func(req serverpb.UserLoginRequest) error {
+			httpClient, err := ts.GetHTTPClient()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var resp serverpb.UserLoginResponse
+			response, err := httputil.PostJSONWithRequest(
+				httpClient, ts.AdminURL()+"/login", &req, &resp,
+			)
+			if err == nil && len(response.Cookies()) == 0 {
+				t.Fatal("expected session cookie")
+			}
+			return err
+		}
+
+		if err := login(serverpb.UserLoginRequest{Token: token("carl")}); !testutils.IsError(err, "status: 401") {
+			t.Fatalf("expected web logins to be disabled, got %v", err)
+		}
+		db.Exec(t, `SET CLUSTER SETTING server.jwt_authentication.web_login.enabled = true`)
+		if err := login(serverpb.UserLoginRequest{Token: token("carl")}); err != nil {
+			t.Fatal(err)
+		}
+		if err := login(serverpb.UserLoginRequest{Username: "carl", Token: token("carl")}); err != nil {
+			t.Fatal(err)
+		}
+		for _, req := range []serverpb.UserLoginRequest{
+			{Username: "dana", Token: token("carl")},
+			{Token: token("dana")},
+			{Token: token("root")},
+			{Token: "invalid"},
+		} {
+			if err := login(req); !testutils.IsError(err, "status: 401") {
+				t.Fatalf("%+v: expected login failure, got %v", req, err)
+			}
+		}
+	})
+}
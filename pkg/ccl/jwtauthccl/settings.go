@@ -0,0 +1,91 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package jwtauthccl
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/pkg/errors"
+	"gopkg.in/square/go-jose.v2"
+)
+
+// jwtAudience is the audience tokens must have been issued for. JWT
+// authentication is refused while it is not set: without it, a token issued
+// for any other application of an accepted issuer would be accepted.
+var jwtAudience = settings.RegisterStringSetting(
+	"server.jwt_authentication.audience",
+	"the audience (aud claim) tokens must have been issued for; JWT authentication is refused while it is empty",
+	"",
+)
+
+// jwtJWKS holds the accepted issuers, and for each of them the keys its tokens
+// can be signed with.
+var jwtJWKS = settings.RegisterValidatedStringSetting(
+	"server.jwt_authentication.jwks",
+	"JSON object mapping each issuer (iss claim) whose tokens are accepted by JWT authentication "+
+		"to the JSON Web Key Set (RFC 7517) with its public keys",
+	"",
+	func(_ *settings.Values, s string) error {
+		_, err := parseIssuerKeys(s)
+		return err
+	},
+)
+
+// jwtClaim is the claim that holds the name of the SQL user.
+var jwtClaim = settings.RegisterStringSetting(
+	"server.jwt_authentication.claim",
+	"the token claim that holds the SQL user name",
+	"sub",
+)
+
+// jwtWebLoginEnabled controls whether tokens can be used to log into the
+// Admin UI. SQL connections are controlled by the jwt HBA method instead.
+var jwtWebLoginEnabled = settings.RegisterBoolSetting(
+	"server.jwt_authentication.web_login.enabled",
+	"if set, users can log into the Admin UI with a token",
+	false,
+)
+
+// parseIssuerKeys parses the value of server.jwt_authentication.jwks: a JSON
+// object mapping issuers to JSON Web Key Sets. An empty string accepts no
+// issuer.
+func parseIssuerKeys(s string) (map[string]*jose.JSONWebKeySet, error) {
+	issuerKeys := make(map[string]*jose.JSONWebKeySet)
+	if strings.TrimSpace(s) == "" {
+		return issuerKeys, nil
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(s), &raw); err != nil {
+		return nil, errors.Wrap(err,
+			"invalid issuer keys: expected a JSON object mapping issuers to JSON Web Key Sets")
+	}
+	for iss, rawJWKS := range raw {
+		if iss == "" {
+			return nil, errors.New("invalid issuer keys: empty issuer")
+		}
+		var jwks jose.JSONWebKeySet
+		if err := json.Unmarshal(rawJWKS, &jwks); err != nil {
+			return nil, errors.Wrapf(err, "invalid JSON Web Key Set for issuer %q", iss)
+		}
+		for _, key := range jwks.Keys {
+			if !key.Valid() {
+				return nil, errors.Errorf("invalid key %q for issuer %q", key.KeyID, iss)
+			}
+			// Symmetric keys would let anyone who can read the cluster settings
+			// sign tokens.
+			if !key.IsPublic() {
+				return nil, errors.Errorf("key %q for issuer %q is not a public key", key.KeyID, iss)
+			}
+		}
+		issuerKeys[iss] = &jwks
+	}
+	return issuerKeys, nil
+}
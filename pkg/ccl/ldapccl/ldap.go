@@ -0,0 +1,345 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package ldapccl
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/utilccl"
+	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/hba"
+	"github.com/pkg/errors"
+	"gopkg.in/ldap.v3"
+)
+
+const authTypeCleartextPassword int32 = 3
+
+// ldapTimeout bounds the time spent connecting to the LDAP server and waiting
+// for each of its responses. It is exposed for testing.
+var ldapTimeout = 10 * time.Second
+
+// ldapConfig is the configuration of an ldap HBA entry. The options have the
+// same names and meanings as in PostgreSQL, see
+// https://www.postgresql.org/docs/current/auth-ldap.html.
+//
+// In simple bind mode, the server is bound with the DN
+// ldapprefix + username + ldapsuffix. In search+bind mode, selected by
+// ldapbasedn, the server is first bound with ldapbinddn and ldapbindpasswd (or
+// anonymously) to search for the DN of the user below ldapbasedn, and then
+// bound with that DN.
+type ldapConfig struct {
+	server   string
+	port     string
+	scheme   string
+	startTLS bool
+
+	// Simple bind mode.
+	prefix string
+	suffix string
+
+	// Search+bind mode.
+	baseDN          string
+	bindDN          string
+	bindPassword    string
+	searchAttribute string
+	searchFilter    string
+
+	// groupAttribute is the attribute of the user's entry that lists the groups
+	// the user belongs to, usually memberOf. If set, the user is granted the
+	// roles that have the same name as its groups, prefixed by groupRolePrefix.
+	// The prefix is required so that directory groups can only grant and revoke
+	// the roles that were created for them.
+	groupAttribute  string
+	groupRolePrefix string
+}
+
+// parseConfig validates the options of an ldap HBA entry.
+func parseConfig(entry hba.Entry) (*ldapConfig, error) {
+	c := &ldapConfig{scheme: "ldap"}
+	seen := make(map[string]bool)
+	for _, op := range entry.Options {
+		if seen[op[0]] {
+			return nil, errors.Errorf("duplicate option %s", op[0])
+		}
+		seen[op[0]] = true
+		switch op[0] {
+		case "ldapserver":
+			c.server = op[1]
+		case "ldapport":
+			c.port = op[1]
+		case "ldapscheme":
+			if op[1] != "ldap" && op[1] != "ldaps" {
+				return nil, errors.Errorf("ldapscheme must be ldap or ldaps: %s", op[1])
+			}
+			c.scheme = op[1]
+		case "ldaptls":
+			if op[1] != "0" && op[1] != "1" {
+				return nil, errors.Errorf("ldaptls must be set to 0 or 1: %s", op[1])
+			}
+			c.startTLS = op[1] == "1"
+		case "ldapprefix":
+			c.prefix = op[1]
+		case "ldapsuffix":
+			c.suffix = op[1]
+		case "ldapbasedn":
+			c.baseDN = op[1]
+		case "ldapbinddn":
+			c.bindDN = op[1]
+		case "ldapbindpasswd":
+			c.bindPassword = op[1]
+		case "ldapsearchattribute":
+			c.searchAttribute = op[1]
+		case "ldapsearchfilter":
+			c.searchFilter = op[1]
+		case "ldapgroupattribute":
+			c.groupAttribute = op[1]
+		case "ldapgrouproleprefix":
+			c.groupRolePrefix = op[1]
+		default:
+			return nil, errors.Errorf("unsupported option %s", op[0])
+		}
+	}
+
+	if c.server == "" {
+		return nil, errors.New(`missing "ldapserver" option in LDAP entry`)
+	}
+	if c.port == "" {
+		c.port = "389"
+		if c.scheme == "ldaps" {
+			c.port = "636"
+		}
+	}
+	if c.startTLS && c.scheme == "ldaps" {
+		return nil, errors.New("ldaptls cannot be used with ldapscheme=ldaps")
+	}
+
+	if c.baseDN == "" {
+		if seen["ldapbinddn"] || seen["ldapbindpasswd"] || seen["ldapsearchattribute"] ||
+			seen["ldapsearchfilter"] {
+			return nil, errors.New("search options require ldapbasedn")
+		}
+		if c.groupAttribute != "" {
+			return nil, errors.New("ldapgroupattribute requires ldapbasedn")
+		}
+		return c, nil
+	}
+
+	if seen["ldapprefix"] || seen["ldapsuffix"] {
+		return nil, errors.New("ldapprefix and ldapsuffix cannot be used with ldapbasedn")
+	}
+	if c.searchAttribute != "" && c.searchFilter != "" {
+		return nil, errors.New("ldapsearchattribute cannot be used with ldapsearchfilter")
+	}
+	if c.searchFilter == "" {
+		attr := c.searchAttribute
+		if attr == "" {
+			attr = "uid"
+		}
+		c.searchFilter = "(" + attr + "=$username)"
+	}
+	if _, err := ldap.CompileFilter(c.filter("user")); err != nil {
+		return nil, errors.Wrap(err, "invalid ldapsearchfilter")
+	}
+	if c.groupRolePrefix != "" && c.groupAttribute == "" {
+		return nil, errors.New("ldapgrouproleprefix requires ldapgroupattribute")
+	}
+	if c.groupAttribute != "" && c.groupRolePrefix == "" {
+		return nil, errors.New("ldapgroupattribute requires ldapgrouproleprefix")
+	}
+	return c, nil
+}
+
+// filter returns the search filter for the given user.
+func (c *ldapConfig) filter(user string) string {
+	return strings.Replace(c.searchFilter, "$username", ldap.EscapeFilter(user), -1)
+}
+
+// dial connects to the LDAP server, upgrading the connection to TLS if
+// requested.
+func (c *ldapConfig) dial() (*ldap.Conn, error) {
+	addr := net.JoinHostPort(c.server, c.port)
+	tlsConfig := &tls.Config{ServerName: c.server}
+	conn, err := net.DialTimeout("tcp", addr, ldapTimeout)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not connect to LDAP server")
+	}
+	if c.scheme == "ldaps" {
+		conn = tls.Client(conn, tlsConfig)
+	}
+	l := ldap.NewConn(conn, c.scheme == "ldaps")
+	l.Start()
+	l.SetTimeout(ldapTimeout)
+	if c.startTLS {
+		if err := l.StartTLS(tlsConfig); err != nil {
+			l.Close()
+			return nil, errors.Wrap(err, "could not start TLS with LDAP server")
+		}
+	}
+	return l, nil
+}
+
+// authenticate checks the password of the user against the LDAP server. In
+// search+bind mode, it returns the groups the user belongs to if
+// groupAttribute is set.
+func (c *ldapConfig) authenticate(user, password string) (groups []string, err error) {
+	l, err := c.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer l.Close()
+
+	if c.baseDN == "" {
+		return nil, bindUser(l, c.prefix+user+c.suffix, user, password)
+	}
+
+	if c.bindDN != "" {
+		err = l.Bind(c.bindDN, c.bindPassword)
+	} else {
+		err = l.UnauthenticatedBind("")
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not perform initial LDAP bind for ldapbinddn %q", c.bindDN)
+	}
+	attrs := []string{"1.1"} // No attributes.
+	if c.groupAttribute != "" {
+		attrs = []string{c.groupAttribute}
+	}
+	res, err := l.Search(ldap.NewSearchRequest(
+		c.baseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+		0 /* sizeLimit */, int(ldapTimeout/time.Second), false, /* typesOnly */
+		c.filter(user), attrs, nil, /* controls */
+	))
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not search LDAP for filter %q", c.filter(user))
+	}
+	switch len(res.Entries) {
+	case 0:
+		return nil, errors.Errorf(security.ErrPasswordUserAuthFailed, user)
+	case 1:
+	default:
+		return nil, errors.Errorf("LDAP user %q is not unique, %d entries found", user, len(res.Entries))
+	}
+	if err := bindUser(l, res.Entries[0].DN, user, password); err != nil {
+		return nil, err
+	}
+
+	if c.groupAttribute != "" {
+		for _, v := range res.Entries[0].GetAttributeValues(c.groupAttribute) {
+			groups = append(groups, groupName(v))
+		}
+	}
+	return groups, nil
+}
+
+// bindUser binds the connection with the DN of the user, which verifies the
+// password.
+func bindUser(l *ldap.Conn, dn, user, password string) error {
+	if err := l.Bind(dn, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return errors.Errorf(security.ErrPasswordUserAuthFailed, user)
+		}
+		return errors.Wrapf(err, "LDAP login failed for user %q", user)
+	}
+	return nil
+}
+
+// groupName returns the name of an LDAP group: the value of the first
+// attribute of its DN (e.g. "admins" for "cn=admins,ou=groups,dc=example"), or
+// the value itself if it is not a DN.
+func groupName(v string) string {
+	dn, err := ldap.ParseDN(v)
+	if err != nil || len(dn.RDNs) == 0 || len(dn.RDNs[0].Attributes) == 0 {
+		return v
+	}
+	return dn.RDNs[0].Attributes[0].Value
+}
+
+// authLDAP performs LDAP authentication. The client sends its password in
+// cleartext, which is then verified by binding to the LDAP server. See:
+// https://github.com/postgres/postgres/blob/REL_11_STABLE/src/backend/libpq/auth.c#L2347
+//
+// The user must also exist in CockroachDB.
+func authLDAP(
+	c pgwire.AuthConn,
+	tlsState tls.ConnectionState,
+	insecure bool,
+	hashedPassword []byte,
+	execCfg *sql.ExecutorConfig,
+	entry *hba.Entry,
+) (security.UserAuthHook, error) {
+	conf, err := parseConfig(*entry)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.SendAuthRequest(authTypeCleartextPassword, nil /* data */); err != nil {
+		return nil, err
+	}
+	pwdData, err := c.GetPwdData()
+	if err != nil {
+		return nil, err
+	}
+	if len(pwdData) == 0 || bytes.IndexByte(pwdData, 0) != len(pwdData)-1 {
+		return nil, errors.New("expected 0-terminated byte array")
+	}
+	password := string(pwdData[:len(pwdData)-1])
+
+	return func(requestedUser string, clientConnection bool) error {
+		if len(requestedUser) == 0 {
+			return errors.New("user is missing")
+		}
+		if !clientConnection {
+			return errors.New("LDAP authentication is only available for client connections")
+		}
+		if requestedUser == security.RootUser {
+			return errors.Errorf("user %s must use certificate authentication instead of LDAP authentication", security.RootUser)
+		}
+		// An empty password would be an unauthenticated bind, which LDAP servers
+		// usually accept for any DN.
+		if password == "" {
+			return errors.Errorf(security.ErrPasswordUserAuthFailed, requestedUser)
+		}
+		groups, err := conf.authenticate(requestedUser, password)
+		if err != nil {
+			return err
+		}
+
+		// Do the license check after contacting the LDAP server so that
+		// administrators are able to test whether their LDAP configuration is
+		// correct.
+		if err := utilccl.CheckEnterpriseEnabled(
+			execCfg.Settings, execCfg.ClusterID(), execCfg.Organization(), "LDAP authentication",
+		); err != nil {
+			return err
+		}
+
+		if conf.groupAttribute != "" {
+			ctx := execCfg.AmbientCtx.AnnotateCtx(context.Background())
+			if err := syncRoles(ctx, execCfg, requestedUser, groups, conf.groupRolePrefix); err != nil {
+				return errors.Wrap(err, "could not map LDAP groups to roles")
+			}
+		}
+		return nil
+	}, nil
+}
+
+func checkEntry(entry hba.Entry) error {
+	_, err := parseConfig(entry)
+	return err
+}
+
+func init() {
+	pgwire.RegisterAuthMethod("ldap", authLDAP, checkEntry)
+}
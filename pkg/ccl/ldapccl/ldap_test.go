@@ -0,0 +1,414 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package ldapccl
+
+import (
+	"context"
+	gosql "database/sql"
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/hba"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	_ "github.com/lib/pq"
+	"gopkg.in/asn1-ber.v1"
+)
+
+// LDAP protocol operations handled by fakeLDAPServer (RFC 4511).
+const (
+	ldapBindRequest       ber.Tag = 0
+	ldapBindResponse      ber.Tag = 1
+	ldapUnbindRequest     ber.Tag = 2
+	ldapSearchRequest     ber.Tag = 3
+	ldapSearchResultEntry ber.Tag = 4
+	ldapSearchResultDone  ber.Tag = 5
+
+	ldapFilterAnd           ber.Tag = 0
+	ldapFilterEqualityMatch ber.Tag = 3
+
+	ldapResultSuccess                  = 0
+	ldapResultProtocolError            = 2
+	ldapResultInvalidCredentials       = 49
+	ldapResultInsufficientAccessRights = 50
+)
+
+type fakeLDAPEntry struct {
+	password string
+	attrs    map[string][]string
+}
+
+// fakeLDAPServer is an in-process LDAP server that supports just enough of the
+// protocol to test LDAP authentication: simple binds, and searches with
+// equality and conjunction filters. Searches are only allowed to connections
+// bound with a DN.
+type fakeLDAPServer struct {
+	ln      net.Listener
+	entries map[string]fakeLDAPEntry
+}
+
+func startFakeLDAPServer(t *testing.T, entries map[string]fakeLDAPEntry) *fakeLDAPServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeLDAPServer{ln: ln, entries: entries}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeLDAPServer) port() string {
+	return fmt.Sprint(s.ln.Addr().(*net.TCPAddr).Port)
+}
+
+func (s *fakeLDAPServer) close() {
+	_ = s.ln.Close()
+}
+
+func (s *fakeLDAPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	bound := false
+	for {
+		p, err := ber.ReadPacket(conn)
+		if err != nil || len(p.Children) < 2 {
+			return
+		}
+		msgID, _ := p.Children[0].Value.(int64)
+		op := p.Children[1]
+		var responses []*ber.Packet
+		switch op.Tag {
+		case ldapBindRequest:
+			dn, _ := op.Children[1].Value.(string)
+			password := op.Children[2].Data.String()
+			code := ldapResultInvalidCredentials
+			if e, ok := s.entries[dn]; (ok && e.password == password) || (dn == "" && password == "") {
+				code = ldapResultSuccess
+			}
+			bound = code == ldapResultSuccess && dn != ""
+			responses = append(responses, ldapResult(ldapBindResponse, code))
+		case ldapSearchRequest:
+			if !bound {
+				responses = append(responses, ldapResult(ldapSearchResultDone, ldapResultInsufficientAccessRights))
+				break
+			}
+			baseDN, _ := op.Children[0].Value.(string)
+			var attrs []string
+			for _, a := range op.Children[7].Children {
+				attrs = append(attrs, a.Value.(string))
+			}
+			for dn, e := range s.entries {
+				if strings.HasSuffix(dn, ","+baseDN) && matchFilter(op.Children[6], e) {
+					responses = append(responses, searchResultEntry(dn, e, attrs))
+				}
+			}
+			responses = append(responses, ldapResult(ldapSearchResultDone, ldapResultSuccess))
+		case ldapUnbindRequest:
+			return
+		default:
+			responses = append(responses, ldapResult(ldapBindResponse, ldapResultProtocolError))
+		}
+		for _, r := range responses {
+			msg := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+			msg.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, msgID, "MessageID"))
+			msg.AppendChild(r)
+			if _, err := conn.Write(msg.Bytes()); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func matchFilter(f *ber.Packet, e fakeLDAPEntry) bool {
+	switch f.Tag {
+	case ldapFilterAnd:
+		for _, c := range f.Children {
+			if !matchFilter(c, e) {
+				return false
+			}
+		}
+		return true
+	case ldapFilterEqualityMatch:
+		attr, _ := f.Children[0].Value.(string)
+		value, _ := f.Children[1].Value.(string)
+		for _, v := range e.attrs[attr] {
+			if strings.EqualFold(v, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func ldapResult(tag ber.Tag, code int) *ber.Packet {
+	p := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "Response")
+	p.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, code, "resultCode"))
+	p.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "matchedDN"))
+	p.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "diagnosticMessage"))
+	return p
+}
+
+func searchResultEntry(dn string, e fakeLDAPEntry, attrs []string) *ber.Packet {
+	p := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldapSearchResultEntry, nil, "Search Result Entry")
+	p.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, dn, "objectName"))
+	attrList := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "attributes")
+	for _, attr := range attrs {
+		values, ok := e.attrs[attr]
+		if !ok {
+			continue
+		}
+		a := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "attribute")
+		a.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, attr, "type"))
+		vals := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "vals")
+		for _, v := range values {
+			vals.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, v, "value"))
+		}
+		a.AppendChild(vals)
+		attrList.AppendChild(a)
+	}
+	p.AppendChild(attrList)
+	return p
+}
+
+var testLDAPEntries = map[string]fakeLDAPEntry{
+	"cn=service,dc=example,dc=com": {password: "service-pass"},
+	"uid=carl,ou=people,dc=example,dc=com": {
+		password: "carl-pass",
+		attrs: map[string][]string{
+			"uid":         {"carl"},
+			"objectClass": {"person"},
+			"memberOf": {
+				"cn=admins,ou=groups,dc=example,dc=com",
+				"cn=Readers,ou=groups,dc=example,dc=com",
+				"cn=unknown,ou=groups,dc=example,dc=com",
+				"cn=min,ou=groups,dc=example,dc=com",
+			},
+		},
+	},
+	"uid=dup,ou=people,dc=example,dc=com": {
+		password: "dup-pass",
+		attrs:    map[string][]string{"uid": {"dup"}},
+	},
+	"uid=dup,ou=others,dc=example,dc=com": {
+		password: "dup-pass",
+		attrs:    map[string][]string{"uid": {"dup"}},
+	},
+}
+
+func TestCheckEntry(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	testCases := []struct {
+		options string
+		err     string
+	}{
+		{`ldapserver=ldap.example.com ldapprefix="uid=" ldapsuffix=",dc=example,dc=com"`, ""},
+		{`ldapserver=ldap.example.com ldapscheme=ldaps ldapbasedn="dc=example,dc=com"`, ""},
+		{`ldapserver=ldap.example.com ldaptls=1 ldapbasedn="dc=example,dc=com" ` +
+			`ldapsearchfilter="(&(objectClass=person)(uid=$username))" ` +
+			`ldapgroupattribute=memberOf ldapgrouproleprefix=ldap_`, ""},
+		{``, `missing "ldapserver" option`},
+		{`ldapserver=a ldapserver=b`, "duplicate option ldapserver"},
+		{`ldapserver=a krb_realm=b`, "unsupported option krb_realm"},
+		{`ldapserver=a ldapscheme=http`, "ldapscheme must be ldap or ldaps"},
+		{`ldapserver=a ldaptls=yes`, "ldaptls must be set to 0 or 1"},
+		{`ldapserver=a ldapscheme=ldaps ldaptls=1`, "ldaptls cannot be used with ldapscheme=ldaps"},
+		{`ldapserver=a ldapbinddn="cn=admin"`, "search options require ldapbasedn"},
+		{`ldapserver=a ldapgroupattribute=memberOf`, "ldapgroupattribute requires ldapbasedn"},
+		{`ldapserver=a ldapbasedn="dc=com" ldapprefix="uid="`, "cannot be used with ldapbasedn"},
+		{`ldapserver=a ldapbasedn="dc=com" ldapsearchattribute=cn ldapsearchfilter="(cn=$username)"`,
+			"ldapsearchattribute cannot be used with ldapsearchfilter"},
+		{`ldapserver=a ldapbasedn="dc=com" ldapsearchfilter="(cn=$username"`, "invalid ldapsearchfilter"},
+		{`ldapserver=a ldapbasedn="dc=com" ldapgrouproleprefix=ldap_`,
+			"ldapgrouproleprefix requires ldapgroupattribute"},
+		{`ldapserver=a ldapbasedn="dc=com" ldapgroupattribute=memberOf`,
+			"ldapgroupattribute requires ldapgrouproleprefix"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.options, func(t *testing.T) {
+			conf, err := hba.Parse("host all all all ldap " + tc.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := checkEntry(conf.Entries[0]); !testutils.IsError(err, tc.err) {
+				t.Fatalf("expected error %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	s := startFakeLDAPServer(t, testLDAPEntries)
+	defer s.close()
+
+	simple := "ldapserver=127.0.0.1 ldapport=" + s.port() +
+		` ldapprefix="uid=" ldapsuffix=",ou=people,dc=example,dc=com"`
+	search := "ldapserver=127.0.0.1 ldapport=" + s.port() + ` ldapbasedn="dc=example,dc=com"` +
+		` ldapbinddn="cn=service,dc=example,dc=com" ldapbindpasswd=service-pass`
+
+	testCases := []struct {
+		options  string
+		user     string
+		password string
+		groups   []string
+		err      string
+	}{
+		{simple, "carl", "carl-pass", nil, ""},
+		{simple, "carl", "wrong", nil, "password authentication failed for user carl"},
+		{simple, "nobody", "carl-pass", nil, "password authentication failed for user nobody"},
+		{search, "carl", "carl-pass", nil, ""},
+		{search, "carl", "wrong", nil, "password authentication failed for user carl"},
+		{search, "nobody", "carl-pass", nil, "password authentication failed for user nobody"},
+		{search, "dup", "dup-pass", nil, `LDAP user "dup" is not unique`},
+		// The user name is escaped in the search filter.
+		{search, "*", "carl-pass", nil, "password authentication failed"},
+		{search + ` ldapsearchfilter="(&(objectClass=person)(uid=$username))"`,
+			"carl", "carl-pass", nil, ""},
+		{search + " ldapgroupattribute=memberOf ldapgrouproleprefix=ldap_", "carl", "carl-pass",
+			[]string{"admins", "Readers", "unknown", "min"}, ""},
+		{strings.Replace(search, "service-pass", "wrong", 1), "carl", "carl-pass", nil,
+			"could not perform initial LDAP bind"},
+		// Anonymous searches are rejected by the server.
+		{"ldapserver=127.0.0.1 ldapport=" + s.port() + ` ldapbasedn="dc=example,dc=com"`,
+			"carl", "carl-pass", nil, "could not search LDAP"},
+	}
+	for i, tc := range testCases {
+		conf, err := hba.Parse("host all all all ldap " + tc.options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := parseConfig(conf.Entries[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		groups, err := c.authenticate(tc.user, tc.password)
+		if !testutils.IsError(err, tc.err) {
+			t.Fatalf("%d: expected error %q, got %v", i, tc.err, err)
+		}
+		if !reflect.DeepEqual(groups, tc.groups) {
+			t.Fatalf("%d: expected groups %v, got %v", i, tc.groups, groups)
+		}
+	}
+}
+
+func TestLDAPAuthentication(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ldapServer := startFakeLDAPServer(t, testLDAPEntries)
+	defer ldapServer.close()
+
+	s, conn, _ := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(context.TODO())
+	db := sqlutils.MakeSQLRunner(conn)
+
+	db.Exec(t, `CREATE USER carl`)
+	db.Exec(t, `CREATE ROLE ldap_admins`)
+	db.Exec(t, `CREATE ROLE ldap_readers`)
+	db.Exec(t, `CREATE ROLE ldap_former`)
+	db.Exec(t, `CREATE ROLE other`)
+	db.Exec(t, `GRANT ldap_former, other TO carl`)
+	setPrefix := func(prefix string) {
+		conf := "host all carl all ldap ldapserver=127.0.0.1 ldapport=" + ldapServer.port() +
+			` ldapbasedn="dc=example,dc=com" ldapbinddn="cn=service,dc=example,dc=com"` +
+			" ldapbindpasswd=service-pass ldapgroupattribute=memberOf ldapgrouproleprefix=" + prefix
+		db.Exec(t, `SET CLUSTER SETTING server.host_based_authentication.configuration = $1`, conf)
+		// Wait for the new configuration to be used by the server.
+		db.CheckQueryResultsRetry(t,
+			`SHOW CLUSTER SETTING server.host_based_authentication.configuration`, [][]string{{conf}})
+	}
+	setPrefix("ldap_")
+
+	host, port, err := net.SplitHostPort(s.ServingAddr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	login := func(password string) error {
+		pgURL := url.URL{
+			Scheme:   "postgres",
+			User:     url.UserPassword("carl", password),
+			Host:     net.JoinHostPort(host, port),
+			RawQuery: "sslmode=require",
+		}
+		userDB, err := gosql.Open("postgres", pgURL.String())
+		if err != nil {
+			return err
+		}
+		defer userDB.Close()
+		_, err = userDB.Exec("SELECT 1")
+		return err
+	}
+
+	if err := login("wrong"); !testutils.IsError(err, "password authentication failed for user carl") {
+		t.Fatalf("expected password authentication failure, got %v", err)
+	}
+	if err := login("carl-pass"); err != nil {
+		t.Fatal(err)
+	}
+
+	checkRoles := func(expected ...string) {
+		t.Helper()
+		rows := db.QueryStr(t, `SELECT role FROM system.role_members WHERE member = 'carl'`)
+		var roles []string
+		for _, row := range rows {
+			roles = append(roles, row[0])
+		}
+		sort.Strings(roles)
+		if !reflect.DeepEqual(roles, expected) {
+			t.Fatalf("expected roles %v, got %v", expected, roles)
+		}
+	}
+
+	// The roles matching the groups of the user are granted, and the stale
+	// ldap_former role is revoked. Roles without the prefix are left alone.
+	checkRoles("ldap_admins", "ldap_readers", "other")
+
+	// The admin role is never granted, even if prefix + group names it.
+	setPrefix("ad")
+	if err := login("carl-pass"); err != nil {
+		t.Fatal(err)
+	}
+	checkRoles("ldap_admins", "ldap_readers", "other")
+
+	// Nor is it revoked, even though it starts with the prefix.
+	db.Exec(t, `GRANT admin TO carl`)
+	if err := login("carl-pass"); err != nil {
+		t.Fatal(err)
+	}
+	checkRoles("admin", "ldap_admins", "ldap_readers", "other")
+
+	// Root is always authenticated with its certificate.
+	rootURL, cleanup := sqlutils.PGUrl(t, s.ServingAddr(), t.Name(), url.User(security.RootUser))
+	defer cleanup()
+	rootDB, err := gosql.Open("postgres", rootURL.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rootDB.Close()
+	if _, err := rootDB.Exec("SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+}
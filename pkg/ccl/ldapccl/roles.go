@@ -0,0 +1,89 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package ldapccl
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+)
+
+// syncRoles makes the role memberships of a user reflect its LDAP groups. The
+// user is granted the existing roles named prefix + group; groups without a
+// matching role are ignored. The roles starting with prefix that the user is a
+// member of but that don't match a group anymore are revoked. Roles that don't
+// start with the prefix are never revoked, so that they can still be managed
+// with GRANT and REVOKE. The admin role is never granted or revoked, so that a
+// directory group can't make its members superusers.
+func syncRoles(
+	ctx context.Context, execCfg *sql.ExecutorConfig, user string, groups []string, prefix string,
+) error {
+	ie := execCfg.InternalExecutor
+	rows, err := ie.Query(
+		ctx, "ldap-get-roles", nil, /* txn */
+		`SELECT username FROM system.users WHERE "isRole" = true`,
+	)
+	if err != nil {
+		return err
+	}
+	roles := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		roles[string(tree.MustBeDString(row[0]))] = true
+	}
+
+	rows, err = ie.Query(
+		ctx, "ldap-get-memberships", nil, /* txn */
+		`SELECT "role" FROM system.role_members WHERE "member" = $1`, user,
+	)
+	if err != nil {
+		return err
+	}
+	memberOf := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		memberOf[string(tree.MustBeDString(row[0]))] = true
+	}
+
+	wanted := make(map[string]bool, len(groups))
+	for _, group := range groups {
+		if role := tree.Name(prefix + group).Normalize(); roles[role] && role != sqlbase.AdminRole {
+			wanted[role] = true
+		}
+	}
+
+	for role := range wanted {
+		if memberOf[role] {
+			continue
+		}
+		stmt := fmt.Sprintf("GRANT %s TO %s", tree.NameString(role), tree.NameString(user))
+		if _, err := ie.ExecWithUser(
+			ctx, "ldap-grant-role", nil /* txn */, security.RootUser, stmt,
+		); err != nil {
+			return err
+		}
+	}
+
+	prefix = tree.Name(prefix).Normalize()
+	for role := range memberOf {
+		if wanted[role] || role == sqlbase.AdminRole || !strings.HasPrefix(role, prefix) {
+			continue
+		}
+		stmt := fmt.Sprintf("REVOKE %s FROM %s", tree.NameString(role), tree.NameString(user))
+		if _, err := ie.ExecWithUser(
+			ctx, "ldap-revoke-role", nil /* txn */, security.RootUser, stmt,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
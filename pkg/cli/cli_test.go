@@ -2408,6 +2408,10 @@ writing ` + os.DevNull + `
   debug/nodes/1/ranges/18.json
   debug/nodes/1/ranges/19.json
   debug/nodes/1/ranges/20.json
+  debug/nodes/1/ranges/21.json
+  debug/nodes/1/ranges/22.json
+  debug/nodes/1/ranges/23.json
+  debug/nodes/1/ranges/24.json
   debug/schema/defaultdb@details.json
   debug/schema/postgres@details.json
   debug/schema/system@details.json
@@ -2418,9 +2422,13 @@ writing ` + os.DevNull + `
   debug/schema/system/lease.json
   debug/schema/system/locations.json
   debug/schema/system/namespace.json
+  debug/schema/system/notifications.json
   debug/schema/system/rangelog.json
   debug/schema/system/role_members.json
   debug/schema/system/settings.json
+  debug/schema/system/statement_diagnostics.json
+  debug/schema/system/statement_diagnostics_requests.json
+  debug/schema/system/statement_hints.json
   debug/schema/system/table_statistics.json
   debug/schema/system/ui.json
   debug/schema/system/users.json
@@ -588,6 +588,15 @@ memory that the store may consume, for example:
   --store=type=mem,size=20GiB
   --store=type=mem,size=90%
 
+</PRE>
+The "raft-path" field can be used to keep the Raft log of an on-disk store in a
+dedicated storage engine, for example on a separate device, so that log
+appends and truncations don't compete with the rest of the store. Once a store
+has been started with a "raft-path", it must always be started with it:
+<PRE>
+
+  --store=path=/mnt/hda1,raft-path=/mnt/ssd01/raft
+
 </PRE>
 Commas are forbidden in all values, since they are used to separate fields.
 Also, if you use equal signs in the file path to a store, you must use the
@@ -984,7 +984,9 @@ func parseGossipValues(gossipInfo *gossip.InfoStatus) (string, error) {
 				return "", errors.Wrapf(err, "failed to parse value for key %q", key)
 			}
 			output = append(output, fmt.Sprintf("%q: %+v", key, drainingInfo))
-		} else if strings.HasPrefix(key, gossip.KeyTableStatAddedPrefix) {
+		} else if strings.HasPrefix(key, gossip.KeyTableStatAddedPrefix) ||
+			key == gossip.KeyStatementHintsChanged ||
+			key == gossip.KeyStmtDiagnosticsRequestsChanged {
 			gossipedTime := timeutil.Unix(0, info.OrigStamp)
 			output = append(output, fmt.Sprintf("%q: %v", key, gossipedTime))
 		} else if strings.HasPrefix(key, gossip.KeyGossipClientsPrefix) {
@@ -501,6 +501,11 @@ func (z *ZoneConfig) InheritFromParent(parent *ZoneConfig) {
 			z.InheritedLeasePreferences = false
 		}
 	}
+	if z.GlobalReads == nil {
+		if parent.GlobalReads != nil {
+			z.GlobalReads = proto.Bool(*parent.GlobalReads)
+		}
+	}
 }
 
 // CopyFromZone copies over the specified fields from the other zone.
@@ -539,9 +544,21 @@ func (z *ZoneConfig) CopyFromZone(other ZoneConfig, fieldList []tree.Name) {
 			z.LeasePreferences = other.LeasePreferences
 			z.InheritedLeasePreferences = other.InheritedLeasePreferences
 		}
+		if fieldName == "global_reads" {
+			z.GlobalReads = nil
+			if other.GlobalReads != nil {
+				z.GlobalReads = proto.Bool(*other.GlobalReads)
+			}
+		}
 	}
 }
 
+// HasGlobalReads returns whether the ranges in the zone are configured to
+// serve consistent reads from all of their replicas. See GlobalReads.
+func (z *ZoneConfig) HasGlobalReads() bool {
+	return z.GlobalReads != nil && *z.GlobalReads
+}
+
 // StoreMatchesConstraint returns whether a store matches the given constraint.
 func StoreMatchesConstraint(store roachpb.StoreDescriptor, constraint Constraint) bool {
 	hasConstraint := storeHasConstraint(store, constraint)
@@ -16,6 +16,7 @@ import (
 	"fmt"
 	"math/rand"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/cockroachdb/cockroach/pkg/testutils"
@@ -735,6 +736,40 @@ func TestExperimentalLeasePreferencesYAML(t *testing.T) {
 	}
 }
 
+func TestZoneConfigGlobalReads(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	var zone ZoneConfig
+	if zone.HasGlobalReads() {
+		t.Fatal("expected global reads to be disabled by default")
+	}
+	if err := yaml.UnmarshalStrict([]byte("global_reads: true"), &zone); err != nil {
+		t.Fatal(err)
+	}
+	if !zone.HasGlobalReads() {
+		t.Fatal("expected global reads to be enabled")
+	}
+	out, err := yaml.Marshal(&zone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "global_reads: true") {
+		t.Fatalf("expected global_reads in marshaled zone config, got:\n%s", out)
+	}
+
+	// The field is inherited from the parent unless it is set explicitly.
+	var child ZoneConfig
+	child.InheritFromParent(&zone)
+	if !child.HasGlobalReads() {
+		t.Fatal("expected global reads to be inherited")
+	}
+	child = ZoneConfig{GlobalReads: proto.Bool(false)}
+	child.InheritFromParent(&zone)
+	if child.HasGlobalReads() {
+		t.Fatal("expected global reads to remain disabled")
+	}
+}
+
 func TestConstraintsListYAML(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
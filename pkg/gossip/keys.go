@@ -83,6 +83,19 @@ const (
 	// the keys are used to notify nodes to invalidate table statistic caches.
 	KeyTableStatAddedPrefix = "table-stat-added"
 
+	// KeyStatementHintsChanged is the key used to notify nodes that the
+	// statement hints in system.statement_hints have changed. The hints
+	// themselves are not stored in gossip; the key is used to notify nodes to
+	// reload their statement hints caches.
+	KeyStatementHintsChanged = "statement-hints-changed"
+
+	// KeyStmtDiagnosticsRequestsChanged is the key used to notify nodes that
+	// the statement diagnostics requests in
+	// system.statement_diagnostics_requests have changed. The requests
+	// themselves are not stored in gossip; the key is used to notify nodes to
+	// reload their pending requests.
+	KeyStmtDiagnosticsRequestsChanged = "stmt-diagnostics-requests-changed"
+
 	// KeyTableDisableMergesPrefix is the prefix for keys that indicate range
 	// merges for the specified table ID should be disabled. This is used by
 	// IMPORT and RESTORE to disable range merging while those operations are in
@@ -74,6 +74,9 @@ var (
 	// localStoreSuggestedCompactionSuffix stores suggested compactions to
 	// be aggregated and processed on the store.
 	localStoreSuggestedCompactionSuffix = []byte("comp")
+	// localStoreRaftEngineSuffix marks a store which keeps its Raft log in a
+	// dedicated engine. It is written once the log has been moved there.
+	localStoreRaftEngineSuffix = []byte("rfte")
 
 	// localRemovedLeakedRaftEntriesSuffix is DEPRECATED and remains to prevent reuse.
 	localRemovedLeakedRaftEntriesSuffix = []byte("dlre")
@@ -307,20 +310,24 @@ const (
 	// to "Ranges" instead of a Table - these IDs are needed to store custom
 	// configuration for non-table ranges (e.g. Zone Configs).
 	// NOTE: IDs must be <= MaxReservedDescID.
-	LeaseTableID           = 11
-	EventLogTableID        = 12
-	RangeEventTableID      = 13
-	UITableID              = 14
-	JobsTableID            = 15
-	MetaRangesID           = 16
-	SystemRangesID         = 17
-	TimeseriesRangesID     = 18
-	WebSessionsTableID     = 19
-	TableStatisticsTableID = 20
-	LocationsTableID       = 21
-	LivenessRangesID       = 22
-	RoleMembersTableID     = 23
-	CommentsTableID        = 24
+	LeaseTableID            = 11
+	EventLogTableID         = 12
+	RangeEventTableID       = 13
+	UITableID               = 14
+	JobsTableID             = 15
+	MetaRangesID            = 16
+	SystemRangesID          = 17
+	TimeseriesRangesID      = 18
+	WebSessionsTableID      = 19
+	TableStatisticsTableID  = 20
+	LocationsTableID        = 21
+	LivenessRangesID        = 22
+	RoleMembersTableID      = 23
+	CommentsTableID         = 24
+	StatementHintsTableID   = 25
+	NotificationsTableID    = 26
+	StmtDiagRequestsTableID = 27
+	StmtDiagTableID         = 28
 
 	// CommentType is type for system.comments
 	DatabaseCommentType = 0
@@ -78,6 +78,12 @@ func StoreHLCUpperBoundKey() roachpb.Key {
 	return MakeStoreKey(localHLCUpperBoundSuffix, nil)
 }
 
+// StoreRaftEngineKey returns the store-local key marking that the store keeps
+// its Raft log in a dedicated engine.
+func StoreRaftEngineKey() roachpb.Key {
+	return MakeStoreKey(localStoreRaftEngineSuffix, nil)
+}
+
 // StoreSuggestedCompactionKey returns a store-local key for a
 // suggested compaction. It combines the specified start and end keys.
 func StoreSuggestedCompactionKey(start, end roachpb.Key) roachpb.Key {
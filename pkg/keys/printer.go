@@ -177,6 +177,7 @@ var constSubKeyDict = []struct {
 	{"/storeIdent", localStoreIdentSuffix},
 	{"/gossipBootstrap", localStoreGossipSuffix},
 	{"/clusterVersion", localStoreClusterVersionSuffix},
+	{"/raftEngine", localStoreRaftEngineSuffix},
 	{"/suggestedCompaction", localStoreSuggestedCompactionSuffix},
 }
 
@@ -486,8 +486,10 @@ func (ds *DistSender) sendSingleRange(
 	// If this request needs to go to a lease holder and we know who that is, move
 	// it to the front.
 	var cachedLeaseHolder roachpb.ReplicaDescriptor
-	canSendToFollower := ds.clusterID != nil &&
-		CanSendToFollower(ds.clusterID.Get(), ds.st, ba)
+	canSendToFollower := (ds.clusterID != nil &&
+		CanSendToFollower(ds.clusterID.Get(), ds.st, ba)) ||
+		(ba.IsReadOnly() && ba.IsAllTransactional() &&
+			(ba.Txn == nil || !ba.Txn.IsWriting()) && ds.hasGlobalReads(desc))
 	if !canSendToFollower && ba.RequiresLeaseHolder() {
 		if storeID, ok := ds.leaseHolderCache.Lookup(ctx, desc.RangeID); ok {
 			if i := replicas.FindReplica(storeID); i >= 0 {
@@ -521,6 +523,22 @@ func (ds *DistSender) sendSingleRange(
 	return br, pErr
 }
 
+// hasGlobalReads returns whether the range is configured to serve consistent
+// present time reads from all of its replicas (see
+// config.ZoneConfig.GlobalReads), in which case reads may be routed to the
+// nearest replica.
+func (ds *DistSender) hasGlobalReads(desc *roachpb.RangeDescriptor) bool {
+	if ds.gossip == nil {
+		return false
+	}
+	cfg := ds.gossip.GetSystemConfig()
+	if cfg == nil {
+		return false
+	}
+	zone, err := cfg.GetZoneConfigForKey(desc.StartKey)
+	return err == nil && zone.HasGlobalReads()
+}
+
 // initAndVerifyBatch initializes timestamp-related information and
 // verifies batch constraints before splitting.
 func (ds *DistSender) initAndVerifyBatch(
@@ -740,6 +740,14 @@ func (tc *TxnCoordSender) Send(
 	// here, and unlock at the botton of the interceptor stack, in the
 	// txnLockGatekeeper. The we lock again in that interceptor when the response
 	// comes, and unlock again in the defer below.
+	//
+	// Committed transactions wait out their commit timestamp after the lock
+	// is released (it's deferred before the unlock), so that the heartbeat
+	// loop and other users of the TxnCoordSender aren't blocked meanwhile.
+	var commitWaitTS hlc.Timestamp
+	defer func(ctx context.Context) {
+		tc.maybeCommitWait(ctx, commitWaitTS)
+	}(ctx)
 	tc.mu.Lock()
 	defer tc.mu.Unlock()
 
@@ -748,7 +756,11 @@ func (tc *TxnCoordSender) Send(
 	}
 
 	if ba.IsSingleEndTransactionRequest() && !tc.interceptorAlloc.txnPipeliner.haveWrites() {
-		return nil, tc.commitReadOnlyTxnLocked(ctx, ba)
+		pErr := tc.commitReadOnlyTxnLocked(ctx, ba)
+		if pErr == nil {
+			commitWaitTS = tc.mu.txn.Timestamp
+		}
+		return nil, pErr
 	}
 
 	startNs := tc.clock.PhysicalNow()
@@ -813,6 +825,7 @@ func (tc *TxnCoordSender) Send(
 				tc.mu.txnState = txnFinalized
 				tc.cleanupTxnLocked(ctx)
 				tc.maybeSleepForLinearizable(ctx, br, startNs)
+				commitWaitTS = br.Txn.Timestamp
 			}
 		} else {
 			// Rollbacks always move us to txnFinalized.
@@ -861,6 +874,25 @@ func (tc *TxnCoordSender) maybeSleepForLinearizable(
 	}
 }
 
+// maybeCommitWait waits until the local clock exceeds the commit timestamp of
+// the transaction. Writes to ranges with global reads are performed at future
+// timestamps, so transactions performing (or observing) them commit in the
+// future. Such transactions mustn't be acknowledged before their commit
+// timestamp has passed, or causally dependent transactions may fail to
+// observe them. It must be called without tc.mu held.
+func (tc *TxnCoordSender) maybeCommitWait(ctx context.Context, commitTS hlc.Timestamp) {
+	wait := time.Duration(commitTS.WallTime - tc.clock.Now().WallTime)
+	if wait <= 0 {
+		return
+	}
+	log.VEventf(ctx, 2, "waiting %s on EndTransaction for commit timestamp %s",
+		duration.Truncate(wait, time.Millisecond), commitTS)
+	select {
+	case <-time.After(wait):
+	case <-ctx.Done():
+	}
+}
+
 // maybeRejectClientLocked checks whether the transaction is in a state that
 // prevents it from continuing, such as the heartbeat having detected the
 // transaction to have been aborted.
@@ -15,6 +15,7 @@ package security
 import (
 	"context"
 	"crypto/x509"
+	"crypto/x509/pkix"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -91,6 +92,7 @@ const (
 	// Filename extenstions.
 	certExtension = `.crt`
 	keyExtension  = `.key`
+	crlExtension  = `.crl`
 	// Certificate directory permissions.
 	defaultCertsDirPerm = 0700
 )
@@ -163,6 +165,10 @@ func isCertificateFile(filename string) bool {
 	return strings.HasSuffix(filename, certExtension)
 }
 
+func isCRLFile(filename string) bool {
+	return strings.HasSuffix(filename, crlExtension)
+}
+
 // CertInfoFromFilename takes a filename and attempts to determine the
 // certificate usage (ca, node, etc..).
 func CertInfoFromFilename(filename string) (*CertInfo, error) {
@@ -225,6 +231,7 @@ type CertificateLoader struct {
 	certsDir             string
 	skipPermissionChecks bool
 	certificates         []*CertInfo
+	crls                 []*pkix.CertificateList
 }
 
 // Certificates returns the loaded certificates.
@@ -232,6 +239,11 @@ func (cl *CertificateLoader) Certificates() []*CertInfo {
 	return cl.certificates
 }
 
+// CRLs returns the loaded certificate revocation lists.
+func (cl *CertificateLoader) CRLs() []*pkix.CertificateList {
+	return cl.crls
+}
+
 // NewCertificateLoader creates a new instance of the certificate loader.
 func NewCertificateLoader(certsDir string) *CertificateLoader {
 	return &CertificateLoader{
@@ -269,8 +281,8 @@ func (cl *CertificateLoader) TestDisablePermissionChecks() {
 }
 
 // Load examines all .crt files in the certs directory, determines their
-// usage, and looks for their keys.
-// It populates the certificates field.
+// usage, and looks for their keys. It also parses all .crl files.
+// It populates the certificates and crls fields.
 func (cl *CertificateLoader) Load() error {
 	fileInfos, err := assetLoaderImpl.ReadDir(cl.certsDir)
 	if err != nil {
@@ -301,6 +313,18 @@ func (cl *CertificateLoader) Load() error {
 			continue
 		}
 
+		if isCRLFile(filename) {
+			// Revocation lists are not matched to a CA here: their signatures are
+			// checked against the issuer of the certificates being verified.
+			crl, err := cl.loadCRL(fullPath)
+			if err != nil {
+				log.Warningf(context.Background(), "could not load revocation list %s: %v", fullPath, err)
+			} else {
+				cl.crls = append(cl.crls, crl)
+			}
+			continue
+		}
+
 		if !isCertificateFile(filename) {
 			if log.V(3) {
 				log.Infof(context.Background(), "skipping non-certificate file %s", filename)
@@ -341,6 +365,15 @@ func (cl *CertificateLoader) Load() error {
 	return nil
 }
 
+// loadCRL reads and parses a PEM or DER encoded certificate revocation list.
+func (cl *CertificateLoader) loadCRL(fullPath string) (*pkix.CertificateList, error) {
+	contents, err := assetLoaderImpl.ReadFile(fullPath)
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCRL(contents)
+}
+
 // findKey takes a CertInfo and looks for the corresponding key file.
 // If found, sets the 'keyFilename' and returns nil, returns error otherwise.
 // Does not load CA keys.
@@ -19,6 +19,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/cockroachdb/cockroach/pkg/settings"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/metric"
 	"github.com/cockroachdb/cockroach/pkg/util/stop"
@@ -86,12 +87,17 @@ var (
 // - client.<user>.crt  client certificate for 'user'. Verified using 'ca.crt', or 'ca-client.crt'.
 // - client.node.crt    client certificate for the 'node' user. If it does not exist,
 //                      fall back on 'node.crt'.
+// - *.crl              optional certificate revocation lists. Used to check node
+//                      and client certificates if enabled by the cluster settings.
 type CertificateManager struct {
 	// Certificate directory is not modified after initialization.
 	certsDir string
 	// The metrics struct is initialized at init time and metrics do their
 	// own locking.
 	certMetrics CertificateMetrics
+	// revocation checks the certificates of clients and other nodes. It does
+	// its own locking.
+	revocation *revocationChecker
 
 	// mu protects all remaining fields.
 	mu syncutil.RWMutex
@@ -128,6 +134,11 @@ type CertificateMetrics struct {
 	NodeExpiration       *metric.Gauge
 	NodeClientExpiration *metric.Gauge
 	UIExpiration         *metric.Gauge
+
+	RevocationChecks        *metric.Counter
+	RevocationRevoked       *metric.Counter
+	RevocationErrors        *metric.Counter
+	RevocationOCSPCacheHits *metric.Counter
 }
 
 func makeCertificateManager(certsDir string) *CertificateManager {
@@ -140,7 +151,13 @@ func makeCertificateManager(certsDir string) *CertificateManager {
 		NodeExpiration:       metric.NewGauge(metaNodeExpiration),
 		NodeClientExpiration: metric.NewGauge(metaNodeClientExpiration),
 		UIExpiration:         metric.NewGauge(metaUIExpiration),
+
+		RevocationChecks:        metric.NewCounter(metaRevocationChecks),
+		RevocationRevoked:       metric.NewCounter(metaRevocationRevoked),
+		RevocationErrors:        metric.NewCounter(metaRevocationErrors),
+		RevocationOCSPCacheHits: metric.NewCounter(metaRevocationOCSPCacheHits),
 	}
+	cm.revocation = makeRevocationChecker(&cm.certMetrics)
 	return cm
 }
 
@@ -168,6 +185,13 @@ func (cm *CertificateManager) Metrics() CertificateMetrics {
 	return cm.certMetrics
 }
 
+// SetSettings provides the cluster settings controlling the revocation checks
+// of the certificates presented by clients and other nodes. Certificates are
+// not checked until this is called.
+func (cm *CertificateManager) SetSettings(sv *settings.Values) {
+	cm.revocation.setSettings(sv)
+}
+
 // RegisterSignalHandler registers a signal handler for SIGHUP, triggering a
 // refresh of the certificates directory on notification.
 func (cm *CertificateManager) RegisterSignalHandler(stopper *stop.Stopper) {
@@ -396,6 +420,7 @@ func (cm *CertificateManager) LoadCertificates() error {
 	cm.clientCerts = clientCerts
 
 	cm.initialized = true
+	cm.revocation.setCRLs(cl.CRLs())
 
 	cm.serverConfig = nil
 	cm.uiServerConfig = nil
@@ -491,11 +516,18 @@ func (cm *CertificateManager) getEmbeddedServerTLSConfig(
 	if err != nil {
 		return nil, err
 	}
+	cm.checkRevocation(cfg)
 
 	cm.serverConfig = cfg
 	return cfg, nil
 }
 
+// checkRevocation makes a TLS config check the certificates of its peers for
+// revocation.
+func (cm *CertificateManager) checkRevocation(cfg *tls.Config) {
+	cfg.VerifyPeerCertificate = cm.revocation.verifyPeerCertificate
+}
+
 // GetUIServerTLSConfig returns a server TLS config for the Admin UI with a
 // callback to fetch the latest TLS config. We still attempt to get the config to make sure
 // the initial call has a valid config loaded.
@@ -653,6 +685,7 @@ func (cm *CertificateManager) GetClientTLSConfig(user string) (*tls.Config, erro
 		if err != nil {
 			return nil, err
 		}
+		cm.checkRevocation(cfg)
 
 		return cfg, nil
 	}
@@ -675,6 +708,7 @@ func (cm *CertificateManager) GetClientTLSConfig(user string) (*tls.Config, erro
 	if err != nil {
 		return nil, err
 	}
+	cm.checkRevocation(cfg)
 
 	// Cache the config.
 	cm.clientConfig = cfg
@@ -36,8 +36,11 @@ var ErrEmptyPassword = errors.New("empty passwords are not permitted")
 
 // CompareHashAndPassword tests that the provided bytes are equivalent to the
 // hash of the supplied password. If they are not equivalent, returns an
-// error.
+// error. Both bcrypt and SCRAM-SHA-256 hashes are supported.
 func CompareHashAndPassword(hashedPassword []byte, password string) error {
+	if IsScramHash(hashedPassword) {
+		return compareScramHashAndPassword(hashedPassword, password)
+	}
 	h := sha256.New()
 	// TODO(benesch): properly apply SHA-256 to the password. The current code
 	// erroneously appends the SHA-256 of the empty hash to the unhashed password
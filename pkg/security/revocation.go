@@ -0,0 +1,358 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package security
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/metric"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ocsp"
+)
+
+// Revocation checking modes.
+const (
+	revocationModeOff = iota
+	revocationModeLax
+	revocationModeStrict
+)
+
+// revocationMode controls whether the certificates presented by clients and
+// other nodes are checked for revocation. In lax mode, only certificates known
+// to be revoked are rejected; in strict mode, certificates whose status cannot
+// be determined are rejected as well.
+var revocationMode = settings.RegisterEnumSetting(
+	"security.certificate_revocation.mode",
+	"check node and client certificates against the CRLs in the certificates directory "+
+		"and their OCSP responders (off, lax: reject revoked certificates, "+
+		"strict: also reject certificates whose status is unknown)",
+	"off",
+	map[int64]string{
+		revocationModeOff:    "off",
+		revocationModeLax:    "lax",
+		revocationModeStrict: "strict",
+	},
+)
+
+// ocspTimeout bounds the time spent querying an OCSP responder during a TLS
+// handshake.
+var ocspTimeout = settings.RegisterDurationSetting(
+	"security.certificate_revocation.ocsp.timeout",
+	"timeout for requests to OCSP responders",
+	3*time.Second,
+)
+
+const (
+	// ocspDefaultCacheDuration is how long OCSP responses without a next update
+	// time are cached.
+	ocspDefaultCacheDuration = time.Hour
+	// ocspFailureCacheDuration is how long failures to obtain a definite
+	// response from the OCSP responders of a certificate are cached, so that an
+	// unavailable responder doesn't delay every handshake by its timeout.
+	ocspFailureCacheDuration = time.Minute
+	// ocspMaxCacheEntries bounds the size of the OCSP response cache.
+	ocspMaxCacheEntries = 10000
+	// ocspMaxResponseSize bounds the size of the responses read from OCSP
+	// responders.
+	ocspMaxResponseSize = 1 << 20
+)
+
+var (
+	metaRevocationChecks = metric.Metadata{
+		Name:        "security.certificate.revocation.checks",
+		Help:        "Number of certificates checked for revocation",
+		Measurement: "Certificates",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRevocationRevoked = metric.Metadata{
+		Name:        "security.certificate.revocation.revoked",
+		Help:        "Number of certificates rejected because they were revoked",
+		Measurement: "Certificates",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRevocationErrors = metric.Metadata{
+		Name:        "security.certificate.revocation.errors",
+		Help:        "Number of certificates whose revocation status could not be determined",
+		Measurement: "Certificates",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRevocationOCSPCacheHits = metric.Metadata{
+		Name:        "security.certificate.revocation.ocsp_cache_hits",
+		Help:        "Number of revocation checks answered by a cached OCSP response or failure",
+		Measurement: "Certificates",
+		Unit:        metric.Unit_COUNT,
+	}
+)
+
+// ocspCacheEntry is a cached OCSP response, or the error encountered while
+// querying the OCSP responders.
+type ocspCacheEntry struct {
+	revoked bool
+	err     error
+	expires time.Time
+}
+
+// revocationChecker checks whether the certificates presented during TLS
+// handshakes have been revoked. A certificate is checked against the CRLs
+// signed by its issuer and, if it names any, against its OCSP responders.
+type revocationChecker struct {
+	metrics    *CertificateMetrics
+	httpClient http.Client
+	logEvery   log.EveryN
+
+	mu struct {
+		syncutil.Mutex
+		// sv holds the cluster settings. Revocation checks are disabled until
+		// they are set.
+		sv *settings.Values
+		// crls are the revocation lists loaded from the certificates directory.
+		crls []*pkix.CertificateList
+		// ocspCache maps ocspCacheKey() to the last response obtained for a
+		// certificate.
+		ocspCache map[string]ocspCacheEntry
+	}
+}
+
+func makeRevocationChecker(metrics *CertificateMetrics) *revocationChecker {
+	rc := &revocationChecker{
+		metrics:  metrics,
+		logEvery: log.Every(time.Minute),
+	}
+	rc.mu.ocspCache = make(map[string]ocspCacheEntry)
+	return rc
+}
+
+func (rc *revocationChecker) setSettings(sv *settings.Values) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.mu.sv = sv
+}
+
+func (rc *revocationChecker) setCRLs(crls []*pkix.CertificateList) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.mu.crls = crls
+}
+
+func (rc *revocationChecker) settings() *settings.Values {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.mu.sv
+}
+
+func (rc *revocationChecker) crls() []*pkix.CertificateList {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.mu.crls
+}
+
+// verifyPeerCertificate is the tls.Config.VerifyPeerCertificate callback. It is
+// called after the chains have been verified, and checks every certificate in
+// them except the trusted roots.
+func (rc *revocationChecker) verifyPeerCertificate(
+	_ [][]byte, verifiedChains [][]*x509.Certificate,
+) error {
+	sv := rc.settings()
+	if sv == nil {
+		return nil
+	}
+	mode := revocationMode.Get(sv)
+	if mode == revocationModeOff {
+		return nil
+	}
+
+	ctx := context.Background()
+	for _, chain := range verifiedChains {
+		for i := 0; i+1 < len(chain); i++ {
+			cert, issuer := chain[i], chain[i+1]
+			rc.metrics.RevocationChecks.Inc(1)
+			revoked, err := rc.checkCert(ctx, sv, cert, issuer, timeutil.Now())
+			if revoked {
+				rc.metrics.RevocationRevoked.Inc(1)
+				return errors.Errorf("certificate %q with serial number %s has been revoked",
+					cert.Subject, cert.SerialNumber)
+			}
+			if err != nil {
+				rc.metrics.RevocationErrors.Inc(1)
+				if mode == revocationModeStrict {
+					return errors.Wrapf(err, "could not check revocation status of certificate %q", cert.Subject)
+				}
+				if rc.logEvery.ShouldLog() {
+					log.Warningf(ctx, "could not check revocation status of certificate %q: %v", cert.Subject, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// checkCert returns whether cert has been revoked by issuer. An error is
+// returned if its status could not be determined.
+func (rc *revocationChecker) checkCert(
+	ctx context.Context, sv *settings.Values, cert, issuer *x509.Certificate, now time.Time,
+) (bool, error) {
+	var crlErr error
+	coveredByCRL := false
+	for _, crl := range rc.crls() {
+		if issuer.CheckCRLSignature(crl) != nil {
+			// Not issued by this CA.
+			continue
+		}
+		if crl.HasExpired(now) {
+			crlErr = errors.Errorf("revocation list for %q has expired", issuer.Subject)
+			continue
+		}
+		for _, revoked := range crl.TBSCertList.RevokedCertificates {
+			if revoked.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+				return true, nil
+			}
+		}
+		coveredByCRL = true
+	}
+
+	if len(cert.OCSPServer) > 0 {
+		revoked, err := rc.checkOCSP(ctx, sv, cert, issuer, now)
+		if err != nil && coveredByCRL {
+			// The revocation list vouches for the certificate.
+			return false, nil
+		}
+		return revoked, err
+	}
+	if coveredByCRL {
+		return false, nil
+	}
+	if crlErr != nil {
+		return false, crlErr
+	}
+	return false, errors.New("no revocation list or OCSP responder available")
+}
+
+// ocspCacheKey identifies a certificate by its issuer and serial number.
+func ocspCacheKey(cert, issuer *x509.Certificate) string {
+	return fmt.Sprintf("%x/%s", sha256.Sum256(issuer.Raw), cert.SerialNumber)
+}
+
+// checkOCSP queries the OCSP responders of cert in turn until one knows
+// whether it has been revoked. Responses are cached until their next update
+// time; if no responder knows the status of cert, the error is cached for
+// ocspFailureCacheDuration.
+func (rc *revocationChecker) checkOCSP(
+	ctx context.Context, sv *settings.Values, cert, issuer *x509.Certificate, now time.Time,
+) (bool, error) {
+	key := ocspCacheKey(cert, issuer)
+	rc.mu.Lock()
+	entry, ok := rc.mu.ocspCache[key]
+	rc.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		rc.metrics.RevocationOCSPCacheHits.Inc(1)
+		return entry.revoked, entry.err
+	}
+
+	req, err := ocsp.CreateRequest(cert, issuer, nil /* opts */)
+	if err != nil {
+		return false, err
+	}
+	var lastErr error
+	for _, server := range cert.OCSPServer {
+		resp, err := rc.queryOCSP(ctx, sv, server, req, cert, issuer, now)
+		if err != nil {
+			lastErr = errors.Wrapf(err, "OCSP responder %s", server)
+			continue
+		}
+		if resp.Status == ocsp.Unknown {
+			lastErr = errors.Errorf("OCSP responder %s does not know the certificate", server)
+			continue
+		}
+		entry := ocspCacheEntry{
+			revoked: resp.Status == ocsp.Revoked,
+			expires: resp.NextUpdate,
+		}
+		if entry.expires.IsZero() {
+			entry.expires = now.Add(ocspDefaultCacheDuration)
+		}
+		rc.cacheOCSP(key, entry, now)
+		return entry.revoked, nil
+	}
+	rc.cacheOCSP(key, ocspCacheEntry{err: lastErr, expires: now.Add(ocspFailureCacheDuration)}, now)
+	return false, lastErr
+}
+
+// queryOCSP sends an OCSP request to a responder and returns its verified
+// response.
+func (rc *revocationChecker) queryOCSP(
+	ctx context.Context,
+	sv *settings.Values,
+	server string,
+	req []byte,
+	cert, issuer *x509.Certificate,
+	now time.Time,
+) (*ocsp.Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, ocspTimeout.Get(sv))
+	defer cancel()
+	httpReq, err := http.NewRequest("POST", server, bytes.NewReader(req))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/ocsp-request")
+	httpResp, err := rc.httpClient.Do(httpReq.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected status %s", httpResp.Status)
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(httpResp.Body, ocspMaxResponseSize))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := ocsp.ParseResponseForCert(body, cert, issuer)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.NextUpdate.IsZero() && now.After(resp.NextUpdate) {
+		return nil, errors.Errorf("stale response, next update was at %s", resp.NextUpdate)
+	}
+	return resp, nil
+}
+
+// cacheOCSP adds a response to the cache, evicting the expired responses if it
+// is full.
+func (rc *revocationChecker) cacheOCSP(key string, entry ocspCacheEntry, now time.Time) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if len(rc.mu.ocspCache) >= ocspMaxCacheEntries {
+		for k, e := range rc.mu.ocspCache {
+			if !now.Before(e.expires) {
+				delete(rc.mu.ocspCache, k)
+			}
+		}
+		if len(rc.mu.ocspCache) >= ocspMaxCacheEntries {
+			rc.mu.ocspCache = make(map[string]ocspCacheEntry)
+		}
+	}
+	rc.mu.ocspCache[key] = entry
+}
@@ -0,0 +1,355 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package security_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"golang.org/x/crypto/ocsp"
+)
+
+// testCA issues certificates into a certificates directory.
+type testCA struct {
+	t       *testing.T
+	dir     string
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	serials map[string]*big.Int
+}
+
+func newTestCA(t *testing.T, dir string) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca := &testCA{t: t, dir: dir, key: key, serials: make(map[string]*big.Int)}
+	template := ca.template("Test CA")
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ca.cert, err = x509.ParseCertificate(der); err != nil {
+		t.Fatal(err)
+	}
+	ca.write(security.CACertFilename(), &pem.Block{Type: "CERTIFICATE", Bytes: der})
+	return ca
+}
+
+func (ca *testCA) template(commonName string) *x509.Certificate {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		ca.t.Fatal(err)
+	}
+	now := timeutil.Now()
+	return &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(time.Hour),
+	}
+}
+
+func (ca *testCA) write(filename string, blocks ...*pem.Block) {
+	if err := security.WritePEMToFile(
+		filepath.Join(ca.dir, filename), 0600, true /* overwrite */, blocks...,
+	); err != nil {
+		ca.t.Fatal(err)
+	}
+}
+
+// issue writes the certificate and key of a user, with an optional OCSP
+// responder. The node certificate is used for both server and client
+// authentication.
+func (ca *testCA) issue(user string, ocspServer string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		ca.t.Fatal(err)
+	}
+	template := ca.template(user)
+	template.KeyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	filename := security.ClientCertFilename(user)
+	if user == security.NodeUser {
+		template.ExtKeyUsage = append(template.ExtKeyUsage, x509.ExtKeyUsageServerAuth)
+		template.DNSNames = []string{"localhost"}
+		filename = "node.crt"
+	}
+	if ocspServer != "" {
+		template.OCSPServer = []string{ocspServer}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		ca.t.Fatal(err)
+	}
+	keyPEM, err := security.PrivateKeyToPEM(key)
+	if err != nil {
+		ca.t.Fatal(err)
+	}
+	ca.write(filename, &pem.Block{Type: "CERTIFICATE", Bytes: der})
+	ca.write(filename[:len(filename)-len(".crt")]+".key", keyPEM)
+	ca.serials[user] = template.SerialNumber
+}
+
+// revoke writes a revocation list with the certificates of the users.
+func (ca *testCA) revoke(users ...string) {
+	now := timeutil.Now()
+	var revoked []pkix.RevokedCertificate
+	for _, user := range users {
+		revoked = append(revoked, pkix.RevokedCertificate{
+			SerialNumber: ca.serials[user], RevocationTime: now,
+		})
+	}
+	der, err := ca.cert.CreateCRL(rand.Reader, ca.key, revoked, now, now.Add(time.Hour))
+	if err != nil {
+		ca.t.Fatal(err)
+	}
+	ca.write("ca.crl", &pem.Block{Type: "X509 CRL", Bytes: der})
+}
+
+// handshake connects a client with the certificate of user to a server with
+// the node certificate.
+func handshake(cm *security.CertificateManager, user string) error {
+	serverCfg, err := cm.GetServerTLSConfig()
+	if err != nil {
+		return err
+	}
+	clientCfg, err := cm.GetClientTLSConfig(user)
+	if err != nil {
+		return err
+	}
+	clientCfg = clientCfg.Clone()
+	clientCfg.ServerName = "localhost"
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+	defer ln.Close()
+	errCh := make(chan error, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			errCh <- err
+			return
+		}
+		defer conn.Close()
+		errCh <- tls.Server(conn, serverCfg).Handshake()
+	}()
+
+	conn, err := tls.Dial("tcp", ln.Addr().String(), clientCfg)
+	if err == nil {
+		conn.Close()
+	}
+	if serverErr := <-errCh; serverErr != nil {
+		return serverErr
+	}
+	return err
+}
+
+func TestRevocation(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	// Do not mock cert access for this test.
+	security.ResetAssetLoader()
+	defer ResetTest()
+	certsDir, err := ioutil.TempDir("", "revocation_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.RemoveAll(certsDir); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	ca := newTestCA(t, certsDir)
+
+	// The OCSP responder knows the certificates issued by the CA, and fails
+	// under /down.
+	var ocspRequests int64
+	var mu syncutil.Mutex
+	ocspRevoked := make(map[string]bool)
+	responder := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&ocspRequests, 1)
+		if r.URL.Path == "/down" {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		req, err := ocsp.ParseRequest(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		now := timeutil.Now()
+		status := ocsp.Unknown
+		mu.Lock()
+		for user, serial := range ca.serials {
+			if serial.Cmp(req.SerialNumber) == 0 {
+				status = ocsp.Good
+				if ocspRevoked[user] {
+					status = ocsp.Revoked
+				}
+			}
+		}
+		mu.Unlock()
+		resp, err := ocsp.CreateResponse(ca.cert, ca.cert, ocsp.Response{
+			Status:       status,
+			SerialNumber: req.SerialNumber,
+			ThisUpdate:   now.Add(-time.Minute),
+			NextUpdate:   now.Add(time.Hour),
+			RevokedAt:    now.Add(-time.Minute),
+		}, ca.key)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/ocsp-response")
+		_, _ = w.Write(resp)
+	}))
+	defer responder.Close()
+
+	mu.Lock()
+	ca.issue(security.NodeUser, responder.URL)
+	ca.issue("good", responder.URL)
+	ca.issue("revoked", responder.URL)
+	ca.issue("unknown", responder.URL+"/down")
+	ca.issue("nourl", "")
+	ocspRevoked["revoked"] = true
+	mu.Unlock()
+
+	cm, err := security.NewCertificateManager(certsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := cluster.MakeTestingClusterSettings()
+	cm.SetSettings(&st.SV)
+	// setMode takes the encoded value of the mode: 1 for lax, 2 for strict.
+	setMode := func(mode string) {
+		if err := st.MakeUpdater().Set(
+			"security.certificate_revocation.mode", mode, "e",
+		); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	type testCase struct {
+		user string
+		err  string
+	}
+	run := func(t *testing.T, testCases []testCase) {
+		for _, tc := range testCases {
+			if err := handshake(cm, tc.user); !testutils.IsError(err, tc.err) {
+				t.Errorf("%s: expected error %q, got %v", tc.user, tc.err, err)
+			}
+		}
+	}
+
+	t.Run("off", func(t *testing.T) {
+		run(t, []testCase{{"good", ""}, {"revoked", ""}, {"unknown", ""}, {"nourl", ""}})
+		if n := cm.Metrics().RevocationChecks.Count(); n != 0 {
+			t.Fatalf("expected no revocation checks, got %d", n)
+		}
+	})
+
+	t.Run("lax", func(t *testing.T) {
+		setMode("1" /* lax */)
+		run(t, []testCase{
+			{"good", ""},
+			{"revoked", `certificate "CN=revoked" with serial number .* has been revoked`},
+			{"unknown", ""},
+			{"nourl", ""},
+		})
+		if n := cm.Metrics().RevocationRevoked.Count(); n != 1 {
+			t.Fatalf("expected 1 revoked certificate, got %d", n)
+		}
+		if n := cm.Metrics().RevocationErrors.Count(); n != 2 {
+			t.Fatalf("expected 2 revocation check errors, got %d", n)
+		}
+	})
+
+	t.Run("cache", func(t *testing.T) {
+		before := atomic.LoadInt64(&ocspRequests)
+		hits := cm.Metrics().RevocationOCSPCacheHits.Count()
+		run(t, []testCase{{"good", ""}})
+		if n := atomic.LoadInt64(&ocspRequests); n != before {
+			t.Fatalf("expected cached OCSP responses, got %d new requests", n-before)
+		}
+		if n := cm.Metrics().RevocationOCSPCacheHits.Count(); n != hits+2 {
+			t.Fatalf("expected 2 cache hits, got %d", n-hits)
+		}
+
+		// Failures to reach the OCSP responder are cached as well.
+		errs := cm.Metrics().RevocationErrors.Count()
+		run(t, []testCase{{"unknown", ""}})
+		if n := atomic.LoadInt64(&ocspRequests); n != before {
+			t.Fatalf("expected cached OCSP failures, got %d new requests", n-before)
+		}
+		if n := cm.Metrics().RevocationErrors.Count(); n != errs+1 {
+			t.Fatalf("expected 1 revocation check error, got %d", n-errs)
+		}
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		setMode("2" /* strict */)
+		run(t, []testCase{
+			{"good", ""},
+			{"revoked", "has been revoked"},
+			{"unknown", `could not check revocation status of certificate "CN=unknown": ` +
+				`OCSP responder .*/down: unexpected status 503`},
+			{"nourl", "no revocation list or OCSP responder available"},
+		})
+	})
+
+	t.Run("crl", func(t *testing.T) {
+		ca.revoke("nourl")
+		if err := cm.LoadCertificates(); err != nil {
+			t.Fatal(err)
+		}
+		// The certificates covered by the revocation list are accepted even if
+		// their OCSP responder is unavailable.
+		run(t, []testCase{
+			{"good", ""},
+			{"unknown", ""},
+			{"nourl", `certificate "CN=nourl" with serial number .* has been revoked`},
+		})
+	})
+}
@@ -0,0 +1,148 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package security
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/bidi"
+	"golang.org/x/text/unicode/norm"
+)
+
+// saslPrepMappedToNothing is table B.1 of RFC 3454.
+var saslPrepMappedToNothing = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x00ad, Hi: 0x00ad, Stride: 1},
+		{Lo: 0x034f, Hi: 0x034f, Stride: 1},
+		{Lo: 0x1806, Hi: 0x1806, Stride: 1},
+		{Lo: 0x180b, Hi: 0x180d, Stride: 1},
+		{Lo: 0x200b, Hi: 0x200d, Stride: 1},
+		{Lo: 0x2060, Hi: 0x2060, Stride: 1},
+		{Lo: 0xfe00, Hi: 0xfe0f, Stride: 1},
+		{Lo: 0xfeff, Hi: 0xfeff, Stride: 1},
+	},
+}
+
+// saslPrepNonASCIISpace is table C.1.2 of RFC 3454.
+var saslPrepNonASCIISpace = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x00a0, Hi: 0x00a0, Stride: 1},
+		{Lo: 0x1680, Hi: 0x1680, Stride: 1},
+		{Lo: 0x2000, Hi: 0x200b, Stride: 1},
+		{Lo: 0x202f, Hi: 0x202f, Stride: 1},
+		{Lo: 0x205f, Hi: 0x205f, Stride: 1},
+		{Lo: 0x3000, Hi: 0x3000, Stride: 1},
+	},
+}
+
+// saslPrepProhibited is the union of tables C.1.2, C.2.1, C.2.2, C.3, C.5,
+// C.6, C.7, C.8 and C.9 of RFC 3454. The non-characters of table C.4 are
+// checked separately.
+var saslPrepProhibited = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x0000, Hi: 0x001f, Stride: 1},
+		{Lo: 0x007f, Hi: 0x00a0, Stride: 1},
+		{Lo: 0x0340, Hi: 0x0341, Stride: 1},
+		{Lo: 0x06dd, Hi: 0x06dd, Stride: 1},
+		{Lo: 0x070f, Hi: 0x070f, Stride: 1},
+		{Lo: 0x1680, Hi: 0x1680, Stride: 1},
+		{Lo: 0x180e, Hi: 0x180e, Stride: 1},
+		{Lo: 0x2000, Hi: 0x200f, Stride: 1},
+		{Lo: 0x2028, Hi: 0x202f, Stride: 1},
+		{Lo: 0x205f, Hi: 0x2063, Stride: 1},
+		{Lo: 0x206a, Hi: 0x206f, Stride: 1},
+		{Lo: 0x2ff0, Hi: 0x2ffb, Stride: 1},
+		{Lo: 0x3000, Hi: 0x3000, Stride: 1},
+		{Lo: 0xd800, Hi: 0xf8ff, Stride: 1},
+		{Lo: 0xfeff, Hi: 0xfeff, Stride: 1},
+		{Lo: 0xfff9, Hi: 0xfffd, Stride: 1},
+	},
+	R32: []unicode.Range32{
+		{Lo: 0x1d173, Hi: 0x1d17a, Stride: 1},
+		{Lo: 0xe0001, Hi: 0xe0001, Stride: 1},
+		{Lo: 0xe0020, Hi: 0xe007f, Stride: 1},
+		{Lo: 0xf0000, Hi: 0xffffd, Stride: 1},
+		{Lo: 0x100000, Hi: 0x10fffd, Stride: 1},
+	},
+}
+
+// saslPrepProhibitedRune returns true iff the rune must not appear in the
+// output of SASLprep.
+func saslPrepProhibitedRune(r rune) bool {
+	// Table C.4 contains the non-characters U+FDD0..U+FDEF and the last two
+	// code points of every plane.
+	return unicode.Is(saslPrepProhibited, r) ||
+		(r >= 0xfdd0 && r <= 0xfdef) || r&0xfffe == 0xfffe
+}
+
+// saslPrep normalizes a password with the SASLprep profile of stringprep
+// (RFC 4013, RFC 3454), as SCRAM clients do before deriving their proof.
+//
+// Like PostgreSQL and libpq, the password is used as is if it is not valid
+// UTF-8 or if it contains characters that SASLprep prohibits, so that such
+// passwords can still be used. Unassigned code points are not checked for,
+// since their set depends on the Unicode version. ASCII passwords are left
+// unchanged.
+func saslPrep(password string) string {
+	ascii := true
+	for i := 0; i < len(password); i++ {
+		if password[i] >= utf8.RuneSelf {
+			ascii = false
+			break
+		}
+	}
+	if ascii || !utf8.ValidString(password) {
+		return password
+	}
+
+	// Map.
+	var b strings.Builder
+	for _, r := range password {
+		switch {
+		case unicode.Is(saslPrepMappedToNothing, r):
+		case unicode.Is(saslPrepNonASCIISpace, r):
+			b.WriteByte(' ')
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	// Normalize.
+	prepared := norm.NFKC.String(b.String())
+
+	// Prohibit, and check bidirectional strings (section 6 of RFC 3454): a
+	// string with right-to-left characters must not contain left-to-right
+	// characters, and must start and end with right-to-left characters.
+	var hasRandAL, hasL, firstRandAL, lastRandAL bool
+	first := true
+	for _, r := range prepared {
+		if saslPrepProhibitedRune(r) {
+			return password
+		}
+		props, _ := bidi.LookupRune(r)
+		isRandAL := props.Class() == bidi.R || props.Class() == bidi.AL
+		hasRandAL = hasRandAL || isRandAL
+		hasL = hasL || props.Class() == bidi.L
+		if first {
+			firstRandAL = isRandAL
+			first = false
+		}
+		lastRandAL = isRandAL
+	}
+	if hasRandAL && (hasL || !firstRandAL || !lastRandAL) {
+		return password
+	}
+	return prepared
+}
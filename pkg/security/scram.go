@@ -0,0 +1,172 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package security
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// ScramIterations is the number of iterations of the SCRAM-SHA-256 key
+// derivation function used when hashing passwords. It is exposed for testing.
+//
+// The default is the same as the one used by PostgreSQL, which is also the
+// minimum recommended by RFC 7677.
+var ScramIterations = 4096
+
+// scramSaltLength is the length of the random salt of SCRAM-SHA-256 hashes.
+const scramSaltLength = 16
+
+// scramHashPrefix is the prefix of SCRAM-SHA-256 password hashes. It
+// distinguishes them from bcrypt hashes, which start with "$2".
+const scramHashPrefix = "SCRAM-SHA-256$"
+
+// ScramCredentials holds the information about a password that is needed to
+// verify a SCRAM-SHA-256 authentication exchange (RFC 5802, RFC 7677). The
+// password itself cannot be recovered from it.
+type ScramCredentials struct {
+	Iterations int
+	Salt       []byte
+	StoredKey  []byte
+	ServerKey  []byte
+}
+
+// HashPasswordScram takes a raw password and returns a SCRAM-SHA-256 hashed
+// password. The hash uses the same textual format as PostgreSQL:
+//
+//   SCRAM-SHA-256$<iterations>:<salt>$<StoredKey>:<ServerKey>
+//
+// where the salt and the keys are base64-encoded.
+func HashPasswordScram(password string) ([]byte, error) {
+	salt := make([]byte, scramSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return makeScramCredentials(password, salt, ScramIterations).encode(), nil
+}
+
+// IsScramHash returns true iff the hashed password was produced by
+// HashPasswordScram.
+func IsScramHash(hashedPassword []byte) bool {
+	return bytes.HasPrefix(hashedPassword, []byte(scramHashPrefix))
+}
+
+// ParseScramHash extracts the SCRAM-SHA-256 credentials from a hashed password
+// produced by HashPasswordScram.
+func ParseScramHash(hashedPassword []byte) (ScramCredentials, error) {
+	if !IsScramHash(hashedPassword) {
+		return ScramCredentials{}, errors.New("password hash is not a SCRAM-SHA-256 hash")
+	}
+	parts := strings.Split(string(hashedPassword[len(scramHashPrefix):]), "$")
+	if len(parts) != 2 {
+		return ScramCredentials{}, errors.New("malformed SCRAM-SHA-256 hash")
+	}
+	iterSalt := strings.Split(parts[0], ":")
+	keys := strings.Split(parts[1], ":")
+	if len(iterSalt) != 2 || len(keys) != 2 {
+		return ScramCredentials{}, errors.New("malformed SCRAM-SHA-256 hash")
+	}
+	var c ScramCredentials
+	var err error
+	if c.Iterations, err = strconv.Atoi(iterSalt[0]); err != nil || c.Iterations <= 0 {
+		return ScramCredentials{}, errors.New("malformed SCRAM-SHA-256 hash iteration count")
+	}
+	for _, f := range []struct {
+		dst *[]byte
+		src string
+	}{{&c.Salt, iterSalt[1]}, {&c.StoredKey, keys[0]}, {&c.ServerKey, keys[1]}} {
+		if *f.dst, err = base64.StdEncoding.DecodeString(f.src); err != nil {
+			return ScramCredentials{}, errors.Wrap(err, "malformed SCRAM-SHA-256 hash")
+		}
+	}
+	return c, nil
+}
+
+// makeScramCredentials derives the SCRAM-SHA-256 credentials of a password,
+// after normalizing it with SASLprep like clients do.
+func makeScramCredentials(password string, salt []byte, iterations int) ScramCredentials {
+	saltedPassword := pbkdf2.Key([]byte(saslPrep(password)), salt, iterations, sha256.Size, sha256.New)
+	clientKey := scramHMAC(saltedPassword, []byte("Client Key"))
+	storedKey := sha256.Sum256(clientKey)
+	return ScramCredentials{
+		Iterations: iterations,
+		Salt:       salt,
+		StoredKey:  storedKey[:],
+		ServerKey:  scramHMAC(saltedPassword, []byte("Server Key")),
+	}
+}
+
+// encode returns the textual form of the credentials, as documented on
+// HashPasswordScram.
+func (c ScramCredentials) encode() []byte {
+	return []byte(fmt.Sprintf("%s%d:%s$%s:%s", scramHashPrefix, c.Iterations,
+		base64.StdEncoding.EncodeToString(c.Salt),
+		base64.StdEncoding.EncodeToString(c.StoredKey),
+		base64.StdEncoding.EncodeToString(c.ServerKey)))
+}
+
+// VerifyClientProof returns true iff the proof sent by the client in the final
+// message of a SCRAM exchange matches the credentials, given the exchange's
+// AuthMessage (the concatenation of the first client and server messages and
+// of the final client message without its proof).
+func (c ScramCredentials) VerifyClientProof(authMessage, clientProof []byte) bool {
+	if len(clientProof) != len(c.StoredKey) {
+		return false
+	}
+	// ClientKey = ClientProof XOR HMAC(StoredKey, AuthMessage), and the client
+	// knows the password iff H(ClientKey) = StoredKey.
+	clientSignature := scramHMAC(c.StoredKey, authMessage)
+	clientKey := make([]byte, len(clientProof))
+	for i := range clientProof {
+		clientKey[i] = clientProof[i] ^ clientSignature[i]
+	}
+	storedKey := sha256.Sum256(clientKey)
+	return subtle.ConstantTimeCompare(storedKey[:], c.StoredKey) == 1
+}
+
+// ServerSignature returns the signature that proves to the client, in the final
+// server message of a SCRAM exchange, that the server knows the credentials.
+func (c ScramCredentials) ServerSignature(authMessage []byte) []byte {
+	return scramHMAC(c.ServerKey, authMessage)
+}
+
+// compareScramHashAndPassword tests that the provided password matches the
+// SCRAM-SHA-256 hashed password. If it does not, returns an error.
+func compareScramHashAndPassword(hashedPassword []byte, password string) error {
+	c, err := ParseScramHash(hashedPassword)
+	if err != nil {
+		return err
+	}
+	expected := makeScramCredentials(password, c.Salt, c.Iterations)
+	if subtle.ConstantTimeCompare(expected.StoredKey, c.StoredKey) != 1 ||
+		subtle.ConstantTimeCompare(expected.ServerKey, c.ServerKey) != 1 {
+		return errors.New("password does not match hash")
+	}
+	return nil
+}
+
+func scramHMAC(key, msg []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	_, _ = h.Write(msg)
+	return h.Sum(nil)
+}
@@ -0,0 +1,132 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package security_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestHashPasswordScram(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	hashed, err := security.HashPasswordScram("pencil")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !security.IsScramHash(hashed) {
+		t.Fatalf("expected SCRAM hash, got %q", hashed)
+	}
+	if err := security.CompareHashAndPassword(hashed, "pencil"); err != nil {
+		t.Fatalf("expected password to match: %v", err)
+	}
+	if err := security.CompareHashAndPassword(hashed, "pen"); err == nil {
+		t.Fatal("expected mismatched password to fail")
+	}
+
+	// bcrypt hashes are still accepted.
+	bcryptHashed, err := security.HashPassword("pencil")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if security.IsScramHash(bcryptHashed) {
+		t.Fatalf("expected bcrypt hash, got %q", bcryptHashed)
+	}
+	if err := security.CompareHashAndPassword(bcryptHashed, "pencil"); err != nil {
+		t.Fatalf("expected password to match: %v", err)
+	}
+}
+
+// TestScramExchange verifies the credentials against the SCRAM-SHA-256 test
+// vector of RFC 7677.
+func TestScramExchange(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	const hashed = "SCRAM-SHA-256$4096:W22ZaJ0SNY7soEsUEjb6gQ==$" +
+		"WG5d8oPm3OtcPnkdi4Uo7BkeZkBFzpcXkuLmtbsT4qY=:wfPLwcE6nTWhTAmQ7tl2KeoiWGPlZqQxSrmfPwDl2dU="
+	if err := security.CompareHashAndPassword([]byte(hashed), "pencil"); err != nil {
+		t.Fatalf("expected password to match: %v", err)
+	}
+	c, err := security.ParseScramHash([]byte(hashed))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const authMessage = "n=user,r=rOprNGfwEbeRWgbNEkqO," +
+		"r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096," +
+		"c=biws,r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0"
+	proof, err := base64.StdEncoding.DecodeString("dHzbZapWIk4jUhN+Ute9ytag9zjfMHgsqmmiz7AndVQ=")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.VerifyClientProof([]byte(authMessage), proof) {
+		t.Fatal("expected client proof to be valid")
+	}
+	proof[0] ^= 1
+	if c.VerifyClientProof([]byte(authMessage), proof) {
+		t.Fatal("expected corrupted client proof to be invalid")
+	}
+
+	expectedSig, err := base64.StdEncoding.DecodeString("6rriTRBi23WpRR/wtup+mMhUZUn/dB5nLTJRsjl95G4=")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig := c.ServerSignature([]byte(authMessage)); !bytes.Equal(sig, expectedSig) {
+		t.Fatalf("expected server signature %x, got %x", expectedSig, sig)
+	}
+}
+
+// TestScramSASLprep verifies that passwords are normalized with SASLprep,
+// using the examples of section 3 of RFC 4013.
+func TestScramSASLprep(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	testCases := []struct {
+		password string
+		// equivalent is a password that must match the hash of password.
+		equivalent string
+		// different is a password that must not match the hash of password.
+		different string
+	}{
+		// Characters mapped to nothing are removed.
+		{password: "I\u00adX", equivalent: "IX", different: "I X"},
+		// Case is preserved.
+		{password: "user", equivalent: "user", different: "USER"},
+		// Compatibility characters are normalized with NFKC.
+		{password: "\u00aa", equivalent: "a", different: "A"},
+		{password: "\u2168", equivalent: "IX", different: "I"},
+		// Non-ASCII spaces are mapped to spaces.
+		{password: "a\u00a0b", equivalent: "a b", different: "ab"},
+		// Prohibited characters and invalid bidirectional strings make SASLprep
+		// fail, in which case the password is used as is.
+		{password: "a\u0007", equivalent: "a\u0007", different: "a"},
+		{password: "\u0627\u0031", equivalent: "\u0627\u0031", different: "\u0627"},
+		{password: "\xff\u00aa", equivalent: "\xff\u00aa", different: "\xffa"},
+	}
+	for _, tc := range testCases {
+		hashed, err := security.HashPasswordScram(tc.password)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := security.CompareHashAndPassword(hashed, tc.equivalent); err != nil {
+			t.Errorf("%q: expected %q to match: %v", tc.password, tc.equivalent, err)
+		}
+		if err := security.CompareHashAndPassword(hashed, tc.different); err == nil {
+			t.Errorf("%q: expected %q not to match", tc.password, tc.different)
+		}
+	}
+}
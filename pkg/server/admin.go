@@ -17,6 +17,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
 	"sort"
 	"strconv"
@@ -42,6 +43,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/storage/storagepb"
 	"github.com/cockroachdb/cockroach/pkg/util/contextutil"
 	"github.com/cockroachdb/cockroach/pkg/util/envutil"
+	"github.com/cockroachdb/cockroach/pkg/util/httputil"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/mon"
 	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
@@ -2114,3 +2116,40 @@ func (s *adminServer) dialNode(
 	}
 	return serverpb.NewAdminClient(conn), nil
 }
+
+// handleStmtBundle serves the statement diagnostics bundle whose ID follows
+// sql.StmtBundleURLPrefix in the request path, as a zip file. Bundles contain
+// statements and their data, so they can only be downloaded by admin users.
+func (s *adminServer) handleStmtBundle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	// Without a web session (in insecure mode, or when web sessions are not
+	// required), the caller is root, as in userFromContext.
+	username := security.RootUser
+	if u, ok := ctx.Value(webSessionUserKey{}).(string); ok {
+		username = u
+	}
+	if !s.server.status.isSuperUser(ctx, username) {
+		http.Error(w, "only admin users can download statement bundles", http.StatusForbidden)
+		return
+	}
+	id, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, sql.StmtBundleURLPrefix), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid statement bundle ID", http.StatusBadRequest)
+		return
+	}
+	bundle, err := s.server.execCfg.StmtDiagnosticsRegistry.GetBundle(ctx, id)
+	if err != nil {
+		log.Warning(ctx, err)
+		http.Error(w, "failed to read statement bundle", http.StatusInternalServerError)
+		return
+	}
+	if bundle == nil {
+		http.Error(w, fmt.Sprintf("statement bundle %d not found", id), http.StatusNotFound)
+		return
+	}
+	w.Header().Set(httputil.ContentTypeHeader, "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=stmt-bundle-%d.zip", id))
+	if _, err := w.Write(bundle); err != nil {
+		log.Warning(r.Context(), err)
+	}
+}
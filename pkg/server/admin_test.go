@@ -1719,3 +1719,58 @@ func TestStatsforSpanOnLocalMax(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// TestAdminStmtBundle verifies that statement diagnostics bundles are
+// served from system.statement_diagnostics, to admin users only.
+func TestAdminStmtBundle(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	s, db, _ := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(context.TODO())
+	ts := s.(*TestServer)
+	sqlDB := sqlutils.MakeSQLRunner(db)
+
+	sqlDB.Exec(t, `EXPLAIN ANALYZE (DEBUG) SELECT 1`)
+	var id int64
+	sqlDB.QueryRow(t, `SELECT id FROM crdb_internal.statement_diagnostics`).Scan(&id)
+
+	normalClient, err := ts.GetHTTPClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	authClient, err := ts.GetAuthenticatedHTTPClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func(client http.Client, id int64, expected int) []byte {
+		t.Helper()
+		resp, err := client.Get(fmt.Sprintf("%s%s%d", ts.AdminURL(), sql.StmtBundleURLPrefix, id))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != expected {
+			t.Fatalf("expected status code %d, got %d: %s", expected, resp.StatusCode, body)
+		}
+		return body
+	}
+
+	// Without a session, the request is rejected.
+	get(normalClient, id, http.StatusUnauthorized)
+	// The authenticated user isn't an admin.
+	get(authClient, id, http.StatusForbidden)
+
+	sqlDB.Exec(t, fmt.Sprintf(`CREATE USER %s`, authenticatedUserName))
+	sqlDB.Exec(t, fmt.Sprintf(`GRANT admin TO %s`, authenticatedUserName))
+	var expected []byte
+	sqlDB.QueryRow(t,
+		`SELECT bundle FROM system.statement_diagnostics WHERE id = $1`, id,
+	).Scan(&expected)
+	if body := get(authClient, id, http.StatusOK); !bytes.Equal(body, expected) {
+		t.Fatalf("expected the stored bundle (%d bytes), got %d bytes", len(expected), len(body))
+	}
+	get(authClient, id+1, http.StatusNotFound)
+}
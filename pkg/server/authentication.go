@@ -55,6 +55,11 @@ var webSessionTimeout = settings.RegisterNonNegativeDurationSetting(
 	7*24*time.Hour,
 )
 
+// VerifyLoginToken, if set, verifies the token of a login request and returns
+// the user it authenticates. It is set by CCL code that implements token
+// authentication.
+var VerifyLoginToken func(ctx context.Context, execCfg *sql.ExecutorConfig, token string) (string, error)
+
 type authenticationServer struct {
 	server     *Server
 	memMetrics *sql.MemoryMetrics
@@ -88,39 +93,46 @@ func (s *authenticationServer) RegisterGateway(
 
 // UserLogin verifies an incoming request by a user to create an web
 // authentication session. It checks the provided credentials against the
-// system.users table, and if successful creates a new authentication session.
-// The session's ID and secret are returned to the caller as an HTTP cookie,
-// added via a "Set-Cookie" header.
+// system.users table, or verifies the provided token, and if successful creates
+// a new authentication session. The session's ID and secret are returned to the
+// caller as an HTTP cookie, added via a "Set-Cookie" header.
 func (s *authenticationServer) UserLogin(
 	ctx context.Context, req *serverpb.UserLoginRequest,
 ) (*serverpb.UserLoginResponse, error) {
 	username := req.Username
-	if username == "" {
-		return nil, status.Errorf(
-			codes.Unauthenticated,
-			"no username was provided",
-		)
-	}
-
-	// Root user does not have a password, simply disallow this.
-	if username == security.RootUser {
-		return nil, status.Errorf(
-			codes.Unauthenticated,
-			"user %s must use certificate authentication instead of password authentication",
-			security.RootUser,
-		)
-	}
-
-	// Verify the provided username/password pair.
-	verified, err := s.verifyPassword(ctx, username, req.Password)
-	if err != nil {
-		return nil, apiInternalError(ctx, err)
-	}
-	if !verified {
-		return nil, status.Errorf(
-			codes.Unauthenticated,
-			"the provided username and password did not match any credentials on the server",
-		)
+	if req.Token != "" {
+		var err error
+		if username, err = s.verifyToken(ctx, req.Username, req.Token); err != nil {
+			return nil, err
+		}
+	} else {
+		if username == "" {
+			return nil, status.Errorf(
+				codes.Unauthenticated,
+				"no username was provided",
+			)
+		}
+
+		// Root user does not have a password, simply disallow this.
+		if username == security.RootUser {
+			return nil, status.Errorf(
+				codes.Unauthenticated,
+				"user %s must use certificate authentication instead of password authentication",
+				security.RootUser,
+			)
+		}
+
+		// Verify the provided username/password pair.
+		verified, err := s.verifyPassword(ctx, username, req.Password)
+		if err != nil {
+			return nil, apiInternalError(ctx, err)
+		}
+		if !verified {
+			return nil, status.Errorf(
+				codes.Unauthenticated,
+				"the provided username and password did not match any credentials on the server",
+			)
+		}
 	}
 
 	// Create a new database session, generating an ID and secret key.
@@ -270,7 +282,55 @@ func (s *authenticationServer) verifyPassword(
 	if !exists {
 		return false, nil
 	}
-	return (security.CompareHashAndPassword(hashedPassword, password) == nil), nil
+	if security.CompareHashAndPassword(hashedPassword, password) != nil {
+		return false, nil
+	}
+	sql.MaybeUpgradeUserPasswordHash(ctx, s.server.execCfg, username, password, hashedPassword)
+	return true, nil
+}
+
+// verifyToken verifies a token provided to log in, and returns the user it
+// authenticates. If a username was also provided, it must match the token. The
+// returned errors are meant to be returned to the client.
+func (s *authenticationServer) verifyToken(
+	ctx context.Context, username string, token string,
+) (string, error) {
+	if VerifyLoginToken == nil {
+		return "", status.Errorf(
+			codes.Unauthenticated,
+			"token authentication is not supported",
+		)
+	}
+	tokenUser, err := VerifyLoginToken(ctx, s.server.execCfg, token)
+	if err != nil {
+		return "", status.Errorf(codes.Unauthenticated, "token authentication failed: %v", err)
+	}
+	if username != "" && username != tokenUser {
+		return "", status.Errorf(
+			codes.Unauthenticated,
+			"the provided token is for user %s, not %s", tokenUser, username,
+		)
+	}
+	if tokenUser == security.RootUser {
+		return "", status.Errorf(
+			codes.Unauthenticated,
+			"user %s must use certificate authentication instead of token authentication",
+			security.RootUser,
+		)
+	}
+	exists, _, err := sql.GetUserHashedPassword(
+		ctx, s.server.execCfg.InternalExecutor, s.memMetrics, tokenUser,
+	)
+	if err != nil {
+		return "", apiInternalError(ctx, err)
+	}
+	if !exists {
+		return "", status.Errorf(
+			codes.Unauthenticated,
+			"the provided token did not match any user on the server",
+		)
+	}
+	return tokenUser, nil
 }
 
 // newAuthSession attempts to create a new authentication session for the given
@@ -227,6 +227,10 @@ type Config struct {
 	// Environment Variable: COCKROACH_SCAN_MAX_IDLE_TIME
 	ScanMaxIdleTime time.Duration
 
+	// UseGoEngine, if set, backs in-memory stores with the pure-Go
+	// engine.GoInMem instead of RocksDB. For testing only.
+	UseGoEngine bool
+
 	// TestingKnobs is used for internal test controls only.
 	TestingKnobs base.TestingKnobs
 
@@ -418,13 +422,28 @@ func (e *Engines) Close() {
 	*e = nil
 }
 
-// CreateEngines creates Engines based on the specs in cfg.Stores.
-func (cfg *Config) CreateEngines(ctx context.Context) (Engines, error) {
+// RaftEngines maps the engines of stores keeping their Raft log in a dedicated
+// engine to that engine. Like Engines, it allows convenient closing.
+type RaftEngines map[engine.Engine]engine.Engine
+
+// Close closes all the RaftEngines.
+func (e *RaftEngines) Close() {
+	for _, eng := range *e {
+		eng.Close()
+	}
+	*e = nil
+}
+
+// CreateEngines creates Engines based on the specs in cfg.Stores, along with
+// the dedicated Raft engines of the stores whose spec has a raft-path.
+func (cfg *Config) CreateEngines(ctx context.Context) (Engines, RaftEngines, error) {
 	engines := Engines(nil)
 	defer engines.Close()
+	raftEngines := RaftEngines(nil)
+	defer raftEngines.Close()
 
 	if cfg.enginesCreated {
-		return Engines{}, errors.Errorf("engines already created")
+		return Engines{}, nil, errors.Errorf("engines already created")
 	}
 	cfg.enginesCreated = true
 
@@ -438,11 +457,14 @@ func (cfg *Config) CreateEngines(ctx context.Context) (Engines, error) {
 	for _, spec := range cfg.Stores.Specs {
 		if !spec.InMemory {
 			physicalStores++
+			if spec.RaftPath != "" {
+				physicalStores++
+			}
 		}
 	}
 	openFileLimitPerStore, err := setOpenFileLimit(physicalStores)
 	if err != nil {
-		return Engines{}, err
+		return Engines{}, nil, err
 	}
 
 	log.Event(ctx, "initializing engines")
@@ -456,27 +478,33 @@ func (cfg *Config) CreateEngines(ctx context.Context) (Engines, error) {
 			if spec.Size.Percent > 0 {
 				sysMem, err := status.GetTotalMemory(ctx)
 				if err != nil {
-					return Engines{}, errors.Errorf("could not retrieve system memory")
+					return Engines{}, nil, errors.Errorf("could not retrieve system memory")
 				}
 				sizeInBytes = int64(float64(sysMem) * spec.Size.Percent / 100)
 			}
 			if sizeInBytes != 0 && !skipSizeCheck && sizeInBytes < base.MinimumStoreSize {
-				return Engines{}, errors.Errorf("%f%% of memory is only %s bytes, which is below the minimum requirement of %s",
+				return Engines{}, nil, errors.Errorf("%f%% of memory is only %s bytes, which is below the minimum requirement of %s",
 					spec.Size.Percent, humanizeutil.IBytes(sizeInBytes), humanizeutil.IBytes(base.MinimumStoreSize))
 			}
-			details = append(details, fmt.Sprintf("store %d: in-memory, size %s",
-				i, humanizeutil.IBytes(sizeInBytes)))
-			engines = append(engines, engine.NewInMem(spec.Attributes, sizeInBytes))
+			if cfg.UseGoEngine {
+				details = append(details, fmt.Sprintf("store %d: in-memory (go), size %s",
+					i, humanizeutil.IBytes(sizeInBytes)))
+				engines = append(engines, engine.NewGoInMem(spec.Attributes, sizeInBytes))
+			} else {
+				details = append(details, fmt.Sprintf("store %d: in-memory, size %s",
+					i, humanizeutil.IBytes(sizeInBytes)))
+				engines = append(engines, engine.NewInMem(spec.Attributes, sizeInBytes))
+			}
 		} else {
 			if spec.Size.Percent > 0 {
 				fileSystemUsage := gosigar.FileSystemUsage{}
 				if err := fileSystemUsage.Get(spec.Path); err != nil {
-					return Engines{}, err
+					return Engines{}, nil, err
 				}
 				sizeInBytes = int64(float64(fileSystemUsage.Total) * spec.Size.Percent / 100)
 			}
 			if sizeInBytes != 0 && !skipSizeCheck && sizeInBytes < base.MinimumStoreSize {
-				return Engines{}, errors.Errorf("%f%% of %s's total free space is only %s bytes, which is below the minimum requirement of %s",
+				return Engines{}, nil, errors.Errorf("%f%% of %s's total free space is only %s bytes, which is below the minimum requirement of %s",
 					spec.Size.Percent, spec.Path, humanizeutil.IBytes(sizeInBytes), humanizeutil.IBytes(base.MinimumStoreSize))
 			}
 
@@ -496,9 +524,24 @@ func (cfg *Config) CreateEngines(ctx context.Context) (Engines, error) {
 
 			eng, err := engine.NewRocksDB(rocksDBConfig, cache)
 			if err != nil {
-				return Engines{}, err
+				return Engines{}, nil, err
 			}
 			engines = append(engines, eng)
+
+			if spec.RaftPath != "" {
+				details = append(details, fmt.Sprintf("store %d: raft log in %s", i, spec.RaftPath))
+				raftConfig := rocksDBConfig
+				raftConfig.Dir = spec.RaftPath
+				raftConfig.MaxSizeBytes = 0
+				raftEng, err := engine.NewRocksDB(raftConfig, cache)
+				if err != nil {
+					return Engines{}, nil, err
+				}
+				if raftEngines == nil {
+					raftEngines = RaftEngines{}
+				}
+				raftEngines[eng] = raftEng
+			}
 		}
 	}
 
@@ -507,9 +550,9 @@ func (cfg *Config) CreateEngines(ctx context.Context) (Engines, error) {
 	for _, s := range details {
 		log.Info(ctx, s)
 	}
-	enginesCopy := engines
-	engines = nil
-	return enginesCopy, nil
+	enginesCopy, raftEnginesCopy := engines, raftEngines
+	engines, raftEngines = nil, nil
+	return enginesCopy, raftEnginesCopy, nil
 }
 
 // InitNode parses node attributes and initializes the gossip bootstrap
@@ -157,6 +157,10 @@ type Node struct {
 	initialBoot bool // True if this is the first time this node has started.
 	txnMetrics  kv.TxnMetrics
 
+	// raftEngines maps the engines of stores keeping their Raft log in a
+	// dedicated engine to that engine.
+	raftEngines map[engine.Engine]engine.Engine
+
 	perReplicaServer storage.Server
 }
 
@@ -327,6 +331,13 @@ func (n *Node) onClusterVersionChange(cv cluster.ClusterVersion) {
 	}
 }
 
+// storeConfig returns the configuration of the store using the given engine.
+func (n *Node) storeConfig(eng engine.Engine) storage.StoreConfig {
+	cfg := n.storeCfg
+	cfg.RaftEngine = n.raftEngines[eng]
+	return cfg
+}
+
 // start starts the node by registering the storage instance for the
 // RPC service "Node" and initializing stores for each specified
 // engine. Launches periodic store gossiping in a goroutine.
@@ -407,7 +418,7 @@ func (n *Node) start(
 
 	// Create stores from the engines that were already bootstrapped.
 	for _, e := range initializedEngines {
-		s := storage.NewStore(ctx, n.storeCfg, e, &n.Descriptor)
+		s := storage.NewStore(ctx, n.storeConfig(e), e, &n.Descriptor)
 		if err := s.Start(ctx, n.stopper); err != nil {
 			return errors.Errorf("failed to start store: %s", err)
 		}
@@ -602,7 +613,7 @@ func (n *Node) bootstrapStores(
 				return err
 			}
 
-			s := storage.NewStore(ctx, n.storeCfg, eng, &n.Descriptor)
+			s := storage.NewStore(ctx, n.storeConfig(eng), eng, &n.Descriptor)
 			if err := s.Start(ctx, stopper); err != nil {
 				return err
 			}
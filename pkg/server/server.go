@@ -64,6 +64,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/ts"
 	"github.com/cockroachdb/cockroach/pkg/ui"
 	"github.com/cockroachdb/cockroach/pkg/util"
+	"github.com/cockroachdb/cockroach/pkg/util/admission"
 	"github.com/cockroachdb/cockroach/pkg/util/envutil"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/httputil"
@@ -189,10 +190,14 @@ type Server struct {
 	jobRegistry        *jobs.Registry
 	statsRefresher     *stats.Refresher
 	engines            Engines
+	raftEngines        RaftEngines
 	internalMemMetrics sql.MemoryMetrics
 	adminMemMetrics    sql.MemoryMetrics
 	// sqlMemMetrics are used to track memory usage of sql sessions.
 	sqlMemMetrics sql.MemoryMetrics
+	// admissionCoordinator subjects KV requests and DistSQL flows to
+	// admission control.
+	admissionCoordinator *admission.GrantCoordinator
 }
 
 // NewServer creates a Server from a server.Config.
@@ -227,6 +232,7 @@ func NewServer(cfg Config, stopper *stop.Stopper) (*Server, error) {
 	} else if certMgr != nil {
 		// The certificate manager is non-nil in secure mode.
 		s.registry.AddMetricStruct(certMgr.Metrics())
+		certMgr.SetSettings(&st.SV)
 	}
 
 	// Add a dynamic log tag value for the node ID.
@@ -415,6 +421,11 @@ func NewServer(cfg Config, stopper *stop.Stopper) (*Server, error) {
 	// Similarly for execCfg.
 	var execCfg sql.ExecutorConfig
 
+	s.admissionCoordinator = admission.NewGrantCoordinator(
+		s.cfg.AmbientCtx, st, s.cfg.HistogramWindowInterval(),
+	)
+	s.registry.AddMetricStruct(s.admissionCoordinator.Metrics())
+
 	// TODO(bdarnell): make StoreConfig configurable.
 	storeCfg := storage.StoreConfig{
 		DefaultZoneConfig:       &s.cfg.DefaultZoneConfig,
@@ -461,6 +472,7 @@ func NewServer(cfg Config, stopper *stop.Stopper) (*Server, error) {
 		}),
 
 		EnableEpochRangeLeases: true,
+		AdmissionController:    s.admissionCoordinator,
 	}
 	if storeTestingKnobs := s.cfg.TestingKnobs.Store; storeTestingKnobs != nil {
 		storeCfg.TestingKnobs = *storeTestingKnobs.(*storage.StoreTestingKnobs)
@@ -544,6 +556,8 @@ func NewServer(cfg Config, stopper *stop.Stopper) (*Server, error) {
 		Gossip:       s.gossip,
 		NodeDialer:   s.nodeDialer,
 		LeaseManager: s.leaseMgr,
+
+		AdmissionQueue: s.admissionCoordinator.CPUWorkQueue(),
 	}
 	if distSQLTestingKnobs := s.cfg.TestingKnobs.DistSQL; distSQLTestingKnobs != nil {
 		distSQLCfg.TestingKnobs = *distSQLTestingKnobs.(*distsqlrun.TestingKnobs)
@@ -659,6 +673,15 @@ func NewServer(cfg Config, stopper *stop.Stopper) (*Server, error) {
 		),
 
 		QueryCache: querycache.New(s.cfg.SQLQueryCacheSize),
+
+		StmtDiagnosticsRegistry: sql.NewStmtDiagnosticsRegistry(s.gossip, s.db, internalExecutor),
+
+		StmtHintsCache: sql.NewStmtHintsCache(s.gossip, internalExecutor),
+
+		NotificationRegistry: sql.NewNotificationRegistry(
+			st, s.clock, s.distSender, internalExecutor,
+			func() bool { return storage.RangefeedEnabled.Get(&st.SV) },
+		),
 	}
 
 	if sqlSchemaChangerTestingKnobs := s.cfg.TestingKnobs.SQLSchemaChanger; sqlSchemaChangerTestingKnobs != nil {
@@ -1339,13 +1362,13 @@ func (s *Server) Start(ctx context.Context) error {
 	}
 	s.mux.Handle("/health", gwMux)
 
-	s.engines, err = s.cfg.CreateEngines(ctx)
+	s.engines, s.raftEngines, err = s.cfg.CreateEngines(ctx)
 	if err != nil {
 		return errors.Wrap(err, "failed to create engines")
 	}
 	s.stopper.AddCloser(&s.engines)
-
-	startAssertEngineHealth(ctx, s.stopper, s.engines)
+	s.stopper.AddCloser(&s.raftEngines)
+	s.node.raftEngines = s.raftEngines
 
 	// Write listener info files early in the startup sequence. `listenerInfo` has a comment.
 	listenerFiles := listenerInfo{
@@ -1583,6 +1606,7 @@ func (s *Server) Start(ctx context.Context) error {
 		s.distSQLServer.ServerConfig.SessionBoundInternalExecutorFactory,
 	).Start(s.stopper)
 
+	s.admissionCoordinator.Start(ctx, s.stopper)
 	s.distSQLServer.Start()
 	s.pgServer.Start(ctx, s.stopper)
 
@@ -1662,6 +1686,13 @@ func (s *Server) Start(ctx context.Context) error {
 	log.Infof(ctx, "done ensuring all necessary migrations have run")
 	close(serveSQL)
 
+	// Load the statement hints now that system.statement_hints exists.
+	s.execCfg.StmtHintsCache.Start(ctx, s.stopper)
+	// Start watching system.notifications for notifications sent with NOTIFY.
+	s.execCfg.NotificationRegistry.Start(ctx, s.stopper)
+	// Load the pending statement diagnostics requests.
+	s.execCfg.StmtDiagnosticsRegistry.Start(ctx, s.stopper)
+
 	log.Info(ctx, "serving sql connections")
 	// Start servicing SQL connections.
 
@@ -1701,6 +1732,11 @@ func (s *Server) Start(ctx context.Context) error {
 	s.mux.Handle(loginPath, gwMux)
 	s.mux.Handle(logoutPath, authHandler)
 	s.mux.Handle(statusVars, http.HandlerFunc(s.status.handleVars))
+	var stmtBundleHandler http.Handler = http.HandlerFunc(s.admin.handleStmtBundle)
+	if s.cfg.RequireWebSession() {
+		stmtBundleHandler = newAuthenticationMux(s.authentication, stmtBundleHandler)
+	}
+	s.mux.Handle(sql.StmtBundleURLPrefix, stmtBundleHandler)
 	log.Event(ctx, "added http endpoints")
 
 	// Attempt to upgrade cluster version.
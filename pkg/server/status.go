@@ -1684,6 +1684,98 @@ func (s *statusServer) CancelQuery(
 	return output, nil
 }
 
+// CancelQueryByKey responds to a pgwire CancelRequest by canceling the queries
+// of the session identified by the request's cancel key.
+func (s *statusServer) CancelQueryByKey(
+	ctx context.Context, req *serverpb.CancelQueryByKeyRequest,
+) (*serverpb.CancelQueryByKeyResponse, error) {
+	ctx = propagateGatewayMetadata(ctx)
+	ctx = s.AnnotateCtx(ctx)
+	if req.NodeId == "" {
+		return s.cancelQueryByKeyOnAllNodes(ctx, req)
+	}
+	nodeID, local, err := s.parseNodeID(req.NodeId)
+
+	if err != nil {
+		return nil, grpcstatus.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	if !local {
+		status, err := s.dialNode(ctx, nodeID)
+		if err != nil {
+			return nil, err
+		}
+		return status.CancelQueryByKey(ctx, req)
+	}
+
+	output := &serverpb.CancelQueryByKeyResponse{}
+	canceled, err := s.sessionRegistry.CancelQueryByKey(sql.CancelKey(req.CancelKey))
+
+	if err != nil {
+		output.Error = err.Error()
+	}
+
+	output.Canceled = canceled
+	return output, nil
+}
+
+// cancelQueryByKeyOnAllNodes looks up the session with the request's cancel
+// key on the local node first, where the CancelRequest is most likely to be
+// sent, then on all the other nodes of the cluster.
+func (s *statusServer) cancelQueryByKeyOnAllNodes(
+	ctx context.Context, req *serverpb.CancelQueryByKeyRequest,
+) (*serverpb.CancelQueryByKeyResponse, error) {
+	output := &serverpb.CancelQueryByKeyResponse{}
+	canceled, err := s.sessionRegistry.CancelQueryByKey(sql.CancelKey(req.CancelKey))
+	if err != sql.ErrCancelKeyNotFound {
+		if err != nil {
+			output.Error = err.Error()
+		}
+		output.Canceled = canceled
+		return output, nil
+	}
+
+	localID := s.gossip.NodeID.Get()
+	localReq := *req
+	localReq.NodeId = "local"
+	found := false
+	dialFn := func(ctx context.Context, nodeID roachpb.NodeID) (interface{}, error) {
+		if nodeID == localID {
+			return nil, nil
+		}
+		client, err := s.dialNode(ctx, nodeID)
+		return client, err
+	}
+	nodeFn := func(ctx context.Context, client interface{}, _ roachpb.NodeID) (interface{}, error) {
+		if client == nil {
+			return (*serverpb.CancelQueryByKeyResponse)(nil), nil
+		}
+		status := client.(serverpb.StatusClient)
+		return status.CancelQueryByKey(ctx, &localReq)
+	}
+	responseFn := func(_ roachpb.NodeID, nodeResp interface{}) {
+		resp := nodeResp.(*serverpb.CancelQueryByKeyResponse)
+		if resp == nil || resp.Error == sql.ErrCancelKeyNotFound.Error() {
+			return
+		}
+		// Keys are only unique within a node; a collision across nodes has
+		// negligible odds given the 64 random bits of a key.
+		found = true
+		output.Canceled = output.Canceled || resp.Canceled
+		output.Error = resp.Error
+	}
+	errorFn := func(nodeID roachpb.NodeID, err error) {
+		log.Warningf(ctx, "searching node %d for cancel key: %v", nodeID, err)
+	}
+	if err := s.iterateNodes(ctx, "cancel key", dialFn, nodeFn, responseFn, errorFn); err != nil {
+		return nil, err
+	}
+	if !found {
+		output.Error = sql.ErrCancelKeyNotFound.Error()
+	}
+	return output, nil
+}
+
 // SpanStats requests the total statistics stored on a node for a given key
 // span, which may include multiple ranges.
 func (s *statusServer) SpanStats(
@@ -177,6 +177,7 @@ func makeTestConfigFromParams(params base.TestServerArgs) Config {
 	if params.DisableWebSessionAuthentication {
 		cfg.EnableWebSessionAuthentication = false
 	}
+	cfg.UseGoEngine = params.UseGoEngine
 
 	// Ensure we have the correct number of engines. Add in-memory ones where
 	// needed. There must be at least one store/engine.
@@ -50,6 +50,11 @@ const (
 	VersionQueryTxnTimestamp
 	VersionStickyBit
 	VersionParallelCommits
+	VersionGlobalReads
+	VersionStatementHints
+	VersionScramPasswords
+	VersionNotifications
+	VersionRowLevelSecurity
 
 	// Add new versions here (step one of two).
 
@@ -479,6 +484,36 @@ var versionsSingleton = keyedVersions([]keyedVersion{
 		Key:     VersionParallelCommits,
 		Version: roachpb.Version{Major: 19, Minor: 1, Unstable: 4},
 	},
+	{
+		// VersionGlobalReads enables the global_reads zone config attribute and
+		// closed timestamps carried in Raft commands.
+		Key:     VersionGlobalReads,
+		Version: roachpb.Version{Major: 19, Minor: 1, Unstable: 5},
+	},
+	{
+		// VersionStatementHints is the version from which the
+		// system.statement_hints table can be written to.
+		Key:     VersionStatementHints,
+		Version: roachpb.Version{Major: 19, Minor: 1, Unstable: 6},
+	},
+	{
+		// VersionScramPasswords is the version from which passwords can be
+		// stored as SCRAM-SHA-256 hashes in system.users.
+		Key:     VersionScramPasswords,
+		Version: roachpb.Version{Major: 19, Minor: 1, Unstable: 7},
+	},
+	{
+		// VersionNotifications is the version from which the
+		// system.notifications table can be written to by NOTIFY.
+		Key:     VersionNotifications,
+		Version: roachpb.Version{Major: 19, Minor: 1, Unstable: 8},
+	},
+	{
+		// VersionRowLevelSecurity is the version from which table descriptors
+		// can have row-level security enabled and policies defined.
+		Key:     VersionRowLevelSecurity,
+		Version: roachpb.Version{Major: 19, Minor: 1, Unstable: 9},
+	},
 
 	// Add new versions here (step two of two).
 
@@ -59,6 +59,19 @@ func (p *planner) AlterTable(ctx context.Context, n *tree.AlterTable) (planNode,
 		return nil, err
 	}
 
+	for _, cmd := range n.Cmds {
+		if t, ok := cmd.(*tree.AlterTableSetRowLevelSecurity); ok {
+			if t.Enabled {
+				if err := p.checkRowLevelSecuritySupported("ENABLE ROW LEVEL SECURITY"); err != nil {
+					return nil, err
+				}
+			}
+			if err := p.checkRowLevelSecurityPrivilege(ctx, tableDesc); err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	n.HoistAddColumnConstraints()
 
 	// See if there's any "inject statistics" in the query and type check the
@@ -433,6 +446,29 @@ func (n *alterTableNode) startExec(params runParams) error {
 				descriptorChanged = true
 			}
 
+			// You can't drop a column referenced by a row-level security policy
+			// unless CASCADE was specified, in which case the policy is dropped.
+			validPolicies := n.tableDesc.Policies[:0]
+			for _, policy := range n.tableDesc.Policies {
+				if used, err := policy.UsesColumn(tree.Name(col.Name)); err != nil {
+					return err
+				} else if !used {
+					validPolicies = append(validPolicies, policy)
+				} else if t.DropBehavior != tree.DropCascade {
+					msg := fmt.Sprintf("cannot drop column %q because policy %q depends on it",
+						col.Name, policy.Name)
+					hint := fmt.Sprintf("you can drop policy %q instead.", policy.Name)
+					return sqlbase.NewDependentObjectErrorWithHint(msg, hint)
+				}
+			}
+			if len(validPolicies) != len(n.tableDesc.Policies) {
+				if err := params.p.checkRowLevelSecurityPrivilege(params.ctx, n.tableDesc); err != nil {
+					return err
+				}
+				n.tableDesc.Policies = validPolicies
+				descriptorChanged = true
+			}
+
 			if err != nil {
 				return err
 			}
@@ -591,6 +627,12 @@ func (n *alterTableNode) startExec(params runParams) error {
 				return err
 			}
 
+		case *tree.AlterTableSetRowLevelSecurity:
+			if n.tableDesc.RowLevelSecurity != t.Enabled {
+				n.tableDesc.RowLevelSecurity = t.Enabled
+				descriptorChanged = true
+			}
+
 		case *tree.AlterTableInjectStats:
 			sd, ok := n.statsData[i]
 			if !ok {
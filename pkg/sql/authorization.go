@@ -20,6 +20,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util"
 	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
 )
 
@@ -111,6 +112,62 @@ func (p *planner) CheckPrivilege(
 		user, privilege, descriptor.TypeName(), descriptor.GetName())
 }
 
+// CheckColumnPrivileges verifies that the user has `priv` on `table` or, failing
+// that, on each of the columns of `table` whose ordinals in DeletableColumns()
+// are in `ords`. When `ords` is empty, it verifies instead that the user has
+// `priv` on at least one column of `table`; this is used when planning starts,
+// before the columns referenced by a query are known.
+func (p *planner) CheckColumnPrivileges(
+	ctx context.Context,
+	table *sqlbase.ImmutableTableDescriptor,
+	priv privilege.Kind,
+	ords util.FastIntSet,
+) error {
+	tableErr := p.CheckPrivilege(ctx, table, priv)
+	if tableErr == nil || !privilege.ColumnPrivileges.Contains(priv) {
+		return tableErr
+	}
+
+	user := p.SessionData().User
+	memberOf, err := p.MemberOfWithAdminOption(ctx, user)
+	if err != nil {
+		return err
+	}
+	hasPrivilege := func(col *sqlbase.ColumnDescriptor) bool {
+		privs := col.Privileges
+		if privs == nil {
+			return false
+		}
+		if privs.CheckPrivilege(user, priv) || privs.CheckPrivilege(sqlbase.PublicRole, priv) {
+			return true
+		}
+		for role := range memberOf {
+			if privs.CheckPrivilege(role, priv) {
+				return true
+			}
+		}
+		return false
+	}
+
+	cols := table.DeletableColumns()
+	if ords.Empty() {
+		for i := range cols {
+			if hasPrivilege(&cols[i]) {
+				return nil
+			}
+		}
+		return tableErr
+	}
+	for ord, ok := ords.Next(0); ok; ord, ok = ords.Next(ord + 1) {
+		if !hasPrivilege(&cols[ord]) {
+			return pgerror.Newf(pgerror.CodeInsufficientPrivilegeError,
+				"user %s does not have %s privilege on column %s of %s %s",
+				user, priv, tree.ErrNameString(cols[ord].Name), table.TypeName(), table.GetName())
+		}
+	}
+	return nil
+}
+
 // CheckAnyPrivilege implements the AuthorizationAccessor interface.
 func (p *planner) CheckAnyPrivilege(ctx context.Context, descriptor sqlbase.DescriptorProto) error {
 	user := p.SessionData().User
@@ -139,6 +196,31 @@ func (p *planner) CheckAnyPrivilege(ctx context.Context, descriptor sqlbase.Desc
 		}
 	}
 
+	// Check if there are privileges on any of the columns of a table.
+	var cols []sqlbase.ColumnDescriptor
+	switch t := descriptor.(type) {
+	case *sqlbase.TableDescriptor:
+		cols = t.Columns
+	case *sqlbase.ImmutableTableDescriptor:
+		cols = t.Columns
+	case *sqlbase.MutableTableDescriptor:
+		cols = t.Columns
+	}
+	for i := range cols {
+		colPrivs := cols[i].Privileges
+		if colPrivs == nil {
+			continue
+		}
+		if colPrivs.AnyPrivilege(user) || colPrivs.AnyPrivilege(sqlbase.PublicRole) {
+			return nil
+		}
+		for role := range memberOf {
+			if colPrivs.AnyPrivilege(role) {
+				return nil
+			}
+		}
+	}
+
 	return pgerror.Newf(pgerror.CodeInsufficientPrivilegeError,
 		"user %s has no privileges on %s %s",
 		p.SessionData().User, descriptor.TypeName(), descriptor.GetName())
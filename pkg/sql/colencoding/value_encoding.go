@@ -13,11 +13,16 @@
 package colencoding
 
 import (
+	"time"
+
 	"github.com/cockroachdb/cockroach/pkg/sql/exec/coldata"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/duration"
 	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
 )
 
 // DecodeTableValueToCol decodes a value encoded by EncodeTableValue, writing
@@ -86,6 +91,25 @@ func decodeUntaggedDatumToCol(vec coldata.Vec, idx uint16, t *types.T, buf []byt
 			// We map these to 64-bit INT now. See #34161.
 			vec.Int64()[idx] = i
 		}
+	case types.TimestampFamily, types.TimestampTZFamily:
+		var t time.Time
+		buf, t, err = encoding.DecodeUntaggedTimeValue(buf)
+		vec.Timestamp()[idx] = t
+	case types.IntervalFamily:
+		var d duration.Duration
+		buf, d, err = encoding.DecodeUntaggedDurationValue(buf)
+		vec.Interval()[idx] = d
+	case types.UuidFamily:
+		var u uuid.UUID
+		buf, u, err = encoding.DecodeUntaggedUUIDValue(buf)
+		vec.Bytes()[idx] = u.GetBytes()
+	case types.JsonFamily:
+		var data []byte
+		buf, data, err = encoding.DecodeUntaggedBytesValue(buf)
+		if err != nil {
+			return buf, err
+		}
+		vec.JSON()[idx], err = json.FromEncoding(data)
 	default:
 		return buf, pgerror.AssertionFailedf(
 			"couldn't decode type: %s", log.Safe(t))
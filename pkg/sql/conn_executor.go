@@ -296,6 +296,7 @@ func makeMetrics(internal bool) Metrics {
 			SQLOptFallbackCount:   metric.NewCounter(getMetricMeta(MetaSQLOptFallback, internal)),
 			SQLOptPlanCacheHits:   metric.NewCounter(getMetricMeta(MetaSQLOptPlanCacheHits, internal)),
 			SQLOptPlanCacheMisses: metric.NewCounter(getMetricMeta(MetaSQLOptPlanCacheMisses, internal)),
+			SQLOptStmtHintsHits:   metric.NewCounter(getMetricMeta(MetaSQLOptStmtHintsHits, internal)),
 
 			// TODO(mrtracy): See HistogramWindowInterval in server/config.go for the 6x factor.
 			DistSQLExecLatency: metric.NewLatency(getMetricMeta(MetaDistSQLExecLatency, internal),
@@ -383,7 +384,11 @@ func (s *Server) SetupConn(
 ) (ConnectionHandler, error) {
 	sd, sdMut := s.newSessionDataAndMutator(args)
 	ex, err := s.newConnExecutor(ctx, sd, sdMut, stmtBuf, clientComm, memMetrics, &s.Metrics)
-	return ConnectionHandler{ex}, err
+	if err != nil {
+		return ConnectionHandler{ex}, err
+	}
+	ex.cancelKey = makeCancelKey()
+	return ConnectionHandler{ex}, nil
 }
 
 // ConnectionHandler is the interface between the result of SetupConn
@@ -410,6 +415,12 @@ func (h ConnectionHandler) GetUnqualifiedIntSize() *types.T {
 	}
 }
 
+// GetCancelKey returns the key that the client can use to cancel the queries
+// of this session through the CancelRequest message of the pgwire protocol.
+func (h ConnectionHandler) GetCancelKey() CancelKey {
+	return h.ex.cancelKey
+}
+
 // GetStatusParam retrieves the configured value of the session
 // variable identified by varName. This is used for the initial
 // message sent to a client during a session set-up.
@@ -733,6 +744,7 @@ func (ex *connExecutor) closeWrapper(ctx context.Context, recovered interface{})
 func (ex *connExecutor) close(ctx context.Context, closeType closeType) {
 	ex.sessionEventf(ctx, "finishing connExecutor")
 
+	ex.stopSuspendedPortals()
 	if closeType == normalClose {
 		// We'll cleanup the SQL txn by creating a non-retriable (commit:true) event.
 		// This event is guaranteed to be accepted in every state.
@@ -767,6 +779,10 @@ func (ex *connExecutor) close(ctx context.Context, closeType closeType) {
 		ex.eventLog = nil
 	}
 
+	if ex.notifications != nil {
+		ex.notifications.close()
+	}
+
 	if closeType != panicClose {
 		ex.state.mon.Stop(ctx)
 		ex.sessionMon.Stop(ctx)
@@ -873,6 +889,10 @@ type connExecutor struct {
 		// txnRewindPos is advanced. Prepared statements are shared between the two
 		// collections, but these collections are periodically reconciled.
 		prepStmtsNamespaceAtTxnRewindPos prepStmtNamespace
+
+		// listenStmts are the LISTEN and UNLISTEN statements executed by the
+		// transaction. They take effect once it commits.
+		listenStmts []tree.Statement
 	}
 
 	// sessionData contains the user-configurable connection variables.
@@ -929,6 +949,16 @@ type connExecutor struct {
 
 	sessionID ClusterWideID
 
+	// cancelKey is the pgwire cancel key handed out to the client of this
+	// session. It is left zero for internal executors, which have no client
+	// that could send a CancelRequest.
+	cancelKey CancelKey
+
+	// notifications is the session's registration with the node's
+	// NotificationRegistry. It is nil until the session first listens on a
+	// channel.
+	notifications *notificationListener
+
 	// activated determines whether activate() was called already.
 	// When this is set, close() must be called to release resources.
 	activated bool
@@ -1024,8 +1054,13 @@ func (ex *connExecutor) resetExtraTxnState(
 
 	ex.extraTxnState.tables.databaseCache = dbCacheHolder.getDatabaseCache()
 
-	// Close all portals.
+	ex.extraTxnState.listenStmts = nil
+
+	// Close all portals. Their statements are stopped even if the portals are
+	// also referenced by prepStmtsNamespaceAtTxnRewindPos: they started after
+	// the rewind position, so a restarted transaction must run them again.
 	for name, p := range ex.extraTxnState.prepStmtsNamespace.portals {
+		p.closeExec()
 		p.decRef(ctx)
 		delete(ex.extraTxnState.prepStmtsNamespace.portals, name)
 	}
@@ -1252,14 +1287,37 @@ func (ex *connExecutor) execCmd(ctx context.Context) error {
 			DontNeedRowDesc,
 			pos, portal.OutFormats,
 			ex.sessionData.DataConversion)
-		stmtRes.SetLimit(tcmd.Limit)
 		res = stmtRes
+
+		// A portal that was suspended (or that backs a cursor) is resumed
+		// instead of running its statement again.
+		if _, inOpen := ex.machine.CurState().(stateOpen); inOpen && portal.exec != nil {
+			ev, payload, err = ex.resumePortal(ctx, portal, tcmd.Limit, stmtRes)
+			if err != nil {
+				return err
+			}
+			break
+		}
+
 		curStmt := Statement{
 			Statement:     portal.Stmt.Statement,
 			Prepared:      portal.Stmt,
 			ExpectedTypes: portal.Stmt.Columns,
 			AnonymizedStr: portal.Stmt.AnonymizedStr,
 		}
+		// If the portal can be suspended, its statement is started and runs
+		// until tcmd.Limit rows have been returned; see portalExec. Otherwise
+		// the limit is enforced by the result, which fails if more rows are
+		// produced.
+		if tcmd.Limit > 0 && ex.canSuspendPortal(portal) {
+			portal.exec = ex.newPortalExec(ctx, curStmt, pinfo, false /* cursor */)
+			ev, payload, err = ex.resumePortal(ctx, portal, tcmd.Limit, stmtRes)
+			if err != nil {
+				return err
+			}
+			break
+		}
+		stmtRes.SetLimit(tcmd.Limit)
 		stmtCtx := withStatement(ctx, ex.curStmt)
 		ev, payload, err = ex.execStmt(stmtCtx, curStmt, stmtRes, pinfo)
 		if err != nil {
@@ -1285,6 +1343,13 @@ func (ex *connExecutor) execCmd(ctx context.Context) error {
 		ev = eventNonRetriableErr{IsCommit: fsm.False}
 		payload = eventNonRetriableErrPayload{err: tcmd.Err}
 	case Sync:
+		// Deliver the notifications which arrived while the session was in a
+		// transaction, now that it might be over.
+		if ex.hasPendingNotifications() {
+			notifRes := ex.clientComm.CreateNotificationResult(pos)
+			ex.sendNotifications(notifRes)
+			notifRes.Close(stateToTxnStatusIndicator(ex.machine.CurState()))
+		}
 		// Note that the Sync result will flush results to the network connection.
 		res = ex.clientComm.CreateSyncResult(pos)
 		if ex.draining {
@@ -1319,6 +1384,10 @@ func (ex *connExecutor) execCmd(ctx context.Context) error {
 	case Flush:
 		// Closing the res will flush the connection's buffer.
 		res = ex.clientComm.CreateFlushResult(pos)
+	case SendNotifications:
+		notifRes := ex.clientComm.CreateNotificationResult(pos)
+		res = notifRes
+		ex.sendNotifications(notifRes)
 	default:
 		panic(fmt.Sprintf("unsupported command type: %T", cmd))
 	}
@@ -1466,8 +1535,10 @@ func (ex *connExecutor) updateTxnRewindPosMaybe(
 			case ExecStmt:
 				canAdvance = ex.stmtDoesntNeedRetry(tcmd.AST)
 			case ExecPortal:
-				portal := ex.extraTxnState.prepStmtsNamespace.portals[tcmd.Name]
-				canAdvance = ex.stmtDoesntNeedRetry(portal.Stmt.AST)
+				// The portal might have been closed by its own statement (CLOSE ALL).
+				if portal, ok := ex.extraTxnState.prepStmtsNamespace.portals[tcmd.Name]; ok {
+					canAdvance = ex.stmtDoesntNeedRetry(portal.Stmt.AST)
+				}
 			case PrepareStmt:
 				canAdvance = true
 			case DescribeStmt:
@@ -1486,6 +1557,8 @@ func (ex *connExecutor) updateTxnRewindPosMaybe(
 				canAdvance = true
 			case Flush:
 				canAdvance = true
+			case SendNotifications:
+				canAdvance = true
 			default:
 				panic(fmt.Sprintf("unsupported cmd: %T", cmd))
 			}
@@ -1618,8 +1691,12 @@ func (ex *connExecutor) execCopyIn(
 }
 
 // stmtHasNoData returns true if describing a result of the input statement
-// type should return NoData.
+// type should return NoData. COPY TO STDOUT returns rows, but sends them through
+// the Copy-out subprotocol instead of describing them.
 func stmtHasNoData(stmt tree.Statement) bool {
+	if _, ok := stmt.(*tree.CopyTo); ok {
+		return true
+	}
 	return stmt == nil || stmt.StatementType() != tree.Rows
 }
 
@@ -1798,6 +1875,8 @@ func (ex *connExecutor) initEvalCtx(ctx context.Context, evalCtx *extendedEvalCo
 			ReCache:          ex.server.reCache,
 			InternalExecutor: ie,
 			DB:               ex.server.cfg.DB,
+
+			StmtDiagnosticsRequestInserter: ex.server.cfg.StmtDiagnosticsRegistry.InsertRequest,
 		},
 		SessionMutator:  ex.dataMutator,
 		VirtualSchemas:  ex.server.cfg.VirtualSchemas,
@@ -1894,6 +1973,7 @@ func (ex *connExecutor) resetPlanner(
 	p.autoCommit = false
 	p.isPreparing = false
 	p.avoidCachedDescriptors = false
+	p.diagnostics = nil
 }
 
 // txnStateTransitionsApplyWrapper is a wrapper on top of Machine built with the
@@ -1910,6 +1990,13 @@ func (ex *connExecutor) txnStateTransitionsApplyWrapper(
 		implicitTxn = os.ImplicitTxn.Get()
 	}
 
+	// The flows of suspended portals use the transaction, so they are stopped
+	// before it finishes, restarts or becomes aborted. Only SAVEPOINT leaves
+	// the transaction usable.
+	if _, ok := ev.(eventRetryIntentSet); !ok {
+		ex.stopSuspendedPortals()
+	}
+
 	err := ex.machine.ApplyWithPayload(withStatement(ex.Ctx(), ex.curStmt), ev, payload)
 	if err != nil {
 		if _, ok := err.(fsm.TransitionNotFoundError); ok {
@@ -1984,6 +2071,8 @@ func (ex *connExecutor) txnStateTransitionsApplyWrapper(
 		// Wait for the cache to reflect the dropped databases if any.
 		ex.extraTxnState.tables.waitForCacheToDropDatabases(ex.Ctx())
 
+		ex.applyListenStmts()
+
 		fallthrough
 	case txnRestart, txnAborted:
 		if err := ex.resetExtraTxnState(ex.Ctx(), ex.server.dbCache); err != nil {
@@ -2042,6 +2131,21 @@ func (ex *connExecutor) cancelQuery(queryID ClusterWideID) bool {
 	return false
 }
 
+// pgwireCancelKey is part of the registrySession interface.
+func (ex *connExecutor) pgwireCancelKey() CancelKey {
+	return ex.cancelKey
+}
+
+// cancelCurrentQueries is part of the registrySession interface.
+func (ex *connExecutor) cancelCurrentQueries() bool {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+	for _, queryMeta := range ex.mu.ActiveQueries {
+		queryMeta.cancel()
+	}
+	return len(ex.mu.ActiveQueries) > 0
+}
+
 // cancelSession is part of the registrySession interface.
 func (ex *connExecutor) cancelSession() {
 	if ex.onCancelSession == nil {
@@ -355,12 +355,35 @@ func (ex *connExecutor) execStmtInOpenState(
 		res.ResetStmtType(ps.AST)
 
 		discardRows = s.DiscardRows
+
+	case *tree.DeclareCursor:
+		return ex.execDeclareCursor(ctx, s, stmt, pinfo, res)
+
+	case *tree.FetchCursor:
+		return ex.execFetchCursor(ctx, s, res)
+
+	case *tree.CloseCursor:
+		if err := ex.execCloseCursor(ctx, s); err != nil {
+			return makeErrEvent(err)
+		}
+		return nil, nil, nil
+
+	case *tree.Listen, *tree.Unlisten:
+		if err := ex.execListen(s); err != nil {
+			return makeErrEvent(err)
+		}
+		return nil, nil, nil
 	}
 
 	// For regular statements (the ones that get to this point), we don't return
 	// any event unless an an error happens.
 
 	p := &ex.planner
+	if pe, ok := res.(*portalExec); ok {
+		// The statement of a portal that can be suspended has its own planner,
+		// since other statements run while it is suspended.
+		p = &pe.p
+	}
 	stmtTS := ex.server.cfg.Clock.PhysicalTime()
 	ex.resetPlanner(ctx, p, ex.state.mu.txn, stmtTS, stmt.NumAnnotations)
 
@@ -645,6 +668,19 @@ func (ex *connExecutor) dispatchToExecutionEngine(
 	ctx context.Context, planner *planner, res RestrictedCommandResult,
 ) error {
 	stmt := planner.stmt
+
+	// If a diagnostics bundle was requested for this statement, collect it
+	// while planning and executing it. Requests are only satisfied by
+	// statements issued by clients.
+	if registry := ex.server.cfg.StmtDiagnosticsRegistry; registry != nil &&
+		!strings.HasPrefix(ex.sessionData.ApplicationName, sqlbase.InternalAppNamePrefix) {
+		if id, ok := registry.shouldCollect(stmt.AST); ok {
+			var finishDiagnostics func()
+			ctx, finishDiagnostics = startStmtDiagnostics(ctx, planner, registry, id)
+			defer finishDiagnostics()
+		}
+	}
+
 	ex.sessionTracing.TracePlanStart(ctx, stmt.AST.StatementTag())
 	planner.statsCollector.PhaseTimes()[plannerStartLogicalPlan] = timeutil.Now()
 
@@ -699,6 +735,11 @@ func (ex *connExecutor) dispatchToExecutionEngine(
 		distributePlan = shouldDistributePlan(
 			ctx, ex.sessionData.DistSQLMode, ex.server.cfg.DistSQLPlanner, planner.curPlan.plan)
 	}
+	// The flow of a portal that can be suspended stays open while the portal is
+	// suspended, so it must not have remote parts that keep running meanwhile.
+	if _, ok := res.(*portalExec); ok {
+		distributePlan = false
+	}
 	ex.sessionTracing.TracePlanCheckEnd(ctx, nil, distributePlan)
 
 	if ex.server.cfg.TestingKnobs.BeforeExecute != nil {
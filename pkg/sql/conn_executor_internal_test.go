@@ -31,6 +31,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/mon"
 	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/uint128"
 	"github.com/cockroachdb/cockroach/pkg/util/uuid"
 )
 
@@ -213,6 +214,61 @@ func TestPortalsDestroyedOnTxnFinish(t *testing.T) {
 	}
 }
 
+// fakeRegistrySession is a registrySession which only records the
+// cancellation of its queries.
+type fakeRegistrySession struct {
+	registrySession
+	key      CancelKey
+	canceled bool
+}
+
+func (s *fakeRegistrySession) pgwireCancelKey() CancelKey { return s.key }
+
+func (s *fakeRegistrySession) cancelCurrentQueries() bool {
+	s.canceled = true
+	return true
+}
+
+// Test that a pgwire cancel key cancels the queries of exactly the session it
+// was handed out to.
+func TestSessionRegistryCancelQueryByKey(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	r := NewSessionRegistry()
+	a := &fakeRegistrySession{key: makeCancelKey()}
+	b := &fakeRegistrySession{key: a.key}
+	internal := &fakeRegistrySession{}
+	idA, idB, idInternal := ClusterWideID{Uint128: uint128.FromInts(0, 1)},
+		ClusterWideID{Uint128: uint128.FromInts(0, 2)}, ClusterWideID{Uint128: uint128.FromInts(0, 3)}
+	r.register(idA, a)
+	// b collides with a's key and must not be reachable through it.
+	r.register(idB, b)
+	r.register(idInternal, internal)
+
+	if _, err := r.CancelQueryByKey(0); err != ErrCancelKeyNotFound {
+		t.Fatalf("expected %v for the zero key, got %v", ErrCancelKeyNotFound, err)
+	}
+	if _, err := r.CancelQueryByKey(a.key + 1); err != ErrCancelKeyNotFound {
+		t.Fatalf("expected %v for a wrong key, got %v", ErrCancelKeyNotFound, err)
+	}
+	if a.canceled || b.canceled || internal.canceled {
+		t.Fatal("unexpected cancellation")
+	}
+	if canceled, err := r.CancelQueryByKey(a.key); err != nil || !canceled {
+		t.Fatalf("expected cancellation, got %t, %v", canceled, err)
+	}
+	if !a.canceled || b.canceled || internal.canceled {
+		t.Fatalf("expected only the first session to be canceled")
+	}
+
+	r.deregister(idA)
+	if _, err := r.CancelQueryByKey(a.key); err != ErrCancelKeyNotFound {
+		t.Fatalf("expected %v after deregistration, got %v", ErrCancelKeyNotFound, err)
+	}
+	r.deregister(idB)
+	r.deregister(idInternal)
+}
+
 func mustParseOne(s string) parser.Statement {
 	stmts, err := parser.Parse(s)
 	if err != nil {
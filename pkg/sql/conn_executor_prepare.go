@@ -424,6 +424,10 @@ func (ex *connExecutor) deletePortal(ctx context.Context, name string) {
 	if !ok {
 		return
 	}
+	// The portal might still be referenced by prepStmtsNamespaceAtTxnRewindPos,
+	// but its statement is not needed anymore (and would have to run again
+	// after a rewind).
+	portal.closeExec()
 	portal.decRef(ctx)
 	delete(ex.extraTxnState.prepStmtsNamespace.portals, name)
 }
@@ -363,6 +363,23 @@ func (s SendError) String() string {
 
 var _ Command = SendError{}
 
+// SendNotifications is a command asking for the notifications received by the
+// session for the channels it listens on to be delivered to the client. It is
+// pushed by the session's NotificationRegistry listener, from another
+// goroutine, when notifications arrive. Notifications are only delivered
+// outside of transactions; otherwise they are delivered by the Sync command
+// following the end of the transaction.
+type SendNotifications struct{}
+
+// command implements the Command interface.
+func (SendNotifications) command() string { return "send notifications" }
+
+func (SendNotifications) String() string {
+	return "SendNotifications"
+}
+
+var _ Command = SendNotifications{}
+
 // NewStmtBuf creates a StmtBuf.
 func NewStmtBuf() *StmtBuf {
 	var buf StmtBuf
@@ -604,6 +621,9 @@ type ClientComm interface {
 	CreateCopyInResult(pos CmdPos) CopyInResult
 	// CreateDrainResult creates a result for a Drain command.
 	CreateDrainResult(pos CmdPos) DrainResult
+	// CreateNotificationResult creates a result through which notifications
+	// can be delivered to the client.
+	CreateNotificationResult(pos CmdPos) NotificationResult
 
 	// lockCommunication ensures that no further results are delivered to the
 	// client. The returned ClientLock can be queried to see what results have
@@ -626,11 +646,20 @@ type CommandResult interface {
 	RestrictedCommandResult
 	CommandResultClose
 
-	// SetLimit is used when executing a portal to set a limit on the number of
-	// rows to be returned. We don't currently properly support this feature of
-	// the Postgres protocol; instead, we'll return an error if the number of rows
-	// produced is larger than this limit.
+	// SetLimit is used when executing a portal outside of an explicit
+	// transaction to set a limit on the number of rows to be returned. Such
+	// portals cannot be suspended; instead, we'll return an error if the number
+	// of rows produced is larger than this limit. Inside explicit transactions,
+	// the connExecutor enforces the limit itself and suspends the portal (see
+	// SetPortalSuspended).
 	SetLimit(n int)
+
+	// SetPortalSuspended is used when executing a portal whose row limit was
+	// reached before all of its rows were returned. The result is then
+	// completed with a PortalSuspended message instead of a CommandComplete
+	// one, telling the client that it can execute the portal again to get more
+	// rows.
+	SetPortalSuspended()
 }
 
 // CommandResultErrBase is the subset of CommandResult dealing with setting a
@@ -781,6 +810,16 @@ type DrainResult interface {
 	ResultBase
 }
 
+// NotificationResult represents the result through which the notifications
+// of the channels listened on by the session are delivered. When closed, the
+// notifications added to it are flushed to the client.
+type NotificationResult interface {
+	ResultBase
+
+	// AddNotification sends a notification to the client.
+	AddNotification(n Notification)
+}
+
 // EmptyQueryResult represents the result of an empty query (a query
 // representing a blank string).
 type EmptyQueryResult interface {
@@ -921,6 +960,11 @@ func (r *bufferedCommandResult) SetLimit(limit int) {
 	}
 }
 
+// SetPortalSuspended is part of the CommandResult interface.
+func (r *bufferedCommandResult) SetPortalSuspended() {
+	panic("unimplemented")
+}
+
 // Close is part of the CommandResult interface.
 func (r *bufferedCommandResult) Close(TransactionStatusIndicator) {
 	if r.closeCallback != nil {
@@ -946,6 +990,9 @@ func (r *bufferedCommandResult) Discard() {
 // SetInferredTypes is part of the DescribeResult interface.
 func (r *bufferedCommandResult) SetInferredTypes([]oid.Oid) {}
 
+// AddNotification is part of the NotificationResult interface.
+func (r *bufferedCommandResult) AddNotification(Notification) {}
+
 // SetNoDataRowDescription is part of the DescribeResult interface.
 func (r *bufferedCommandResult) SetNoDataRowDescription() {}
 
@@ -15,6 +15,7 @@ package sql
 import (
 	"bytes"
 	"context"
+	"encoding/binary"
 	"io"
 	"strconv"
 	"time"
@@ -48,6 +49,13 @@ type copyMachine struct {
 	table         tree.TableExpr
 	columns       tree.NameList
 	resultColumns sqlbase.ResultColumns
+	// opts are the options of the statement, with the defaults filled in.
+	opts tree.CopyOptions
+	// skipHeader is set while the header line of the CSV format remains to be
+	// skipped.
+	skipHeader bool
+	// binaryState tracks the parsing of the binary format.
+	binaryState binaryState
 	// buf is used to parse input data into rows. It also accumulates a partial
 	// row between protocol messages.
 	buf bytes.Buffer
@@ -91,10 +99,12 @@ func newCopyMachine(
 	resetPlanner func(p *planner, txn *client.Txn, txnTS time.Time, stmtTS time.Time),
 ) (_ *copyMachine, retErr error) {
 	c := &copyMachine{
-		conn:    conn,
-		table:   &n.Table,
-		columns: n.Columns,
-		txnOpt:  txnOpt,
+		conn:       conn,
+		table:      &n.Table,
+		columns:    n.Columns,
+		opts:       n.Options.WithDefaults(),
+		skipHeader: n.Options.FileFormat == tree.CopyFormatCSV && n.Options.Header,
+		txnOpt:     txnOpt,
 		// The planner will be prepared before use.
 		p:            planner{execCfg: execCfg},
 		resetPlanner: resetPlanner,
@@ -148,7 +158,11 @@ func (c *copyMachine) run(ctx context.Context) error {
 	defer c.bufMemAcc.Close(ctx)
 
 	// Send the message describing the columns to the client.
-	if err := c.conn.BeginCopyIn(ctx, c.resultColumns); err != nil {
+	format := pgwirebase.FormatText
+	if c.opts.FileFormat == tree.CopyFormatBinary {
+		format = pgwirebase.FormatBinary
+	}
+	if err := c.conn.BeginCopyIn(ctx, c.resultColumns, format); err != nil {
 		return err
 	}
 
@@ -196,12 +210,25 @@ Loop:
 }
 
 const (
-	nullString = `\N`
-	lineDelim  = '\n'
+	lineDelim = '\n'
+	// endOfData is the line that marks the end of the data in the text and CSV
+	// formats.
+	endOfData = `\.`
 )
 
-var (
-	fieldDelim = []byte{'\t'}
+// binarySignature starts the header of the binary format.
+var binarySignature = []byte("PGCOPY\n\377\r\n\000")
+
+// binaryState is the state of the parsing of the binary format.
+type binaryState int
+
+const (
+	// binaryStateHeader means that the header is expected.
+	binaryStateHeader binaryState = iota
+	// binaryStateTuples means that tuples or the trailer are expected.
+	binaryStateTuples
+	// binaryStateDone means that the trailer has been read.
+	binaryStateDone
 )
 
 // processCopyData buffers incoming data and, once the buffer fills up, inserts
@@ -231,30 +258,17 @@ func (c *copyMachine) processCopyData(
 		}
 	}
 	c.buf.WriteString(data)
-	for c.buf.Len() > 0 {
-		line, err := c.buf.ReadBytes(lineDelim)
-		if err != nil {
-			if err != io.EOF {
-				return err
-			} else if !final {
-				// Put the incomplete row back in the buffer, to be processed next time.
-				c.buf.Write(line)
-				break
-			}
-		} else {
-			// Remove lineDelim from end.
-			line = line[:len(line)-1]
-			// Remove a single '\r' at EOL, if present.
-			if len(line) > 0 && line[len(line)-1] == '\r' {
-				line = line[:len(line)-1]
-			}
-		}
-		if c.buf.Len() == 0 && bytes.Equal(line, []byte(`\.`)) {
-			break
-		}
-		if err := c.addRow(ctx, line); err != nil {
-			return err
-		}
+	var err error
+	switch c.opts.FileFormat {
+	case tree.CopyFormatCSV:
+		err = c.readCSVData(ctx, final)
+	case tree.CopyFormatBinary:
+		err = c.readBinaryData(ctx, final)
+	default:
+		err = c.readTextData(ctx, final)
+	}
+	if err != nil {
+		return err
 	}
 	// Only do work if we have a full batch of rows or this is the end.
 	if ln := len(c.rows); ln == 0 || (ln < copyBatchRowSize && !final) {
@@ -349,9 +363,40 @@ func (c *copyMachine) insertRows(ctx context.Context) (retErr error) {
 	return nil
 }
 
-func (c *copyMachine) addRow(ctx context.Context, line []byte) error {
+// readTextData parses the buffered data in the text format. Each line is a
+// row, whose values are separated by the delimiter.
+func (c *copyMachine) readTextData(ctx context.Context, final bool) error {
+	for c.buf.Len() > 0 {
+		line, err := c.buf.ReadBytes(lineDelim)
+		if err != nil {
+			if err != io.EOF {
+				return err
+			} else if !final {
+				// Put the incomplete row back in the buffer, to be processed next time.
+				c.buf.Write(line)
+				break
+			}
+		} else {
+			// Remove lineDelim from end.
+			line = line[:len(line)-1]
+			// Remove a single '\r' at EOL, if present.
+			if len(line) > 0 && line[len(line)-1] == '\r' {
+				line = line[:len(line)-1]
+			}
+		}
+		if c.buf.Len() == 0 && bytes.Equal(line, []byte(endOfData)) {
+			break
+		}
+		if err := c.addTextRow(ctx, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *copyMachine) addTextRow(ctx context.Context, line []byte) error {
 	var err error
-	parts := bytes.Split(line, fieldDelim)
+	parts := bytes.Split(line, []byte(c.opts.Delimiter))
 	if len(parts) != len(c.resultColumns) {
 		return pgerror.Newf(pgerror.CodeProtocolViolationError,
 			"expected %d values, got %d", len(c.resultColumns), len(parts))
@@ -359,7 +404,7 @@ func (c *copyMachine) addRow(ctx context.Context, line []byte) error {
 	exprs := make(tree.Exprs, len(parts))
 	for i, part := range parts {
 		s := string(part)
-		if s == nullString {
+		if s == c.opts.Null {
 			exprs[i] = tree.DNull
 			continue
 		}
@@ -381,14 +426,258 @@ func (c *copyMachine) addRow(ctx context.Context, line []byte) error {
 		if err != nil {
 			return err
 		}
+		exprs[i] = d
+	}
+	return c.addRow(ctx, exprs)
+}
 
-		sz := d.Size()
-		if err := c.rowsMemAcc.Grow(ctx, int64(sz)); err != nil {
+// readCSVData parses the buffered data in the CSV format. Records end with a
+// line break outside of a quoted value.
+func (c *copyMachine) readCSVData(ctx context.Context, final bool) error {
+	for c.buf.Len() > 0 {
+		data := c.buf.Bytes()
+		if bytes.HasPrefix(data, []byte(endOfData)) {
+			if rest := data[len(endOfData):]; (len(rest) == 0 && final) ||
+				(len(rest) > 0 && (rest[0] == '\n' || rest[0] == '\r')) {
+				// Data following the end marker is ignored.
+				c.buf.Reset()
+				break
+			}
+		}
+		fields, n, err := c.readCSVRecord(data, final)
+		if err != nil {
 			return err
 		}
+		if n == 0 {
+			// The record is incomplete; it will be processed next time.
+			break
+		}
+		c.buf.Next(n)
+		if c.skipHeader {
+			c.skipHeader = false
+			continue
+		}
+		if err := c.addCSVRow(ctx, fields); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readCSVRecord parses the record at the start of data. It returns the values
+// of the record, with nil for NULL values, and the number of bytes consumed,
+// which is 0 if data does not hold a complete record. Unless final is set, a
+// record is only complete once its line break has been seen.
+func (c *copyMachine) readCSVRecord(data []byte, final bool) ([][]byte, int, error) {
+	delim, quote, escape := c.opts.Delimiter[0], c.opts.Quote[0], c.opts.Escape[0]
+	var fields [][]byte
+	var field []byte
+	// quoted is set if any part of the current value was quoted; a quoted
+	// value is never NULL.
+	quoted, inQuotes := false, false
+	endField := func() {
+		if !quoted && string(field) == c.opts.Null {
+			fields = append(fields, nil)
+		} else {
+			fields = append(fields, append([]byte{}, field...))
+		}
+		field, quoted = field[:0], false
+	}
+	for i := 0; i < len(data); i++ {
+		ch := data[i]
+		if inQuotes {
+			if ch == quote || ch == escape {
+				if i+1 == len(data) && !final {
+					// The next character decides whether this one is escaped.
+					return nil, 0, nil
+				}
+				if ch == escape && i+1 < len(data) && (data[i+1] == quote || data[i+1] == escape) {
+					i++
+					field = append(field, data[i])
+					continue
+				}
+				if ch == quote {
+					inQuotes = false
+					continue
+				}
+			}
+			field = append(field, ch)
+			continue
+		}
+		switch ch {
+		case quote:
+			inQuotes, quoted = true, true
+		case delim:
+			endField()
+		case '\r':
+			if i+1 == len(data) && !final {
+				return nil, 0, nil
+			}
+			if i+1 < len(data) && data[i+1] == '\n' {
+				i++
+			}
+			endField()
+			return fields, i + 1, nil
+		case '\n':
+			endField()
+			return fields, i + 1, nil
+		default:
+			field = append(field, ch)
+		}
+	}
+	if !final {
+		return nil, 0, nil
+	}
+	if inQuotes {
+		return nil, 0, pgerror.New(pgerror.CodeBadCopyFileFormatError,
+			"unterminated CSV quoted field")
+	}
+	endField()
+	return fields, len(data), nil
+}
 
+func (c *copyMachine) addCSVRow(ctx context.Context, fields [][]byte) error {
+	if len(fields) != len(c.resultColumns) {
+		return pgerror.Newf(pgerror.CodeProtocolViolationError,
+			"expected %d values, got %d", len(c.resultColumns), len(fields))
+	}
+	exprs := make(tree.Exprs, len(fields))
+	for i, field := range fields {
+		if field == nil {
+			exprs[i] = tree.DNull
+			continue
+		}
+		d, err := tree.ParseStringAs(c.resultColumns[i].Typ, string(field), c.parsingEvalCtx)
+		if err != nil {
+			return err
+		}
 		exprs[i] = d
 	}
+	return c.addRow(ctx, exprs)
+}
+
+// readBinaryData parses the buffered data in the binary format: a header,
+// followed by tuples made of a field count and of length-prefixed values in
+// the binary encoding, followed by a trailer.
+func (c *copyMachine) readBinaryData(ctx context.Context, final bool) error {
+	for c.buf.Len() > 0 && c.binaryState != binaryStateDone {
+		var n int
+		var err error
+		if c.binaryState == binaryStateHeader {
+			n, err = c.readBinaryHeader(c.buf.Bytes())
+		} else {
+			n, err = c.readBinaryTuple(ctx, c.buf.Bytes())
+		}
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			// The header or tuple is incomplete.
+			break
+		}
+		c.buf.Next(n)
+	}
+	if final && c.binaryState != binaryStateDone {
+		return pgerror.New(pgerror.CodeBadCopyFileFormatError, "unexpected EOF in COPY data")
+	}
+	return nil
+}
+
+// readBinaryHeader parses the header at the start of data. It returns the
+// number of bytes consumed, which is 0 if the header is incomplete.
+func (c *copyMachine) readBinaryHeader(data []byte) (int, error) {
+	// The signature is followed by the flags and the length of the header
+	// extension.
+	const fixedLen = 8
+	n := len(binarySignature) + fixedLen
+	if len(data) < len(binarySignature) {
+		if !bytes.HasPrefix(binarySignature, data) {
+			return 0, pgerror.New(pgerror.CodeBadCopyFileFormatError,
+				"COPY file signature not recognized")
+		}
+		return 0, nil
+	}
+	if !bytes.HasPrefix(data, binarySignature) {
+		return 0, pgerror.New(pgerror.CodeBadCopyFileFormatError,
+			"COPY file signature not recognized")
+	}
+	if len(data) < n {
+		return 0, nil
+	}
+	flags := binary.BigEndian.Uint32(data[len(binarySignature):])
+	if flags&(1<<16) != 0 {
+		return 0, pgerror.New(pgerror.CodeBadCopyFileFormatError,
+			"COPY with OIDs is not supported")
+	}
+	if flags&^(1<<16) != 0 {
+		return 0, pgerror.New(pgerror.CodeBadCopyFileFormatError,
+			"unrecognized critical flags in COPY file header")
+	}
+	// The header extension is skipped.
+	n += int(binary.BigEndian.Uint32(data[len(binarySignature)+4:]))
+	if len(data) < n {
+		return 0, nil
+	}
+	c.binaryState = binaryStateTuples
+	return n, nil
+}
+
+// readBinaryTuple parses the tuple or trailer at the start of data. It returns
+// the number of bytes consumed, which is 0 if the tuple is incomplete.
+func (c *copyMachine) readBinaryTuple(ctx context.Context, data []byte) (int, error) {
+	if len(data) < 2 {
+		return 0, nil
+	}
+	count := int16(binary.BigEndian.Uint16(data))
+	if count == -1 {
+		c.binaryState = binaryStateDone
+		return 2, nil
+	}
+	if int(count) != len(c.resultColumns) {
+		return 0, pgerror.Newf(pgerror.CodeBadCopyFileFormatError,
+			"row field count is %d, expected %d", count, len(c.resultColumns))
+	}
+	n := 2
+	exprs := make(tree.Exprs, len(c.resultColumns))
+	for i := range exprs {
+		if len(data) < n+4 {
+			return 0, nil
+		}
+		length := int32(binary.BigEndian.Uint32(data[n:]))
+		n += 4
+		if length == -1 {
+			exprs[i] = tree.DNull
+			continue
+		}
+		if length < 0 {
+			return 0, pgerror.Newf(pgerror.CodeBadCopyFileFormatError,
+				"invalid field size %d", length)
+		}
+		if len(data) < n+int(length) {
+			return 0, nil
+		}
+		d, err := pgwirebase.DecodeOidDatum(
+			c.parsingEvalCtx, c.resultColumns[i].Typ.Oid(), pgwirebase.FormatBinary, data[n:n+int(length)],
+		)
+		if err != nil {
+			return 0, err
+		}
+		n += int(length)
+		exprs[i] = d
+	}
+	if err := c.addRow(ctx, exprs); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// addRow adds a row to the batch of rows to be inserted.
+func (c *copyMachine) addRow(ctx context.Context, exprs tree.Exprs) error {
+	for _, e := range exprs {
+		if err := c.rowsMemAcc.Grow(ctx, int64(e.(tree.Datum).Size())); err != nil {
+			return err
+		}
+	}
 	if err := c.rowsMemAcc.Grow(ctx, int64(unsafe.Sizeof(exprs))); err != nil {
 		return err
 	}
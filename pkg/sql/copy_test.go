@@ -13,8 +13,10 @@
 package sql
 
 import (
+	"reflect"
 	"testing"
 
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 )
 
@@ -86,3 +88,90 @@ func TestDecodeCopy(t *testing.T) {
 		}
 	}
 }
+
+func TestReadCSVRecord(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	c := &copyMachine{
+		opts: tree.CopyOptions{FileFormat: tree.CopyFormatCSV}.WithDefaults(),
+	}
+
+	// A <NULL> value in expect denotes NULL; a nil expect denotes that more
+	// data is needed.
+	tests := []struct {
+		in       string
+		final    bool
+		expect   []string
+		consumed int
+		err      bool
+	}{
+		{
+			in:       "a,b,c\n",
+			expect:   []string{"a", "b", "c"},
+			consumed: 6,
+		},
+		{
+			in:       "a,,\"\"\nnext",
+			expect:   []string{"a", "<NULL>", ""},
+			consumed: 6,
+		},
+		{
+			in:       `"a,b","c""d","e` + "\n" + `f"` + "\r\n",
+			expect:   []string{"a,b", `c"d`, "e\nf"},
+			consumed: 20,
+		},
+		{
+			in:       "x,y",
+			final:    true,
+			expect:   []string{"x", "y"},
+			consumed: 3,
+		},
+
+		// Incomplete records.
+
+		{
+			in: "a,b",
+		},
+		{
+			in: `"a` + "\n",
+		},
+		{
+			in: `"a"`,
+		},
+		{
+			in: "a\r",
+		},
+
+		// Error cases.
+
+		{
+			in:    `"a`,
+			final: true,
+			err:   true,
+		},
+	}
+
+	for _, test := range tests {
+		fields, n, err := c.readCSVRecord([]byte(test.in), test.final)
+		if gotErr := err != nil; gotErr != test.err {
+			if gotErr {
+				t.Errorf("%q: unexpected error: %v", test.in, err)
+				continue
+			}
+			t.Errorf("%q: expected error", test.in)
+			continue
+		}
+		var out []string
+		for _, f := range fields {
+			if f == nil {
+				out = append(out, "<NULL>")
+			} else {
+				out = append(out, string(f))
+			}
+		}
+		if !reflect.DeepEqual(out, test.expect) || n != test.consumed {
+			t.Errorf("%q: got %q (%d bytes), expected %q (%d bytes)",
+				test.in, out, n, test.expect, test.consumed)
+		}
+	}
+}
@@ -91,6 +91,7 @@ var crdbInternal = virtualSchema{
 		sqlbase.CrdbInternalSchemaChangesTableID:        crdbInternalSchemaChangesTable,
 		sqlbase.CrdbInternalSessionTraceTableID:         crdbInternalSessionTraceTable,
 		sqlbase.CrdbInternalSessionVariablesTableID:     crdbInternalSessionVariablesTable,
+		sqlbase.CrdbInternalStmtDiagnosticsTableID:      crdbInternalStmtDiagnosticsTable,
 		sqlbase.CrdbInternalStmtStatsTableID:            crdbInternalStmtStatsTable,
 		sqlbase.CrdbInternalTableColumnsTableID:         crdbInternalTableColumnsTable,
 		sqlbase.CrdbInternalTableIndexesTableID:         crdbInternalTableIndexesTable,
@@ -565,6 +566,50 @@ func (s stmtList) Less(i, j int) bool {
 	return s[i].stmt < s[j].stmt
 }
 
+// crdbInternalStmtDiagnosticsTable exposes the statement diagnostics bundles
+// stored in system.statement_diagnostics, collected either by EXPLAIN ANALYZE
+// (DEBUG) or on demand through crdb_internal.request_statement_bundle(). The
+// pending requests are listed with a NULL id.
+var crdbInternalStmtDiagnosticsTable = virtualSchemaTable{
+	comment: `statement diagnostics bundles and pending requests`,
+	schema: `
+CREATE TABLE crdb_internal.statement_diagnostics (
+  id                    INT,
+  request_id            INT,
+  statement_fingerprint STRING NOT NULL,
+  statement             STRING,
+  collected_at          TIMESTAMP,
+  url                   STRING
+)`,
+	populate: func(ctx context.Context, p *planner, _ *DatabaseDescriptor, addRow func(...tree.Datum) error) error {
+		if err := p.RequireSuperUser(ctx, "read crdb_internal.statement_diagnostics"); err != nil {
+			return err
+		}
+		rows, err := p.ExecCfg().InternalExecutor.Query(
+			ctx, "crdb-internal-statement-diagnostics", p.txn,
+			`SELECT d.id, r.id, d.statement_fingerprint, d.statement, d.collected_at
+  FROM system.statement_diagnostics AS d
+  LEFT JOIN system.statement_diagnostics_requests AS r ON r.statement_diagnostics_id = d.id
+UNION ALL
+SELECT NULL, id, statement_fingerprint, NULL, NULL
+  FROM system.statement_diagnostics_requests WHERE completed = false`,
+		)
+		if err != nil {
+			return err
+		}
+		for _, r := range rows {
+			url := tree.DNull
+			if r[0] != tree.DNull {
+				url = tree.NewDString(fmt.Sprintf("%s%d", StmtBundleURLPrefix, tree.MustBeDInt(r[0])))
+			}
+			if err := addRow(r[0], r[1], r[2], r[3], r[4], url); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
 // TODO(tbg): prefix with node_.
 var crdbInternalStmtStatsTable = virtualSchemaTable{
 	comment: `statement statistics (RAM; local node only)`,
@@ -0,0 +1,168 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package sql
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+)
+
+type createPolicyNode struct {
+	n         *tree.CreatePolicy
+	tableDesc *sqlbase.MutableTableDescriptor
+}
+
+// CreatePolicy adds a row-level security policy to a table.
+// Privileges: ALL on table.
+//   notes: postgres requires ownership of the table.
+func (p *planner) CreatePolicy(ctx context.Context, n *tree.CreatePolicy) (planNode, error) {
+	if err := p.checkRowLevelSecuritySupported("CREATE POLICY"); err != nil {
+		return nil, err
+	}
+
+	tableDesc, err := p.ResolveMutableTableDescriptorEx(ctx, n.Table, true /* required */, ResolveRequireTableDesc)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.checkRowLevelSecurityPrivilege(ctx, tableDesc); err != nil {
+		return nil, err
+	}
+
+	switch n.Command {
+	case privilege.SELECT, privilege.DELETE:
+		if n.WithCheck != nil {
+			return nil, pgerror.New(pgerror.CodeSyntaxError,
+				"WITH CHECK cannot be applied to SELECT or DELETE")
+		}
+	case privilege.INSERT:
+		if n.Using != nil {
+			return nil, pgerror.New(pgerror.CodeSyntaxError,
+				"only WITH CHECK expression allowed for INSERT")
+		}
+	}
+
+	return &createPolicyNode{n: n, tableDesc: tableDesc}, nil
+}
+
+func (n *createPolicyNode) startExec(params runParams) error {
+	tn := params.p.ResolvedName(n.n.Table)
+	if n.tableDesc.FindPolicyByName(string(n.n.Name)) != -1 {
+		return pgerror.Newf(pgerror.CodeDuplicateObjectError,
+			"policy %q for table %q already exists", n.n.Name, n.tableDesc.Name)
+	}
+
+	policy := sqlbase.TableDescriptor_Policy{
+		Name:    string(n.n.Name),
+		Command: policyCommandFromPrivilege(n.n.Command),
+	}
+	for _, role := range n.n.Roles {
+		policy.Roles = append(policy.Roles, string(role))
+	}
+	var err error
+	if n.n.Using != nil {
+		if policy.UsingExpr, err = makePolicyExpr(
+			params, n.tableDesc, n.n.Using, "POLICY USING", *tn,
+		); err != nil {
+			return err
+		}
+	}
+	if n.n.WithCheck != nil {
+		if policy.WithCheckExpr, err = makePolicyExpr(
+			params, n.tableDesc, n.n.WithCheck, "POLICY WITH CHECK", *tn,
+		); err != nil {
+			return err
+		}
+	}
+	n.tableDesc.Policies = append(n.tableDesc.Policies, policy)
+
+	if err := params.p.writeSchemaChange(params.ctx, n.tableDesc, sqlbase.InvalidMutationID); err != nil {
+		return err
+	}
+
+	return logPolicyChange(params, n.tableDesc, tn, n.n)
+}
+
+func (n *createPolicyNode) Next(runParams) (bool, error) { return false, nil }
+func (n *createPolicyNode) Values() tree.Datums          { return tree.Datums{} }
+func (n *createPolicyNode) Close(context.Context)        {}
+
+// makePolicyExpr verifies that the given expression can be used in a policy
+// on the given table, and returns its serialized form for storage in the
+// table descriptor.
+func makePolicyExpr(
+	params runParams,
+	desc *sqlbase.MutableTableDescriptor,
+	expr tree.Expr,
+	context string,
+	tableName tree.TableName,
+) (string, error) {
+	// Policies are evaluated as part of every query that scans the table, so
+	// they can't contain subqueries.
+	if _, err := tree.SimpleVisit(expr, func(expr tree.Expr) (bool, tree.Expr, error) {
+		if _, ok := expr.(*tree.Subquery); ok {
+			return false, nil, pgerror.Newf(pgerror.CodeFeatureNotSupportedError,
+				"subqueries are not allowed in %s", context)
+		}
+		return true, expr, nil
+	}); err != nil {
+		return "", err
+	}
+
+	replacedExpr, _, err := replaceVars(desc, expr)
+	if err != nil {
+		return "", err
+	}
+	if _, err := sqlbase.SanitizeVarFreeExpr(
+		replacedExpr, types.Bool, context, &params.p.semaCtx, true, /* allowImpure */
+	); err != nil {
+		return "", err
+	}
+
+	sourceInfo := sqlbase.NewSourceInfoForSingleTable(
+		tableName, sqlbase.ResultColumnsFromColDescs(desc.TableDesc().AllNonDropColumns()),
+	)
+	expr, err = dequalifyColumnRefs(params.ctx, sqlbase.MultiSourceInfo{sourceInfo}, expr)
+	if err != nil {
+		return "", err
+	}
+	return tree.Serialize(expr), nil
+}
+
+// logPolicyChange records an event for a statement that created or dropped a
+// policy on a table. It is recorded in the same transaction as the table
+// descriptor update.
+func logPolicyChange(
+	params runParams,
+	desc *sqlbase.MutableTableDescriptor,
+	tn *tree.TableName,
+	stmt tree.Statement,
+) error {
+	return MakeEventLogger(params.extendedEvalCtx.ExecCfg).InsertEventRecord(
+		params.ctx,
+		params.p.txn,
+		EventLogAlterTable,
+		int32(desc.ID),
+		int32(params.extendedEvalCtx.NodeID),
+		struct {
+			TableName string
+			Statement string
+			User      string
+		}{tn.FQString(), stmt.String(), params.SessionData().User},
+	)
+}
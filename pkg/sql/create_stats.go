@@ -269,20 +269,46 @@ const maxNonIndexCols = 100
 //
 // In addition to the index columns, we collect stats on up to maxNonIndexCols
 // other columns from the table.
-//
-// TODO(rytaft): This currently only generates one single-column stat per
-// index. Add code to collect multi-column stats once they are supported.
 func createStatsDefaultColumns(
 	desc *ImmutableTableDescriptor,
 ) ([]jobspb.CreateStatsDetails_ColList, error) {
 	columns := make([]jobspb.CreateStatsDetails_ColList, 0, len(desc.Indexes)+1)
 
 	var requestedCols util.FastIntSet
+	// requestedMultiCols contains the column sets of the multi-column stats
+	// requested so far, to avoid collecting the same stat twice (e.g. for
+	// indexes on (a, b) and (b, a)).
+	requestedMultiCols := make(map[string]struct{})
+
+	// addIndexColumnStats adds stats on the prefixes of the index columns.
+	addIndexColumnStats := func(idx *sqlbase.IndexDescriptor) {
+		var prefixCols util.FastIntSet
+		for i, colID := range idx.ColumnIDs {
+			prefixCols.Add(int(colID))
+			if i == 0 {
+				if !requestedCols.Contains(int(colID)) {
+					columns = append(
+						columns, jobspb.CreateStatsDetails_ColList{IDs: []sqlbase.ColumnID{colID}},
+					)
+					requestedCols.Add(int(colID))
+				}
+				continue
+			}
+			key := prefixCols.String()
+			if _, ok := requestedMultiCols[key]; ok || prefixCols.Len() < i+1 {
+				// The stat was already requested, or the index contains the same
+				// column twice.
+				continue
+			}
+			requestedMultiCols[key] = struct{}{}
+			colIDs := make([]sqlbase.ColumnID, i+1)
+			copy(colIDs, idx.ColumnIDs[:i+1])
+			columns = append(columns, jobspb.CreateStatsDetails_ColList{IDs: colIDs})
+		}
+	}
 
-	// Add a column for the primary key.
-	pkCol := desc.PrimaryIndex.ColumnIDs[0]
-	columns = append(columns, jobspb.CreateStatsDetails_ColList{IDs: []sqlbase.ColumnID{pkCol}})
-	requestedCols.Add(int(pkCol))
+	// Add columns for the primary key.
+	addIndexColumnStats(&desc.PrimaryIndex)
 
 	// Add columns for each secondary index.
 	for i := range desc.Indexes {
@@ -290,13 +316,7 @@ func createStatsDefaultColumns(
 			// We don't yet support stats on inverted indexes.
 			continue
 		}
-		idxCol := desc.Indexes[i].ColumnIDs[0]
-		if !requestedCols.Contains(int(idxCol)) {
-			columns = append(
-				columns, jobspb.CreateStatsDetails_ColList{IDs: []sqlbase.ColumnID{idxCol}},
-			)
-			requestedCols.Add(int(idxCol))
-		}
+		addIndexColumnStats(&desc.Indexes[i])
 	}
 
 	// Add all remaining non-json columns in the table, up to maxNonIndexCols.
@@ -17,6 +17,7 @@ import (
 	"regexp"
 
 	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
@@ -70,7 +71,7 @@ func (p *planner) CreateUserNode(
 }
 
 func (n *CreateUserNode) startExec(params runParams) error {
-	normalizedUsername, hashedPassword, err := n.userAuthInfo.resolve()
+	normalizedUsername, hashedPassword, err := n.userAuthInfo.resolve(params.extendedEvalCtx.ExecCfg.Settings)
 	if err != nil {
 		return err
 	}
@@ -210,7 +211,7 @@ func (p *planner) getUserAuthInfo(nameE, passwordE tree.Expr, ctx string) (userA
 }
 
 // resolve returns the actual user name and (hashed) password.
-func (ua *userAuthInfo) resolve() (string, []byte, error) {
+func (ua *userAuthInfo) resolve(st *cluster.Settings) (string, []byte, error) {
 	name, err := ua.name()
 	if err != nil {
 		return "", nil, err
@@ -233,7 +234,7 @@ func (ua *userAuthInfo) resolve() (string, []byte, error) {
 			return "", nil, security.ErrEmptyPassword
 		}
 
-		hashedPassword, err = security.HashPassword(resolvedPassword)
+		hashedPassword, err = hashPassword(st, resolvedPassword)
 		if err != nil {
 			return "", nil, err
 		}
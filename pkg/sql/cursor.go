@@ -0,0 +1,370 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package sql
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/fsm"
+	"github.com/pkg/errors"
+)
+
+// errPortalClosed is returned by portalExec.AddRow to stop the statement of a
+// portal that is closed while suspended.
+var errPortalClosed = errors.New("portal closed")
+
+// portalExec is the execution of the statement of a portal that can be
+// suspended: a portal executed with a row limit inside an explicit
+// transaction, or a cursor created with DECLARE.
+//
+// The statement runs in its own goroutine, with its own planner, and its flow
+// is kept open while the portal is suspended. Once an execution of the portal
+// (or a FETCH) has returned the rows it asked for, the goroutine blocks when
+// the next row is produced, until the portal is executed again or closed.
+// Control is handed back and forth between the connExecutor's goroutine and
+// the portal's, so they never run at the same time; several portals can be
+// suspended while other statements run in the transaction.
+//
+// The flow of a suspended portal holds on to the memory it uses, and is never
+// distributed so that no remote flows keep running in the meantime. Unlike in
+// Postgres, rows written by the transaction after the portal was first
+// executed can be visible to the rest of its statement.
+//
+// portalExec is also the RestrictedCommandResult of its statement: it forwards
+// everything to the result of the current execution of the portal.
+type portalExec struct {
+	ex    *connExecutor
+	stmt  Statement
+	pinfo *tree.PlaceholderInfo
+	// p is the planner used by the statement. It can't be the connExecutor's
+	// planner, which is reset by the statements that run while the portal is
+	// suspended.
+	p planner
+	// cursor is set if the portal backs a cursor. The DECLARE statement that
+	// starts a cursor's statement doesn't return rows, so the statement's
+	// columns are not sent to it.
+	cursor bool
+
+	// cols are the statement's result columns. They are set when the statement
+	// starts running.
+	cols sqlbase.ResultColumns
+	// res is the result of the current execution of the portal.
+	res RestrictedCommandResult
+	// limit is the number of rows returned by the current execution of the
+	// portal before it is suspended. All rows are returned if it is negative.
+	limit int
+	// sent is the number of rows returned by the current execution.
+	sent int
+
+	started, done bool
+	// stopping is set when the portal is closed while it is suspended. Its
+	// statement is then resumed, and stopped by failing to add more rows.
+	stopping bool
+	// resumeCh is used by the connExecutor to resume the statement of a
+	// suspended portal.
+	resumeCh chan struct{}
+	// yieldCh is used by the statement's goroutine to hand control back to the
+	// connExecutor: true is sent when the portal is suspended, and false when
+	// the statement has finished.
+	yieldCh chan bool
+
+	// ev, payload and err are the outcome of the statement, as returned by
+	// execStmt.
+	ev      fsm.Event
+	payload fsm.EventPayload
+	err     error
+	// panicked is set if the statement panicked. The panic is propagated to the
+	// connExecutor's goroutine, which reports it.
+	panicked interface{}
+}
+
+var _ RestrictedCommandResult = &portalExec{}
+
+func (ex *connExecutor) newPortalExec(
+	ctx context.Context, stmt Statement, pinfo *tree.PlaceholderInfo, cursor bool,
+) *portalExec {
+	pe := &portalExec{
+		ex:       ex,
+		stmt:     stmt,
+		pinfo:    pinfo,
+		cursor:   cursor,
+		resumeCh: make(chan struct{}),
+		yieldCh:  make(chan bool),
+	}
+	ex.initPlanner(ctx, &pe.p)
+	return pe
+}
+
+// run starts the statement, or resumes it if the portal is suspended, and
+// sends its rows to res until limit rows have been sent (all of them if limit
+// is negative). It returns true if the portal was suspended because more rows
+// are left. Otherwise the statement has finished, and its outcome is returned
+// by the execution that finished it.
+//
+// run must only be called in the Open state.
+func (pe *portalExec) run(
+	ctx context.Context, res RestrictedCommandResult, limit int,
+) (suspended bool, _ fsm.Event, _ fsm.EventPayload, _ error) {
+	pe.res, pe.limit, pe.sent = res, limit, 0
+	if pe.done {
+		res.SetColumns(ctx, pe.cols)
+		return false, nil, nil, nil
+	}
+	if !pe.started {
+		pe.started = true
+		// The statement outlives the command that starts it, so it runs in the
+		// transaction's context.
+		stmtCtx := withStatement(pe.ex.Ctx(), pe.stmt.AST)
+		go func() {
+			defer func() {
+				pe.panicked = recover()
+				pe.yieldCh <- false
+			}()
+			pe.ev, pe.payload, pe.err = pe.ex.execStmt(stmtCtx, pe.stmt, pe, pe.pinfo)
+		}()
+	} else {
+		res.SetColumns(ctx, pe.cols)
+		pe.resumeCh <- struct{}{}
+	}
+	if suspended := pe.wait(); suspended {
+		return true, nil, nil, nil
+	}
+	ev, payload, err := pe.ev, pe.payload, pe.err
+	pe.ev, pe.payload, pe.err = nil, nil, nil
+	return false, ev, payload, err
+}
+
+// close stops the statement if the portal is suspended. The portal returns no
+// more rows afterwards.
+func (pe *portalExec) close() {
+	if !pe.started || pe.done {
+		return
+	}
+	// Whatever the statement reports while it stops is discarded.
+	pe.res = &bufferedCommandResult{}
+	pe.stopping = true
+	pe.resumeCh <- struct{}{}
+	pe.wait()
+}
+
+// wait blocks until the statement's goroutine hands control back, and returns
+// true if the portal was suspended.
+func (pe *portalExec) wait() (suspended bool) {
+	if suspended := <-pe.yieldCh; suspended {
+		return true
+	}
+	pe.done = true
+	if pe.panicked != nil {
+		panic(pe.panicked)
+	}
+	return false
+}
+
+// SetColumns is part of the RestrictedCommandResult interface.
+func (pe *portalExec) SetColumns(ctx context.Context, cols sqlbase.ResultColumns) {
+	pe.cols = cols
+	if !pe.cursor {
+		pe.res.SetColumns(ctx, cols)
+	}
+}
+
+// ResetStmtType is part of the RestrictedCommandResult interface.
+func (pe *portalExec) ResetStmtType(stmt tree.Statement) {
+	pe.res.ResetStmtType(stmt)
+}
+
+// AddRow is part of the RestrictedCommandResult interface. If the current
+// execution of the portal has returned all the rows it asked for, AddRow
+// suspends the portal and blocks until it is resumed.
+func (pe *portalExec) AddRow(ctx context.Context, row tree.Datums) error {
+	if pe.sent == pe.limit && !pe.stopping {
+		pe.yieldCh <- true
+		<-pe.resumeCh
+	}
+	if pe.stopping {
+		return errPortalClosed
+	}
+	pe.sent++
+	return pe.res.AddRow(ctx, row)
+}
+
+// IncrementRowsAffected is part of the RestrictedCommandResult interface.
+func (pe *portalExec) IncrementRowsAffected(n int) {
+	pe.res.IncrementRowsAffected(n)
+}
+
+// RowsAffected is part of the RestrictedCommandResult interface.
+func (pe *portalExec) RowsAffected() int {
+	return pe.res.RowsAffected()
+}
+
+// DisableBuffering is part of the RestrictedCommandResult interface.
+func (pe *portalExec) DisableBuffering() {
+	pe.res.DisableBuffering()
+}
+
+// SetError is part of the RestrictedCommandResult interface.
+func (pe *portalExec) SetError(err error) {
+	pe.res.SetError(err)
+}
+
+// Err is part of the RestrictedCommandResult interface.
+func (pe *portalExec) Err() error {
+	return pe.res.Err()
+}
+
+// canSuspendPortal returns true if executing the given portal with a row limit
+// can suspend it once the limit is reached, instead of failing if more rows
+// are produced. This is only possible inside explicit transactions, as
+// implicit ones are committed as soon as the statement has run, and only for
+// SELECT statements: other statements returning rows, like mutations with
+// RETURNING, are not paused halfway through their writes.
+func (ex *connExecutor) canSuspendPortal(portal *PreparedPortal) bool {
+	if _, ok := ex.machine.CurState().(stateOpen); !ok || ex.implicitTxn() {
+		return false
+	}
+	_, ok := portal.Stmt.AST.(*tree.Select)
+	return ok
+}
+
+// resumePortal returns at most limit rows (all of them if limit is 0) of a
+// portal that can be suspended, starting its statement if needed. If rows are
+// left over, res is marked as suspended. Once the statement finishes, its
+// outcome is returned like by execStmt.
+func (ex *connExecutor) resumePortal(
+	ctx context.Context, portal *PreparedPortal, limit int, res CommandResult,
+) (fsm.Event, fsm.EventPayload, error) {
+	if limit == 0 {
+		limit = -1
+	}
+	suspended, ev, payload, err := portal.exec.run(ctx, res, limit)
+	if suspended {
+		res.SetPortalSuspended()
+	}
+	return ev, payload, err
+}
+
+// stopSuspendedPortals stops the statements of all suspended portals. This
+// needs to happen before the transaction finishes or restarts, since their
+// flows use it and its memory monitor.
+func (ex *connExecutor) stopSuspendedPortals() {
+	for _, portal := range ex.extraTxnState.prepStmtsNamespace.portals {
+		if portal.exec != nil {
+			portal.exec.close()
+		}
+	}
+}
+
+// execDeclareCursor runs a DECLARE statement. The cursor's query is started
+// and suspended before it returns its first row; the rows are then returned
+// by FETCH statements.
+func (ex *connExecutor) execDeclareCursor(
+	ctx context.Context,
+	s *tree.DeclareCursor,
+	stmt Statement,
+	pinfo *tree.PlaceholderInfo,
+	res RestrictedCommandResult,
+) (fsm.Event, fsm.EventPayload, error) {
+	if ex.implicitTxn() {
+		ev, payload := ex.makeErrEvent(pgerror.New(pgerror.CodeNoActiveSQLTransactionError,
+			"DECLARE CURSOR can only be used in transaction blocks"), s)
+		return ev, payload, nil
+	}
+	name := string(s.Name)
+	if _, ok := ex.extraTxnState.prepStmtsNamespace.portals[name]; ok {
+		ev, payload := ex.makeErrEvent(pgerror.Newf(pgerror.CodeDuplicateCursorError,
+			"cursor %q already exists", name), s)
+		return ev, payload, nil
+	}
+	prepared := &PreparedStatement{
+		PrepareMetadata: sqlbase.PrepareMetadata{
+			Statement: parser.Statement{
+				SQL:             tree.AsStringWithFlags(s.Select, tree.FmtParsable),
+				AST:             s.Select,
+				NumPlaceholders: stmt.NumPlaceholders,
+				NumAnnotations:  stmt.NumAnnotations,
+			},
+			AnonymizedStr: anonymizeStmt(s.Select),
+		},
+		memAcc:   ex.sessionMon.MakeBoundAccount(),
+		refCount: 1,
+	}
+	portal, err := ex.newPreparedPortal(
+		ctx, name, prepared, nil /* qargs */, nil, /* outFormats */
+	)
+	// The portal holds its own reference to prepared.
+	prepared.decRef(ctx)
+	if err != nil {
+		ev, payload := ex.makeErrEvent(err, s)
+		return ev, payload, nil
+	}
+	portal.exec = ex.newPortalExec(ctx, Statement{
+		Statement:     prepared.Statement,
+		AnonymizedStr: prepared.AnonymizedStr,
+	}, pinfo, true /* cursor */)
+
+	_, ev, payload, err := portal.exec.run(ctx, res, 0 /* limit */)
+	if err != nil || ev != nil {
+		portal.decRef(ctx)
+		return ev, payload, err
+	}
+	portal.Stmt.Columns = portal.exec.cols
+	ex.extraTxnState.prepStmtsNamespace.portals[name] = portal
+	return nil, nil, nil
+}
+
+// execFetchCursor runs a FETCH statement. Once the cursor's query finishes,
+// its outcome is returned like by execStmt.
+func (ex *connExecutor) execFetchCursor(
+	ctx context.Context, s *tree.FetchCursor, res RestrictedCommandResult,
+) (fsm.Event, fsm.EventPayload, error) {
+	name := string(s.Name)
+	portal, ok := ex.extraTxnState.prepStmtsNamespace.portals[name]
+	if !ok || portal.exec == nil {
+		ev, payload := ex.makeErrEvent(pgerror.Newf(pgerror.CodeInvalidCursorNameError,
+			"cursor %q does not exist", name), s)
+		return ev, payload, nil
+	}
+	limit := -1
+	if !s.All {
+		if s.Count <= 0 {
+			ev, payload := ex.makeErrEvent(pgerror.New(pgerror.CodeObjectNotInPrerequisiteStateError,
+				"cursor can only scan forward"), s)
+			return ev, payload, nil
+		}
+		limit = int(s.Count)
+	}
+	_, ev, payload, err := portal.exec.run(ctx, res, limit)
+	return ev, payload, err
+}
+
+// execCloseCursor runs a CLOSE statement.
+func (ex *connExecutor) execCloseCursor(ctx context.Context, s *tree.CloseCursor) error {
+	if s.Name == "" {
+		for name := range ex.extraTxnState.prepStmtsNamespace.portals {
+			ex.deletePortal(ctx, name)
+		}
+		return nil
+	}
+	name := string(s.Name)
+	if _, ok := ex.extraTxnState.prepStmtsNamespace.portals[name]; !ok {
+		return pgerror.Newf(pgerror.CodeInvalidCursorNameError,
+			"cursor %q does not exist", name)
+	}
+	ex.deletePortal(ctx, name)
+	return nil
+}
@@ -325,6 +325,9 @@ func (p *planner) getPlanForDesc(
 	}
 
 	// This name designates a real table.
+	if err := p.checkRowLevelSecurityUnsupported(ctx, desc.TableDesc()); err != nil {
+		return planDataSource{}, err
+	}
 	scan := p.Scan()
 	if err := scan.initTable(ctx, p, desc, indexFlags, colCfg); err != nil {
 		return planDataSource{}, err
@@ -38,6 +38,9 @@ func TryDelegate(
 		evalCtx: evalCtx,
 	}
 	switch t := stmt.(type) {
+	case *tree.CopyTo:
+		return d.delegateCopyTo(t)
+
 	case *tree.ShowAllClusterSettings:
 		return d.delegateShowAllClusterSettings(t)
 
@@ -86,6 +89,9 @@ func TryDelegate(
 	case *tree.ShowSessions:
 		return d.delegateShowSessions(t)
 
+	case *tree.ShowStmtHints:
+		return d.delegateShowStmtHints(t)
+
 	case *tree.ShowSyntax:
 		return d.delegateShowSyntax(t)
 
@@ -300,6 +300,7 @@ func (dsp *DistSQLPlanner) mustWrapNode(planCtx *PlanningCtx, node planNode) boo
 	case *scanNode:
 	case *indexJoinNode:
 	case *lookupJoinNode:
+	case *invertedJoinNode:
 	case *zigzagJoinNode:
 	case *joinNode:
 	case *renderNode:
@@ -419,6 +420,12 @@ func (dsp *DistSQLPlanner) checkSupportForNode(node planNode) (distRecommendatio
 		}
 		return shouldDistribute, nil
 
+	case *invertedJoinNode:
+		if _, err := dsp.checkSupportForNode(n.input); err != nil {
+			return cannotDistribute, err
+		}
+		return shouldDistribute, nil
+
 	case *zigzagJoinNode:
 		if err := dsp.checkExpr(n.onCond); err != nil {
 			return cannotDistribute, err
@@ -1986,6 +1993,68 @@ func (dsp *DistSQLPlanner) createPlanForLookupJoin(
 	return plan, nil
 }
 
+// createPlanForInvertedJoin creates a distributed plan for an invertedJoinNode.
+func (dsp *DistSQLPlanner) createPlanForInvertedJoin(
+	planCtx *PlanningCtx, n *invertedJoinNode,
+) (PhysicalPlan, error) {
+	plan, err := dsp.createPlanForNode(planCtx, n.input)
+	if err != nil {
+		return PhysicalPlan{}, err
+	}
+
+	invertedJoinerSpec := distsqlpb.InvertedJoinerSpec{
+		Table: *n.table.desc.TableDesc(),
+	}
+	invertedJoinerSpec.IndexIdx, err = getIndexIdx(n.table)
+	if err != nil {
+		return PhysicalPlan{}, err
+	}
+	if plan.PlanToStreamColMap[n.inputCol] == -1 {
+		panic("inverted join input column not in planToStreamColMap")
+	}
+	invertedJoinerSpec.InputColumn = uint32(plan.PlanToStreamColMap[n.inputCol])
+
+	// The n.table node is configured with the primary key columns. Apply the
+	// corresponding projection.
+	// The internal schema of the inverted joiner is:
+	//    <input columns>... <table columns>...
+	numLeftCols := len(plan.ResultTypes)
+	numOutCols := numLeftCols + len(n.table.cols)
+	post := distsqlpb.PostProcessSpec{Projection: true}
+
+	post.OutputColumns = make([]uint32, numOutCols)
+	types := make([]types.T, numOutCols)
+
+	for i := 0; i < numLeftCols; i++ {
+		types[i] = plan.ResultTypes[i]
+		post.OutputColumns[i] = uint32(i)
+	}
+	for i := range n.table.cols {
+		types[numLeftCols+i] = n.table.cols[i].Type
+		ord := tableOrdinal(n.table.desc, n.table.cols[i].ID, n.table.colCfg.visibility)
+		post.OutputColumns[numLeftCols+i] = uint32(numLeftCols + ord)
+	}
+
+	// Map the columns of the invertedJoinNode to the result streams of the
+	// InvertedJoiner.
+	planToStreamColMap := makePlanToStreamColMap(len(n.columns))
+	copy(planToStreamColMap, plan.PlanToStreamColMap)
+	numInputNodeCols := len(planColumns(n.input))
+	for i := range n.table.cols {
+		planToStreamColMap[numInputNodeCols+i] = numLeftCols + i
+	}
+
+	// Instantiate one inverted joiner for every stream.
+	plan.AddNoGroupingStage(
+		distsqlpb.ProcessorCoreUnion{InvertedJoiner: &invertedJoinerSpec},
+		post,
+		types,
+		distsqlpb.Ordering{},
+	)
+	plan.PlanToStreamColMap = planToStreamColMap
+	return plan, nil
+}
+
 // createPlanForZigzagJoin creates a distributed plan for a zigzagJoinNode.
 func (dsp *DistSQLPlanner) createPlanForZigzagJoin(
 	planCtx *PlanningCtx, n *zigzagJoinNode,
@@ -2301,6 +2370,9 @@ func (dsp *DistSQLPlanner) createPlanForNode(
 	case *lookupJoinNode:
 		plan, err = dsp.createPlanForLookupJoin(planCtx, n)
 
+	case *invertedJoinNode:
+		plan, err = dsp.createPlanForInvertedJoin(planCtx, n)
+
 	case *zigzagJoinNode:
 		plan, err = dsp.createPlanForZigzagJoin(planCtx, n)
 
@@ -2828,6 +2900,10 @@ func (dsp *DistSQLPlanner) isOnlyOnGateway(plan *PhysicalPlan) bool {
 func (dsp *DistSQLPlanner) createPlanForSetOp(
 	planCtx *PlanningCtx, n *unionNode,
 ) (PhysicalPlan, error) {
+	if n.hardLimit != 0 {
+		return dsp.createPlanForLocalityOptimizedSearch(planCtx, n)
+	}
+
 	leftLogicalPlan := n.left
 	leftPlan, err := dsp.createPlanForNode(planCtx, n.left)
 	if err != nil {
@@ -3073,6 +3149,96 @@ func (dsp *DistSQLPlanner) createPlanForSetOp(
 	return p, nil
 }
 
+// createPlanForLocalityOptimizedSearch creates a physical plan for a unionNode
+// which implements a locality optimized search: the rows of the local input
+// are returned first, and the remote input is only read if the local input
+// returns fewer rows than the limit.
+//
+// Both inputs are planned on the gateway node (their TableReaders may still
+// read from remote ranges), and are connected to a no-op processor through a
+// serial synchronizer, which reads its input streams one after the other. The
+// processors of the inputs are fused with the synchronizer, which only starts
+// the remote input once the local input is exhausted; if the limit is reached
+// before that, the remote input is never executed.
+//
+//   Plan:
+//   TableReader (local)  TableReader (remote)
+//          |                   |
+//           -------------------
+//                    |
+//           No-op (serial input, limit)
+func (dsp *DistSQLPlanner) createPlanForLocalityOptimizedSearch(
+	planCtx *PlanningCtx, n *unionNode,
+) (PhysicalPlan, error) {
+	defer func(isLocal bool) {
+		planCtx.isLocal = isLocal
+	}(planCtx.isLocal)
+	planCtx.isLocal = true
+
+	localLogicalPlan, remoteLogicalPlan := n.left, n.right
+	if n.inverted {
+		localLogicalPlan, remoteLogicalPlan = remoteLogicalPlan, localLogicalPlan
+	}
+	localPlan, err := dsp.createPlanForNode(planCtx, localLogicalPlan)
+	if err != nil {
+		return PhysicalPlan{}, err
+	}
+	remotePlan, err := dsp.createPlanForNode(planCtx, remoteLogicalPlan)
+	if err != nil {
+		return PhysicalPlan{}, err
+	}
+
+	if !reflect.DeepEqual(localPlan.PlanToStreamColMap, remotePlan.PlanToStreamColMap) {
+		return PhysicalPlan{}, errors.Errorf(
+			"planToStreamColMap mismatch: %v, %v", localPlan.PlanToStreamColMap,
+			remotePlan.PlanToStreamColMap)
+	}
+	resultTypes, err := distsqlplan.MergeResultTypes(localPlan.ResultTypes, remotePlan.ResultTypes)
+	if err != nil {
+		return PhysicalPlan{}, err
+	}
+
+	var p PhysicalPlan
+	var localRouters, remoteRouters []distsqlplan.ProcessorIdx
+	p.PhysicalPlan, localRouters, remoteRouters = distsqlplan.MergePlans(
+		&localPlan.PhysicalPlan, &remotePlan.PhysicalPlan)
+	p.PlanToStreamColMap = localPlan.PlanToStreamColMap
+
+	pIdx := p.AddProcessor(distsqlplan.Processor{
+		Node: dsp.nodeDesc.NodeID,
+		Spec: distsqlpb.ProcessorSpec{
+			Input: []distsqlpb.InputSyncSpec{{
+				Type:        distsqlpb.InputSyncSpec_SERIAL_UNORDERED,
+				ColumnTypes: resultTypes,
+			}},
+			Core: distsqlpb.ProcessorCoreUnion{Noop: &distsqlpb.NoopCoreSpec{}},
+			Post: distsqlpb.PostProcessSpec{Limit: n.hardLimit},
+			Output: []distsqlpb.OutputRouterSpec{{
+				Type: distsqlpb.OutputRouterSpec_PASS_THROUGH,
+			}},
+			StageID: p.NewStageID(),
+		},
+	})
+
+	// The serial synchronizer reads the streams in the order in which they are
+	// added to its input, so add the streams of the local input first.
+	for _, routers := range [][]distsqlplan.ProcessorIdx{localRouters, remoteRouters} {
+		for _, resultProc := range routers {
+			p.Streams = append(p.Streams, distsqlplan.Stream{
+				SourceProcessor:  resultProc,
+				SourceRouterSlot: 0,
+				DestProcessor:    pIdx,
+				DestInput:        0,
+			})
+		}
+	}
+
+	p.ResultRouters = []distsqlplan.ProcessorIdx{pIdx}
+	p.ResultTypes = resultTypes
+	p.SetMergeOrdering(distsqlpb.Ordering{})
+	return p, nil
+}
+
 // createPlanForWindow creates a physical plan for computing window functions.
 // We add a new stage of windower processors for each different partitioning
 // scheme found in the query's window functions.
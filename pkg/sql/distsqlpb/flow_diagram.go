@@ -182,6 +182,19 @@ func (jr *JoinReaderSpec) summary() (string, []string) {
 	return "JoinReader", details
 }
 
+// summary implements the diagramCellType interface.
+func (ij *InvertedJoinerSpec) summary() (string, []string) {
+	index := "primary"
+	if ij.IndexIdx > 0 {
+		index = ij.Table.Indexes[ij.IndexIdx-1].Name
+	}
+	details := []string{
+		fmt.Sprintf("%s@%s", index, ij.Table.Name),
+		fmt.Sprintf("Input column: %s", colListStr([]uint32{ij.InputColumn})),
+	}
+	return "InvertedJoiner", details
+}
+
 func joinTypeDetail(joinType sqlbase.JoinType) string {
 	typeStr := strings.Replace(joinType.String(), "_", " ", -1)
 	if joinType == sqlbase.IntersectAllJoin || joinType == sqlbase.ExceptAllJoin {
@@ -358,6 +371,8 @@ func (is *InputSyncSpec) summary() (string, []string) {
 		return "unordered", []string{}
 	case InputSyncSpec_ORDERED:
 		return "ordered", []string{is.Ordering.diagramString()}
+	case InputSyncSpec_SERIAL_UNORDERED:
+		return "serial", []string{}
 	default:
 		return "unknown", []string{}
 	}
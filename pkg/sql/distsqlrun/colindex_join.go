@@ -0,0 +1,254 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package distsqlrun
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/distsqlpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/exec"
+	"github.com/cockroachdb/cockroach/pkg/sql/exec/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/row"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/pkg/errors"
+)
+
+// colIndexJoin is the exec.Operator implementation of the index joiner. It
+// reads the primary keys from its input, looks up the corresponding rows of
+// the primary index in batches of spans and emits the coldata.Batches of the
+// fetcher directly. The rows are emitted in the order of the input.
+type colIndexJoin struct {
+	input   exec.Operator
+	flowCtx *FlowCtx
+	rf      *row.CFetcher
+	ctx     context.Context
+
+	// keyCols generates the spans of the primary index out of the first
+	// columns of the input.
+	keyCols colSpanGenerator
+	// batchSize is the minimum number of spans looked up at once. Not a
+	// constant so we can lower it for testing.
+	batchSize int
+	spans     roachpb.Spans
+	// fetcherReady indicates that we have started a scan of the primary index
+	// and there are potentially more rows to retrieve.
+	fetcherReady bool
+	inputDone    bool
+
+	zeroBatch coldata.Batch
+}
+
+var _ exec.Operator = &colIndexJoin{}
+
+func (s *colIndexJoin) Init() {
+	s.ctx = context.Background()
+	s.input.Init()
+}
+
+func (s *colIndexJoin) Next(ctx context.Context) coldata.Batch {
+	for {
+		if !s.fetcherReady {
+			s.spans = s.spans[:0]
+			// Read whole batches of the input until there are enough spans.
+			for !s.inputDone && len(s.spans) < s.batchSize {
+				batch := s.input.Next(ctx)
+				if batch.Length() == 0 {
+					s.inputDone = true
+					break
+				}
+				var err error
+				s.spans, err = s.keyCols.appendSpans(s.spans, batch)
+				if err != nil {
+					panic(err)
+				}
+			}
+			if len(s.spans) == 0 {
+				return s.zeroBatch
+			}
+			if err := s.rf.StartScan(
+				s.ctx, s.flowCtx.txn, s.spans,
+				false /* limitBatches */, 0 /* limitHint */, s.flowCtx.traceKV,
+			); err != nil {
+				panic(err)
+			}
+			s.fetcherReady = true
+		}
+		bat, err := s.rf.NextBatch(ctx)
+		if err != nil {
+			panic(err)
+		}
+		if bat.Length() == 0 {
+			// Done with this batch of spans.
+			s.fetcherReady = false
+			continue
+		}
+		bat.SetSelection(false)
+		return bat
+	}
+}
+
+// DrainMeta is part of the MetadataSource interface.
+func (s *colIndexJoin) DrainMeta(ctx context.Context) []distsqlpb.ProducerMetadata {
+	if meta := getTxnCoordMeta(ctx, s.flowCtx.txn); meta != nil {
+		return []distsqlpb.ProducerMetadata{{TxnCoordMeta: meta}}
+	}
+	return nil
+}
+
+// newColIndexJoin creates a new colIndexJoin operator performing the index
+// join described by spec over input, which has columns of the given types.
+func newColIndexJoin(
+	flowCtx *FlowCtx,
+	spec *distsqlpb.JoinReaderSpec,
+	input exec.Operator,
+	inputTypes []types.T,
+	post *distsqlpb.PostProcessSpec,
+) (*colIndexJoin, error) {
+	if spec.IndexIdx != 0 {
+		return nil, errors.Errorf("index join must be against primary index")
+	}
+	numKeyCols := len(spec.Table.PrimaryIndex.ColumnIDs)
+	if len(inputTypes) < numKeyCols {
+		return nil, errors.Errorf(
+			"index join input has %d columns, expected at least %d", len(inputTypes), numKeyCols)
+	}
+
+	returnMutations := spec.Visibility == distsqlpb.ScanVisibility_PUBLIC_AND_NOT_PUBLIC
+	typs := spec.Table.ColumnTypesWithMutations(returnMutations)
+	helper := ProcOutputHelper{}
+	if err := helper.Init(
+		post,
+		typs,
+		flowCtx.NewEvalCtx(),
+		nil,
+	); err != nil {
+		return nil, err
+	}
+
+	fetcher := row.CFetcher{}
+	if _, _, err := initCRowFetcher(
+		&fetcher, &spec.Table, 0 /* primary index */, spec.Table.ColumnIdxMapWithMutations(returnMutations),
+		false /* reverse */, helper.neededColumns(), false /* isCheck */, spec.Visibility,
+	); err != nil {
+		return nil, err
+	}
+
+	// There may be extra columns in the input, e.g. to allow an ordered
+	// synchronizer to interleave multiple input streams. Only the first
+	// numKeyCols columns are the primary key.
+	keyCols := make([]uint32, numKeyCols)
+	for i := range keyCols {
+		keyCols[i] = uint32(i)
+	}
+	s := &colIndexJoin{
+		input:     input,
+		flowCtx:   flowCtx,
+		rf:        &fetcher,
+		batchSize: indexJoinerBatchSize,
+		zeroBatch: coldata.NewMemBatchWithSize(nil /* types */, 0 /* size */),
+	}
+	s.keyCols.init(&spec.Table, &spec.Table.PrimaryIndex, keyCols, inputTypes, nil /* encTypes */)
+	return s, nil
+}
+
+// colSpanGenerator generates the spans of an index which contain the keys
+// made of the values of some columns of coldata.Batches.
+type colSpanGenerator struct {
+	desc      *sqlbase.TableDescriptor
+	index     *sqlbase.IndexDescriptor
+	keyPrefix []byte
+	// keyCols are the indices of the columns containing the values of the
+	// first len(keyCols) columns of the index, and keyTypes are the types of
+	// these columns.
+	keyCols  []uint32
+	keyTypes []types.T
+	// encTypes are the types the values are encoded with.
+	encTypes  []types.T
+	indexDirs []sqlbase.IndexDescriptor_Direction
+
+	// Scratch space.
+	keyRow sqlbase.EncDatumRow
+	alloc  sqlbase.DatumAlloc
+}
+
+// init initializes the generator; colTypes are the types of the columns of
+// the batches. The values are encoded with the types of the columns of the
+// batches unless encTypes is set.
+func (g *colSpanGenerator) init(
+	desc *sqlbase.TableDescriptor,
+	index *sqlbase.IndexDescriptor,
+	keyCols []uint32,
+	colTypes []types.T,
+	encTypes []types.T,
+) {
+	g.desc = desc
+	g.index = index
+	g.keyPrefix = sqlbase.MakeIndexKeyPrefix(desc, index.ID)
+	g.keyCols = keyCols
+	g.keyTypes = make([]types.T, len(keyCols))
+	for i, colIdx := range keyCols {
+		g.keyTypes[i] = colTypes[colIdx]
+	}
+	g.encTypes = encTypes
+	if g.encTypes == nil {
+		g.encTypes = g.keyTypes
+	}
+	_, g.indexDirs = index.FullColumnIDs()
+	g.keyRow = make(sqlbase.EncDatumRow, len(keyCols))
+}
+
+// makeSpan returns the span for the rowIdx'th tuple of vecs. The second
+// return value is false if one of the key columns is NULL, in which case the
+// tuple cannot match any row of the index.
+func (g *colSpanGenerator) makeSpan(vecs []coldata.Vec, rowIdx uint64) (roachpb.Span, bool, error) {
+	for i, colIdx := range g.keyCols {
+		vec := vecs[colIdx]
+		if vec.Nulls().NullAt64(rowIdx) {
+			return roachpb.Span{}, false, nil
+		}
+		d, err := exec.PhysicalTypeColElemToDatum(vec, rowIdx, &g.alloc, &g.keyTypes[i])
+		if err != nil {
+			return roachpb.Span{}, false, err
+		}
+		g.keyRow[i] = sqlbase.DatumToEncDatum(&g.encTypes[i], d)
+	}
+	span, err := sqlbase.MakeSpanFromEncDatums(
+		g.keyPrefix, g.keyRow, g.encTypes, g.indexDirs, g.desc, g.index, &g.alloc,
+	)
+	return span, err == nil, err
+}
+
+// appendSpans appends the spans for all tuples of batch to spans. Tuples with
+// NULL key values are skipped.
+func (g *colSpanGenerator) appendSpans(
+	spans roachpb.Spans, batch coldata.Batch,
+) (roachpb.Spans, error) {
+	vecs := batch.ColVecs()
+	sel := batch.Selection()
+	for i := uint16(0); i < batch.Length(); i++ {
+		rowIdx := i
+		if sel != nil {
+			rowIdx = sel[i]
+		}
+		span, ok, err := g.makeSpan(vecs, uint64(rowIdx))
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			spans = append(spans, span)
+		}
+	}
+	return spans, nil
+}
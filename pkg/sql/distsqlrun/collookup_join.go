@@ -0,0 +1,424 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package distsqlrun
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/distsqlpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/exec"
+	"github.com/cockroachdb/cockroach/pkg/sql/exec/coldata"
+	coltypes "github.com/cockroachdb/cockroach/pkg/sql/exec/types"
+	"github.com/cockroachdb/cockroach/pkg/sql/exec/types/conv"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/row"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/pkg/errors"
+)
+
+// colLookupJoin is the exec.Operator implementation of the lookup join. It
+// performs a join between its input and an index of a table, using the values
+// of lookupCols of the input as the keys of the index.
+//
+// The input is read in batches of at least joinReaderBatchSize tuples. The
+// keys of every batch are looked up at once and the results are joined with
+// the tuples of the batch, preserving the order of the input. INNER, LEFT
+// OUTER, LEFT SEMI and LEFT ANTI joins are supported; joins with an ON
+// expression or an index filter are planned with the row-based joinReader.
+//
+// The input tuples of a batch and the looked up tuples are buffered in memory,
+// which is accounted for against the memory monitor of the processor.
+type colLookupJoin struct {
+	input   exec.Operator
+	flowCtx *FlowCtx
+	rf      *row.CFetcher
+	ctx     context.Context
+
+	joinType sqlbase.JoinType
+	// inputTypes are the physical types of the input, and tableTypes are the
+	// physical types of the columns of the table.
+	inputTypes []coltypes.T
+	tableTypes []coltypes.T
+	// inputKeys generates the spans of the index out of the lookup columns of
+	// the input, and lookupKeys generates the same spans out of the looked up
+	// rows of the index, which allows matching the looked up rows with the
+	// input tuples.
+	inputKeys  colSpanGenerator
+	lookupKeys colSpanGenerator
+	// batchSize is the minimum number of input tuples looked up at once. Not a
+	// constant so we can lower it for testing.
+	batchSize int
+
+	// State variables for each batch of input tuples.
+	inputTuples    colTupleBuffer
+	lookupTuples   colTupleBuffer
+	keyToInputIdxs map[string][]int
+	// matches contains, for every input tuple, the indices of the looked up
+	// tuples it matches.
+	matches [][]int
+	spans   roachpb.Spans
+	// emitInputIdx and emitMatchIdx are the indices of the next input tuple
+	// and of its next match to be emitted.
+	emitInputIdx int
+	emitMatchIdx int
+	inputDone    bool
+
+	output coldata.Batch
+	// Scratch space for emitting output batches.
+	inputSel  []uint64
+	lookupSel []uint64
+	lookupNil []bool
+}
+
+var _ exec.Operator = &colLookupJoin{}
+
+func (j *colLookupJoin) Init() {
+	j.ctx = context.Background()
+	j.input.Init()
+}
+
+func (j *colLookupJoin) Next(ctx context.Context) coldata.Batch {
+	for {
+		if j.emitInputIdx < len(j.matches) {
+			if batch := j.emit(); batch.Length() > 0 {
+				return batch
+			}
+			continue
+		}
+		if j.inputDone {
+			j.output.SetLength(0)
+			return j.output
+		}
+		j.lookupBatch(ctx)
+	}
+}
+
+// lookupBatch reads the next batch of input tuples, looks up their keys and
+// matches the looked up rows with them.
+func (j *colLookupJoin) lookupBatch(ctx context.Context) {
+	j.inputTuples.reset(ctx)
+	j.lookupTuples.reset(ctx)
+	j.matches = j.matches[:0]
+	j.emitInputIdx, j.emitMatchIdx = 0, 0
+	for k := range j.keyToInputIdxs {
+		delete(j.keyToInputIdxs, k)
+	}
+
+	// Read whole batches of the input until there are enough tuples.
+	for !j.inputDone && j.inputTuples.n < uint64(j.batchSize) {
+		batch := j.input.Next(ctx)
+		if batch.Length() == 0 {
+			j.inputDone = true
+			break
+		}
+		if err := j.inputTuples.append(ctx, batch); err != nil {
+			panic(pgerror.Wrap(err, pgerror.CodeOutOfMemoryError, "buffering lookup join input"))
+		}
+	}
+
+	// Maintain a map from the key of every span to the input tuples which
+	// generated it, so that the looked up rows can be joined with them.
+	j.spans = j.spans[:0]
+	for i := uint64(0); i < j.inputTuples.n; i++ {
+		j.matches = append(j.matches, nil)
+		span, ok, err := j.inputKeys.makeSpan(j.inputTuples.vecs, i)
+		if err != nil {
+			panic(err)
+		}
+		if !ok {
+			// A NULL key doesn't match any row.
+			continue
+		}
+		inputIdxs := j.keyToInputIdxs[string(span.Key)]
+		if inputIdxs == nil {
+			j.spans = append(j.spans, span)
+		}
+		j.keyToInputIdxs[string(span.Key)] = append(inputIdxs, int(i))
+	}
+	if len(j.spans) == 0 {
+		// All of the input tuples were filtered out. Skip the index lookup.
+		return
+	}
+
+	if err := j.rf.StartScan(
+		j.ctx, j.flowCtx.txn, j.spans,
+		false /* limitBatches */, 0 /* limitHint */, j.flowCtx.traceKV,
+	); err != nil {
+		panic(err)
+	}
+	for {
+		batch, err := j.rf.NextBatch(ctx)
+		if err != nil {
+			panic(err)
+		}
+		if batch.Length() == 0 {
+			break
+		}
+		start := j.lookupTuples.n
+		if err := j.lookupTuples.append(ctx, batch); err != nil {
+			panic(pgerror.Wrap(err, pgerror.CodeOutOfMemoryError, "buffering looked up rows"))
+		}
+		for lookupIdx := start; lookupIdx < j.lookupTuples.n; lookupIdx++ {
+			span, ok, err := j.lookupKeys.makeSpan(j.lookupTuples.vecs, lookupIdx)
+			if err != nil {
+				panic(err)
+			}
+			if !ok {
+				continue
+			}
+			for _, inputIdx := range j.keyToInputIdxs[string(span.Key)] {
+				j.matches[inputIdx] = append(j.matches[inputIdx], int(lookupIdx))
+			}
+		}
+	}
+}
+
+// emit returns the next output batch for the current batch of input tuples.
+// The returned batch is empty if none of the remaining input tuples produce
+// any output.
+func (j *colLookupJoin) emit() coldata.Batch {
+	j.inputSel, j.lookupSel, j.lookupNil = j.inputSel[:0], j.lookupSel[:0], j.lookupNil[:0]
+	for j.emitInputIdx < len(j.matches) && len(j.inputSel) < coldata.BatchSize {
+		matches := j.matches[j.emitInputIdx]
+		switch j.joinType {
+		case sqlbase.JoinType_INNER, sqlbase.JoinType_LEFT_OUTER:
+			if len(matches) == 0 {
+				if j.joinType == sqlbase.JoinType_LEFT_OUTER {
+					j.inputSel = append(j.inputSel, uint64(j.emitInputIdx))
+					j.lookupSel = append(j.lookupSel, 0)
+					j.lookupNil = append(j.lookupNil, true)
+				}
+				break
+			}
+			for ; j.emitMatchIdx < len(matches) && len(j.inputSel) < coldata.BatchSize; j.emitMatchIdx++ {
+				j.inputSel = append(j.inputSel, uint64(j.emitInputIdx))
+				j.lookupSel = append(j.lookupSel, uint64(matches[j.emitMatchIdx]))
+				j.lookupNil = append(j.lookupNil, false)
+			}
+			if j.emitMatchIdx < len(matches) {
+				// The output batch is full.
+				continue
+			}
+		case sqlbase.JoinType_LEFT_SEMI:
+			if len(matches) > 0 {
+				j.inputSel = append(j.inputSel, uint64(j.emitInputIdx))
+			}
+		case sqlbase.JoinType_LEFT_ANTI:
+			if len(matches) == 0 {
+				j.inputSel = append(j.inputSel, uint64(j.emitInputIdx))
+			}
+		}
+		j.emitInputIdx++
+		j.emitMatchIdx = 0
+	}
+
+	n := uint16(len(j.inputSel))
+	for i, t := range j.inputTypes {
+		j.output.ColVec(i).CopyWithSelInt64(j.inputTuples.vecs[i], j.inputSel, n, t)
+	}
+	if shouldIncludeRightColsInOutput(j.joinType) {
+		for i, t := range j.tableTypes {
+			j.output.ColVec(len(j.inputTypes)+i).CopyWithSelAndNilsInt64(
+				j.lookupTuples.vecs[i], j.lookupSel, n, j.lookupNil, t,
+			)
+		}
+	}
+	j.output.SetLength(n)
+	return j.output
+}
+
+// DrainMeta is part of the MetadataSource interface.
+func (j *colLookupJoin) DrainMeta(ctx context.Context) []distsqlpb.ProducerMetadata {
+	if meta := getTxnCoordMeta(ctx, j.flowCtx.txn); meta != nil {
+		return []distsqlpb.ProducerMetadata{{TxnCoordMeta: meta}}
+	}
+	return nil
+}
+
+// supportsColLookupJoin returns whether the lookup join described by spec can
+// be performed by a colLookupJoin.
+func supportsColLookupJoin(spec *distsqlpb.JoinReaderSpec) bool {
+	if !spec.OnExpr.Empty() || !spec.IndexFilterExpr.Empty() {
+		return false
+	}
+	if spec.Visibility != distsqlpb.ScanVisibility_PUBLIC {
+		return false
+	}
+	switch spec.Type {
+	case sqlbase.JoinType_INNER, sqlbase.JoinType_LEFT_OUTER,
+		sqlbase.JoinType_LEFT_SEMI, sqlbase.JoinType_LEFT_ANTI:
+	default:
+		return false
+	}
+	// The joinReader outputs the columns being added to the table as well,
+	// while only the public columns are fetched.
+	return len(spec.Table.ColumnTypesWithMutations(true)) == len(spec.Table.Columns)
+}
+
+// colLookupJoinOutputTypes returns the types of the columns output by the
+// lookup join described by spec over an input with the given types.
+func colLookupJoinOutputTypes(spec *distsqlpb.JoinReaderSpec, inputTypes []types.T) []types.T {
+	outputTypes := append([]types.T(nil), inputTypes...)
+	if shouldIncludeRightColsInOutput(spec.Type) {
+		outputTypes = append(outputTypes, spec.Table.ColumnTypes()...)
+	}
+	return outputTypes
+}
+
+// newColLookupJoin creates a new colLookupJoin operator performing the lookup
+// join described by spec over input, which has columns of the given types.
+// spec must be supported according to supportsColLookupJoin.
+func newColLookupJoin(
+	flowCtx *FlowCtx,
+	spec *distsqlpb.JoinReaderSpec,
+	input exec.Operator,
+	inputTypes []types.T,
+	post *distsqlpb.PostProcessSpec,
+	acc *mon.BoundAccount,
+) (*colLookupJoin, error) {
+	if !supportsColLookupJoin(spec) {
+		return nil, pgerror.AssertionFailedf("unsupported lookup join %s", spec)
+	}
+	desc := &spec.Table
+	index, _, err := desc.FindIndexByIndexIdx(int(spec.IndexIdx))
+	if err != nil {
+		return nil, err
+	}
+	colIdxMap := desc.ColumnIdxMap()
+	columnIDs, _ := index.FullColumnIDs()
+	if len(spec.LookupColumns) > len(columnIDs) {
+		return nil, errors.Errorf(
+			"%d lookup columns specified, expecting at most %d", len(spec.LookupColumns), len(columnIDs))
+	}
+	tableTypes := desc.ColumnTypes()
+
+	// Figure out which columns of the table are needed: the ones needed by the
+	// post-processing, and the key columns used to match the looked up rows
+	// with the input tuples.
+	helper := ProcOutputHelper{}
+	if err := helper.Init(
+		post,
+		colLookupJoinOutputTypes(spec, inputTypes),
+		flowCtx.NewEvalCtx(),
+		nil,
+	); err != nil {
+		return nil, err
+	}
+	neededCols := helper.neededColumns()
+	var neededTableCols util.FastIntSet
+	for i, ok := neededCols.Next(len(inputTypes)); ok; i, ok = neededCols.Next(i + 1) {
+		neededTableCols.Add(i - len(inputTypes))
+	}
+	lookupKeyCols := make([]uint32, len(spec.LookupColumns))
+	indexKeyTypes := make([]types.T, len(spec.LookupColumns))
+	for i := range spec.LookupColumns {
+		colIdx := colIdxMap[columnIDs[i]]
+		neededTableCols.Add(colIdx)
+		lookupKeyCols[i] = uint32(colIdx)
+		indexKeyTypes[i] = tableTypes[colIdx]
+	}
+
+	fetcher := row.CFetcher{}
+	index, isSecondary, err := initCRowFetcher(
+		&fetcher, desc, int(spec.IndexIdx), colIdxMap, false, /* reverse */
+		neededTableCols, false /* isCheck */, spec.Visibility,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if isSecondary && !neededTableCols.SubsetOf(getIndexColSet(index, colIdxMap)) {
+		return nil, errors.Errorf("joinreader index does not cover all columns")
+	}
+
+	j := &colLookupJoin{
+		input:          input,
+		flowCtx:        flowCtx,
+		rf:             &fetcher,
+		joinType:       spec.Type,
+		inputTypes:     conv.FromColumnTypes(inputTypes),
+		tableTypes:     conv.FromColumnTypes(tableTypes),
+		batchSize:      joinReaderBatchSize,
+		keyToInputIdxs: make(map[string][]int),
+	}
+	j.inputKeys.init(desc, index, spec.LookupColumns, inputTypes, indexKeyTypes)
+	j.lookupKeys.init(desc, index, lookupKeyCols, tableTypes, indexKeyTypes)
+	j.inputTuples.init(j.inputTypes, acc)
+	j.lookupTuples.init(j.tableTypes, acc)
+	outputTypes := j.inputTypes
+	if shouldIncludeRightColsInOutput(j.joinType) {
+		outputTypes = append(outputTypes[:len(outputTypes):len(outputTypes)], j.tableTypes...)
+	}
+	j.output = coldata.NewMemBatch(outputTypes)
+	return j, nil
+}
+
+// colTupleBuffer buffers the tuples of coldata.Batches in column vectors.
+type colTupleBuffer struct {
+	typs []coltypes.T
+	vecs []coldata.Vec
+	// n is the number of buffered tuples.
+	n uint64
+
+	// acc, if set, accounts for the memory used by the buffered tuples, of
+	// which there are usedBytes.
+	acc       *mon.BoundAccount
+	usedBytes int64
+}
+
+func (b *colTupleBuffer) init(typs []coltypes.T, acc *mon.BoundAccount) {
+	b.typs = typs
+	b.acc = acc
+	b.vecs = make([]coldata.Vec, len(typs))
+	for i, t := range typs {
+		b.vecs[i] = coldata.NewMemColumn(t, 0)
+	}
+}
+
+// append buffers all the tuples of batch. An error is returned if the memory
+// used by the tuples can't be accounted for.
+func (b *colTupleBuffer) append(ctx context.Context, batch coldata.Batch) error {
+	sel := batch.Selection()
+	for i, t := range b.typs {
+		if sel != nil {
+			b.vecs[i].AppendSliceWithSel(batch.ColVec(i), t, b.n, 0, batch.Length(), sel)
+		} else {
+			b.vecs[i].AppendSlice(batch.ColVec(i), t, b.n, 0, batch.Length())
+		}
+	}
+	end := b.n + uint64(batch.Length())
+	if b.acc != nil {
+		size := exec.EstimateVecsSizeBytes(b.vecs, b.typs, b.n, end)
+		if err := b.acc.Grow(ctx, size); err != nil {
+			return err
+		}
+		b.usedBytes += size
+	}
+	b.n = end
+	return nil
+}
+
+// reset empties the buffer, keeping the memory of its vectors.
+func (b *colTupleBuffer) reset(ctx context.Context) {
+	for _, vec := range b.vecs {
+		vec.Nulls().UnsetNulls()
+	}
+	b.n = 0
+	if b.acc != nil {
+		b.acc.Shrink(ctx, b.usedBytes)
+		b.usedBytes = 0
+	}
+}
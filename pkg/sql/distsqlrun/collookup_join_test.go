@@ -0,0 +1,168 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package distsqlrun
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/sql/distsqlpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+)
+
+func TestColJoinReaderAgainstProcessor(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	s, sqlDB, kvDB := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(context.TODO())
+
+	// Create a table where each row is:
+	//
+	//  |     a    |     b    |         sum         |         s           |
+	//  |-----------------------------------------------------------------|
+	//  | rowId/10 | rowId%10 | rowId/10 + rowId%10 | IntToEnglish(rowId) |
+	aFn := func(row int) tree.Datum {
+		return tree.NewDInt(tree.DInt(row / 10))
+	}
+	bFn := func(row int) tree.Datum {
+		return tree.NewDInt(tree.DInt(row % 10))
+	}
+	sumFn := func(row int) tree.Datum {
+		return tree.NewDInt(tree.DInt(row/10 + row%10))
+	}
+	sqlutils.CreateTable(t, sqlDB, "t",
+		"a INT, b INT, sum INT, s STRING, PRIMARY KEY (a,b), INDEX bs (b,s)",
+		99,
+		sqlutils.ToRowFn(aFn, bFn, sumFn, sqlutils.RowEnglishFn))
+	td := sqlbase.GetTableDescriptor(kvDB, "test", "t")
+	tableTypes := td.ColumnTypes()
+
+	// The input contains some keys which don't exist in the table as well as
+	// many more tuples than looked up at once.
+	rng, _ := randutil.NewPseudoRand()
+	const nRows = 500
+	input := sqlbase.MakeRandIntRowsInRange(rng, nRows, 2 /* numCols */, 12 /* maxNum */, 0.1 /* nullProbability */)
+	// The primary keys looked up by the index join cannot be NULL.
+	pkInput := sqlbase.MakeRandIntRowsInRange(rng, nRows, 2 /* numCols */, 12 /* maxNum */, 0 /* nullProbability */)
+
+	testCases := []struct {
+		description string
+		spec        distsqlpb.JoinReaderSpec
+		post        distsqlpb.PostProcessSpec
+		input       sqlbase.EncDatumRows
+		outputTypes []types.T
+	}{
+		{
+			description: "index join",
+			spec:        distsqlpb.JoinReaderSpec{IndexIdx: 0},
+			input:       pkInput,
+			outputTypes: tableTypes,
+		},
+		{
+			description: "index join with a projection",
+			spec:        distsqlpb.JoinReaderSpec{IndexIdx: 0},
+			post:        distsqlpb.PostProcessSpec{Projection: true, OutputColumns: []uint32{3, 2}},
+			input:       pkInput,
+			outputTypes: []types.T{tableTypes[3], tableTypes[2]},
+		},
+		{
+			description: "lookup join on a prefix of the primary index",
+			spec:        distsqlpb.JoinReaderSpec{IndexIdx: 0, LookupColumns: []uint32{1}},
+			input:       input,
+		},
+		{
+			description: "lookup join on the primary index",
+			spec:        distsqlpb.JoinReaderSpec{IndexIdx: 0, LookupColumns: []uint32{0, 1}},
+			input:       input,
+		},
+		{
+			description: "lookup join on a secondary index",
+			spec:        distsqlpb.JoinReaderSpec{IndexIdx: 1, LookupColumns: []uint32{0}},
+			// Only the columns stored in the index can be output.
+			post:  distsqlpb.PostProcessSpec{Projection: true, OutputColumns: []uint32{0, 1, 2, 3, 5}},
+			input: input,
+		},
+	}
+
+	for _, tc := range testCases {
+		joinTypes := []sqlbase.JoinType{sqlbase.JoinType_INNER}
+		if len(tc.spec.LookupColumns) > 0 {
+			joinTypes = append(joinTypes,
+				sqlbase.JoinType_LEFT_OUTER, sqlbase.JoinType_LEFT_SEMI, sqlbase.JoinType_LEFT_ANTI,
+			)
+		}
+		for _, joinType := range joinTypes {
+			t.Run(fmt.Sprintf("%s/%s", tc.description, joinType), func(t *testing.T) {
+				spec := tc.spec
+				spec.Table = *td
+				spec.Type = joinType
+				post := tc.post
+				outputTypes := tc.outputTypes
+				if len(spec.LookupColumns) > 0 {
+					outputTypes = colLookupJoinOutputTypes(&spec, sqlbase.TwoIntCols)
+					if post.Projection && shouldIncludeRightColsInOutput(joinType) {
+						projected := make([]types.T, len(post.OutputColumns))
+						for i, colIdx := range post.OutputColumns {
+							projected[i] = outputTypes[colIdx]
+						}
+						outputTypes = projected
+					} else if post.Projection {
+						post = distsqlpb.PostProcessSpec{}
+					}
+				}
+				pspec := &distsqlpb.ProcessorSpec{
+					Input: []distsqlpb.InputSyncSpec{{ColumnTypes: sqlbase.TwoIntCols}},
+					Core:  distsqlpb.ProcessorCoreUnion{JoinReader: &spec},
+					Post:  post,
+				}
+				txn := client.NewTxn(context.Background(), s.DB(), s.NodeID(), client.RootTxn)
+				// Both the processors and the columnar operators preserve the order
+				// of their input.
+				if err := verifyColOperatorWithTxn(
+					false /* anyOrder */, [][]types.T{sqlbase.TwoIntCols}, []sqlbase.EncDatumRows{tc.input},
+					outputTypes, pspec, txn,
+				); err != nil {
+					t.Fatal(err)
+				}
+			})
+		}
+	}
+
+	// The tuples buffered by the lookup join are accounted for against the
+	// memory monitor of the processor.
+	t.Run("memory limit", func(t *testing.T) {
+		spec := distsqlpb.JoinReaderSpec{Table: *td, IndexIdx: 0, LookupColumns: []uint32{0, 1}}
+		pspec := &distsqlpb.ProcessorSpec{
+			Input: []distsqlpb.InputSyncSpec{{ColumnTypes: sqlbase.TwoIntCols}},
+			Core:  distsqlpb.ProcessorCoreUnion{JoinReader: &spec},
+		}
+		txn := client.NewTxn(context.Background(), s.DB(), s.NodeID(), client.RootTxn)
+		err := verifyColOperatorImpl(
+			false /* anyOrder */, [][]types.T{sqlbase.TwoIntCols}, []sqlbase.EncDatumRows{input},
+			colLookupJoinOutputTypes(&spec, sqlbase.TwoIntCols), pspec, txn, 1, /* memLimit */
+		)
+		if !testutils.IsError(err, "memory budget exceeded") {
+			t.Fatalf("expected memory budget exceeded error, got %v", err)
+		}
+	})
+}
@@ -17,9 +17,11 @@ import (
 	"fmt"
 	"math"
 	"reflect"
+	"sort"
 
 	"github.com/cockroachdb/cockroach/pkg/sql/distsqlpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/exec"
+	coltypes "github.com/cockroachdb/cockroach/pkg/sql/exec/types"
 	"github.com/cockroachdb/cockroach/pkg/sql/exec/types/conv"
 	"github.com/cockroachdb/cockroach/pkg/sql/exec/vecbuiltins"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
@@ -27,6 +29,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 	semtypes "github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util"
+	"github.com/cockroachdb/cockroach/pkg/util/duration"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 	"github.com/cockroachdb/cockroach/pkg/util/tracing"
@@ -90,8 +93,16 @@ func wrapRowSource(
 	return newColumnarizer(flowCtx, processorID, toWrap)
 }
 
+// newColOperator plans the operator for the given processor spec. The memory
+// monitors and spilling configs of operators which may spill to temporary
+// storage are tracked by resources; if resources is nil, these operators
+// always keep their whole input in memory.
 func newColOperator(
-	ctx context.Context, flowCtx *FlowCtx, spec *distsqlpb.ProcessorSpec, inputs []exec.Operator,
+	ctx context.Context,
+	flowCtx *FlowCtx,
+	spec *distsqlpb.ProcessorSpec,
+	inputs []exec.Operator,
+	resources *vectorizedFlowResources,
 ) (exec.Operator, error) {
 	core := &spec.Core
 	post := &spec.Post
@@ -197,9 +208,20 @@ func newColOperator(
 			columnTypes[i] = *retType
 		}
 		if needHash {
-			op, err = exec.NewHashAggregator(
-				inputs[0], conv.FromColumnTypes(spec.Input[0].ColumnTypes), aggFns, aggSpec.GroupCols, aggCols,
-			)
+			typs := conv.FromColumnTypes(spec.Input[0].ColumnTypes)
+			// There is no setting disabling the use of temporary storage by
+			// aggregations, since the row-based aggregator never spills.
+			if cfg := resources.newSpillingConfig(
+				ctx, flowCtx, "hashagg", typs, true, /* useTempStorage */
+			); cfg != nil {
+				op, err = exec.NewSpillingHashAggregator(
+					inputs[0], typs, aggFns, aggSpec.GroupCols, aggCols, cfg,
+				)
+			} else {
+				op, err = exec.NewHashAggregator(
+					inputs[0], typs, aggFns, aggSpec.GroupCols, aggCols,
+				)
+			}
 		} else {
 			op, err = exec.NewOrderedAggregator(
 				inputs[0], conv.FromColumnTypes(spec.Input[0].ColumnTypes), aggFns, aggSpec.GroupCols, aggCols,
@@ -272,19 +294,45 @@ func newColOperator(
 			}
 		}
 
-		op, err = exec.NewEqHashJoinerOp(
-			inputs[0],
-			inputs[1],
-			core.HashJoiner.LeftEqColumns,
-			core.HashJoiner.RightEqColumns,
-			leftOutCols,
-			rightOutCols,
-			leftTypes,
-			rightTypes,
-			core.HashJoiner.RightEqColumnsAreKey,
-			core.HashJoiner.LeftEqColumnsAreKey || core.HashJoiner.RightEqColumnsAreKey,
-			core.HashJoiner.Type,
-		)
+		// Both inputs are written to temporary storage if the build side doesn't
+		// fit in memory.
+		var cfg *exec.SpillingConfig
+		if len(leftTypes) > 0 && len(rightTypes) > 0 {
+			cfg = resources.newSpillingConfig(
+				ctx, flowCtx, "hashjoiner", conv.FromColumnTypes(columnTypes),
+				settingUseTempStorageJoins.Get(&flowCtx.Settings.SV),
+			)
+		}
+		if cfg != nil {
+			op, err = exec.NewSpillingEqHashJoinerOp(
+				inputs[0],
+				inputs[1],
+				core.HashJoiner.LeftEqColumns,
+				core.HashJoiner.RightEqColumns,
+				leftOutCols,
+				rightOutCols,
+				leftTypes,
+				rightTypes,
+				core.HashJoiner.RightEqColumnsAreKey,
+				core.HashJoiner.LeftEqColumnsAreKey || core.HashJoiner.RightEqColumnsAreKey,
+				core.HashJoiner.Type,
+				cfg,
+			)
+		} else {
+			op, err = exec.NewEqHashJoinerOp(
+				inputs[0],
+				inputs[1],
+				core.HashJoiner.LeftEqColumns,
+				core.HashJoiner.RightEqColumns,
+				leftOutCols,
+				rightOutCols,
+				leftTypes,
+				rightTypes,
+				core.HashJoiner.RightEqColumnsAreKey,
+				core.HashJoiner.LeftEqColumnsAreKey || core.HashJoiner.RightEqColumnsAreKey,
+				core.HashJoiner.Type,
+			)
+		}
 
 	case core.MergeJoiner != nil:
 		if err := checkNumIn(inputs, 2); err != nil {
@@ -347,24 +395,32 @@ func newColOperator(
 			return nil, err
 		}
 
+		if len(core.JoinReader.LookupColumns) == 0 {
+			op, err = newColIndexJoin(flowCtx, core.JoinReader, inputs[0], spec.Input[0].ColumnTypes, post)
+			returnMutations := core.JoinReader.Visibility == distsqlpb.ScanVisibility_PUBLIC_AND_NOT_PUBLIC
+			columnTypes = core.JoinReader.Table.ColumnTypesWithMutations(returnMutations)
+			break
+		}
+		if supportsColLookupJoin(core.JoinReader) {
+			op, err = newColLookupJoin(
+				flowCtx, core.JoinReader, inputs[0], spec.Input[0].ColumnTypes, post,
+				resources.newMemAccount(ctx, flowCtx, "lookup-join"),
+			)
+			columnTypes = colLookupJoinOutputTypes(core.JoinReader, spec.Input[0].ColumnTypes)
+			break
+		}
 		op, err = wrapRowSource(flowCtx, inputs[0], spec.Input[0].ColumnTypes, func(input RowSource) (RowSource, error) {
 			var (
 				jr  RowSource
 				err error
 			)
-			// The lookup and index joiners need to be passed the post-process specs,
-			// since they inspect them to figure out information about needed columns.
-			// This means that we'll let those processors do any renders or filters,
+			// The lookup joiner needs to be passed the post-process specs, since
+			// it inspects them to figure out information about needed columns.
+			// This means that we'll let the processor do any renders or filters,
 			// which isn't ideal. We could improve this.
-			if len(core.JoinReader.LookupColumns) == 0 {
-				jr, err = newIndexJoiner(
-					flowCtx, spec.ProcessorID, core.JoinReader, input, post, nil, /* output */
-				)
-			} else {
-				jr, err = newJoinReader(
-					flowCtx, spec.ProcessorID, core.JoinReader, input, post, nil, /* output */
-				)
-			}
+			jr, err = newJoinReader(
+				flowCtx, spec.ProcessorID, core.JoinReader, input, post, nil, /* output */
+			)
 			post = &distsqlpb.PostProcessSpec{}
 			if err != nil {
 				return nil, err
@@ -392,8 +448,16 @@ func newColOperator(
 			k := uint16(post.Limit + post.Offset)
 			op = exec.NewTopKSorter(input, inputTypes, orderingCols, k)
 		} else {
-			// No optimizations possible. Default to the standard sort operator.
-			op, err = exec.NewSorter(input, inputTypes, orderingCols)
+			// No optimizations possible. Default to the standard sort operator,
+			// which falls back to an external sort if the input doesn't fit in
+			// memory.
+			if cfg := resources.newSpillingConfig(
+				ctx, flowCtx, "sorter", inputTypes, settingUseTempStorageSorts.Get(&flowCtx.Settings.SV),
+			); cfg != nil {
+				op, err = exec.NewSpillingSorter(input, inputTypes, orderingCols, cfg)
+			} else {
+				op, err = exec.NewSorter(input, inputTypes, orderingCols)
+			}
 		}
 		columnTypes = spec.Input[0].ColumnTypes
 
@@ -401,68 +465,33 @@ func newColOperator(
 		if err := checkNumIn(inputs, 1); err != nil {
 			return nil, err
 		}
-		if len(core.Windower.WindowFns) != 1 {
-			return nil, pgerror.Newf(pgerror.CodeDataExceptionError,
-				"only a single window function is currently supported")
-		}
-		wf := core.Windower.WindowFns[0]
-		if wf.Frame != nil {
-			return nil, pgerror.Newf(pgerror.CodeDataExceptionError,
-				"window functions with window frames are not supported")
-		}
-		if wf.Func.AggregateFunc != nil {
-			return nil, pgerror.Newf(pgerror.CodeDataExceptionError,
-				"aggregate functions used as window functions are not supported")
-		}
-
-		input := inputs[0]
-		typs := conv.FromColumnTypes(spec.Input[0].ColumnTypes)
-		var orderingCols []uint32
-		tempPartitionColOffset, partitionColIdx := 0, -1
-		if len(core.Windower.PartitionBy) > 0 {
-			// TODO(yuzefovich): add support for hashing partitioner (probably by
-			// leveraging hash routers once we can distribute). The decision about
-			// which kind of partitioner to use should come from the optimizer.
-			input, orderingCols, err = exec.NewWindowSortingPartitioner(input, typs, core.Windower.PartitionBy, wf.Ordering.Columns, int(wf.OutputColIdx))
-			tempPartitionColOffset, partitionColIdx = 1, int(wf.OutputColIdx)
-		} else {
-			if len(wf.Ordering.Columns) > 0 {
-				input, err = exec.NewSorter(input, typs, wf.Ordering.Columns)
-			}
-			orderingCols = make([]uint32, len(wf.Ordering.Columns))
-			for i, col := range wf.Ordering.Columns {
-				orderingCols[i] = col.ColIdx
+		// Window functions are computed one at a time, each of them appending
+		// its output column to the columns of its input, so they are planned in
+		// the order of their output columns.
+		windowFns := make([]distsqlpb.WindowerSpec_WindowFn, len(core.Windower.WindowFns))
+		copy(windowFns, core.Windower.WindowFns)
+		sort.Slice(windowFns, func(i, j int) bool {
+			return windowFns[i].OutputColIdx < windowFns[j].OutputColIdx
+		})
+		op = inputs[0]
+		columnTypes = make([]semtypes.T, len(spec.Input[0].ColumnTypes), len(spec.Input[0].ColumnTypes)+len(windowFns))
+		copy(columnTypes, spec.Input[0].ColumnTypes)
+		for i := range windowFns {
+			wf := &windowFns[i]
+			if int(wf.OutputColIdx) != len(columnTypes) {
+				return nil, pgerror.AssertionFailedf(
+					"window function %s has unexpected output column %d", wf.String(), wf.OutputColIdx)
 			}
-		}
-		if err != nil {
-			return nil, err
-		}
-
-		switch *wf.Func.WindowFunc {
-		case distsqlpb.WindowerSpec_ROW_NUMBER:
-			op = vecbuiltins.NewRowNumberOperator(input, int(wf.OutputColIdx)+tempPartitionColOffset, partitionColIdx)
-		case distsqlpb.WindowerSpec_RANK:
-			op, err = vecbuiltins.NewRankOperator(input, typs, false /* dense */, orderingCols, int(wf.OutputColIdx)+tempPartitionColOffset, partitionColIdx)
-		case distsqlpb.WindowerSpec_DENSE_RANK:
-			op, err = vecbuiltins.NewRankOperator(input, typs, true /* dense */, orderingCols, int(wf.OutputColIdx)+tempPartitionColOffset, partitionColIdx)
-		default:
-			return nil, pgerror.Newf(pgerror.CodeDataExceptionError,
-				"window function %s is not supported", wf.String())
-		}
-
-		if partitionColIdx != -1 {
-			// Window partitioner will append a temporary column to the batch which
-			// we want to project out.
-			projection := make([]uint32, 0, wf.OutputColIdx+1)
-			for i := uint32(0); i < wf.OutputColIdx; i++ {
-				projection = append(projection, i)
+			var outputType *semtypes.T
+			op, outputType, err = planWindowFn(
+				ctx, flowCtx, op, columnTypes, core.Windower.PartitionBy, wf, resources,
+			)
+			if err != nil {
+				return nil, err
 			}
-			projection = append(projection, wf.OutputColIdx+1)
-			op = exec.NewSimpleProjectOp(op, projection)
+			columnTypes = append(columnTypes, *outputType)
 		}
 
-		columnTypes = append(spec.Input[0].ColumnTypes, *semtypes.Int)
-
 	default:
 		return nil, pgerror.Newf(pgerror.CodeDataExceptionError,
 			"unsupported processor core %s", core)
@@ -533,6 +562,110 @@ func newColOperator(
 	return op, nil
 }
 
+// planWindowFn plans the operators computing the window function wf over
+// input, which has columns of the given types, and returns the resulting
+// operator, which emits all the columns of input followed by the result of the
+// window function, along with the type of that result.
+func planWindowFn(
+	ctx context.Context,
+	flowCtx *FlowCtx,
+	input exec.Operator,
+	inputTypes []semtypes.T,
+	partitionBy []uint32,
+	wf *distsqlpb.WindowerSpec_WindowFn,
+	resources *vectorizedFlowResources,
+) (exec.Operator, *semtypes.T, error) {
+	typs := conv.FromColumnTypes(inputTypes)
+	var (
+		orderingCols []uint32
+		err          error
+	)
+	partitionColIdx := -1
+	if len(partitionBy) > 0 {
+		// TODO(yuzefovich): add support for hashing partitioner (probably by
+		// leveraging hash routers once we can distribute). The decision about
+		// which kind of partitioner to use should come from the optimizer.
+		partitionColIdx = len(inputTypes)
+		input, orderingCols, err = exec.NewWindowSortingPartitioner(input, typs, partitionBy, wf.Ordering.Columns, partitionColIdx)
+	} else {
+		if len(wf.Ordering.Columns) > 0 {
+			input, err = exec.NewSorter(input, typs, wf.Ordering.Columns)
+		}
+		orderingCols = make([]uint32, len(wf.Ordering.Columns))
+		for i, col := range wf.Ordering.Columns {
+			orderingCols[i] = col.ColIdx
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The window partitioner appends a temporary column to the batch, so the
+	// output column of the window function comes after it.
+	outputColIdx := len(inputTypes)
+	if partitionColIdx != -1 {
+		outputColIdx++
+	}
+	if wf.Func.WindowFunc != nil {
+		// Ranking functions have specialized operators which are used regardless
+		// of the frame, since the frame doesn't affect their results.
+		var op exec.Operator
+		switch *wf.Func.WindowFunc {
+		case distsqlpb.WindowerSpec_ROW_NUMBER:
+			op = vecbuiltins.NewRowNumberOperator(input, outputColIdx, partitionColIdx)
+		case distsqlpb.WindowerSpec_RANK:
+			op, err = vecbuiltins.NewRankOperator(input, typs, false /* dense */, orderingCols, outputColIdx, partitionColIdx)
+		case distsqlpb.WindowerSpec_DENSE_RANK:
+			op, err = vecbuiltins.NewRankOperator(input, typs, true /* dense */, orderingCols, outputColIdx, partitionColIdx)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if op != nil {
+			if partitionColIdx != -1 {
+				// Project out the temporary column appended by the partitioner.
+				projection := make([]uint32, 0, len(inputTypes)+1)
+				for i := range inputTypes {
+					projection = append(projection, uint32(i))
+				}
+				projection = append(projection, uint32(outputColIdx))
+				op = exec.NewSimpleProjectOp(op, projection)
+			}
+			return op, semtypes.Int, nil
+		}
+	}
+
+	// All other window functions are computed by the general window operator,
+	// which uses the same implementations as the windower processor.
+	argTypes := make([]semtypes.T, len(wf.ArgsIdxs))
+	for i, argIdx := range wf.ArgsIdxs {
+		argTypes[i] = inputTypes[argIdx]
+	}
+	windowFn, outputType, err := GetWindowFunctionInfo(wf.Func, argTypes...)
+	if err != nil {
+		return nil, nil, err
+	}
+	frameRun := &tree.WindowFrameRun{
+		ArgsIdxs:     wf.ArgsIdxs,
+		FilterColIdx: int(wf.FilterColIdx),
+	}
+	var da sqlbase.DatumAlloc
+	if err := initWindowFrameRun(frameRun, wf.Frame, wf.Ordering, inputTypes, &da); err != nil {
+		return nil, nil, err
+	}
+	// Like the windower processor, the window operator always uses temporary
+	// storage for the partitions which don't fit in memory.
+	cfg := resources.newSpillingConfig(ctx, flowCtx, "window", nil /* typs */, true /* useTempStorage */)
+	op, err := vecbuiltins.NewWindowOperator(
+		flowCtx.NewEvalCtx(), input, inputTypes, windowFn, frameRun, orderingCols, outputType,
+		partitionColIdx, cfg,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	return op, outputType, nil
+}
+
 func planSelectionOperators(
 	ctx *tree.EvalContext, expr tree.TypedExpr, columnTypes []semtypes.T, input exec.Operator,
 ) (op exec.Operator, resultIdx int, ct []semtypes.T, err error) {
@@ -591,9 +724,9 @@ func planProjectionOperators(
 	case *tree.IndexedVar:
 		return input, t.Idx, columnTypes, nil
 	case *tree.ComparisonExpr:
-		return planProjectionExpr(ctx, t.Operator, t.TypedLeft(), t.TypedRight(), columnTypes, input)
+		return planProjectionExpr(ctx, t.Operator, t.ResolvedType(), t.TypedLeft(), t.TypedRight(), columnTypes, input)
 	case *tree.BinaryExpr:
-		return planProjectionExpr(ctx, t.Operator, t.TypedLeft(), t.TypedRight(), columnTypes, input)
+		return planProjectionExpr(ctx, t.Operator, t.ResolvedType(), t.TypedLeft(), t.TypedRight(), columnTypes, input)
 	case tree.Datum:
 		datumType := t.ResolvedType()
 		ct := columnTypes
@@ -620,6 +753,7 @@ func planProjectionOperators(
 func planProjectionExpr(
 	ctx *tree.EvalContext,
 	binOp tree.Operator,
+	outputType *semtypes.T,
 	left, right tree.TypedExpr,
 	columnTypes []semtypes.T,
 	input exec.Operator,
@@ -641,10 +775,14 @@ func planProjectionExpr(
 		}
 		resultIdx = len(ct)
 		typ := &ct[rightIdx]
+		constTyp := projectionConstType(lConstArg, typ)
+		if err := checkProjectionTypes(ctx, binOp, constTyp, typ); err != nil {
+			return nil, resultIdx, ct, err
+		}
 		// The projection result will be outputted to a new column which is appended
 		// to the input batch.
-		op, err = exec.GetProjectionLConstOperator(typ, binOp, rightOp, rightIdx, lConstArg, resultIdx)
-		ct = append(ct, *typ)
+		op, err = exec.GetProjectionLConstOperator(constTyp, typ, binOp, rightOp, rightIdx, lConstArg, resultIdx)
+		ct = append(ct, *outputType)
 		return op, resultIdx, ct, err
 	}
 	leftOp, leftIdx, ct, err := planProjectionOperators(ctx, left, columnTypes, input)
@@ -656,9 +794,13 @@ func planProjectionExpr(
 		// Case 2: The right is constant.
 		// The projection result will be outputted to a new column which is appended
 		// to the input batch.
+		constTyp := projectionConstType(rConstArg, typ)
+		if err := checkProjectionTypes(ctx, binOp, typ, constTyp); err != nil {
+			return nil, resultIdx, ct, err
+		}
 		resultIdx = len(ct)
-		op, err = exec.GetProjectionRConstOperator(typ, binOp, leftOp, leftIdx, rConstArg, resultIdx)
-		ct = append(ct, *typ)
+		op, err = exec.GetProjectionRConstOperator(typ, constTyp, binOp, leftOp, leftIdx, rConstArg, resultIdx)
+		ct = append(ct, *outputType)
 		return op, resultIdx, ct, err
 	}
 	// Case 3: neither are constant.
@@ -666,18 +808,50 @@ func planProjectionExpr(
 	if err != nil {
 		return nil, resultIdx, nil, err
 	}
-	if !ct[leftIdx].Identical(&ct[rightIdx]) {
-		err = errors.Errorf(
-			"projection on %s and %s is unhandled", ct[leftIdx].Family(),
-			ct[rightIdx].Family())
+	if err := checkProjectionTypes(ctx, binOp, &ct[leftIdx], &ct[rightIdx]); err != nil {
 		return nil, resultIdx, ct, err
 	}
 	resultIdx = len(ct)
-	op, err = exec.GetProjectionOperator(typ, binOp, rightOp, leftIdx, rightIdx, resultIdx)
-	ct = append(ct, *typ)
+	op, err = exec.GetProjectionOperator(&ct[leftIdx], &ct[rightIdx], binOp, rightOp, leftIdx, rightIdx, resultIdx)
+	ct = append(ct, *outputType)
 	return op, resultIdx, ct, err
 }
 
+// projectionConstType returns the type with which the constant argument of a
+// projection whose other argument is of type colType is converted. Constants of
+// the same family as the column, like INT constants used with INT2 columns, are
+// converted to the column's type.
+func projectionConstType(constArg tree.Datum, colType *semtypes.T) *semtypes.T {
+	if typ := constArg.ResolvedType(); typ.Family() != colType.Family() {
+		return typ
+	}
+	return colType
+}
+
+// checkProjectionTypes returns an error if a projection of the given operator
+// on arguments of the given types can't be planned. Projection operators are
+// chosen by the physical types of their arguments, so arguments of different
+// types are only supported if their physical types tell them apart; for
+// example, TIMESTAMP and INTERVAL are, but TIMESTAMP and TIMESTAMPTZ aren't.
+func checkProjectionTypes(
+	ctx *tree.EvalContext, op tree.Operator, left, right *semtypes.T,
+) error {
+	leftTyp, rightTyp := conv.FromColumnType(left), conv.FromColumnType(right)
+	if leftTyp == coltypes.Unhandled || rightTyp == coltypes.Unhandled ||
+		(!left.Identical(right) && leftTyp == rightTyp) {
+		return errors.Errorf("projection on %s and %s is unhandled", left.Family(), right.Family())
+	}
+	// The operators adding intervals to timestamps don't have access to the
+	// session, and always use duration.AdditionModeCompatible.
+	if (op == tree.Plus || op == tree.Minus) &&
+		(left.Family() == semtypes.IntervalFamily) != (right.Family() == semtypes.IntervalFamily) &&
+		ctx.GetAdditionMode() != duration.AdditionModeCompatible {
+		return errors.Errorf("projection on %s and %s is unhandled in %s duration addition mode",
+			left.Family(), right.Family(), ctx.GetAdditionMode())
+	}
+	return nil
+}
+
 // wrapWithVectorizedStatsCollector creates a new exec.VectorizedStatsCollector
 // that wraps op and connects the newly created wrapper with those
 // corresponding to operators in inputs (the latter must have already been
@@ -759,7 +933,7 @@ func (f *Flow) setupVectorized(ctx context.Context) error {
 			inputs = append(inputs, streamIDToInputOp[inputStream.StreamID])
 		}
 
-		op, err := newColOperator(ctx, &f.FlowCtx, pspec, inputs)
+		op, err := newColOperator(ctx, &f.FlowCtx, pspec, inputs, &f.vectorizedResources)
 		if err != nil {
 			return err
 		}
@@ -175,6 +175,152 @@ func TestMergeJoinerAgainstProcessor(t *testing.T) {
 	}
 }
 
+func TestWindowerAgainstProcessor(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	rng, _ := randutil.NewPseudoRand()
+
+	nRows := 200
+	maxNum := 10
+	nullProbability := 0.1
+	// The input has four columns: the partitioning column, the ordering column,
+	// a unique column that is used to make the ordering of rows fully
+	// deterministic, and the argument column.
+	inputTypes := []types.T{*types.Int, *types.Int, *types.Int, *types.Int}
+	rows := make(sqlbase.EncDatumRows, nRows)
+	for i := range rows {
+		rows[i] = make(sqlbase.EncDatumRow, len(inputTypes))
+		rows[i][0] = sqlbase.DatumToEncDatum(types.Int, tree.NewDInt(tree.DInt(rng.Intn(maxNum))))
+		rows[i][1] = sqlbase.DatumToEncDatum(types.Int, tree.NewDInt(tree.DInt(rng.Intn(maxNum))))
+		if rng.Float64() < nullProbability {
+			rows[i][1] = sqlbase.DatumToEncDatum(types.Int, tree.DNull)
+		}
+		rows[i][2] = sqlbase.DatumToEncDatum(types.Int, tree.NewDInt(tree.DInt(i)))
+		rows[i][3] = sqlbase.DatumToEncDatum(types.Int, tree.NewDInt(tree.DInt(rng.Intn(maxNum)+1)))
+		if rng.Float64() < nullProbability {
+			rows[i][3] = sqlbase.DatumToEncDatum(types.Int, tree.DNull)
+		}
+	}
+
+	windowFunc := func(fn distsqlpb.WindowerSpec_WindowFunc) distsqlpb.WindowerSpec_Func {
+		return distsqlpb.WindowerSpec_Func{WindowFunc: &fn}
+	}
+	aggregateFunc := func(fn distsqlpb.AggregatorSpec_Func) distsqlpb.WindowerSpec_Func {
+		return distsqlpb.WindowerSpec_Func{AggregateFunc: &fn}
+	}
+	rowsFrame := func(start, end uint64) *distsqlpb.WindowerSpec_Frame {
+		return &distsqlpb.WindowerSpec_Frame{
+			Mode: distsqlpb.WindowerSpec_Frame_ROWS,
+			Bounds: distsqlpb.WindowerSpec_Frame_Bounds{
+				Start: distsqlpb.WindowerSpec_Frame_Bound{
+					BoundType: distsqlpb.WindowerSpec_Frame_OFFSET_PRECEDING, IntOffset: start,
+				},
+				End: &distsqlpb.WindowerSpec_Frame_Bound{
+					BoundType: distsqlpb.WindowerSpec_Frame_OFFSET_FOLLOWING, IntOffset: end,
+				},
+			},
+		}
+	}
+	rangeFrameBound := func(
+		boundType distsqlpb.WindowerSpec_Frame_BoundType, offset int,
+	) distsqlpb.WindowerSpec_Frame_Bound {
+		var da sqlbase.DatumAlloc
+		encOffset, err := sqlbase.DatumToEncDatum(types.Int, tree.NewDInt(tree.DInt(offset))).Encode(
+			types.Int, &da, sqlbase.DatumEncoding_VALUE, nil, /* appendTo */
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return distsqlpb.WindowerSpec_Frame_Bound{
+			BoundType:   boundType,
+			TypedOffset: encOffset,
+			OffsetType:  distsqlpb.DatumInfo{Encoding: sqlbase.DatumEncoding_VALUE, Type: *types.Int},
+		}
+	}
+	rangeFrame := func(start, end int) *distsqlpb.WindowerSpec_Frame {
+		endBound := rangeFrameBound(distsqlpb.WindowerSpec_Frame_OFFSET_FOLLOWING, end)
+		return &distsqlpb.WindowerSpec_Frame{
+			Mode: distsqlpb.WindowerSpec_Frame_RANGE,
+			Bounds: distsqlpb.WindowerSpec_Frame_Bounds{
+				Start: rangeFrameBound(distsqlpb.WindowerSpec_Frame_OFFSET_PRECEDING, start),
+				End:   &endBound,
+			},
+		}
+	}
+	// fullOrdering makes the order of rows within a partition deterministic.
+	fullOrdering := distsqlpb.Ordering{Columns: []distsqlpb.Ordering_Column{
+		{ColIdx: 1, Direction: distsqlpb.Ordering_Column_ASC},
+		{ColIdx: 2, Direction: distsqlpb.Ordering_Column_DESC},
+	}}
+	// rangeOrdering is the single ordering column required by RANGE frames with
+	// offsets.
+	rangeOrdering := distsqlpb.Ordering{Columns: []distsqlpb.Ordering_Column{
+		{ColIdx: 1, Direction: distsqlpb.Ordering_Column_ASC},
+	}}
+
+	testCases := [][]distsqlpb.WindowerSpec_WindowFn{
+		{
+			{Func: windowFunc(distsqlpb.WindowerSpec_ROW_NUMBER), Ordering: fullOrdering},
+			{Func: windowFunc(distsqlpb.WindowerSpec_RANK), Ordering: rangeOrdering},
+			{Func: windowFunc(distsqlpb.WindowerSpec_PERCENT_RANK), Ordering: rangeOrdering},
+			{Func: windowFunc(distsqlpb.WindowerSpec_CUME_DIST), Ordering: rangeOrdering},
+		},
+		{
+			{Func: windowFunc(distsqlpb.WindowerSpec_LAG), ArgsIdxs: []uint32{3}, Ordering: fullOrdering},
+			{Func: windowFunc(distsqlpb.WindowerSpec_LEAD), ArgsIdxs: []uint32{3}, Ordering: fullOrdering},
+			{Func: windowFunc(distsqlpb.WindowerSpec_NTILE), ArgsIdxs: []uint32{3}, Ordering: fullOrdering},
+		},
+		{
+			{Func: windowFunc(distsqlpb.WindowerSpec_FIRST_VALUE), ArgsIdxs: []uint32{3}, Ordering: fullOrdering},
+			{Func: windowFunc(distsqlpb.WindowerSpec_LAST_VALUE), ArgsIdxs: []uint32{3}, Ordering: fullOrdering, Frame: rowsFrame(2, 3)},
+			{Func: windowFunc(distsqlpb.WindowerSpec_NTH_VALUE), ArgsIdxs: []uint32{3, 3}, Ordering: fullOrdering, Frame: rowsFrame(5, 5)},
+		},
+		{
+			{Func: aggregateFunc(distsqlpb.AggregatorSpec_SUM), ArgsIdxs: []uint32{3}},
+			{Func: aggregateFunc(distsqlpb.AggregatorSpec_COUNT), ArgsIdxs: []uint32{3}, Ordering: rangeOrdering},
+			{Func: aggregateFunc(distsqlpb.AggregatorSpec_MAX), ArgsIdxs: []uint32{3}, Ordering: fullOrdering, Frame: rowsFrame(1, 2)},
+			{Func: aggregateFunc(distsqlpb.AggregatorSpec_AVG), ArgsIdxs: []uint32{3}, Ordering: rangeOrdering, Frame: rangeFrame(2, 1)},
+			{Func: aggregateFunc(distsqlpb.AggregatorSpec_MIN), ArgsIdxs: []uint32{3}, Ordering: rangeOrdering, Frame: rangeFrame(0, 3)},
+		},
+	}
+	for _, partitionBy := range [][]uint32{nil, {0}} {
+		for _, windowFns := range testCases {
+			outputTypes := make([]types.T, len(inputTypes), len(inputTypes)+len(windowFns))
+			copy(outputTypes, inputTypes)
+			for i := range windowFns {
+				windowFns[i].FilterColIdx = -1
+				windowFns[i].OutputColIdx = uint32(len(outputTypes))
+				argTypes := make([]types.T, len(windowFns[i].ArgsIdxs))
+				for j, argIdx := range windowFns[i].ArgsIdxs {
+					argTypes[j] = inputTypes[argIdx]
+				}
+				_, outputType, err := GetWindowFunctionInfo(windowFns[i].Func, argTypes...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				outputTypes = append(outputTypes, *outputType)
+			}
+			windowerSpec := &distsqlpb.WindowerSpec{
+				PartitionBy: partitionBy,
+				WindowFns:   windowFns,
+			}
+			pspec := &distsqlpb.ProcessorSpec{
+				Input: []distsqlpb.InputSyncSpec{{ColumnTypes: inputTypes}},
+				Core:  distsqlpb.ProcessorCoreUnion{Windower: windowerSpec},
+			}
+			// With a memory limit, the window operators spill the partitions which
+			// don't fit within it to temporary storage (the whole input doesn't fit
+			// within either of them).
+			for _, memLimit := range []int64{0, 1 << 10, 1 << 12} {
+				if err := verifyColOperatorWithMemLimit(
+					true /* anyOrder */, [][]types.T{inputTypes}, []sqlbase.EncDatumRows{rows}, outputTypes, pspec, memLimit,
+				); err != nil {
+					t.Fatalf("%v with memory limit %d: %s", windowerSpec, memLimit, err)
+				}
+			}
+		}
+	}
+}
+
 // generateColumnOrdering produces a random ordering of nOrderingCols columns
 // on a table with nCols columns, so nOrderingCols must be not greater than
 // nCols
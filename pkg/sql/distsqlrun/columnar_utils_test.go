@@ -16,6 +16,7 @@ import (
 	"context"
 
 	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/sql/distsqlpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/exec"
@@ -37,6 +38,45 @@ func verifyColOperator(
 	inputs []sqlbase.EncDatumRows,
 	outputTypes []types.T,
 	pspec *distsqlpb.ProcessorSpec,
+) error {
+	return verifyColOperatorWithTxn(anyOrder, inputTypes, inputs, outputTypes, pspec, nil /* txn */)
+}
+
+// verifyColOperatorWithTxn is like verifyColOperator, but both the processor
+// and the columnar operator run within txn, which allows them to read tables.
+func verifyColOperatorWithTxn(
+	anyOrder bool,
+	inputTypes [][]types.T,
+	inputs []sqlbase.EncDatumRows,
+	outputTypes []types.T,
+	pspec *distsqlpb.ProcessorSpec,
+	txn *client.Txn,
+) error {
+	return verifyColOperatorImpl(anyOrder, inputTypes, inputs, outputTypes, pspec, txn, 0 /* memLimit */)
+}
+
+// verifyColOperatorWithMemLimit is like verifyColOperator, but the memory used
+// by the operators which can spill to temporary storage is limited to memLimit
+// bytes.
+func verifyColOperatorWithMemLimit(
+	anyOrder bool,
+	inputTypes [][]types.T,
+	inputs []sqlbase.EncDatumRows,
+	outputTypes []types.T,
+	pspec *distsqlpb.ProcessorSpec,
+	memLimit int64,
+) error {
+	return verifyColOperatorImpl(anyOrder, inputTypes, inputs, outputTypes, pspec, nil /* txn */, memLimit)
+}
+
+func verifyColOperatorImpl(
+	anyOrder bool,
+	inputTypes [][]types.T,
+	inputs []sqlbase.EncDatumRows,
+	outputTypes []types.T,
+	pspec *distsqlpb.ProcessorSpec,
+	txn *client.Txn,
+	memLimit int64,
 ) error {
 	ctx := context.Background()
 	st := cluster.MakeTestingClusterSettings()
@@ -55,7 +95,9 @@ func verifyColOperator(
 		Settings:    cluster.MakeTestingClusterSettings(),
 		TempStorage: tempEngine,
 		diskMonitor: diskMonitor,
+		txn:         txn,
 	}
+	flowCtx.testingKnobs.MemoryLimitBytes = memLimit
 
 	inputsProc := make([]RowSource, len(inputs))
 	inputsColOp := make([]RowSource, len(inputs))
@@ -82,7 +124,9 @@ func verifyColOperator(
 		columnarizers[i] = c
 	}
 
-	colOp, err := newColOperator(ctx, flowCtx, pspec, columnarizers)
+	var resources vectorizedFlowResources
+	defer resources.release(ctx)
+	colOp, err := newColOperator(ctx, flowCtx, pspec, columnarizers, &resources)
 	if err != nil {
 		return err
 	}
@@ -186,6 +186,10 @@ type Flow struct {
 
 	doneFn func()
 
+	// admissionDone, if set, is called on Cleanup to release the CPU slot
+	// which the flow was admitted with (see ServerImpl.admitFlow).
+	admissionDone func()
+
 	status flowStatus
 
 	// Cancel function for ctx. Call this to cancel the flow (safe to be called
@@ -195,6 +199,10 @@ type Flow struct {
 
 	// spec is the request that produced this flow. Only used for debugging.
 	spec *distsqlpb.FlowSpec
+
+	// vectorizedResources tracks the resources of the operators of a vectorized
+	// flow which may spill to temporary storage.
+	vectorizedResources vectorizedFlowResources
 }
 
 func newFlow(
@@ -398,6 +406,25 @@ func (f *Flow) setupInputSyncs(ctx context.Context) ([][]RowSource, error) {
 					return nil, err
 				}
 
+			case distsqlpb.InputSyncSpec_SERIAL_UNORDERED:
+				// Serial synchronizer: create a RowChannel for each input. The
+				// RowChannels of local streams may later be replaced with the
+				// processors producing them (see setupProcessors).
+				streams := make([]RowSource, len(is.Streams))
+				for i, s := range is.Streams {
+					rowChan := &RowChannel{}
+					rowChan.InitWithNumSenders(is.ColumnTypes, 1 /* numSenders */)
+					if err := f.setupInboundStream(ctx, s, rowChan); err != nil {
+						return nil, err
+					}
+					streams[i] = rowChan
+				}
+				var err error
+				sync, err = makeSerialSync(streams)
+				if err != nil {
+					return nil, err
+				}
+
 			default:
 				return nil, errors.Errorf("unsupported input sync type %s", is.Type)
 			}
@@ -454,6 +481,18 @@ func (f *Flow) setupProcessors(ctx context.Context, inputSyncs [][]RowSource) er
 					continue
 				}
 				for inIdx, in := range ps.Input {
+					if in.Type == distsqlpb.InputSyncSpec_SERIAL_UNORDERED {
+						// The serial synchronizer starts each of its sources only
+						// once the previous ones are exhausted, so it can only avoid
+						// executing processors that are fused with it.
+						for sIdx := range in.Streams {
+							if in.Streams[sIdx].StreamID == ospec.Streams[0].StreamID {
+								inputSyncs[pIdx][inIdx].(*serialSynchronizer).sources[sIdx] = source
+								return true
+							}
+						}
+						continue
+					}
 					// Look for "simple" inputs: an unordered input (which, by definition,
 					// doesn't require an ordered synchronizer), with a single input stream
 					// (which doesn't require a multiplexed RowChannel).
@@ -495,6 +534,7 @@ func (f *Flow) setup(ctx context.Context, spec *distsqlpb.FlowSpec) error {
 			log.VEventf(ctx, 1, "vectorized flow.")
 			return nil
 		}
+		f.vectorizedResources.release(ctx)
 		// Vectorization attempt failed with an error.
 		if f.EvalCtx.SessionData.Vectorize == sessiondata.VectorizeAlways {
 			// Only return the error if we are running a local planNode that is an
@@ -661,6 +701,9 @@ func (f *Flow) Cleanup(ctx context.Context) {
 	if f.status == FlowFinished {
 		panic("flow cleanup called twice")
 	}
+	// The monitors of the vectorized operators are children of the monitor
+	// closed below.
+	f.vectorizedResources.release(ctx)
 	// This closes the monitor opened in ServerImpl.setupFlow.
 	f.EvalCtx.Stop(ctx)
 	for _, p := range f.processors {
@@ -680,6 +723,10 @@ func (f *Flow) Cleanup(ctx context.Context) {
 	f.ctxCancel()
 	f.doneFn()
 	f.doneFn = nil
+	if f.admissionDone != nil {
+		f.admissionDone()
+		f.admissionDone = nil
+	}
 	sp.Finish()
 }
 
@@ -375,3 +375,85 @@ func makeOrderedSync(
 	}
 	return s, nil
 }
+
+// serialSynchronizer receives rows from multiple sources and produces a single
+// stream of rows, by returning all the rows of the first source, followed by
+// all the rows of the second source, and so on.
+//
+// A source is only started once all the previous sources are exhausted. If the
+// consumer doesn't need more rows (see ConsumerDone), the source being read is
+// drained and the remaining sources are closed without ever being started.
+// When the sources are processors fused with the synchronizer, this means that
+// they never execute.
+type serialSynchronizer struct {
+	ctx     context.Context
+	types   []types.T
+	sources []RowSource
+
+	// srcIdx is the index of the source currently being read. The sources
+	// before it are exhausted, and the sources after it haven't been started.
+	srcIdx int
+}
+
+var _ RowSource = &serialSynchronizer{}
+
+// OutputTypes is part of the RowSource interface.
+func (s *serialSynchronizer) OutputTypes() []types.T {
+	return s.types
+}
+
+// Start is part of the RowSource interface. Only the first source is started;
+// the other sources are started once the previous ones are exhausted.
+func (s *serialSynchronizer) Start(ctx context.Context) context.Context {
+	s.ctx = ctx
+	s.sources[0].Start(ctx)
+	return ctx
+}
+
+// Next is part of the RowSource interface.
+func (s *serialSynchronizer) Next() (sqlbase.EncDatumRow, *distsqlpb.ProducerMetadata) {
+	for s.srcIdx < len(s.sources) {
+		row, meta := s.sources[s.srcIdx].Next()
+		if row != nil || meta != nil {
+			return row, meta
+		}
+		// The current source is exhausted; move on to the next one.
+		s.srcIdx++
+		if s.srcIdx < len(s.sources) {
+			s.sources[s.srcIdx].Start(s.ctx)
+		}
+	}
+	return nil, nil
+}
+
+// ConsumerDone is part of the RowSource interface.
+func (s *serialSynchronizer) ConsumerDone() {
+	if s.srcIdx >= len(s.sources) {
+		return
+	}
+	// Drain the current source, and close the sources which haven't been
+	// started, since they can't produce any metadata.
+	s.sources[s.srcIdx].ConsumerDone()
+	for _, src := range s.sources[s.srcIdx+1:] {
+		src.ConsumerClosed()
+	}
+	s.sources = s.sources[:s.srcIdx+1]
+}
+
+// ConsumerClosed is part of the RowSource interface.
+func (s *serialSynchronizer) ConsumerClosed() {
+	for _, src := range s.sources[s.srcIdx:] {
+		src.ConsumerClosed()
+	}
+	s.srcIdx = len(s.sources)
+}
+
+func makeSerialSync(sources []RowSource) (*serialSynchronizer, error) {
+	if len(sources) < 2 {
+		return nil, errors.Errorf("only %d sources for serial synchronizer", len(sources))
+	}
+	return &serialSynchronizer{
+		types:   sources[0].OutputTypes(),
+		sources: sources,
+	}, nil
+}
@@ -17,11 +17,13 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/cockroachdb/cockroach/pkg/base"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/sql/distsqlpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
 	"github.com/cockroachdb/cockroach/pkg/util/encoding"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/pkg/errors"
@@ -275,3 +277,185 @@ func TestUnorderedSync(t *testing.T) {
 		t.Error("Did not receive expected error")
 	}
 }
+
+func TestSerialSync(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	rows := sqlbase.MakeIntRows(5, 1)
+	expectedMeta := &distsqlpb.ProducerMetadata{Err: errors.New("expected metadata")}
+	makeSources := func() []*RowBuffer {
+		sources := []*RowBuffer{
+			NewRowBuffer(sqlbase.OneIntCol, rows[:2], RowBufferArgs{}),
+			NewRowBuffer(sqlbase.OneIntCol, nil /* rows */, RowBufferArgs{}),
+			NewRowBuffer(sqlbase.OneIntCol, rows[2:], RowBufferArgs{}),
+		}
+		sources[1].Push(nil /* row */, expectedMeta)
+		return sources
+	}
+	makeSync := func(sources []*RowBuffer) *serialSynchronizer {
+		rowSources := make([]RowSource, len(sources))
+		for i := range sources {
+			rowSources[i] = sources[i]
+		}
+		s, err := makeSerialSync(rowSources)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.Start(context.Background())
+		return s
+	}
+
+	// The rows of each source are returned after the rows of the previous ones.
+	s := makeSync(makeSources())
+	var retRows sqlbase.EncDatumRows
+	metasFound := 0
+	for {
+		row, meta := s.Next()
+		if meta != nil {
+			if meta != expectedMeta {
+				t.Fatalf("unexpected meta %v, expected %v", meta, expectedMeta)
+			}
+			if len(retRows) != 2 {
+				t.Fatalf("expected metadata after 2 rows, found it after %d", len(retRows))
+			}
+			metasFound++
+			continue
+		}
+		if row == nil {
+			break
+		}
+		retRows = append(retRows, row)
+	}
+	if metasFound != 1 {
+		t.Fatalf("unexpected number of metadata items %d, expected 1", metasFound)
+	}
+	if expStr, retStr := rows.String(sqlbase.OneIntCol), retRows.String(sqlbase.OneIntCol); expStr != retStr {
+		t.Errorf("invalid results; expected:\n   %s\ngot:\n   %s", expStr, retStr)
+	}
+
+	// Once the consumer is done, only the current source is drained; the
+	// following sources are closed without being read.
+	sources := makeSources()
+	s = makeSync(sources)
+	if row, meta := s.Next(); row == nil || meta != nil {
+		t.Fatalf("expected a row, got %v, %v", row, meta)
+	}
+	s.ConsumerDone()
+	for {
+		row, meta := s.Next()
+		if meta != nil {
+			t.Fatalf("unexpected metadata: %v", meta)
+		}
+		if row == nil {
+			break
+		}
+	}
+	if sources[0].ConsumerStatus != DrainRequested || !sources[0].Done {
+		t.Errorf("expected the first source to be drained")
+	}
+	for _, src := range sources[1:] {
+		if src.ConsumerStatus != ConsumerClosed || src.Done {
+			t.Errorf("expected the remaining sources to be closed without being read")
+		}
+	}
+}
+
+// TestSerialSyncFlow verifies that processors feeding a serial synchronizer
+// are fused with it, and that they are only executed if the consumer needs
+// their rows.
+func TestSerialSyncFlow(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	s, _, _ := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(ctx)
+	distSQLSrv := s.DistSQLServer().(*ServerImpl)
+
+	rows := sqlbase.MakeIntRows(4, 1)
+	firstValues, err := generateValuesSpec(sqlbase.OneIntCol, rows[:2], 1 /* rowsPerChunk */)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secondValues, err := generateValuesSpec(sqlbase.OneIntCol, rows[2:], 1 /* rowsPerChunk */)
+	if err != nil {
+		t.Fatal(err)
+	}
+	localStream := func(id distsqlpb.StreamID) []distsqlpb.StreamEndpointSpec {
+		return []distsqlpb.StreamEndpointSpec{{StreamID: id, Type: distsqlpb.StreamEndpointSpec_LOCAL}}
+	}
+
+	testCases := []struct {
+		limit    uint64
+		expected sqlbase.EncDatumRows
+		// secondRead is true if the second Values processor is expected to
+		// execute.
+		secondRead bool
+	}{
+		{limit: 0, expected: rows, secondRead: true},
+		{limit: 1, expected: rows[:1], secondRead: false},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("limit=%d", tc.limit), func(t *testing.T) {
+			req := distsqlpb.SetupFlowRequest{Version: Version}
+			req.Flow = distsqlpb.FlowSpec{
+				Processors: []distsqlpb.ProcessorSpec{
+					{
+						Core: distsqlpb.ProcessorCoreUnion{Values: &firstValues},
+						Output: []distsqlpb.OutputRouterSpec{{
+							Type:    distsqlpb.OutputRouterSpec_PASS_THROUGH,
+							Streams: localStream(1),
+						}},
+					},
+					{
+						Core: distsqlpb.ProcessorCoreUnion{Values: &secondValues},
+						Output: []distsqlpb.OutputRouterSpec{{
+							Type:    distsqlpb.OutputRouterSpec_PASS_THROUGH,
+							Streams: localStream(2),
+						}},
+					},
+					{
+						Input: []distsqlpb.InputSyncSpec{{
+							Type:        distsqlpb.InputSyncSpec_SERIAL_UNORDERED,
+							Streams:     append(localStream(1), localStream(2)...),
+							ColumnTypes: sqlbase.OneIntCol,
+						}},
+						Core: distsqlpb.ProcessorCoreUnion{Noop: &distsqlpb.NoopCoreSpec{}},
+						Post: distsqlpb.PostProcessSpec{Limit: tc.limit},
+						Output: []distsqlpb.OutputRouterSpec{{
+							Type:    distsqlpb.OutputRouterSpec_PASS_THROUGH,
+							Streams: []distsqlpb.StreamEndpointSpec{{Type: distsqlpb.StreamEndpointSpec_SYNC_RESPONSE}},
+						}},
+					},
+				},
+			}
+
+			rb := NewRowBuffer(sqlbase.OneIntCol, nil /* rows */, RowBufferArgs{})
+			ctx, flow, err := distSQLSrv.SetupSyncFlow(ctx, &distSQLSrv.memMonitor, &req, rb)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer flow.Cleanup(ctx)
+
+			// Both Values processors are fused with the synchronizer.
+			if len(flow.processors) != 1 {
+				t.Fatalf("expected 1 processor to run in its own goroutine, found %d", len(flow.processors))
+			}
+			serialSync := flow.processors[0].(*noopProcessor).input.(*serialSynchronizer)
+			second := serialSync.sources[1].(*valuesProcessor)
+
+			if err := flow.Start(ctx, func() {}); err != nil {
+				t.Fatal(err)
+			}
+			flow.Wait()
+
+			retRows := rb.GetRowsNoMeta(t)
+			if expStr, retStr := tc.expected.String(sqlbase.OneIntCol), retRows.String(sqlbase.OneIntCol); expStr != retStr {
+				t.Errorf("invalid results; expected:\n   %s\ngot:\n   %s", expStr, retStr)
+			}
+			// A processor's context is only set once it is started.
+			if secondRead := second.Ctx != nil; secondRead != tc.secondRead {
+				t.Errorf("expected second Values processor to execute: %t, executed: %t", tc.secondRead, secondRead)
+			}
+		})
+	}
+}
@@ -0,0 +1,525 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package distsqlrun
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/distsqlpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/row"
+	"github.com/cockroachdb/cockroach/pkg/sql/scrub"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/pkg/errors"
+)
+
+const invertedJoinerBatchSize = 100
+
+// invertedJoinerState represents the state of the processor.
+type invertedJoinerState int
+
+const (
+	ijStateUnknown invertedJoinerState = iota
+	// ijReadingInput means that a batch of rows is being read from the input.
+	ijReadingInput
+	// ijPerformingLookup means we are performing an inverted index lookup for
+	// the current input row batch.
+	ijPerformingLookup
+	// ijEmittingRows means we are emitting the results of the index lookup.
+	ijEmittingRows
+)
+
+// invertedJoiner performs a join between `input` and an inverted index on a
+// JSON column of the specified table. For each input row, it derives inverted
+// index keys from the JSON value in the input column and outputs the input row
+// joined with every table row whose indexed value could contain the input
+// value.
+//
+// The inverted index keys of an input value are partitioned into groups; an
+// indexed value can only contain the input value if it has at least one key of
+// every group:
+//  - an array or object produces one group per path to a leaf, with the key for
+//    that path. Paths which end in an empty array or object can't be looked up
+//    and are skipped.
+//  - a scalar produces a single group with two keys: one for the scalar itself
+//    and one for an array which contains the scalar.
+// An input value without any groups (for example {} or []) is looked up using
+// a full scan of the index.
+//
+// The candidate rows are a superset of the rows which contain the input value,
+// so the containment predicate must be re-checked by a later stage. Only the
+// primary key columns of the table are produced.
+type invertedJoiner struct {
+	ProcessorBase
+
+	// runningState represents the state of the invertedJoiner. This is in
+	// addition to ProcessorBase.State - the runningState is only relevant when
+	// ProcessorBase.State == StateRunning.
+	runningState invertedJoinerState
+
+	desc      sqlbase.TableDescriptor
+	index     *sqlbase.IndexDescriptor
+	colIdxMap map[sqlbase.ColumnID]int
+
+	// fetcherInput wraps fetcher in a RowSource implementation and should be
+	// used to get rows from the fetcher.
+	fetcherInput   RowSource
+	fetcher        row.Fetcher
+	indexKeyPrefix []byte
+	alloc          sqlbase.DatumAlloc
+	rowAlloc       sqlbase.EncDatumRowAlloc
+
+	input      RowSource
+	inputTypes []types.T
+	// inputCol is the index of the JSON column in the input stream.
+	inputCol int
+
+	// Batch size for fetches. Not a constant so we can lower for testing.
+	batchSize int
+
+	// State variables for each batch of input rows.
+	inputRows sqlbase.EncDatumRows
+	// rowState contains the lookup state of each row in inputRows.
+	rowState []invertedJoinerRowState
+	// keyToGroups maps an inverted index key to the groups (of the input rows)
+	// which contain that key.
+	keyToGroups map[string][]invertedJoinerGroupRef
+	// fullScan is set if an input row in the batch requires a full scan of the
+	// index.
+	fullScan bool
+	// emitRowIdx is the index of the next input row whose candidates are
+	// emitted.
+	emitRowIdx int
+
+	// A scratch buffer, to avoid re-allocating.
+	combinedRow sqlbase.EncDatumRow
+}
+
+// invertedJoinerRowState is the lookup state of an input row.
+type invertedJoinerRowState struct {
+	// numGroups is the number of key groups of the row. If it is zero, every row
+	// in the index is a candidate (unless the input value is NULL, in which case
+	// skip is set).
+	numGroups int
+	skip      bool
+	// matches maps the primary key suffix of an index entry to the set of groups
+	// which matched it.
+	matches map[string]util.FastIntSet
+	// candidates are the index rows which matched all groups, in the order in
+	// which they were found.
+	candidates sqlbase.EncDatumRows
+}
+
+// invertedJoinerGroupRef identifies a group of keys of an input row.
+type invertedJoinerGroupRef struct {
+	rowIdx   int
+	groupIdx int
+}
+
+var _ Processor = &invertedJoiner{}
+var _ RowSource = &invertedJoiner{}
+var _ distsqlpb.MetadataSource = &invertedJoiner{}
+
+const invertedJoinerProcName = "inverted joiner"
+
+func newInvertedJoiner(
+	flowCtx *FlowCtx,
+	processorID int32,
+	spec *distsqlpb.InvertedJoinerSpec,
+	input RowSource,
+	post *distsqlpb.PostProcessSpec,
+	output RowReceiver,
+) (*invertedJoiner, error) {
+	ij := &invertedJoiner{
+		desc:        spec.Table,
+		input:       input,
+		inputTypes:  input.OutputTypes(),
+		inputCol:    int(spec.InputColumn),
+		batchSize:   invertedJoinerBatchSize,
+		keyToGroups: make(map[string][]invertedJoinerGroupRef),
+	}
+	if ij.inputCol >= len(ij.inputTypes) {
+		return nil, errors.Errorf(
+			"inverted joiner input column %d out of range (%d columns)", ij.inputCol, len(ij.inputTypes))
+	}
+	if ij.inputTypes[ij.inputCol].Family() != types.JsonFamily {
+		return nil, errors.Errorf(
+			"inverted joiner input column has type %s, expected JSON", ij.inputTypes[ij.inputCol].String())
+	}
+
+	var err error
+	ij.index, _, err = ij.desc.FindIndexByIndexIdx(int(spec.IndexIdx))
+	if err != nil {
+		return nil, err
+	}
+	if ij.index.Type != sqlbase.IndexDescriptor_INVERTED {
+		return nil, errors.Errorf("inverted joiner index %s is not inverted", ij.index.Name)
+	}
+	ij.colIdxMap = ij.desc.ColumnIdxMap()
+
+	// The internal schema of the inverted joiner is the input columns followed
+	// by the table columns.
+	columnTypes := ij.desc.ColumnTypes()
+	internalTypes := make([]types.T, 0, len(ij.inputTypes)+len(columnTypes))
+	internalTypes = append(internalTypes, ij.inputTypes...)
+	internalTypes = append(internalTypes, columnTypes...)
+	ij.combinedRow = make(sqlbase.EncDatumRow, 0, len(internalTypes))
+
+	if err := ij.Init(
+		ij,
+		post,
+		internalTypes,
+		flowCtx,
+		processorID,
+		output,
+		nil, /* memMonitor */
+		ProcStateOpts{
+			InputsToDrain: []RowSource{ij.input},
+			TrailingMetaCallback: func(ctx context.Context) []distsqlpb.ProducerMetadata {
+				ij.InternalClose()
+				return ij.generateMeta(ctx)
+			},
+		},
+	); err != nil {
+		return nil, err
+	}
+
+	// Only the primary key columns are available in the inverted index.
+	neededRightCols := ij.neededRightCols()
+	if !neededRightCols.SubsetOf(getIndexColSet(&ij.desc.PrimaryIndex, ij.colIdxMap)) {
+		return nil, errors.Errorf("inverted joiner can only produce primary key columns")
+	}
+
+	if _, _, err := initRowFetcher(
+		&ij.fetcher, &ij.desc, int(spec.IndexIdx), ij.colIdxMap, false, /* reverse */
+		neededRightCols, false /* isCheck */, &ij.alloc,
+		distsqlpb.ScanVisibility_PUBLIC,
+	); err != nil {
+		return nil, err
+	}
+	ij.fetcherInput = &rowFetcherWrapper{Fetcher: &ij.fetcher}
+
+	ij.indexKeyPrefix = sqlbase.MakeIndexKeyPrefix(&ij.desc, ij.index.ID)
+	return ij, nil
+}
+
+// neededRightCols returns the set of column indices which need to be fetched
+// from the table (ij.desc).
+func (ij *invertedJoiner) neededRightCols() util.FastIntSet {
+	neededCols := ij.out.neededColumns()
+
+	// Get the columns from the right side of the join and shift them over by
+	// the size of the left side so the right side starts at 0.
+	neededRightCols := util.MakeFastIntSet()
+	for i, ok := neededCols.Next(len(ij.inputTypes)); ok; i, ok = neededCols.Next(i + 1) {
+		neededRightCols.Add(i - len(ij.inputTypes))
+	}
+	return neededRightCols
+}
+
+// Start is part of the RowSource interface.
+func (ij *invertedJoiner) Start(ctx context.Context) context.Context {
+	ij.input.Start(ctx)
+	ij.fetcherInput.Start(ctx)
+	ij.runningState = ijReadingInput
+	return ij.StartInternal(ctx, invertedJoinerProcName)
+}
+
+// Next is part of the RowSource interface.
+func (ij *invertedJoiner) Next() (sqlbase.EncDatumRow, *distsqlpb.ProducerMetadata) {
+	// The inverted join is implemented as follows:
+	// - Read the input rows in batches.
+	// - For each batch, map the rows onto groups of inverted index keys and
+	//   perform an index lookup for all the keys.
+	// - Count the groups matched by each index entry, and collect the index
+	//   rows which matched all groups of an input row.
+	// - Join the collected index rows with the corresponding input rows, while
+	//   preserving the input order.
+	for ij.State == StateRunning {
+		var row sqlbase.EncDatumRow
+		var meta *distsqlpb.ProducerMetadata
+		switch ij.runningState {
+		case ijReadingInput:
+			ij.runningState, meta = ij.readInput()
+		case ijPerformingLookup:
+			ij.runningState, meta = ij.performLookup()
+		case ijEmittingRows:
+			ij.runningState, row = ij.emitRow()
+		default:
+			log.Fatalf(ij.Ctx, "unsupported state: %d", ij.runningState)
+		}
+		if row == nil && meta == nil {
+			continue
+		}
+		if meta != nil {
+			return nil, meta
+		}
+		if outRow := ij.ProcessRowHelper(row); outRow != nil {
+			return outRow, nil
+		}
+	}
+	return nil, ij.DrainHelper()
+}
+
+// readInput reads the next batch of input rows and starts an index scan.
+func (ij *invertedJoiner) readInput() (invertedJoinerState, *distsqlpb.ProducerMetadata) {
+	// Read the next batch of input rows.
+	for len(ij.inputRows) < ij.batchSize {
+		row, meta := ij.input.Next()
+		if meta != nil {
+			if meta.Err != nil {
+				ij.MoveToDraining(nil /* err */)
+				return ijStateUnknown, meta
+			}
+			return ijReadingInput, meta
+		}
+		if row == nil {
+			break
+		}
+		ij.inputRows = append(ij.inputRows, ij.rowAlloc.CopyRow(row))
+	}
+
+	if len(ij.inputRows) == 0 {
+		// We're done.
+		ij.MoveToDraining(nil)
+		return ijStateUnknown, ij.DrainHelper()
+	}
+
+	ij.rowState = make([]invertedJoinerRowState, len(ij.inputRows))
+	var spans roachpb.Spans
+	for i := range ij.inputRows {
+		groups, err := ij.generateKeyGroups(ij.inputRows[i])
+		if err != nil {
+			ij.MoveToDraining(err)
+			return ijStateUnknown, ij.DrainHelper()
+		}
+		state := &ij.rowState[i]
+		state.matches = make(map[string]util.FastIntSet)
+		if groups == nil {
+			state.skip = true
+			continue
+		}
+		state.numGroups = len(groups)
+		if len(groups) == 0 {
+			ij.fullScan = true
+			continue
+		}
+		for groupIdx, keys := range groups {
+			for _, key := range keys {
+				refs := ij.keyToGroups[string(key)]
+				if refs == nil {
+					spans = append(spans, roachpb.Span{Key: key, EndKey: key.PrefixEnd()})
+				}
+				ij.keyToGroups[string(key)] = append(refs, invertedJoinerGroupRef{rowIdx: i, groupIdx: groupIdx})
+			}
+		}
+	}
+	if ij.fullScan {
+		// The full scan covers all the other spans.
+		indexKey := roachpb.Key(ij.indexKeyPrefix)
+		spans = roachpb.Spans{{Key: indexKey, EndKey: indexKey.PrefixEnd()}}
+	}
+	if len(spans) == 0 {
+		// All of the input rows were filtered out. Skip the index lookup.
+		return ijEmittingRows, nil
+	}
+	err := ij.fetcher.StartScan(
+		ij.Ctx, ij.flowCtx.txn, spans, false /* limitBatches */, 0, /* limitHint */
+		ij.flowCtx.traceKV)
+	if err != nil {
+		ij.MoveToDraining(err)
+		return ijStateUnknown, ij.DrainHelper()
+	}
+	return ijPerformingLookup, nil
+}
+
+// generateKeyGroups returns the groups of inverted index keys for the JSON
+// value of the given input row (see the invertedJoiner comment). It returns nil
+// if the value is NULL, in which case the row has no matches.
+func (ij *invertedJoiner) generateKeyGroups(row sqlbase.EncDatumRow) ([][]roachpb.Key, error) {
+	encDatum := &row[ij.inputCol]
+	if err := encDatum.EnsureDecoded(&ij.inputTypes[ij.inputCol], &ij.alloc); err != nil {
+		return nil, err
+	}
+	if encDatum.Datum == tree.DNull {
+		return nil, nil
+	}
+	val := tree.UnwrapDatum(nil /* evalCtx */, encDatum.Datum).(*tree.DJSON).JSON
+
+	groups := [][]roachpb.Key{}
+	switch val.Type() {
+	case json.ArrayJSONType, json.ObjectJSONType:
+		paths, err := json.AllPaths(val)
+		if err != nil {
+			return nil, err
+		}
+		seen := make(map[string]struct{}, len(paths))
+		for i := range paths {
+			hasContainerLeaf, err := paths[i].HasContainerLeaf()
+			if err != nil {
+				return nil, err
+			}
+			if hasContainerLeaf {
+				continue
+			}
+			keys, err := ij.encodeKeys(paths[i])
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := seen[string(keys[0])]; ok {
+				continue
+			}
+			seen[string(keys[0])] = struct{}{}
+			groups = append(groups, keys)
+		}
+
+	default:
+		// A scalar is contained by the same scalar, or by an array which contains
+		// the scalar.
+		b := json.NewArrayBuilder(1)
+		b.Add(val)
+		scalarKeys, err := ij.encodeKeys(val)
+		if err != nil {
+			return nil, err
+		}
+		arrayKeys, err := ij.encodeKeys(b.Build())
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, append(scalarKeys, arrayKeys...))
+	}
+	return groups, nil
+}
+
+// encodeKeys returns the inverted index key for a JSON value with a single
+// path.
+func (ij *invertedJoiner) encodeKeys(val json.JSON) ([]roachpb.Key, error) {
+	keys, err := json.EncodeInvertedIndexKeys(ij.indexKeyPrefix, val)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) != 1 {
+		return nil, errors.Errorf("expected a single inverted index key, found %d", len(keys))
+	}
+	return []roachpb.Key{keys[0]}, nil
+}
+
+// performLookup reads all the index entries for the current batch and
+// collects, for each input row, the index rows which matched all its groups.
+func (ij *invertedJoiner) performLookup() (invertedJoinerState, *distsqlpb.ProducerMetadata) {
+	for {
+		// Construct a "partial key" of the inverted column, so we can match the
+		// keys stored in keyToGroups. The remainder of the key identifies the
+		// indexed row.
+		key, err := ij.fetcher.PartialKey(1 /* nCols */)
+		if err != nil {
+			ij.MoveToDraining(err)
+			return ijStateUnknown, ij.DrainHelper()
+		}
+		pkKey := string(ij.fetcher.Key()[len(key):])
+
+		indexRow, meta := ij.fetcherInput.Next()
+		if meta != nil {
+			ij.MoveToDraining(scrub.UnwrapScrubError(meta.Err))
+			return ijStateUnknown, ij.DrainHelper()
+		}
+		if indexRow == nil {
+			// Done with this input batch.
+			return ijEmittingRows, nil
+		}
+
+		for _, ref := range ij.keyToGroups[string(key)] {
+			state := &ij.rowState[ref.rowIdx]
+			state.addMatch(pkKey, ref.groupIdx, state.numGroups, indexRow, &ij.rowAlloc)
+		}
+		if ij.fullScan {
+			for i := range ij.rowState {
+				state := &ij.rowState[i]
+				if !state.skip && state.numGroups == 0 {
+					// Every index row is a candidate; treat the full scan as a single
+					// group.
+					state.addMatch(pkKey, 0 /* groupIdx */, 1 /* numGroups */, indexRow, &ij.rowAlloc)
+				}
+			}
+		}
+	}
+}
+
+// addMatch records that the index entry for the row identified by pkKey
+// matched the given group. The index row becomes a candidate once it has
+// matched all numGroups groups.
+func (s *invertedJoinerRowState) addMatch(
+	pkKey string,
+	groupIdx int,
+	numGroups int,
+	indexRow sqlbase.EncDatumRow,
+	rowAlloc *sqlbase.EncDatumRowAlloc,
+) {
+	groups := s.matches[pkKey]
+	if groups.Len() == numGroups || groups.Contains(groupIdx) {
+		// We already found the row, or this group was already matched.
+		return
+	}
+	groups.Add(groupIdx)
+	s.matches[pkKey] = groups
+	if groups.Len() == numGroups {
+		s.candidates = append(s.candidates, rowAlloc.CopyRow(indexRow))
+	}
+}
+
+// emitRow returns the next joined row of the current batch, if present.
+// Otherwise it prepares for another input batch.
+func (ij *invertedJoiner) emitRow() (invertedJoinerState, sqlbase.EncDatumRow) {
+	for ; ij.emitRowIdx < len(ij.rowState); ij.emitRowIdx++ {
+		state := &ij.rowState[ij.emitRowIdx]
+		if len(state.candidates) == 0 {
+			continue
+		}
+		indexRow := state.candidates[0]
+		state.candidates = state.candidates[1:]
+		ij.combinedRow = append(ij.combinedRow[:0], ij.inputRows[ij.emitRowIdx]...)
+		ij.combinedRow = append(ij.combinedRow, indexRow...)
+		return ijEmittingRows, ij.combinedRow
+	}
+
+	// Ready for another input batch. Reset state.
+	ij.inputRows = ij.inputRows[:0]
+	ij.rowState = nil
+	ij.emitRowIdx = 0
+	ij.keyToGroups = make(map[string][]invertedJoinerGroupRef)
+	ij.fullScan = false
+	return ijReadingInput, nil
+}
+
+// ConsumerClosed is part of the RowSource interface.
+func (ij *invertedJoiner) ConsumerClosed() {
+	// The consumer is done, Next() will not be called again.
+	ij.InternalClose()
+}
+
+func (ij *invertedJoiner) generateMeta(ctx context.Context) []distsqlpb.ProducerMetadata {
+	if meta := getTxnCoordMeta(ctx, ij.flowCtx.txn); meta != nil {
+		return []distsqlpb.ProducerMetadata{{TxnCoordMeta: meta}}
+	}
+	return nil
+}
+
+// DrainMeta is part of the MetadataSource interface.
+func (ij *invertedJoiner) DrainMeta(ctx context.Context) []distsqlpb.ProducerMetadata {
+	return ij.generateMeta(ctx)
+}
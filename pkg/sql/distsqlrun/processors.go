@@ -1008,6 +1008,12 @@ func newProcessor(
 		}
 		return newJoinReader(flowCtx, processorID, core.JoinReader, inputs[0], post, outputs[0])
 	}
+	if core.InvertedJoiner != nil {
+		if err := checkNumInOut(inputs, outputs, 1, 1); err != nil {
+			return nil, err
+		}
+		return newInvertedJoiner(flowCtx, processorID, core.InvertedJoiner, inputs[0], post, outputs[0])
+	}
 	if core.Sorter != nil {
 		if err := checkNumInOut(inputs, outputs, 1, 1); err != nil {
 			return nil, err
@@ -19,7 +19,6 @@ import (
 
 	"github.com/axiomhq/hyperloglog"
 	"github.com/cockroachdb/cockroach/pkg/sql/distsqlpb"
-	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 	"github.com/cockroachdb/cockroach/pkg/sql/stats"
@@ -96,8 +95,8 @@ func newSamplerProcessor(
 		if _, ok := supportedSketchTypes[s.SketchType]; !ok {
 			return nil, errors.Errorf("unsupported sketch type %s", s.SketchType)
 		}
-		if len(s.Columns) != 1 {
-			return nil, pgerror.UnimplementedWithIssue(34422, "multi-column statistics are not supported yet.")
+		if len(s.Columns) == 0 {
+			return nil, errors.Errorf("sketch has no columns")
 		}
 	}
 
@@ -260,15 +259,20 @@ func (s *samplerProcessor) mainLoop(ctx context.Context) (earlyExit bool, err er
 
 		var intbuf [8]byte
 		for i := range s.sketches {
-			// TODO(radu): for multi-column sketches, we will need to do this for all
-			// columns.
-			col := s.sketches[i].spec.Columns[0]
+			cols := s.sketches[i].spec.Columns
 			s.sketches[i].numRows++
-			if row[col].IsNull() {
+			hasNull := false
+			for _, col := range cols {
+				if row[col].IsNull() {
+					hasNull = true
+					break
+				}
+			}
+			if hasNull {
 				s.sketches[i].numNulls++
 				continue
 			}
-			if s.outTypes[col].Family() == types.IntFamily {
+			if col := cols[0]; len(cols) == 1 && s.outTypes[col].Family() == types.IntFamily {
 				// Fast path for integers.
 				// TODO(radu): make this more general.
 				val, err := row[col].GetInt()
@@ -290,10 +294,14 @@ func (s *samplerProcessor) mainLoop(ctx context.Context) (earlyExit bool, err er
 				s.sketches[i].sketch.Insert(intbuf[:])
 			} else {
 				// We need to use a KEY encoding because equal values should have the same
-				// encoding.
-				buf, err = row[col].Encode(&s.outTypes[col], &da, sqlbase.DatumEncoding_ASCENDING_KEY, buf[:0])
-				if err != nil {
-					return false, err
+				// encoding. Key encodings are self-delimiting, so the concatenation of
+				// the encodings of multiple columns is equal only for equal tuples.
+				buf = buf[:0]
+				for _, col := range cols {
+					buf, err = row[col].Encode(&s.outTypes[col], &da, sqlbase.DatumEncoding_ASCENDING_KEY, buf)
+					if err != nil {
+						return false, err
+					}
 				}
 				s.sketches[i].sketch.Insert(buf)
 			}
@@ -147,12 +147,14 @@ func TestSamplerSketch(t *testing.T) {
 		{2, 6},
 		{1, 7},
 		{2, 8},
+		{2, 1},
 		{-1, 1},
 		{-1, 3},
 		{1, -1},
 	}
-	cardinalities := []int{2, 8}
-	numNulls := []int{2, 1}
+	// The third sketch is on both columns.
+	cardinalities := []int{2, 8, 9}
+	numNulls := []int{2, 1, 3}
 
 	rows := sqlbase.GenEncDatumRowsInt(inputRows)
 	in := NewRowBuffer(sqlbase.TwoIntCols, rows, RowBufferArgs{})
@@ -187,6 +189,10 @@ func TestSamplerSketch(t *testing.T) {
 				SketchType: distsqlpb.SketchType_HLL_PLUS_PLUS_V1,
 				Columns:    []uint32{1},
 			},
+			{
+				SketchType: distsqlpb.SketchType_HLL_PLUS_PLUS_V1,
+				Columns:    []uint32{0, 1},
+			},
 		},
 	}
 	p, err := newSamplerProcessor(&flowCtx, 0 /* processorID */, spec, in, &distsqlpb.PostProcessSpec{}, out)
@@ -210,9 +216,9 @@ func TestSamplerSketch(t *testing.T) {
 		rows = append(rows, row)
 	}
 
-	// We expect one sampled row and two sketch rows.
-	if len(rows) != 3 {
-		t.Fatalf("expected 3 rows, got %v\n", rows.String(outTypes))
+	// We expect one sampled row and three sketch rows.
+	if len(rows) != 4 {
+		t.Fatalf("expected 4 rows, got %v\n", rows.String(outTypes))
 	}
 	rows = rows[1:]
 
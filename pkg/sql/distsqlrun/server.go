@@ -34,7 +34,9 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlutil"
 	"github.com/cockroachdb/cockroach/pkg/storage/diskmap"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
 	"github.com/cockroachdb/cockroach/pkg/storage/storagebase"
+	"github.com/cockroachdb/cockroach/pkg/util/admission"
 	"github.com/cockroachdb/cockroach/pkg/util/contextutil"
 	"github.com/cockroachdb/cockroach/pkg/util/duration"
 	"github.com/cockroachdb/cockroach/pkg/util/envutil"
@@ -74,7 +76,7 @@ import (
 //
 // ATTENTION: When updating these fields, add to version_history.txt explaining
 // what changed.
-const Version distsqlpb.DistSQLVersion = 23
+const Version distsqlpb.DistSQLVersion = 24
 
 // MinAcceptedVersion is the oldest version that the server is
 // compatible with; see above.
@@ -169,6 +171,10 @@ type ServerConfig struct {
 	// executors. The idea is that a higher-layer binds some of the arguments
 	// required, so that users of ServerConfig don't have to care about them.
 	SessionBoundInternalExecutorFactory sqlutil.SessionBoundInternalExecutorFactory
+
+	// AdmissionQueue, if set, admits flows set up by remote nodes before they
+	// are scheduled.
+	AdmissionQueue *admission.WorkQueue
 }
 
 // RuntimeStats is an interface through which the distsqlrun layer can get
@@ -559,14 +565,26 @@ func (ds *ServerImpl) SetupFlow(
 	log.VEventf(ctx, 1, "received SetupFlow request from n%v for flow %v", req.Flow.Gateway, req.Flow.FlowID)
 	parentSpan := opentracing.SpanFromContext(ctx)
 
+	// Wait for admission using the RPC's context, so that the wait is
+	// abandoned if the gateway gives up on the flow.
+	admissionDone, err := ds.admitFlow(ctx, req)
+	if err != nil {
+		return &distsqlpb.SimpleResponse{Error: distsqlpb.NewError(err)}, nil
+	}
+
 	// Note: the passed context will be canceled when this RPC completes, so we
 	// can't associate it with the flow.
 	ctx = ds.AnnotateCtx(context.Background())
 	ctx, f, err := ds.setupFlow(ctx, parentSpan, &ds.memMonitor, req, nil /* syncFlowConsumer */, LocalState{})
 	if err == nil {
+		// The flow holds on to its CPU slot until it is cleaned up. Flows which
+		// fail to be scheduled are never cleaned up, so the slot is released
+		// below instead.
+		f.admissionDone = admissionDone
 		err = ds.flowScheduler.ScheduleFlow(ctx, f)
 	}
 	if err != nil {
+		admissionDone()
 		// We return flow deployment errors in the response so that they are
 		// packaged correctly over the wire. If we return them directly to this
 		// function, they become part of an rpc error.
@@ -575,6 +593,45 @@ func (ds *ServerImpl) SetupFlow(
 	return &distsqlpb.SimpleResponse{}, nil
 }
 
+// admitFlow subjects a flow set up by a remote node to admission control,
+// blocking until it is admitted or the context is canceled. Flows performing
+// bulk operations are admitted with low priority. On success, the returned
+// function must be called once the flow has finished: the flow holds a CPU
+// slot for its lifetime. Flows commonly block on KV requests, which are
+// admitted to the same slots; the granter adds slots while they're all used
+// but the CPU isn't, so such flows don't starve their own requests.
+func (ds *ServerImpl) admitFlow(
+	ctx context.Context, req *distsqlpb.SetupFlowRequest,
+) (func(), error) {
+	if ds.AdmissionQueue == nil || !admission.DistSQLAdmissionControlEnabled.Get(&ds.Settings.SV) {
+		return func() {}, nil
+	}
+	info := admission.WorkInfo{
+		Priority:   admission.NormalPri,
+		CreateTime: timeutil.Now().UnixNano(),
+	}
+	if meta := req.TxnCoordMeta; meta != nil {
+		switch meta.Txn.Priority {
+		case enginepb.MaxTxnPriority:
+			info.Priority = admission.HighPri
+		case enginepb.MinTxnPriority:
+			info.Priority = admission.LowPri
+		}
+	}
+	for i := range req.Flow.Processors {
+		core := &req.Flow.Processors[i].Core
+		if core.Backfiller != nil || core.ReadImport != nil || core.SSTWriter != nil ||
+			core.CSVWriter != nil || core.Sampler != nil {
+			info.Priority = admission.LowPri
+			break
+		}
+	}
+	if err := ds.AdmissionQueue.Admit(ctx, info); err != nil {
+		return nil, err
+	}
+	return ds.AdmissionQueue.AdmittedWorkDone, nil
+}
+
 func (ds *ServerImpl) flowStreamInt(
 	ctx context.Context, stream distsqlpb.DistSQL_FlowStreamServer,
 ) error {
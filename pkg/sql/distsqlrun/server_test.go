@@ -17,6 +17,7 @@ import (
 	"fmt"
 	"io"
 	"testing"
+	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/base"
 	"github.com/cockroachdb/cockroach/pkg/gossip"
@@ -26,7 +27,11 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
 	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/admission"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+	"github.com/pkg/errors"
 )
 
 func TestServer(t *testing.T) {
@@ -172,3 +177,135 @@ func TestDistSQLServerGossipsVersion(t *testing.T) {
 			Version, MinAcceptedVersion, v.Version, v.MinAcceptedVersion)
 	}
 }
+
+// TestSetupFlowAdmission verifies that flows set up by remote nodes wait for a
+// CPU slot, and hold on to it until they have been cleaned up.
+func TestSetupFlowAdmission(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	s, _, _ := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(ctx)
+	conn, err := s.RPCContext().GRPCDialNode(s.ServingAddr(), s.NodeID()).Connect(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Set up a DistSQL server admitting flows to the slots of a coordinator
+	// which isn't started, so that the number of slots doesn't change.
+	coord := admission.NewGrantCoordinator(log.AmbientContext{}, s.ClusterSettings(), time.Minute)
+	metrics := coord.Metrics()
+	cfg := s.DistSQLServer().(*ServerImpl).ServerConfig
+	cfg.AdmissionQueue = coord.CPUWorkQueue()
+	distSQLSrv := NewServer(ctx, cfg)
+
+	// Use up all slots.
+	totalSlots := int(metrics.TotalSlots.Value())
+	for i := 0; i < totalSlots; i++ {
+		if err := cfg.AdmissionQueue.Admit(ctx, admission.WorkInfo{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The flow sends a row to a flow running on the test server, which is set
+	// up below.
+	fid := distsqlpb.FlowID{UUID: uuid.MakeV4()}
+	req1 := &distsqlpb.SetupFlowRequest{Version: Version}
+	req1.Flow = distsqlpb.FlowSpec{
+		FlowID: fid,
+		Processors: []distsqlpb.ProcessorSpec{{
+			Core: distsqlpb.ProcessorCoreUnion{Values: &distsqlpb.ValuesCoreSpec{NumRows: 1}},
+			Output: []distsqlpb.OutputRouterSpec{{
+				Type: distsqlpb.OutputRouterSpec_PASS_THROUGH,
+				Streams: []distsqlpb.StreamEndpointSpec{
+					{Type: distsqlpb.StreamEndpointSpec_REMOTE, StreamID: 1, TargetNodeID: s.NodeID()},
+				},
+			}},
+		}},
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		resp, err := distSQLSrv.SetupFlow(ctx, req1)
+		if err == nil && resp.Error != nil {
+			err = resp.Error.ErrorDetail()
+		}
+		errCh <- err
+	}()
+
+	// The flow waits for a slot.
+	testutils.SucceedsSoon(t, func() error {
+		if n := metrics.CPUQueue.WaitQueueLength.Value(); n != 1 {
+			return errors.Errorf("expected 1 flow waiting for admission, found %d", n)
+		}
+		return nil
+	})
+	select {
+	case err := <-errCh:
+		t.Fatalf("flow set up without a slot: %v", err)
+	default:
+	}
+	cfg.AdmissionQueue.AdmittedWorkDone()
+	if err := <-errCh; err != nil {
+		t.Fatal(err)
+	}
+	if used := int(metrics.UsedSlots.Value()); used != totalSlots {
+		t.Fatalf("expected the flow to hold a slot, found %d of %d slots used", used, totalSlots)
+	}
+
+	// Consume the output of the flow, which then finishes.
+	req2 := &distsqlpb.SetupFlowRequest{Version: Version}
+	req2.Flow = distsqlpb.FlowSpec{
+		FlowID: fid,
+		Processors: []distsqlpb.ProcessorSpec{{
+			Input: []distsqlpb.InputSyncSpec{{
+				Type:    distsqlpb.InputSyncSpec_UNORDERED,
+				Streams: []distsqlpb.StreamEndpointSpec{{Type: distsqlpb.StreamEndpointSpec_REMOTE, StreamID: 1}},
+			}},
+			Core: distsqlpb.ProcessorCoreUnion{Noop: &distsqlpb.NoopCoreSpec{}},
+			Output: []distsqlpb.OutputRouterSpec{{
+				Type:    distsqlpb.OutputRouterSpec_PASS_THROUGH,
+				Streams: []distsqlpb.StreamEndpointSpec{{Type: distsqlpb.StreamEndpointSpec_SYNC_RESPONSE}},
+			}},
+		}},
+	}
+	stream, err := distsqlpb.NewDistSQLClient(conn).RunSyncFlow(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.Send(&distsqlpb.ConsumerSignal{SetupFlowRequest: req2}); err != nil {
+		t.Fatal(err)
+	}
+	var decoder StreamDecoder
+	var rows sqlbase.EncDatumRows
+	var metas []distsqlpb.ProducerMetadata
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			t.Fatal(err)
+		}
+		if err := decoder.AddMessage(msg); err != nil {
+			t.Fatal(err)
+		}
+		rows, metas = testGetDecodedRows(t, &decoder, rows, metas)
+	}
+	if len(metas) != 0 {
+		t.Fatalf("unexpected metadata: %v", metas)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("expected 1 row, found %d", len(rows))
+	}
+
+	// The slot is released once the flow has been cleaned up.
+	testutils.SucceedsSoon(t, func() error {
+		if used := int(metrics.UsedSlots.Value()); used != totalSlots-1 {
+			return errors.Errorf("expected %d slots to be used, found %d", totalSlots-1, used)
+		}
+		return nil
+	})
+	for i := 0; i < totalSlots-1; i++ {
+		cfg.AdmissionQueue.AdmittedWorkDone()
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package distsqlrun
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/exec"
+	"github.com/cockroachdb/cockroach/pkg/sql/exec/types"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+)
+
+// vectorizedFlowResources tracks the memory monitors, memory accounts and
+// spilling configs created while planning the operators of a vectorized flow.
+// They are released when the flow is cleaned up, or when setting up the
+// vectorized flow fails.
+type vectorizedFlowResources struct {
+	spillingConfigs []*exec.SpillingConfig
+	memAccounts     []*mon.BoundAccount
+	monitors        []*mon.BytesMonitor
+}
+
+// newSpillingConfig returns a SpillingConfig for an operator buffering its
+// input of the given types, or nil if the operator should keep its whole input
+// in memory. typs can be nil if the operator spills rows rather than batches,
+// which supports all types. Like the row-based processors, the operator is limited to the
+// sql.distsql.temp_storage.workmem setting (or to the MemoryLimitBytes testing
+// knob) once it is allowed to use temporary storage.
+func (r *vectorizedFlowResources) newSpillingConfig(
+	ctx context.Context, flowCtx *FlowCtx, name string, typs []types.T, useTempStorage bool,
+) *exec.SpillingConfig {
+	if r == nil || flowCtx.TempStorage == nil || flowCtx.diskMonitor == nil {
+		return nil
+	}
+	if !useTempStorage && flowCtx.testingKnobs.MemoryLimitBytes <= 0 {
+		return nil
+	}
+	if typs != nil && !exec.SpillingSupported(typs) {
+		return nil
+	}
+	limit := flowCtx.testingKnobs.MemoryLimitBytes
+	if limit <= 0 {
+		limit = settingWorkMemBytes.Get(&flowCtx.Settings.SV)
+	}
+	limitedMon := mon.MakeMonitorInheritWithLimit(name+"-limited", limit, flowCtx.EvalCtx.Mon)
+	limitedMon.Start(ctx, flowCtx.EvalCtx.Mon, mon.BoundAccount{})
+	diskMon := NewMonitor(ctx, flowCtx.diskMonitor, name+"-disk")
+	r.monitors = append(r.monitors, &limitedMon, diskMon)
+
+	cfg := &exec.SpillingConfig{
+		MemMonitor:  &limitedMon,
+		DiskMonitor: diskMon,
+		TempStorage: flowCtx.TempStorage,
+	}
+	r.spillingConfigs = append(r.spillingConfigs, cfg)
+	return cfg
+}
+
+// newMemAccount returns an account bound to a new memory monitor, for an
+// operator which buffers tuples in memory without being able to spill them. The
+// monitor is limited by the MemoryLimitBytes testing knob, if set. nil is
+// returned if resources is nil, in which case the operator doesn't account for
+// its memory.
+func (r *vectorizedFlowResources) newMemAccount(
+	ctx context.Context, flowCtx *FlowCtx, name string,
+) *mon.BoundAccount {
+	if r == nil {
+		return nil
+	}
+	var memMon *mon.BytesMonitor
+	if limit := flowCtx.testingKnobs.MemoryLimitBytes; limit > 0 {
+		limitedMon := mon.MakeMonitorInheritWithLimit(name+"-limited", limit, flowCtx.EvalCtx.Mon)
+		limitedMon.Start(ctx, flowCtx.EvalCtx.Mon, mon.BoundAccount{})
+		memMon = &limitedMon
+	} else {
+		memMon = NewMonitor(ctx, flowCtx.EvalCtx.Mon, name+"-mem")
+	}
+	r.monitors = append(r.monitors, memMon)
+	acc := memMon.MakeBoundAccount()
+	r.memAccounts = append(r.memAccounts, &acc)
+	return &acc
+}
+
+// release closes the spilling configs and the memory accounts, and stops the
+// monitors. It must be called before the flow's memory monitor is stopped.
+func (r *vectorizedFlowResources) release(ctx context.Context) {
+	for _, cfg := range r.spillingConfigs {
+		cfg.Close(ctx)
+	}
+	r.spillingConfigs = nil
+	for _, acc := range r.memAccounts {
+		acc.Close(ctx)
+	}
+	r.memAccounts = nil
+	for _, m := range r.monitors {
+		m.Stop(ctx)
+	}
+	r.monitors = nil
+}
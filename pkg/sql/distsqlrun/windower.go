@@ -471,78 +471,10 @@ func (w *windower) processPartition(
 			FilterColIdx: windowFn.filterColIdx,
 		}
 
-		if windowFn.frame != nil {
-			frameRun.Frame = windowFn.frame.ConvertToAST()
-			startBound, endBound := windowFn.frame.Bounds.Start, windowFn.frame.Bounds.End
-			if startBound.BoundType == distsqlpb.WindowerSpec_Frame_OFFSET_PRECEDING ||
-				startBound.BoundType == distsqlpb.WindowerSpec_Frame_OFFSET_FOLLOWING {
-				switch windowFn.frame.Mode {
-				case distsqlpb.WindowerSpec_Frame_ROWS:
-					frameRun.StartBoundOffset = tree.NewDInt(tree.DInt(int(startBound.IntOffset)))
-				case distsqlpb.WindowerSpec_Frame_RANGE:
-					datum, rem, err := sqlbase.DecodeTableValue(&w.datumAlloc, &startBound.OffsetType.Type, startBound.TypedOffset)
-					if err != nil {
-						return pgerror.NewAssertionErrorWithWrappedErrf(err,
-							"error decoding %d bytes", log.Safe(len(startBound.TypedOffset)))
-					}
-					if len(rem) != 0 {
-						return pgerror.AssertionFailedf(
-							"%d trailing bytes in encoded value", log.Safe(len(rem)))
-					}
-					frameRun.StartBoundOffset = datum
-				case distsqlpb.WindowerSpec_Frame_GROUPS:
-					frameRun.StartBoundOffset = tree.NewDInt(tree.DInt(int(startBound.IntOffset)))
-				default:
-					return pgerror.AssertionFailedf(
-						"unexpected WindowFrameMode: %d", log.Safe(windowFn.frame.Mode))
-				}
-			}
-			if endBound != nil {
-				if endBound.BoundType == distsqlpb.WindowerSpec_Frame_OFFSET_PRECEDING ||
-					endBound.BoundType == distsqlpb.WindowerSpec_Frame_OFFSET_FOLLOWING {
-					switch windowFn.frame.Mode {
-					case distsqlpb.WindowerSpec_Frame_ROWS:
-						frameRun.EndBoundOffset = tree.NewDInt(tree.DInt(int(endBound.IntOffset)))
-					case distsqlpb.WindowerSpec_Frame_RANGE:
-						datum, rem, err := sqlbase.DecodeTableValue(&w.datumAlloc, &endBound.OffsetType.Type, endBound.TypedOffset)
-						if err != nil {
-							return pgerror.NewAssertionErrorWithWrappedErrf(err,
-								"error decoding %d bytes", log.Safe(len(endBound.TypedOffset)))
-						}
-						if len(rem) != 0 {
-							return pgerror.AssertionFailedf(
-								"%d trailing bytes in encoded value", log.Safe(len(rem)))
-						}
-						frameRun.EndBoundOffset = datum
-					case distsqlpb.WindowerSpec_Frame_GROUPS:
-						frameRun.EndBoundOffset = tree.NewDInt(tree.DInt(int(endBound.IntOffset)))
-					default:
-						return pgerror.AssertionFailedf("unexpected WindowFrameMode: %d",
-							log.Safe(windowFn.frame.Mode))
-					}
-				}
-			}
-			if frameRun.RangeModeWithOffsets() {
-				ordCol := windowFn.ordering.Columns[0]
-				frameRun.OrdColIdx = int(ordCol.ColIdx)
-				// We need this +1 because encoding.Direction has extra value "_"
-				// as zeroth "entry" which its proto equivalent doesn't have.
-				frameRun.OrdDirection = encoding.Direction(ordCol.Direction + 1)
-
-				colTyp := &w.inputTypes[ordCol.ColIdx]
-				// Type of offset depends on the ordering column's type.
-				offsetTyp := colTyp
-				if types.IsDateTimeType(colTyp) {
-					// For datetime related ordering columns, offset must be an Interval.
-					offsetTyp = types.Interval
-				}
-				plusOp, minusOp, found := tree.WindowFrameRangeOps{}.LookupImpl(colTyp, offsetTyp)
-				if !found {
-					return pgerror.Newf(pgerror.CodeWindowingError,
-						"given logical offset cannot be combined with ordering column")
-				}
-				frameRun.PlusOp, frameRun.MinusOp = plusOp, minusOp
-			}
+		if err := initWindowFrameRun(
+			frameRun, windowFn.frame, windowFn.ordering, w.inputTypes, &w.datumAlloc,
+		); err != nil {
+			return err
 		}
 
 		builtin := windowFn.create(evalCtx)
@@ -627,6 +559,93 @@ func (w *windower) processPartition(
 	return nil
 }
 
+// initWindowFrameRun initializes the fields of frameRun which describe the
+// given frame of a window function with the given ordering over columns of
+// inputTypes. The offsets of the frame bounds are decoded using datumAlloc.
+func initWindowFrameRun(
+	frameRun *tree.WindowFrameRun,
+	frame *distsqlpb.WindowerSpec_Frame,
+	ordering distsqlpb.Ordering,
+	inputTypes []types.T,
+	datumAlloc *sqlbase.DatumAlloc,
+) error {
+	if frame == nil {
+		return nil
+	}
+	frameRun.Frame = frame.ConvertToAST()
+	startBound, endBound := frame.Bounds.Start, frame.Bounds.End
+	if startBound.BoundType == distsqlpb.WindowerSpec_Frame_OFFSET_PRECEDING ||
+		startBound.BoundType == distsqlpb.WindowerSpec_Frame_OFFSET_FOLLOWING {
+		switch frame.Mode {
+		case distsqlpb.WindowerSpec_Frame_ROWS:
+			frameRun.StartBoundOffset = tree.NewDInt(tree.DInt(int(startBound.IntOffset)))
+		case distsqlpb.WindowerSpec_Frame_RANGE:
+			datum, rem, err := sqlbase.DecodeTableValue(datumAlloc, &startBound.OffsetType.Type, startBound.TypedOffset)
+			if err != nil {
+				return pgerror.NewAssertionErrorWithWrappedErrf(err,
+					"error decoding %d bytes", log.Safe(len(startBound.TypedOffset)))
+			}
+			if len(rem) != 0 {
+				return pgerror.AssertionFailedf(
+					"%d trailing bytes in encoded value", log.Safe(len(rem)))
+			}
+			frameRun.StartBoundOffset = datum
+		case distsqlpb.WindowerSpec_Frame_GROUPS:
+			frameRun.StartBoundOffset = tree.NewDInt(tree.DInt(int(startBound.IntOffset)))
+		default:
+			return pgerror.AssertionFailedf(
+				"unexpected WindowFrameMode: %d", log.Safe(frame.Mode))
+		}
+	}
+	if endBound != nil {
+		if endBound.BoundType == distsqlpb.WindowerSpec_Frame_OFFSET_PRECEDING ||
+			endBound.BoundType == distsqlpb.WindowerSpec_Frame_OFFSET_FOLLOWING {
+			switch frame.Mode {
+			case distsqlpb.WindowerSpec_Frame_ROWS:
+				frameRun.EndBoundOffset = tree.NewDInt(tree.DInt(int(endBound.IntOffset)))
+			case distsqlpb.WindowerSpec_Frame_RANGE:
+				datum, rem, err := sqlbase.DecodeTableValue(datumAlloc, &endBound.OffsetType.Type, endBound.TypedOffset)
+				if err != nil {
+					return pgerror.NewAssertionErrorWithWrappedErrf(err,
+						"error decoding %d bytes", log.Safe(len(endBound.TypedOffset)))
+				}
+				if len(rem) != 0 {
+					return pgerror.AssertionFailedf(
+						"%d trailing bytes in encoded value", log.Safe(len(rem)))
+				}
+				frameRun.EndBoundOffset = datum
+			case distsqlpb.WindowerSpec_Frame_GROUPS:
+				frameRun.EndBoundOffset = tree.NewDInt(tree.DInt(int(endBound.IntOffset)))
+			default:
+				return pgerror.AssertionFailedf("unexpected WindowFrameMode: %d",
+					log.Safe(frame.Mode))
+			}
+		}
+	}
+	if frameRun.RangeModeWithOffsets() {
+		ordCol := ordering.Columns[0]
+		frameRun.OrdColIdx = int(ordCol.ColIdx)
+		// We need this +1 because encoding.Direction has extra value "_"
+		// as zeroth "entry" which its proto equivalent doesn't have.
+		frameRun.OrdDirection = encoding.Direction(ordCol.Direction + 1)
+
+		colTyp := &inputTypes[ordCol.ColIdx]
+		// Type of offset depends on the ordering column's type.
+		offsetTyp := colTyp
+		if types.IsDateTimeType(colTyp) {
+			// For datetime related ordering columns, offset must be an Interval.
+			offsetTyp = types.Interval
+		}
+		plusOp, minusOp, found := tree.WindowFrameRangeOps{}.LookupImpl(colTyp, offsetTyp)
+		if !found {
+			return pgerror.Newf(pgerror.CodeWindowingError,
+				"given logical offset cannot be combined with ordering column")
+		}
+		frameRun.PlusOp, frameRun.MinusOp = plusOp, minusOp
+	}
+	return nil
+}
+
 // computeWindowFunctions computes all window functions over all partitions.
 // Partitions are processed one at a time with the underlying row container
 // reused (and reordered if needed).
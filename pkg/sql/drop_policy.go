@@ -0,0 +1,67 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package sql
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+)
+
+type dropPolicyNode struct {
+	n         *tree.DropPolicy
+	tableDesc *sqlbase.MutableTableDescriptor
+}
+
+// DropPolicy removes a row-level security policy from a table.
+// Privileges: ALL on table.
+//   notes: postgres requires ownership of the table.
+func (p *planner) DropPolicy(ctx context.Context, n *tree.DropPolicy) (planNode, error) {
+	tableDesc, err := p.ResolveMutableTableDescriptorEx(ctx, n.Table, !n.IfExists, ResolveRequireTableDesc)
+	if err != nil {
+		return nil, err
+	}
+	if tableDesc == nil {
+		return newZeroNode(nil /* columns */), nil
+	}
+
+	if err := p.checkRowLevelSecurityPrivilege(ctx, tableDesc); err != nil {
+		return nil, err
+	}
+
+	return &dropPolicyNode{n: n, tableDesc: tableDesc}, nil
+}
+
+func (n *dropPolicyNode) startExec(params runParams) error {
+	idx := n.tableDesc.FindPolicyByName(string(n.n.Name))
+	if idx == -1 {
+		if n.n.IfExists {
+			return nil
+		}
+		return pgerror.Newf(pgerror.CodeUndefinedObjectError,
+			"policy %q for table %q does not exist", n.n.Name, n.tableDesc.Name)
+	}
+	n.tableDesc.Policies = append(n.tableDesc.Policies[:idx], n.tableDesc.Policies[idx+1:]...)
+
+	if err := params.p.writeSchemaChange(params.ctx, n.tableDesc, sqlbase.InvalidMutationID); err != nil {
+		return err
+	}
+
+	return logPolicyChange(params, n.tableDesc, params.p.ResolvedName(n.n.Table), n.n)
+}
+
+func (n *dropPolicyNode) Next(runParams) (bool, error) { return false, nil }
+func (n *dropPolicyNode) Values() tree.Datums          { return tree.Datums{} }
+func (n *dropPolicyNode) Close(context.Context)        {}
@@ -131,17 +131,26 @@ func (n *DropUserNode) startExec(params runParams) error {
 		if !tableIsVisible(table, true /*allowAdding*/) {
 			continue
 		}
-		for _, u := range table.GetPrivileges().Users {
-			if _, ok := userNames[u.User]; ok {
-				if f.Len() > 0 {
-					f.WriteString(", ")
+		hasGrants := hasGrantsForUsers(table.GetPrivileges(), userNames)
+		for i := range table.Columns {
+			hasGrants = hasGrants || hasGrantsForUsers(table.Columns[i].Privileges, userNames)
+		}
+		// Row-level security policies naming the user also count as grants.
+		for i := range table.Policies {
+			for _, role := range table.Policies[i].Roles {
+				if _, ok := userNames[role]; ok {
+					hasGrants = true
 				}
-				parentName := lCtx.getParentName(table)
-				tn := tree.MakeTableName(tree.Name(parentName), tree.Name(table.Name))
-				f.FormatNode(&tn)
-				break
 			}
 		}
+		if hasGrants {
+			if f.Len() > 0 {
+				f.WriteString(", ")
+			}
+			parentName := lCtx.getParentName(table)
+			tn := tree.MakeTableName(tree.Name(parentName), tree.Name(table.Name))
+			f.FormatNode(&tn)
+		}
 	}
 
 	// Was there any object dependin on that user?
@@ -231,3 +240,17 @@ func (*DropUserNode) Close(context.Context) {}
 
 // FastPathResults implements the planNodeFastPath interface.
 func (n *DropUserNode) FastPathResults() (int, bool) { return n.run.numDeleted, true }
+
+// hasGrantsForUsers returns true if the privilege descriptor, which may be
+// nil, grants anything to one of the given users.
+func hasGrantsForUsers(privs *sqlbase.PrivilegeDescriptor, userNames map[string]struct{}) bool {
+	if privs == nil {
+		return false
+	}
+	for _, u := range privs.Users {
+		if _, ok := userNames[u.User]; ok {
+			return true
+		}
+	}
+	return false
+}
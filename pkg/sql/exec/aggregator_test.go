@@ -699,6 +699,28 @@ func TestHashAggregator(t *testing.T) {
 
 			name: "unusedInputCol",
 		},
+		{
+			// JSON values that compare equal are grouped together even if they are
+			// represented differently.
+			input: tuples{
+				{mustParseJSON(`{"a": 1}`), 1},
+				{mustParseJSON(`[1, 2]`), 2},
+				{mustParseJSON(`{"a": 1.0}`), 4},
+				{mustParseJSON(`[1.00, 2]`), 8},
+				{mustParseJSON(`{"a": 2}`), 16},
+			},
+			colTypes:  []types.T{types.JSON, types.Int64},
+			groupCols: []uint32{0},
+			aggCols:   [][]uint32{{1}},
+
+			expected: tuples{
+				{5},
+				{10},
+				{16},
+			},
+
+			name: "jsonGroups",
+		},
 	}
 
 	for _, tc := range tcs {
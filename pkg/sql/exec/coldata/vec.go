@@ -14,9 +14,12 @@ package coldata
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/cockroachdb/apd"
 	"github.com/cockroachdb/cockroach/pkg/sql/exec/types"
+	"github.com/cockroachdb/cockroach/pkg/util/duration"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
 )
 
 // column is an interface that represents a raw array of a Go native type.
@@ -48,6 +51,12 @@ type Vec interface {
 	// TODO(jordan): should this be [][]byte?
 	// Decimal returns an apd.Decimal slice.
 	Decimal() []apd.Decimal
+	// Timestamp returns a time.Time slice.
+	Timestamp() []time.Time
+	// Interval returns a duration.Duration slice.
+	Interval() []duration.Duration
+	// JSON returns a json.JSON slice.
+	JSON() []json.JSON
 
 	// Col returns the raw, typeless backing storage for this Vec.
 	Col() interface{}
@@ -142,6 +151,12 @@ func NewMemColumn(t types.T, n int) Vec {
 		return &memColumn{t: t, col: make([]float64, n), nulls: nulls}
 	case types.Decimal:
 		return &memColumn{t: t, col: make([]apd.Decimal, n), nulls: nulls}
+	case types.Timestamp:
+		return &memColumn{t: t, col: make([]time.Time, n), nulls: nulls}
+	case types.Interval:
+		return &memColumn{t: t, col: make([]duration.Duration, n), nulls: nulls}
+	case types.JSON:
+		return &memColumn{t: t, col: make([]json.JSON, n), nulls: nulls}
 	default:
 		panic(fmt.Sprintf("unhandled type %s", t))
 	}
@@ -191,6 +206,18 @@ func (m *memColumn) Decimal() []apd.Decimal {
 	return m.col.([]apd.Decimal)
 }
 
+func (m *memColumn) Timestamp() []time.Time {
+	return m.col.([]time.Time)
+}
+
+func (m *memColumn) Interval() []duration.Duration {
+	return m.col.([]duration.Duration)
+}
+
+func (m *memColumn) JSON() []json.JSON {
+	return m.col.([]json.JSON)
+}
+
 func (m *memColumn) Col() interface{} {
 	return m.col
 }
@@ -13,6 +13,7 @@
 package colserde
 
 import (
+	"encoding/binary"
 	"fmt"
 	"reflect"
 	"unsafe"
@@ -22,6 +23,8 @@ import (
 	"github.com/apache/arrow/go/arrow/memory"
 	"github.com/cockroachdb/cockroach/pkg/sql/exec/coldata"
 	"github.com/cockroachdb/cockroach/pkg/sql/exec/types"
+	"github.com/cockroachdb/cockroach/pkg/util/duration"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
 	"github.com/pkg/errors"
 )
 
@@ -37,7 +40,9 @@ type ArrowBatchConverter struct {
 		// boolBuilder builds arrow bool columns as a bitmap from a bool slice.
 		boolBuilder *array.BooleanBuilder
 		// binaryBuilder builds arrow []byte columns as one []byte slice with
-		// accompanying offsets from a [][]byte slice.
+		// accompanying offsets from a [][]byte slice. Decimal, timestamp, interval
+		// and JSON columns are also built as []byte columns, from the encodings
+		// of their values.
 		binaryBuilder *array.BinaryBuilder
 	}
 
@@ -49,6 +54,8 @@ type ArrowBatchConverter struct {
 		// buffers is scratch space for exactly two buffers per element in
 		// arrowData.
 		buffers [][]*memory.Buffer
+		// value is scratch space for the encoding of a single value.
+		value []byte
 	}
 }
 
@@ -79,6 +86,20 @@ const (
 	sizeOfFloat64 = int(unsafe.Sizeof(float64(0)))
 )
 
+// intervalEncodingSize is the size of the encoding of an interval, which
+// consists of its months, days and nanos.
+const intervalEncodingSize = 3 * sizeOfInt64
+
+// isBinaryType returns whether columns of the given type are represented as
+// arrow []byte columns.
+func isBinaryType(t types.T) bool {
+	switch t {
+	case types.Bytes, types.Decimal, types.Timestamp, types.Interval, types.JSON:
+		return true
+	}
+	return false
+}
+
 // BatchToArrow converts the first batch.Length elements of the batch into an
 // arrow []*array.Data. It is assumed that the batch is not larger than
 // coldata.BatchSize. The returned []*array.Data may only be used until the
@@ -99,9 +120,10 @@ func (c *ArrowBatchConverter) BatchToArrow(batch coldata.Batch) ([]*array.Data,
 			arrowBitmap = n.NullBitmap()
 		}
 
-		if typ == types.Bool || typ == types.Bytes {
-			// Bools and Bytes are handled differently from other types. Refer to the
-			// comment on ArrowBatchConverter.builders for more information.
+		if typ == types.Bool || isBinaryType(typ) {
+			// Bools and binary types are handled differently from other types.
+			// Refer to the comment on ArrowBatchConverter.builders for more
+			// information.
 			var data *array.Data
 			switch typ {
 			case types.Bool:
@@ -111,7 +133,10 @@ func (c *ArrowBatchConverter) BatchToArrow(batch coldata.Batch) ([]*array.Data,
 				c.builders.binaryBuilder.AppendValues(vec.Bytes()[:n], nil /* valid */)
 				data = c.builders.binaryBuilder.NewBinaryArray().Data()
 			default:
-				panic(fmt.Sprintf("unexpected type %s", typ))
+				if err := c.appendEncodedValues(typ, vec, n); err != nil {
+					return nil, err
+				}
+				data = c.builders.binaryBuilder.NewBinaryArray().Data()
 			}
 			if arrowBitmap != nil {
 				// Overwrite empty null bitmap with the true bitmap.
@@ -183,6 +208,109 @@ func (c *ArrowBatchConverter) BatchToArrow(batch coldata.Batch) ([]*array.Data,
 	return c.scratch.arrowData, nil
 }
 
+// appendEncodedValues appends the encodings of the first n values of vec, which
+// is a decimal, timestamp, interval or JSON column, to the binary builder.
+// Nulls are encoded like other values, since the null bitmap is overwritten.
+func (c *ArrowBatchConverter) appendEncodedValues(typ types.T, vec coldata.Vec, n int) error {
+	b := c.builders.binaryBuilder
+	switch typ {
+	case types.Decimal:
+		decs := vec.Decimal()[:n]
+		for i := range decs {
+			c.scratch.value = decs[i].Append(c.scratch.value[:0], 'G')
+			b.Append(c.scratch.value)
+		}
+	case types.Timestamp:
+		for _, t := range vec.Timestamp()[:n] {
+			value, err := t.MarshalBinary()
+			if err != nil {
+				return err
+			}
+			b.Append(value)
+		}
+	case types.Interval:
+		if cap(c.scratch.value) < intervalEncodingSize {
+			c.scratch.value = make([]byte, intervalEncodingSize)
+		}
+		value := c.scratch.value[:intervalEncodingSize]
+		for _, d := range vec.Interval()[:n] {
+			binary.LittleEndian.PutUint64(value, uint64(d.Months))
+			binary.LittleEndian.PutUint64(value[sizeOfInt64:], uint64(d.Days))
+			binary.LittleEndian.PutUint64(value[2*sizeOfInt64:], uint64(d.Nanos()))
+			b.Append(value)
+		}
+	case types.JSON:
+		for _, j := range vec.JSON()[:n] {
+			if j == nil {
+				// The value of a null JSON is unset.
+				b.Append(nil)
+				continue
+			}
+			var err error
+			c.scratch.value, err = json.EncodeJSON(c.scratch.value[:0], j)
+			if err != nil {
+				return err
+			}
+			b.Append(c.scratch.value)
+		}
+	default:
+		panic(fmt.Sprintf("unexpected type %s", typ))
+	}
+	return nil
+}
+
+// decodeValues decodes the values of bytesArr, which were encoded by
+// appendEncodedValues, into vec.
+func decodeValues(typ types.T, bytesArr *array.Binary, vec coldata.Vec) error {
+	n := bytesArr.Len()
+	switch typ {
+	case types.Decimal:
+		decs := vec.Decimal()
+		for i := 0; i < n; i++ {
+			if _, _, err := decs[i].SetString(string(bytesArr.Value(i))); err != nil {
+				return err
+			}
+		}
+	case types.Timestamp:
+		timestamps := vec.Timestamp()
+		for i := 0; i < n; i++ {
+			if err := timestamps[i].UnmarshalBinary(bytesArr.Value(i)); err != nil {
+				return err
+			}
+		}
+	case types.Interval:
+		intervals := vec.Interval()
+		for i := 0; i < n; i++ {
+			value := bytesArr.Value(i)
+			if len(value) != intervalEncodingSize {
+				return errors.Errorf("unexpected interval encoding length: %d", len(value))
+			}
+			intervals[i] = duration.DecodeDuration(
+				int64(binary.LittleEndian.Uint64(value)),
+				int64(binary.LittleEndian.Uint64(value[sizeOfInt64:])),
+				int64(binary.LittleEndian.Uint64(value[2*sizeOfInt64:])),
+			)
+		}
+	case types.JSON:
+		jsons := vec.JSON()
+		for i := 0; i < n; i++ {
+			value := bytesArr.Value(i)
+			if len(value) == 0 {
+				jsons[i] = nil
+				continue
+			}
+			_, j, err := json.DecodeJSON(value)
+			if err != nil {
+				return err
+			}
+			jsons[i] = j
+		}
+	default:
+		panic(fmt.Sprintf("unexpected type %s", typ))
+	}
+	return nil
+}
+
 // ArrowToBatch converts []*array.Data to a coldata.Batch. There must not be
 // more than coldata.BatchSize elements in data. The returned batch may only be
 // used until the next call to ArrowToBatch.
@@ -197,7 +325,7 @@ func (c *ArrowBatchConverter) ArrowToBatch(data []*array.Data) (coldata.Batch, e
 		d := data[i]
 
 		var arr array.Interface
-		if typ == types.Bool || typ == types.Bytes {
+		if typ == types.Bool || isBinaryType(typ) {
 			switch typ {
 			case types.Bool:
 				boolArr := array.NewBooleanData(d)
@@ -222,7 +350,11 @@ func (c *ArrowBatchConverter) ArrowToBatch(data []*array.Data) (coldata.Batch, e
 				}
 				arr = bytesArr
 			default:
-				panic(fmt.Sprintf("unexpected type %s", typ))
+				bytesArr := array.NewBinaryData(d)
+				if err := decodeValues(typ, bytesArr, vec); err != nil {
+					return nil, err
+				}
+				arr = bytesArr
 			}
 		} else {
 			var col interface{}
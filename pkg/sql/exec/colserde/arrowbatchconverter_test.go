@@ -10,7 +10,7 @@
 // included in the file licenses/APL.txt and at
 // https://www.apache.org/licenses/LICENSE-2.0
 
-package colserde
+package colserde_test
 
 import (
 	"fmt"
@@ -19,6 +19,7 @@ import (
 	"github.com/apache/arrow/go/arrow/array"
 	"github.com/cockroachdb/cockroach/pkg/sql/exec"
 	"github.com/cockroachdb/cockroach/pkg/sql/exec/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/exec/colserde"
 	"github.com/cockroachdb/cockroach/pkg/sql/exec/types"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/randutil"
@@ -32,21 +33,13 @@ func TestArrowBatchConverterRandom(t *testing.T) {
 
 	rng, _ := randutil.NewPseudoRand()
 
-	availableTyps := make([]types.T, 0, len(types.AllTypes))
-	for _, typ := range types.AllTypes {
-		// TODO(asubiotto): We do not support decimal conversion yet.
-		if typ == types.Decimal {
-			continue
-		}
-		availableTyps = append(availableTyps, typ)
-	}
 	typs := make([]types.T, rng.Intn(maxTyps)+1)
 	for i := range typs {
-		typs[i] = availableTyps[rng.Intn(len(availableTyps))]
+		typs[i] = types.AllTypes[rng.Intn(len(types.AllTypes))]
 	}
 
 	b := exec.RandomBatch(rng, typs, rng.Intn(coldata.BatchSize)+1, rng.Float64())
-	c := NewArrowBatchConverter(typs)
+	c := colserde.NewArrowBatchConverter(typs)
 
 	// Make a copy of the original batch because the converter modifies and casts
 	// data without copying for performance reasons.
@@ -73,11 +66,20 @@ func TestArrowBatchConverterRandom(t *testing.T) {
 		// that the converter keeps around, the coldata.Vec needs to be sliced to
 		// the first length elements to match on length, otherwise the check will
 		// fail.
-		require.Equal(
-			t,
-			expectedColVecs[i].Slice(typ, 0, uint64(b.Length())),
-			result.ColVec(i).Slice(typ, 0, uint64(result.Length())),
-		)
+		expected := expectedColVecs[i].Slice(typ, 0, uint64(b.Length()))
+		actual := result.ColVec(i).Slice(typ, 0, uint64(result.Length()))
+		if typ == types.JSON {
+			// JSON values are decoded into a different representation than the
+			// one they were generated with, so compare them by value.
+			require.Equal(t, expected.Nulls(), actual.Nulls())
+			for j, e := range expected.JSON() {
+				cmp, err := e.Compare(actual.JSON()[j])
+				require.NoError(t, err)
+				require.Equal(t, 0, cmp, "expected %s, got %s", e, actual.JSON()[j])
+			}
+			continue
+		}
+		require.Equal(t, expected, actual)
 	}
 }
 
@@ -112,7 +114,7 @@ func BenchmarkArrowBatchConverter(b *testing.B) {
 				}
 			}
 		}
-		c := NewArrowBatchConverter([]types.T{typ})
+		c := colserde.NewArrowBatchConverter([]types.T{typ})
 		nullFractions := []float64{0, 0.25, 0.5}
 		setNullFraction := func(batch coldata.Batch, nullFraction float64) {
 			vec := batch.ColVec(0)
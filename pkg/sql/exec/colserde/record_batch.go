@@ -38,8 +38,9 @@ func numBuffersForType(t types.T) int {
 	// null bitmap and one for the values.
 	numBuffers := 2
 	switch t {
-	case types.Bytes:
-		// This type has an extra offsets buffer.
+	case types.Bytes, types.Decimal, types.Timestamp, types.Interval, types.JSON:
+		// These types are represented as []byte columns, which have an extra
+		// offsets buffer.
 		numBuffers = 3
 	}
 	return numBuffers
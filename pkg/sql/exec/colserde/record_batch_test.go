@@ -148,6 +148,19 @@ func randomDataFromType(rng *rand.Rand, t types.T, n int, nullProbability float6
 			}
 			builder.(*array.FixedSizeBinaryBuilder).AppendValues(data, valid)
 		}
+	case types.Decimal, types.Timestamp, types.Interval, types.JSON:
+		// These types are serialized as the encodings of their values, which are
+		// opaque to the serializer.
+		builder = array.NewBinaryBuilder(memory.DefaultAllocator, arrow.BinaryTypes.Binary)
+		data := make([][]byte, n)
+		for i := range data {
+			slice := make([]byte, rng.Intn(maxVarLen))
+			if valid[i] {
+				_, _ = rng.Read(slice)
+			}
+			data[i] = slice
+		}
+		builder.(*array.BinaryBuilder).AppendValues(data, valid)
 	default:
 		panic(fmt.Sprintf("unsupported type %s", t))
 	}
@@ -188,7 +201,6 @@ func TestRecordBatchSerializerSerializeDeserializeRandom(t *testing.T) {
 	)
 
 	var (
-		supportedTypes  = make([]types.T, 0, len(types.AllTypes))
 		typs            = make([]types.T, rng.Intn(maxTypes)+1)
 		data            = make([]*array.Data, len(typs))
 		dataLen         = rng.Intn(maxDataLen) + 1
@@ -196,16 +208,8 @@ func TestRecordBatchSerializerSerializeDeserializeRandom(t *testing.T) {
 		buf             = bytes.Buffer{}
 	)
 
-	// We do not support decimals yet.
-	for _, t := range types.AllTypes {
-		if t == types.Decimal {
-			continue
-		}
-		supportedTypes = append(supportedTypes, t)
-	}
-
 	for i := range typs {
-		typs[i] = supportedTypes[rng.Intn(len(supportedTypes))]
+		typs[i] = types.AllTypes[rng.Intn(len(types.AllTypes))]
 		data[i] = randomDataFromType(rng, typs[i], dataLen, nullProbability)
 	}
 
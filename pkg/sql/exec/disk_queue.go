@@ -0,0 +1,194 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package exec
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/cockroachdb/cockroach/pkg/sql/exec/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/exec/colserde"
+	"github.com/cockroachdb/cockroach/pkg/sql/exec/types"
+	"github.com/cockroachdb/cockroach/pkg/storage/diskmap"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+)
+
+// diskQueue stores batches in temporary storage, serialized in the Arrow IPC
+// format, in a number of partitions. The batches of a partition are read back
+// in the order in which they were enqueued, by a diskQueueReader.
+//
+// All the diskQueues created from a SpillingConfig share a single
+// SortedDiskMap, in which the key of a batch is made of the ID of its queue,
+// the index of its partition and its sequence number within the partition.
+type diskQueue struct {
+	cfg  *SpillingConfig
+	typs []types.T
+	id   uint64
+
+	writer     diskmap.SortedDiskMapBatchWriter
+	converter  *colserde.ArrowBatchConverter
+	serializer *colserde.RecordBatchSerializer
+
+	// numBatches is the number of batches enqueued into each partition.
+	numBatches []uint64
+	// dirty is set if batches were enqueued since the writer was last flushed.
+	dirty bool
+
+	scratch struct {
+		key   []byte
+		buf   bytes.Buffer
+		batch coldata.Batch
+	}
+}
+
+// newDiskQueue creates a diskQueue with the given number of partitions. The
+// types must be supported by SpillingSupported.
+func newDiskQueue(cfg *SpillingConfig, typs []types.T, numPartitions int) *diskQueue {
+	serializer, err := colserde.NewRecordBatchSerializer(typs)
+	panicOnSpillingError(err)
+	if cfg.diskMap == nil {
+		cfg.diskMap = cfg.TempStorage.NewSortedDiskMap()
+		cfg.diskAcc = cfg.DiskMonitor.MakeBoundAccount()
+	}
+	q := &diskQueue{
+		cfg:        cfg,
+		typs:       typs,
+		id:         cfg.nextQueueID,
+		writer:     cfg.diskMap.NewBatchWriter(),
+		converter:  colserde.NewArrowBatchConverter(typs),
+		serializer: serializer,
+		numBatches: make([]uint64, numPartitions),
+	}
+	cfg.nextQueueID++
+	return q
+}
+
+func (q *diskQueue) makeKey(buf []byte, partitionIdx int, seq uint64) []byte {
+	buf = encoding.EncodeUvarintAscending(buf[:0], q.id)
+	buf = encoding.EncodeUvarintAscending(buf, uint64(partitionIdx))
+	if seq != 0 {
+		buf = encoding.EncodeUvarintAscending(buf, seq)
+	}
+	return buf
+}
+
+// enqueue writes the selected tuples of the batch to the given partition.
+func (q *diskQueue) enqueue(ctx context.Context, partitionIdx int, batch coldata.Batch) {
+	if batch.Length() == 0 {
+		return
+	}
+	if sel := batch.Selection(); sel != nil {
+		// The Arrow converter ignores the selection vector.
+		if q.scratch.batch == nil {
+			q.scratch.batch = coldata.NewMemBatch(q.typs)
+		}
+		for i, t := range q.typs {
+			q.scratch.batch.ColVec(i).CopyWithSelInt16(batch.ColVec(i), sel, batch.Length(), t)
+		}
+		q.scratch.batch.SetLength(batch.Length())
+		batch = q.scratch.batch
+	}
+	data, err := q.converter.BatchToArrow(batch)
+	panicOnSpillingError(err)
+	q.scratch.buf.Reset()
+	panicOnSpillingError(q.serializer.Serialize(&q.scratch.buf, data))
+
+	q.numBatches[partitionIdx]++
+	// Sequence numbers start at 1, so that the key of a partition (with a
+	// sequence number of 0) is a prefix of the keys of its batches.
+	q.scratch.key = q.makeKey(q.scratch.key, partitionIdx, q.numBatches[partitionIdx])
+	panicOnSpillingError(q.cfg.diskAcc.Grow(ctx, int64(len(q.scratch.key)+q.scratch.buf.Len())))
+	panicOnSpillingError(q.writer.Put(q.scratch.key, q.scratch.buf.Bytes()))
+	q.dirty = true
+}
+
+// numPartitions returns the number of partitions of the queue.
+func (q *diskQueue) numPartitions() int {
+	return len(q.numBatches)
+}
+
+// empty returns whether no batch was enqueued into the given partition.
+func (q *diskQueue) empty(partitionIdx int) bool {
+	return q.numBatches[partitionIdx] == 0
+}
+
+// newReader returns an Operator emitting the batches of the given partition.
+// Batches enqueued into the partition after the reader is first used are not
+// emitted by it.
+func (q *diskQueue) newReader(partitionIdx int) Operator {
+	return &diskQueueReader{
+		q:            q,
+		partitionIdx: partitionIdx,
+		converter:    colserde.NewArrowBatchConverter(q.typs),
+		zero:         coldata.NewMemBatchWithSize(q.typs, 0),
+	}
+}
+
+// close releases the writer of the queue. Its batches remain readable until
+// the SpillingConfig is closed.
+func (q *diskQueue) close(ctx context.Context) {
+	if q.writer != nil {
+		panicOnSpillingError(q.writer.Close(ctx))
+		q.writer = nil
+	}
+}
+
+// diskQueueReader emits the batches of a partition of a diskQueue.
+type diskQueueReader struct {
+	q            *diskQueue
+	partitionIdx int
+	prefix       []byte
+
+	iter      diskmap.SortedDiskMapIterator
+	converter *colserde.ArrowBatchConverter
+	arrowData []*array.Data
+	zero      coldata.Batch
+	done      bool
+}
+
+var _ Operator = &diskQueueReader{}
+
+func (r *diskQueueReader) Init() {}
+
+func (r *diskQueueReader) Next(context.Context) coldata.Batch {
+	if r.done {
+		return r.zero
+	}
+	if r.iter == nil {
+		if r.q.dirty {
+			panicOnSpillingError(r.q.writer.Flush())
+			r.q.dirty = false
+		}
+		r.prefix = r.q.makeKey(nil, r.partitionIdx, 0 /* seq */)
+		r.iter = r.q.cfg.diskMap.NewIterator()
+		r.iter.Seek(r.prefix)
+	} else {
+		// The iterator is only advanced now, since the previous batch may
+		// reference the memory of the previous value.
+		r.iter.Next()
+	}
+	ok, err := r.iter.Valid()
+	panicOnSpillingError(err)
+	if !ok || !bytes.HasPrefix(r.iter.UnsafeKey(), r.prefix) {
+		r.iter.Close()
+		r.iter = nil
+		r.done = true
+		return r.zero
+	}
+	r.arrowData = r.arrowData[:0]
+	panicOnSpillingError(r.q.serializer.Deserialize(&r.arrowData, r.iter.UnsafeValue()))
+	batch, err := r.converter.ArrowToBatch(r.arrowData)
+	panicOnSpillingError(err)
+	return batch
+}
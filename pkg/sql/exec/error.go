@@ -72,8 +72,10 @@ func CatchVectorizedRuntimeError(operation func()) (retErr error) {
 }
 
 const (
-	execPackagePrefix  = "github.com/cockroachdb/cockroach/pkg/sql/exec"
-	colBatchScanPrefix = "github.com/cockroachdb/cockroach/pkg/sql/distsqlrun.(*colBatchScan)"
+	execPackagePrefix   = "github.com/cockroachdb/cockroach/pkg/sql/exec"
+	colBatchScanPrefix  = "github.com/cockroachdb/cockroach/pkg/sql/distsqlrun.(*colBatchScan)"
+	colIndexJoinPrefix  = "github.com/cockroachdb/cockroach/pkg/sql/distsqlrun.(*colIndexJoin)"
+	colLookupJoinPrefix = "github.com/cockroachdb/cockroach/pkg/sql/distsqlrun.(*colLookupJoin)"
 )
 
 // isPanicFromVectorizedEngine checks whether the panic that was emitted from
@@ -82,7 +84,9 @@ const (
 // panicEmittedFrom must be trimmed to not have any white spaces in the prefix.
 func isPanicFromVectorizedEngine(panicEmittedFrom string) bool {
 	return strings.HasPrefix(panicEmittedFrom, execPackagePrefix) ||
-		strings.HasPrefix(panicEmittedFrom, colBatchScanPrefix)
+		strings.HasPrefix(panicEmittedFrom, colBatchScanPrefix) ||
+		strings.HasPrefix(panicEmittedFrom, colIndexJoinPrefix) ||
+		strings.HasPrefix(panicEmittedFrom, colLookupJoinPrefix)
 }
 
 // TestVectorizedErrorEmitter is an Operator that panics on every odd-numbered
@@ -153,9 +153,10 @@ func init() {
 	for _, t := range inputTypes {
 		customizer := typeCustomizers[t]
 		for _, op := range binOps {
-			// Skip types that don't have associated binary ops.
+			// Skip types that don't have associated binary ops. Timestamps and
+			// intervals only support some binary ops, which are added below.
 			switch t {
-			case types.Bytes, types.Bool:
+			case types.Bytes, types.Bool, types.Timestamp, types.Interval, types.JSON:
 				continue
 			}
 			ov := &overload{
@@ -218,6 +219,26 @@ func init() {
 		}
 		hashOverloads = append(hashOverloads, ov)
 	}
+
+	// Timestamps and intervals support a few binary ops, some of which have
+	// arguments or results of different types.
+	for _, ov := range []*overload{
+		{BinOp: tree.Plus, LTyp: types.Timestamp, RTyp: types.Interval, RetTyp: types.Timestamp},
+		{BinOp: tree.Minus, LTyp: types.Timestamp, RTyp: types.Interval, RetTyp: types.Timestamp},
+		{BinOp: tree.Minus, LTyp: types.Timestamp, RTyp: types.Timestamp, RetTyp: types.Interval},
+		{BinOp: tree.Plus, LTyp: types.Interval, RTyp: types.Timestamp, RetTyp: types.Timestamp},
+		{BinOp: tree.Plus, LTyp: types.Interval, RTyp: types.Interval, RetTyp: types.Interval},
+		{BinOp: tree.Minus, LTyp: types.Interval, RTyp: types.Interval, RetTyp: types.Interval},
+	} {
+		ov.Name = binaryOpName[ov.BinOp]
+		ov.IsBinOp = true
+		ov.OpStr = binaryOpInfix[ov.BinOp]
+		ov.LGoType = ov.LTyp.GoTypeName()
+		ov.RGoType = ov.RTyp.GoTypeName()
+		ov.AssignFunc = typeCustomizers[ov.LTyp].(binOpTypeCustomizer).getBinOpAssignFunc()
+		binaryOpOverloads = append(binaryOpOverloads, ov)
+		binaryOpToOverloads[ov.BinOp] = append(binaryOpToOverloads[ov.BinOp], ov)
+	}
 }
 
 // typeCustomizer is a marker interface for something that implements one or
@@ -272,6 +293,17 @@ type floatCustomizer struct{ width int }
 // intCustomizers are used for hash functions.
 type intCustomizer struct{ width int }
 
+// timestampCustomizer is necessary since time.Time doesn't have infix operators.
+type timestampCustomizer struct{}
+
+// intervalCustomizer is necessary since duration.Duration doesn't have infix
+// operators.
+type intervalCustomizer struct{}
+
+// jsonCustomizer is necessary since json.JSON doesn't have infix operators, and
+// since JSON values that compare equal can have different representations.
+type jsonCustomizer struct{}
+
 func (boolCustomizer) getCmpOpCompareFunc() compareFunc {
 	return func(l, r string) string {
 		return fmt.Sprintf("tree.CompareBools(%s, %s)", l, r)
@@ -344,6 +376,92 @@ func (c intCustomizer) getHashAssignFunc() assignFunc {
 	}
 }
 
+func (timestampCustomizer) getCmpOpCompareFunc() compareFunc {
+	return func(l, r string) string {
+		return fmt.Sprintf("tree.CompareTimes(%s, %s)", l, r)
+	}
+}
+
+func (timestampCustomizer) getBinOpAssignFunc() assignFunc {
+	return func(op overload, target, l, r string) string {
+		switch {
+		case op.BinOp == tree.Minus && op.RTyp == types.Timestamp:
+			return fmt.Sprintf("%s = duration.MakeDuration(%s.Sub(%s).Nanoseconds(), 0, 0)", target, l, r)
+		case op.BinOp == tree.Plus:
+			// The projection operators have no access to the session, so the
+			// addition always uses duration.AdditionModeCompatible; the planner
+			// only uses them if that is the session's mode.
+			return fmt.Sprintf("%s = duration.Add(nil, %s, %s).Round(time.Microsecond)", target, l, r)
+		case op.BinOp == tree.Minus:
+			return fmt.Sprintf("%s = duration.Add(nil, %s, %s.Mul(-1)).Round(time.Microsecond)", target, l, r)
+		}
+		panic(fmt.Sprintf("unhandled binary operator %s", op.BinOp))
+	}
+}
+
+func (timestampCustomizer) getHashAssignFunc() assignFunc {
+	return func(op overload, target, v, _ string) string {
+		return fmt.Sprintf(`
+			t := %[2]s.UnixNano()
+			%[1]s = memhash64(noescape(unsafe.Pointer(&t)), %[1]s)
+		`, target, v)
+	}
+}
+
+func (intervalCustomizer) getCmpOpCompareFunc() compareFunc {
+	return func(l, r string) string {
+		return fmt.Sprintf("%s.Compare(%s)", l, r)
+	}
+}
+
+func (intervalCustomizer) getBinOpAssignFunc() assignFunc {
+	return func(op overload, target, l, r string) string {
+		switch {
+		case op.BinOp == tree.Plus && op.RTyp == types.Timestamp:
+			return fmt.Sprintf("%s = duration.Add(nil, %s, %s).Round(time.Microsecond)", target, r, l)
+		case op.BinOp == tree.Plus:
+			return fmt.Sprintf("%s = %s.Add(%s)", target, l, r)
+		case op.BinOp == tree.Minus:
+			return fmt.Sprintf("%s = %s.Sub(%s)", target, l, r)
+		}
+		panic(fmt.Sprintf("unhandled binary operator %s", op.BinOp))
+	}
+}
+
+func (intervalCustomizer) getHashAssignFunc() assignFunc {
+	return func(op overload, target, v, _ string) string {
+		return fmt.Sprintf(`
+			// Intervals which compare equal have the same total number of
+			// nanoseconds, unless that number overflows.
+			nanos, _, _, _ := %[2]s.Encode()
+			%[1]s = memhash64(noescape(unsafe.Pointer(&nanos)), %[1]s)
+		`, target, v)
+	}
+}
+
+func (jsonCustomizer) getCmpOpCompareFunc() compareFunc {
+	return func(l, r string) string {
+		return fmt.Sprintf("tree.CompareJSON(%s, %s)", l, r)
+	}
+}
+
+func (jsonCustomizer) getHashAssignFunc() assignFunc {
+	return func(op overload, target, v, _ string) string {
+		return fmt.Sprintf(`
+			// JSON values which compare equal, like 1 and 1.0, have the same
+			// inverted index keys.
+			keys, err := json.EncodeInvertedIndexKeys(nil /* b */, %[2]s)
+			if err != nil {
+				panic(err)
+			}
+			for _, k := range keys {
+				sh := (*reflect.SliceHeader)(unsafe.Pointer(&k))
+				%[1]s = memhash(unsafe.Pointer(sh.Data), %[1]s, uintptr(len(k)))
+			}
+		`, target, v)
+	}
+}
+
 func registerTypeCustomizers() {
 	typeCustomizers = make(map[types.T]typeCustomizer)
 	registerTypeCustomizer(types.Bool, boolCustomizer{})
@@ -355,6 +473,9 @@ func registerTypeCustomizers() {
 	registerTypeCustomizer(types.Int16, intCustomizer{width: 16})
 	registerTypeCustomizer(types.Int32, intCustomizer{width: 32})
 	registerTypeCustomizer(types.Int64, intCustomizer{width: 64})
+	registerTypeCustomizer(types.Timestamp, timestampCustomizer{})
+	registerTypeCustomizer(types.Interval, intervalCustomizer{})
+	registerTypeCustomizer(types.JSON, jsonCustomizer{})
 }
 
 // Avoid unused warning for functions which are only used in templates.
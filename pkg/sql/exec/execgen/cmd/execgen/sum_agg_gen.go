@@ -19,6 +19,7 @@ import (
 	"strings"
 	"text/template"
 
+	"github.com/cockroachdb/cockroach/pkg/sql/exec/types"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 )
 
@@ -43,7 +44,16 @@ func genSumAgg(wr io.Writer) error {
 		return err
 	}
 
-	return tmpl.Execute(wr, binaryOpToOverloads[tree.Plus])
+	// Timestamps can't be summed, and sums of intervals aren't supported yet.
+	var overloads []*overload
+	for _, o := range binaryOpToOverloads[tree.Plus] {
+		switch o.LTyp {
+		case types.Timestamp, types.Interval:
+			continue
+		}
+		overloads = append(overloads, o)
+	}
+	return tmpl.Execute(wr, overloads)
 }
 
 func init() {
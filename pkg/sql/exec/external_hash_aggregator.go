@@ -0,0 +1,149 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package exec
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/distsqlpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/exec/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/exec/types"
+)
+
+// NewSpillingHashAggregator returns a hash aggregator which aggregates its
+// input in memory if it fits within the memory limit of cfg, and falls back to
+// an external hash aggregation otherwise. The arguments are otherwise the same
+// as NewHashAggregator.
+func NewSpillingHashAggregator(
+	input Operator,
+	colTypes []types.T,
+	aggFns []distsqlpb.AggregatorSpec_Func,
+	groupCols []uint32,
+	aggCols [][]uint32,
+	cfg *SpillingConfig,
+) (Operator, error) {
+	return newSpillingHashAggregator(input, colTypes, aggFns, groupCols, aggCols, cfg, 0 /* depth */)
+}
+
+func newSpillingHashAggregator(
+	input Operator,
+	colTypes []types.T,
+	aggFns []distsqlpb.AggregatorSpec_Func,
+	groupCols []uint32,
+	aggCols [][]uint32,
+	cfg *SpillingConfig,
+	depth int,
+) (Operator, error) {
+	if depth >= externalHashMaxDepth {
+		return NewHashAggregator(input, colTypes, aggFns, groupCols, aggCols)
+	}
+	return newDiskSpiller(
+		input, colTypes, cfg,
+		func(input Operator) (Operator, error) {
+			return NewHashAggregator(input, colTypes, aggFns, groupCols, aggCols)
+		},
+		func(input Operator) (Operator, error) {
+			return newExternalHashAggregator(input, colTypes, aggFns, groupCols, aggCols, cfg, depth)
+		},
+	)
+}
+
+// externalHashAggregator is an Operator performing an external hash
+// aggregation. Its input is partitioned on the hash of the grouping columns
+// into temporary storage, so that all the tuples of a group end up in the same
+// partition. Each partition is then aggregated on its own, in memory if it
+// fits or by partitioning it again otherwise.
+type externalHashAggregator struct {
+	input     Operator
+	colTypes  []types.T
+	aggFns    []distsqlpb.AggregatorSpec_Func
+	groupCols []uint32
+	aggCols   [][]uint32
+	cfg       *SpillingConfig
+	depth     int
+
+	partitions   *diskQueue
+	partitionIdx int
+	// current is the aggregator of the partition being emitted.
+	current Operator
+	zero    coldata.Batch
+}
+
+var _ Operator = &externalHashAggregator{}
+
+func newExternalHashAggregator(
+	input Operator,
+	colTypes []types.T,
+	aggFns []distsqlpb.AggregatorSpec_Func,
+	groupCols []uint32,
+	aggCols [][]uint32,
+	cfg *SpillingConfig,
+	depth int,
+) (*externalHashAggregator, error) {
+	_, outTyps, err := makeAggregateFuncs(extractAggTypes(aggCols, colTypes), aggFns)
+	if err != nil {
+		return nil, err
+	}
+	return &externalHashAggregator{
+		input:     input,
+		colTypes:  colTypes,
+		aggFns:    aggFns,
+		groupCols: groupCols,
+		aggCols:   aggCols,
+		cfg:       cfg,
+		depth:     depth,
+		zero:      coldata.NewMemBatchWithSize(outTyps, 0),
+	}, nil
+}
+
+func (ag *externalHashAggregator) Init() {
+	ag.input.Init()
+}
+
+func (ag *externalHashAggregator) Next(ctx context.Context) coldata.Batch {
+	if ag.partitions == nil {
+		ag.partitions = newDiskQueue(ag.cfg, ag.colTypes, externalHashNumPartitions)
+		partitioner := newHashPartitioner(ag.colTypes, ag.groupCols, ag.depth)
+		for {
+			batch := ag.input.Next(ctx)
+			if batch.Length() == 0 {
+				break
+			}
+			partitioner.partition(ctx, batch, ag.partitions)
+		}
+		partitioner.flush(ctx, ag.partitions)
+		ag.partitions.close(ctx)
+	}
+	for {
+		if ag.current != nil {
+			if batch := ag.current.Next(ctx); batch.Length() > 0 {
+				return batch
+			}
+			ag.current = nil
+			ag.partitionIdx++
+		}
+		for ag.partitionIdx < ag.partitions.numPartitions() && ag.partitions.empty(ag.partitionIdx) {
+			ag.partitionIdx++
+		}
+		if ag.partitionIdx == ag.partitions.numPartitions() {
+			return ag.zero
+		}
+		op, err := newSpillingHashAggregator(
+			ag.partitions.newReader(ag.partitionIdx),
+			ag.colTypes, ag.aggFns, ag.groupCols, ag.aggCols, ag.cfg, ag.depth+1,
+		)
+		panicOnSpillingError(err)
+		op.Init()
+		ag.current = op
+	}
+}
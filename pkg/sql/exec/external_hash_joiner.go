@@ -0,0 +1,210 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package exec
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/exec/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/exec/types"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+)
+
+// hashJoinerArgs are the arguments of NewEqHashJoinerOp, except for the
+// sources.
+type hashJoinerArgs struct {
+	leftEqCols     []uint32
+	rightEqCols    []uint32
+	leftOutCols    []uint32
+	rightOutCols   []uint32
+	leftTypes      []types.T
+	rightTypes     []types.T
+	buildRightSide bool
+	buildDistinct  bool
+	joinType       sqlbase.JoinType
+}
+
+// buildsRightSide returns whether the hash joiner builds its hash table from
+// its right source, mirroring NewEqHashJoinerOp.
+func (a *hashJoinerArgs) buildsRightSide() bool {
+	return a.buildRightSide || a.joinType == sqlbase.JoinType_LEFT_SEMI
+}
+
+func (a *hashJoinerArgs) newInMemoryOp(build, probe Operator) (Operator, error) {
+	left, right := build, probe
+	if a.buildsRightSide() {
+		left, right = probe, build
+	}
+	return NewEqHashJoinerOp(
+		left, right, a.leftEqCols, a.rightEqCols, a.leftOutCols, a.rightOutCols,
+		a.leftTypes, a.rightTypes, a.buildRightSide, a.buildDistinct, a.joinType,
+	)
+}
+
+// NewSpillingEqHashJoinerOp returns a hash joiner which builds its hash table
+// in memory if its build side fits within the memory limit of cfg, and falls
+// back to an external hash join otherwise. The arguments are otherwise the
+// same as NewEqHashJoinerOp.
+func NewSpillingEqHashJoinerOp(
+	leftSource Operator,
+	rightSource Operator,
+	leftEqCols []uint32,
+	rightEqCols []uint32,
+	leftOutCols []uint32,
+	rightOutCols []uint32,
+	leftTypes []types.T,
+	rightTypes []types.T,
+	buildRightSide bool,
+	buildDistinct bool,
+	joinType sqlbase.JoinType,
+	cfg *SpillingConfig,
+) (Operator, error) {
+	args := &hashJoinerArgs{
+		leftEqCols:     leftEqCols,
+		rightEqCols:    rightEqCols,
+		leftOutCols:    leftOutCols,
+		rightOutCols:   rightOutCols,
+		leftTypes:      leftTypes,
+		rightTypes:     rightTypes,
+		buildRightSide: buildRightSide,
+		buildDistinct:  buildDistinct,
+		joinType:       joinType,
+	}
+	if args.buildsRightSide() {
+		return newSpillingHashJoiner(rightSource, leftSource, args, cfg, 0 /* depth */)
+	}
+	return newSpillingHashJoiner(leftSource, rightSource, args, cfg, 0 /* depth */)
+}
+
+func newSpillingHashJoiner(
+	build, probe Operator, args *hashJoinerArgs, cfg *SpillingConfig, depth int,
+) (Operator, error) {
+	if depth >= externalHashMaxDepth {
+		return args.newInMemoryOp(build, probe)
+	}
+	buildTypes := args.leftTypes
+	if args.buildsRightSide() {
+		buildTypes = args.rightTypes
+	}
+	return newDiskSpiller(
+		build, buildTypes, cfg,
+		func(build Operator) (Operator, error) {
+			return args.newInMemoryOp(build, probe)
+		},
+		func(build Operator) (Operator, error) {
+			return newExternalHashJoiner(build, probe, args, cfg, depth), nil
+		},
+	)
+}
+
+// externalHashJoiner is an Operator performing a partitioned hash join. Both
+// of its sources are partitioned on the hash of their equality columns into
+// temporary storage, so that matching tuples end up in partitions with the
+// same index. Each pair of partitions is then joined on its own, in memory if
+// the build partition fits or by partitioning the pair again otherwise.
+type externalHashJoiner struct {
+	build      Operator
+	probe      Operator
+	args       *hashJoinerArgs
+	cfg        *SpillingConfig
+	depth      int
+	buildTypes []types.T
+	probeTypes []types.T
+	buildEq    []uint32
+	probeEq    []uint32
+
+	buildPartitions *diskQueue
+	probePartitions *diskQueue
+	partitionIdx    int
+	// current is the joiner of the pair of partitions being emitted.
+	current Operator
+	zero    coldata.Batch
+}
+
+var _ Operator = &externalHashJoiner{}
+
+func newExternalHashJoiner(
+	build, probe Operator, args *hashJoinerArgs, cfg *SpillingConfig, depth int,
+) *externalHashJoiner {
+	j := &externalHashJoiner{
+		build: build,
+		probe: probe,
+		args:  args,
+		cfg:   cfg,
+		depth: depth,
+		zero:  coldata.NewMemBatchWithSize(append(append([]types.T(nil), args.leftTypes...), args.rightTypes...), 0),
+	}
+	if args.buildsRightSide() {
+		j.buildTypes, j.probeTypes = args.rightTypes, args.leftTypes
+		j.buildEq, j.probeEq = args.rightEqCols, args.leftEqCols
+	} else {
+		j.buildTypes, j.probeTypes = args.leftTypes, args.rightTypes
+		j.buildEq, j.probeEq = args.leftEqCols, args.rightEqCols
+	}
+	return j
+}
+
+func (j *externalHashJoiner) Init() {
+	j.build.Init()
+	j.probe.Init()
+}
+
+// partitionInput writes the tuples of the input to temporary storage,
+// partitioned on their equality columns.
+func (j *externalHashJoiner) partitionInput(
+	ctx context.Context, input Operator, typs []types.T, eqCols []uint32,
+) *diskQueue {
+	q := newDiskQueue(j.cfg, typs, externalHashNumPartitions)
+	partitioner := newHashPartitioner(typs, eqCols, j.depth)
+	for {
+		batch := input.Next(ctx)
+		if batch.Length() == 0 {
+			break
+		}
+		partitioner.partition(ctx, batch, q)
+	}
+	partitioner.flush(ctx, q)
+	q.close(ctx)
+	return q
+}
+
+func (j *externalHashJoiner) Next(ctx context.Context) coldata.Batch {
+	if j.buildPartitions == nil {
+		j.buildPartitions = j.partitionInput(ctx, j.build, j.buildTypes, j.buildEq)
+		j.probePartitions = j.partitionInput(ctx, j.probe, j.probeTypes, j.probeEq)
+	}
+	for {
+		if j.current != nil {
+			if batch := j.current.Next(ctx); batch.Length() > 0 {
+				return batch
+			}
+			j.current = nil
+			j.partitionIdx++
+		}
+		for j.partitionIdx < externalHashNumPartitions &&
+			j.buildPartitions.empty(j.partitionIdx) && j.probePartitions.empty(j.partitionIdx) {
+			j.partitionIdx++
+		}
+		if j.partitionIdx == externalHashNumPartitions {
+			return j.zero
+		}
+		op, err := newSpillingHashJoiner(
+			j.buildPartitions.newReader(j.partitionIdx),
+			j.probePartitions.newReader(j.partitionIdx),
+			j.args, j.cfg, j.depth+1,
+		)
+		panicOnSpillingError(err)
+		op.Init()
+		j.current = op
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package exec
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/distsqlpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/exec/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/exec/types"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+)
+
+// defaultMaxMergeFanIn is the maximum number of sorted runs the external
+// sorter merges at once, unless overridden by the SpillingConfig.
+const defaultMaxMergeFanIn = 16
+
+// NewSpillingSorter returns a sort operator which sorts its input in memory if
+// it fits within the memory limit of cfg, and falls back to an external merge
+// sort otherwise. The arguments are otherwise the same as NewSorter.
+func NewSpillingSorter(
+	input Operator,
+	inputTypes []types.T,
+	orderingCols []distsqlpb.Ordering_Column,
+	cfg *SpillingConfig,
+) (Operator, error) {
+	return newDiskSpiller(
+		input, inputTypes, cfg,
+		func(input Operator) (Operator, error) {
+			return NewSorter(input, inputTypes, orderingCols)
+		},
+		func(input Operator) (Operator, error) {
+			return newExternalSorter(input, inputTypes, orderingCols, cfg), nil
+		},
+	)
+}
+
+// externalSorter is an Operator performing an external merge sort. Its input
+// is split into runs which fit in memory, each of which is sorted by the
+// in-memory sorter and written to temporary storage. The runs are then merged
+// by an orderedSynchronizer; if there are too many of them to be merged at
+// once, runs are merged into longer runs first.
+type externalSorter struct {
+	input        Operator
+	inputTypes   []types.T
+	orderingCols []distsqlpb.Ordering_Column
+	cfg          *SpillingConfig
+	acc          *mon.BoundAccount
+
+	// runs are the sorted runs, each stored in a single-partition diskQueue.
+	runs   []*diskQueue
+	output Operator
+}
+
+var _ Operator = &externalSorter{}
+
+func newExternalSorter(
+	input Operator,
+	inputTypes []types.T,
+	orderingCols []distsqlpb.Ordering_Column,
+	cfg *SpillingConfig,
+) *externalSorter {
+	return &externalSorter{
+		input:        input,
+		inputTypes:   inputTypes,
+		orderingCols: orderingCols,
+		cfg:          cfg,
+		acc:          cfg.NewMemAccount(),
+	}
+}
+
+func (s *externalSorter) Init() {
+	s.input.Init()
+}
+
+func (s *externalSorter) Next(ctx context.Context) coldata.Batch {
+	if s.output == nil {
+		s.createRuns(ctx)
+		s.output = s.mergeRuns(ctx)
+		s.output.Init()
+	}
+	return s.output.Next(ctx)
+}
+
+// createRuns consumes the input, writing it to temporary storage as sorted
+// runs.
+func (s *externalSorter) createRuns(ctx context.Context) {
+	var run []coldata.Batch
+	for {
+		batch := s.input.Next(ctx)
+		if batch.Length() == 0 {
+			break
+		}
+		// A run always holds at least one batch, even if it doesn't fit in
+		// memory on its own.
+		if err := s.acc.Grow(ctx, estimateBatchSizeBytes(batch, s.inputTypes)); err != nil && len(run) > 0 {
+			s.writeRun(ctx, run)
+			run = run[:0]
+			// The account was cleared: account for the batch, if possible, in the
+			// next run.
+			_ = s.acc.Grow(ctx, estimateBatchSizeBytes(batch, s.inputTypes))
+		}
+		run = append(run, copyBatch(batch, s.inputTypes))
+	}
+	if len(run) > 0 {
+		s.writeRun(ctx, run)
+	}
+}
+
+// writeRun sorts the given batches in memory and writes them to temporary
+// storage as a new run.
+func (s *externalSorter) writeRun(ctx context.Context, run []coldata.Batch) {
+	buffered := newBufferedInputOp(s.inputTypes)
+	buffered.batches = append(buffered.batches, run...)
+	for i := range run {
+		run[i] = nil
+	}
+	sorter, err := NewSorter(buffered, s.inputTypes, s.orderingCols)
+	panicOnSpillingError(err)
+	sorter.Init()
+	s.writeSortedRun(ctx, sorter)
+	s.acc.Clear(ctx)
+}
+
+// writeSortedRun writes the output of the given sorted operator to
+// temporary storage as a new run.
+func (s *externalSorter) writeSortedRun(ctx context.Context, sorted Operator) {
+	q := newDiskQueue(s.cfg, s.inputTypes, 1 /* numPartitions */)
+	for {
+		batch := sorted.Next(ctx)
+		if batch.Length() == 0 {
+			break
+		}
+		q.enqueue(ctx, 0 /* partitionIdx */, batch)
+	}
+	q.close(ctx)
+	s.runs = append(s.runs, q)
+}
+
+// mergeRuns returns an Operator merging all the runs.
+func (s *externalSorter) mergeRuns(ctx context.Context) Operator {
+	maxFanIn := defaultMaxMergeFanIn
+	if s.cfg.MaxMergeFanIn > 1 {
+		maxFanIn = s.cfg.MaxMergeFanIn
+	}
+	for len(s.runs) > maxFanIn {
+		merger := s.newMerger(s.runs[:maxFanIn])
+		s.runs = s.runs[maxFanIn:]
+		merger.Init()
+		s.writeSortedRun(ctx, merger)
+	}
+	return s.newMerger(s.runs)
+}
+
+func (s *externalSorter) newMerger(runs []*diskQueue) Operator {
+	inputs := make([]Operator, len(runs))
+	for i, q := range runs {
+		inputs[i] = q.newReader(0 /* partitionIdx */)
+	}
+	return &orderedSynchronizer{
+		inputs:      inputs,
+		ordering:    distsqlpb.ConvertToColumnOrdering(distsqlpb.Ordering{Columns: s.orderingCols}),
+		columnTypes: s.inputTypes,
+	}
+}
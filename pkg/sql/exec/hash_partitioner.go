@@ -0,0 +1,153 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package exec
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/exec/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/exec/types"
+)
+
+const (
+	// externalHashPartitionBits is the number of bits of the hash of a tuple
+	// used to pick its partition at each level of recursion of the external
+	// hash operators.
+	externalHashPartitionBits = 4
+	// externalHashNumPartitions is the number of partitions the external hash
+	// operators split their input into at each level of recursion.
+	externalHashNumPartitions = 1 << externalHashPartitionBits
+	// externalHashMaxDepth is the maximum number of times the external hash
+	// operators repartition their input. Partitions which still don't fit in
+	// memory at that depth (for instance because a single key has too many
+	// tuples) are processed in memory regardless of the limit.
+	externalHashMaxDepth = 4
+)
+
+// hashPartitioner splits batches into the partitions of a diskQueue according
+// to the hash of their key columns. The partition of a tuple is picked using
+// the most significant bits of its hash (so that partitions don't degrade the
+// in-memory hash tables, which use the least significant ones), and
+// partitioners at different depths use different bits, so that a partition
+// can be split again.
+type hashPartitioner struct {
+	// ht is only used to hash the key columns.
+	ht      *hashTable
+	typs    []types.T
+	keyCols []uint32
+	shift   uint
+
+	buckets []uint64
+	keys    []coldata.Vec
+	// sels holds the indices of the tuples of the current batch belonging to
+	// each partition.
+	sels [][]uint16
+	// pending holds the tuples of each partition which haven't been written to
+	// the diskQueue yet, so that full batches are written.
+	pending []coldata.Batch
+}
+
+func newHashPartitioner(typs []types.T, keyCols []uint32, depth int) *hashPartitioner {
+	p := &hashPartitioner{
+		ht:      makeHashTable(1 /* bucketSize */, typs, keyCols, nil /* outCols */),
+		typs:    typs,
+		keyCols: keyCols,
+		shift:   uint(64 - externalHashPartitionBits*(depth+1)),
+		buckets: make([]uint64, coldata.BatchSize),
+		keys:    make([]coldata.Vec, len(keyCols)),
+		sels:    make([][]uint16, externalHashNumPartitions),
+		pending: make([]coldata.Batch, externalHashNumPartitions),
+	}
+	for i := range p.sels {
+		p.sels[i] = make([]uint16, 0, coldata.BatchSize)
+	}
+	return p
+}
+
+// partition splits the batch into the partitions of q.
+func (p *hashPartitioner) partition(ctx context.Context, batch coldata.Batch, q *diskQueue) {
+	n := batch.Length()
+	if n == 0 {
+		return
+	}
+	sel := batch.Selection()
+	for i, colIdx := range p.keyCols {
+		p.keys[i] = batch.ColVec(int(colIdx))
+	}
+	p.ht.initHash(p.buckets, uint64(n))
+	for i, k := range p.ht.keyCols {
+		p.ht.rehash(ctx, p.buckets, i, p.ht.valTypes[k], p.keys[i], uint64(n), sel)
+	}
+
+	for i := range p.sels {
+		p.sels[i] = p.sels[i][:0]
+	}
+	for i := uint16(0); i < n; i++ {
+		idx := i
+		if sel != nil {
+			idx = sel[i]
+		}
+		partitionIdx := int((p.buckets[i] >> p.shift) & (externalHashNumPartitions - 1))
+		for _, key := range p.keys {
+			// The hash of a NULL is undefined, so tuples with NULL keys all go
+			// to the first partition, which keeps identical keys together.
+			if key.HasNulls() && key.Nulls().NullAt(idx) {
+				partitionIdx = 0
+				break
+			}
+		}
+		p.sels[partitionIdx] = append(p.sels[partitionIdx], idx)
+	}
+
+	for partitionIdx, partitionSel := range p.sels {
+		for len(partitionSel) > 0 {
+			if p.pending[partitionIdx] == nil {
+				p.pending[partitionIdx] = coldata.NewMemBatch(p.typs)
+				p.pending[partitionIdx].SetLength(0)
+			}
+			pending := p.pending[partitionIdx]
+			length := pending.Length()
+			toAppend := uint16(len(partitionSel))
+			if room := coldata.BatchSize - length; toAppend > room {
+				toAppend = room
+			}
+			for i, t := range p.typs {
+				pending.ColVec(i).AppendWithSel(batch.ColVec(i), partitionSel, toAppend, t, uint64(length))
+			}
+			pending.SetLength(length + toAppend)
+			partitionSel = partitionSel[toAppend:]
+			if pending.Length() == coldata.BatchSize {
+				p.flushPartition(ctx, partitionIdx, q)
+			}
+		}
+	}
+}
+
+func (p *hashPartitioner) flushPartition(ctx context.Context, partitionIdx int, q *diskQueue) {
+	pending := p.pending[partitionIdx]
+	if pending == nil || pending.Length() == 0 {
+		return
+	}
+	q.enqueue(ctx, partitionIdx, pending)
+	pending.SetLength(0)
+	for i := range p.typs {
+		pending.ColVec(i).Nulls().UnsetNulls()
+	}
+}
+
+// flush writes the pending tuples of all partitions to q.
+func (p *hashPartitioner) flush(ctx context.Context, q *diskQueue) {
+	for partitionIdx := range p.pending {
+		p.flushPartition(ctx, partitionIdx, q)
+	}
+}
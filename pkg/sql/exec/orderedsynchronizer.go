@@ -47,6 +47,11 @@ func (o *orderedSynchronizer) Next(ctx context.Context) coldata.Batch {
 			o.updateComparators(i)
 		}
 	}
+	// Appending to the output only sets the nulls of NULL values, so those of
+	// the previous batch need to be unset.
+	for i := range o.columnTypes {
+		o.output.ColVec(i).Nulls().UnsetNulls()
+	}
 	outputIdx := uint16(0)
 	for outputIdx < coldata.BatchSize {
 		// Determine the batch with the smallest row.
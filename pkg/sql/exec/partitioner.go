@@ -38,8 +38,8 @@ func NewWindowSortingPartitioner(
 	}
 	copy(partitionAndOrderingCols[len(partitionIdxs):], ordCols)
 	orderingColsIdxs = make([]uint32, len(ordCols))
-	for i := range ordCols {
-		orderingColsIdxs[i] = uint32(len(partitionIdxs) + i)
+	for i, col := range ordCols {
+		orderingColsIdxs[i] = col.ColIdx
 	}
 	input, err = NewSorter(input, inputTyps, partitionAndOrderingCols)
 	if err != nil {
@@ -0,0 +1,91 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package exec
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/distsqlpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/exec/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestWindowSortingPartitioner(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	tcs := []struct {
+		tuples        tuples
+		expected      tuples
+		typ           []types.T
+		partitionIdxs []uint32
+		ordCols       []distsqlpb.Ordering_Column
+		// expectedOrderingColsIdxs are the indices of the ordering columns in the
+		// output of the partitioner.
+		expectedOrderingColsIdxs []uint32
+	}{
+		{
+			tuples:                   tuples{{3, 1}, {1, 2}, {2, 1}, {1, 1}},
+			expected:                 tuples{{1, 1, true}, {2, 1, false}, {3, 1, false}, {1, 2, true}},
+			typ:                      []types.T{types.Int64, types.Int64},
+			partitionIdxs:            []uint32{1},
+			ordCols:                  []distsqlpb.Ordering_Column{{ColIdx: 0}},
+			expectedOrderingColsIdxs: []uint32{0},
+		},
+		{
+			tuples: tuples{
+				{1, 5, 2, 0},
+				{2, 4, 1, 0},
+				{1, 3, 1, 0},
+				{2, 2, 2, 0},
+				{1, 1, 1, 0},
+			},
+			expected: tuples{
+				{1, 3, 1, 0, true},
+				{1, 1, 1, 0, false},
+				{1, 5, 2, 0, false},
+				{2, 4, 1, 0, true},
+				{2, 2, 2, 0, false},
+			},
+			typ:           []types.T{types.Int64, types.Int64, types.Int64, types.Int64},
+			partitionIdxs: []uint32{0},
+			ordCols: []distsqlpb.Ordering_Column{
+				{ColIdx: 2},
+				{ColIdx: 1, Direction: distsqlpb.Ordering_Column_DESC},
+			},
+			expectedOrderingColsIdxs: []uint32{2, 1},
+		},
+	}
+	for _, tc := range tcs {
+		runTests(t, []tuples{tc.tuples}, func(t *testing.T, input []Operator) {
+			partitionColIdx := len(tc.typ)
+			op, orderingColsIdxs, err := NewWindowSortingPartitioner(
+				input[0], tc.typ, tc.partitionIdxs, tc.ordCols, partitionColIdx,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(orderingColsIdxs, tc.expectedOrderingColsIdxs) {
+				t.Fatalf("expected ordering columns %v, found %v", tc.expectedOrderingColsIdxs, orderingColsIdxs)
+			}
+			cols := make([]int, len(tc.typ)+1)
+			for i := range cols {
+				cols[i] = i
+			}
+			out := newOpTestOutput(op, cols, tc.expected)
+			if err := out.Verify(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -18,11 +18,14 @@ import (
 	"math/rand"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/sql/exec/coldata"
 	"github.com/cockroachdb/cockroach/pkg/sql/exec/types"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	semtypes "github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/duration"
 )
 
 func TestProjPlusInt64Int64ConstOp(t *testing.T) {
@@ -114,7 +117,7 @@ func TestGetProjectionConstOperator(t *testing.T) {
 	constVal := float64(31.37)
 	constArg := tree.NewDFloat(tree.DFloat(constVal))
 	outputIdx := 5
-	op, err := GetProjectionRConstOperator(semtypes.Float, binOp, input, colIdx, constArg, outputIdx)
+	op, err := GetProjectionRConstOperator(semtypes.Float, semtypes.Float, binOp, input, colIdx, constArg, outputIdx)
 	if err != nil {
 		t.Error(err)
 	}
@@ -136,7 +139,7 @@ func TestGetProjectionOperator(t *testing.T) {
 	col1Idx := 5
 	col2Idx := 7
 	outputIdx := 9
-	op, err := GetProjectionOperator(ct, binOp, input, col1Idx, col2Idx, outputIdx)
+	op, err := GetProjectionOperator(ct, ct, binOp, input, col1Idx, col2Idx, outputIdx)
 	if err != nil {
 		t.Error(err)
 	}
@@ -151,6 +154,68 @@ func TestGetProjectionOperator(t *testing.T) {
 	}
 }
 
+func TestProjTimestampIntervalOps(t *testing.T) {
+	ts := func(sec int64, nsec int64) time.Time {
+		return time.Unix(sec, nsec).UTC()
+	}
+	day := duration.MakeDuration(0, 1, 0)
+	hour := duration.MakeDuration(int64(time.Hour), 0, 0)
+	runTests(t, []tuples{{
+		{ts(0, 0), ts(3600, 0), hour},
+		{ts(86400, 1500), ts(0, 0), day},
+		{nil, ts(0, 0), hour},
+		{ts(0, 0), nil, nil},
+	}}, func(t *testing.T, input []Operator) {
+		var op Operator = input[0]
+		op = &projPlusTimestampIntervalOp{input: op, col1Idx: 0, col2Idx: 2, outputIdx: 3}
+		op = &projMinusTimestampIntervalOp{input: op, col1Idx: 1, col2Idx: 2, outputIdx: 4}
+		op = &projMinusTimestampTimestampOp{input: op, col1Idx: 1, col2Idx: 0, outputIdx: 5}
+		op = &projPlusIntervalTimestampOp{input: op, col1Idx: 2, col2Idx: 0, outputIdx: 6}
+		op = &projMinusIntervalIntervalOp{input: op, col1Idx: 2, col2Idx: 2, outputIdx: 7}
+		op = &projPlusIntervalIntervalConstOp{input: op, colIdx: 2, constArg: day, outputIdx: 8}
+		op.Init()
+		out := newOpTestOutput(op, []int{3, 4, 5, 6, 7, 8}, tuples{
+			// Results are rounded to microseconds, like those of the row engine.
+			{ts(3600, 0), ts(0, 0), hour, ts(3600, 0), duration.Duration{}, duration.MakeDuration(int64(time.Hour), 1, 0)},
+			{ts(2*86400, 2000), ts(-86400, 0), duration.MakeDuration(-86400*int64(time.Second)-1500, 0, 0), ts(2*86400, 2000), duration.Duration{}, duration.MakeDuration(0, 2, 0)},
+			{nil, ts(-3600, 0), nil, nil, duration.Duration{}, duration.MakeDuration(int64(time.Hour), 1, 0)},
+			{nil, nil, nil, nil, nil, nil},
+		})
+		if err := out.Verify(); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestGetProjectionTimestampIntervalOperators(t *testing.T) {
+	var input Operator
+	for _, tc := range []struct {
+		leftType, rightType *semtypes.T
+		binOp               tree.BinaryOperator
+		expected            Operator
+	}{
+		{semtypes.TimestampTZ, semtypes.Interval, tree.Plus, &projPlusTimestampIntervalOp{}},
+		{semtypes.Timestamp, semtypes.Interval, tree.Minus, &projMinusTimestampIntervalOp{}},
+		{semtypes.Timestamp, semtypes.Timestamp, tree.Minus, &projMinusTimestampTimestampOp{}},
+		{semtypes.Interval, semtypes.TimestampTZ, tree.Plus, &projPlusIntervalTimestampOp{}},
+		{semtypes.Interval, semtypes.Interval, tree.Minus, &projMinusIntervalIntervalOp{}},
+	} {
+		op, err := GetProjectionOperator(tc.leftType, tc.rightType, tc.binOp, input, 0, 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(op, tc.expected) {
+			t.Errorf("got %+v, expected %+v", op, tc.expected)
+		}
+	}
+	// Timestamps can't be added to each other.
+	if _, err := GetProjectionOperator(
+		semtypes.Timestamp, semtypes.Timestamp, tree.Plus, input, 0, 0, 0,
+	); !testutils.IsError(err, "unhandled binary operator") {
+		t.Errorf("expected an unhandled binary operator error, got %v", err)
+	}
+}
+
 func benchmarkProjPlusInt64Int64Op(b *testing.B, useSelectionVector bool, hasNulls bool) {
 	ctx := context.Background()
 
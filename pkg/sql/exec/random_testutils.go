@@ -19,6 +19,9 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/sql/exec/coldata"
 	"github.com/cockroachdb/cockroach/pkg/sql/exec/types"
+	"github.com/cockroachdb/cockroach/pkg/util/duration"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 )
 
 // maxVarLen specifies a length limit for variable length types (e.g. byte slices).
@@ -94,6 +97,25 @@ func randomVec(rng *rand.Rand, typ types.T, vec coldata.Vec, n int, nullProbabil
 		for i := 0; i < n; i++ {
 			floats[i] = rng.Float64()
 		}
+	case types.Timestamp:
+		timestamps := vec.Timestamp()
+		for i := 0; i < n; i++ {
+			timestamps[i] = timeutil.Unix(rng.Int63n(1000000), rng.Int63n(1000000))
+		}
+	case types.Interval:
+		intervals := vec.Interval()
+		for i := 0; i < n; i++ {
+			intervals[i] = duration.MakeDuration(rng.Int63n(1000000), rng.Int63n(1000), rng.Int63n(1000))
+		}
+	case types.JSON:
+		jsons := vec.JSON()
+		for i := 0; i < n; i++ {
+			j, err := json.Random(rng.Intn(maxVarLen), rng)
+			if err != nil {
+				panic(err)
+			}
+			jsons[i] = j
+		}
 	default:
 		panic(fmt.Sprintf("unhandled type %s", typ))
 	}
@@ -112,6 +112,28 @@ func TestSort(t *testing.T) {
 			typ:     []types.T{types.Int64, types.Int64, types.Int64},
 			ordCols: []distsqlpb.Ordering_Column{{ColIdx: 0}, {ColIdx: 1}, {ColIdx: 2}},
 		},
+		{
+			tuples: tuples{
+				{mustParseJSON(`{"a": 1}`)},
+				{mustParseJSON(`[1]`)},
+				{mustParseJSON(`true`)},
+				{mustParseJSON(`"x"`)},
+				{mustParseJSON(`2`)},
+				{mustParseJSON(`null`)},
+				{mustParseJSON(`1.5`)},
+			},
+			expected: tuples{
+				{mustParseJSON(`null`)},
+				{mustParseJSON(`"x"`)},
+				{mustParseJSON(`1.5`)},
+				{mustParseJSON(`2`)},
+				{mustParseJSON(`true`)},
+				{mustParseJSON(`[1]`)},
+				{mustParseJSON(`{"a": 1}`)},
+			},
+			typ:     []types.T{types.JSON},
+			ordCols: []distsqlpb.Ordering_Column{{ColIdx: 0}},
+		},
 	}
 	for _, tc := range tcs {
 		runTests(t, []tuples{tc.tuples}, func(t *testing.T, input []Operator) {
@@ -0,0 +1,399 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package exec
+
+import (
+	"context"
+	"math/big"
+	"time"
+	"unsafe"
+
+	"github.com/cockroachdb/apd"
+	"github.com/cockroachdb/cockroach/pkg/sql/exec/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/exec/types"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/storage/diskmap"
+	"github.com/cockroachdb/cockroach/pkg/util/duration"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+)
+
+// SpillingConfig configures the fallback of the operators that buffer their
+// whole input in memory (the sorter, the hash joiner and the hash aggregator)
+// to external variants which spill to temporary storage, and of the window
+// operator, which spills the partitions that don't fit in memory. A config must
+// only be used by the operators planned for a single processor, since they
+// share its temporary storage, and must be closed once they have finished.
+type SpillingConfig struct {
+	// MemMonitor limits the memory used to buffer input. Once an account of
+	// this monitor can't be grown anymore, operators fall back to their
+	// external variants.
+	MemMonitor *mon.BytesMonitor
+	// DiskMonitor accounts for the temporary storage used by spilled data.
+	DiskMonitor *mon.BytesMonitor
+	// TempStorage is the temporary storage spilled data is written to.
+	TempStorage diskmap.Factory
+	// MaxMergeFanIn, if positive, overrides the maximum number of runs the
+	// external sorter merges at once. Used for testing.
+	MaxMergeFanIn int
+
+	// memAccounts are the accounts of all the operators using this config.
+	memAccounts []*mon.BoundAccount
+	diskAcc     mon.BoundAccount
+	// diskMap is created the first time an operator spills to disk, and is
+	// shared by all the diskQueues of the operators using this config.
+	diskMap     diskmap.SortedDiskMap
+	nextQueueID uint64
+	// closers release the resources which operators using this config acquired
+	// outside of it.
+	closers []func(context.Context)
+}
+
+// NewMemAccount returns an account bound to the memory monitor, which is
+// closed when the config is closed.
+func (c *SpillingConfig) NewMemAccount() *mon.BoundAccount {
+	acc := c.MemMonitor.MakeBoundAccount()
+	c.memAccounts = append(c.memAccounts, &acc)
+	return &acc
+}
+
+// AddCloser registers a function which is called when the config is closed,
+// before the memory accounts are cleared. Operators which spill using their
+// own containers (bound to the monitors of the config) use it to close them
+// even if they are not exhausted.
+func (c *SpillingConfig) AddCloser(closer func(context.Context)) {
+	c.closers = append(c.closers, closer)
+}
+
+// Close releases the memory accounted for by the operators using this config,
+// as well as the temporary storage they used.
+func (c *SpillingConfig) Close(ctx context.Context) {
+	for _, closer := range c.closers {
+		closer(ctx)
+	}
+	c.closers = nil
+	for _, acc := range c.memAccounts {
+		acc.Clear(ctx)
+	}
+	c.memAccounts = nil
+	if c.diskMap != nil {
+		c.diskMap.Close(ctx)
+		c.diskMap = nil
+		c.diskAcc.Clear(ctx)
+	}
+}
+
+// SpillingSupported returns whether batches of the given types can be spilled
+// to temporary storage. Operators over other types always buffer their input
+// in memory.
+func SpillingSupported(typs []types.T) bool {
+	if len(typs) == 0 {
+		return false
+	}
+	for _, t := range typs {
+		switch t {
+		case types.Bool, types.Bytes, types.Decimal, types.Int8, types.Int16, types.Int32,
+			types.Int64, types.Float32, types.Float64, types.Timestamp, types.Interval, types.JSON:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// panicOnSpillingError panics with err if it is not nil, converting it into an
+// error the vectorized engine propagates.
+func panicOnSpillingError(err error) {
+	if err != nil {
+		panic(pgerror.Wrap(err, pgerror.CodeSystemError, "spilling to temporary storage"))
+	}
+}
+
+const (
+	sizeOfBool    = int64(unsafe.Sizeof(true))
+	sizeOfInt8    = int64(unsafe.Sizeof(int8(0)))
+	sizeOfInt16   = int64(unsafe.Sizeof(int16(0)))
+	sizeOfInt32   = int64(unsafe.Sizeof(int32(0)))
+	sizeOfInt64   = int64(unsafe.Sizeof(int64(0)))
+	sizeOfFloat32 = int64(unsafe.Sizeof(float32(0)))
+	sizeOfFloat64 = int64(unsafe.Sizeof(float64(0)))
+	sizeOfBytes   = int64(unsafe.Sizeof([]byte(nil)))
+	sizeOfDecimal = int64(unsafe.Sizeof(apd.Decimal{}))
+	sizeOfTime    = int64(unsafe.Sizeof(time.Time{}))
+	sizeOfDur     = int64(unsafe.Sizeof(duration.Duration{}))
+	sizeOfJSON    = int64(unsafe.Sizeof(json.JSON(nil)))
+	sizeOfWord    = int64(unsafe.Sizeof(big.Word(0)))
+)
+
+// fixedSizeBytes returns the memory used by an element of a column vector of
+// the given type, not counting the contents of byte slices, the coefficients
+// of decimals and JSON values.
+func fixedSizeBytes(t types.T) int64 {
+	switch t {
+	case types.Bool:
+		return sizeOfBool
+	case types.Int8:
+		return sizeOfInt8
+	case types.Int16:
+		return sizeOfInt16
+	case types.Int32:
+		return sizeOfInt32
+	case types.Int64:
+		return sizeOfInt64
+	case types.Float32:
+		return sizeOfFloat32
+	case types.Float64:
+		return sizeOfFloat64
+	case types.Bytes:
+		return sizeOfBytes
+	case types.Decimal:
+		return sizeOfDecimal
+	case types.Timestamp:
+		return sizeOfTime
+	case types.Interval:
+		return sizeOfDur
+	case types.JSON:
+		return sizeOfJSON
+	default:
+		return sizeOfInt64
+	}
+}
+
+// estimateBatchSizeBytes estimates the memory used by the selected tuples of
+// the batch once they are copied into column vectors.
+func estimateBatchSizeBytes(batch coldata.Batch, typs []types.T) int64 {
+	n := int64(batch.Length())
+	sel := batch.Selection()
+	var size int64
+	for i, t := range typs {
+		size += n * fixedSizeBytes(t)
+		switch t {
+		case types.Bytes:
+			col := batch.ColVec(i).Bytes()
+			if sel != nil {
+				for _, idx := range sel[:n] {
+					size += int64(len(col[idx]))
+				}
+			} else {
+				for _, b := range col[:n] {
+					size += int64(len(b))
+				}
+			}
+		case types.Decimal:
+			col := batch.ColVec(i).Decimal()
+			if sel != nil {
+				for _, idx := range sel[:n] {
+					size += decimalSizeBytes(&col[idx])
+				}
+			} else {
+				for j := range col[:n] {
+					size += decimalSizeBytes(&col[j])
+				}
+			}
+		case types.JSON:
+			col := batch.ColVec(i).JSON()
+			if sel != nil {
+				for _, idx := range sel[:n] {
+					size += jsonSizeBytes(col[idx])
+				}
+			} else {
+				for _, j := range col[:n] {
+					size += jsonSizeBytes(j)
+				}
+			}
+		}
+	}
+	return size
+}
+
+// EstimateVecsSizeBytes estimates the memory used by the elements with indices
+// in [start, end) of the given column vectors, which have the given types.
+func EstimateVecsSizeBytes(vecs []coldata.Vec, typs []types.T, start, end uint64) int64 {
+	n := int64(end - start)
+	var size int64
+	for i, t := range typs {
+		size += n * fixedSizeBytes(t)
+		switch t {
+		case types.Bytes:
+			for _, b := range vecs[i].Bytes()[start:end] {
+				size += int64(len(b))
+			}
+		case types.Decimal:
+			col := vecs[i].Decimal()
+			for j := start; j < end; j++ {
+				size += decimalSizeBytes(&col[j])
+			}
+		case types.JSON:
+			for _, j := range vecs[i].JSON()[start:end] {
+				size += jsonSizeBytes(j)
+			}
+		}
+	}
+	return size
+}
+
+// decimalSizeBytes returns the memory used by the coefficient of a decimal.
+func decimalSizeBytes(d *apd.Decimal) int64 {
+	return int64(cap(d.Coeff.Bits())) * sizeOfWord
+}
+
+// jsonSizeBytes returns the memory used by the contents of a JSON value, which
+// is nil for unset values.
+func jsonSizeBytes(j json.JSON) int64 {
+	if j == nil {
+		return 0
+	}
+	return int64(j.Size())
+}
+
+// copyBatch returns a copy of the selected tuples of the batch, which doesn't
+// have a selection vector.
+func copyBatch(batch coldata.Batch, typs []types.T) coldata.Batch {
+	n := batch.Length()
+	cpy := coldata.NewMemBatchWithSize(typs, int(n))
+	sel := batch.Selection()
+	for i, t := range typs {
+		if sel != nil {
+			cpy.ColVec(i).CopyWithSelInt16(batch.ColVec(i), sel, n, t)
+		} else {
+			cpy.ColVec(i).Copy(batch.ColVec(i), 0, uint64(n), t)
+		}
+	}
+	cpy.SetLength(n)
+	return cpy
+}
+
+// bufferedInputOp is an Operator that emits buffered batches, followed by the
+// batches of its input, if any. The input must already be initialized.
+type bufferedInputOp struct {
+	batches []coldata.Batch
+	input   Operator
+	zero    coldata.Batch
+}
+
+var _ Operator = &bufferedInputOp{}
+
+func newBufferedInputOp(typs []types.T) *bufferedInputOp {
+	return &bufferedInputOp{zero: coldata.NewMemBatchWithSize(typs, 0)}
+}
+
+func (b *bufferedInputOp) Init() {}
+
+func (b *bufferedInputOp) Next(ctx context.Context) coldata.Batch {
+	if len(b.batches) > 0 {
+		batch := b.batches[0]
+		// Drop the reference to the batch, so that its memory can be reclaimed
+		// once the consumer has copied it.
+		b.batches[0] = nil
+		b.batches = b.batches[1:]
+		return batch
+	}
+	if b.input != nil {
+		return b.input.Next(ctx)
+	}
+	return b.zero
+}
+
+// diskSpiller is an Operator which runs an in-memory operator if its input
+// fits within its memory limit, and falls back to an external operator
+// otherwise. To decide, it buffers its input until either the input is
+// exhausted, in which case the in-memory operator is run over the buffered
+// batches, or the memory account can't be grown anymore, in which case the
+// external operator is run over the buffered batches followed by the rest of
+// the input.
+//
+// The memory accounted for the buffered batches is kept until the operator is
+// exhausted, since the in-memory operator keeps the same tuples in its own
+// state.
+type diskSpiller struct {
+	input      Operator
+	inputTypes []types.T
+	acc        *mon.BoundAccount
+
+	buffered   *bufferedInputOp
+	inMemoryOp Operator
+	externalOp Operator
+
+	// op is the operator chosen once the input has been buffered.
+	op   Operator
+	done bool
+}
+
+var _ Operator = &diskSpiller{}
+
+// newDiskSpiller creates a diskSpiller. The in-memory and the external
+// operators are created with the same buffered input; the external operator
+// may create diskQueues from cfg, while the in-memory one must not use it.
+func newDiskSpiller(
+	input Operator,
+	inputTypes []types.T,
+	cfg *SpillingConfig,
+	newInMemoryOp func(input Operator) (Operator, error),
+	newExternalOp func(input Operator) (Operator, error),
+) (Operator, error) {
+	buffered := newBufferedInputOp(inputTypes)
+	inMemoryOp, err := newInMemoryOp(buffered)
+	if err != nil {
+		return nil, err
+	}
+	externalOp, err := newExternalOp(buffered)
+	if err != nil {
+		return nil, err
+	}
+	return &diskSpiller{
+		input:      input,
+		inputTypes: inputTypes,
+		acc:        cfg.NewMemAccount(),
+		buffered:   buffered,
+		inMemoryOp: inMemoryOp,
+		externalOp: externalOp,
+	}, nil
+}
+
+func (s *diskSpiller) Init() {
+	s.input.Init()
+}
+
+func (s *diskSpiller) Next(ctx context.Context) coldata.Batch {
+	if s.op == nil {
+		s.op = s.inMemoryOp
+		for {
+			batch := s.input.Next(ctx)
+			if batch.Length() == 0 {
+				break
+			}
+			if err := s.acc.Grow(ctx, estimateBatchSizeBytes(batch, s.inputTypes)); err != nil {
+				// The input doesn't fit in memory: the batch is handed to the
+				// external operator along with the rest of the input.
+				s.buffered.batches = append(s.buffered.batches, batch)
+				s.buffered.input = s.input
+				s.op = s.externalOp
+				break
+			}
+			s.buffered.batches = append(s.buffered.batches, copyBatch(batch, s.inputTypes))
+		}
+		if s.op == s.externalOp {
+			// The external operator copies the buffered batches into temporary
+			// storage as it consumes them.
+			s.acc.Clear(ctx)
+		}
+		s.inMemoryOp, s.externalOp = nil, nil
+		s.op.Init()
+	}
+	batch := s.op.Next(ctx)
+	if batch.Length() == 0 && !s.done {
+		s.done = true
+		s.acc.Clear(ctx)
+	}
+	return batch
+}
@@ -0,0 +1,271 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package exec
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"testing"
+
+	"github.com/cockroachdb/apd"
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/distsqlpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/exec/types"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/util/duration"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+// newTestSpillingConfig returns a SpillingConfig whose memory monitor is
+// limited to memLimit bytes, along with a function releasing its resources.
+func newTestSpillingConfig(t *testing.T, memLimit int64) (*SpillingConfig, func()) {
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	tempEngine, err := engine.NewTempEngine(base.DefaultTestTempStorageConfig(st), base.DefaultTestStoreSpec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	memMonitor := mon.MakeMonitorWithLimit(
+		"test-mem", mon.MemoryResource, memLimit, nil /* curCount */, nil, /* maxHist */
+		1 /* increment */, math.MaxInt64 /* noteworthy */, st,
+	)
+	memMonitor.Start(ctx, nil /* pool */, mon.MakeStandaloneBudget(math.MaxInt64))
+	diskMonitor := mon.MakeMonitor(
+		"test-disk", mon.DiskResource, nil /* curCount */, nil, /* maxHist */
+		-1 /* increment */, math.MaxInt64 /* noteworthy */, st,
+	)
+	diskMonitor.Start(ctx, nil /* pool */, mon.MakeStandaloneBudget(math.MaxInt64))
+	cfg := &SpillingConfig{
+		MemMonitor:  &memMonitor,
+		DiskMonitor: &diskMonitor,
+		TempStorage: tempEngine,
+		// Merge few runs at once, so that runs are merged repeatedly.
+		MaxMergeFanIn: 2,
+	}
+	return cfg, func() {
+		cfg.Close(ctx)
+		memMonitor.Stop(ctx)
+		diskMonitor.Stop(ctx)
+		tempEngine.Close()
+	}
+}
+
+// spillingTestMemLimits are the memory limits the spilling operators are
+// tested with: either everything spills, or nothing does.
+var spillingTestMemLimits = []int64{1, math.MaxInt64}
+
+func TestSpillingSorter(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	rng, _ := randutil.NewPseudoRand()
+
+	const nTuples = 500
+	input := make(tuples, nTuples)
+	for i := range input {
+		input[i] = tuple{rng.Int63n(100), i}
+	}
+	expected := make(tuples, nTuples)
+	copy(expected, input)
+	sort.Slice(expected, func(i, j int) bool {
+		if expected[i][0].(int64) != expected[j][0].(int64) {
+			return expected[i][0].(int64) < expected[j][0].(int64)
+		}
+		return expected[i][1].(int) < expected[j][1].(int)
+	})
+	ordCols := []distsqlpb.Ordering_Column{{ColIdx: 0}, {ColIdx: 1}}
+
+	for _, memLimit := range spillingTestMemLimits {
+		t.Run(fmt.Sprintf("memLimit=%d", memLimit), func(t *testing.T) {
+			runTests(t, []tuples{input}, func(t *testing.T, input []Operator) {
+				cfg, cleanup := newTestSpillingConfig(t, memLimit)
+				defer cleanup()
+				sorter, err := NewSpillingSorter(input[0], []types.T{types.Int64, types.Int64}, ordCols, cfg)
+				if err != nil {
+					t.Fatal(err)
+				}
+				out := newOpTestOutput(sorter, []int{0, 1}, expected)
+				if err := out.Verify(); err != nil {
+					t.Fatal(err)
+				}
+			})
+		})
+	}
+}
+
+func TestSpillingSorterTypes(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	rng, _ := randutil.NewPseudoRand()
+
+	// Sort by a key and carry columns of the types which are spilled as the
+	// encodings of their values.
+	const nTuples = 500
+	input := make(tuples, nTuples)
+	for i := range input {
+		var dec apd.Decimal
+		dec.SetFinite(int64(rng.Uint64()), int32(rng.Intn(40)-20))
+		input[i] = tuple{
+			rng.Int63n(100),
+			i,
+			dec,
+			timeutil.Unix(rng.Int63n(1000000), rng.Int63n(1000000)),
+			duration.MakeDuration(rng.Int63n(1000000), rng.Int63n(1000), rng.Int63n(1000)),
+		}
+	}
+	expected := make(tuples, nTuples)
+	copy(expected, input)
+	sort.Slice(expected, func(i, j int) bool {
+		if expected[i][0].(int64) != expected[j][0].(int64) {
+			return expected[i][0].(int64) < expected[j][0].(int64)
+		}
+		return expected[i][1].(int) < expected[j][1].(int)
+	})
+	typs := []types.T{types.Int64, types.Int64, types.Decimal, types.Timestamp, types.Interval}
+	if !SpillingSupported(typs) {
+		t.Fatalf("expected spilling to be supported for %v", typs)
+	}
+	ordCols := []distsqlpb.Ordering_Column{{ColIdx: 0}, {ColIdx: 1}}
+
+	for _, memLimit := range spillingTestMemLimits {
+		t.Run(fmt.Sprintf("memLimit=%d", memLimit), func(t *testing.T) {
+			runTests(t, []tuples{input}, func(t *testing.T, input []Operator) {
+				cfg, cleanup := newTestSpillingConfig(t, memLimit)
+				defer cleanup()
+				sorter, err := NewSpillingSorter(input[0], typs, ordCols, cfg)
+				if err != nil {
+					t.Fatal(err)
+				}
+				out := newOpTestOutput(sorter, []int{0, 1, 2, 3, 4}, expected)
+				if err := out.Verify(); err != nil {
+					t.Fatal(err)
+				}
+			})
+		})
+	}
+}
+
+func TestSpillingHashAggregator(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	rng, _ := randutil.NewPseudoRand()
+
+	const nTuples = 500
+	input := make(tuples, nTuples)
+	sums := make(map[int64]int64)
+	for i := range input {
+		key, val := rng.Int63n(50), rng.Int63n(1000)
+		input[i] = tuple{key, val}
+		sums[key] += val
+	}
+	var expected tuples
+	for key, sum := range sums {
+		expected = append(expected, tuple{key, sum})
+	}
+
+	for _, memLimit := range spillingTestMemLimits {
+		t.Run(fmt.Sprintf("memLimit=%d", memLimit), func(t *testing.T) {
+			runTests(t, []tuples{input}, func(t *testing.T, input []Operator) {
+				cfg, cleanup := newTestSpillingConfig(t, memLimit)
+				defer cleanup()
+				agg, err := NewSpillingHashAggregator(
+					input[0],
+					[]types.T{types.Int64, types.Int64},
+					[]distsqlpb.AggregatorSpec_Func{
+						distsqlpb.AggregatorSpec_ANY_NOT_NULL, distsqlpb.AggregatorSpec_SUM_INT,
+					},
+					[]uint32{0},          /* groupCols */
+					[][]uint32{{0}, {1}}, /* aggCols */
+					cfg,
+				)
+				if err != nil {
+					t.Fatal(err)
+				}
+				out := newOpTestOutput(agg, []int{0, 1}, expected)
+				if err := out.VerifyAnyOrder(); err != nil {
+					t.Fatal(err)
+				}
+			})
+		})
+	}
+}
+
+func TestSpillingHashJoiner(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	rng, _ := randutil.NewPseudoRand()
+
+	const nTuples = 200
+	left := make(tuples, nTuples)
+	right := make(tuples, nTuples)
+	for i := range left {
+		left[i] = tuple{rng.Int63n(100), i}
+		right[i] = tuple{rng.Int63n(100), -i}
+	}
+
+	for _, joinType := range []sqlbase.JoinType{
+		sqlbase.JoinType_INNER, sqlbase.JoinType_LEFT_OUTER, sqlbase.JoinType_LEFT_SEMI,
+	} {
+		var expected tuples
+		for _, l := range left {
+			matched := false
+			for _, r := range right {
+				if l[0] != r[0] {
+					continue
+				}
+				matched = true
+				if joinType == sqlbase.JoinType_LEFT_SEMI {
+					break
+				}
+				expected = append(expected, tuple{l[0], l[1], r[0], r[1]})
+			}
+			switch {
+			case joinType == sqlbase.JoinType_LEFT_SEMI && matched:
+				expected = append(expected, tuple{l[0], l[1]})
+			case joinType == sqlbase.JoinType_LEFT_OUTER && !matched:
+				expected = append(expected, tuple{l[0], l[1], nil, nil})
+			}
+		}
+		rightOutCols, cols := []uint32{0, 1}, []int{0, 1, 2, 3}
+		if joinType == sqlbase.JoinType_LEFT_SEMI {
+			rightOutCols, cols = nil, []int{0, 1}
+		}
+
+		for _, memLimit := range spillingTestMemLimits {
+			t.Run(fmt.Sprintf("%s/memLimit=%d", joinType, memLimit), func(t *testing.T) {
+				runTests(t, []tuples{left, right}, func(t *testing.T, inputs []Operator) {
+					cfg, cleanup := newTestSpillingConfig(t, memLimit)
+					defer cleanup()
+					typs := []types.T{types.Int64, types.Int64}
+					hj, err := NewSpillingEqHashJoinerOp(
+						inputs[0], inputs[1],
+						[]uint32{0}, []uint32{0},
+						[]uint32{0, 1}, rightOutCols,
+						typs, typs,
+						false /* buildRightSide */, false, /* buildDistinct */
+						joinType, cfg,
+					)
+					if err != nil {
+						t.Fatal(err)
+					}
+					out := newOpTestOutput(hj, cols, expected)
+					if err := out.VerifyAnyOrder(); err != nil {
+						t.Fatal(err)
+					}
+				})
+			})
+		}
+	}
+}
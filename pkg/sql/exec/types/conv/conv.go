@@ -28,7 +28,8 @@ func FromColumnType(ct *semtypes.T) types.T {
 	switch ct.Family() {
 	case semtypes.BoolFamily:
 		return types.Bool
-	case semtypes.BytesFamily, semtypes.StringFamily:
+	case semtypes.BytesFamily, semtypes.StringFamily, semtypes.UuidFamily:
+		// UUIDs are represented by their bytes.
 		return types.Bytes
 	case semtypes.DateFamily, semtypes.OidFamily:
 		return types.Int64
@@ -48,6 +49,12 @@ func FromColumnType(ct *semtypes.T) types.T {
 		panic(fmt.Sprintf("integer with unknown width %d", ct.Width()))
 	case semtypes.FloatFamily:
 		return types.Float64
+	case semtypes.TimestampFamily, semtypes.TimestampTZFamily:
+		return types.Timestamp
+	case semtypes.IntervalFamily:
+		return types.Interval
+	case semtypes.JsonFamily:
+		return types.JSON
 	}
 	return types.Unhandled
 }
@@ -164,6 +171,46 @@ func GetDatumToPhysicalFn(ct *semtypes.T) func(tree.Datum) (interface{}, error)
 			}
 			return d.Decimal, nil
 		}
+	case semtypes.TimestampFamily:
+		return func(datum tree.Datum) (interface{}, error) {
+			d, ok := datum.(*tree.DTimestamp)
+			if !ok {
+				return nil, errors.Errorf("expected *tree.DTimestamp, found %s", reflect.TypeOf(datum))
+			}
+			return d.Time, nil
+		}
+	case semtypes.TimestampTZFamily:
+		return func(datum tree.Datum) (interface{}, error) {
+			d, ok := datum.(*tree.DTimestampTZ)
+			if !ok {
+				return nil, errors.Errorf("expected *tree.DTimestampTZ, found %s", reflect.TypeOf(datum))
+			}
+			return d.Time, nil
+		}
+	case semtypes.IntervalFamily:
+		return func(datum tree.Datum) (interface{}, error) {
+			d, ok := datum.(*tree.DInterval)
+			if !ok {
+				return nil, errors.Errorf("expected *tree.DInterval, found %s", reflect.TypeOf(datum))
+			}
+			return d.Duration, nil
+		}
+	case semtypes.UuidFamily:
+		return func(datum tree.Datum) (interface{}, error) {
+			d, ok := datum.(*tree.DUuid)
+			if !ok {
+				return nil, errors.Errorf("expected *tree.DUuid, found %s", reflect.TypeOf(datum))
+			}
+			return d.GetBytes(), nil
+		}
+	case semtypes.JsonFamily:
+		return func(datum tree.Datum) (interface{}, error) {
+			d, ok := datum.(*tree.DJSON)
+			if !ok {
+				return nil, errors.Errorf("expected *tree.DJSON, found %s", reflect.TypeOf(datum))
+			}
+			return d.JSON, nil
+		}
 	}
 	panic(fmt.Sprintf("unhandled type %s", ct.DebugString()))
 }
@@ -14,8 +14,11 @@ package types
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/cockroachdb/apd"
+	"github.com/cockroachdb/cockroach/pkg/util/duration"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
 )
 
 // T represents an exec physical type - a bytes representation of a particular
@@ -42,6 +45,12 @@ const (
 	Float32
 	// Float64 is a column of type float64
 	Float64
+	// Timestamp is a column of type time.Time
+	Timestamp
+	// Interval is a column of type duration.Duration
+	Interval
+	// JSON is a column of type json.JSON
+	JSON
 
 	// Unhandled is a temporary value that represents an unhandled type.
 	// TODO(jordan): this should be replaced by a panic once all types are
@@ -82,6 +91,12 @@ func FromGoType(v interface{}) T {
 		return Bytes
 	case apd.Decimal:
 		return Decimal
+	case time.Time:
+		return Timestamp
+	case duration.Duration:
+		return Interval
+	case json.JSON:
+		return JSON
 	default:
 		panic(fmt.Sprintf("type %T not supported yet", t))
 	}
@@ -108,6 +123,12 @@ func (t T) GoTypeName() string {
 		return "float32"
 	case Float64:
 		return "float64"
+	case Timestamp:
+		return "time.Time"
+	case Interval:
+		return "duration.Duration"
+	case JSON:
+		return "json.JSON"
 	default:
 		panic(fmt.Sprintf("unhandled type %d", t))
 	}
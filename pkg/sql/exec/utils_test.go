@@ -22,6 +22,7 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/sql/exec/coldata"
 	"github.com/cockroachdb/cockroach/pkg/sql/exec/types"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
 	"github.com/cockroachdb/cockroach/pkg/util/randutil"
 	"github.com/pkg/errors"
 )
@@ -32,6 +33,15 @@ type tuple []interface{}
 // tuples represents a table of a single type.
 type tuples []tuple
 
+// mustParseJSON parses a JSON value for use in tuples, panicking on error.
+func mustParseJSON(s string) json.JSON {
+	j, err := json.ParseJSON(s)
+	if err != nil {
+		panic(err)
+	}
+	return j
+}
+
 // runTests is a helper that automatically runs your tests with varied batch
 // sizes and with and without a random selection vector.
 // Provide a test function that takes a list of input Operators, which will give
@@ -0,0 +1,86 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package exec
+
+import (
+	"unsafe"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/exec/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	semtypes "github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil/pgdate"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+	"github.com/lib/pq/oid"
+	"github.com/pkg/errors"
+)
+
+// PhysicalTypeColElemToDatum converts the rowIdx'th element of col, which
+// stores values of the given ColumnType, to a datum. The datum is allocated
+// using da, and strings and bytes share the memory of col.
+func PhysicalTypeColElemToDatum(
+	col coldata.Vec, rowIdx uint64, da *sqlbase.DatumAlloc, ct *semtypes.T,
+) (tree.Datum, error) {
+	if col.Nulls().NullAt64(rowIdx) {
+		return tree.DNull, nil
+	}
+	switch ct.Family() {
+	case semtypes.BoolFamily:
+		if col.Bool()[rowIdx] {
+			return tree.DBoolTrue, nil
+		}
+		return tree.DBoolFalse, nil
+	case semtypes.IntFamily:
+		switch ct.Width() {
+		case 8:
+			return da.NewDInt(tree.DInt(col.Int8()[rowIdx])), nil
+		case 16:
+			return da.NewDInt(tree.DInt(col.Int16()[rowIdx])), nil
+		case 32:
+			return da.NewDInt(tree.DInt(col.Int32()[rowIdx])), nil
+		default:
+			return da.NewDInt(tree.DInt(col.Int64()[rowIdx])), nil
+		}
+	case semtypes.FloatFamily:
+		return da.NewDFloat(tree.DFloat(col.Float64()[rowIdx])), nil
+	case semtypes.DecimalFamily:
+		return da.NewDDecimal(tree.DDecimal{Decimal: col.Decimal()[rowIdx]}), nil
+	case semtypes.DateFamily:
+		return tree.NewDDate(pgdate.MakeCompatibleDateFromDisk(col.Int64()[rowIdx])), nil
+	case semtypes.StringFamily:
+		b := col.Bytes()[rowIdx]
+		if ct.Oid() == oid.T_name {
+			return da.NewDName(tree.DString(*(*string)(unsafe.Pointer(&b)))), nil
+		}
+		return da.NewDString(tree.DString(*(*string)(unsafe.Pointer(&b)))), nil
+	case semtypes.BytesFamily:
+		return da.NewDBytes(tree.DBytes(col.Bytes()[rowIdx])), nil
+	case semtypes.OidFamily:
+		return da.NewDOid(tree.MakeDOid(tree.DInt(col.Int64()[rowIdx]))), nil
+	case semtypes.TimestampFamily:
+		return da.NewDTimestamp(tree.DTimestamp{Time: col.Timestamp()[rowIdx]}), nil
+	case semtypes.TimestampTZFamily:
+		return da.NewDTimestampTZ(tree.DTimestampTZ{Time: col.Timestamp()[rowIdx]}), nil
+	case semtypes.IntervalFamily:
+		return da.NewDInterval(tree.DInterval{Duration: col.Interval()[rowIdx]}), nil
+	case semtypes.UuidFamily:
+		u, err := uuid.FromBytes(col.Bytes()[rowIdx])
+		if err != nil {
+			return nil, err
+		}
+		return da.NewDUuid(tree.DUuid{UUID: u}), nil
+	case semtypes.JsonFamily:
+		return da.NewDJSON(tree.DJSON{JSON: col.JSON()[rowIdx]}), nil
+	}
+	return nil, errors.Errorf("unsupported column type %s", ct.String())
+}
@@ -0,0 +1,538 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package vecbuiltins
+
+import (
+	"context"
+	"unsafe"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/exec"
+	"github.com/cockroachdb/cockroach/pkg/sql/exec/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/exec/types"
+	"github.com/cockroachdb/cockroach/pkg/sql/exec/types/conv"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowcontainer"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/builtins"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	semtypes "github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+)
+
+// NewWindowOperator creates a new exec.Operator that computes the window
+// function created by windowFn over the tuples of input, which have the given
+// column types. frameRun describes the frame of the window function (its
+// Rows, RowIdx and PeerHelper are set by the operator).
+//
+// input *must* already be ordered on the partitioning columns followed by
+// orderingCols. If partitionColIdx is not -1, the partitionColIdx'th column of
+// input must be true for every tuple that is the first within its partition;
+// that column is not emitted. The operator emits all other columns of input
+// followed by the result of the window function.
+//
+// Every partition is buffered, and the window function is computed over it by
+// the same implementation as the one the windower processor uses, so that both
+// return the same results. If cfg is not nil, the memory used to buffer a
+// partition is accounted for against its memory monitor, and the partitions
+// which don't fit in memory are spilled to its temporary storage.
+func NewWindowOperator(
+	evalCtx *tree.EvalContext,
+	input exec.Operator,
+	inputTypes []semtypes.T,
+	windowFn func(*tree.EvalContext) tree.WindowFunc,
+	frameRun *tree.WindowFrameRun,
+	orderingCols []uint32,
+	outputType *semtypes.T,
+	partitionColIdx int,
+	cfg *exec.SpillingConfig,
+) (exec.Operator, error) {
+	physTypes := conv.FromColumnTypes(inputTypes)
+	outputPhysType := conv.FromColumnType(outputType)
+	if outputPhysType == types.Unhandled {
+		return nil, pgerror.Newf(pgerror.CodeDataExceptionError,
+			"window function with output type %s is not supported", outputType)
+	}
+	w := &windowOp{
+		input:           input,
+		evalCtx:         evalCtx,
+		windowFn:        windowFn,
+		frameRun:        *frameRun,
+		outputType:      outputType,
+		partitionColIdx: partitionColIdx,
+		physTypes:       physTypes,
+		output:          coldata.NewMemBatch(append(physTypes[:len(physTypes):len(physTypes)], outputPhysType)),
+	}
+	w.partition.init(evalCtx, inputTypes, physTypes, cfg)
+	if cfg != nil {
+		cfg.AddCloser(w.partition.close)
+	}
+	if len(orderingCols) > 0 {
+		var err error
+		w.input, w.peersCol, err = exec.OrderedDistinctColsToOperators(input, orderingCols, physTypes)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// windowOp is an Operator that computes a window function over the partitions
+// of its input one at a time. A partition is buffered until the first tuple of
+// the next partition is seen (or the input is exhausted), after which the
+// results of the window function are computed one batch at a time, and emitted
+// along with the buffered tuples.
+type windowOp struct {
+	input    exec.Operator
+	evalCtx  *tree.EvalContext
+	windowFn func(*tree.EvalContext) tree.WindowFunc
+	frameRun tree.WindowFrameRun
+
+	outputType      *semtypes.T
+	partitionColIdx int
+	// peersCol is the output column of the chain of ordered distinct operators
+	// in which true indicates that the corresponding tuple starts a new peer
+	// group. It is nil if the window function has no ordering, in which case
+	// all tuples of a partition are peers.
+	peersCol  []bool
+	physTypes []types.T
+
+	// batch is the input batch being buffered, and batchIdx is the index of its
+	// next tuple to be buffered.
+	batch     coldata.Batch
+	batchIdx  uint16
+	inputDone bool
+	// peerGroupNum is the number of the peer group of the last buffered tuple,
+	// and peerGroupNums is scratch space for the numbers of the peer groups of
+	// the tuples of a batch.
+	peerGroupNum  int
+	peerGroupNums []int
+
+	partition windowPartition
+	// builtin is the window function being computed over the buffered
+	// partition. It is nil if the partition is still being buffered.
+	builtin tree.WindowFunc
+
+	output coldata.Batch
+	// resultRows and spilledRows are scratch space for converting the results of
+	// the window function and the tuples of a spilled partition into column
+	// vectors.
+	resultRows  sqlbase.EncDatumRows
+	spilledRows sqlbase.EncDatumRows
+	da          sqlbase.DatumAlloc
+}
+
+var _ exec.Operator = &windowOp{}
+
+func (w *windowOp) Init() {
+	w.input.Init()
+}
+
+func (w *windowOp) Next(ctx context.Context) coldata.Batch {
+	for {
+		if w.builtin != nil {
+			if w.frameRun.RowIdx < w.partition.Len() {
+				return w.computeBatch(ctx)
+			}
+			w.builtin.Close(ctx, w.evalCtx)
+			w.builtin = nil
+			w.partition.reset(ctx)
+		}
+		if w.inputDone {
+			w.partition.close(ctx)
+			w.output.SetLength(0)
+			return w.output
+		}
+		if w.bufferPartition(ctx) {
+			w.startPartition(ctx)
+		}
+	}
+}
+
+// bufferPartition buffers the tuples of the input until the end of the current
+// partition. It returns whether any tuples have been buffered.
+func (w *windowOp) bufferPartition(ctx context.Context) bool {
+	for {
+		if w.batch == nil || w.batchIdx == w.batch.Length() {
+			w.batch, w.batchIdx = w.input.Next(ctx), 0
+			if w.batch.Length() == 0 {
+				w.inputDone = true
+				return w.partition.Len() > 0
+			}
+		}
+		var partitionCol []bool
+		if w.partitionColIdx != -1 {
+			partitionCol = w.batch.ColVec(w.partitionColIdx).Bool()
+		}
+		sel := w.batch.Selection()
+		start, end := w.batchIdx, w.batchIdx
+		w.peerGroupNums = w.peerGroupNums[:0]
+		for ; end < w.batch.Length(); end++ {
+			i := end
+			if sel != nil {
+				i = sel[end]
+			}
+			if w.partition.Len() == 0 && end == start {
+				// This is the first tuple of the partition.
+				w.peerGroupNum = 0
+			} else {
+				if partitionCol != nil && partitionCol[i] {
+					break
+				}
+				if w.peersCol != nil && w.peersCol[i] {
+					w.peerGroupNum++
+				}
+			}
+			w.peerGroupNums = append(w.peerGroupNums, w.peerGroupNum)
+		}
+		w.partition.append(ctx, w.batch, start, end, w.peerGroupNums)
+		w.batchIdx = end
+		if end < w.batch.Length() {
+			return true
+		}
+	}
+}
+
+// startPartition prepares the computation of the window function over the
+// buffered partition.
+func (w *windowOp) startPartition(ctx context.Context) {
+	w.builtin = w.windowFn(w.evalCtx)
+	w.partition.ctx = ctx
+	frameRun := &w.frameRun
+	frameRun.Rows = &w.partition
+	frameRun.RowIdx = 0
+	if !frameRun.IsDefaultFrame() {
+		// With a custom frame, an aggregate has to be reset for every row instead
+		// of accumulating the results over the previous rows.
+		builtins.ShouldReset(w.builtin)
+	}
+	panicOnError(frameRun.PeerHelper.Init(frameRun, &w.partition))
+	frameRun.CurRowPeerGroupNum = 0
+}
+
+// computeBatch computes the results of the window function over the next
+// batch of the buffered tuples, and returns these tuples along with the
+// results.
+func (w *windowOp) computeBatch(ctx context.Context) coldata.Batch {
+	frameRun := &w.frameRun
+	start := frameRun.RowIdx
+	end := start + coldata.BatchSize
+	if end > w.partition.Len() {
+		end = w.partition.Len()
+	}
+	w.resultRows = w.resultRows[:0]
+	for frameRun.RowIdx < end {
+		res, err := w.builtin.Compute(ctx, w.evalCtx, frameRun)
+		panicOnError(err)
+		w.resultRows = append(w.resultRows, sqlbase.EncDatumRow{sqlbase.DatumToEncDatum(w.outputType, res)})
+		frameRun.RowIdx++
+		peerGroupEndIdx := frameRun.PeerHelper.GetFirstPeerIdx(frameRun.CurRowPeerGroupNum) +
+			frameRun.PeerHelper.GetRowCount(frameRun.CurRowPeerGroupNum)
+		if frameRun.RowIdx == peerGroupEndIdx {
+			panicOnError(frameRun.PeerHelper.Update(frameRun))
+			frameRun.CurRowPeerGroupNum++
+		}
+	}
+
+	if w.partition.spilled {
+		for len(w.spilledRows) < end-start {
+			w.spilledRows = append(w.spilledRows, make(sqlbase.EncDatumRow, len(w.physTypes)))
+		}
+		spilledRows := w.spilledRows[:end-start]
+		for i := start; i < end; i++ {
+			// The datums are copied out of the row right away, since reading the
+			// next row can reuse its memory.
+			row, err := w.partition.GetRow(ctx, i)
+			panicOnError(err)
+			for j := range w.physTypes {
+				d, err := row.GetDatum(j)
+				panicOnError(err)
+				spilledRows[i-start][j] = sqlbase.DatumToEncDatum(&w.partition.typs[j], d)
+			}
+		}
+		for j := range w.physTypes {
+			vec := w.output.ColVec(j)
+			vec.Nulls().UnsetNulls()
+			panicOnError(exec.EncDatumRowsToColVec(spilledRows, vec, j, &w.partition.typs[j], &w.da))
+		}
+	} else {
+		for j, t := range w.physTypes {
+			w.output.ColVec(j).Copy(w.partition.vecs[j].Slice(t, uint64(start), uint64(end)), 0, uint64(end-start), t)
+		}
+	}
+	outputVec := w.output.ColVec(len(w.physTypes))
+	outputVec.Nulls().UnsetNulls()
+	panicOnError(exec.EncDatumRowsToColVec(w.resultRows, outputVec, 0 /* columnIdx */, w.outputType, &w.da))
+	w.output.SetLength(uint16(end - start))
+	return w.output
+}
+
+const sizeOfInt = int64(unsafe.Sizeof(int(0)))
+
+// windowPartition is a buffered partition. It implements tree.IndexedRows, so
+// that window functions can be computed over it, and tree.PeerGroupChecker.
+//
+// The tuples of a partition are buffered in column vectors, unless they don't
+// fit within the memory limit, in which case the partition is spilled to a
+// row container which stores the tuples in temporary storage.
+type windowPartition struct {
+	evalCtx   *tree.EvalContext
+	typs      []semtypes.T
+	physTypes []types.T
+	// n is the number of buffered tuples.
+	n int
+
+	// vecs contain the buffered tuples unless the partition is spilled.
+	vecs []coldata.Vec
+	// peerGroupNums contains the number of the peer group of every tuple in
+	// vecs.
+	peerGroupNums []int
+
+	// cfg is the config used to spill partitions; partitions are always kept in
+	// memory if it is nil. acc accounts for the memory used by vecs and
+	// peerGroupNums.
+	cfg *exec.SpillingConfig
+	acc *mon.BoundAccount
+	// spilled is set if the tuples of the partition are stored in rows, each of
+	// them followed by the number of its peer group. rows is created when a
+	// partition is spilled for the first time, and reused for the following
+	// partitions.
+	spilled bool
+	rows    *rowcontainer.DiskBackedIndexedRowContainer
+	// ctx is used to read the rows of a spilled partition in InSameGroup.
+	ctx context.Context
+
+	scratch struct {
+		vecs []coldata.Vec
+		row  sqlbase.EncDatumRow
+	}
+	da sqlbase.DatumAlloc
+}
+
+var _ tree.IndexedRows = &windowPartition{}
+var _ tree.PeerGroupChecker = &windowPartition{}
+
+func (p *windowPartition) init(
+	evalCtx *tree.EvalContext, typs []semtypes.T, physTypes []types.T, cfg *exec.SpillingConfig,
+) {
+	p.evalCtx = evalCtx
+	p.typs = typs
+	p.physTypes = physTypes
+	p.initVecs()
+	if cfg != nil {
+		p.cfg = cfg
+		p.acc = cfg.NewMemAccount()
+	}
+	p.scratch.vecs = make([]coldata.Vec, len(physTypes))
+	p.scratch.row = make(sqlbase.EncDatumRow, len(typs)+1)
+}
+
+func (p *windowPartition) initVecs() {
+	p.vecs = make([]coldata.Vec, len(p.physTypes))
+	for i, t := range p.physTypes {
+		p.vecs[i] = coldata.NewMemColumn(t, 0)
+	}
+	p.peerGroupNums = nil
+}
+
+// append buffers the tuples of batch with indices in [start, end), whose peer
+// groups have the given numbers.
+func (p *windowPartition) append(
+	ctx context.Context, batch coldata.Batch, start, end uint16, peerGroupNums []int,
+) {
+	sel := batch.Selection()
+	if p.spilled {
+		for i := range p.scratch.vecs {
+			p.scratch.vecs[i] = batch.ColVec(i)
+		}
+		for idx := start; idx < end; idx++ {
+			rowIdx := idx
+			if sel != nil {
+				rowIdx = sel[idx]
+			}
+			p.addRow(ctx, p.scratch.vecs, uint64(rowIdx), peerGroupNums[idx-start])
+		}
+		p.n += int(end - start)
+		return
+	}
+
+	for i, t := range p.physTypes {
+		if sel != nil {
+			p.vecs[i].AppendSliceWithSel(batch.ColVec(i), t, uint64(p.n), start, end, sel)
+		} else {
+			p.vecs[i].AppendSlice(batch.ColVec(i), t, uint64(p.n), start, end)
+		}
+	}
+	p.peerGroupNums = append(p.peerGroupNums, peerGroupNums...)
+	prevN := p.n
+	p.n += int(end - start)
+	if p.acc == nil {
+		return
+	}
+	size := exec.EstimateVecsSizeBytes(p.vecs, p.physTypes, uint64(prevN), uint64(p.n)) +
+		int64(end-start)*sizeOfInt
+	if err := p.acc.Grow(ctx, size); err != nil {
+		// The partition doesn't fit in memory.
+		p.spill(ctx)
+	}
+}
+
+// spill moves the tuples buffered in vecs into temporary storage.
+func (p *windowPartition) spill(ctx context.Context) {
+	if p.rows == nil {
+		storedTypes := make([]semtypes.T, len(p.typs)+1)
+		copy(storedTypes, p.typs)
+		storedTypes[len(p.typs)] = *semtypes.Int
+		p.rows = rowcontainer.MakeDiskBackedIndexedRowContainer(
+			nil /* ordering */, storedTypes, p.evalCtx,
+			p.cfg.TempStorage, p.cfg.MemMonitor, p.cfg.DiskMonitor, 0, /* rowCapacity */
+		)
+	}
+	panicOnError(p.rows.SpillToDisk(ctx))
+	for i := 0; i < p.n; i++ {
+		p.addRow(ctx, p.vecs, uint64(i), p.peerGroupNums[i])
+	}
+	p.spilled = true
+	// Release the memory of the vectors, which won't be used by this partition
+	// anymore.
+	p.initVecs()
+	p.acc.Clear(ctx)
+}
+
+// addRow adds the rowIdx'th tuple of vecs, whose peer group has the given
+// number, to the spilled partition.
+func (p *windowPartition) addRow(
+	ctx context.Context, vecs []coldata.Vec, rowIdx uint64, peerGroupNum int,
+) {
+	for i := range p.typs {
+		d, err := exec.PhysicalTypeColElemToDatum(vecs[i], rowIdx, &p.da, &p.typs[i])
+		panicOnError(err)
+		p.scratch.row[i] = sqlbase.DatumToEncDatum(&p.typs[i], d)
+	}
+	p.scratch.row[len(p.typs)] = sqlbase.DatumToEncDatum(
+		semtypes.Int, p.da.NewDInt(tree.DInt(peerGroupNum)),
+	)
+	panicOnError(p.rows.AddRow(ctx, p.scratch.row))
+}
+
+// reset empties the partition. The memory of the vectors is kept for the next
+// partition.
+func (p *windowPartition) reset(ctx context.Context) {
+	if p.spilled {
+		panicOnError(p.rows.UnsafeReset(ctx))
+		p.spilled = false
+	}
+	for _, vec := range p.vecs {
+		vec.Nulls().UnsetNulls()
+	}
+	p.n = 0
+	p.peerGroupNums = p.peerGroupNums[:0]
+	if p.acc != nil {
+		p.acc.Clear(ctx)
+	}
+}
+
+// close releases the temporary storage used by the partition.
+func (p *windowPartition) close(ctx context.Context) {
+	if p.rows != nil {
+		p.rows.Close(ctx)
+		p.rows = nil
+		p.spilled = false
+	}
+}
+
+// Len implements the tree.IndexedRows interface.
+func (p *windowPartition) Len() int {
+	return p.n
+}
+
+// GetRow implements the tree.IndexedRows interface.
+func (p *windowPartition) GetRow(ctx context.Context, idx int) (tree.IndexedRow, error) {
+	if p.spilled {
+		return p.rows.GetRow(ctx, idx)
+	}
+	return windowPartitionRow{partition: p, idx: idx}, nil
+}
+
+// InSameGroup implements the tree.PeerGroupChecker interface.
+func (p *windowPartition) InSameGroup(i, j int) (bool, error) {
+	if !p.spilled {
+		return p.peerGroupNums[i] == p.peerGroupNums[j], nil
+	}
+	// The number of the peer group of i is read before the row j is, since
+	// reading a row can reuse the memory of the previous ones.
+	iNum, err := p.getSpilledPeerGroupNum(i)
+	if err != nil {
+		return false, err
+	}
+	jNum, err := p.getSpilledPeerGroupNum(j)
+	if err != nil {
+		return false, err
+	}
+	return iNum == jNum, nil
+}
+
+func (p *windowPartition) getSpilledPeerGroupNum(idx int) (tree.DInt, error) {
+	row, err := p.rows.GetRow(p.ctx, idx)
+	if err != nil {
+		return 0, err
+	}
+	d, err := row.GetDatum(len(p.typs))
+	if err != nil {
+		return 0, err
+	}
+	return *d.(*tree.DInt), nil
+}
+
+// windowPartitionRow is a tuple of a windowPartition buffered in memory.
+type windowPartitionRow struct {
+	partition *windowPartition
+	idx       int
+}
+
+var _ tree.IndexedRow = windowPartitionRow{}
+
+// GetIdx implements the tree.IndexedRow interface.
+func (r windowPartitionRow) GetIdx() int {
+	return r.idx
+}
+
+// GetDatum implements the tree.IndexedRow interface.
+func (r windowPartitionRow) GetDatum(colIdx int) (tree.Datum, error) {
+	p := r.partition
+	return exec.PhysicalTypeColElemToDatum(p.vecs[colIdx], uint64(r.idx), &p.da, &p.typs[colIdx])
+}
+
+// GetDatums implements the tree.IndexedRow interface.
+func (r windowPartitionRow) GetDatums(startColIdx, endColIdx int) (tree.Datums, error) {
+	datums := make(tree.Datums, 0, endColIdx-startColIdx)
+	for colIdx := startColIdx; colIdx < endColIdx; colIdx++ {
+		d, err := r.GetDatum(colIdx)
+		if err != nil {
+			return nil, err
+		}
+		datums = append(datums, d)
+	}
+	return datums, nil
+}
+
+// panicOnError panics with err if it is not nil. Errors which are not pgerrors
+// are wrapped into one, so that the vectorized engine propagates them.
+func panicOnError(err error) {
+	if err == nil {
+		return
+	}
+	if _, ok := err.(*pgerror.Error); !ok {
+		err = pgerror.Wrap(err, pgerror.CodeDataExceptionError, "computing window function")
+	}
+	panic(err)
+}
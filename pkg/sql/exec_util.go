@@ -15,6 +15,8 @@ package sql
 import (
 	"bytes"
 	"context"
+	cryptorand "crypto/rand"
+	"encoding/binary"
 	"fmt"
 	"net"
 	"net/url"
@@ -227,6 +229,12 @@ var (
 		Measurement: "SQL Statements",
 		Unit:        metric.Unit_COUNT,
 	}
+	MetaSQLOptStmtHintsHits = metric.Metadata{
+		Name:        "sql.optimizer.statement_hints.hits",
+		Help:        "Number of statements planned using hints from system.statement_hints",
+		Measurement: "SQL Statements",
+		Unit:        metric.Unit_COUNT,
+	}
 	MetaDistSQLSelect = metric.Metadata{
 		Name:        "sql.distsql.select.count",
 		Help:        "Number of DistSQL SELECT statements",
@@ -485,6 +493,18 @@ type ExecutorConfig struct {
 	InternalExecutor  *InternalExecutor
 	QueryCache        *querycache.C
 
+	// StmtDiagnosticsRegistry keeps track of the statement diagnostics
+	// requests and bundles of this node.
+	StmtDiagnosticsRegistry *StmtDiagnosticsRegistry
+
+	// StmtHintsCache caches the contents of system.statement_hints, used when
+	// planning statements.
+	StmtHintsCache *StmtHintsCache
+
+	// NotificationRegistry delivers the notifications sent with NOTIFY to the
+	// sessions of this node which are listening for them.
+	NotificationRegistry *NotificationRegistry
+
 	TestingKnobs              ExecutorTestingKnobs
 	PGWireTestingKnobs        *PGWireTestingKnobs
 	SchemaChangerTestingKnobs *SchemaChangerTestingKnobs
@@ -859,6 +879,8 @@ func (p *planner) isAsOf(stmt tree.Statement) (*hlc.Timestamp, error) {
 		asOf = s.AsOf
 	case *tree.Export:
 		return p.isAsOf(s.Query)
+	case *tree.CopyTo:
+		return p.isAsOf(s.Select())
 	case *tree.CreateStats:
 		if s.Options.AsOf.Expr == nil {
 			return nil, nil
@@ -947,34 +969,80 @@ type SessionArgs struct {
 // specified or left empty.
 func (s SessionArgs) isDefined() bool { return len(s.User) != 0 }
 
+// CancelKey identifies a session to the CancelRequest message of the pgwire
+// protocol. It is sent to the client in the BackendKeyData message when the
+// connection is established, in the place of the Postgres backend's process
+// ID and secret key. All its 64 bits are random so that it cannot be guessed
+// by other clients; since it doesn't identify the session's gateway node, a
+// CancelRequest is looked up on every node. The zero key denotes the absence
+// of a key.
+type CancelKey uint64
+
+// makeCancelKey generates a new random CancelKey.
+func makeCancelKey() CancelKey {
+	var buf [8]byte
+	for {
+		if _, err := cryptorand.Read(buf[:]); err != nil {
+			panic(fmt.Sprintf("could not read from crypto/rand: %s", err))
+		}
+		if key := CancelKey(binary.BigEndian.Uint64(buf[:])); key != 0 {
+			return key
+		}
+	}
+}
+
 // SessionRegistry stores a set of all sessions on this node.
 // Use register() and deregister() to modify this registry.
 type SessionRegistry struct {
 	syncutil.Mutex
 	sessions map[ClusterWideID]registrySession
+	// sessionsByCancelKey indexes the sessions which have a pgwire cancel key.
+	sessionsByCancelKey map[CancelKey]registrySession
 }
 
 // NewSessionRegistry creates a new SessionRegistry with an empty set
 // of sessions.
 func NewSessionRegistry() *SessionRegistry {
-	return &SessionRegistry{sessions: make(map[ClusterWideID]registrySession)}
+	return &SessionRegistry{
+		sessions:            make(map[ClusterWideID]registrySession),
+		sessionsByCancelKey: make(map[CancelKey]registrySession),
+	}
 }
 
 func (r *SessionRegistry) register(id ClusterWideID, s registrySession) {
 	r.Lock()
+	defer r.Unlock()
 	r.sessions[id] = s
-	r.Unlock()
+	if key := s.pgwireCancelKey(); key != 0 {
+		// In the unlikely event of a collision of random keys, the key keeps
+		// designating the first session only, so that a CancelRequest never
+		// affects more than one session.
+		if _, ok := r.sessionsByCancelKey[key]; !ok {
+			r.sessionsByCancelKey[key] = s
+		}
+	}
 }
 
 func (r *SessionRegistry) deregister(id ClusterWideID) {
 	r.Lock()
+	defer r.Unlock()
+	if s, ok := r.sessions[id]; ok {
+		if key := s.pgwireCancelKey(); key != 0 && r.sessionsByCancelKey[key] == s {
+			delete(r.sessionsByCancelKey, key)
+		}
+	}
 	delete(r.sessions, id)
-	r.Unlock()
 }
 
 type registrySession interface {
 	user() string
 	cancelQuery(queryID ClusterWideID) bool
+	// pgwireCancelKey returns the pgwire cancel key handed out to the
+	// session's client, or 0 if the session has none.
+	pgwireCancelKey() CancelKey
+	// cancelCurrentQueries cancels all the queries currently running in the
+	// session and returns whether there were any.
+	cancelCurrentQueries() bool
 	cancelSession()
 	// serialize serializes a Session into a serverpb.Session
 	// that can be served over RPC.
@@ -1027,6 +1095,29 @@ func (r *SessionRegistry) CancelSession(sessionIDBytes []byte, username string)
 	return false, fmt.Errorf("session ID %s not found", sessionID)
 }
 
+// ErrCancelKeyNotFound is returned by CancelQueryByKey when no session has the
+// given cancel key.
+var ErrCancelKeyNotFound = errors.New("session for cancel key not found")
+
+// CancelQueryByKey looks up the session with the given pgwire cancel key and
+// cancels the queries it is currently running. This implements the
+// CancelRequest message of the pgwire protocol; like in Postgres, the key is
+// the only credential the client needs to present.
+func (r *SessionRegistry) CancelQueryByKey(key CancelKey) (bool, error) {
+	if key == 0 {
+		return false, ErrCancelKeyNotFound
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	session, ok := r.sessionsByCancelKey[key]
+	if !ok {
+		return false, ErrCancelKeyNotFound
+	}
+	return session.cancelCurrentQueries(), nil
+}
+
 // SerializeAll returns a slice of all sessions in the registry, converted to serverpb.Sessions.
 func (r *SessionRegistry) SerializeAll() []serverpb.Session {
 	r.Lock()
@@ -72,6 +72,8 @@ type EngineMetrics struct {
 	SQLOptFallbackCount   *metric.Counter
 	SQLOptPlanCacheHits   *metric.Counter
 	SQLOptPlanCacheMisses *metric.Counter
+	// The subset of queries which were planned using statement hints.
+	SQLOptStmtHintsHits *metric.Counter
 
 	DistSQLExecLatency    *metric.Histogram
 	SQLExecLatency        *metric.Histogram
@@ -205,4 +207,8 @@ func (ex *connExecutor) updateOptCounters(planFlags planFlags) {
 	} else if planFlags.IsSet(planFlagOptCacheMiss) {
 		m.SQLOptPlanCacheMisses.Inc(1)
 	}
+
+	if planFlags.IsSet(planFlagOptStmtHints) {
+		m.SQLOptStmtHintsHits.Inc(1)
+	}
 }
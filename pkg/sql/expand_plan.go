@@ -358,6 +358,9 @@ func doExpandPlan(
 	case *alterUserSetPasswordNode:
 	case *commentOnColumnNode:
 	case *commentOnDatabaseNode:
+	case *createStmtHintsNode:
+	case *dropStmtHintsNode:
+	case *notifyNode:
 	case *commentOnTableNode:
 	case *renameColumnNode:
 	case *renameDatabaseNode:
@@ -369,12 +372,14 @@ func doExpandPlan(
 	case *createIndexNode:
 	case *CreateUserNode:
 	case *createViewNode:
+	case *createPolicyNode:
 	case *createSequenceNode:
 	case *createStatsNode:
 	case *dropDatabaseNode:
 	case *dropIndexNode:
 	case *dropTableNode:
 	case *dropViewNode:
+	case *dropPolicyNode:
 	case *dropSequenceNode:
 	case *DropUserNode:
 	case *zeroNode:
@@ -880,6 +885,9 @@ func (p *planner) simplifyOrderings(plan planNode, usefulOrdering sqlbase.Column
 	case *alterUserSetPasswordNode:
 	case *commentOnColumnNode:
 	case *commentOnDatabaseNode:
+	case *createStmtHintsNode:
+	case *dropStmtHintsNode:
+	case *notifyNode:
 	case *commentOnTableNode:
 	case *renameColumnNode:
 	case *renameDatabaseNode:
@@ -891,12 +899,14 @@ func (p *planner) simplifyOrderings(plan planNode, usefulOrdering sqlbase.Column
 	case *createIndexNode:
 	case *CreateUserNode:
 	case *createViewNode:
+	case *createPolicyNode:
 	case *createSequenceNode:
 	case *createStatsNode:
 	case *dropDatabaseNode:
 	case *dropIndexNode:
 	case *dropTableNode:
 	case *dropViewNode:
+	case *dropPolicyNode:
 	case *dropSequenceNode:
 	case *DropUserNode:
 	case *zeroNode:
@@ -34,8 +34,12 @@ func (p *planner) Explain(ctx context.Context, n *tree.Explain) (planNode, error
 	p.extendedEvalCtx.SkipNormalize = opts.Flags.Contains(tree.ExplainFlagNoNormalize)
 
 	switch opts.Mode {
-	case tree.ExplainDistSQL:
+	case tree.ExplainDistSQL, tree.ExplainDebug:
 		analyze := opts.Flags.Contains(tree.ExplainFlagAnalyze)
+		debug := opts.Mode == tree.ExplainDebug
+		if debug && !analyze {
+			return nil, errors.New("EXPLAIN (DEBUG) only supported with the ANALYZE option")
+		}
 		if analyze && tree.IsStmtParallelized(n.Statement) {
 			// TODO(nvanbenschoten): Lift this restriction. Then we
 			// can remove tree.IsStmtParallelized.
@@ -55,6 +59,7 @@ func (p *planner) Explain(ctx context.Context, n *tree.Explain) (planNode, error
 			subqueryPlans:      p.curPlan.subqueryPlans,
 			optimizeSubqueries: true,
 			analyze:            analyze,
+			debug:              debug,
 			stmtType:           n.Statement.StatementType(),
 		}, nil
 
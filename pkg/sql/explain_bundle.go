@@ -0,0 +1,258 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package sql
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/lex"
+	"github.com/cockroachdb/cockroach/pkg/sql/opt/exec"
+	"github.com/cockroachdb/cockroach/pkg/sql/opt/exec/execbuilder"
+	"github.com/cockroachdb/cockroach/pkg/sql/opt/memo"
+	"github.com/cockroachdb/cockroach/pkg/sql/opt/xform"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+)
+
+// stmtDiagnosticsCollector holds the planning information about a statement
+// which is included in its diagnostics bundle. It is populated by
+// makeOptimizerPlan when the planner's diagnostics collector is set.
+type stmtDiagnosticsCollector struct {
+	// optimized is set if the statement was planned by the optimizer; the
+	// fields below are only set in that case.
+	optimized bool
+	// memo is the formatted memo of the statement.
+	memo string
+	// plan is the formatted optimizer plan of the statement.
+	plan string
+	// env lists the catalog objects referenced by the statement.
+	env exec.ExplainEnvData
+}
+
+// isExplainAnalyzeDebug returns whether stmt is an EXPLAIN ANALYZE (DEBUG)
+// statement.
+func isExplainAnalyzeDebug(stmt tree.Statement) bool {
+	explain, ok := stmt.(*tree.Explain)
+	if !ok {
+		return false
+	}
+	opts, err := explain.ParseOptions()
+	return err == nil && opts.Mode == tree.ExplainDebug
+}
+
+// recordOptimizerPlan saves the memo, the plan and the referenced catalog
+// objects of an optimizer plan in the collector.
+func (c *stmtDiagnosticsCollector) recordOptimizerPlan(opc *optPlanningCtx, execMemo *memo.Memo) {
+	c.optimized = true
+	if execMemo == opc.optimizer.Memo() {
+		c.memo = opc.optimizer.FormatMemo(xform.FmtPretty)
+	} else {
+		// The memo was reused from the query cache so the optimizer state
+		// doesn't correspond to it; only the chosen plan is available.
+		c.memo = "memo reused from the query cache; only the best plan is available\n"
+	}
+	f := memo.MakeExprFmtCtx(memo.ExprFmtHideQualifications, execMemo)
+	f.FormatExpr(execMemo.RootExpr())
+	c.plan = f.Buffer.String()
+	c.env = execbuilder.GetEnvData(execMemo)
+}
+
+// bundleFile is a file of a diagnostics bundle.
+type bundleFile struct {
+	name, contents string
+}
+
+// buildStmtBundle creates the diagnostics bundle of the statement planned and
+// executed by p. spans is the trace recording of the execution and planJSON
+// the DistSQL plan diagram annotated with execution statistics, if available.
+//
+// The bundle is a zip archive containing:
+//  - statement.txt: the statement.
+//  - opt.txt and memo.txt: the optimizer plan and memo.
+//  - schema.sql: the definitions of the referenced tables, views and
+//    sequences.
+//  - stats-<table>.sql: the statistics of the referenced tables, as
+//    INJECT STATISTICS statements.
+//  - env.sql: the version of the node and the session variables.
+//  - trace.txt and trace.json: the trace of the execution.
+//  - distsql.json: the physical plan with per-operator execution statistics.
+//
+// The information is collected on a best-effort basis: if a part of the
+// environment cannot be retrieved, the error is recorded in the bundle
+// instead.
+func buildStmtBundle(
+	ctx context.Context, p *planner, spans []tracing.RecordedSpan, planJSON string,
+) (*StmtBundle, error) {
+	b := &StmtBundle{
+		Fingerprint: anonymizeStmt(p.stmt.AST),
+		Statement:   p.stmt.AST.String(),
+		CollectedAt: timeutil.Now(),
+	}
+
+	var buf bytes.Buffer
+	z := zip.NewWriter(&buf)
+	addFile := func(name, contents string) error {
+		w, err := z.Create(name)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write([]byte(contents))
+		return err
+	}
+
+	files := []bundleFile{
+		{"statement.txt", b.Statement + "\n"},
+	}
+	c := p.diagnostics
+	if c != nil && c.optimized {
+		files = append(files,
+			bundleFile{"opt.txt", c.plan},
+			bundleFile{"memo.txt", c.memo},
+			bundleFile{"schema.sql", p.bundleSchema(ctx, c.env)},
+		)
+		for _, tn := range c.env.Tables {
+			files = append(files, bundleFile{
+				fmt.Sprintf("stats-%s.sql", tn.String()), p.bundleTableStats(ctx, tn),
+			})
+		}
+	} else {
+		files = append(files, bundleFile{
+			"opt.txt", "statement not planned by the cost-based optimizer\n",
+		})
+	}
+	files = append(files,
+		bundleFile{"env.sql", p.bundleEnv(ctx)},
+		bundleFile{"trace.txt", tracing.FormatRecordedSpans(spans)},
+	)
+	traceJSON, err := json.MarshalIndent(spans, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, bundleFile{"trace.json", string(traceJSON)})
+	if planJSON != "" {
+		files = append(files, bundleFile{"distsql.json", planJSON})
+	}
+
+	for _, f := range files {
+		if err := addFile(f.name, f.contents); err != nil {
+			return nil, err
+		}
+	}
+	if err := z.Close(); err != nil {
+		return nil, err
+	}
+	b.Zip = buf.Bytes()
+	return b, nil
+}
+
+// bundleQuery runs a query returning a single string for a diagnostics bundle.
+// The query is run in its own transaction since the transaction of the
+// statement may have been aborted by its execution.
+func (p *planner) bundleQuery(ctx context.Context, query string) (string, error) {
+	r, err := p.ExecCfg().InternalExecutor.QueryRow(ctx, "stmt-diag-bundle", nil /* txn */, query)
+	if err != nil {
+		return "", err
+	}
+	if len(r) != 1 {
+		return "", pgerror.AssertionFailedf(
+			"expected bundle query %q to return a single column, returned %d", query, len(r),
+		)
+	}
+	s, ok := r[0].(*tree.DString)
+	if !ok {
+		return "", pgerror.AssertionFailedf(
+			"expected bundle query %q to return a DString, returned %T", query, r[0],
+		)
+	}
+	return string(*s), nil
+}
+
+// bundleSchema returns the definitions of the catalog objects referenced by a
+// statement.
+func (p *planner) bundleSchema(ctx context.Context, env exec.ExplainEnvData) string {
+	var buf bytes.Buffer
+	show := func(kind string, tn tree.TableName) {
+		createStatement, err := p.bundleQuery(ctx, fmt.Sprintf(
+			"SELECT create_statement FROM [SHOW CREATE %s %s]", kind, tn.String(),
+		))
+		if err != nil {
+			fmt.Fprintf(&buf, "-- error getting schema of %s: %v\n", tn.String(), err)
+			return
+		}
+		fmt.Fprintf(&buf, "%s;\n", createStatement)
+	}
+	for _, tn := range env.Sequences {
+		show("SEQUENCE", tn)
+	}
+	for _, tn := range env.Tables {
+		show("TABLE", tn)
+	}
+	for _, tn := range env.Views {
+		show("VIEW", tn)
+	}
+	return buf.String()
+}
+
+// bundleTableStats returns a statement injecting the statistics of a table.
+// The histograms are not included, like in EXPLAIN (OPT, ENV).
+func (p *planner) bundleTableStats(ctx context.Context, tn tree.TableName) string {
+	stats, err := p.bundleQuery(ctx, fmt.Sprintf(`
+SELECT
+	jsonb_pretty(COALESCE(json_agg(stat), '[]'))
+FROM
+	(
+		SELECT
+			json_array_elements(statistics) - 'histo_buckets' AS stat
+		FROM
+			[SHOW STATISTICS USING JSON FOR TABLE %s]
+	)
+`, tn.String()))
+	if err != nil {
+		return fmt.Sprintf("-- error getting statistics of %s: %v\n", tn.String(), err)
+	}
+	return fmt.Sprintf("ALTER TABLE %s INJECT STATISTICS '%s';\n", tn.String(), stats)
+}
+
+// bundleEnv returns the version of the node and the session variables of the
+// session which executed a statement.
+func (p *planner) bundleEnv(ctx context.Context) string {
+	var buf bytes.Buffer
+	version, err := p.bundleQuery(ctx, "SELECT version()")
+	if err != nil {
+		fmt.Fprintf(&buf, "-- error getting version: %v\n", err)
+	} else {
+		fmt.Fprintf(&buf, "-- Version: %s\n\n", version)
+	}
+	for _, name := range varNames {
+		v := varGen[name]
+		if v.Hidden || v.Get == nil {
+			continue
+		}
+		value := v.Get(&p.extendedEvalCtx)
+		if v.Set == nil {
+			// The variable can't be set, only show its value.
+			fmt.Fprintf(&buf, "-- %s = %s\n", name, value)
+			continue
+		}
+		fmt.Fprintf(&buf, "SET %s = ", name)
+		lex.EncodeSQLString(&buf, value)
+		buf.WriteString(";\n")
+	}
+	return buf.String()
+}
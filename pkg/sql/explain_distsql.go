@@ -14,11 +14,14 @@ package sql
 
 import (
 	"context"
+	"fmt"
 
+	"github.com/cockroachdb/cockroach/pkg/server/telemetry"
 	"github.com/cockroachdb/cockroach/pkg/sql/distsqlpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sessiondata"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqltelemetry"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/tracing"
 	opentracing "github.com/opentracing/opentracing-go"
@@ -39,6 +42,11 @@ type explainDistSQLNode struct {
 	// returned by the node.
 	analyze bool
 
+	// If debug is set (EXPLAIN ANALYZE (DEBUG)), a statement diagnostics
+	// bundle is collected while the plan is executed and the node returns rows
+	// describing how to retrieve it instead of the plan diagram.
+	debug bool
+
 	// optimizeSubqueries indicates whether to invoke optimizeSubquery and
 	// setUnlimited on the subqueries.
 	optimizeSubqueries bool
@@ -48,11 +56,12 @@ type explainDistSQLNode struct {
 
 // explainDistSQLRun contains the run-time state of explainDistSQLNode during local execution.
 type explainDistSQLRun struct {
-	// The single row returned by the node.
-	values tree.Datums
+	// The rows returned by the node; there is a single row unless debug is
+	// set.
+	rows []tree.Datums
 
-	// done is set if Next() was called.
-	done bool
+	// curRow is the index of the next row to return.
+	curRow int
 
 	// executedStatement is set if EXPLAIN ANALYZE was active and finished
 	// executing the query, regardless of query success or failure.
@@ -175,6 +184,7 @@ func (n *explainDistSQLNode) startExec(params runParams) error {
 		return err
 	}
 
+	var spans []tracing.RecordedSpan
 	if n.analyze {
 		// TODO(andrei): We don't create a child span if the parent is already
 		// recording because we don't currently have a good way to ask for a
@@ -229,7 +239,7 @@ func (n *explainDistSQLNode) startExec(params runParams) error {
 		n.run.executedStatement = true
 
 		sp.Finish()
-		spans := tracing.GetRecording(sp)
+		spans = tracing.GetRecording(sp)
 
 		if err := rw.Err(); err != nil {
 			return err
@@ -242,23 +252,46 @@ func (n *explainDistSQLNode) startExec(params runParams) error {
 		return err
 	}
 
-	n.run.values = tree.Datums{
+	if n.debug {
+		bundle, err := buildStmtBundle(params.ctx, params.p, spans, planJSON)
+		if err != nil {
+			return err
+		}
+		id, err := params.p.ExecCfg().StmtDiagnosticsRegistry.storeBundle(
+			params.ctx, 0 /* requestID */, bundle,
+		)
+		if err != nil {
+			return err
+		}
+		telemetry.Inc(sqltelemetry.StatementDiagnosticsCollectedCounter)
+		for _, line := range []string{
+			"Statement diagnostics bundle generated.",
+			fmt.Sprintf("Bundle ID: %d", id),
+			fmt.Sprintf("Download from the Admin UI: %s%d", StmtBundleURLPrefix, id),
+			"Collected bundles are listed in crdb_internal.statement_diagnostics.",
+		} {
+			n.run.rows = append(n.run.rows, tree.Datums{tree.NewDString(line)})
+		}
+		return nil
+	}
+
+	n.run.rows = []tree.Datums{{
 		tree.MakeDBool(tree.DBool(recommendation == shouldDistribute)),
 		tree.NewDString(planURL.String()),
 		tree.NewDString(planJSON),
-	}
+	}}
 	return nil
 }
 
 func (n *explainDistSQLNode) Next(runParams) (bool, error) {
-	if n.run.done {
+	if n.run.curRow >= len(n.run.rows) {
 		return false, nil
 	}
-	n.run.done = true
+	n.run.curRow++
 	return true, nil
 }
 
-func (n *explainDistSQLNode) Values() tree.Datums { return n.run.values }
+func (n *explainDistSQLNode) Values() tree.Datums { return n.run.rows[n.run.curRow-1] }
 func (n *explainDistSQLNode) Close(ctx context.Context) {
 	n.plan.Close(ctx)
 	for i := range n.subqueryPlans {
@@ -15,6 +15,7 @@ package sql
 import (
 	"context"
 
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
@@ -31,9 +32,11 @@ import (
 //   Notes: postgres requires the object owner.
 //          mysql requires the "grant option" and the same privileges, and sometimes superuser.
 func (p *planner) Grant(ctx context.Context, n *tree.Grant) (planNode, error) {
-	return p.changePrivileges(ctx, n.Targets, n.Grantees, func(privDesc *sqlbase.PrivilegeDescriptor, grantee string) {
-		privDesc.Grant(grantee, n.Privileges)
-	})
+	return p.changePrivileges(ctx, n.Targets, n.Grantees, n.Privileges, n.ColumnPrivileges,
+		false, /* revokeFromColumns */
+		func(privDesc *sqlbase.PrivilegeDescriptor, grantee string, privs privilege.List) {
+			privDesc.Grant(grantee, privs)
+		})
 }
 
 // Revoke removes privileges from users.
@@ -45,17 +48,28 @@ func (p *planner) Grant(ctx context.Context, n *tree.Grant) (planNode, error) {
 // Privileges: GRANT on database/table/view.
 //   Notes: postgres requires the object owner.
 //          mysql requires the "grant option" and the same privileges, and sometimes superuser.
+// As in postgres, revoking a privilege on a table also revokes it on each of
+// its columns.
 func (p *planner) Revoke(ctx context.Context, n *tree.Revoke) (planNode, error) {
-	return p.changePrivileges(ctx, n.Targets, n.Grantees, func(privDesc *sqlbase.PrivilegeDescriptor, grantee string) {
-		privDesc.Revoke(grantee, n.Privileges)
-	})
+	return p.changePrivileges(ctx, n.Targets, n.Grantees, n.Privileges, n.ColumnPrivileges,
+		true, /* revokeFromColumns */
+		func(privDesc *sqlbase.PrivilegeDescriptor, grantee string, privs privilege.List) {
+			privDesc.Revoke(grantee, privs)
+		})
 }
 
+// changePrivileges applies changePrivilege for every grantee to the
+// descriptors of the targets with privs, and to the descriptors of the columns
+// named in colPrivs with the corresponding privilege. If revokeFromColumns is
+// set, privs is also applied to every column of the target tables.
 func (p *planner) changePrivileges(
 	ctx context.Context,
 	targets tree.TargetList,
 	grantees tree.NameList,
-	changePrivilege func(*sqlbase.PrivilegeDescriptor, string),
+	privs privilege.List,
+	colPrivs tree.ColumnPrivileges,
+	revokeFromColumns bool,
+	changePrivilege func(*sqlbase.PrivilegeDescriptor, string, privilege.List),
 ) (planNode, error) {
 	// Check whether grantees exists
 	users, err := p.GetAllUsersAndRoles(ctx)
@@ -91,8 +105,34 @@ func (p *planner) changePrivileges(
 			return nil, err
 		}
 		privileges := descriptor.GetPrivileges()
-		for _, grantee := range grantees {
-			changePrivilege(privileges, string(grantee))
+		if len(privs) > 0 {
+			for _, grantee := range grantees {
+				changePrivilege(privileges, string(grantee), privs)
+			}
+		}
+		if len(colPrivs) > 0 {
+			tableDesc, ok := descriptor.(*sqlbase.MutableTableDescriptor)
+			if !ok || tableDesc.IsView() || tableDesc.IsSequence() {
+				return nil, pgerror.Newf(pgerror.CodeWrongObjectTypeError,
+					"column privileges are only supported on tables, %q is not a table",
+					descriptor.GetName())
+			}
+			for _, colPriv := range colPrivs {
+				for _, colName := range colPriv.Columns {
+					col, err := tableDesc.FindActiveColumnByName(string(colName))
+					if err != nil {
+						return nil, err
+					}
+					changeColumnPrivilege(col, grantees, privilege.List{colPriv.Privilege}, changePrivilege)
+				}
+			}
+		}
+		if revokeFromColumns && len(privs) > 0 {
+			if tableDesc, ok := descriptor.(*sqlbase.MutableTableDescriptor); ok {
+				for i := range tableDesc.Columns {
+					changeColumnPrivilege(&tableDesc.Columns[i], grantees, privs, changePrivilege)
+				}
+			}
 		}
 
 		// Validate privilege descriptors directly as the db/table level Validate
@@ -125,3 +165,23 @@ func (p *planner) changePrivileges(
 	}
 	return newZeroNode(nil /* columns */), nil
 }
+
+// changeColumnPrivilege applies changePrivilege for every grantee to the
+// privileges of a column. The column's privilege descriptor is created when
+// first needed and cleared once it no longer holds any privilege.
+func changeColumnPrivilege(
+	col *sqlbase.ColumnDescriptor,
+	grantees tree.NameList,
+	privs privilege.List,
+	changePrivilege func(*sqlbase.PrivilegeDescriptor, string, privilege.List),
+) {
+	if col.Privileges == nil {
+		col.Privileges = &sqlbase.PrivilegeDescriptor{}
+	}
+	for _, grantee := range grantees {
+		changePrivilege(col.Privileges, string(grantee), privs)
+	}
+	if len(col.Privileges.Users) == 0 {
+		col.Privileges = nil
+	}
+}
@@ -271,24 +271,46 @@ https://www.postgresql.org/docs/9.5/infoschema-column-privileges.html`,
 		return forEachTableDesc(ctx, p, dbContext, virtualMany, func(db *sqlbase.DatabaseDescriptor, scName string, table *sqlbase.TableDescriptor) error {
 			dbNameStr := tree.NewDString(db.Name)
 			scNameStr := tree.NewDString(scName)
-			columndata := privilege.List{privilege.SELECT, privilege.INSERT, privilege.UPDATE} // privileges for column level granularity
-			for _, u := range table.Privileges.Users {
-				for _, priv := range columndata {
-					if priv.Mask()&u.Privileges != 0 {
-						for i := range table.Columns {
-							cd := &table.Columns[i]
-							if err := addRow(
-								tree.DNull,                     // grantor
-								tree.NewDString(u.User),        // grantee
-								dbNameStr,                      // table_catalog
-								scNameStr,                      // table_schema
-								tree.NewDString(table.Name),    // table_name
-								tree.NewDString(cd.Name),       // column_name
-								tree.NewDString(priv.String()), // privilege_type
-								tree.DNull,                     // is_grantable
-							); err != nil {
-								return err
-							}
+			// Privileges granted on the table apply to all of its columns, in
+			// addition to those granted on specific columns.
+			var users []string
+			seen := make(map[string]struct{})
+			addUsers := func(privs *sqlbase.PrivilegeDescriptor) {
+				if privs == nil {
+					return
+				}
+				for _, u := range privs.Users {
+					if _, ok := seen[u.User]; !ok {
+						seen[u.User] = struct{}{}
+						users = append(users, u.User)
+					}
+				}
+			}
+			addUsers(table.Privileges)
+			for i := range table.Columns {
+				addUsers(table.Columns[i].Privileges)
+			}
+			sort.Strings(users)
+
+			for _, user := range users {
+				tablePrivs := userPrivilegeBits(table.Privileges, user)
+				for _, priv := range privilege.ColumnPrivileges {
+					for i := range table.Columns {
+						cd := &table.Columns[i]
+						if priv.Mask()&(tablePrivs|userPrivilegeBits(cd.Privileges, user)) == 0 {
+							continue
+						}
+						if err := addRow(
+							tree.DNull,                     // grantor
+							tree.NewDString(user),          // grantee
+							dbNameStr,                      // table_catalog
+							scNameStr,                      // table_schema
+							tree.NewDString(table.Name),    // table_name
+							tree.NewDString(cd.Name),       // column_name
+							tree.NewDString(priv.String()), // privilege_type
+							tree.DNull,                     // is_grantable
+						); err != nil {
+							return err
 						}
 					}
 				}
@@ -298,6 +320,20 @@ https://www.postgresql.org/docs/9.5/infoschema-column-privileges.html`,
 	},
 }
 
+// userPrivilegeBits returns the privilege bits granted to the given user by a
+// privilege descriptor, which may be nil.
+func userPrivilegeBits(privs *sqlbase.PrivilegeDescriptor, user string) uint32 {
+	if privs == nil {
+		return 0
+	}
+	for _, u := range privs.Users {
+		if u.User == user {
+			return u.Privileges
+		}
+	}
+	return 0
+}
+
 var informationSchemaColumnsTable = virtualSchemaTable{
 	comment: `table and view columns (incomplete)
 ` + base.DocsURL("information-schema.html#columns") + `
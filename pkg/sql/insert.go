@@ -96,6 +96,9 @@ func (p *planner) Insert(
 	if err := p.CheckPrivilege(ctx, desc, privilege.INSERT); err != nil {
 		return nil, err
 	}
+	if err := p.checkRowLevelSecurityUnsupported(ctx, desc.TableDesc()); err != nil {
+		return nil, err
+	}
 	if n.OnConflict != nil {
 		// UPSERT and INDEX ON CONFLICT will read from the table to check for duplicates.
 		if err := p.CheckPrivilege(ctx, desc, privilege.SELECT); err != nil {
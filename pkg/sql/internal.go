@@ -687,6 +687,14 @@ func (icc *internalClientComm) CreateDrainResult(pos CmdPos) DrainResult {
 	panic("unimplemented")
 }
 
+// CreateNotificationResult is part of the ClientComm interface.
+//
+// Notifications are not delivered to internal clients; the returned result
+// discards them and is not part of the results of the batch.
+func (icc *internalClientComm) CreateNotificationResult(pos CmdPos) NotificationResult {
+	return &bufferedCommandResult{errOnly: true}
+}
+
 // noopClientLock is an implementation of ClientLock that says that no results
 // have been communicated to the client.
 type noopClientLock struct {
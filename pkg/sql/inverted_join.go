@@ -0,0 +1,57 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package sql
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+)
+
+// invertedJoinNode looks up the inverted index keys derived from a JSON column
+// of each input row, and produces the input row together with the primary key
+// of each indexed row which could contain the JSON value. The caller is
+// responsible for re-checking the containment predicate.
+type invertedJoinNode struct {
+	input planNode
+
+	// table is the scanNode for the inverted index; it only fetches primary key
+	// columns.
+	table *scanNode
+
+	// inputCol identifies the column from the input from which the inverted
+	// index keys are derived.
+	inputCol int
+
+	// columns are the produced columns, namely the input columns and the
+	// columns in the table scanNode.
+	columns sqlbase.ResultColumns
+}
+
+func (ij *invertedJoinNode) startExec(params runParams) error {
+	panic("invertedJoinNode cannot be run in local mode")
+}
+
+func (ij *invertedJoinNode) Next(params runParams) (bool, error) {
+	panic("invertedJoinNode cannot be run in local mode")
+}
+
+func (ij *invertedJoinNode) Values() tree.Datums {
+	panic("invertedJoinNode cannot be run in local mode")
+}
+
+func (ij *invertedJoinNode) Close(ctx context.Context) {
+	ij.input.Close(ctx)
+	ij.table.Close(ctx)
+}
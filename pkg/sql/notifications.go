@@ -0,0 +1,641 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package sql
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/row"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/ctxgroup"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/pkg/errors"
+)
+
+const (
+	// maxNotificationPayloadLen is the maximum length of the payload of a
+	// notification, as in Postgres.
+	maxNotificationPayloadLen = 8000
+
+	// maxPendingNotifications is the maximum number of notifications queued
+	// for a session which hasn't delivered them to its client yet. Further
+	// notifications are dropped.
+	maxPendingNotifications = 10000
+
+	// notificationsRetryInterval is the interval after which a failed rangefeed
+	// on system.notifications is restarted.
+	notificationsRetryInterval = 5 * time.Second
+
+	// notificationsCleanupInterval is the interval at which each node deletes
+	// the notifications older than the retention.
+	notificationsCleanupInterval = 10 * time.Minute
+
+	// notificationsCleanupBatchSize is the number of old notifications deleted
+	// by each statement of a cleanup.
+	notificationsCleanupBatchSize = 1000
+)
+
+// notificationsRetention is the amount of time for which notifications are
+// kept in system.notifications. A notification is only delivered to the
+// listening sessions of a node which receives it within this time.
+var notificationsRetention = settings.RegisterNonNegativeDurationSetting(
+	"sql.notifications.retention",
+	"the amount of time for which notifications sent with NOTIFY are kept in system.notifications",
+	time.Hour,
+)
+
+// Notification is a notification sent with NOTIFY or pg_notify().
+type Notification struct {
+	Channel string
+	Payload string
+	// NodeID is the gateway node of the session which sent the notification.
+	// It is reported to clients as the process ID of the notifying session,
+	// like in the BackendKeyData message.
+	NodeID roachpb.NodeID
+}
+
+// NotificationRegistry delivers the notifications sent with NOTIFY and
+// pg_notify() to the sessions of this node listening on their channel.
+//
+// Notifications are inserted into system.notifications by the transaction
+// sending them, so they are only visible once it commits. Once a session of
+// the node listens on a channel, the registry watches the table through a
+// rangefeed and hands each notification it receives to the sessions listening
+// on its channel. Sessions queue these notifications, and deliver them to
+// their client when they are not in a transaction.
+//
+// Watching the table requires the kv.rangefeed.enabled cluster setting. The
+// registry also periodically deletes the notifications older than
+// sql.notifications.retention.
+type NotificationRegistry struct {
+	st    *cluster.Settings
+	clock *hlc.Clock
+	ds    *kv.DistSender
+	ie    *InternalExecutor
+	// rangefeedEnabled returns whether rangefeeds are enabled in the cluster.
+	rangefeedEnabled func() bool
+
+	// startCh is closed when the first listener is registered, at which point
+	// the rangefeed is started.
+	startCh chan struct{}
+
+	// dropLogEvery limits the logging of dropped notifications.
+	dropLogEvery log.EveryN
+
+	mu struct {
+		syncutil.Mutex
+		// channels maps the channels listened on to their listeners.
+		channels map[string]map[*notificationListener]struct{}
+		// startTS is the timestamp from which notifications are watched. It is
+		// set when the first listener is registered.
+		startTS hlc.Timestamp
+	}
+}
+
+// NewNotificationRegistry creates a new NotificationRegistry. Start must be
+// called for notifications to be delivered.
+func NewNotificationRegistry(
+	st *cluster.Settings,
+	clock *hlc.Clock,
+	ds *kv.DistSender,
+	ie *InternalExecutor,
+	rangefeedEnabled func() bool,
+) *NotificationRegistry {
+	r := &NotificationRegistry{
+		st:               st,
+		clock:            clock,
+		ds:               ds,
+		ie:               ie,
+		rangefeedEnabled: rangefeedEnabled,
+		startCh:          make(chan struct{}),
+		dropLogEvery:     log.Every(10 * time.Second),
+	}
+	r.mu.channels = make(map[string]map[*notificationListener]struct{})
+	return r
+}
+
+// Start starts the background workers which watch system.notifications once a
+// session listens on a channel, and which delete old notifications.
+func (r *NotificationRegistry) Start(ctx context.Context, stopper *stop.Stopper) {
+	stopper.RunWorker(ctx, func(ctx context.Context) {
+		select {
+		case <-r.startCh:
+		case <-stopper.ShouldQuiesce():
+			return
+		}
+		r.watch(ctx, stopper)
+	})
+	stopper.RunWorker(ctx, func(ctx context.Context) {
+		var timer timeutil.Timer
+		defer timer.Stop()
+		for {
+			timer.Reset(notificationsCleanupInterval)
+			select {
+			case <-timer.C:
+				timer.Read = true
+			case <-stopper.ShouldQuiesce():
+				return
+			}
+			if err := r.deleteOldNotifications(ctx); err != nil {
+				log.Warningf(ctx, "failed to delete old notifications: %v", err)
+			}
+		}
+	})
+}
+
+// newListener creates a listener which isn't listening on any channel yet.
+// wake is called when a notification is queued for the listener while none
+// were pending.
+func (r *NotificationRegistry) newListener(wake func()) *notificationListener {
+	return &notificationListener{
+		registry: r,
+		wake:     wake,
+		channels: make(map[string]struct{}),
+	}
+}
+
+// checkListenSupported returns an error if notifications can't be received.
+func (r *NotificationRegistry) checkListenSupported() error {
+	if r == nil {
+		return pgerror.New(pgerror.CodeFeatureNotSupportedError,
+			"LISTEN is not supported by this server")
+	}
+	if !r.rangefeedEnabled() {
+		return pgerror.New(pgerror.CodeObjectNotInPrerequisiteStateError,
+			"LISTEN requires the kv.rangefeed.enabled setting")
+	}
+	return nil
+}
+
+func (r *NotificationRegistry) listen(l *notificationListener, channel string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.mu.startTS == (hlc.Timestamp{}) {
+		r.mu.startTS = r.clock.Now()
+		close(r.startCh)
+	}
+	listeners, ok := r.mu.channels[channel]
+	if !ok {
+		listeners = make(map[*notificationListener]struct{})
+		r.mu.channels[channel] = listeners
+	}
+	listeners[l] = struct{}{}
+	l.channels[channel] = struct{}{}
+}
+
+func (r *NotificationRegistry) unlisten(l *notificationListener, channel string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.unlistenLocked(l, channel)
+}
+
+func (r *NotificationRegistry) unlistenAll(l *notificationListener) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for channel := range l.channels {
+		r.unlistenLocked(l, channel)
+	}
+}
+
+func (r *NotificationRegistry) unlistenLocked(l *notificationListener, channel string) {
+	if listeners, ok := r.mu.channels[channel]; ok {
+		delete(listeners, l)
+		if len(listeners) == 0 {
+			delete(r.mu.channels, channel)
+		}
+	}
+	delete(l.channels, channel)
+}
+
+// dispatch queues a notification for the listeners of its channel.
+func (r *NotificationRegistry) dispatch(ctx context.Context, n Notification) {
+	var toWake []*notificationListener
+	r.mu.Lock()
+	for l := range r.mu.channels[n.Channel] {
+		queued, wake := l.add(n)
+		if !queued && r.dropLogEvery.ShouldLog() {
+			log.Warningf(ctx, "dropping notification on channel %q: "+
+				"too many notifications pending for a session", n.Channel)
+		}
+		if wake {
+			toWake = append(toWake, l)
+		}
+	}
+	r.mu.Unlock()
+	for _, l := range toWake {
+		l.wake()
+	}
+}
+
+// watch runs a rangefeed on system.notifications until the stopper quiesces.
+// After errors, the rangefeed is restarted from the timestamp up to which all
+// notifications have been received.
+func (r *NotificationRegistry) watch(ctx context.Context, stopper *stop.Stopper) {
+	ctx, cancel := stopper.WithCancelOnQuiesce(ctx)
+	defer cancel()
+
+	prefix := roachpb.Key(keys.MakeTablePrefix(keys.NotificationsTableID))
+	span := roachpb.Span{Key: prefix, EndKey: prefix.PrefixEnd()}
+	r.mu.Lock()
+	w := makeNotificationsWatcher(r, span, r.mu.startTS)
+	r.mu.Unlock()
+	for {
+		// Notifications older than the retention can have been deleted, and
+		// rangefeeds can't be started further back than the GC TTL.
+		minTS := r.clock.Now().Add(-notificationsRetention.Get(&r.st.SV).Nanoseconds(), 0)
+		w.resolved.Forward(minTS)
+
+		err := r.runRangeFeed(ctx, &w)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Warningf(ctx, "notifications rangefeed failed, restarting in %s: %v",
+			notificationsRetryInterval, err)
+		select {
+		case <-time.After(notificationsRetryInterval):
+		case <-stopper.ShouldQuiesce():
+			return
+		}
+	}
+}
+
+func (r *NotificationRegistry) runRangeFeed(ctx context.Context, w *notificationsWatcher) error {
+	eventCh := make(chan *roachpb.RangeFeedEvent)
+	startTS := w.resolved
+	g := ctxgroup.WithContext(ctx)
+	g.GoCtx(func(ctx context.Context) error {
+		if err := r.ds.RangeFeed(ctx, w.span, startTS, eventCh); err != nil {
+			return err
+		}
+		return errors.New("rangefeed exited")
+	})
+	g.GoCtx(func(ctx context.Context) error {
+		for {
+			select {
+			case e := <-eventCh:
+				switch t := e.GetValue().(type) {
+				case *roachpb.RangeFeedValue:
+					if err := w.onValue(ctx, t.Key, t.Value); err != nil {
+						return err
+					}
+				case *roachpb.RangeFeedCheckpoint:
+					w.onCheckpoint(t.Span, t.ResolvedTS)
+				default:
+					return errors.Errorf("unexpected RangeFeedEvent variant %v", t)
+				}
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	})
+	return g.Wait()
+}
+
+// deleteOldNotifications deletes the notifications older than the retention.
+func (r *NotificationRegistry) deleteOldNotifications(ctx context.Context) error {
+	if !r.st.Version.IsActive(cluster.VersionNotifications) {
+		return nil
+	}
+	cutoff := timeutil.Now().Add(-notificationsRetention.Get(&r.st.SV))
+	for {
+		n, err := r.ie.Exec(
+			ctx, "delete-old-notifications", nil, /* txn */
+			`DELETE FROM system.notifications WHERE created_at < $1 LIMIT $2`,
+			cutoff, notificationsCleanupBatchSize,
+		)
+		if err != nil {
+			return err
+		}
+		if n < notificationsCleanupBatchSize {
+			return nil
+		}
+	}
+}
+
+// notificationsWatcher decodes the notifications received from a rangefeed on
+// system.notifications and dispatches them.
+//
+// Rangefeeds can emit a value more than once, in particular when they are
+// restarted. The watcher remembers the keys it has received since the
+// timestamp up to which all the notifications have been received, and skips
+// the notifications it has already dispatched.
+type notificationsWatcher struct {
+	registry *NotificationRegistry
+	span     roachpb.Span
+	// resolved is the timestamp up to which all the notifications in span have
+	// been received.
+	resolved hlc.Timestamp
+	// checkpoints are the resolved timestamps of the parts of span, as
+	// received from the rangefeed. They are keyed by the start key of their
+	// span and don't overlap.
+	checkpoints map[string]notificationsCheckpoint
+	// seen maps the keys of the notifications received after resolved to
+	// their timestamp.
+	seen map[string]hlc.Timestamp
+
+	alloc     sqlbase.DatumAlloc
+	colIdxMap map[sqlbase.ColumnID]int
+}
+
+type notificationsCheckpoint struct {
+	span     roachpb.Span
+	resolved hlc.Timestamp
+}
+
+func makeNotificationsWatcher(
+	r *NotificationRegistry, span roachpb.Span, startTS hlc.Timestamp,
+) notificationsWatcher {
+	return notificationsWatcher{
+		registry:    r,
+		span:        span,
+		resolved:    startTS,
+		checkpoints: make(map[string]notificationsCheckpoint),
+		seen:        make(map[string]hlc.Timestamp),
+		colIdxMap:   row.ColIDtoRowIndexFromCols(sqlbase.NotificationsTable.Columns),
+	}
+}
+
+func (w *notificationsWatcher) onValue(ctx context.Context, key roachpb.Key, value roachpb.Value) error {
+	if !value.IsPresent() {
+		// The notification was deleted.
+		return nil
+	}
+	if !w.resolved.Less(value.Timestamp) {
+		return nil
+	}
+	if _, ok := w.seen[string(key)]; ok {
+		return nil
+	}
+	w.seen[string(key)] = value.Timestamp
+	n, err := w.decode(value)
+	if err != nil {
+		return errors.Wrapf(err, "failed to decode notification %s", key)
+	}
+	w.registry.dispatch(ctx, n)
+	return nil
+}
+
+// decode decodes the columns of a notification from the value of its row.
+func (w *notificationsWatcher) decode(value roachpb.Value) (Notification, error) {
+	tbl := &sqlbase.NotificationsTable
+	var n Notification
+	b, err := value.GetTuple()
+	if err != nil {
+		return n, err
+	}
+	var colIDDiff uint32
+	var lastColID sqlbase.ColumnID
+	var res tree.Datum
+	for len(b) > 0 {
+		_, _, colIDDiff, _, err = encoding.DecodeValueTag(b)
+		if err != nil {
+			return n, err
+		}
+		colID := lastColID + sqlbase.ColumnID(colIDDiff)
+		lastColID = colID
+		idx, ok := w.colIdxMap[colID]
+		if !ok {
+			return n, errors.Errorf("unknown column: %v", colID)
+		}
+		res, b, err = sqlbase.DecodeTableValue(&w.alloc, &tbl.Columns[idx].Type, b)
+		if err != nil {
+			return n, err
+		}
+		switch tbl.Columns[idx].Name {
+		case "channel":
+			n.Channel = string(tree.MustBeDString(res))
+		case "payload":
+			n.Payload = string(tree.MustBeDString(res))
+		case "node_id":
+			n.NodeID = roachpb.NodeID(tree.MustBeDInt(res))
+		}
+	}
+	return n, nil
+}
+
+// onCheckpoint records the resolved timestamp of a part of the watched span,
+// and forwards the resolved timestamp of the whole span if possible.
+func (w *notificationsWatcher) onCheckpoint(span roachpb.Span, resolved hlc.Timestamp) {
+	// The span of a checkpoint is the span of a range. After splits and merges,
+	// the checkpoints of the previous ranges are replaced.
+	for k, c := range w.checkpoints {
+		if c.span.Overlaps(span) {
+			delete(w.checkpoints, k)
+		}
+	}
+	w.checkpoints[string(span.Key)] = notificationsCheckpoint{span: span, resolved: resolved}
+
+	// The whole span is resolved up to the minimum timestamp of the
+	// checkpoints, if they cover it.
+	sorted := make([]notificationsCheckpoint, 0, len(w.checkpoints))
+	for _, c := range w.checkpoints {
+		sorted = append(sorted, c)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].span.Key.Compare(sorted[j].span.Key) < 0
+	})
+	var minResolved hlc.Timestamp
+	next := w.span.Key
+	for i, c := range sorted {
+		if !bytes.Equal(c.span.Key, next) {
+			return
+		}
+		next = c.span.EndKey
+		if i == 0 || c.resolved.Less(minResolved) {
+			minResolved = c.resolved
+		}
+	}
+	if !bytes.Equal(next, w.span.EndKey) || !w.resolved.Less(minResolved) {
+		return
+	}
+	w.resolved = minResolved
+	for k, ts := range w.seen {
+		if !w.resolved.Less(ts) {
+			delete(w.seen, k)
+		}
+	}
+}
+
+// notificationListener is the registration of a session with the
+// NotificationRegistry.
+type notificationListener struct {
+	registry *NotificationRegistry
+	wake     func()
+
+	// channels are the channels listened on. It is protected by the
+	// registry's mutex.
+	channels map[string]struct{}
+
+	mu struct {
+		syncutil.Mutex
+		// pending are the notifications which haven't been delivered to the
+		// session's client yet.
+		pending []Notification
+	}
+}
+
+// add queues a notification. It returns whether the notification was queued,
+// and whether the listener needs to be woken up.
+func (l *notificationListener) add(n Notification) (queued bool, wake bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.mu.pending) >= maxPendingNotifications {
+		return false, false
+	}
+	l.mu.pending = append(l.mu.pending, n)
+	return true, len(l.mu.pending) == 1
+}
+
+// takePending returns the queued notifications and empties the queue.
+func (l *notificationListener) takePending() []Notification {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	pending := l.mu.pending
+	l.mu.pending = nil
+	return pending
+}
+
+// close unregisters the listener from all its channels.
+func (l *notificationListener) close() {
+	l.registry.unlistenAll(l)
+}
+
+// execListen runs a LISTEN or UNLISTEN statement. The statement takes effect
+// once the current transaction commits.
+func (ex *connExecutor) execListen(stmt tree.Statement) error {
+	if _, ok := stmt.(*tree.Listen); ok {
+		if err := ex.server.cfg.NotificationRegistry.checkListenSupported(); err != nil {
+			return err
+		}
+	}
+	ex.extraTxnState.listenStmts = append(ex.extraTxnState.listenStmts, stmt)
+	return nil
+}
+
+// applyListenStmts applies the LISTEN and UNLISTEN statements of a transaction
+// which committed.
+func (ex *connExecutor) applyListenStmts() {
+	for _, stmt := range ex.extraTxnState.listenStmts {
+		switch s := stmt.(type) {
+		case *tree.Listen:
+			if ex.notifications == nil {
+				connCtx := ex.ctxHolder.connCtx
+				ex.notifications = ex.server.cfg.NotificationRegistry.newListener(func() {
+					// The buffer is closed once the session is done, in which case
+					// there is no one to deliver the notifications to anyway.
+					_ = ex.stmtBuf.Push(connCtx, SendNotifications{})
+				})
+			}
+			ex.server.cfg.NotificationRegistry.listen(ex.notifications, string(s.Channel))
+		case *tree.Unlisten:
+			if ex.notifications == nil {
+				continue
+			}
+			if s.Channel == "" {
+				ex.notifications.close()
+			} else {
+				ex.server.cfg.NotificationRegistry.unlisten(ex.notifications, string(s.Channel))
+			}
+		}
+	}
+	ex.extraTxnState.listenStmts = nil
+}
+
+// sendNotifications delivers the notifications queued for the session to its
+// client, if the session is not in a transaction.
+func (ex *connExecutor) sendNotifications(res NotificationResult) {
+	if ex.notifications == nil {
+		return
+	}
+	if _, ok := ex.machine.CurState().(stateNoTxn); !ok {
+		return
+	}
+	for _, n := range ex.notifications.takePending() {
+		res.AddNotification(n)
+	}
+}
+
+// hasPendingNotifications returns whether notifications are queued for the
+// session.
+func (ex *connExecutor) hasPendingNotifications() bool {
+	if ex.notifications == nil {
+		return false
+	}
+	ex.notifications.mu.Lock()
+	defer ex.notifications.mu.Unlock()
+	return len(ex.notifications.mu.pending) > 0
+}
+
+// SendNotification implements the tree.EvalPlanner interface. It sends a
+// notification, which is delivered to the sessions listening on the channel
+// once the current transaction commits.
+func (p *planner) SendNotification(ctx context.Context, channel, payload string) error {
+	if !p.ExecCfg().Settings.Version.IsActive(cluster.VersionNotifications) {
+		return pgerror.Newf(pgerror.CodeObjectNotInPrerequisiteStateError,
+			`NOTIFY requires all nodes to be upgraded to %s`,
+			cluster.VersionByKey(cluster.VersionNotifications),
+		)
+	}
+	if channel == "" {
+		return pgerror.New(pgerror.CodeInvalidParameterValueError,
+			"channel name cannot be empty")
+	}
+	if len(payload) >= maxNotificationPayloadLen {
+		return pgerror.New(pgerror.CodeInvalidParameterValueError,
+			"payload string too long")
+	}
+	_, err := p.ExecCfg().InternalExecutor.Exec(
+		ctx,
+		"notify",
+		p.txn,
+		`INSERT INTO system.notifications (channel, payload, node_id) VALUES ($1, $2, $3)`,
+		channel,
+		payload,
+		p.ExecCfg().NodeID.Get(),
+	)
+	return err
+}
+
+type notifyNode struct {
+	n *tree.Notify
+}
+
+// Notify sends a notification on a channel.
+// Privileges: None.
+//   Notes: postgres does not require privileges either.
+func (p *planner) Notify(ctx context.Context, n *tree.Notify) (planNode, error) {
+	return &notifyNode{n: n}, nil
+}
+
+func (n *notifyNode) startExec(params runParams) error {
+	return params.p.SendNotification(params.ctx, string(n.n.Channel), n.n.Payload)
+}
+
+func (n *notifyNode) Next(runParams) (bool, error) { return false, nil }
+func (n *notifyNode) Values() tree.Datums          { return tree.Datums{} }
+func (n *notifyNode) Close(context.Context)        {}
@@ -128,6 +128,12 @@ func (f *stubFactory) ConstructSetOp(
 	return struct{}{}, nil
 }
 
+func (f *stubFactory) ConstructLocalityOptimizedSearch(
+	local, remote exec.Node, hardLimit int64,
+) (exec.Node, error) {
+	return struct{}{}, nil
+}
+
 func (f *stubFactory) ConstructSort(
 	input exec.Node, ordering sqlbase.ColumnOrdering,
 ) (exec.Node, error) {
@@ -157,6 +163,16 @@ func (f *stubFactory) ConstructLookupJoin(
 	return struct{}{}, nil
 }
 
+func (f *stubFactory) ConstructInvertedJoin(
+	input exec.Node,
+	table cat.Table,
+	index cat.Index,
+	inputCol exec.ColumnOrdinal,
+	lookupCols exec.ColumnOrdinalSet,
+) (exec.Node, error) {
+	return struct{}{}, nil
+}
+
 func (f *stubFactory) ConstructZigzagJoin(
 	leftTable cat.Table,
 	leftIndex cat.Index,
@@ -19,6 +19,7 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util"
 )
 
 // StableID permanently and uniquely identifies a catalog object (table, view,
@@ -119,6 +120,19 @@ type Catalog interface {
 	// the given catalog object. If not, then CheckAnyPrivilege returns an error.
 	CheckAnyPrivilege(ctx context.Context, o Object) error
 
+	// CheckColumnPrivileges verifies that the current user has the given
+	// privilege either on the given table, or on each of its columns with the
+	// given ordinals. If no ordinals are given, having the privilege on any
+	// column of the table is sufficient. If not, then CheckColumnPrivileges
+	// returns an error.
+	CheckColumnPrivileges(ctx context.Context, tab Table, priv privilege.Kind, ords util.FastIntSet) error
+
+	// RowLevelSecurityPolicies returns whether the row-level security policies
+	// of the given table are enforced for the current user and, if so, the
+	// ordinals of the policies which apply to the user. No rows are accessible
+	// if the policies are enforced and none of them applies.
+	RowLevelSecurityPolicies(ctx context.Context, tab Table) (enforced bool, policies util.FastIntSet, err error)
+
 	// RequireSuperUser checks that the current user has admin privileges. If not,
 	// returns an error.
 	RequireSuperUser(ctx context.Context, action string) error
@@ -126,6 +126,40 @@ type Index interface {
 
 	// Span returns the KV span associated with the index.
 	Span() roachpb.Span
+
+	// PartitionCount returns the number of PARTITION BY LIST partitions of the
+	// index. Only the top-level partitions are returned; subpartitions are
+	// ignored.
+	PartitionCount() int
+
+	// Partition returns the ith PARTITION BY LIST partition of the index, where
+	// i < PartitionCount.
+	Partition(i int) Partition
+}
+
+// Partition is an interface to a PARTITION BY LIST partition of an index. It
+// is used to plan locality optimized searches, which first scan the partitions
+// that are located near the gateway node, and only scan the remote partitions
+// if the local partitions don't return enough rows.
+type Partition interface {
+	// Name is the name of the partition.
+	Name() string
+
+	// Zone returns the zone which constrains placement of the partition's range
+	// replicas. If the partition was not explicitly assigned to a zone, then it
+	// inherits the zone of its owning index.
+	Zone() Zone
+
+	// PartitionByListPrefixes returns the values of the partition. Each value
+	// is a prefix of the index key; the partition contains all the index keys
+	// which start with one of the prefixes. For example, a partition with
+	// VALUES IN (('us', 'east'), ('us', 'west')) returns:
+	//
+	//   [/'us'/'east', /'us'/'west']
+	//
+	// The DEFAULT value is not returned, since it doesn't correspond to a
+	// specific prefix.
+	PartitionByListPrefixes() []tree.Datums
 }
 
 // IndexColumn describes a single column that is part of an index definition.
@@ -15,6 +15,7 @@ package cat
 import (
 	"time"
 
+	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 )
 
@@ -137,6 +138,13 @@ type Table interface {
 
 	// InboundForeignKey returns the ith inbound foreign key reference.
 	InboundForeignKey(i int) ForeignKeyConstraint
+
+	// PolicyCount returns the number of row-level security policies defined on
+	// the table.
+	PolicyCount() int
+
+	// Policy returns the ith row-level security policy, where i < PolicyCount.
+	Policy(i int) Policy
 }
 
 // CheckConstraint contains the SQL text and the validity status for a check
@@ -151,6 +159,29 @@ type CheckConstraint struct {
 	Validated  bool
 }
 
+// Policy contains the SQL text of the expressions of a row-level security
+// policy on a table. Policies restrict the rows which can be accessed or added
+// by users who are subject to them. For example, this policy only allows
+// users to access the rows they own:
+//
+//   CREATE POLICY p ON a USING (owner = current_user())
+//
+type Policy struct {
+	Name tree.Name
+
+	// Command is the privilege required by the statements the policy applies
+	// to, or privilege.ALL if it applies to all statements.
+	Command privilege.Kind
+
+	// UsingExpr filters the existing rows which are visible to statements. It
+	// is empty if the policy has no USING expression.
+	UsingExpr string
+
+	// WithCheckExpr must hold for the rows added or modified by statements. It
+	// is empty if the policy has no WITH CHECK expression.
+	WithCheckExpr string
+}
+
 // TableStatistic is an interface to a table statistic. Each statistic is
 // associated with a set of columns.
 type TableStatistic interface {
@@ -12,9 +12,10 @@
 
 package opt
 
-// DefaultJoinOrderLimit denotes the default limit on the number of joins to
-// reorder.
-const DefaultJoinOrderLimit = 4
+// DefaultJoinOrderLimit denotes the default limit on the number of relations
+// in a join tree for which all the join orders are enumerated. Larger join
+// trees are reordered greedily.
+const DefaultJoinOrderLimit = 8
 
 // SaveTablesDatabase is the name of the database where tables created by
 // the saveTableNode are stored.
@@ -199,6 +199,9 @@ func (b *Builder) buildRelational(e memo.RelExpr) (execPlan, error) {
 	case *memo.LookupJoinExpr:
 		ep, err = b.buildLookupJoin(t)
 
+	case *memo.InvertedJoinExpr:
+		ep, err = b.buildInvertedJoin(t)
+
 	case *memo.ZigzagJoinExpr:
 		ep, err = b.buildZigzagJoin(t)
 
@@ -217,6 +220,11 @@ func (b *Builder) buildRelational(e memo.RelExpr) (execPlan, error) {
 	case *memo.Max1RowExpr:
 		ep, err = b.buildMax1Row(t)
 
+	case *memo.BarrierExpr:
+		// The barrier only constrains the optimizer. The filters of its input
+		// are evaluated before the filters above it.
+		ep, err = b.buildRelational(t.Input)
+
 	case *memo.ProjectSetExpr:
 		ep, err = b.buildProjectSet(t)
 
@@ -241,6 +249,9 @@ func (b *Builder) buildRelational(e memo.RelExpr) (execPlan, error) {
 	case *memo.SequenceSelectExpr:
 		ep, err = b.buildSequenceSelect(t)
 
+	case *memo.LocalityOptimizedSearchExpr:
+		ep, err = b.buildLocalityOptimizedSearch(t)
+
 	default:
 		if opt.IsSetOp(e) {
 			ep, err = b.buildSetOp(e)
@@ -1052,6 +1063,47 @@ func (b *Builder) buildSetOp(set memo.RelExpr) (execPlan, error) {
 	return ep, nil
 }
 
+func (b *Builder) buildLocalityOptimizedSearch(
+	los *memo.LocalityOptimizedSearchExpr,
+) (execPlan, error) {
+	local, err := b.buildRelational(los.Local)
+	if err != nil {
+		return execPlan{}, err
+	}
+	remote, err := b.buildRelational(los.Remote)
+	if err != nil {
+		return execPlan{}, err
+	}
+
+	// Make sure both inputs produce their columns in the order of the SetPrivate
+	// column lists.
+	local, err = b.ensureColumns(
+		local, los.LeftCols, nil /* colNames */, los.Local.ProvidedPhysical().Ordering,
+	)
+	if err != nil {
+		return execPlan{}, err
+	}
+	remote, err = b.ensureColumns(
+		remote, los.RightCols, nil /* colNames */, los.Remote.ProvidedPhysical().Ordering,
+	)
+	if err != nil {
+		return execPlan{}, err
+	}
+
+	// The LocalityOptimizedSearch is always in the same group as the Limit it
+	// was generated from, so the maximum cardinality of the group is the limit.
+	hardLimit := int64(los.Relational().Cardinality.Max)
+	node, err := b.factory.ConstructLocalityOptimizedSearch(local.root, remote.root, hardLimit)
+	if err != nil {
+		return execPlan{}, err
+	}
+	ep := execPlan{root: node}
+	for i, col := range los.OutCols {
+		ep.outputCols.Set(int(col), i)
+	}
+	return ep, nil
+}
+
 // buildLimitOffset builds a plan for a LimitOp or OffsetOp
 func (b *Builder) buildLimitOffset(e memo.RelExpr) (execPlan, error) {
 	input, err := b.buildRelational(e.Child(0).(memo.RelExpr))
@@ -1205,6 +1257,34 @@ func (b *Builder) buildLookupJoin(join *memo.LookupJoinExpr) (execPlan, error) {
 	return res, nil
 }
 
+func (b *Builder) buildInvertedJoin(join *memo.InvertedJoinExpr) (execPlan, error) {
+	input, err := b.buildRelational(join.Input)
+	if err != nil {
+		return execPlan{}, err
+	}
+
+	md := b.mem.Metadata()
+
+	inputCol := input.getColumnOrdinal(join.InputCol)
+	lookupCols := join.Cols.Difference(join.Input.Relational().OutputCols)
+
+	lookupOrdinals, lookupColMap := b.getColumns(lookupCols, join.Table)
+	res := execPlan{outputCols: joinOutputMap(input.outputCols, lookupColMap)}
+
+	tab := md.Table(join.Table)
+	res.root, err = b.factory.ConstructInvertedJoin(
+		input.root,
+		tab,
+		tab.Index(join.Index),
+		inputCol,
+		lookupOrdinals,
+	)
+	if err != nil {
+		return execPlan{}, err
+	}
+	return res, nil
+}
+
 func (b *Builder) buildZigzagJoin(join *memo.ZigzagJoinExpr) (execPlan, error) {
 	md := b.mem.Metadata()
 
@@ -1988,25 +2068,32 @@ func (b *Builder) applyPresentation(input execPlan, p *physical.Required) (execP
 // getEnvData consolidates the information that must be presented in
 // EXPLAIN (opt, env).
 func (b *Builder) getEnvData() exec.ExplainEnvData {
+	return GetEnvData(b.mem)
+}
+
+// GetEnvData returns the catalog objects referenced by the given memo, which
+// are needed to reproduce the environment a query was planned in. It is used
+// by EXPLAIN (opt, env) and by statement diagnostics bundles.
+func GetEnvData(mem *memo.Memo) exec.ExplainEnvData {
 	envOpts := exec.ExplainEnvData{ShowEnv: true}
 	// Catalog objects can show up multiple times in these lists, so
 	// deduplicate them.
 	seen := make(map[tree.TableName]bool)
-	for _, t := range b.mem.Metadata().AllTables() {
+	for _, t := range mem.Metadata().AllTables() {
 		tn := *t.Table.Name()
 		if !seen[tn] {
 			seen[tn] = true
 			envOpts.Tables = append(envOpts.Tables, tn)
 		}
 	}
-	for _, s := range b.mem.Metadata().AllSequences() {
+	for _, s := range mem.Metadata().AllSequences() {
 		tn := *s.Name()
 		if !seen[tn] {
 			seen[tn] = true
 			envOpts.Sequences = append(envOpts.Sequences, tn)
 		}
 	}
-	for _, v := range b.mem.Metadata().AllViews() {
+	for _, v := range mem.Metadata().AllViews() {
 		tn := *v.Name()
 		if !seen[tn] {
 			seen[tn] = true
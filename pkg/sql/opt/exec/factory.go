@@ -186,6 +186,13 @@ type Factory interface {
 	// nodes must have the same number of columns.
 	ConstructSetOp(typ tree.UnionType, all bool, left, right Node) (Node, error)
 
+	// ConstructLocalityOptimizedSearch returns a node that returns the rows of
+	// the local node followed by the rows of the remote node, up to hardLimit
+	// rows in total. The remote node is only executed if the local node doesn't
+	// return enough rows. The local and remote nodes must have the same number
+	// of columns.
+	ConstructLocalityOptimizedSearch(local, remote Node, hardLimit int64) (Node, error)
+
 	// ConstructSort returns a node that performs a resorting of the rows produced
 	// by the input node.
 	ConstructSort(input Node, ordering sqlbase.ColumnOrdering) (Node, error)
@@ -219,6 +226,21 @@ type Factory interface {
 		reqOrdering OutputOrdering,
 	) (Node, error)
 
+	// ConstructInvertedJoin returns a node that performs an inverted join.
+	// For each input row, the inverted index keys derived from the JSON value in
+	// the inputCol column are looked up in the (inverted) index; lookupCols are
+	// ordinals for the primary key columns of the candidate rows.
+	//
+	// The node produces the columns in the input and lookupCols (ordered by
+	// ordinal). Each candidate row is produced at most once per input row.
+	ConstructInvertedJoin(
+		input Node,
+		table cat.Table,
+		index cat.Index,
+		inputCol ColumnOrdinal,
+		lookupCols ColumnOrdinalSet,
+	) (Node, error)
+
 	// ConstructZigzagJoin returns a node that performs a zigzag join.
 	// Each side of the join has two kinds of columns that form a prefix
 	// of the specified index: fixed columns (with values specified in
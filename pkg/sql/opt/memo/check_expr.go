@@ -159,6 +159,14 @@ func (m *Memo) checkExpr(e opt.Expr) {
 			panic(pgerror.AssertionFailedf("lookup join with no lookup columns"))
 		}
 
+	case *InvertedJoinExpr:
+		if t.Cols.Empty() {
+			panic(pgerror.AssertionFailedf("inverted join with no output columns"))
+		}
+		if !t.Input.Relational().OutputCols.Contains(int(t.InputCol)) {
+			panic(pgerror.AssertionFailedf("inverted join input column not produced by input"))
+		}
+
 	case *InsertExpr:
 		tab := m.Metadata().Table(t.Table)
 		m.checkColListLen(t.InsertCols, tab.DeletableColumnCount(), "InsertCols")
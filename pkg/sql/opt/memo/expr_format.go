@@ -182,6 +182,11 @@ func (f *ExprFmtCtx) formatRelational(e RelExpr, tp treeprinter.Node) {
 		FormatPrivate(f, e.Private(), required)
 		f.Buffer.WriteByte(')')
 
+	case *InvertedJoinExpr:
+		fmt.Fprintf(f.Buffer, "%v (lookup", e.Op())
+		FormatPrivate(f, e.Private(), required)
+		f.Buffer.WriteByte(')')
+
 	case *ZigzagJoinExpr:
 		fmt.Fprintf(f.Buffer, "%v (zigzag", opt.InnerJoinOp)
 		FormatPrivate(f, e.Private(), required)
@@ -226,7 +231,7 @@ func (f *ExprFmtCtx) formatRelational(e RelExpr, tp treeprinter.Node) {
 		colList = t.Cols
 
 	case *UnionExpr, *IntersectExpr, *ExceptExpr,
-		*UnionAllExpr, *IntersectAllExpr, *ExceptAllExpr:
+		*UnionAllExpr, *IntersectAllExpr, *ExceptAllExpr, *LocalityOptimizedSearchExpr:
 		colList = e.Private().(*SetPrivate).OutCols
 
 	default:
@@ -261,7 +266,7 @@ func (f *ExprFmtCtx) formatRelational(e RelExpr, tp treeprinter.Node) {
 	// Special-case handling for set operators to show the left and right
 	// input columns that correspond to the output columns.
 	case *UnionExpr, *IntersectExpr, *ExceptExpr,
-		*UnionAllExpr, *IntersectAllExpr, *ExceptAllExpr:
+		*UnionAllExpr, *IntersectAllExpr, *ExceptAllExpr, *LocalityOptimizedSearchExpr:
 		if !f.HasFlags(ExprFmtHideColumns) {
 			private := e.Private().(*SetPrivate)
 			f.formatColList(e, tp, "left columns:", private.LeftCols)
@@ -275,6 +280,9 @@ func (f *ExprFmtCtx) formatRelational(e RelExpr, tp treeprinter.Node) {
 		if t.HardLimit.IsSet() {
 			tp.Childf("limit: %s", t.HardLimit)
 		}
+		if t.LocalityOptimized {
+			tp.Child("locality-optimized")
+		}
 		if !t.Flags.Empty() {
 			if t.Flags.NoIndexJoin {
 				tp.Childf("flags: no-index-join")
@@ -305,6 +313,11 @@ func (f *ExprFmtCtx) formatRelational(e RelExpr, tp treeprinter.Node) {
 			tp.Childf("key columns: %v = %v", t.KeyCols, idxCols)
 		}
 
+	case *InvertedJoinExpr:
+		if !f.HasFlags(ExprFmtHideColumns) {
+			tp.Childf("input column: %v", t.InputCol)
+		}
+
 	case *ZigzagJoinExpr:
 		if !f.HasFlags(ExprFmtHideColumns) {
 			tp.Childf("eq columns: %v = %v", t.LeftEqCols, t.RightEqCols)
@@ -488,9 +501,6 @@ func (f *ExprFmtCtx) formatRelational(e RelExpr, tp treeprinter.Node) {
 		if len(r.InterestingOrderings) > 0 {
 			tp.Childf("interesting orderings: %s", r.InterestingOrderings.String())
 		}
-		if r.JoinSize > 1 {
-			tp.Childf("join-size: %d", r.JoinSize)
-		}
 	}
 
 	switch t := e.(type) {
@@ -879,6 +889,10 @@ func FormatPrivate(f *ExprFmtCtx, private interface{}, physProps *physical.Requi
 			fmt.Fprintf(f.Buffer, " %s@%s", tab.Name().TableName, tab.Index(t.Index).Name())
 		}
 
+	case *InvertedJoinPrivate:
+		tab := f.Memo.metadata.Table(t.Table)
+		fmt.Fprintf(f.Buffer, " %s@%s", tab.Name().TableName, tab.Index(t.Index).Name())
+
 	case *ValuesPrivate:
 		fmt.Fprintf(f.Buffer, " id=v%d", t.ID)
 
@@ -466,6 +466,56 @@ func (b *logicalPropsBuilder) buildLookupJoinProps(join *LookupJoinExpr, rel *pr
 	b.buildJoinProps(join, rel)
 }
 
+func (b *logicalPropsBuilder) buildInvertedJoinProps(
+	join *InvertedJoinExpr, rel *props.Relational,
+) {
+	BuildSharedProps(b.mem, join, &rel.Shared)
+
+	inputProps := join.Input.Relational()
+	md := b.mem.Metadata()
+
+	// Output Columns
+	// --------------
+	// The primary key columns of the table are appended to the input columns.
+	rel.OutputCols = inputProps.OutputCols.Union(join.Cols)
+
+	// Not Null Columns
+	// ----------------
+	// Input rows with a NULL input column never have any matches, so that column
+	// is not null. Add not-NULL primary key columns from the table schema.
+	rel.NotNullCols = inputProps.NotNullCols.Copy()
+	rel.NotNullCols.Add(int(join.InputCol))
+	rel.NotNullCols.UnionWith(tableNotNullCols(md, join.Table).Intersection(join.Cols))
+
+	// Outer Columns
+	// -------------
+	// Outer columns were already derived by buildSharedProps.
+
+	// Functional Dependencies
+	// -----------------------
+	// The inverted join is a filtered cartesian product of the input and the
+	// table, so start with the product of the input FD set and the table's FD.
+	rel.FuncDeps.CopyFrom(&inputProps.FuncDeps)
+	rel.FuncDeps.MakeProduct(makeTableFuncDep(md, join.Table))
+	rel.FuncDeps.MakeNotNull(rel.NotNullCols)
+	rel.FuncDeps.ProjectCols(rel.OutputCols)
+
+	// Cardinality
+	// -----------
+	// Each input row can match any number of rows in the table.
+	if inputProps.Cardinality.IsZero() {
+		rel.Cardinality = props.ZeroCardinality
+	} else {
+		rel.Cardinality = props.AnyCardinality
+	}
+
+	// Statistics
+	// ----------
+	if !b.disableStats {
+		b.sb.buildInvertedJoin(join, rel)
+	}
+}
+
 func (b *logicalPropsBuilder) buildZigzagJoinProps(join *ZigzagJoinExpr, rel *props.Relational) {
 	b.buildJoinProps(join, rel)
 }
@@ -578,6 +628,12 @@ func (b *logicalPropsBuilder) buildExceptAllProps(except *ExceptAllExpr, rel *pr
 	b.buildSetProps(except, rel)
 }
 
+func (b *logicalPropsBuilder) buildLocalityOptimizedSearchProps(
+	locOptSearch *LocalityOptimizedSearchExpr, rel *props.Relational,
+) {
+	b.buildSetProps(locOptSearch, rel)
+}
+
 func (b *logicalPropsBuilder) buildSetProps(setNode RelExpr, rel *props.Relational) {
 	BuildSharedProps(b.mem, setNode, &rel.Shared)
 
@@ -879,6 +935,42 @@ func (b *logicalPropsBuilder) buildMax1RowProps(max1Row *Max1RowExpr, rel *props
 	}
 }
 
+func (b *logicalPropsBuilder) buildBarrierProps(barrier *BarrierExpr, rel *props.Relational) {
+	BuildSharedProps(b.mem, barrier, &rel.Shared)
+
+	inputProps := barrier.Input.Relational()
+
+	// Output Columns
+	// --------------
+	// Output columns are inherited from input.
+	rel.OutputCols = inputProps.OutputCols
+
+	// Not Null Columns
+	// ----------------
+	// Not null columns are inherited from input.
+	rel.NotNullCols = inputProps.NotNullCols
+
+	// Outer Columns
+	// -------------
+	// Outer columns were already derived by buildSharedProps.
+
+	// Functional Dependencies
+	// -----------------------
+	// Inherit functional dependencies from input.
+	rel.FuncDeps.CopyFrom(&inputProps.FuncDeps)
+
+	// Cardinality
+	// -----------
+	// Inherit cardinality from input.
+	rel.Cardinality = inputProps.Cardinality
+
+	// Statistics
+	// ----------
+	if !b.disableStats {
+		b.sb.buildBarrier(barrier, rel)
+	}
+}
+
 func (b *logicalPropsBuilder) buildOrdinalityProps(ord *OrdinalityExpr, rel *props.Relational) {
 	BuildSharedProps(b.mem, ord, &rel.Shared)
 
@@ -1325,7 +1417,7 @@ func (b *logicalPropsBuilder) makeSetCardinality(
 ) props.Cardinality {
 	var card props.Cardinality
 	switch nt {
-	case opt.UnionOp, opt.UnionAllOp:
+	case opt.UnionOp, opt.UnionAllOp, opt.LocalityOptimizedSearchOp:
 		// Add cardinality of left and right inputs.
 		card = left.Add(right)
 
@@ -15,6 +15,7 @@ package memo
 import (
 	"math"
 	"reflect"
+	"sort"
 
 	"github.com/cockroachdb/cockroach/pkg/sql/opt"
 	"github.com/cockroachdb/cockroach/pkg/sql/opt/constraint"
@@ -314,8 +315,11 @@ func (sb *statisticsBuilder) colStat(colSet opt.ColSet, e RelExpr) *props.Column
 	case opt.IndexJoinOp:
 		return sb.colStatIndexJoin(colSet, e.(*IndexJoinExpr))
 
+	case opt.InvertedJoinOp:
+		return sb.colStatInvertedJoin(colSet, e.(*InvertedJoinExpr))
+
 	case opt.UnionOp, opt.IntersectOp, opt.ExceptOp,
-		opt.UnionAllOp, opt.IntersectAllOp, opt.ExceptAllOp:
+		opt.UnionAllOp, opt.IntersectAllOp, opt.ExceptAllOp, opt.LocalityOptimizedSearchOp:
 		return sb.colStatSetNode(colSet, e)
 
 	case opt.GroupByOp, opt.ScalarGroupByOp, opt.DistinctOnOp:
@@ -330,6 +334,9 @@ func (sb *statisticsBuilder) colStat(colSet opt.ColSet, e RelExpr) *props.Column
 	case opt.Max1RowOp:
 		return sb.colStatMax1Row(colSet, e.(*Max1RowExpr))
 
+	case opt.BarrierOp:
+		return sb.colStatBarrier(colSet, e.(*BarrierExpr))
+
 	case opt.OrdinalityOp:
 		return sb.colStatOrdinality(colSet, e.(*OrdinalityExpr))
 
@@ -408,13 +415,27 @@ func (sb *statisticsBuilder) colStatLeaf(
 	} else {
 		distinctCount := 1.0
 		nullCount := 0.0
-		colSet.ForEach(func(i int) {
-			colStatLeaf := sb.colStatLeaf(util.MakeFastIntSet(i), s, fd, notNullCols)
+		addColStat := func(colStatLeaf *props.ColumnStatistic) {
 			distinctCount *= colStatLeaf.DistinctCount
 			if nullCount < s.RowCount {
 				// Subtract the expected chance of collisions with nulls already collected.
 				nullCount += colStatLeaf.NullCount * (1 - nullCount/s.RowCount)
 			}
+		}
+
+		// If there are multi-column statistics on subsets of colSet, use them
+		// instead of assuming that all the columns are independent. The
+		// independence assumption is then only made between the subsets.
+		remaining := colSet.Copy()
+		for _, multiCols := range sb.multiColStatSubsets(colSet) {
+			if multiCols.Equals(colSet) || !multiCols.SubsetOf(remaining) {
+				continue
+			}
+			remaining.DifferenceWith(multiCols)
+			addColStat(sb.colStatLeaf(multiCols, s, fd, notNullCols))
+		}
+		remaining.ForEach(func(i int) {
+			addColStat(sb.colStatLeaf(util.MakeFastIntSet(i), s, fd, notNullCols))
 		})
 		// Fetch the colStat again since it may now have a different address.
 		colStat, _ = s.ColStats.Lookup(colSet)
@@ -425,6 +446,52 @@ func (sb *statisticsBuilder) colStatLeaf(
 	return colStat
 }
 
+// multiColStatSubsets returns the column sets of the multi-column statistics
+// collected on the base tables of the given columns which only contain columns
+// in colSet. The sets are ordered by decreasing number of columns.
+func (sb *statisticsBuilder) multiColStatSubsets(colSet opt.ColSet) []opt.ColSet {
+	var tables util.FastIntSet
+	colSet.ForEach(func(i int) {
+		if tabID := sb.md.ColumnMeta(opt.ColumnID(i)).Table; tabID != 0 {
+			tables.Add(int(tabID))
+		}
+	})
+
+	var subsets []opt.ColSet
+	tables.ForEach(func(i int) {
+		tabID := opt.TableID(i)
+		tab := sb.md.Table(tabID)
+		for j := 0; j < tab.StatisticCount(); j++ {
+			stat := tab.Statistic(j)
+			if stat.ColumnCount() < 2 {
+				continue
+			}
+			var cols opt.ColSet
+			for k := 0; k < stat.ColumnCount(); k++ {
+				cols.Add(int(tabID.ColumnID(stat.ColumnOrdinal(k))))
+			}
+			if !cols.SubsetOf(colSet) {
+				continue
+			}
+			// There can be several statistics on the same column set.
+			duplicate := false
+			for _, subset := range subsets {
+				if subset.Equals(cols) {
+					duplicate = true
+					break
+				}
+			}
+			if !duplicate {
+				subsets = append(subsets, cols)
+			}
+		}
+	})
+	sort.SliceStable(subsets, func(i, j int) bool {
+		return subsets[i].Len() > subsets[j].Len()
+	})
+	return subsets
+}
+
 // +-------+
 // | Table |
 // +-------+
@@ -1279,6 +1346,40 @@ func (sb *statisticsBuilder) colStatIndexJoin(
 	return colStat
 }
 
+// +---------------+
+// | Inverted Join |
+// +---------------+
+
+func (sb *statisticsBuilder) buildInvertedJoin(
+	join *InvertedJoinExpr, relProps *props.Relational,
+) {
+	s := &relProps.Stats
+	if zeroCardinality := s.Init(relProps); zeroCardinality {
+		// Short cut if cardinality is 0.
+		return
+	}
+
+	inputStats := &join.Input.Relational().Stats
+	tableStats := sb.makeTableStatistics(join.Table)
+
+	// Estimate the number of candidate rows for each input row as if the lookup
+	// was an equality on the indexed column. Each lookup usually matches a small
+	// fraction of the table, since every path of the input value must match.
+	index := sb.md.Table(join.Table).Index(join.Index)
+	indexCol := join.Table.ColumnID(index.Column(0).Ordinal)
+	colStat := sb.colStatTable(join.Table, util.MakeFastIntSet(int(indexCol)))
+	distinctCount := max(colStat.DistinctCount, 1)
+	s.RowCount = inputStats.RowCount * tableStats.RowCount / distinctCount
+	sb.finalizeFromCardinality(relProps)
+}
+
+func (sb *statisticsBuilder) colStatInvertedJoin(
+	colSet opt.ColSet, join *InvertedJoinExpr,
+) *props.ColumnStatistic {
+	relProps := join.Relational()
+	return sb.colStatLeaf(colSet, &relProps.Stats, &relProps.FuncDeps, relProps.NotNullCols)
+}
+
 // +-------------+
 // | Zigzag Join |
 // +-------------+
@@ -1476,7 +1577,7 @@ func (sb *statisticsBuilder) buildSetNode(setNode RelExpr, relProps *props.Relat
 	// These calculations are an upper bound on the row count. It's likely that
 	// there is some overlap between the two sets, but not full overlap.
 	switch setNode.Op() {
-	case opt.UnionOp, opt.UnionAllOp:
+	case opt.UnionOp, opt.UnionAllOp, opt.LocalityOptimizedSearchOp:
 		s.RowCount = leftStats.RowCount + rightStats.RowCount
 
 	case opt.IntersectOp, opt.IntersectAllOp:
@@ -1536,7 +1637,7 @@ func (sb *statisticsBuilder) colStatSetNodeImpl(
 	// These calculations are an upper bound on the distinct count. It's likely
 	// that there is some overlap between the two sets, but not full overlap.
 	switch setNode.Op() {
-	case opt.UnionOp, opt.UnionAllOp:
+	case opt.UnionOp, opt.UnionAllOp, opt.LocalityOptimizedSearchOp:
 		colStat.DistinctCount = leftColStat.DistinctCount + rightColStat.DistinctCount
 		colStat.NullCount = leftNullCount + rightNullCount
 
@@ -1752,6 +1853,37 @@ func (sb *statisticsBuilder) colStatMax1Row(
 	return colStat
 }
 
+// +---------+
+// | Barrier |
+// +---------+
+
+func (sb *statisticsBuilder) buildBarrier(barrier *BarrierExpr, relProps *props.Relational) {
+	s := &relProps.Stats
+	if zeroCardinality := s.Init(relProps); zeroCardinality {
+		// Short cut if cardinality is 0.
+		return
+	}
+
+	inputStats := &barrier.Input.Relational().Stats
+
+	s.RowCount = inputStats.RowCount
+	sb.finalizeFromCardinality(relProps)
+}
+
+func (sb *statisticsBuilder) colStatBarrier(
+	colSet opt.ColSet, barrier *BarrierExpr,
+) *props.ColumnStatistic {
+	relProps := barrier.Relational()
+	s := &relProps.Stats
+
+	inputColStat := sb.colStatFromChild(colSet, barrier, 0 /* childIdx */)
+	colStat, _ := s.ColStats.Add(colSet)
+	colStat.DistinctCount = inputColStat.DistinctCount
+	colStat.NullCount = inputColStat.NullCount
+	sb.finalizeFromRowCount(colStat, s.RowCount)
+	return colStat
+}
+
 // +------------+
 // | Row Number |
 // +------------+
@@ -2638,28 +2770,103 @@ func (sb *statisticsBuilder) updateDistinctNullCountsFromEquivalency(
 // This selectivity will be used later to update the row count and the
 // distinct count for the unconstrained columns.
 //
-// This algorithm assumes the columns are completely independent.
+// This algorithm assumes the columns are completely independent, unless there
+// are multi-column statistics on some of the constrained columns. In that
+// case, the selectivity of these columns is corrected using the distinct count
+// of the column set; see correlatedSelectivity.
 //
 func (sb *statisticsBuilder) selectivityFromDistinctCounts(
 	cols opt.ColSet, e RelExpr, s *props.Statistics,
 ) (selectivity float64) {
 	selectivity = 1.0
 	for col, ok := cols.Next(0); ok; col, ok = cols.Next(col + 1) {
-		colStat, ok := s.ColStats.Lookup(util.MakeFastIntSet(col))
-		if !ok {
+		newDistinct, oldDistinct := sb.distinctCountsForCol(col, e, s)
+		if oldDistinct != 0 {
+			selectivity *= newDistinct / oldDistinct
+		}
+	}
+
+	if cols.Len() < 2 {
+		return selectivity
+	}
+	var correctedCols opt.ColSet
+	for _, multiCols := range sb.multiColStatSubsets(cols) {
+		if multiCols.Intersects(correctedCols) {
 			continue
 		}
+		correctedCols.UnionWith(multiCols)
+		selectivity = sb.correlatedSelectivity(selectivity, multiCols, e, s)
+	}
+	return selectivity
+}
 
-		inputStat := sb.colStatFromInput(colStat.Cols, e)
-		newDistinct := colStat.DistinctCount
-		oldDistinct := inputStat.DistinctCount
+// distinctCountsForCol returns the distinct count of the given constrained
+// column after the filter is applied and its distinct count in the input. If
+// the filter does not reduce the distinct count, both are the same.
+func (sb *statisticsBuilder) distinctCountsForCol(
+	col int, e RelExpr, s *props.Statistics,
+) (newDistinct, oldDistinct float64) {
+	colStat, ok := s.ColStats.Lookup(util.MakeFastIntSet(col))
+	if !ok {
+		return 1, 1
+	}
+	inputStat := sb.colStatFromInput(colStat.Cols, e)
+	newDistinct = colStat.DistinctCount
+	oldDistinct = inputStat.DistinctCount
+	if newDistinct >= oldDistinct {
+		return oldDistinct, oldDistinct
+	}
+	return newDistinct, oldDistinct
+}
 
-		if oldDistinct != 0 && newDistinct < oldDistinct {
-			selectivity *= newDistinct / oldDistinct
+// correlatedSelectivity corrects the given selectivity, which was calculated
+// assuming that the columns in multiCols are independent, using the distinct
+// count of the column set multiCols in the input. The selectivity of the
+// constrained columns in multiCols is then estimated as:
+//
+//                   ┬-┬ new distinct(i)
+//                   ┴ ┴
+//                  i in
+//               {multiCols}
+//   selectivity =  -------------------
+//                  old distinct(multiCols)
+//
+// For example, for city = 'Paris' AND country = 'France', the old distinct
+// count of (city, country) is about the same as the distinct count of city,
+// so the selectivity is close to that of city = 'Paris' alone rather than the
+// product of the selectivities of both predicates.
+//
+// The result is bounded by the selectivity under the independence assumption
+// and the lowest selectivity of a single column in multiCols. The selectivity
+// is left unchanged unless the filter constrains all the columns in multiCols.
+func (sb *statisticsBuilder) correlatedSelectivity(
+	selectivity float64, multiCols opt.ColSet, e RelExpr, s *props.Statistics,
+) float64 {
+	independentSel := 1.0
+	minColSel := 1.0
+	newDistinct := 1.0
+	for col, ok := multiCols.Next(0); ok; col, ok = multiCols.Next(col + 1) {
+		colNewDistinct, colOldDistinct := sb.distinctCountsForCol(col, e, s)
+		if colOldDistinct == 0 || colNewDistinct == colOldDistinct {
+			// The filter does not constrain this column.
+			return selectivity
 		}
+		colSel := colNewDistinct / colOldDistinct
+		independentSel *= colSel
+		minColSel = min(minColSel, colSel)
+		newDistinct *= colNewDistinct
+	}
+	if independentSel == 0 {
+		return selectivity
 	}
 
-	return selectivity
+	inputStat := sb.colStatFromInput(multiCols, e)
+	if inputStat.DistinctCount == 0 {
+		return selectivity
+	}
+	correlatedSel := min(newDistinct/inputStat.DistinctCount, minColSel)
+	correlatedSel = max(correlatedSel, independentSel)
+	return selectivity / independentSel * correlatedSel
 }
 
 // selectivityFromNullCounts calculates the selectivity of a filter from the number
@@ -2779,10 +2986,62 @@ func (sb *statisticsBuilder) selectivityFromEquivalencies(
 	equivReps opt.ColSet, filterFD *props.FuncDepSet, e RelExpr, s *props.Statistics,
 ) (selectivity float64) {
 	selectivity = 1.0
+	var equivGroups []opt.ColSet
+	var groupSels []float64
+	var allCols opt.ColSet
 	equivReps.ForEach(func(i int) {
 		equivGroup := filterFD.ComputeEquivGroup(opt.ColumnID(i))
-		selectivity *= sb.selectivityFromEquivalency(equivGroup, e, s)
+		groupSel := sb.selectivityFromEquivalency(equivGroup, e, s)
+		selectivity *= groupSel
+		equivGroups = append(equivGroups, equivGroup)
+		groupSels = append(groupSels, groupSel)
+		allCols.UnionWith(equivGroup)
 	})
+	if len(equivGroups) < 2 {
+		return selectivity
+	}
+
+	// If there is a multi-column statistic on columns from several equivalency
+	// groups, the equalities are not independent. For example, joining on
+	// (city, country) is not much more selective than joining on city alone.
+	// The selectivity of these groups is estimated as 1/distinct(multiCols),
+	// bounded by the selectivity under the independence assumption and the
+	// lowest selectivity of a single group.
+	var correctedCols opt.ColSet
+	for _, multiCols := range sb.multiColStatSubsets(allCols) {
+		if multiCols.Intersects(correctedCols) {
+			continue
+		}
+		independentSel := 1.0
+		minGroupSel := 1.0
+		numGroups := 0
+		for i, equivGroup := range equivGroups {
+			n := equivGroup.Intersection(multiCols).Len()
+			if n == 0 {
+				continue
+			}
+			if n > 1 {
+				// Several columns of the statistic are in the same group.
+				numGroups = 0
+				break
+			}
+			numGroups++
+			independentSel *= groupSels[i]
+			minGroupSel = min(minGroupSel, groupSels[i])
+		}
+		if numGroups < 2 {
+			continue
+		}
+		correctedCols.UnionWith(multiCols)
+
+		inputStat := sb.colStatFromInput(multiCols, e)
+		if inputStat.DistinctCount <= 1 || independentSel == 0 {
+			continue
+		}
+		correlatedSel := min(1/inputStat.DistinctCount, minGroupSel)
+		correlatedSel = max(correlatedSel, independentSel)
+		selectivity = selectivity / independentSel * correlatedSel
+	}
 	return selectivity
 }
 
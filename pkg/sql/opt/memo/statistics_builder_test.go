@@ -184,11 +184,14 @@ func TestGetStatsFromConstraint(t *testing.T) {
 		1.0/500,
 	)
 
+	// The multi-column statistic on (a, b, c) shows that the columns are
+	// correlated, so constraining all three columns is estimated using its
+	// distinct count rather than assuming independence.
 	cs123 := constraint.SingleConstraint(&c123)
 	statsFunc(
 		cs123,
-		"[rows=400, distinct(1)=1, null(1)=0, distinct(2)=1, null(2)=0, distinct(3)=5, null(3)=0]",
-		5.0/125000000,
+		"[rows=5050505.05, distinct(1)=1, null(1)=0, distinct(2)=1, null(2)=0, distinct(3)=5, null(3)=0]",
+		5.0/9900,
 	)
 
 	cs123n := constraint.SingleConstraint(&c123n)
@@ -215,8 +218,8 @@ func TestGetStatsFromConstraint(t *testing.T) {
 	cs312 := constraint.SingleConstraint(&c312)
 	statsFunc(
 		cs312,
-		"[rows=2240, distinct(1)=2, null(1)=0, distinct(2)=7, null(2)=0, distinct(3)=2, null(3)=0]",
-		28.0/125000000,
+		"[rows=28282828.3, distinct(1)=2, null(1)=0, distinct(2)=7, null(2)=0, distinct(3)=2, null(3)=0]",
+		28.0/9900,
 	)
 
 	cs312n := constraint.SingleConstraint(&c312n)
@@ -229,15 +232,15 @@ func TestGetStatsFromConstraint(t *testing.T) {
 	cs := cs3.Intersect(&evalCtx, cs123)
 	statsFunc(
 		cs,
-		"[rows=80, distinct(1)=1, null(1)=0, distinct(2)=1, null(2)=0, distinct(3)=1, null(3)=0]",
-		1.0/125000000,
+		"[rows=1010101.01, distinct(1)=1, null(1)=0, distinct(2)=1, null(2)=0, distinct(3)=1, null(3)=0]",
+		1.0/9900,
 	)
 
 	cs = cs32.Intersect(&evalCtx, cs123)
 	statsFunc(
 		cs,
-		"[rows=80, distinct(1)=1, null(1)=0, distinct(2)=1, null(2)=0, distinct(3)=1, null(3)=0]",
-		1.0/125000000,
+		"[rows=1010101.01, distinct(1)=1, null(1)=0, distinct(2)=1, null(2)=0, distinct(3)=1, null(3)=0]",
+		1.0/9900,
 	)
 
 	cs45 := constraint.SingleSpanConstraint(&keyCtx45, &sp45)
@@ -23,6 +23,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util"
 )
 
 // SchemaID uniquely identifies the usage of a schema within the scope of a
@@ -108,6 +109,24 @@ type mdDep struct {
 
 	// privileges is the union of all required privileges.
 	privileges privilegeBitmap
+
+	// columns maps each privilege which is held on some columns of a table
+	// rather than on the table itself to the ordinals of the columns which
+	// require it. It is nil if there are no such privileges.
+	columns map[privilege.Kind]util.FastIntSet
+
+	// rowLevelSecurity stores the row-level security policies of a table which
+	// were applied to the query. It is nil if the policies were not looked up.
+	// The struct is immutable, so it can be shared between copies.
+	rowLevelSecurity *rowLevelSecurityDep
+}
+
+// rowLevelSecurityDep records whether the row-level security policies of a
+// table were enforced, and the ordinals of the policies which applied to the
+// user. Unlike the table itself, these depend on the user running the query.
+type rowLevelSecurityDep struct {
+	enforced bool
+	policies util.FastIntSet
 }
 
 func (d *mdDep) dsName() *cat.DataSourceName {
@@ -161,6 +180,16 @@ func (md *Metadata) CopyFrom(from *Metadata) {
 
 	md.sequences = append(md.sequences, from.sequences...)
 	md.deps = append(md.deps, from.deps...)
+
+	// Copy the column dependencies, so that they can be extended independently.
+	for i := range md.deps {
+		if cols := md.deps[i].columns; cols != nil {
+			md.deps[i].columns = make(map[privilege.Kind]util.FastIntSet, len(cols))
+			for priv, ords := range cols {
+				md.deps[i].columns[priv] = ords.Copy()
+			}
+		}
+	}
 }
 
 // AddDataSourceDependency tracks one of the catalog data sources on which the
@@ -185,6 +214,36 @@ func (md *Metadata) AddDataSourceDependency(
 	})
 }
 
+// AddColumnDependency tracks the columns of a table on which the query needs
+// the given privilege, in the case where the privilege is not held on the table
+// itself but on some of its columns. The table must have been added as a
+// dependency with AddDataSourceDependency first. CheckDependencies uses the
+// columns to recheck the privilege if it is still not held on the table.
+func (md *Metadata) AddColumnDependency(tab cat.Table, priv privilege.Kind, ords util.FastIntSet) {
+	for i := range md.deps {
+		if md.deps[i].object == tab {
+			if md.deps[i].columns == nil {
+				md.deps[i].columns = make(map[privilege.Kind]util.FastIntSet)
+			}
+			md.deps[i].columns[priv] = md.deps[i].columns[priv].Union(ords)
+		}
+	}
+}
+
+// AddRowLevelSecurityDependency tracks the row-level security policies of a
+// table which were applied to the query. The table must have been added as a
+// dependency with AddDataSourceDependency first. CheckDependencies looks up the
+// policies again, since they might not apply in the same way to another user.
+func (md *Metadata) AddRowLevelSecurityDependency(
+	tab cat.Table, enforced bool, policies util.FastIntSet,
+) {
+	for i := range md.deps {
+		if md.deps[i].object == tab {
+			md.deps[i].rowLevelSecurity = &rowLevelSecurityDep{enforced: enforced, policies: policies}
+		}
+	}
+}
+
 // AddSchemaDependency tracks one of the catalog schemas on which the query depends,
 // as well as the privilege required to access that schema. If the Memo using
 // this metadata is cached, then a call to CheckDependencies can detect if
@@ -255,13 +314,38 @@ func (md *Metadata) CheckDependencies(
 			priv := privilege.Kind(bits.TrailingZeros32(uint32(privs)))
 			if priv != 0 {
 				if err := catalog.CheckPrivilege(ctx, toCheck, priv); err != nil {
-					return false, err
+					// The privilege may be held on the columns used by the query instead.
+					tab, isTable := toCheck.(cat.Table)
+					if !isTable || !privilege.ColumnPrivileges.Contains(priv) {
+						return false, err
+					}
+					ords, ok := md.deps[i].columns[priv]
+					if !ok {
+						// The metadata was built for a user holding the privilege on the
+						// table, so the columns used by the query were not recorded. It
+						// needs to be built again to check them.
+						return false, nil
+					}
+					if err := catalog.CheckColumnPrivileges(ctx, tab, priv, ords); err != nil {
+						return false, err
+					}
 				}
 			}
 
 			// Set the just-handled privilege bit to zero and look for next.
 			privs &= ^(1 << priv)
 		}
+
+		// Ensure that the same row-level security policies apply.
+		if rls := md.deps[i].rowLevelSecurity; rls != nil {
+			enforced, policies, err := catalog.RowLevelSecurityPolicies(ctx, toCheck.(cat.Table))
+			if err != nil {
+				return false, err
+			}
+			if enforced != rls.enforced || !policies.Equals(rls.policies) {
+				return false, nil
+			}
+		}
 	}
 	return true, nil
 }
@@ -16,6 +16,7 @@ import (
 	"context"
 
 	"github.com/cockroachdb/cockroach/pkg/sql/delegate"
+	"github.com/cockroachdb/cockroach/pkg/sql/opt"
 	"github.com/cockroachdb/cockroach/pkg/sql/opt/cat"
 	"github.com/cockroachdb/cockroach/pkg/sql/opt/norm"
 	"github.com/cockroachdb/cockroach/pkg/sql/opt/optgen/exprgen"
@@ -23,6 +24,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/transform"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util"
 )
 
 // Builder holds the context needed for building a memo structure from a SQL
@@ -75,6 +77,11 @@ type Builder struct {
 	// statements.
 	DisableMemoReuse bool
 
+	// StmtHints, if set, are the hints stored for the fingerprint of the
+	// statement (see opt.StmtHints). They are applied to the data sources and
+	// joins which don't have inline hints.
+	StmtHints *opt.StmtHints
+
 	factory *norm.Factory
 	stmt    tree.Statement
 
@@ -91,6 +98,22 @@ type Builder struct {
 	// be used with care.
 	skipSelectPrivilegeChecks bool
 
+	// If set, the planner will skip checking column privileges for the columns
+	// referenced by scalar expressions. This is used when building expressions
+	// which come from the schema rather than from the query, like check
+	// constraints and computed columns.
+	skipColumnPrivilegeChecks bool
+
+	// columnPrivileges maps the tables on which the current user only has some
+	// privileges on specific columns to the bitmask of those privileges. Each
+	// column of such tables referenced by the query is checked for them.
+	columnPrivileges map[cat.StableID]uint32
+
+	// viewTables contains the TableIDs of the tables scanned by the views in
+	// the query. Their columns are not subject to column privilege checks, as
+	// the SELECT privilege on the view has already been checked.
+	viewTables util.FastIntSet
+
 	// views contains a cache of views that have already been parsed, in case they
 	// are referenced multiple times in the same query.
 	views map[cat.View]*tree.Select
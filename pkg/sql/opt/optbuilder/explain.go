@@ -55,6 +55,21 @@ func (b *Builder) buildExplain(explain *tree.Explain, inScope *scope) (outScope
 		}
 		cols = sqlbase.ExplainDistSQLColumns
 
+	case tree.ExplainDebug:
+		if !opts.Flags.Contains(tree.ExplainFlagAnalyze) {
+			panic(pgerror.Newf(pgerror.CodeFeatureNotSupportedError,
+				"EXPLAIN (DEBUG) only supported with the ANALYZE option"))
+		}
+		telemetry.Inc(sqltelemetry.ExplainAnalyzeDebugUseCounter)
+		if tree.IsStmtParallelized(explain.Statement) {
+			panic(pgerror.Newf(pgerror.CodeFeatureNotSupportedError,
+				"EXPLAIN ANALYZE does not support RETURNING NOTHING statements"))
+		}
+		// The statement diagnostics bundle includes the memo, which is formatted
+		// from the optimizer state of this planning; don't let it be cached.
+		b.DisableMemoReuse = true
+		cols = sqlbase.ExplainAnalyzeDebugColumns
+
 	case tree.ExplainOpt:
 		if opts.Flags.Contains(tree.ExplainFlagVerbose) {
 			telemetry.Inc(sqltelemetry.ExplainOptVerboseUseCounter)
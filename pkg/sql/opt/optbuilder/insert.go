@@ -22,6 +22,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util"
 )
@@ -179,6 +180,11 @@ func (b *Builder) buildInsert(ins *tree.Insert, inScope *scope) (outScope *scope
 			// UPSERT and INDEX ON CONFLICT DO UPDATE may modify rows if the
 			// DO NOTHING clause is not present.
 			b.checkPrivilege(tn, tab, privilege.UPDATE)
+
+			// Row-level security policies are not supported for upserts.
+			if _, enforced := b.rowLevelSecurityPolicies(tab, privilege.UPDATE); enforced {
+				panic(builderError{sqlbase.NewUnsupportedRowLevelSecurityError(string(tab.Name().TableName))})
+			}
 		}
 	}
 
@@ -372,6 +378,7 @@ func (mb *mutationBuilder) addTargetNamedColsForInsert(names tree.NameList) {
 
 	// Add target table columns by the names specified in the Insert statement.
 	mb.addTargetColsByName(names)
+	mb.checkTargetColPrivileges(privilege.INSERT)
 
 	// Ensure that primary key columns are in the target column list, or that
 	// they have default values.
@@ -504,6 +511,8 @@ func (mb *mutationBuilder) addTargetTableColsForInsert(maxCols int) {
 	// Ensure that the number of input columns does not exceed the number of
 	// target columns.
 	mb.checkNumCols(len(mb.targetColList), maxCols)
+
+	mb.checkTargetColPrivileges(privilege.INSERT)
 }
 
 // buildInputForInsert constructs the memo group for the input expression and
@@ -857,6 +866,12 @@ func (mb *mutationBuilder) setUpsertCols(insertCols tree.NameList) {
 	for i, n := 0, conflictIndex.KeyColumnCount(); i < n; i++ {
 		mb.updateOrds[conflictIndex.Column(i).Ordinal] = -1
 	}
+
+	for ord := range mb.updateOrds {
+		if mb.updateOrds[ord] != -1 {
+			mb.b.checkColumnPrivilege(mb.tabID, ord, privilege.UPDATE)
+		}
+	}
 }
 
 // buildUpsert constructs an Upsert operator, possibly wrapped by a Project
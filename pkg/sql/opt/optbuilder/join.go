@@ -40,6 +40,8 @@ func (b *Builder) buildJoin(join *tree.JoinTableExpr, inScope *scope) (outScope
 	var flags memo.JoinFlags
 	switch join.Hint {
 	case "":
+		flags = b.stmtHintsJoinFlags(joinType)
+
 	case tree.AstHash:
 		telemetry.Inc(sqltelemetry.HashJoinHintUseCounter)
 		flags.DisallowMergeJoin = true
@@ -112,6 +114,32 @@ func (b *Builder) buildJoin(join *tree.JoinTableExpr, inScope *scope) (outScope
 	}
 }
 
+// stmtHintsJoinFlags returns the join flags corresponding to the join hint of
+// the statement hints, if any. Unlike inline join hints, a hinted algorithm
+// which can't be used for the join type is ignored.
+func (b *Builder) stmtHintsJoinFlags(joinType sqlbase.JoinType) memo.JoinFlags {
+	var flags memo.JoinFlags
+	if b.StmtHints == nil {
+		return flags
+	}
+	switch b.StmtHints.Join {
+	case tree.AstHash:
+		flags.DisallowMergeJoin = true
+		flags.DisallowLookupJoin = true
+
+	case tree.AstLookup:
+		if joinType == sqlbase.InnerJoin || joinType == sqlbase.LeftOuterJoin {
+			flags.DisallowHashJoin = true
+			flags.DisallowMergeJoin = true
+		}
+
+	case tree.AstMerge:
+		flags.DisallowLookupJoin = true
+		flags.DisallowHashJoin = true
+	}
+	return flags
+}
+
 // validateJoinTableNames checks that table names are not repeated between the
 // left and right sides of a join. leftTables contains a pre-built map of the
 // tables from the left side of the join, and rightScope contains the
@@ -423,6 +451,8 @@ func (jb *usingJoinBuilder) addEqualityCondition(leftCol, rightCol *scopeColumn)
 	}
 
 	// Construct the predicate.
+	jb.b.checkColumnRefPrivilege(leftCol.id)
+	jb.b.checkColumnRefPrivilege(rightCol.id)
 	leftVar := jb.b.factory.ConstructVariable(leftCol.id)
 	rightVar := jb.b.factory.ConstructVariable(rightCol.id)
 	eq := jb.b.factory.ConstructEq(leftVar, rightVar)
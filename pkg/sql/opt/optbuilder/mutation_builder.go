@@ -20,6 +20,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/opt/memo"
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/builtins"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
@@ -104,7 +105,8 @@ type mutationBuilder struct {
 	// checkOrds lists the outScope columns storing the boolean results of
 	// evaluating check constraint expressions defined on the target table. Its
 	// length is always equal to the number of check constraints on the table
-	// (see opt.Table.CheckCount).
+	// (see opt.Table.CheckCount), plus one if the row-level security policies
+	// of the table are checked (see addCheckConstraintCols).
 	checkOrds []scopeOrdinal
 
 	// canaryColID is the ID of the column that is used to decide whether to
@@ -192,6 +194,14 @@ func (mb *mutationBuilder) buildInputForUpdateOrDelete(
 		inScope,
 	)
 
+	// Only the rows satisfying the row-level security policies of the table can
+	// be updated or deleted.
+	if mb.op == opt.UpdateOp {
+		mb.b.addPolicyFilter(mb.tab, privilege.UPDATE, mb.outScope)
+	} else {
+		mb.b.addPolicyFilter(mb.tab, privilege.DELETE, mb.outScope)
+	}
+
 	// WHERE
 	mb.b.buildWhere(where, mb.outScope)
 
@@ -256,6 +266,15 @@ func (mb *mutationBuilder) addTargetCol(ord int) {
 	mb.targetColList = append(mb.targetColList, colID)
 }
 
+// checkTargetColPrivileges ensures that the current user has the given
+// privilege on each of the target columns, in the case where it is only held
+// on some of the columns of the target table.
+func (mb *mutationBuilder) checkTargetColPrivileges(priv privilege.Kind) {
+	for _, colID := range mb.targetColList {
+		mb.b.checkColumnPrivilege(mb.tabID, mb.tabID.ColumnOrdinal(colID), priv)
+	}
+}
+
 // extractValuesInput tests whether the given input is a VALUES clause with no
 // WITH, ORDER BY, or LIMIT modifier. If so, it's returned, otherwise nil is
 // returned.
@@ -355,6 +374,13 @@ func (mb *mutationBuilder) replaceDefaultExprs(inRows *tree.Select) (outRows *tr
 func (mb *mutationBuilder) addSynthesizedCols(
 	scopeOrds []scopeOrdinal, addCol func(tabCol cat.Column) bool,
 ) {
+	// Default and computed expressions are part of the schema, so the columns
+	// they reference are not subject to column privileges.
+	if !mb.b.skipColumnPrivilegeChecks {
+		mb.b.skipColumnPrivilegeChecks = true
+		defer func() { mb.b.skipColumnPrivilegeChecks = false }()
+	}
+
 	var projectionsScope *scope
 
 	// Skip delete-only mutation columns, since they are ignored by all mutation
@@ -506,8 +532,20 @@ func findRoundingFunction(typ *types.T, precision int) (*tree.FunctionProperties
 // addCheckConstraintCols synthesizes a boolean output column for each check
 // constraint defined on the target table. The mutation operator will report
 // a constraint violation error if the value of the column is false.
+//
+// If row-level security policies are enforced on the target table, an extra
+// column is synthesized after the check constraint columns, which holds the
+// result of evaluating the policies for the row.
 func (mb *mutationBuilder) addCheckConstraintCols() {
-	if mb.tab.CheckCount() > 0 {
+	policyCheck := mb.policyCheckExpr()
+	if mb.tab.CheckCount() > 0 || policyCheck != nil {
+		// Check constraints are part of the schema, so the columns they reference
+		// are not subject to column privileges.
+		if !mb.b.skipColumnPrivilegeChecks {
+			mb.b.skipColumnPrivilegeChecks = true
+			defer func() { mb.b.skipColumnPrivilegeChecks = false }()
+		}
+
 		// Disambiguate names so that references in the constraint expression refer
 		// to the correct columns.
 		mb.disambiguateColumns()
@@ -531,11 +569,49 @@ func (mb *mutationBuilder) addCheckConstraintCols() {
 			mb.checkOrds[i] = scopeOrdinal(len(projectionsScope.cols) - 1)
 		}
 
+		if policyCheck != nil {
+			texpr := mb.outScope.resolveAndRequireType(policyCheck, types.Bool)
+			scopeCol := mb.b.addColumn(projectionsScope, "policy_check", texpr)
+			mb.b.buildScalar(texpr, mb.outScope, projectionsScope, scopeCol, nil)
+			mb.checkOrds = append(mb.checkOrds, scopeOrdinal(len(projectionsScope.cols)-1))
+		}
+
 		mb.b.constructProjectForScope(mb.outScope, projectionsScope)
 		mb.outScope = projectionsScope
 	}
 }
 
+// policyCheckExpr returns the expression which rows inserted or updated by the
+// mutation must satisfy, or nil if the row-level security policies of the
+// target table are not enforced for the current user. A row satisfies the
+// policies if it satisfies the WITH CHECK expression, or lacking that the
+// USING expression, of any policy applying to the mutation.
+func (mb *mutationBuilder) policyCheckExpr() tree.Expr {
+	var priv privilege.Kind
+	switch mb.op {
+	case opt.InsertOp:
+		priv = privilege.INSERT
+	case opt.UpdateOp:
+		priv = privilege.UPDATE
+	default:
+		// Upserts are rejected if policies are enforced, see buildInsert.
+		return nil
+	}
+	policies, enforced := mb.b.rowLevelSecurityPolicies(mb.tab, priv)
+	if !enforced {
+		return nil
+	}
+	exprs := make([]string, 0, len(policies))
+	for i := range policies {
+		if policies[i].WithCheckExpr != "" {
+			exprs = append(exprs, policies[i].WithCheckExpr)
+		} else if policies[i].UsingExpr != "" {
+			exprs = append(exprs, policies[i].UsingExpr)
+		}
+	}
+	return combinePolicyExprs(exprs)
+}
+
 // disambiguateColumns ranges over the scope and ensures that at most one column
 // has each table column name, and that name refers to the column with the final
 // value that the mutation applies.
@@ -247,6 +247,9 @@ func (b *Builder) finishBuildScalar(
 func (b *Builder) finishBuildScalarRef(
 	col *scopeColumn, inScope, outScope *scope, outCol *scopeColumn, colRefs *opt.ColSet,
 ) (out opt.ScalarExpr) {
+	// Check the column privileges of referenced table columns.
+	b.checkColumnRefPrivilege(col.id)
+
 	// Update the sets of column references and outer columns if needed.
 	if colRefs != nil {
 		colRefs.Add(int(col.id))
@@ -0,0 +1,102 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package optbuilder
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/sql/opt/cat"
+	"github.com/cockroachdb/cockroach/pkg/sql/opt/memo"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+)
+
+// rowLevelSecurityPolicies returns whether the row-level security policies of
+// the given table are enforced for the current user and, if so, the policies
+// which apply to statements requiring the given privilege. The policies are
+// added as a dependency to the metadata, since they depend on the user.
+func (b *Builder) rowLevelSecurityPolicies(
+	tab cat.Table, priv privilege.Kind,
+) (policies []cat.Policy, enforced bool) {
+	enforced, ords, err := b.catalog.RowLevelSecurityPolicies(b.ctx, tab)
+	if err != nil {
+		panic(builderError{err})
+	}
+	b.factory.Metadata().AddRowLevelSecurityDependency(tab, enforced, ords)
+	if !enforced {
+		return nil, false
+	}
+	for i, ok := ords.Next(0); ok; i, ok = ords.Next(i + 1) {
+		policy := tab.Policy(i)
+		if policy.Command == privilege.ALL || policy.Command == priv {
+			policies = append(policies, policy)
+		}
+	}
+	return policies, true
+}
+
+// addPolicyFilter filters the rows of the given table scanned by the given
+// scope, so that only the rows which satisfy the USING expression of some
+// policy applying to statements requiring the given privilege remain. It is a
+// no-op if the row-level security policies of the table are not enforced for
+// the current user.
+func (b *Builder) addPolicyFilter(tab cat.Table, priv privilege.Kind, inScope *scope) {
+	policies, enforced := b.rowLevelSecurityPolicies(tab, priv)
+	if !enforced {
+		return
+	}
+	exprs := make([]string, 0, len(policies))
+	for i := range policies {
+		if policies[i].UsingExpr != "" {
+			exprs = append(exprs, policies[i].UsingExpr)
+		}
+	}
+
+	// Policies are part of the schema, so the columns they reference are not
+	// subject to column privileges.
+	if !b.skipColumnPrivilegeChecks {
+		b.skipColumnPrivilegeChecks = true
+		defer func() { b.skipColumnPrivilegeChecks = false }()
+	}
+
+	defer b.semaCtx.Properties.Restore(b.semaCtx.Properties)
+	b.semaCtx.Properties.Require("POLICY", tree.RejectSpecial)
+
+	texpr := inScope.resolveAndRequireType(combinePolicyExprs(exprs), types.Bool)
+	filter := b.buildScalar(texpr, inScope, nil, nil, nil)
+	// The barrier keeps the predicates of the query from being evaluated on the
+	// rows which don't satisfy the policies.
+	inScope.expr = b.factory.ConstructBarrier(b.factory.ConstructSelect(
+		inScope.expr.(memo.RelExpr),
+		memo.FiltersExpr{{Condition: filter}},
+	))
+}
+
+// combinePolicyExprs parses the given policy expressions and combines them
+// with OR, since a row satisfies the policies if it satisfies any of them. If
+// there are no expressions, no row satisfies the policies.
+func combinePolicyExprs(exprs []string) tree.Expr {
+	var res tree.Expr = tree.DBoolFalse
+	for i, s := range exprs {
+		expr, err := parser.ParseExpr(s)
+		if err != nil {
+			panic(builderError{err})
+		}
+		if i == 0 {
+			res = expr
+		} else {
+			res = &tree.OrExpr{Left: res, Right: expr}
+		}
+	}
+	return res
+}
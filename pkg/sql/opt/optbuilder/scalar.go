@@ -274,6 +274,7 @@ func (b *Builder) buildScalar(
 			panic(pgerror.Newf(pgerror.CodeUndefinedColumnError,
 				"invalid column ordinal: @%d", t.Idx+1))
 		}
+		b.checkColumnRefPrivilege(inScope.cols[t.Idx].id)
 		out = b.factory.ConstructVariable(inScope.cols[t.Idx].id)
 
 	case *tree.NotExpr:
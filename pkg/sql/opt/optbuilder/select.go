@@ -23,6 +23,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqltelemetry"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/pkg/errors"
@@ -89,7 +90,12 @@ func (b *Builder) buildDataSource(
 		ds, resName := b.resolveDataSource(tn, privilege.SELECT)
 		switch t := ds.(type) {
 		case cat.Table:
-			return b.buildScan(t, &resName, nil /* ordinals */, indexFlags, excludeMutations, inScope)
+			if indexFlags == nil {
+				indexFlags = b.stmtHintsIndexFlags(t, &resName)
+			}
+			outScope = b.buildScan(t, &resName, nil /* ordinals */, indexFlags, excludeMutations, inScope)
+			b.addPolicyFilter(t, privilege.SELECT, outScope)
+			return outScope
 		case cat.View:
 			return b.buildView(t, inScope)
 		case cat.Sequence:
@@ -127,7 +133,14 @@ func (b *Builder) buildDataSource(
 		ds := b.resolveDataSourceRef(source, privilege.SELECT)
 		switch t := ds.(type) {
 		case cat.Table:
+			if source.Columns != nil {
+				// The policies might reference columns which are not scanned.
+				if _, enforced := b.rowLevelSecurityPolicies(t, privilege.SELECT); enforced {
+					panic(builderError{sqlbase.NewUnsupportedRowLevelSecurityError(string(t.Name().TableName))})
+				}
+			}
 			outScope = b.buildScanFromTableRef(t, source, indexFlags, inScope)
+			b.addPolicyFilter(t, privilege.SELECT, outScope)
 		default:
 			panic(unimplementedWithIssueDetailf(35708, fmt.Sprintf("%T", t), "view and sequence numeric refs are not supported"))
 		}
@@ -289,6 +302,26 @@ func (b *Builder) buildScanFromTableRef(
 	return b.buildScan(tab, tab.Name(), ordinals, indexFlags, excludeMutations, inScope)
 }
 
+// stmtHintsIndexFlags returns the index flags corresponding to the index hint
+// of the statement hints for the given table, if any. Unlike inline index
+// hints, a hinted index which doesn't exist is ignored so that a stale hint
+// doesn't cause the statement to fail.
+func (b *Builder) stmtHintsIndexFlags(tab cat.Table, tn *tree.TableName) *tree.IndexFlags {
+	if b.StmtHints == nil {
+		return nil
+	}
+	index := b.StmtHints.IndexHint(tn.Table())
+	if index == "" {
+		return nil
+	}
+	for i, n := 0, tab.IndexCount(); i < n; i++ {
+		if string(tab.Index(i).Name()) == index {
+			return &tree.IndexFlags{Index: tree.UnrestrictedName(index)}
+		}
+	}
+	return nil
+}
+
 // buildScan builds a memo group for a ScanOp or VirtualScanOp expression on the
 // given table with the given table name. Note that the table name is passed
 // separately in order to preserve knowledge of whether the catalog and schema
@@ -311,6 +344,10 @@ func (b *Builder) buildScan(
 	md := b.factory.Metadata()
 	tabID := md.AddTableWithAlias(tab, alias)
 	tabMeta := md.TableMeta(tabID)
+	if b.skipSelectPrivilegeChecks {
+		// The table is scanned by a view.
+		b.viewTables.Add(int(tabID))
+	}
 	if indexFlags != nil && indexFlags.IgnoreForeignKeys {
 		tabMeta.IgnoreForeignKeys = true
 	}
@@ -395,6 +432,13 @@ func (b *Builder) buildScan(
 // table and adds them to the table metadata. To do this, the scalar expression
 // of the check constraints are built here.
 func (b *Builder) addCheckConstraintsToScan(scope *scope, tabID opt.TableID) {
+	// Check constraints are part of the schema, so the columns they reference
+	// are not subject to column privileges.
+	if !b.skipColumnPrivilegeChecks {
+		b.skipColumnPrivilegeChecks = true
+		defer func() { b.skipColumnPrivilegeChecks = false }()
+	}
+
 	md := b.factory.Metadata()
 	tabMeta := md.TableMeta(tabID)
 	tab := tabMeta.Table
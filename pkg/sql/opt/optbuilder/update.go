@@ -169,6 +169,8 @@ func (mb *mutationBuilder) addTargetColsForUpdate(exprs tree.UpdateExprs) {
 			}
 		}
 	}
+
+	mb.checkTargetColPrivileges(privilege.UPDATE)
 }
 
 // addUpdateCols builds nested Project and LeftOuterJoin expressions that
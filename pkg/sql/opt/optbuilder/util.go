@@ -20,6 +20,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util"
 )
 
 func checkFrom(expr tree.Expr, inScope *scope) {
@@ -468,13 +469,23 @@ func (b *Builder) resolveDataSourceRef(ref *tree.TableRef, priv privilege.Kind)
 // error. It also adds the object and it's original unresolved name as a
 // dependency to the metadata, so that the privileges can be re-checked on reuse
 // of the memo.
+//
+// If the object is a table and the privilege is only held on some of its
+// columns, then the error is deferred: each column of the table referenced by
+// the query is checked with checkColumnPrivilege instead.
 func (b *Builder) checkPrivilege(
 	origName *cat.DataSourceName, ds cat.DataSource, priv privilege.Kind,
 ) {
+	var colTab cat.Table
 	if !(priv == privilege.SELECT && b.skipSelectPrivilegeChecks) {
 		err := b.catalog.CheckPrivilege(b.ctx, ds, priv)
 		if err != nil {
-			panic(builderError{err})
+			tab, ok := ds.(cat.Table)
+			if !ok || !privilege.ColumnPrivileges.Contains(priv) ||
+				b.catalog.CheckColumnPrivileges(b.ctx, tab, priv, util.FastIntSet{}) != nil {
+				panic(builderError{err})
+			}
+			colTab = tab
 		}
 	} else {
 		// The check is skipped, so don't recheck when dependencies are checked.
@@ -484,4 +495,45 @@ func (b *Builder) checkPrivilege(
 	// Add dependency on this object to the metadata, so that the metadata can be
 	// cached and later checked for freshness.
 	b.factory.Metadata().AddDataSourceDependency(origName, ds, priv)
+
+	if colTab != nil {
+		if b.columnPrivileges == nil {
+			b.columnPrivileges = make(map[cat.StableID]uint32)
+		}
+		b.columnPrivileges[colTab.ID()] |= priv.Mask()
+		b.factory.Metadata().AddColumnDependency(colTab, priv, util.FastIntSet{})
+	}
+}
+
+// checkColumnPrivilege ensures that the current user has the given privilege
+// on the column of the given table with the given ordinal, in the case where
+// checkPrivilege found that the privilege is only held on some of the columns
+// of the table. If not, then checkColumnPrivilege raises an error.
+func (b *Builder) checkColumnPrivilege(tabID opt.TableID, ord int, priv privilege.Kind) {
+	if len(b.columnPrivileges) == 0 || b.skipColumnPrivilegeChecks ||
+		(priv == privilege.SELECT && b.skipSelectPrivilegeChecks) ||
+		b.viewTables.Contains(int(tabID)) {
+		return
+	}
+	tab := b.factory.Metadata().Table(tabID)
+	if b.columnPrivileges[tab.ID()]&priv.Mask() == 0 {
+		return
+	}
+	ords := util.MakeFastIntSet(ord)
+	if err := b.catalog.CheckColumnPrivileges(b.ctx, tab, priv, ords); err != nil {
+		panic(builderError{err})
+	}
+	b.factory.Metadata().AddColumnDependency(tab, priv, ords)
+}
+
+// checkColumnRefPrivilege ensures that the current user has the SELECT
+// privilege on the given column if it is a table column referenced by the
+// query. See checkColumnPrivilege.
+func (b *Builder) checkColumnRefPrivilege(colID opt.ColumnID) {
+	if len(b.columnPrivileges) == 0 {
+		return
+	}
+	if tabID := b.factory.Metadata().ColumnMeta(colID).Table; tabID != 0 {
+		b.checkColumnPrivilege(tabID, tabID.ColumnOrdinal(colID), privilege.SELECT)
+	}
 }
@@ -0,0 +1,149 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package opt
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util"
+)
+
+// StmtHints is a set of hints applied when planning the statements with a
+// given fingerprint (see system.statement_hints). The hints have the same
+// effect as the corresponding inline hints, without requiring changes to the
+// text of the statements. Inline hints take precedence over them.
+//
+// StmtHints are stored as JSON, for example:
+//
+//   {"index": {"t": "t_idx"}, "join": "merge", "fixed_join_order": true}
+//   {"reorder_joins_limit": 12}
+//
+type StmtHints struct {
+	// Indexes maps table names to the name of the index used to scan them, as
+	// with the table@index syntax. Tables are matched by their unqualified
+	// name; hinted indexes which don't exist are ignored.
+	Indexes map[string]string `json:"index,omitempty"`
+
+	// Join is the algorithm used for the joins of the statement: HASH, MERGE or
+	// LOOKUP, as with the INNER HASH JOIN syntax. The hint is ignored for joins
+	// which can't use the algorithm.
+	Join string `json:"join,omitempty"`
+
+	// FixedJoinOrder prevents the optimizer from reordering the joins of the
+	// statement: they are executed in the order in which they are written.
+	FixedJoinOrder bool `json:"fixed_join_order,omitempty"`
+
+	// ReorderJoinsLimit overrides the reorder_joins_limit session setting: the
+	// maximum number of relations in a join tree for which the optimizer
+	// enumerates all the join orders. Larger join trees are reordered greedily.
+	ReorderJoinsLimit *int `json:"reorder_joins_limit,omitempty"`
+
+	// DisabledRules are the names of optimizer rules which are not applied
+	// when planning the statement.
+	DisabledRules []string `json:"disabled_rules,omitempty"`
+
+	// disabledRules is the set of rules which are not applied, including the
+	// rules disabled by FixedJoinOrder.
+	disabledRules util.FastIntSet
+}
+
+// joinOrderRules are the rules which change the order of joins; they are
+// disabled by the FixedJoinOrder hint.
+var joinOrderRules = []RuleName{
+	CommuteJoin, CommuteLeftJoin, CommuteRightJoin, ReorderJoins,
+}
+
+// ParseStmtHints parses and validates the JSON representation of a set of
+// statement hints.
+func ParseStmtHints(s string) (*StmtHints, error) {
+	var h StmtHints
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&h); err != nil {
+		return nil, pgerror.Wrap(err, pgerror.CodeInvalidParameterValueError, "invalid statement hints")
+	}
+
+	for table, index := range h.Indexes {
+		if table == "" || index == "" {
+			return nil, pgerror.Newf(pgerror.CodeInvalidParameterValueError,
+				"invalid index hint %q: %q", table, index)
+		}
+	}
+
+	if h.Join != "" {
+		h.Join = strings.ToUpper(h.Join)
+		switch h.Join {
+		case tree.AstHash, tree.AstLookup, tree.AstMerge:
+		default:
+			return nil, pgerror.Newf(pgerror.CodeInvalidParameterValueError,
+				"invalid join hint %q, expected one of %s, %s or %s",
+				h.Join, tree.AstHash, tree.AstLookup, tree.AstMerge)
+		}
+	}
+
+	if h.ReorderJoinsLimit != nil && *h.ReorderJoinsLimit < 0 {
+		return nil, pgerror.Newf(pgerror.CodeInvalidParameterValueError,
+			"invalid reorder_joins_limit hint: %d", *h.ReorderJoinsLimit)
+	}
+
+	if h.FixedJoinOrder {
+		for _, r := range joinOrderRules {
+			h.disabledRules.Add(int(r))
+		}
+	}
+	for _, name := range h.DisabledRules {
+		r, ok := ruleNameFromString(name)
+		if !ok {
+			return nil, pgerror.Newf(pgerror.CodeInvalidParameterValueError,
+				"unknown optimizer rule %q", name)
+		}
+		h.disabledRules.Add(int(r))
+	}
+	return &h, nil
+}
+
+// IndexHint returns the name of the index which must be used to scan the
+// table with the given name, or the empty string if there is no such hint.
+func (h *StmtHints) IndexHint(table string) string {
+	return h.Indexes[table]
+}
+
+// DisabledRuleSet returns the set of rules which are not applied when planning
+// the statement.
+func (h *StmtHints) DisabledRuleSet() util.FastIntSet {
+	return h.disabledRules
+}
+
+// String returns the JSON representation of the hints.
+func (h *StmtHints) String() string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	if err := enc.Encode(h); err != nil {
+		return err.Error()
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// ruleNameFromString returns the rule with the given name.
+func ruleNameFromString(name string) (RuleName, bool) {
+	for r := RuleName(1); r < NumRuleNames; r++ {
+		if r.String() == name {
+			return r, true
+		}
+	}
+	return 0, false
+}
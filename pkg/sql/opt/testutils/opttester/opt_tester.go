@@ -139,8 +139,9 @@ type Flags struct {
 	// the coster will be in the range [c - 0.5 * c, c + 0.5 * c).
 	PerturbCost float64
 
-	// ReorderJoinsLimit is the maximum number of joins in a query which the optimizer
-	// should attempt to reorder.
+	// JoinLimit is the maximum number of relations in a join tree for which the
+	// optimizer enumerates all the join orders; larger join trees are reordered
+	// greedily. Joins are not reordered if it is zero.
 	JoinLimit int
 
 	// Locality specifies the location of the planning node as a set of user-
@@ -181,7 +182,7 @@ func New(catalog cat.Catalog, sql string) *OptTester {
 	// Enable zigzag joins for all opt tests. Execbuilder tests exercise
 	// cases where this flag is false.
 	ot.evalCtx.SessionData.ZigzagJoinEnabled = true
-	ot.evalCtx.SessionData.ReorderJoinsLimit = opt.DefaultJoinOrderLimit
+	ot.Flags.JoinLimit = opt.DefaultJoinOrderLimit
 
 	return ot
 }
@@ -305,16 +306,10 @@ func (ot *OptTester) RunCommand(tb testing.TB, d *datadriven.TestData) string {
 		}
 	}
 
-	if ot.Flags.JoinLimit != 0 {
-		defer func(oldValue int) {
-			ot.evalCtx.SessionData.ReorderJoinsLimit = oldValue
-		}(ot.evalCtx.SessionData.ReorderJoinsLimit)
-		ot.evalCtx.SessionData.ReorderJoinsLimit = ot.Flags.JoinLimit
-	}
-
 	ot.Flags.Verbose = testing.Verbose()
 	ot.evalCtx.TestingKnobs.OptimizerCostPerturbation = ot.Flags.PerturbCost
 	ot.evalCtx.Locality = ot.Flags.Locality
+	ot.evalCtx.SessionData.ReorderJoinsLimit = ot.Flags.JoinLimit
 	ot.evalCtx.SessionData.SaveTablesPrefix = ot.Flags.SaveTablesPrefix
 
 	switch d.Cmd {
@@ -17,6 +17,7 @@ import (
 	"strings"
 
 	"github.com/cockroachdb/cockroach/pkg/config"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/sql/opt/cat"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
@@ -136,6 +137,11 @@ func (tc *Catalog) CreateTable(stmt *tree.CreateTable) *Table {
 		tab.addPrimaryColumnIndex("rowid")
 	}
 
+	// Partition the primary index if the table is partitioned.
+	if stmt.PartitionBy != nil {
+		tab.Indexes[cat.PrimaryIndex].addPartitions(stmt.PartitionBy)
+	}
+
 	// Add check constraints.
 	for _, def := range stmt.Defs {
 		switch def := def.(type) {
@@ -403,6 +409,9 @@ func (tt *Table) addIndex(def *tree.IndexTableDef, typ indexType) *Index {
 		if len(tt.Indexes) != 0 {
 			panic("primary index should always be 0th index")
 		}
+		if def.PartitionBy != nil {
+			idx.addPartitions(def.PartitionBy)
+		}
 		idx.Ordinal = len(tt.Indexes)
 		tt.Indexes = append(tt.Indexes, idx)
 		return idx
@@ -456,12 +465,73 @@ func (tt *Table) addIndex(def *tree.IndexTableDef, typ indexType) *Index {
 		}
 	}
 
+	if def.PartitionBy != nil {
+		idx.addPartitions(def.PartitionBy)
+	}
+
 	idx.Ordinal = len(tt.Indexes)
 	tt.Indexes = append(tt.Indexes, idx)
 
 	return idx
 }
 
+// addPartitions adds the PARTITION BY LIST partitions of the index. The
+// partitioning columns must be a prefix of the index columns. Subpartitions and
+// RANGE partitions are not supported.
+func (ti *Index) addPartitions(partitionBy *tree.PartitionBy) {
+	if len(partitionBy.Range) > 0 {
+		panic("PARTITION BY RANGE is not supported")
+	}
+	for i, name := range partitionBy.Fields {
+		if i >= len(ti.Columns) || ti.Columns[i].ColName() != name {
+			panic(fmt.Errorf("partitioning column %q is not a prefix of the index columns", name))
+		}
+	}
+
+	semaCtx := tree.MakeSemaContext()
+	evalCtx := tree.MakeTestingEvalContext(cluster.MakeTestingClusterSettings())
+	ti.Partitions = make([]Partition, len(partitionBy.List))
+	for i := range partitionBy.List {
+		listPart := &partitionBy.List[i]
+		if listPart.Subpartition != nil {
+			panic("subpartitions are not supported")
+		}
+		part := &ti.Partitions[i]
+		part.PartitionName = string(listPart.Name)
+		part.index = ti
+		for _, expr := range listPart.Exprs {
+			exprs := tree.Exprs{expr}
+			if tuple, ok := expr.(*tree.Tuple); ok {
+				exprs = tuple.Exprs
+			}
+			if len(exprs) != len(partitionBy.Fields) {
+				panic(fmt.Errorf("partition %q has values of the wrong length", part.PartitionName))
+			}
+			var prefix tree.Datums
+			for j, e := range exprs {
+				if _, ok := e.(tree.DefaultVal); ok {
+					// DEFAULT matches any value, so the prefix ends here.
+					break
+				}
+				typedExpr, err := tree.TypeCheckAndRequire(
+					e, &semaCtx, ti.Columns[j].DatumType(), "PARTITION BY",
+				)
+				if err != nil {
+					panic(err)
+				}
+				d, err := typedExpr.Eval(&evalCtx)
+				if err != nil {
+					panic(err)
+				}
+				prefix = append(prefix, d)
+			}
+			if len(prefix) > 0 {
+				part.Prefixes = append(part.Prefixes, prefix)
+			}
+		}
+	}
+}
+
 func (tt *Table) makeIndexName(defName tree.Name, typ indexType) string {
 	name := string(defName)
 	if name == "" {
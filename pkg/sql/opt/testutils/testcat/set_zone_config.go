@@ -21,26 +21,40 @@ import (
 )
 
 // SetZoneConfig is a partial implementation of the ALTER TABLE ... CONFIGURE
-// ZONE USING statement.
+// ZONE USING and ALTER PARTITION ... CONFIGURE ZONE USING statements.
 func (tc *Catalog) SetZoneConfig(stmt *tree.SetZoneConfig) *config.ZoneConfig {
 	// Update the table name to include catalog and schema if not provided.
 	tabName := stmt.TableOrIndex.Table
 	tc.qualifyTableName(&tabName)
 	tab := tc.Table(&tabName)
 
-	// Handle special case of primary index.
-	if stmt.TableOrIndex.Index == "" {
-		tab.Indexes[0].IdxZone = makeZoneConfig(stmt.Options)
-		return tab.Indexes[0].IdxZone
+	// The zone applies to the primary index if no index is specified.
+	idx := tab.Indexes[0]
+	if stmt.TableOrIndex.Index != "" {
+		idx = nil
+		for _, i := range tab.Indexes {
+			if i.IdxName == string(stmt.TableOrIndex.Index) {
+				idx = i
+				break
+			}
+		}
+		if idx == nil {
+			panic(fmt.Errorf("\"%q\" is not an index", stmt.TableOrIndex.Index))
+		}
 	}
 
-	for _, idx := range tab.Indexes {
-		if idx.IdxName == string(stmt.TableOrIndex.Index) {
-			idx.IdxZone = makeZoneConfig(stmt.Options)
-			return idx.IdxZone
+	if stmt.Partition != "" {
+		for i := range idx.Partitions {
+			if idx.Partitions[i].PartitionName == string(stmt.Partition) {
+				idx.Partitions[i].PartitionZone = makeZoneConfig(stmt.Options)
+				return idx.Partitions[i].PartitionZone
+			}
 		}
+		panic(fmt.Errorf("\"%q\" is not a partition", stmt.Partition))
 	}
-	panic(fmt.Errorf("\"%q\" is not an index", stmt.TableOrIndex.Index))
+
+	idx.IdxZone = makeZoneConfig(stmt.Options)
+	return idx.IdxZone
 }
 
 // makeZoneConfig constructs a ZoneConfig from options provided to the CONFIGURE
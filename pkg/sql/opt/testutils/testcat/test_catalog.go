@@ -26,6 +26,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/stats"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/treeprinter"
 )
@@ -166,6 +167,13 @@ func (tc *Catalog) CheckPrivilege(ctx context.Context, o cat.Object, priv privil
 	return tc.CheckAnyPrivilege(ctx, o)
 }
 
+// CheckColumnPrivileges is part of the cat.Catalog interface.
+func (tc *Catalog) CheckColumnPrivileges(
+	ctx context.Context, tab cat.Table, priv privilege.Kind, ords util.FastIntSet,
+) error {
+	return tc.CheckPrivilege(ctx, tab, priv)
+}
+
 // CheckAnyPrivilege is part of the cat.Catalog interface.
 func (tc *Catalog) CheckAnyPrivilege(ctx context.Context, o cat.Object) error {
 	switch t := o.(type) {
@@ -191,6 +199,13 @@ func (tc *Catalog) CheckAnyPrivilege(ctx context.Context, o cat.Object) error {
 	return nil
 }
 
+// RowLevelSecurityPolicies is part of the cat.Catalog interface.
+func (tc *Catalog) RowLevelSecurityPolicies(
+	ctx context.Context, tab cat.Table,
+) (enforced bool, policies util.FastIntSet, err error) {
+	return false, policies, nil
+}
+
 // RequireSuperUser is part of the cat.Catalog interface.
 func (tc *Catalog) RequireSuperUser(ctx context.Context, action string) error {
 	return nil
@@ -640,6 +655,16 @@ func (tt *Table) InboundForeignKey(i int) cat.ForeignKeyConstraint {
 	return &tt.inboundFKs[i]
 }
 
+// PolicyCount is part of the cat.Table interface.
+func (tt *Table) PolicyCount() int {
+	return 0
+}
+
+// Policy is part of the cat.Table interface.
+func (tt *Table) Policy(i int) cat.Policy {
+	panic("no policies")
+}
+
 // FindOrdinal returns the ordinal of the column with the given name.
 func (tt *Table) FindOrdinal(name string) int {
 	for i, col := range tt.Columns {
@@ -682,6 +707,9 @@ type Index struct {
 	// the parent table, database, or even the default zone.
 	IdxZone *config.ZoneConfig
 
+	// Partitions are the PARTITION BY LIST partitions of the index.
+	Partitions []Partition
+
 	// table is a back reference to the table this index is on.
 	table *Table
 }
@@ -741,6 +769,50 @@ func (ti *Index) Span() roachpb.Span {
 	panic("not implemented")
 }
 
+// PartitionCount is part of the cat.Index interface.
+func (ti *Index) PartitionCount() int {
+	return len(ti.Partitions)
+}
+
+// Partition is part of the cat.Index interface.
+func (ti *Index) Partition(i int) cat.Partition {
+	return &ti.Partitions[i]
+}
+
+// Partition implements the cat.Partition interface for testing purposes.
+type Partition struct {
+	PartitionName string
+
+	// PartitionZone is the zone associated with the partition. If it is nil,
+	// the partition inherits the zone of its index.
+	PartitionZone *config.ZoneConfig
+
+	Prefixes []tree.Datums
+
+	// index is a back reference to the index this partition is on.
+	index *Index
+}
+
+var _ cat.Partition = &Partition{}
+
+// Name is part of the cat.Partition interface.
+func (tp *Partition) Name() string {
+	return tp.PartitionName
+}
+
+// Zone is part of the cat.Partition interface.
+func (tp *Partition) Zone() cat.Zone {
+	if tp.PartitionZone == nil {
+		return tp.index.IdxZone
+	}
+	return tp.PartitionZone
+}
+
+// PartitionByListPrefixes is part of the cat.Partition interface.
+func (tp *Partition) PartitionByListPrefixes() []tree.Datums {
+	return tp.Prefixes
+}
+
 // Column implements the cat.Column interface for testing purposes.
 type Column struct {
 	Ordinal      int
@@ -19,6 +19,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/opt"
 	"github.com/cockroachdb/cockroach/pkg/sql/opt/cat"
+	"github.com/cockroachdb/cockroach/pkg/sql/opt/constraint"
 	"github.com/cockroachdb/cockroach/pkg/sql/opt/memo"
 	"github.com/cockroachdb/cockroach/pkg/sql/opt/ordering"
 	"github.com/cockroachdb/cockroach/pkg/sql/opt/props/physical"
@@ -77,6 +78,10 @@ type coster struct {
 	//
 	locality roachpb.Locality
 
+	// evalCtx is used to compare the values of index keys with the values of
+	// index partitions.
+	evalCtx *tree.EvalContext
+
 	// perturbation indicates how much to randomly perturb the cost. It is used
 	// to generate alternative plans for testing. For example, if perturbation is
 	// 0.5, and the estimated cost of an expression is c, the cost returned by
@@ -121,6 +126,20 @@ const (
 	// up with better way to incorporate latency into the coster.
 	latencyCostFactor = cpuCostFactor
 
+	// remotePartitionCost is the cost of scanning index partitions that are
+	// located outside of the gateway's locality, when the index also has
+	// partitions located inside of it. Unlike latencyCostFactor, it is a fixed
+	// cost which reflects the latency of a single cross-region round trip; it is
+	// high enough that the optimizer prefers plans which only scan the local
+	// partitions whenever possible (see LocalityOptimizedSearch).
+	remotePartitionCost = 10 * randIOCostFactor
+
+	// remoteBranchCostScale is the fraction of remotePartitionCost which is
+	// charged to the remote input of a LocalityOptimizedSearch. The remote input
+	// is only executed if the local input doesn't return enough rows, which is
+	// expected to be rare.
+	remoteBranchCostScale = 1.0 / 3.0
+
 	// hugeCost is used with expressions we want to avoid; these are expressions
 	// that "violate" a hint like forcing a specific index or join algorithm.
 	// If the final expression has this cost or larger, it means that there was no
@@ -132,6 +151,7 @@ const (
 func (c *coster) Init(evalCtx *tree.EvalContext, mem *memo.Memo, perturbation float64) {
 	c.mem = mem
 	c.locality = evalCtx.Locality
+	c.evalCtx = evalCtx
 	c.perturbation = perturbation
 }
 
@@ -178,6 +198,9 @@ func (c *coster) ComputeCost(candidate memo.RelExpr, required *physical.Required
 	case opt.LookupJoinOp:
 		cost = c.computeLookupJoinCost(candidate.(*memo.LookupJoinExpr))
 
+	case opt.InvertedJoinOp:
+		cost = c.computeInvertedJoinCost(candidate.(*memo.InvertedJoinExpr))
+
 	case opt.ZigzagJoinOp:
 		cost = c.computeZigzagJoinCost(candidate.(*memo.ZigzagJoinExpr))
 
@@ -185,6 +208,9 @@ func (c *coster) ComputeCost(candidate memo.RelExpr, required *physical.Required
 		opt.UnionAllOp, opt.IntersectAllOp, opt.ExceptAllOp:
 		cost = c.computeSetCost(candidate)
 
+	case opt.LocalityOptimizedSearchOp:
+		cost = c.computeLocalityOptimizedSearchCost(candidate.(*memo.LocalityOptimizedSearchExpr))
+
 	case opt.GroupByOp, opt.ScalarGroupByOp, opt.DistinctOnOp:
 		cost = c.computeGroupingCost(candidate, required)
 
@@ -282,7 +308,44 @@ func (c *coster) computeScanCost(scan *memo.ScanExpr, required *physical.Require
 	if scan.Constraint == nil || scan.Constraint.IsUnconstrained() {
 		preferConstrainedScanCost = cpuCostFactor
 	}
-	return memo.Cost(rowCount)*(seqIOCostFactor+perRowCost) + preferConstrainedScanCost
+	cost := memo.Cost(rowCount)*(seqIOCostFactor+perRowCost) + preferConstrainedScanCost
+	if c.scanTouchesRemotePartitions(&scan.ScanPrivate) {
+		if scan.LocalityOptimized {
+			cost += remotePartitionCost * remoteBranchCostScale
+		} else {
+			cost += remotePartitionCost
+		}
+	}
+	return cost
+}
+
+// scanTouchesRemotePartitions returns true if the given scan is on an index
+// which has partitions located inside the gateway's locality, but may also
+// need to scan partitions located outside of it.
+func (c *coster) scanTouchesRemotePartitions(scan *memo.ScanPrivate) bool {
+	if len(c.locality.Tiers) == 0 {
+		return false
+	}
+	idx := c.mem.Metadata().Table(scan.Table).Index(scan.Index)
+	if idx.PartitionCount() == 0 {
+		return false
+	}
+	localPrefixes, _ := partitionPrefixes(idx, c.locality)
+	if len(localPrefixes) == 0 {
+		// None of the partitions are local, so every plan has to communicate
+		// with remote nodes.
+		return false
+	}
+	if scan.Constraint == nil {
+		return true
+	}
+	spans := &scan.Constraint.Spans
+	for i, n := 0, spans.Count(); i < n; i++ {
+		if !spanHasPrefix(c.evalCtx, spans.Get(i), localPrefixes) {
+			return true
+		}
+	}
+	return false
 }
 
 func (c *coster) computeVirtualScanCost(scan *memo.VirtualScanExpr) memo.Cost {
@@ -410,6 +473,25 @@ func (c *coster) computeLookupJoinCost(join *memo.LookupJoinExpr) memo.Cost {
 	return cost
 }
 
+func (c *coster) computeInvertedJoinCost(join *memo.InvertedJoinExpr) memo.Cost {
+	leftRowCount := join.Input.Relational().Stats.RowCount
+
+	// Each input row results in lookups of one or more inverted index keys, which
+	// are not likely to be in the same range, so this counts as random I/O. We
+	// don't know how many keys each JSON value produces, so assume one lookup
+	// per input row.
+	cost := memo.Cost(leftRowCount) * randIOCostFactor
+
+	// Each lookup might retrieve many index entries; add the IO cost of
+	// retrieving them and the CPU cost of deduplicating and emitting the
+	// candidate rows.
+	perRowCost := lookupJoinRetrieveRowCost + cpuCostFactor +
+		c.rowScanCost(join.Table, join.Index, join.Cols.Len())
+
+	cost += memo.Cost(join.Relational().Stats.RowCount) * perRowCost
+	return cost
+}
+
 func (c *coster) computeZigzagJoinCost(join *memo.ZigzagJoinExpr) memo.Cost {
 	rowCount := join.Relational().Stats.RowCount
 
@@ -449,6 +531,15 @@ func (c *coster) computeSetCost(set memo.RelExpr) memo.Cost {
 	return cost
 }
 
+func (c *coster) computeLocalityOptimizedSearchCost(
+	los *memo.LocalityOptimizedSearchExpr,
+) memo.Cost {
+	// The search emits each row of its inputs once. The discounted cost of
+	// scanning the remote partitions is charged to the remote input itself
+	// (see computeScanCost).
+	return memo.Cost(los.Relational().Stats.RowCount) * cpuCostFactor
+}
+
 func (c *coster) computeGroupingCost(grouping memo.RelExpr, required *physical.Required) memo.Cost {
 	// Add the CPU cost of emitting the rows.
 	cost := memo.Cost(grouping.Relational().Stats.RowCount) * cpuCostFactor
@@ -555,6 +646,53 @@ func (c *coster) rowScanCost(tabID opt.TableID, idxOrd int, numScannedCols int)
 	return memo.Cost(numCols+numScannedCols) * costFactor
 }
 
+// isZoneLocal returns true if the given zone places the replicas or the
+// leaseholders of its ranges in the given locality (or part of it).
+func isZoneLocal(zone cat.Zone, locality roachpb.Locality) bool {
+	return localityMatchScore(zone, locality) > 0
+}
+
+// partitionPrefixes returns the PARTITION BY LIST prefixes of the given index,
+// separated into the prefixes of the partitions which are local to the given
+// locality (see isZoneLocal), and the prefixes of the other partitions.
+func partitionPrefixes(
+	idx cat.Index, locality roachpb.Locality,
+) (localPrefixes, remotePrefixes []tree.Datums) {
+	for i, n := 0, idx.PartitionCount(); i < n; i++ {
+		part := idx.Partition(i)
+		if isZoneLocal(part.Zone(), locality) {
+			localPrefixes = append(localPrefixes, part.PartitionByListPrefixes()...)
+		} else {
+			remotePrefixes = append(remotePrefixes, part.PartitionByListPrefixes()...)
+		}
+	}
+	return localPrefixes, remotePrefixes
+}
+
+// spanHasPrefix returns true if all the keys in the given span start with one
+// of the given prefixes; that is, if the start and end keys of the span both
+// start with the same prefix.
+func spanHasPrefix(evalCtx *tree.EvalContext, sp *constraint.Span, prefixes []tree.Datums) bool {
+	startKey, endKey := sp.StartKey(), sp.EndKey()
+	for _, prefix := range prefixes {
+		if startKey.Length() < len(prefix) || endKey.Length() < len(prefix) {
+			continue
+		}
+		matches := true
+		for i := range prefix {
+			if prefix[i].Compare(evalCtx, startKey.Value(i)) != 0 ||
+				prefix[i].Compare(evalCtx, endKey.Value(i)) != 0 {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
 // localityMatchScore returns a number from 0.0 to 1.0 that describes how well
 // the current node's locality matches the given zone constraints and
 // leaseholder preferences, with 0.0 indicating 0% and 1.0 indicating 100%. This
@@ -20,7 +20,6 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/opt/memo"
 	"github.com/cockroachdb/cockroach/pkg/sql/opt/norm"
 	"github.com/cockroachdb/cockroach/pkg/sql/opt/ordering"
-	"github.com/cockroachdb/cockroach/pkg/sql/opt/props"
 	"github.com/cockroachdb/cockroach/pkg/sql/opt/props/physical"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
@@ -112,6 +111,132 @@ func (c *CustomFuncs) GenerateIndexScans(grp memo.RelExpr, scanPrivate *memo.Sca
 	}
 }
 
+// localityOptimizedSearchMaxRows is the maximum number of rows that a limited
+// Scan can return for GenerateLocalityOptimizedScan to apply. The remote
+// partitions are more likely to be needed when many rows are requested, in
+// which case executing the local and remote scans one after the other would
+// only add latency.
+const localityOptimizedSearchMaxRows = 100
+
+// CanMaybeGenerateLocalityOptimizedScan returns true if it may be possible to
+// generate a locality optimized scan from the given ScanPrivate. It checks that
+// the scan is limited to a small number of rows, that it has multiple spans
+// which may belong to different partitions, and that the scanned index is
+// partitioned. The remaining checks are done by GenerateLocalityOptimizedScan.
+func (c *CustomFuncs) CanMaybeGenerateLocalityOptimizedScan(scanPrivate *memo.ScanPrivate) bool {
+	if !scanPrivate.HardLimit.IsSet() ||
+		scanPrivate.HardLimit.RowCount() > localityOptimizedSearchMaxRows {
+		return false
+	}
+	if scanPrivate.Constraint == nil || scanPrivate.Constraint.Spans.Count() < 2 {
+		return false
+	}
+	if scanPrivate.LocalityOptimized {
+		return false
+	}
+	if len(c.e.evalCtx.Locality.Tiers) == 0 {
+		return false
+	}
+	tab := c.e.mem.Metadata().Table(scanPrivate.Table)
+	return tab.Index(scanPrivate.Index).PartitionCount() > 0
+}
+
+// GenerateLocalityOptimizedScan generates a LocalityOptimizedSearch expression
+// from a limited Scan whose spans cover partitions both inside and outside of
+// the gateway's locality. The spans are divided between two limited Scans: the
+// local Scan, which only scans local partitions, and the remote Scan, which
+// scans the rest. For example, consider this table and query:
+//
+//   CREATE TABLE abc (
+//     r STRING NOT NULL CHECK (r IN ('east', 'west')),
+//     k INT NOT NULL,
+//     PRIMARY KEY (r, k)
+//   ) PARTITION BY LIST (r) (
+//     PARTITION east VALUES IN ('east'),
+//     PARTITION west VALUES IN ('west')
+//   )
+//
+//   SELECT * FROM abc WHERE k = 10 LIMIT 1
+//
+// If the gateway is in the east, and the east partition is constrained to be
+// located in the east, then the limited Scan with spans [/'east'/10 -
+// /'east'/10] and [/'west'/10 - /'west'/10] becomes:
+//
+//   (LocalityOptimizedSearch
+//     (Scan [/'east'/10 - /'east'/10] limit=1)
+//     (Scan [/'west'/10 - /'west'/10] limit=1)
+//   )
+//
+// The remote Scan is only executed if the local Scan doesn't return enough
+// rows to satisfy the limit.
+//
+// The LocalityOptimizedSearch is added to the same group as the limited Scan,
+// which must also contain the Limit operator that the limit originated from.
+// Since LocalityOptimizedSearch provides no ordering, the Limit must not
+// require any ordering from its input.
+func (c *CustomFuncs) GenerateLocalityOptimizedScan(
+	grp memo.RelExpr, scanPrivate *memo.ScanPrivate,
+) {
+	limit, ok := grp.FirstExpr().(*memo.LimitExpr)
+	if !ok || !limit.Ordering.Any() {
+		return
+	}
+
+	tab := c.e.mem.Metadata().Table(scanPrivate.Table)
+	localPrefixes, _ := partitionPrefixes(tab.Index(scanPrivate.Index), c.e.evalCtx.Locality)
+	if len(localPrefixes) == 0 {
+		return
+	}
+
+	// Divide the spans into those which only touch local partitions, and the
+	// others.
+	spans := &scanPrivate.Constraint.Spans
+	var localSpans, remoteSpans constraint.Spans
+	localSpans.Alloc(spans.Count())
+	remoteSpans.Alloc(spans.Count())
+	for i, n := 0, spans.Count(); i < n; i++ {
+		span := spans.Get(i)
+		if spanHasPrefix(c.e.evalCtx, span, localPrefixes) {
+			localSpans.Append(span)
+		} else {
+			remoteSpans.Append(span)
+		}
+	}
+	if localSpans.Count() == 0 || remoteSpans.Count() == 0 {
+		// There is nothing to gain if all the spans are either local or remote.
+		return
+	}
+
+	keyCtx := constraint.MakeKeyContext(&scanPrivate.Constraint.Columns, c.e.evalCtx)
+	localScan := c.buildPartialScan(scanPrivate, &keyCtx, &localSpans)
+	remoteScan := c.buildPartialScan(scanPrivate, &keyCtx, &remoteSpans)
+
+	cols := opt.ColSetToList(scanPrivate.Cols)
+	los := memo.LocalityOptimizedSearchExpr{
+		Local:  localScan,
+		Remote: remoteScan,
+		SetPrivate: memo.SetPrivate{
+			LeftCols:  cols,
+			RightCols: cols,
+			OutCols:   cols,
+		},
+	}
+	c.e.mem.AddLocalityOptimizedSearchToGroup(&los, grp)
+}
+
+// buildPartialScan constructs a Scan which is identical to the given one,
+// except that it only scans the given subset of its spans and is marked as an
+// input of a LocalityOptimizedSearch.
+func (c *CustomFuncs) buildPartialScan(
+	scanPrivate *memo.ScanPrivate, keyCtx *constraint.KeyContext, spans *constraint.Spans,
+) memo.RelExpr {
+	newScanPrivate := *scanPrivate
+	newScanPrivate.Constraint = &constraint.Constraint{}
+	newScanPrivate.Constraint.Init(keyCtx, spans)
+	newScanPrivate.LocalityOptimized = true
+	return c.e.f.ConstructScan(&newScanPrivate)
+}
+
 // ----------------------------------------------------------------------
 //
 // Select Rules
@@ -911,6 +1036,106 @@ func (c *CustomFuncs) GenerateLookupJoins(
 	}
 }
 
+// GenerateInvertedJoins looks at the inverted indexes of the Scan table and
+// creates inverted join expressions in the current group. An inverted join can
+// be created when the ON condition requires the indexed JSON column to contain
+// a JSON column of the input, for example:
+//
+//   SELECT * FROM a JOIN b ON b.j @> a.j
+//
+// The inverted join looks up the inverted index keys derived from each input
+// value and returns the primary keys of the candidate rows. The candidates are
+// a superset of the matching rows, so we always generate an index join (a
+// LookupJoin into the primary index) above the inverted join, which retrieves
+// the remaining columns and re-checks the full ON condition:
+//
+//         Join                       LookupJoin(t@primary)
+//         /   \                           |
+//        /     \            ->            |
+//      Input  Scan(t)              InvertedJoin(t@idx)
+//                                         |
+//                                         |
+//                                       Input
+//
+func (c *CustomFuncs) GenerateInvertedJoins(
+	grp memo.RelExpr,
+	input memo.RelExpr,
+	scanPrivate *memo.ScanPrivate,
+	on memo.FiltersExpr,
+	joinPrivate *memo.JoinPrivate,
+) {
+	if joinPrivate.Flags.DisallowLookupJoin || scanPrivate.Flags.NoIndexJoin {
+		return
+	}
+	inputProps := input.Relational()
+
+	var pkCols opt.ColList
+
+	var iter scanIndexIter
+	iter.init(c.e.mem, scanPrivate)
+	for iter.nextInverted() {
+		indexCol := scanPrivate.Table.ColumnID(iter.index.Column(0).Ordinal)
+		inputCol, ok := c.findInvertedJoinCondition(on, indexCol, inputProps.OutputCols)
+		if !ok {
+			continue
+		}
+
+		if pkCols == nil {
+			pkIndex := iter.tab.Index(cat.PrimaryIndex)
+			pkCols = make(opt.ColList, pkIndex.KeyColumnCount())
+			for i := range pkCols {
+				pkCols[i] = scanPrivate.Table.ColumnID(pkIndex.Column(i).Ordinal)
+			}
+		}
+
+		invertedJoin := c.e.f.ConstructInvertedJoin(input, &memo.InvertedJoinPrivate{
+			Table:    scanPrivate.Table,
+			Index:    iter.indexOrdinal,
+			InputCol: inputCol,
+			Cols:     pkCols.ToSet(),
+		})
+
+		indexJoin := memo.LookupJoinExpr{Input: invertedJoin, On: on}
+		indexJoin.JoinPrivate = *joinPrivate
+		indexJoin.JoinType = opt.InnerJoinOp
+		indexJoin.Table = scanPrivate.Table
+		indexJoin.Index = cat.PrimaryIndex
+		indexJoin.KeyCols = pkCols
+		indexJoin.Cols = scanPrivate.Cols.Union(inputProps.OutputCols)
+
+		// Create the LookupJoin for the index join in the same group.
+		c.e.mem.AddLookupJoinToGroup(&indexJoin, grp)
+	}
+}
+
+// findInvertedJoinCondition searches the given filters for a condition of the
+// form:
+//
+//   indexCol @> inputCol
+//
+// where inputCol is one of the given input columns. If one is found, it returns
+// inputCol and ok=true.
+func (c *CustomFuncs) findInvertedJoinCondition(
+	filters memo.FiltersExpr, indexCol opt.ColumnID, inputCols opt.ColSet,
+) (inputCol opt.ColumnID, ok bool) {
+	for i := range filters {
+		contains, ok := filters[i].Condition.(*memo.ContainsExpr)
+		if !ok {
+			continue
+		}
+		left, ok := contains.Left.(*memo.VariableExpr)
+		if !ok || left.Col != indexCol {
+			continue
+		}
+		right, ok := contains.Right.(*memo.VariableExpr)
+		if !ok || !inputCols.Contains(int(right.Col)) {
+			continue
+		}
+		return right.Col, true
+	}
+	return 0, false
+}
+
 // eqColsForZigzag is a helper function to generate eqCol lists for the zigzag
 // joiner. The zigzag joiner requires that the equality columns immediately
 // follow the fixed columns in the index. Fixed here refers to columns that
@@ -1418,33 +1643,23 @@ func (c *CustomFuncs) GenerateInvertedIndexZigzagJoins(
 	}
 }
 
-// deriveJoinSize returns the number of base relations (i.e., not joins)
-// being joined underneath the given relational expression.
-func (c *CustomFuncs) deriveJoinSize(e memo.RelExpr) int {
-	relProps := e.Relational()
-	if relProps.IsAvailable(props.JoinSize) {
-		return relProps.Rule.JoinSize
-	}
-	relProps.SetAvailable(props.JoinSize)
-
-	switch j := e.(type) {
-	case *memo.InnerJoinExpr:
-		relProps.Rule.JoinSize = c.deriveJoinSize(j.Left) + c.deriveJoinSize(j.Right)
-	default:
-		relProps.Rule.JoinSize = 1
-	}
-
-	return relProps.Rule.JoinSize
+// ShouldReorderJoins returns whether the optimizer should attempt to find a
+// better ordering of the joins in the join tree rooted at a join with the given
+// private. Joins with hints are never reordered.
+func (c *CustomFuncs) ShouldReorderJoins(private *memo.JoinPrivate) bool {
+	return c.e.o.reorderJoinsLimit > 0 && private.Flags.Empty()
 }
 
-// ShouldReorderJoins returns whether the optimizer should attempt to find
-// a better ordering of inner joins.
-func (c *CustomFuncs) ShouldReorderJoins(left, right memo.RelExpr) bool {
-	// TODO(justin): referencing left and right here is a hack: ideally
-	// we'd want to be able to reference the logical properties of the
-	// expression being explored in this CustomFunc.
-	size := c.deriveJoinSize(left) + c.deriveJoinSize(right)
-	return size <= c.e.evalCtx.SessionData.ReorderJoinsLimit
+// ReorderJoins adds alternate orders of the join tree rooted at the given join
+// to the memo. See JoinOrderBuilder for more details.
+func (c *CustomFuncs) ReorderJoins(grp memo.RelExpr) {
+	// The other expressions in the group were either added by a previous
+	// reordering of the same join tree, or by rules which don't change the
+	// relations being joined; reordering them would only duplicate work.
+	if grp != grp.FirstExpr() {
+		return
+	}
+	c.e.o.jb.Reorder(grp, c.e.o.reorderJoinsLimit)
 }
 
 // ----------------------------------------------------------------------
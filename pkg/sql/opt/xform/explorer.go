@@ -30,20 +30,21 @@ import (
 // rule efficiently enumerates all possible combinations of its sub-expressions
 // in order to look for matches. For example:
 //
-//  // [AssociateJoin]
+//  // [GenerateLookupJoinsWithFilter]
 //  (InnerJoin
-//    (InnerJoin $r:* $s:* $lowerOn:*)
-//    $t:*
-//    $upperOn:*
+//    $left:*
+//    (Select (Scan $scanPrivate:*) $filters:*)
+//    $on:*
+//    $private:*
 //  )
 //  =>
 //  ...
 //
-// Say the memo group containing the upper inner-join has 3 expressions in it,
-// and the memo group containing the lower inner-join has 4 expressions. Then
-// the explorer will enumerate 12 possible expression combinations, looking for
-// a combination that has an InnerJoin operator with another InnerJoin operator
-// as its left operand.
+// Say the memo group containing the inner-join has 3 expressions in it, and
+// the memo group containing the select has 4 expressions. Then the explorer
+// will enumerate 12 possible expression combinations, looking for a
+// combination that has an InnerJoin operator with a Select operator as its
+// right operand.
 //
 // Once new expressions have been added to a group, they themselves become
 // eligible for exploration, which might generate further expressions, and so
@@ -59,28 +60,16 @@ import (
 // For each expression combination that matches, a replace expression is
 // constructed and added to the same memo group as the matched expression:
 //
-//  // [AssociateJoin]
-//  (InnerJoin
-//    (InnerJoin $r:* $s:* $lowerOn:*)
-//    $t:*
-//    $upperOn:*
-//  )
+//  // [CommuteJoin]
+//  (InnerJoin $left:* $right:* $on:* $private:*)
 //  =>
-//  (InnerJoin
-//    (InnerJoin
-//      $r
-//      $t
-//      (ConstructFiltersNotUsing $s $lowerOn $upperOn)
-//    )
-//    $s
-//    (ConstructFiltersUsing $s $lowerOn $upperOn)
-//  )
+//  (InnerJoin $right $left $on $private)
 //
-// In this example, if the upper and lower groups each contain two InnerJoin
-// expressions, then four new expressions will be added to the memo group of the
-// matched expression. During the next pass, the four new expressions will
-// themselves match this same rule. However, adding their replace expressions to
-// the memo group will be a no-op, because they're already present.
+// In this example, an InnerJoin with its inputs swapped will be added to the
+// memo group of the matched expression. During the next pass, the new
+// expression will itself match this same rule. However, adding its replace
+// expression to the memo group will be a no-op, because the original
+// expression is already present.
 type explorer struct {
 	evalCtx *tree.EvalContext
 	o       *Optimizer
@@ -0,0 +1,593 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package xform
+
+import (
+	"math/bits"
+	"sort"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/opt"
+	"github.com/cockroachdb/cockroach/pkg/sql/opt/memo"
+	"github.com/cockroachdb/cockroach/pkg/sql/opt/norm"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/util"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+// maxJoinOrderVertexes is the maximum number of base relations in a join tree
+// which can be reordered by the JoinOrderBuilder.
+const maxJoinOrderVertexes = 64
+
+// joinOrderIterationBudget is the maximum number of pairs of relation sets
+// considered by the dynamic programming enumeration of join orders. Once the
+// budget is exhausted, the JoinOrderBuilder falls back to a greedy heuristic
+// which takes polynomial time. The exhaustive enumeration of the join orders of
+// n relations considers about 3^n pairs, so the budget is exhausted by join
+// trees of a dozen relations or so.
+const joinOrderIterationBudget = 100000
+
+// JoinOrderBuilder reorders a tree of joins. It extracts a join graph from the
+// join tree, where the vertexes are the base relations (the inputs of the join
+// tree which aren't themselves reorderable joins) and the edges are the join
+// conditions. It then enumerates the join orders which are valid according to
+// the graph, and adds the resulting joins to the memo.
+//
+// The enumeration uses the DPsube algorithm: every set of vertexes is split in
+// all possible ways into two non-empty subsets, in order of increasing set
+// size, so that the plans for the subsets are always built before the plans for
+// their union. Only pairs of subsets connected by an edge are joined, so cross
+// products are never introduced. See:
+//
+//   Guido Moerkotte, Thomas Neumann: Dynamic Programming Strikes Back.
+//   SIGMOD 2008.
+//
+// Joins other than inner joins (left, full, semi and anti joins) can't be
+// freely reordered. The validity of an order is checked by the conflict rules
+// and the total eligibility sets of the edges, computed with the CD-C algorithm
+// of:
+//
+//   Guido Moerkotte, Pit Fender, Marius Eich: On the Correct and Complete
+//   Enumeration of the Core Search Space. SIGMOD 2013.
+//
+// If the join tree has more relations than the reorder_joins_limit, or if the
+// enumeration exhausts its iteration budget, the JoinOrderBuilder falls back to
+// a greedy heuristic: it repeatedly joins the pair of plans which produces the
+// fewest rows, until a single plan remains (Greedy Operator Ordering, see
+// Leonidas Fegaras: A New Heuristic for Optimizing Large Queries. DEXA 1998).
+type JoinOrderBuilder struct {
+	f *norm.Factory
+
+	// vertexes are the base relations of the join tree.
+	vertexes []memo.RelExpr
+
+	// edges are the join conditions of the join tree. Inner joins have an edge
+	// per conjunct of their ON condition; other joins have a single edge.
+	edges []edge
+
+	// innerEdges and nonInnerEdges are the ordinals of the edges of inner joins
+	// and of other joins, respectively.
+	innerEdges    util.FastIntSet
+	nonInnerEdges util.FastIntSet
+
+	// plans maps each set of vertexes to the memo group which joins them.
+	plans map[vertexSet]memo.RelExpr
+}
+
+// Init initializes the JoinOrderBuilder with the factory used to construct the
+// joins.
+func (jb *JoinOrderBuilder) Init(f *norm.Factory) {
+	*jb = JoinOrderBuilder{f: f}
+}
+
+// Reorder adds the join orders of the given join tree to the memo. The joins
+// which join all the base relations are added to the group of the root join;
+// other joins are added to the groups of the corresponding sets of relations.
+// Join trees with no more than limit relations are enumerated exhaustively;
+// larger ones are reordered greedily.
+func (jb *JoinOrderBuilder) Reorder(root memo.RelExpr, limit int) {
+	if countJoinVertexes(root) > maxJoinOrderVertexes {
+		return
+	}
+
+	jb.vertexes = jb.vertexes[:0]
+	jb.edges = jb.edges[:0]
+	jb.innerEdges = util.FastIntSet{}
+	jb.nonInnerEdges = util.FastIntSet{}
+	jb.plans = make(map[vertexSet]memo.RelExpr)
+	jb.populateGraph(root)
+
+	if len(jb.vertexes) <= limit && jb.dpSube() {
+		return
+	}
+	jb.greedy()
+}
+
+// populateGraph adds the vertexes and edges of the given join tree to the join
+// graph, and returns the vertexes and edges of the tree.
+func (jb *JoinOrderBuilder) populateGraph(rel memo.RelExpr) (vertexSet, util.FastIntSet) {
+	if !isReorderableJoin(rel) {
+		jb.plans[vertexSet(0).add(len(jb.vertexes))] = rel
+		jb.vertexes = append(jb.vertexes, rel)
+		return vertexSet(0).add(len(jb.vertexes) - 1), util.FastIntSet{}
+	}
+
+	leftVertexes, leftEdges := jb.populateGraph(rel.Child(0).(memo.RelExpr))
+	rightVertexes, rightEdges := jb.populateGraph(rel.Child(1).(memo.RelExpr))
+
+	op := &operator{
+		joinType:      rel.Op(),
+		private:       rel.Private().(*memo.JoinPrivate),
+		leftVertexes:  leftVertexes,
+		rightVertexes: rightVertexes,
+		leftEdges:     leftEdges,
+		rightEdges:    rightEdges,
+	}
+	filters := *rel.Child(2).(*memo.FiltersExpr)
+
+	first := len(jb.edges)
+	if op.joinType == opt.InnerJoinOp {
+		// Each conjunct of an inner join condition is an edge of its own, so that
+		// it can be applied as soon as the relations it references are joined.
+		for i := range filters {
+			jb.makeEdge(op, filters[i:i+1])
+		}
+		if len(filters) == 0 {
+			jb.makeEdge(op, nil)
+		}
+	} else {
+		jb.makeEdge(op, filters)
+	}
+
+	edges := leftEdges.Union(rightEdges)
+	edges.AddRange(first, len(jb.edges)-1)
+
+	vertexes := leftVertexes.union(rightVertexes)
+	jb.plans[vertexes] = rel
+	return vertexes, edges
+}
+
+// makeEdge adds an edge for the given filters of the given join to the join
+// graph, and computes its conflict rules.
+func (jb *JoinOrderBuilder) makeEdge(op *operator, filters memo.FiltersExpr) {
+	e := edge{op: op, filters: filters}
+
+	// The syntactic eligibility set (SES) of the edge contains the relations
+	// referenced by its filters. It must contain relations from both sides of
+	// the join: a degenerate condition (such as a cross join, or a condition
+	// which only references one side) stays on top of the relations that the
+	// join originally had on the other side.
+	var cols opt.ColSet
+	for i := range filters {
+		cols.UnionWith(filters[i].ScalarProps(jb.f.Memo()).OuterCols)
+	}
+	for i, v := range jb.vertexes {
+		if cols.Intersects(v.Relational().OutputCols) {
+			e.ses = e.ses.add(i)
+		}
+	}
+	if !e.ses.intersects(op.leftVertexes) {
+		e.ses = e.ses.union(op.leftVertexes)
+	}
+	if !e.ses.intersects(op.rightVertexes) {
+		e.ses = e.ses.union(op.rightVertexes)
+	}
+	e.ses = e.ses.intersection(op.leftVertexes.union(op.rightVertexes))
+
+	// The total eligibility set (TES) starts as the SES, and grows with the
+	// conflicts with the joins below (CD-C).
+	e.tes = e.ses
+	op.leftEdges.ForEach(func(i int) {
+		child := &jb.edges[i]
+		if !jb.assoc(child, &e, child.op.rightVertexes) {
+			e.addRule(child.op.rightVertexes, child.op.leftVertexes, child.ses)
+		}
+		if !leftAsscom(child.op.joinType, op.joinType) {
+			e.addRule(child.op.leftVertexes, child.op.rightVertexes, child.ses)
+		}
+	})
+	op.rightEdges.ForEach(func(i int) {
+		child := &jb.edges[i]
+		if !jb.assoc(&e, child, child.op.leftVertexes) {
+			e.addRule(child.op.leftVertexes, child.op.rightVertexes, child.ses)
+		}
+		if !rightAsscom(op.joinType, child.op.joinType) {
+			e.addRule(child.op.rightVertexes, child.op.leftVertexes, child.ses)
+		}
+	})
+
+	idx := len(jb.edges)
+	jb.edges = append(jb.edges, e)
+	if op.joinType == opt.InnerJoinOp {
+		jb.innerEdges.Add(idx)
+	} else {
+		jb.nonInnerEdges.Add(idx)
+	}
+}
+
+// dpSube enumerates the join orders of the join graph exhaustively, and returns
+// false if it exhausted its iteration budget before it was done.
+func (jb *JoinOrderBuilder) dpSube() bool {
+	budget := joinOrderIterationBudget
+	all := allVertexes(len(jb.vertexes))
+	for subset := vertexSet(1); subset <= all; subset++ {
+		if subset.len() < 2 {
+			continue
+		}
+		// Iterate over the non-empty proper subsets of subset.
+		for sub := (subset - 1) & subset; sub > 0; sub = (sub - 1) & subset {
+			budget--
+			if budget < 0 {
+				return false
+			}
+			complement := subset.difference(sub)
+			if jb.plans[sub] == nil || jb.plans[complement] == nil {
+				continue
+			}
+			jb.addJoins(sub, complement)
+		}
+	}
+	return true
+}
+
+// greedy builds a join order by repeatedly joining the pair of plans which
+// produces the fewest rows.
+func (jb *JoinOrderBuilder) greedy() {
+	components := make([]vertexSet, len(jb.vertexes))
+	for i := range components {
+		components[i] = vertexSet(0).add(i)
+	}
+	for len(components) > 1 {
+		bestLeft, bestRight := -1, -1
+		var bestRowCount float64
+		for i := range components {
+			for j := range components {
+				if i == j || !jb.addJoins(components[i], components[j]) {
+					continue
+				}
+				rowCount := jb.plans[components[i].union(components[j])].Relational().Stats.RowCount
+				if bestLeft == -1 || rowCount < bestRowCount {
+					bestLeft, bestRight, bestRowCount = i, j, rowCount
+				}
+			}
+		}
+		if bestLeft == -1 {
+			// The remaining plans can't be joined; this should only happen if
+			// the join graph is disconnected.
+			return
+		}
+		components[bestLeft] = components[bestLeft].union(components[bestRight])
+		components = append(components[:bestRight], components[bestRight+1:]...)
+	}
+}
+
+// addJoins adds the joins of the plans for the given sets of vertexes to the
+// memo, using the edges which are applicable to them. It returns false if the
+// plans can't be joined: either no edge connects them, or an edge connects them
+// which isn't applicable yet. In the latter case, the edge could never be
+// applied once the plans are joined.
+func (jb *JoinOrderBuilder) addJoins(s1, s2 vertexSet) bool {
+	union := s1.union(s2)
+
+	var innerFilters memo.FiltersExpr
+	innerFound := false
+	for i, ok := jb.innerEdges.Next(0); ok; i, ok = jb.innerEdges.Next(i + 1) {
+		e := &jb.edges[i]
+		if !e.ses.intersects(s1) || !e.ses.intersects(s2) {
+			continue
+		}
+		if e.checkInnerJoin(union) {
+			innerFilters = append(innerFilters, e.filters...)
+			innerFound = true
+		} else if e.ses.isSubsetOf(union) {
+			return false
+		}
+	}
+
+	var nonInner *edge
+	for i, ok := jb.nonInnerEdges.Next(0); ok; i, ok = jb.nonInnerEdges.Next(i + 1) {
+		e := &jb.edges[i]
+		if !e.ses.intersects(s1) || !e.ses.intersects(s2) {
+			continue
+		}
+		if nonInner != nil || !e.checkNonInnerJoin(s1, s2) {
+			return false
+		}
+		nonInner = e
+	}
+
+	switch {
+	case nonInner != nil:
+		// The inner join conditions can't be merged with the ON condition of
+		// the join, so they are applied by a Select on top of it.
+		jb.addJoin(nonInner.op.joinType, s1, s2, nonInner.filters, innerFilters, nonInner.op.private)
+	case innerFound:
+		jb.addJoin(opt.InnerJoinOp, s1, s2, innerFilters, nil, &memo.JoinPrivate{})
+	default:
+		return false
+	}
+	return true
+}
+
+// addJoin adds the join of the plans for the given sets of vertexes to the
+// group for their union, creating the group if it doesn't exist yet.
+func (jb *JoinOrderBuilder) addJoin(
+	op opt.Operator,
+	s1, s2 vertexSet,
+	joinFilters, selectFilters memo.FiltersExpr,
+	private *memo.JoinPrivate,
+) {
+	union := s1.union(s2)
+	left, right := jb.plans[s1], jb.plans[s2]
+	joinFilters = sortFilters(joinFilters)
+	selectFilters = sortFilters(selectFilters)
+
+	grp := jb.plans[union]
+	if grp == nil {
+		join := jb.f.ConstructJoin(op, left, right, joinFilters, private)
+		if len(selectFilters) > 0 {
+			join = jb.f.ConstructSelect(join, selectFilters)
+		}
+		jb.plans[union] = join
+		return
+	}
+
+	if len(selectFilters) > 0 {
+		join := jb.f.ConstructJoin(op, left, right, joinFilters, private)
+		jb.f.Memo().AddSelectToGroup(&memo.SelectExpr{Input: join, Filters: selectFilters}, grp)
+		return
+	}
+
+	mem := jb.f.Memo()
+	switch op {
+	case opt.InnerJoinOp:
+		mem.AddInnerJoinToGroup(&memo.InnerJoinExpr{
+			Left: left, Right: right, On: joinFilters, JoinPrivate: *private,
+		}, grp)
+	case opt.LeftJoinOp:
+		mem.AddLeftJoinToGroup(&memo.LeftJoinExpr{
+			Left: left, Right: right, On: joinFilters, JoinPrivate: *private,
+		}, grp)
+	case opt.FullJoinOp:
+		mem.AddFullJoinToGroup(&memo.FullJoinExpr{
+			Left: left, Right: right, On: joinFilters, JoinPrivate: *private,
+		}, grp)
+	case opt.SemiJoinOp:
+		mem.AddSemiJoinToGroup(&memo.SemiJoinExpr{
+			Left: left, Right: right, On: joinFilters, JoinPrivate: *private,
+		}, grp)
+	case opt.AntiJoinOp:
+		mem.AddAntiJoinToGroup(&memo.AntiJoinExpr{
+			Left: left, Right: right, On: joinFilters, JoinPrivate: *private,
+		}, grp)
+	default:
+		panic(pgerror.AssertionFailedf("unexpected join operator: %v", log.Safe(op)))
+	}
+}
+
+// assoc returns true if the joins of the given edges are associative, that is
+// if (e1 a e2) b e3 is equivalent to e1 a (e2 b e3). middle are the vertexes of
+// e2.
+func (jb *JoinOrderBuilder) assoc(a, b *edge, middle vertexSet) bool {
+	switch a.op.joinType {
+	case opt.InnerJoinOp:
+		return b.op.joinType != opt.FullJoinOp
+
+	case opt.LeftJoinOp:
+		// (e1 LEFT JOIN e2 ON p12) LEFT JOIN e3 ON p23 is equivalent to
+		// e1 LEFT JOIN (e2 LEFT JOIN e3 ON p23) ON p12 if p23 rejects nulls on e2.
+		return b.op.joinType == opt.LeftJoinOp && jb.rejectsNulls(b.filters, middle)
+
+	case opt.FullJoinOp:
+		switch b.op.joinType {
+		case opt.LeftJoinOp:
+			return jb.rejectsNulls(b.filters, middle)
+		case opt.FullJoinOp:
+			return jb.rejectsNulls(a.filters, middle) && jb.rejectsNulls(b.filters, middle)
+		}
+	}
+	return false
+}
+
+// rejectsNulls returns true if the given filters reject null values of the
+// columns of the given vertexes.
+func (jb *JoinOrderBuilder) rejectsNulls(filters memo.FiltersExpr, s vertexSet) bool {
+	var cols opt.ColSet
+	s.forEach(func(i int) {
+		cols.UnionWith(jb.vertexes[i].Relational().OutputCols)
+	})
+	return jb.f.CustomFuncs().HasNullRejectingFilter(filters, cols)
+}
+
+// leftAsscom returns true if (e1 a e2) b e3 is equivalent to (e1 b e3) a e2.
+// The equivalences which only hold if some conditions reject nulls are
+// conservatively considered invalid.
+func leftAsscom(a, b opt.Operator) bool {
+	switch a {
+	case opt.InnerJoinOp, opt.SemiJoinOp, opt.AntiJoinOp, opt.LeftJoinOp:
+		return b != opt.FullJoinOp
+	}
+	return false
+}
+
+// rightAsscom returns true if e1 a (e2 b e3) is equivalent to e2 b (e1 a e3).
+// The equivalences which only hold if some conditions reject nulls are
+// conservatively considered invalid.
+func rightAsscom(a, b opt.Operator) bool {
+	return a == opt.InnerJoinOp && b == opt.InnerJoinOp
+}
+
+// isReorderableJoin returns true if the given expression is a join which can be
+// reordered by the JoinOrderBuilder. Joins with hints are never reordered.
+func isReorderableJoin(rel memo.RelExpr) bool {
+	switch rel.Op() {
+	case opt.InnerJoinOp, opt.LeftJoinOp, opt.FullJoinOp, opt.SemiJoinOp, opt.AntiJoinOp:
+		return rel.Private().(*memo.JoinPrivate).Flags.Empty()
+	}
+	return false
+}
+
+// countJoinVertexes returns the number of base relations of the given join
+// tree.
+func countJoinVertexes(rel memo.RelExpr) int {
+	if !isReorderableJoin(rel) {
+		return 1
+	}
+	return countJoinVertexes(rel.Child(0).(memo.RelExpr)) +
+		countJoinVertexes(rel.Child(1).(memo.RelExpr))
+}
+
+// sortFilters returns the given filters sorted by the IDs of their
+// expressions, so that the same conditions always result in the same filters.
+func sortFilters(f memo.FiltersExpr) memo.FiltersExpr {
+	if len(f) < 2 {
+		return f
+	}
+	result := make(memo.FiltersExpr, len(f))
+	copy(result, f)
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Condition.ID() < result[j].Condition.ID()
+	})
+	return result
+}
+
+// operator is a join of the original join tree.
+type operator struct {
+	joinType opt.Operator
+	private  *memo.JoinPrivate
+
+	// leftVertexes and rightVertexes are the base relations of the left and
+	// right inputs of the join.
+	leftVertexes  vertexSet
+	rightVertexes vertexSet
+
+	// leftEdges and rightEdges are the edges of the joins in the left and right
+	// inputs of the join.
+	leftEdges  util.FastIntSet
+	rightEdges util.FastIntSet
+}
+
+// edge is a join condition of the join graph.
+type edge struct {
+	op      *operator
+	filters memo.FiltersExpr
+
+	// ses is the syntactic eligibility set of the edge: the relations which
+	// are referenced by its filters.
+	ses vertexSet
+
+	// tes is the total eligibility set of the edge: the relations which must be
+	// joined before the edge can be applied.
+	tes vertexSet
+
+	// rules are the conflict rules of the edge which couldn't be folded into
+	// its TES.
+	rules []conflictRule
+}
+
+// conflictRule states that if any of the relations in from are present in the
+// inputs of a join, all the relations in to must be present as well.
+type conflictRule struct {
+	from vertexSet
+	to   vertexSet
+}
+
+// addRule adds the conflict rule from -> to to the edge. The relations in to
+// are restricted to the ones referenced by the conflicting edge, if any.
+func (e *edge) addRule(from, to, conflictSES vertexSet) {
+	if to.intersects(conflictSES) {
+		to = to.intersection(conflictSES)
+	}
+	switch {
+	case from.intersects(e.tes):
+		// The rule always applies.
+		e.tes = e.tes.union(to)
+	case to.isSubsetOf(e.tes):
+		// The rule is always satisfied.
+	default:
+		e.rules = append(e.rules, conflictRule{from: from, to: to})
+	}
+}
+
+// checkRules returns true if the conflict rules of the edge allow it to join
+// the given set of relations.
+func (e *edge) checkRules(s vertexSet) bool {
+	for _, rule := range e.rules {
+		if rule.from.intersects(s) && !rule.to.isSubsetOf(s) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkInnerJoin returns true if the inner join edge is applicable to a join
+// of the given set of relations. The caller checks that the SES of the edge
+// intersects both inputs of the join.
+func (e *edge) checkInnerJoin(s vertexSet) bool {
+	return e.tes.isSubsetOf(s) && e.checkRules(s)
+}
+
+// checkNonInnerJoin returns true if the edge is applicable to a join with the
+// given left and right sets of relations.
+func (e *edge) checkNonInnerJoin(s1, s2 vertexSet) bool {
+	return e.op.leftVertexes.intersection(e.tes).isSubsetOf(s1) &&
+		e.op.rightVertexes.intersection(e.tes).isSubsetOf(s2) &&
+		e.checkRules(s1.union(s2))
+}
+
+// vertexSet is a set of base relations of the join graph, identified by their
+// ordinal in JoinOrderBuilder.vertexes.
+type vertexSet uint64
+
+// allVertexes returns the set of the first n vertexes.
+func allVertexes(n int) vertexSet {
+	if n >= maxJoinOrderVertexes {
+		return ^vertexSet(0)
+	}
+	return vertexSet(1)<<uint(n) - 1
+}
+
+func (s vertexSet) add(i int) vertexSet {
+	return s | vertexSet(1)<<uint(i)
+}
+
+func (s vertexSet) union(o vertexSet) vertexSet {
+	return s | o
+}
+
+func (s vertexSet) intersection(o vertexSet) vertexSet {
+	return s & o
+}
+
+func (s vertexSet) difference(o vertexSet) vertexSet {
+	return s &^ o
+}
+
+func (s vertexSet) intersects(o vertexSet) bool {
+	return s&o != 0
+}
+
+func (s vertexSet) isSubsetOf(o vertexSet) bool {
+	return s&^o == 0
+}
+
+func (s vertexSet) len() int {
+	return bits.OnesCount64(uint64(s))
+}
+
+func (s vertexSet) forEach(fn func(i int)) {
+	for s != 0 {
+		i := bits.TrailingZeros64(uint64(s))
+		fn(i)
+		s &^= vertexSet(1) << uint(i)
+	}
+}
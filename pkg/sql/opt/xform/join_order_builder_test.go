@@ -0,0 +1,159 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package xform
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/opt"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func makeVertexSet(vertexes ...int) vertexSet {
+	var s vertexSet
+	for _, i := range vertexes {
+		s = s.add(i)
+	}
+	return s
+}
+
+func TestVertexSet(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	s := makeVertexSet(0, 2, 63)
+	if s.len() != 3 {
+		t.Fatalf("expected 3 vertexes, got %d", s.len())
+	}
+	var got []int
+	s.forEach(func(i int) { got = append(got, i) })
+	if len(got) != 3 || got[0] != 0 || got[1] != 2 || got[2] != 63 {
+		t.Fatalf("unexpected vertexes %v", got)
+	}
+
+	o := makeVertexSet(2, 3)
+	if !s.intersects(o) || s.intersection(o) != makeVertexSet(2) {
+		t.Fatalf("expected %b and %b to intersect on vertex 2", s, o)
+	}
+	if s.difference(o) != makeVertexSet(0, 63) {
+		t.Fatalf("unexpected difference %b", s.difference(o))
+	}
+	if !makeVertexSet(2).isSubsetOf(o) || s.isSubsetOf(o) {
+		t.Fatalf("unexpected subset result")
+	}
+	if allVertexes(3) != makeVertexSet(0, 1, 2) || allVertexes(64).len() != 64 {
+		t.Fatalf("unexpected sets of all vertexes")
+	}
+}
+
+func TestJoinOrderEdgeRules(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	const a, b, c, d = 0, 1, 2, 3
+
+	// A rule whose from set intersects the TES always applies, so it extends
+	// the TES.
+	e := edge{ses: makeVertexSet(a, b), tes: makeVertexSet(a, b)}
+	e.addRule(makeVertexSet(a), makeVertexSet(c), 0)
+	if e.tes != makeVertexSet(a, b, c) || len(e.rules) != 0 {
+		t.Fatalf("expected the TES to be extended, got tes=%b rules=%v", e.tes, e.rules)
+	}
+
+	// A rule whose to set is a subset of the TES is always satisfied.
+	e = edge{ses: makeVertexSet(a, b), tes: makeVertexSet(a, b)}
+	e.addRule(makeVertexSet(c), makeVertexSet(b), 0)
+	if e.tes != makeVertexSet(a, b) || len(e.rules) != 0 {
+		t.Fatalf("expected the rule to be dropped, got tes=%b rules=%v", e.tes, e.rules)
+	}
+
+	// Other rules are checked when the edge is applied. The to set is
+	// restricted to the relations referenced by the conflicting edge.
+	e = edge{ses: makeVertexSet(a, b), tes: makeVertexSet(a, b)}
+	e.addRule(makeVertexSet(c), makeVertexSet(b, d), makeVertexSet(c, d))
+	if len(e.rules) != 1 || e.rules[0].to != makeVertexSet(d) {
+		t.Fatalf("expected a single rule C -> D, got %v", e.rules)
+	}
+	for _, tc := range []struct {
+		s        vertexSet
+		expected bool
+	}{
+		{s: makeVertexSet(a, b), expected: true},
+		{s: makeVertexSet(a, b, c), expected: false},
+		{s: makeVertexSet(a, b, c, d), expected: true},
+		{s: makeVertexSet(a, b, d), expected: true},
+	} {
+		if res := e.checkInnerJoin(tc.s); res != tc.expected {
+			t.Errorf("%b: expected %t, got %t", tc.s, tc.expected, res)
+		}
+	}
+}
+
+func TestJoinOrderNonInnerEdge(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	const a, b, c = 0, 1, 2
+
+	// A LEFT JOIN B ON A.x = B.x, with C inner joined to A.
+	op := &operator{
+		joinType:      opt.LeftJoinOp,
+		leftVertexes:  makeVertexSet(a, c),
+		rightVertexes: makeVertexSet(b),
+	}
+	e := edge{op: op, ses: makeVertexSet(a, b), tes: makeVertexSet(a, b)}
+
+	for _, tc := range []struct {
+		s1, s2   vertexSet
+		expected bool
+	}{
+		{s1: makeVertexSet(a), s2: makeVertexSet(b), expected: true},
+		{s1: makeVertexSet(a, c), s2: makeVertexSet(b), expected: true},
+		// The left join isn't commutative.
+		{s1: makeVertexSet(b), s2: makeVertexSet(a), expected: false},
+		// The left input must contain all the relations of the TES which are in
+		// the original left input.
+		{s1: makeVertexSet(c), s2: makeVertexSet(a, b), expected: false},
+	} {
+		if res := e.checkNonInnerJoin(tc.s1, tc.s2); res != tc.expected {
+			t.Errorf("%b, %b: expected %t, got %t", tc.s1, tc.s2, tc.expected, res)
+		}
+	}
+}
+
+func TestJoinOrderOperatorProperties(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ops := []opt.Operator{
+		opt.InnerJoinOp, opt.SemiJoinOp, opt.AntiJoinOp, opt.LeftJoinOp, opt.FullJoinOp,
+	}
+
+	// The expected properties, from table 3 of "On the Correct and Complete
+	// Enumeration of the Core Search Space". The equivalences which only hold
+	// if some conditions reject nulls are expected to be false.
+	leftAsscomExpected := [][]bool{
+		{true, true, true, true, false},
+		{true, true, true, true, false},
+		{true, true, true, true, false},
+		{true, true, true, true, false},
+		{false, false, false, false, false},
+	}
+	for i, a := range ops {
+		for j, b := range ops {
+			if res := leftAsscom(a, b); res != leftAsscomExpected[i][j] {
+				t.Errorf("leftAsscom(%s, %s): expected %t", a, b, leftAsscomExpected[i][j])
+			}
+			expected := a == opt.InnerJoinOp && b == opt.InnerJoinOp
+			if res := rightAsscom(a, b); res != expected {
+				t.Errorf("rightAsscom(%s, %s): expected %t", a, b, expected)
+			}
+		}
+	}
+}
@@ -293,6 +293,9 @@ func (mf *memoFormatter) formatPrivate(e opt.Expr, physProps *physical.Required)
 	case *memo.LookupJoinExpr:
 		fmt.Fprintf(mf.buf, ",keyCols=%v,outCols=%s", t.KeyCols, t.Cols)
 
+	case *memo.InvertedJoinExpr:
+		fmt.Fprintf(mf.buf, ",inputCol=%d,outCols=%s", t.InputCol, t.Cols)
+
 	case *memo.ExplainExpr:
 		propsStr := t.Props.String()
 		if propsStr != "" {
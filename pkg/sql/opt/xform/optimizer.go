@@ -87,9 +87,18 @@ type Optimizer struct {
 	// It can be set via a call to the NotifyOnAppliedRule method.
 	appliedRule AppliedRuleFunc
 
-	// disabledRules is a set of rules that are not allowed to run, used for
-	// testing.
+	// disabledRules is a set of rules that are not allowed to run. It is used
+	// for testing and to apply statement hints (see DisableRules).
 	disabledRules RuleSet
+
+	// jb is used by the ReorderJoins rule to enumerate join orders.
+	jb JoinOrderBuilder
+
+	// reorderJoinsLimit is the maximum number of relations in a join tree for
+	// which the join orders are enumerated exhaustively; larger join trees are
+	// reordered greedily. Joins are not reordered if it is zero. It defaults to
+	// the reorder_joins_limit session setting (see SetReorderJoinsLimit).
+	reorderJoinsLimit int
 }
 
 // Init initializes the Optimizer with a new, blank memo structure inside. This
@@ -104,6 +113,9 @@ func (o *Optimizer) Init(evalCtx *tree.EvalContext) {
 	o.stateMap = make(map[groupStateKey]*groupState)
 	o.matchedRule = nil
 	o.appliedRule = nil
+	o.disabledRules = RuleSet{}
+	o.jb.Init(&o.f)
+	o.reorderJoinsLimit = evalCtx.SessionData.ReorderJoinsLimit
 	if evalCtx.TestingKnobs.DisableOptimizerRuleProbability > 0 {
 		o.disableRules(evalCtx.TestingKnobs.DisableOptimizerRuleProbability)
 	}
@@ -846,25 +858,49 @@ func (a *groupStateAlloc) allocate() *groupState {
 	return state
 }
 
+// essentialRules are the rules which are never disabled, since they are needed
+// to produce a valid plan.
+var essentialRules = util.MakeFastIntSet(
+	// Needed to prevent constraint building from failing.
+	int(opt.NormalizeInConst),
+	// Needed when an index is forced.
+	int(opt.GenerateIndexScans),
+	// Needed to prevent "same fingerprint cannot map to different groups."
+	int(opt.PruneJoinLeftCols),
+	int(opt.PruneJoinRightCols),
+	// Needed to prevent stack overflow.
+	int(opt.PushFilterIntoJoinLeftAndRight),
+	int(opt.PruneSelectCols),
+	// Needed to prevent execbuilder error.
+	// TODO(radu): the DistinctOn execution path should be fixed up so it
+	// supports distinct on an empty column set.
+	int(opt.EliminateDistinctOnNoColumns),
+)
+
+// DisableRules prevents the given rules from being applied, with the exception
+// of the rules which are needed to produce a valid plan. It is used to apply
+// the disabled rules of statement hints, and must be called after Init and
+// before the memo is built, since normalization rules are applied during the
+// build.
+func (o *Optimizer) DisableRules(rules RuleSet) {
+	if rules.Empty() {
+		return
+	}
+	o.disabledRules.UnionWith(rules.Difference(essentialRules))
+	o.NotifyOnMatchedRule(func(ruleName opt.RuleName) bool {
+		return !o.disabledRules.Contains(int(ruleName))
+	})
+}
+
+// SetReorderJoinsLimit overrides the reorder_joins_limit session setting for
+// the statement being optimized. It is used to apply statement hints, and must
+// be called after Init.
+func (o *Optimizer) SetReorderJoinsLimit(limit int) {
+	o.reorderJoinsLimit = limit
+}
+
 // disableRules disables rules with the given probability for testing.
 func (o *Optimizer) disableRules(probability float64) {
-	essentialRules := util.MakeFastIntSet(
-		// Needed to prevent constraint building from failing.
-		int(opt.NormalizeInConst),
-		// Needed when an index is forced.
-		int(opt.GenerateIndexScans),
-		// Needed to prevent "same fingerprint cannot map to different groups."
-		int(opt.PruneJoinLeftCols),
-		int(opt.PruneJoinRightCols),
-		// Needed to prevent stack overflow.
-		int(opt.PushFilterIntoJoinLeftAndRight),
-		int(opt.PruneSelectCols),
-		// Needed to prevent execbuilder error.
-		// TODO(radu): the DistinctOn execution path should be fixed up so it
-		// supports distinct on an empty column set.
-		int(opt.EliminateDistinctOnNoColumns),
-	)
-
 	for i := opt.RuleName(1); i < opt.NumRuleNames; i++ {
 		if rand.Float64() < probability && !essentialRules.Contains(int(i)) {
 			o.disabledRules.Add(int(i))
@@ -232,6 +232,26 @@ func (oc *optCatalog) CheckAnyPrivilege(ctx context.Context, o cat.Object) error
 	}
 }
 
+// CheckColumnPrivileges is part of the cat.Catalog interface.
+func (oc *optCatalog) CheckColumnPrivileges(
+	ctx context.Context, tab cat.Table, priv privilege.Kind, ords util.FastIntSet,
+) error {
+	if t, ok := tab.(*optTable); ok {
+		return oc.planner.CheckColumnPrivileges(ctx, t.desc, priv, ords)
+	}
+	return oc.CheckPrivilege(ctx, tab, priv)
+}
+
+// RowLevelSecurityPolicies is part of the cat.Catalog interface.
+func (oc *optCatalog) RowLevelSecurityPolicies(
+	ctx context.Context, tab cat.Table,
+) (enforced bool, policies util.FastIntSet, err error) {
+	if t, ok := tab.(*optTable); ok {
+		return oc.planner.rowLevelSecurityPolicies(ctx, t.desc.TableDesc())
+	}
+	return false, policies, nil
+}
+
 // RequireSuperUser is part of the cat.Catalog interface.
 func (oc *optCatalog) RequireSuperUser(ctx context.Context, action string) error {
 	return oc.planner.RequireSuperUser(ctx, action)
@@ -828,6 +848,22 @@ func (ot *optTable) InboundForeignKey(i int) cat.ForeignKeyConstraint {
 	return &ot.inboundFKs[i]
 }
 
+// PolicyCount is part of the cat.Table interface.
+func (ot *optTable) PolicyCount() int {
+	return len(ot.desc.Policies)
+}
+
+// Policy is part of the cat.Table interface.
+func (ot *optTable) Policy(i int) cat.Policy {
+	policy := &ot.desc.Policies[i]
+	return cat.Policy{
+		Name:          tree.Name(policy.Name),
+		Command:       policyCommandPrivileges[policy.Command],
+		UsingExpr:     policy.UsingExpr,
+		WithCheckExpr: policy.WithCheckExpr,
+	}
+}
+
 // lookupColumnOrdinal returns the ordinal of the column with the given ID. A
 // cache makes the lookup O(1).
 func (ot *optTable) lookupColumnOrdinal(colID sqlbase.ColumnID) (int, error) {
@@ -853,6 +889,9 @@ type optIndex struct {
 	numCols       int
 	numKeyCols    int
 	numLaxKeyCols int
+
+	// partitions are the top-level PARTITION BY LIST partitions of the index.
+	partitions []optPartition
 }
 
 var _ cat.Index = &optIndex{}
@@ -913,6 +952,53 @@ func (oi *optIndex) init(tab *optTable, desc *sqlbase.IndexDescriptor, zone *con
 		oi.numLaxKeyCols = len(desc.ColumnIDs) + len(desc.ExtraColumnIDs)
 		oi.numKeyCols = oi.numLaxKeyCols
 	}
+
+	oi.initPartitions()
+}
+
+// initPartitions builds the wrappers for the PARTITION BY LIST partitions of
+// the index, along with the zones that apply to them.
+func (oi *optIndex) initPartitions() {
+	partDesc := &oi.desc.Partitioning
+	if len(partDesc.List) == 0 {
+		return
+	}
+	var a sqlbase.DatumAlloc
+	oi.partitions = make([]optPartition, len(partDesc.List))
+	for i := range partDesc.List {
+		listDesc := &partDesc.List[i]
+		p := &oi.partitions[i]
+		p.name = listDesc.Name
+
+		// If there is a subzone that applies to the partition, use that, else
+		// use the index zone.
+		p.zone = oi.zone
+		for j := range oi.tab.zone.Subzones {
+			subzone := &oi.tab.zone.Subzones[j]
+			if subzone.IndexID == uint32(oi.desc.ID) && subzone.PartitionName == p.name {
+				copyZone := subzone.Config
+				copyZone.InheritFromParent(oi.zone)
+				p.zone = &copyZone
+			}
+		}
+
+		for _, values := range listDesc.Values {
+			tuple, _, err := sqlbase.DecodePartitionTuple(
+				&a, oi.tab.desc.TableDesc(), oi.desc, partDesc, values, nil, /* prefixDatums */
+			)
+			if err != nil {
+				// The values were validated when the partitioning was created, so
+				// this should never happen. Ignore the value, since the partition is
+				// only used to find a better plan.
+				continue
+			}
+			if len(tuple.Datums) == 0 {
+				// Skip the DEFAULT value, which isn't a specific prefix.
+				continue
+			}
+			p.prefixes = append(p.prefixes, tuple.Datums)
+		}
+	}
 }
 
 // ID is part of the cat.Index interface.
@@ -995,6 +1081,41 @@ func (oi *optIndex) Table() cat.Table {
 	return oi.tab
 }
 
+// PartitionCount is part of the cat.Index interface.
+func (oi *optIndex) PartitionCount() int {
+	return len(oi.partitions)
+}
+
+// Partition is part of the cat.Index interface.
+func (oi *optIndex) Partition(i int) cat.Partition {
+	return &oi.partitions[i]
+}
+
+// optPartition is a wrapper around sqlbase.PartitioningDescriptor_List that
+// keeps the decoded values of the partition along with its zone.
+type optPartition struct {
+	name     string
+	zone     *config.ZoneConfig
+	prefixes []tree.Datums
+}
+
+var _ cat.Partition = &optPartition{}
+
+// Name is part of the cat.Partition interface.
+func (op *optPartition) Name() string {
+	return op.name
+}
+
+// Zone is part of the cat.Partition interface.
+func (op *optPartition) Zone() cat.Zone {
+	return op.zone
+}
+
+// PartitionByListPrefixes is part of the cat.Partition interface.
+func (op *optPartition) PartitionByListPrefixes() []tree.Datums {
+	return op.prefixes
+}
+
 type optTableStat struct {
 	createdAt      time.Time
 	columnOrdinals []int
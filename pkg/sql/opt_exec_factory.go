@@ -64,6 +64,21 @@ func (ef *execFactory) ConstructValues(
 	}, nil
 }
 
+// initScan initializes a scanNode on the given table. initTable checks that
+// the current user has the correct privilege to access the table. However, the
+// privilege has already been checked in optbuilder, and does not need to be
+// rechecked. In fact, it's an error to check the privilege if the table was
+// originally part of a view, since lower privilege users might be able to
+// access a view that uses a higher privilege table, or if the privilege is only
+// held on the columns of the table used by the query.
+func (ef *execFactory) initScan(
+	scan *scanNode, tabDesc *sqlbase.ImmutableTableDescriptor, colCfg scanColumnsConfig,
+) error {
+	ef.planner.skipSelectPrivilegeChecks = true
+	defer func() { ef.planner.skipSelectPrivilegeChecks = false }()
+	return scan.initTable(context.TODO(), ef.planner, tabDesc, nil /* indexFlags */, colCfg)
+}
+
 // ConstructScan is part of the exec.Factory interface.
 func (ef *execFactory) ConstructScan(
 	table cat.Table,
@@ -81,14 +96,7 @@ func (ef *execFactory) ConstructScan(
 	scan := ef.planner.Scan()
 	colCfg := makeScanColumnsConfig(table, needed)
 
-	// initTable checks that the current user has the correct privilege to access
-	// the table. However, the privilege has already been checked in optbuilder,
-	// and does not need to be rechecked. In fact, it's an error to check the
-	// privilege if the table was originally part of a view, since lower privilege
-	// users might be able to access a view that uses a higher privilege table.
-	ef.planner.skipSelectPrivilegeChecks = true
-	defer func() { ef.planner.skipSelectPrivilegeChecks = false }()
-	if err := scan.initTable(context.TODO(), ef.planner, tabDesc, nil, colCfg); err != nil {
+	if err := ef.initScan(scan, tabDesc, colCfg); err != nil {
 		return nil, err
 	}
 
@@ -506,6 +514,13 @@ func (ef *execFactory) ConstructSetOp(
 	return ef.planner.newUnionNode(typ, all, left.(planNode), right.(planNode))
 }
 
+// ConstructLocalityOptimizedSearch is part of the exec.Factory interface.
+func (ef *execFactory) ConstructLocalityOptimizedSearch(
+	local, remote exec.Node, hardLimit int64,
+) (exec.Node, error) {
+	return ef.planner.newLocalityOptimizedSearchNode(local.(planNode), remote.(planNode), hardLimit)
+}
+
 // ConstructSort is part of the exec.Factory interface.
 func (ef *execFactory) ConstructSort(
 	input exec.Node, ordering sqlbase.ColumnOrdering,
@@ -569,7 +584,7 @@ func (ef *execFactory) ConstructIndexJoin(
 
 	tableScan := ef.planner.Scan()
 
-	if err := tableScan.initTable(context.TODO(), ef.planner, tabDesc, nil, colCfg); err != nil {
+	if err := ef.initScan(tableScan, tabDesc, colCfg); err != nil {
 		return nil, err
 	}
 
@@ -613,7 +628,7 @@ func (ef *execFactory) ConstructLookupJoin(
 	colCfg := makeScanColumnsConfig(table, lookupCols)
 	tableScan := ef.planner.Scan()
 
-	if err := tableScan.initTable(context.TODO(), ef.planner, tabDesc, nil, colCfg); err != nil {
+	if err := ef.initScan(tableScan, tabDesc, colCfg); err != nil {
 		return nil, err
 	}
 
@@ -643,6 +658,39 @@ func (ef *execFactory) ConstructLookupJoin(
 	return n, nil
 }
 
+// ConstructInvertedJoin is part of the exec.Factory interface.
+func (ef *execFactory) ConstructInvertedJoin(
+	input exec.Node,
+	table cat.Table,
+	index cat.Index,
+	inputCol exec.ColumnOrdinal,
+	lookupCols exec.ColumnOrdinalSet,
+) (exec.Node, error) {
+	tabDesc := table.(*optTable).desc
+	indexDesc := index.(*optIndex).desc
+	colCfg := makeScanColumnsConfig(table, lookupCols)
+	tableScan := ef.planner.Scan()
+
+	if err := ef.initScan(tableScan, tabDesc, colCfg); err != nil {
+		return nil, err
+	}
+
+	tableScan.index = indexDesc
+	tableScan.isSecondaryIndex = true
+
+	n := &invertedJoinNode{
+		input:    input.(planNode),
+		table:    tableScan,
+		inputCol: int(inputCol),
+	}
+	inputCols := planColumns(input.(planNode))
+	scanCols := planColumns(tableScan)
+	n.columns = make(sqlbase.ResultColumns, 0, len(inputCols)+len(scanCols))
+	n.columns = append(n.columns, inputCols...)
+	n.columns = append(n.columns, scanCols...)
+	return n, nil
+}
+
 // Helper function to create a scanNode from just a table / index descriptor
 // and requested cols.
 func (ef *execFactory) constructScanForZigzag(
@@ -660,7 +708,7 @@ func (ef *execFactory) constructScanForZigzag(
 	}
 
 	scan := ef.planner.Scan()
-	if err := scan.initTable(context.TODO(), ef.planner, tableDesc, nil, colCfg); err != nil {
+	if err := ef.initScan(scan, tableDesc, colCfg); err != nil {
 		return nil, err
 	}
 
@@ -1097,6 +1145,18 @@ func (ef *execFactory) ConstructExplain(
 			stmtType:      stmtType,
 		}, nil
 
+	case tree.ExplainDebug:
+		if !analyzeSet {
+			return nil, errors.New("EXPLAIN (DEBUG) only supported with the ANALYZE option")
+		}
+		return &explainDistSQLNode{
+			plan:          p.plan,
+			subqueryPlans: p.subqueryPlans,
+			analyze:       true,
+			debug:         true,
+			stmtType:      stmtType,
+		}, nil
+
 	case tree.ExplainPlan:
 		if analyzeSet {
 			return nil, errors.New("EXPLAIN ANALYZE only supported with (DISTSQL) option")
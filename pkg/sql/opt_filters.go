@@ -386,11 +386,15 @@ func (p *planner) propagateFilters(
 	case *truncateNode:
 	case *commentOnColumnNode:
 	case *commentOnDatabaseNode:
+	case *createStmtHintsNode:
+	case *dropStmtHintsNode:
+	case *notifyNode:
 	case *commentOnTableNode:
 	case *createDatabaseNode:
 	case *createIndexNode:
 	case *CreateUserNode:
 	case *createViewNode:
+	case *createPolicyNode:
 	case *createSequenceNode:
 	case *createStatsNode:
 	case *deleteRangeNode:
@@ -398,6 +402,7 @@ func (p *planner) propagateFilters(
 	case *dropIndexNode:
 	case *dropTableNode:
 	case *dropViewNode:
+	case *dropPolicyNode:
 	case *dropSequenceNode:
 	case *DropUserNode:
 	case *hookFnNode:
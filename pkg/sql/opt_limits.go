@@ -218,17 +218,22 @@ func (p *planner) applyLimit(plan planNode, numRows int64, soft bool) {
 	case *truncateNode:
 	case *commentOnColumnNode:
 	case *commentOnDatabaseNode:
+	case *createStmtHintsNode:
+	case *dropStmtHintsNode:
+	case *notifyNode:
 	case *commentOnTableNode:
 	case *createDatabaseNode:
 	case *createIndexNode:
 	case *CreateUserNode:
 	case *createViewNode:
+	case *createPolicyNode:
 	case *createSequenceNode:
 	case *createStatsNode:
 	case *dropDatabaseNode:
 	case *dropIndexNode:
 	case *dropTableNode:
 	case *dropViewNode:
+	case *dropPolicyNode:
 	case *dropSequenceNode:
 	case *DropUserNode:
 	case *zeroNode:
@@ -243,7 +248,7 @@ func (p *planner) applyLimit(plan planNode, numRows int64, soft bool) {
 	case *scatterNode:
 	case *scanBufferNode:
 
-	case *applyJoinNode, *lookupJoinNode, *zigzagJoinNode, *saveTableNode:
+	case *applyJoinNode, *lookupJoinNode, *invertedJoinNode, *zigzagJoinNode, *saveTableNode:
 		// These nodes are only planned by the optimizer.
 
 	default:
@@ -274,17 +274,22 @@ func setNeededColumns(plan planNode, needed []bool) {
 	case *truncateNode:
 	case *commentOnColumnNode:
 	case *commentOnDatabaseNode:
+	case *createStmtHintsNode:
+	case *dropStmtHintsNode:
+	case *notifyNode:
 	case *commentOnTableNode:
 	case *createDatabaseNode:
 	case *createIndexNode:
 	case *CreateUserNode:
 	case *createViewNode:
+	case *createPolicyNode:
 	case *createSequenceNode:
 	case *createStatsNode:
 	case *dropDatabaseNode:
 	case *dropIndexNode:
 	case *dropTableNode:
 	case *dropViewNode:
+	case *dropPolicyNode:
 	case *dropSequenceNode:
 	case *DropUserNode:
 	case *zeroNode:
@@ -113,6 +113,12 @@ func TestContextualHelp(t *testing.T) {
 
 		{`CREATE STATISTICS ??`, `CREATE STATISTICS`},
 
+		{`CREATE STATEMENT ??`, `CREATE STATEMENT HINTS`},
+		{`CREATE STATEMENT HINTS FOR 'SELECT 1' ??`, `CREATE STATEMENT HINTS`},
+
+		{`CREATE POLICY ??`, `CREATE POLICY`},
+		{`CREATE POLICY p ON t FOR SELECT ??`, `CREATE POLICY`},
+
 		{`CREATE TABLE blah (??`, `CREATE TABLE`},
 		{`CREATE TABLE IF NOT ??`, `CREATE TABLE`},
 		{`CREATE TABLE blah (x, y) AS ??`, `CREATE TABLE`},
@@ -157,6 +163,12 @@ func TestContextualHelp(t *testing.T) {
 		{`DROP USER IF ??`, `DROP USER`},
 		{`DROP USER IF EXISTS bloh ??`, `DROP USER`},
 
+		{`DROP STATEMENT ??`, `DROP STATEMENT HINTS`},
+		{`DROP STATEMENT HINTS IF ??`, `DROP STATEMENT HINTS`},
+
+		{`DROP POLICY ??`, `DROP POLICY`},
+		{`DROP POLICY IF ??`, `DROP POLICY`},
+
 		{`EXPLAIN (??`, `EXPLAIN`},
 		{`EXPLAIN SELECT 1 ??`, `SELECT`},
 		{`EXPLAIN INSERT INTO xx (SELECT 1) ??`, `INSERT`},
@@ -181,6 +193,20 @@ func TestContextualHelp(t *testing.T) {
 		{`DEALLOCATE ALL ??`, `DEALLOCATE`},
 		{`DEALLOCATE PREPARE ??`, `DEALLOCATE`},
 
+		{`DECLARE ??`, `DECLARE`},
+		{`DECLARE c ??`, `DECLARE`},
+
+		{`FETCH ??`, `FETCH`},
+		{`FETCH 10 ??`, `FETCH`},
+
+		{`CLOSE ??`, `CLOSE`},
+		{`CLOSE c ??`, `CLOSE`},
+
+		{`LISTEN ??`, `LISTEN`},
+		{`UNLISTEN ??`, `UNLISTEN`},
+		{`NOTIFY ??`, `NOTIFY`},
+		{`NOTIFY c ??`, `NOTIFY`},
+
 		{`INSERT INTO ??`, `INSERT`},
 		{`INSERT INTO blah (??`, `<SELECTCLAUSE>`},
 		{`INSERT INTO blah VALUES (1) RETURNING ??`, `INSERT`},
@@ -204,6 +230,7 @@ func TestContextualHelp(t *testing.T) {
 		{`GRANT ALL ??`, `GRANT`},
 		{`GRANT ALL ON foo TO ??`, `GRANT`},
 		{`GRANT ALL ON foo TO bar ??`, `GRANT`},
+		{`GRANT SELECT (a) ON foo TO ??`, `GRANT`},
 
 		{`PAUSE ??`, `PAUSE JOBS`},
 
@@ -212,6 +239,7 @@ func TestContextualHelp(t *testing.T) {
 		{`REVOKE ALL ??`, `REVOKE`},
 		{`REVOKE ALL ON foo FROM ??`, `REVOKE`},
 		{`REVOKE ALL ON foo FROM bar ??`, `REVOKE`},
+		{`REVOKE SELECT (a) ON foo FROM ??`, `REVOKE`},
 
 		{`SELECT * FROM ??`, `<SOURCE>`},
 		{`SELECT * FROM (??`, `<SOURCE>`}, // not <selectclause>! joins are allowed.
@@ -231,6 +259,8 @@ func TestContextualHelp(t *testing.T) {
 		{`SHOW SESSIONS ??`, `SHOW SESSIONS`},
 		{`SHOW LOCAL SESSIONS ??`, `SHOW SESSIONS`},
 
+		{`SHOW STATEMENT ??`, `SHOW STATEMENT HINTS`},
+
 		{`SHOW STATISTICS ??`, `SHOW STATISTICS`},
 		{`SHOW STATISTICS FOR TABLE ??`, `SHOW STATISTICS`},
 
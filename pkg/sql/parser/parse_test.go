@@ -297,6 +297,11 @@ func TestParse(t *testing.T) {
 		{`CREATE STATISTICS a ON col1 FROM t WITH OPTIONS AS OF SYSTEM TIME '2016-01-01'`},
 		{`CREATE STATISTICS a ON col1 FROM t WITH OPTIONS THROTTLING 0.1 AS OF SYSTEM TIME '2016-01-01'`},
 
+		{`CREATE STATEMENT HINTS FOR 'SELECT * FROM t WHERE k = _' AS '{"index": {"t": "t_idx"}}'`},
+		{`DROP STATEMENT HINTS FOR 'SELECT * FROM t WHERE k = _'`},
+		{`DROP STATEMENT HINTS IF EXISTS FOR 'SELECT * FROM t WHERE k = _'`},
+		{`SHOW STATEMENT HINTS`},
+
 		{`DELETE FROM a`},
 		{`EXPLAIN DELETE FROM a`},
 		{`DELETE FROM a.b`},
@@ -538,6 +543,21 @@ func TestParse(t *testing.T) {
 		{`DEALLOCATE a`},
 		{`DEALLOCATE ALL`},
 
+		{`DECLARE c CURSOR FOR SELECT a FROM t`},
+		{`DECLARE c CURSOR FOR SELECT $1 FROM t ORDER BY a LIMIT 10`},
+		{`FETCH 10 FROM c`},
+		{`FETCH ALL FROM c`},
+		{`CLOSE c`},
+		{`CLOSE ALL`},
+
+		{`LISTEN c`},
+		{`UNLISTEN c`},
+		{`UNLISTEN *`},
+		{`NOTIFY c`},
+		{`NOTIFY c, 'hello'`},
+		{`NOTIFY c, e'it\'s'`},
+		{`NOTIFY "Foo", 'bar'`},
+
 		// Tables are the default, but can also be specified with
 		// GRANT x ON TABLE y. However, the stringer does not output TABLE.
 		{`GRANT SELECT ON TABLE foo TO root`},
@@ -547,6 +567,8 @@ func TestParse(t *testing.T) {
 		{`GRANT SELECT, INSERT ON DATABASE bar TO foo, bar, baz`},
 		{`GRANT SELECT, INSERT ON DATABASE db1, db2 TO foo, bar, baz`},
 		{`GRANT SELECT, INSERT ON DATABASE db1, db2 TO "test-user"`},
+		{`GRANT SELECT (a, b) ON TABLE foo TO root`},
+		{`GRANT SELECT, INSERT (a), UPDATE (b, c) ON TABLE foo, db.foo TO root, bar`},
 		{`GRANT rolea, roleb TO usera, userb`},
 		{`GRANT rolea, roleb TO usera, userb WITH ADMIN OPTION`},
 
@@ -558,6 +580,8 @@ func TestParse(t *testing.T) {
 		{`REVOKE ALL ON DATABASE foo FROM root, test`},
 		{`REVOKE SELECT, INSERT ON DATABASE bar FROM foo, bar, baz`},
 		{`REVOKE SELECT, INSERT ON DATABASE db1, db2 FROM foo, bar, baz`},
+		{`REVOKE SELECT (a, b) ON TABLE foo FROM root`},
+		{`REVOKE DELETE, SELECT (a), UPDATE (b) ON TABLE foo FROM root, bar`},
 		{`REVOKE rolea, roleb FROM usera, userb`},
 		{`REVOKE ADMIN OPTION FOR rolea, roleb FROM usera, userb`},
 
@@ -1098,6 +1122,15 @@ func TestParse(t *testing.T) {
 
 		{`COPY t FROM STDIN`},
 		{`COPY t (a, b, c) FROM STDIN`},
+		{`COPY t FROM STDIN WITH (FORMAT csv)`},
+		{`COPY t FROM STDIN WITH (FORMAT binary)`},
+		{`COPY t (a, b) FROM STDIN WITH (FORMAT csv, DELIMITER '|', NULL 'null', HEADER true, QUOTE '"', ESCAPE '"')`},
+		{`COPY t FROM STDIN WITH (DELIMITER e'\t', NULL '')`},
+		{`COPY t TO STDOUT`},
+		{`COPY t (a, b) TO STDOUT WITH (FORMAT text)`},
+		{`COPY t TO STDOUT WITH (FORMAT csv, HEADER false)`},
+		{`COPY (SELECT a FROM t WHERE b > 1) TO STDOUT WITH (FORMAT binary)`},
+		{`COPY (SELECT $1) TO STDOUT`},
 
 		{`ALTER TABLE a SPLIT AT VALUES (1)`},
 		{`EXPLAIN ALTER TABLE a SPLIT AT VALUES (1)`},
@@ -1213,6 +1246,16 @@ func TestParse(t *testing.T) {
 		{`EXPLAIN ALTER TABLE t EXPERIMENTAL_AUDIT SET READ WRITE`},
 		{`ALTER TABLE t EXPERIMENTAL_AUDIT SET OFF`},
 
+		{`ALTER TABLE t ENABLE ROW LEVEL SECURITY`},
+		{`ALTER TABLE t DISABLE ROW LEVEL SECURITY`},
+		{`CREATE POLICY p ON t FOR ALL USING (owner = current_user())`},
+		{`CREATE POLICY p ON db.t FOR SELECT TO alice, bob USING (tenant = 1)`},
+		{`CREATE POLICY p ON t FOR INSERT WITH CHECK (tenant = 1)`},
+		{`CREATE POLICY p ON t FOR UPDATE TO alice USING (a > 0) WITH CHECK (a > 1)`},
+		{`CREATE POLICY p ON t FOR DELETE USING (a IN (SELECT a FROM u))`},
+		{`DROP POLICY p ON t`},
+		{`DROP POLICY IF EXISTS p ON db.t`},
+
 		{`COMMENT ON COLUMN a.b IS 'a'`},
 		{`COMMENT ON COLUMN a.b IS NULL`},
 		{`COMMENT ON COLUMN a.b.c IS 'a'`},
@@ -1721,6 +1764,18 @@ func TestParse2(t *testing.T) {
 		{`DEALLOCATE PREPARE ALL`,
 			`DEALLOCATE ALL`},
 
+		{`DECLARE c CURSOR WITHOUT HOLD FOR SELECT 1`,
+			`DECLARE c CURSOR FOR SELECT 1`},
+		{`FETCH c`, `FETCH 1 FROM c`},
+		{`FETCH IN c`, `FETCH 1 FROM c`},
+		{`FETCH NEXT FROM c`, `FETCH 1 FROM c`},
+		{`FETCH FORWARD c`, `FETCH 1 FROM c`},
+		{`FETCH forward`, `FETCH 1 FROM forward`},
+		{`FETCH 5 c`, `FETCH 5 FROM c`},
+		{`FETCH FORWARD 5 IN c`, `FETCH 5 FROM c`},
+		{`FETCH FORWARD ALL c`, `FETCH ALL FROM c`},
+		{`NOTIFY c, ''`, `NOTIFY c`},
+
 		{`CANCEL JOB a`, `CANCEL JOBS VALUES (a)`},
 		{`RESUME JOB a`, `RESUME JOBS VALUES (a)`},
 		{`PAUSE JOB a`, `PAUSE JOBS VALUES (a)`},
@@ -1806,6 +1861,20 @@ func TestParse2(t *testing.T) {
 		{`ALTER INDEX i CONFIGURE ZONE USING foo = COPY FROM PARENT`,
 			`ALTER INDEX i CONFIGURE ZONE USING foo = COPY FROM PARENT`},
 
+		// Options of COPY.
+		{`COPY t FROM STDIN CSV HEADER`,
+			`COPY t FROM STDIN WITH (FORMAT csv, HEADER true)`},
+		{`COPY t FROM STDIN WITH CSV DELIMITER AS '|' NULL AS ''`,
+			`COPY t FROM STDIN WITH (FORMAT csv, DELIMITER '|', NULL '')`},
+		{`COPY t FROM STDIN BINARY`,
+			`COPY t FROM STDIN WITH (FORMAT binary)`},
+		{`COPY t TO STDOUT (header on, format 'csv')`,
+			`COPY t TO STDOUT WITH (FORMAT csv, HEADER true)`},
+		{`COPY t TO STDOUT WITH (FORMAT csv, HEADER)`,
+			`COPY t TO STDOUT WITH (FORMAT csv, HEADER true)`},
+		{`COPY ((SELECT 1)) TO STDOUT WITH DELIMITER ','`,
+			`COPY ((SELECT 1)) TO STDOUT WITH (DELIMITER ',')`},
+
 		// Alternative forms for table patterns.
 
 		{`SHOW GRANTS ON foo`,
@@ -1851,6 +1920,12 @@ func TestParse2(t *testing.T) {
 			`GRANT SELECT ON TABLE role TO root`},
 		{`REVOKE SELECT ON foo FROM root`,
 			`REVOKE SELECT ON TABLE foo FROM root`},
+		{`GRANT SELECT (a), INSERT, UPDATE (a) ON foo TO root`,
+			`GRANT INSERT, SELECT (a), UPDATE (a) ON TABLE foo TO root`},
+		{`REVOKE UPDATE (a), DELETE ON foo FROM root`,
+			`REVOKE DELETE, UPDATE (a) ON TABLE foo FROM root`},
+		{`CREATE POLICY p ON t USING (true)`,
+			`CREATE POLICY p ON t FOR ALL USING (true)`},
 		{`REVOKE UPDATE, DELETE ON foo, db.foo FROM root, bar`,
 			`REVOKE UPDATE, DELETE ON TABLE foo, db.foo FROM root, bar`},
 
@@ -2445,6 +2520,13 @@ SELECT 1 + ANY ARRAY[1, 2, 3]
 			`syntax error: type does not exist at or near "blah"
 SELECT 'f'::"blah"
             ^
+`,
+		},
+		{
+			`GRANT DELETE (a) ON foo TO bar`,
+			`syntax error: invalid privilege type DELETE for column at or near ")"
+GRANT DELETE (a) ON foo TO bar
+                ^
 `,
 		},
 		// Ensure that the support for ON ROLE <namelist> doesn't leak
@@ -2857,6 +2939,8 @@ func TestUnimplementedSyntax(t *testing.T) {
 		{`CREATE TEXT SEARCH a`, 7821, `create text`},
 		{`CREATE TRIGGER a`, 28296, `create`},
 
+		{`DECLARE a CURSOR WITH HOLD FOR SELECT 1`, 0, `declare with hold`},
+
 		{`DROP AGGREGATE a`, 0, `drop aggregate`},
 		{`DROP CAST a`, 0, `drop cast`},
 		{`DROP COLLATION a`, 0, `drop collation`},
@@ -39,6 +39,21 @@ const MaxUint = ^uint(0)
 // MaxInt is the maximum value of an int.
 const MaxInt = int(MaxUint >> 1)
 
+// splitColumnPrivileges separates the privileges of a column_privileges list
+// which apply to whole targets from those which apply to specific columns.
+func splitColumnPrivileges(l tree.ColumnPrivileges) (privilege.List, tree.ColumnPrivileges) {
+    var privs privilege.List
+    var colPrivs tree.ColumnPrivileges
+    for _, p := range l {
+        if p.Columns == nil {
+            privs = append(privs, p.Privilege)
+        } else {
+            colPrivs = append(colPrivs, p)
+        }
+    }
+    return privs, colPrivs
+}
+
 func unimplemented(sqllex sqlLexer, feature string) int {
     sqllex.(*lexer).Unimplemented(feature)
     return 1
@@ -311,6 +326,15 @@ func (u *sqlSymUnion) privilegeType() privilege.Kind {
 func (u *sqlSymUnion) privilegeList() privilege.List {
     return u.val.(privilege.List)
 }
+func (u *sqlSymUnion) privilegeKind() privilege.Kind {
+    return u.val.(privilege.Kind)
+}
+func (u *sqlSymUnion) columnPrivilege() tree.ColumnPrivilege {
+    return u.val.(tree.ColumnPrivilege)
+}
+func (u *sqlSymUnion) columnPrivileges() tree.ColumnPrivileges {
+    return u.val.(tree.ColumnPrivileges)
+}
 func (u *sqlSymUnion) onConflict() *tree.OnConflict {
     return u.val.(*tree.OnConflict)
 }
@@ -425,6 +449,12 @@ func (u *sqlSymUnion) kvOptions() []tree.KVOption {
     }
     return nil
 }
+func (u *sqlSymUnion) copyOptions() tree.CopyOptions {
+    return u.val.(tree.CopyOptions)
+}
+func (u *sqlSymUnion) fetchCursor() *tree.FetchCursor {
+    return u.val.(*tree.FetchCursor)
+}
 func (u *sqlSymUnion) transactionModes() tree.TransactionModes {
     return u.val.(tree.TransactionModes)
 }
@@ -478,23 +508,23 @@ func newNameFromStr(s string) *tree.Name {
 %token <str> ALL ALTER ANALYSE ANALYZE AND ANY ANNOTATE_TYPE ARRAY AS ASC
 %token <str> ASYMMETRIC AT AUTOMATIC
 
-%token <str> BACKUP BEGIN BETWEEN BIGINT BIGSERIAL BIT
+%token <str> BACKUP BEGIN BETWEEN BIGINT BIGSERIAL BINARY BIT
 %token <str> BLOB BOOL BOOLEAN BOTH BY BYTEA BYTES
 
 %token <str> CACHE CANCEL CASCADE CASE CAST CHANGEFEED CHAR
 %token <str> CHARACTER CHARACTERISTICS CHECK
-%token <str> CLUSTER COALESCE COLLATE COLLATION COLUMN COLUMNS COMMENT COMMIT
+%token <str> CLOSE CLUSTER COALESCE COLLATE COLLATION COLUMN COLUMNS COMMENT COMMIT
 %token <str> COMMITTED COMPACT CONCAT CONFIGURATION CONFIGURATIONS CONFIGURE
 %token <str> CONFLICT CONSTRAINT CONSTRAINTS CONTAINS CONVERSION COPY COVERING CREATE
-%token <str> CROSS CUBE CURRENT CURRENT_CATALOG CURRENT_DATE CURRENT_SCHEMA
+%token <str> CROSS CSV CUBE CURRENT CURRENT_CATALOG CURRENT_DATE CURRENT_SCHEMA
 %token <str> CURRENT_ROLE CURRENT_TIME CURRENT_TIMESTAMP
-%token <str> CURRENT_USER CYCLE
+%token <str> CURRENT_USER CURSOR CYCLE
 
 %token <str> DATA DATABASE DATABASES DATE DAY DEC DECIMAL DEFAULT
-%token <str> DEALLOCATE DEFERRABLE DEFERRED DELETE DESC
-%token <str> DISCARD DISTINCT DO DOMAIN DOUBLE DROP
+%token <str> DEALLOCATE DECLARE DEFERRABLE DEFERRED DELETE DELIMITER DESC
+%token <str> DISABLE DISCARD DISTINCT DO DOMAIN DOUBLE DROP
 
-%token <str> ELSE ENCODING END ENUM ESCAPE EXCEPT
+%token <str> ELSE ENABLE ENCODING END ENUM ESCAPE EXCEPT
 %token <str> EXISTS EXECUTE EXPERIMENTAL
 %token <str> EXPERIMENTAL_FINGERPRINTS EXPERIMENTAL_REPLICA
 %token <str> EXPERIMENTAL_AUDIT
@@ -502,11 +532,11 @@ func newNameFromStr(s string) *tree.Name {
 
 %token <str> FALSE FAMILY FETCH FETCHVAL FETCHTEXT FETCHVAL_PATH FETCHTEXT_PATH
 %token <str> FILES FILTER
-%token <str> FIRST FLOAT FLOAT4 FLOAT8 FLOORDIV FOLLOWING FOR FORCE_INDEX FOREIGN FROM FULL FUNCTION
+%token <str> FIRST FLOAT FLOAT4 FLOAT8 FLOORDIV FOLLOWING FOR FORCE_INDEX FOREIGN FORWARD FROM FULL FUNCTION
 
 %token <str> GLOBAL GRANT GRANTS GREATEST GROUP GROUPING GROUPS
 
-%token <str> HAVING HASH HIGH HISTOGRAM HOUR
+%token <str> HAVING HASH HEADER HIGH HINTS HISTOGRAM HOLD HOUR
 
 %token <str> IF IFERROR IFNULL IGNORE_FOREIGN_KEYS ILIKE IMMEDIATE IMPORT IN INCREMENT INCREMENTAL
 %token <str> INET INET_CONTAINED_BY_OR_EQUALS INET_CONTAINS_OR_CONTAINED_BY
@@ -519,19 +549,19 @@ func newNameFromStr(s string) *tree.Name {
 %token <str> KEY KEYS KV
 
 %token <str> LANGUAGE LATERAL LC_CTYPE LC_COLLATE
-%token <str> LEADING LEASE LEAST LEFT LESS LEVEL LIKE LIMIT LIST LOCAL
+%token <str> LEADING LEASE LEAST LEFT LESS LEVEL LIKE LIMIT LIST LISTEN LOCAL
 %token <str> LOCALTIME LOCALTIMESTAMP LOOKUP LOW LSHIFT
 
 %token <str> MATCH MATERIALIZED MERGE MINVALUE MAXVALUE MINUTE MONTH
 
 %token <str> NAN NAME NAMES NATURAL NEXT NO NO_INDEX_JOIN NORMAL
-%token <str> NOT NOTHING NOTNULL NULL NULLIF NUMERIC
+%token <str> NOT NOTHING NOTIFY NOTNULL NULL NULLIF NUMERIC
 
 %token <str> OF OFF OFFSET OID OIDS OIDVECTOR ON ONLY OPT OPTION OPTIONS OR
 %token <str> ORDER ORDINALITY OUT OUTER OVER OVERLAPS OVERLAY OWNED OPERATOR
 
 %token <str> PARENT PARTIAL PARTITION PASSWORD PAUSE PHYSICAL PLACING
-%token <str> PLAN PLANS POSITION PRECEDING PRECISION PREPARE PRIMARY PRIORITY
+%token <str> PLAN PLANS POLICY POSITION PRECEDING PRECISION PREPARE PRIMARY PRIORITY
 %token <str> PROCEDURAL PUBLICATION
 
 %token <str> QUERIES QUERY
@@ -542,12 +572,12 @@ func newNameFromStr(s string) *tree.Name {
 %token <str> RELEASE RESET RESTORE RESTRICT RESUME RETURNING REVOKE RIGHT
 %token <str> ROLE ROLES ROLLBACK ROLLUP ROW ROWS RSHIFT RULE
 
-%token <str> SAVEPOINT SCATTER SCHEMA SCHEMAS SCRUB SEARCH SECOND SELECT SEQUENCE SEQUENCES
+%token <str> SAVEPOINT SCATTER SCHEMA SCHEMAS SCRUB SEARCH SECOND SECURITY SELECT SEQUENCE SEQUENCES
 %token <str> SERIAL SERIAL2 SERIAL4 SERIAL8
 %token <str> SERIALIZABLE SERVER SESSION SESSIONS SESSION_USER SET SETTING SETTINGS
 %token <str> SHOW SIMILAR SIMPLE SMALLINT SMALLSERIAL SNAPSHOT SOME SPLIT SQL
 
-%token <str> START STATISTICS STATUS STDIN STRICT STRING STORE STORED STORING SUBSTRING
+%token <str> START STATEMENT STATISTICS STATUS STDIN STDOUT STRICT STRING STORE STORED STORING SUBSTRING
 %token <str> SYMMETRIC SYNTAX SYSTEM SUBSCRIPTION
 
 %token <str> TABLE TABLES TEMP TEMPLATE TEMPORARY TESTING_RANGES EXPERIMENTAL_RANGES TESTING_RELOCATE EXPERIMENTAL_RELOCATE TEXT THEN
@@ -555,7 +585,7 @@ func newNameFromStr(s string) *tree.Name {
 %token <str> TRUNCATE TRUSTED TYPE
 %token <str> TRACING
 
-%token <str> UNBOUNDED UNCOMMITTED UNION UNIQUE UNKNOWN UNLOGGED UNSPLIT
+%token <str> UNBOUNDED UNCOMMITTED UNION UNIQUE UNKNOWN UNLISTEN UNLOGGED UNSPLIT
 %token <str> UPDATE UPSERT USE USER USERS USING UUID
 
 %token <str> VALID VALIDATE VALUE VALUES VARBIT VARCHAR VARIADIC VIEW VARYING VIRTUAL
@@ -652,6 +682,7 @@ func newNameFromStr(s string) *tree.Name {
 %type <tree.Statement> comment_stmt
 %type <tree.Statement> commit_stmt
 %type <tree.Statement> copy_from_stmt
+%type <tree.Statement> copy_to_stmt
 
 %type <tree.Statement> create_stmt
 %type <tree.Statement> create_changefeed_stmt
@@ -665,7 +696,9 @@ func newNameFromStr(s string) *tree.Name {
 %type <tree.Statement> create_view_stmt
 %type <tree.Statement> create_sequence_stmt
 
+%type <tree.Statement> create_policy_stmt
 %type <tree.Statement> create_stats_stmt
+%type <tree.Statement> create_stmt_hints_stmt
 %type <*tree.CreateStatsOptions> opt_create_stats_options
 %type <*tree.CreateStatsOptions> create_stats_option_list
 %type <*tree.CreateStatsOptions> create_stats_option
@@ -681,6 +714,8 @@ func newNameFromStr(s string) *tree.Name {
 %type <tree.Statement> drop_role_stmt
 %type <tree.Statement> drop_table_stmt
 %type <tree.Statement> drop_user_stmt
+%type <tree.Statement> drop_policy_stmt
+%type <tree.Statement> drop_stmt_hints_stmt
 %type <tree.Statement> drop_view_stmt
 %type <tree.Statement> drop_sequence_stmt
 
@@ -691,6 +726,13 @@ func newNameFromStr(s string) *tree.Name {
 %type <tree.Statement> export_stmt
 %type <tree.Statement> execute_stmt
 %type <tree.Statement> deallocate_stmt
+%type <tree.Statement> declare_cursor_stmt
+%type <tree.Statement> fetch_cursor_stmt
+%type <*tree.FetchCursor> fetch_direction
+%type <tree.Statement> close_cursor_stmt
+%type <tree.Statement> listen_stmt
+%type <tree.Statement> unlisten_stmt
+%type <tree.Statement> notify_stmt
 %type <tree.Statement> grant_stmt
 %type <tree.Statement> insert_stmt
 %type <tree.Statement> import_stmt
@@ -726,6 +768,7 @@ func newNameFromStr(s string) *tree.Name {
 %type <tree.Statement> show_histogram_stmt
 %type <tree.Statement> show_indexes_stmt
 %type <tree.Statement> show_jobs_stmt
+%type <tree.Statement> show_stmt_hints_stmt
 %type <tree.Statement> show_queries_stmt
 %type <tree.Statement> show_ranges_stmt
 %type <tree.Statement> show_roles_stmt
@@ -752,6 +795,9 @@ func newNameFromStr(s string) *tree.Name {
 
 %type <[]string> opt_incremental
 %type <tree.KVOption> kv_option
+%type <tree.CopyOptions> copy_options copy_generic_opt_list copy_generic_opt_elem
+%type <tree.CopyOptions> copy_legacy_opt_list copy_legacy_opt_elem
+%type <str> copy_generic_opt_arg
 %type <[]tree.KVOption> kv_option_list opt_with_options var_set_list
 %type <str> import_format
 
@@ -781,6 +827,9 @@ func newNameFromStr(s string) *tree.Name {
 
 %type <str> name opt_name opt_name_parens opt_to_savepoint
 %type <str> privilege savepoint_name
+%type <privilege.Kind> opt_policy_command
+%type <tree.NameList> opt_policy_roles
+%type <tree.Expr> opt_policy_using opt_policy_with_check
 
 %type <tree.Operator> subquery_op
 %type <*tree.UnresolvedName> func_name
@@ -974,6 +1023,8 @@ func newNameFromStr(s string) *tree.Name {
 %type <*tree.TargetList> opt_on_targets_roles
 %type <tree.NameList> for_grantee_clause
 %type <privilege.List> privileges
+%type <tree.ColumnPrivilege> column_privilege
+%type <tree.ColumnPrivileges> column_privileges
 %type <tree.AuditMode> audit_mode
 
 %type <str> relocate_kw ranges_kw
@@ -1058,9 +1109,16 @@ stmt:
   HELPTOKEN { return helpWith(sqllex, "") }
 | preparable_stmt  // help texts in sub-rule
 | copy_from_stmt
+| copy_to_stmt
 | comment_stmt
 | execute_stmt      // EXTEND WITH HELP: EXECUTE
 | deallocate_stmt   // EXTEND WITH HELP: DEALLOCATE
+| declare_cursor_stmt // EXTEND WITH HELP: DECLARE
+| fetch_cursor_stmt // EXTEND WITH HELP: FETCH
+| close_cursor_stmt // EXTEND WITH HELP: CLOSE
+| listen_stmt       // EXTEND WITH HELP: LISTEN
+| unlisten_stmt     // EXTEND WITH HELP: UNLISTEN
+| notify_stmt       // EXTEND WITH HELP: NOTIFY
 | discard_stmt      // EXTEND WITH HELP: DISCARD
 | export_stmt       // EXTEND WITH HELP: EXPORT
 | grant_stmt        // EXTEND WITH HELP: GRANT
@@ -1108,6 +1166,7 @@ alter_ddl_stmt:
 //   ALTER TABLE ... RENAME TO <newname>
 //   ALTER TABLE ... RENAME [COLUMN] <colname> TO <newname>
 //   ALTER TABLE ... VALIDATE CONSTRAINT <constraintname>
+//   ALTER TABLE ... {ENABLE | DISABLE} ROW LEVEL SECURITY
 //   ALTER TABLE ... SPLIT AT <selectclause> [WITH EXPIRATION <expr>]
 //   ALTER TABLE ... UNSPLIT AT <selectclause>
 //   ALTER TABLE ... UNSPLIT ALL
@@ -1623,6 +1682,16 @@ alter_table_cmd:
   {
     $$.val = &tree.AlterTableSetAudit{Mode: $3.auditMode()}
   }
+  // ALTER TABLE <name> ENABLE ROW LEVEL SECURITY
+| ENABLE ROW LEVEL SECURITY
+  {
+    $$.val = &tree.AlterTableSetRowLevelSecurity{Enabled: true}
+  }
+  // ALTER TABLE <name> DISABLE ROW LEVEL SECURITY
+| DISABLE ROW LEVEL SECURITY
+  {
+    $$.val = &tree.AlterTableSetRowLevelSecurity{Enabled: false}
+  }
   // ALTER TABLE <name> PARTITION BY ...
 | partition_by
   {
@@ -1932,16 +2001,137 @@ opt_with_options:
   }
 
 copy_from_stmt:
-  COPY table_name opt_column_list FROM STDIN
+  COPY table_name opt_column_list FROM STDIN copy_options
   {
     name := $2.unresolvedObjectName().ToTableName()
+    opts := $6.copyOptions()
+    if err := opts.Validate(); err != nil {
+      return setErr(sqllex, err)
+    }
     $$.val = &tree.CopyFrom{
        Table: name,
        Columns: $3.nameList(),
        Stdin: true,
+       Options: opts,
+    }
+  }
+
+copy_to_stmt:
+  COPY table_name opt_column_list TO STDOUT copy_options
+  {
+    name := $2.unresolvedObjectName().ToTableName()
+    opts := $6.copyOptions()
+    if err := opts.Validate(); err != nil {
+      return setErr(sqllex, err)
+    }
+    $$.val = &tree.CopyTo{
+       Table: name,
+       Columns: $3.nameList(),
+       Options: opts,
+    }
+  }
+| COPY select_with_parens TO STDOUT copy_options
+  {
+    opts := $5.copyOptions()
+    if err := opts.Validate(); err != nil {
+      return setErr(sqllex, err)
+    }
+    $$.val = &tree.CopyTo{
+       Statement: $2.selectStmt().(*tree.ParenSelect).Select,
+       Options: opts,
+    }
+  }
+
+// The options of COPY can be given either as a parenthesized list, e.g.
+// WITH (FORMAT csv, HEADER), or with the syntax of PostgreSQL 9.0 and
+// earlier, e.g. WITH CSV HEADER.
+copy_options:
+  WITH '(' copy_generic_opt_list ')'
+  {
+    $$.val = $3.copyOptions()
+  }
+| '(' copy_generic_opt_list ')'
+  {
+    $$.val = $2.copyOptions()
+  }
+| WITH copy_legacy_opt_list
+  {
+    $$.val = $2.copyOptions()
+  }
+| copy_legacy_opt_list
+| /* EMPTY */
+  {
+    $$.val = tree.CopyOptions{}
+  }
+
+copy_generic_opt_list:
+  copy_generic_opt_elem
+| copy_generic_opt_list ',' copy_generic_opt_elem
+  {
+    opts := $1.copyOptions()
+    if err := opts.CombineWith($3.copyOptions()); err != nil {
+      return setErr(sqllex, err)
+    }
+    $$.val = opts
+  }
+
+copy_generic_opt_elem:
+  unrestricted_name copy_generic_opt_arg
+  {
+    opt, err := tree.MakeCopyOption($1, $2)
+    if err != nil {
+      return setErr(sqllex, err)
+    }
+    $$.val = opt
+  }
+
+copy_generic_opt_arg:
+  non_reserved_word_or_sconst
+| TRUE
+| FALSE
+| ON
+| /* EMPTY */
+  {
+    $$ = ""
+  }
+
+copy_legacy_opt_list:
+  copy_legacy_opt_elem
+| copy_legacy_opt_list copy_legacy_opt_elem
+  {
+    opts := $1.copyOptions()
+    if err := opts.CombineWith($2.copyOptions()); err != nil {
+      return setErr(sqllex, err)
     }
+    $$.val = opts
   }
 
+copy_legacy_opt_elem:
+  BINARY
+  {
+    $$.val, _ = tree.MakeCopyOption("format", "binary")
+  }
+| CSV
+  {
+    $$.val, _ = tree.MakeCopyOption("format", "csv")
+  }
+| HEADER
+  {
+    $$.val, _ = tree.MakeCopyOption("header", "")
+  }
+| DELIMITER opt_as SCONST
+  {
+    $$.val, _ = tree.MakeCopyOption("delimiter", $3)
+  }
+| NULL opt_as SCONST
+  {
+    $$.val, _ = tree.MakeCopyOption("null", $3)
+  }
+
+opt_as:
+  AS {}
+| /* EMPTY */ {}
+
 // %Help: CANCEL
 // %Category: Group
 // %Text: CANCEL JOBS, CANCEL QUERIES, CANCEL SESSIONS
@@ -2092,12 +2282,14 @@ comment_text:
 // %Text:
 // CREATE DATABASE, CREATE TABLE, CREATE INDEX, CREATE TABLE AS,
 // CREATE USER, CREATE VIEW, CREATE SEQUENCE, CREATE STATISTICS,
-// CREATE ROLE
+// CREATE ROLE, CREATE STATEMENT HINTS, CREATE POLICY
 create_stmt:
   create_user_stmt     // EXTEND WITH HELP: CREATE USER
 | create_role_stmt     // EXTEND WITH HELP: CREATE ROLE
 | create_ddl_stmt      // help texts in sub-rule
 | create_stats_stmt    // EXTEND WITH HELP: CREATE STATISTICS
+| create_policy_stmt   // EXTEND WITH HELP: CREATE POLICY
+| create_stmt_hints_stmt // EXTEND WITH HELP: CREATE STATEMENT HINTS
 | create_unsupported   {}
 | CREATE error         // SHOW HELP: CREATE
 
@@ -2170,6 +2362,109 @@ create_ddl_stmt:
 | create_view_stmt     // EXTEND WITH HELP: CREATE VIEW
 | create_sequence_stmt // EXTEND WITH HELP: CREATE SEQUENCE
 
+// %Help: CREATE STATEMENT HINTS - set the hints for a statement fingerprint
+// %Category: Misc
+// %Text:
+// CREATE STATEMENT HINTS FOR <fingerprint> AS <hints>
+//
+// Replaces the hints applied when planning the statements with the given
+// fingerprint, as listed in crdb_internal.node_statement_statistics. The hints
+// are a JSON object with the optional fields:
+//   index:            {"<tablename>": "<indexname>", ...}
+//   join:             "hash" | "merge" | "lookup"
+//   fixed_join_order: true | false
+//   disabled_rules:   ["<rulename>", ...]
+// %SeeAlso: SHOW STATEMENT HINTS, DROP STATEMENT HINTS
+create_stmt_hints_stmt:
+  CREATE STATEMENT HINTS FOR SCONST AS SCONST
+  {
+    $$.val = &tree.CreateStmtHints{Fingerprint: $5, Hints: $7}
+  }
+| CREATE STATEMENT error // SHOW HELP: CREATE STATEMENT HINTS
+
+// %Help: CREATE POLICY - define a row-level security policy for a table
+// %Category: Priv
+// %Text:
+// CREATE POLICY <name> ON <tablename>
+//   [FOR {ALL | SELECT | INSERT | UPDATE | DELETE}]
+//   [TO <role> [, ...]]
+//   [USING (<expr>)]
+//   [WITH CHECK (<expr>)]
+//
+// The policies of a table only restrict the rows accessed by users who do
+// not own it, once row-level security is enabled on the table with
+// ALTER TABLE ... ENABLE ROW LEVEL SECURITY.
+// %SeeAlso: DROP POLICY, ALTER TABLE
+create_policy_stmt:
+  CREATE POLICY name ON table_name opt_policy_command opt_policy_roles opt_policy_using opt_policy_with_check
+  {
+    $$.val = &tree.CreatePolicy{
+      Name: tree.Name($3),
+      Table: $5.unresolvedObjectName(),
+      Command: $6.privilegeKind(),
+      Roles: $7.nameList(),
+      Using: $8.expr(),
+      WithCheck: $9.expr(),
+    }
+  }
+| CREATE POLICY error // SHOW HELP: CREATE POLICY
+
+opt_policy_command:
+  FOR ALL
+  {
+    $$.val = privilege.ALL
+  }
+| FOR SELECT
+  {
+    $$.val = privilege.SELECT
+  }
+| FOR INSERT
+  {
+    $$.val = privilege.INSERT
+  }
+| FOR UPDATE
+  {
+    $$.val = privilege.UPDATE
+  }
+| FOR DELETE
+  {
+    $$.val = privilege.DELETE
+  }
+| /* EMPTY */
+  {
+    $$.val = privilege.ALL
+  }
+
+opt_policy_roles:
+  TO name_list
+  {
+    $$.val = $2.nameList()
+  }
+| /* EMPTY */
+  {
+    $$.val = tree.NameList(nil)
+  }
+
+opt_policy_using:
+  USING '(' a_expr ')'
+  {
+    $$.val = $3.expr()
+  }
+| /* EMPTY */
+  {
+    $$.val = tree.Expr(nil)
+  }
+
+opt_policy_with_check:
+  WITH CHECK '(' a_expr ')'
+  {
+    $$.val = $4.expr()
+  }
+| /* EMPTY */
+  {
+    $$.val = tree.Expr(nil)
+  }
+
 // %Help: CREATE STATISTICS - create a new table statistic
 // %Category: Misc
 // %Text:
@@ -2354,11 +2649,13 @@ discard_stmt:
 // %Category: Group
 // %Text:
 // DROP DATABASE, DROP INDEX, DROP TABLE, DROP VIEW, DROP SEQUENCE,
-// DROP USER, DROP ROLE
+// DROP USER, DROP ROLE, DROP STATEMENT HINTS, DROP POLICY
 drop_stmt:
   drop_ddl_stmt      // help texts in sub-rule
 | drop_role_stmt     // EXTEND WITH HELP: DROP ROLE
 | drop_user_stmt     // EXTEND WITH HELP: DROP USER
+| drop_policy_stmt   // EXTEND WITH HELP: DROP POLICY
+| drop_stmt_hints_stmt // EXTEND WITH HELP: DROP STATEMENT HINTS
 | drop_unsupported   {}
 | DROP error         // SHOW HELP: DROP
 
@@ -2460,6 +2757,36 @@ drop_database_stmt:
   }
 | DROP DATABASE error // SHOW HELP: DROP DATABASE
 
+// %Help: DROP STATEMENT HINTS - remove the hints for a statement fingerprint
+// %Category: Misc
+// %Text: DROP STATEMENT HINTS [IF EXISTS] FOR <fingerprint>
+// %SeeAlso: CREATE STATEMENT HINTS, SHOW STATEMENT HINTS
+drop_stmt_hints_stmt:
+  DROP STATEMENT HINTS FOR SCONST
+  {
+    $$.val = &tree.DropStmtHints{Fingerprint: $5, IfExists: false}
+  }
+| DROP STATEMENT HINTS IF EXISTS FOR SCONST
+  {
+    $$.val = &tree.DropStmtHints{Fingerprint: $7, IfExists: true}
+  }
+| DROP STATEMENT error // SHOW HELP: DROP STATEMENT HINTS
+
+// %Help: DROP POLICY - remove a row-level security policy
+// %Category: Priv
+// %Text: DROP POLICY [IF EXISTS] <name> ON <tablename>
+// %SeeAlso: CREATE POLICY
+drop_policy_stmt:
+  DROP POLICY name ON table_name
+  {
+    $$.val = &tree.DropPolicy{Name: tree.Name($3), Table: $5.unresolvedObjectName(), IfExists: false}
+  }
+| DROP POLICY IF EXISTS name ON table_name
+  {
+    $$.val = &tree.DropPolicy{Name: tree.Name($5), Table: $7.unresolvedObjectName(), IfExists: true}
+  }
+| DROP POLICY error // SHOW HELP: DROP POLICY
+
 // %Help: DROP USER - remove a user
 // %Category: Priv
 // %Text: DROP USER [IF EXISTS] <user> [, ...]
@@ -2681,16 +3008,156 @@ deallocate_stmt:
   }
 | DEALLOCATE error // SHOW HELP: DEALLOCATE
 
+// %Help: DECLARE - define a cursor
+// %Category: Misc
+// %Text: DECLARE <name> CURSOR [WITHOUT HOLD] FOR <selectclause>
+// %SeeAlso: FETCH, CLOSE
+declare_cursor_stmt:
+  DECLARE name CURSOR FOR select_stmt
+  {
+    $$.val = &tree.DeclareCursor{Name: tree.Name($2), Select: $5.slct()}
+  }
+| DECLARE name CURSOR WITHOUT HOLD FOR select_stmt
+  {
+    $$.val = &tree.DeclareCursor{Name: tree.Name($2), Select: $7.slct()}
+  }
+| DECLARE name CURSOR WITH HOLD FOR select_stmt
+  {
+    return unimplemented(sqllex, "declare with hold")
+  }
+| DECLARE error // SHOW HELP: DECLARE
+
+// %Help: FETCH - retrieve rows from a cursor
+// %Category: Misc
+// %Text:
+// FETCH [ <direction> ] [ FROM | IN ] <name>
+//
+// Direction:
+//   NEXT | FORWARD | <count> | FORWARD <count> | ALL | FORWARD ALL
+//
+// %SeeAlso: DECLARE, CLOSE
+fetch_cursor_stmt:
+  FETCH name
+  {
+    $$.val = &tree.FetchCursor{Name: tree.Name($2), Count: 1}
+  }
+| FETCH from_or_in name
+  {
+    $$.val = &tree.FetchCursor{Name: tree.Name($3), Count: 1}
+  }
+| FETCH fetch_direction name
+  {
+    n := $2.fetchCursor()
+    n.Name = tree.Name($3)
+    $$.val = n
+  }
+| FETCH fetch_direction from_or_in name
+  {
+    n := $2.fetchCursor()
+    n.Name = tree.Name($4)
+    $$.val = n
+  }
+| FETCH error // SHOW HELP: FETCH
+
+fetch_direction:
+  NEXT
+  {
+    $$.val = &tree.FetchCursor{Count: 1}
+  }
+| FORWARD
+  {
+    $$.val = &tree.FetchCursor{Count: 1}
+  }
+| iconst64
+  {
+    $$.val = &tree.FetchCursor{Count: $1.int64()}
+  }
+| FORWARD iconst64
+  {
+    $$.val = &tree.FetchCursor{Count: $2.int64()}
+  }
+| ALL
+  {
+    $$.val = &tree.FetchCursor{All: true}
+  }
+| FORWARD ALL
+  {
+    $$.val = &tree.FetchCursor{All: true}
+  }
+
+from_or_in:
+  FROM {}
+| IN {}
+
+// %Help: CLOSE - close a cursor
+// %Category: Misc
+// %Text: CLOSE { <name> | ALL }
+// %SeeAlso: DECLARE, FETCH
+close_cursor_stmt:
+  CLOSE name
+  {
+    $$.val = &tree.CloseCursor{Name: tree.Name($2)}
+  }
+| CLOSE ALL
+  {
+    $$.val = &tree.CloseCursor{}
+  }
+| CLOSE error // SHOW HELP: CLOSE
+
+// %Help: LISTEN - listen for notifications on a channel
+// %Category: Misc
+// %Text: LISTEN <channel>
+// %SeeAlso: UNLISTEN, NOTIFY
+listen_stmt:
+  LISTEN name
+  {
+    $$.val = &tree.Listen{Channel: tree.Name($2)}
+  }
+| LISTEN error // SHOW HELP: LISTEN
+
+// %Help: UNLISTEN - stop listening for notifications
+// %Category: Misc
+// %Text: UNLISTEN { <channel> | * }
+// %SeeAlso: LISTEN, NOTIFY
+unlisten_stmt:
+  UNLISTEN name
+  {
+    $$.val = &tree.Unlisten{Channel: tree.Name($2)}
+  }
+| UNLISTEN '*'
+  {
+    $$.val = &tree.Unlisten{}
+  }
+| UNLISTEN error // SHOW HELP: UNLISTEN
+
+// %Help: NOTIFY - generate a notification
+// %Category: Misc
+// %Text: NOTIFY <channel> [, <payload>]
+// %SeeAlso: LISTEN, UNLISTEN
+notify_stmt:
+  NOTIFY name
+  {
+    $$.val = &tree.Notify{Channel: tree.Name($2)}
+  }
+| NOTIFY name ',' SCONST
+  {
+    $$.val = &tree.Notify{Channel: tree.Name($2), Payload: $4}
+  }
+| NOTIFY error // SHOW HELP: NOTIFY
+
 // %Help: GRANT - define access privileges and role memberships
 // %Category: Priv
 // %Text:
 // Grant privileges:
 //   GRANT {ALL | <privileges...> } ON <targets...> TO <grantees...>
+// Grant privileges on columns:
+//   GRANT <privilege> (<colnames...>) [, ...] ON [TABLE] <tablename> [, ...] TO <grantees...>
 // Grant role membership (CCL only):
 //   GRANT <roles...> TO <grantees...> [WITH ADMIN OPTION]
 //
 // Privileges:
 //   CREATE, DROP, GRANT, SELECT, INSERT, DELETE, UPDATE
+//   Only SELECT, INSERT and UPDATE can be granted on columns.
 //
 // Targets:
 //   DATABASE <databasename> [, ...]
@@ -2702,6 +3169,11 @@ grant_stmt:
   {
     $$.val = &tree.Grant{Privileges: $2.privilegeList(), Grantees: $6.nameList(), Targets: $4.targetList()}
   }
+| GRANT column_privileges ON targets TO name_list
+  {
+    privs, colPrivs := splitColumnPrivileges($2.columnPrivileges())
+    $$.val = &tree.Grant{Privileges: privs, ColumnPrivileges: colPrivs, Grantees: $6.nameList(), Targets: $4.targetList()}
+  }
 | GRANT privilege_list TO name_list
   {
     $$.val = &tree.GrantRole{Roles: $2.nameList(), Members: $4.nameList(), AdminOption: false}
@@ -2717,11 +3189,14 @@ grant_stmt:
 // %Text:
 // Revoke privileges:
 //   REVOKE {ALL | <privileges...> } ON <targets...> FROM <grantees...>
+// Revoke privileges on columns:
+//   REVOKE <privilege> (<colnames...>) [, ...] ON [TABLE] <tablename> [, ...] FROM <grantees...>
 // Revoke role membership (CCL only):
 //   REVOKE [ADMIN OPTION FOR] <roles...> FROM <grantees...>
 //
 // Privileges:
 //   CREATE, DROP, GRANT, SELECT, INSERT, DELETE, UPDATE
+//   Only SELECT, INSERT and UPDATE can be revoked on columns.
 //
 // Targets:
 //   DATABASE <databasename> [, <databasename>]...
@@ -2733,6 +3208,11 @@ revoke_stmt:
   {
     $$.val = &tree.Revoke{Privileges: $2.privilegeList(), Grantees: $6.nameList(), Targets: $4.targetList()}
   }
+| REVOKE column_privileges ON targets FROM name_list
+  {
+    privs, colPrivs := splitColumnPrivileges($2.columnPrivileges())
+    $$.val = &tree.Revoke{Privileges: privs, ColumnPrivileges: colPrivs, Grantees: $6.nameList(), Targets: $4.targetList()}
+  }
 | REVOKE privilege_list FROM name_list
   {
     $$.val = &tree.RevokeRole{Roles: $2.nameList(), Members: $4.nameList(), AdminOption: false }
@@ -2758,6 +3238,53 @@ privileges:
      $$.val = privList
   }
 
+// A list of privileges of which at least one applies to specific columns.
+// Privileges without a column list are kept in the result with nil Columns,
+// and are separated out by splitColumnPrivileges.
+column_privileges:
+  column_privilege
+  {
+    $$.val = tree.ColumnPrivileges{$1.columnPrivilege()}
+  }
+| privilege_list ',' column_privilege
+  {
+    privList, err := privilege.ListFromStrings($1.nameList().ToStrings())
+    if err != nil {
+      return setErr(sqllex, err)
+    }
+    colPrivs := make(tree.ColumnPrivileges, len(privList), len(privList)+1)
+    for i, p := range privList {
+      colPrivs[i].Privilege = p
+    }
+    $$.val = append(colPrivs, $3.columnPrivilege())
+  }
+| column_privileges ',' column_privilege
+  {
+    $$.val = append($1.columnPrivileges(), $3.columnPrivilege())
+  }
+| column_privileges ',' privilege
+  {
+    privList, err := privilege.ListFromStrings([]string{$3})
+    if err != nil {
+      return setErr(sqllex, err)
+    }
+    $$.val = append($1.columnPrivileges(), tree.ColumnPrivilege{Privilege: privList[0]})
+  }
+
+column_privilege:
+  privilege '(' name_list ')'
+  {
+    privList, err := privilege.ListFromStrings([]string{$1})
+    if err != nil {
+      return setErr(sqllex, err)
+    }
+    if !privilege.ColumnPrivileges.Contains(privList[0]) {
+      sqllex.Error(fmt.Sprintf("invalid privilege type %s for column", privList[0]))
+      return 1
+    }
+    $$.val = tree.ColumnPrivilege{Privilege: privList[0], Columns: $3.nameList()}
+  }
+
 privilege_list:
   privilege
   {
@@ -3183,8 +3710,8 @@ zone_value:
 // SHOW BACKUP, SHOW CLUSTER SETTING, SHOW COLUMNS, SHOW CONSTRAINTS,
 // SHOW CREATE, SHOW DATABASES, SHOW HISTOGRAM, SHOW INDEXES, SHOW
 // JOBS, SHOW QUERIES, SHOW ROLES, SHOW SCHEMAS, SHOW SEQUENCES, SHOW
-// SESSION, SHOW SESSIONS, SHOW STATISTICS, SHOW SYNTAX, SHOW TABLES,
-// SHOW TRACE SHOW TRANSACTION, SHOW USERS
+// SESSION, SHOW SESSIONS, SHOW STATEMENT HINTS, SHOW STATISTICS, SHOW
+// SYNTAX, SHOW TABLES, SHOW TRACE SHOW TRANSACTION, SHOW USERS
 show_stmt:
   show_backup_stmt          // EXTEND WITH HELP: SHOW BACKUP
 | show_columns_stmt         // EXTEND WITH HELP: SHOW COLUMNS
@@ -3204,6 +3731,7 @@ show_stmt:
 | show_sequences_stmt       // EXTEND WITH HELP: SHOW SEQUENCES
 | show_session_stmt         // EXTEND WITH HELP: SHOW SESSION
 | show_sessions_stmt        // EXTEND WITH HELP: SHOW SESSIONS
+| show_stmt_hints_stmt      // EXTEND WITH HELP: SHOW STATEMENT HINTS
 | show_stats_stmt           // EXTEND WITH HELP: SHOW STATISTICS
 | show_syntax_stmt          // EXTEND WITH HELP: SHOW SYNTAX
 | show_tables_stmt          // EXTEND WITH HELP: SHOW TABLES
@@ -3237,6 +3765,17 @@ session_var:
 | TIME ZONE { $$ = "timezone" }
 | TIME error // SHOW HELP: SHOW SESSION
 
+// %Help: SHOW STATEMENT HINTS - list the hints for statement fingerprints
+// %Category: Misc
+// %Text: SHOW STATEMENT HINTS
+// %SeeAlso: CREATE STATEMENT HINTS, DROP STATEMENT HINTS
+show_stmt_hints_stmt:
+  SHOW STATEMENT HINTS
+  {
+    $$.val = &tree.ShowStmtHints{}
+  }
+| SHOW STATEMENT error // SHOW HELP: SHOW STATEMENT HINTS
+
 // %Help: SHOW STATISTICS - display table statistics (experimental)
 // %Category: Experimental
 // %Text: SHOW STATISTICS [USING JSON] FOR TABLE <table_name>
@@ -8900,6 +9439,7 @@ unreserved_keyword:
 | BACKUP
 | BEGIN
 | BIGSERIAL
+| BINARY
 | BLOB
 | BOOL
 | BY
@@ -8909,6 +9449,7 @@ unreserved_keyword:
 | CANCEL
 | CASCADE
 | CHANGEFEED
+| CLOSE
 | CLUSTER
 | COLUMNS
 | COMMENT
@@ -8923,8 +9464,10 @@ unreserved_keyword:
 | CONVERSION
 | COPY
 | COVERING
+| CSV
 | CUBE
 | CURRENT
+| CURSOR
 | CYCLE
 | DATA
 | DATABASE
@@ -8932,12 +9475,16 @@ unreserved_keyword:
 | DATE
 | DAY
 | DEALLOCATE
+| DECLARE
 | DELETE
 | DEFERRED
+| DELIMITER
+| DISABLE
 | DISCARD
 | DOMAIN
 | DOUBLE
 | DROP
+| ENABLE
 | ENCODING
 | ENUM
 | ESCAPE
@@ -8959,13 +9506,17 @@ unreserved_keyword:
 | FLOAT8
 | FOLLOWING
 | FORCE_INDEX
+| FORWARD
 | FUNCTION
 | GLOBAL
 | GRANTS
 | GROUPS
 | HASH
+| HEADER
 | HIGH
+| HINTS
 | HISTOGRAM
+| HOLD
 | HOUR
 | IMMEDIATE
 | IMPORT
@@ -8997,6 +9548,7 @@ unreserved_keyword:
 | LESS
 | LEVEL
 | LIST
+| LISTEN
 | LOCAL
 | LOOKUP
 | LOW
@@ -9013,6 +9565,7 @@ unreserved_keyword:
 | NEXT
 | NO
 | NORMAL
+| NOTIFY
 | NO_INDEX_JOIN
 | IGNORE_FOREIGN_KEYS
 | OF
@@ -9035,6 +9588,7 @@ unreserved_keyword:
 | PHYSICAL
 | PLAN
 | PLANS
+| POLICY
 | PRECEDING
 | PREPARE
 | PRIORITY
@@ -9076,6 +9630,7 @@ unreserved_keyword:
 | SCRUB
 | SEARCH
 | SECOND
+| SECURITY
 | SERIAL
 | SERIALIZABLE
 | SERIAL2
@@ -9093,8 +9648,10 @@ unreserved_keyword:
 | SNAPSHOT
 | SQL
 | START
+| STATEMENT
 | STATISTICS
 | STDIN
+| STDOUT
 | STORE
 | STORED
 | STORING
@@ -9121,6 +9678,7 @@ unreserved_keyword:
 | UNBOUNDED
 | UNCOMMITTED
 | UNKNOWN
+| UNLISTEN
 | UNLOGGED
 | UNSPLIT
 | UPDATE
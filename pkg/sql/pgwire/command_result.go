@@ -36,6 +36,9 @@ const (
 	emptyQueryResponse
 	readyForQuery
 	flush
+	// portalSuspended is used for results of portals whose row limit was
+	// reached.
+	portalSuspended
 	// Some commands, like Describe, don't need a completion message.
 	noCompletionMsg
 )
@@ -82,6 +85,10 @@ type commandResult struct {
 	// bufferingDisabled is conditionally set during planning of certain
 	// statements.
 	bufferingDisabled bool
+
+	// copyOut is set for COPY TO STDOUT statements, whose rows are sent in
+	// CopyData messages instead of DataRow messages.
+	copyOut *copyOutEncoder
 }
 
 func (c *conn) makeCommandResult(
@@ -91,7 +98,7 @@ func (c *conn) makeCommandResult(
 	formatCodes []pgwirebase.FormatCode,
 	conv sessiondata.DataConversionConfig,
 ) commandResult {
-	return commandResult{
+	r := commandResult{
 		conn:           c,
 		pos:            pos,
 		descOpt:        descOpt,
@@ -101,6 +108,10 @@ func (c *conn) makeCommandResult(
 		cmdCompleteTag: stmt.StatementTag(),
 		conv:           conv,
 	}
+	if copyTo, ok := stmt.(*tree.CopyTo); ok {
+		r.copyOut = newCopyOutEncoder(copyTo.Options)
+	}
+	return r
 }
 
 func (c *conn) makeMiscResult(pos sql.CmdPos, typ completionMsgType) commandResult {
@@ -139,6 +150,12 @@ func (r *commandResult) Close(t sql.TransactionStatusIndicator) {
 	// Send a completion message, specific to the type of result.
 	switch r.typ {
 	case commandComplete:
+		if r.copyOut != nil {
+			if trailer := r.copyOut.trailer(); trailer != nil {
+				r.conn.bufferCopyData(trailer)
+			}
+			r.conn.bufferCopyDone()
+		}
 		tag := cookTag(
 			r.cmdCompleteTag, r.conn.writerState.tagBuf[:0], r.stmtType, r.rowsAffected,
 		)
@@ -155,6 +172,8 @@ func (r *commandResult) Close(t sql.TransactionStatusIndicator) {
 		_ /* err */ = r.conn.Flush(r.pos)
 	case emptyQueryResponse:
 		r.conn.bufferEmptyQueryResponse()
+	case portalSuspended:
+		r.conn.bufferPortalSuspended()
 	case flush:
 		// The error is saved on conn.err.
 		_ /* err */ = r.conn.Flush(r.pos)
@@ -209,7 +228,15 @@ func (r *commandResult) AddRow(ctx context.Context, row tree.Datums) error {
 	}
 	r.rowsAffected++
 
-	r.conn.bufferRow(ctx, row, r.formatCodes, r.conv, r.oids)
+	if r.copyOut != nil {
+		data, err := r.copyOut.encodeRow(ctx, row, r.conv, r.oids)
+		if err != nil {
+			return err
+		}
+		r.conn.bufferCopyData(data)
+	} else {
+		r.conn.bufferRow(ctx, row, r.formatCodes, r.conv, r.oids)
+	}
 	var err error
 	if r.bufferingDisabled {
 		err = r.conn.Flush(r.pos)
@@ -227,7 +254,12 @@ func (r *commandResult) DisableBuffering() {
 // SetColumns is part of the CommandResult interface.
 func (r *commandResult) SetColumns(ctx context.Context, cols sqlbase.ResultColumns) {
 	r.conn.writerState.fi.registerCmd(r.pos)
-	if r.descOpt == sql.NeedRowDesc {
+	if r.copyOut != nil {
+		r.conn.bufferCopyOutResponse(r.copyOut.formatCode(), len(cols))
+		if header := r.copyOut.header(cols); header != nil {
+			r.conn.bufferCopyData(header)
+		}
+	} else if r.descOpt == sql.NeedRowDesc {
 		_ /* err */ = r.conn.writeRowDescription(ctx, cols, r.formatCodes, &r.conn.writerState.buf)
 	}
 	r.oids = make([]oid.Oid, len(cols))
@@ -277,6 +309,20 @@ func (r *commandResult) SetLimit(n int) {
 	r.limit = n
 }
 
+// SetPortalSuspended is part of the CommandResult interface.
+func (r *commandResult) SetPortalSuspended() {
+	r.typ = portalSuspended
+}
+
+// AddNotification is part of the NotificationResult interface.
+func (r *commandResult) AddNotification(n sql.Notification) {
+	r.conn.writerState.fi.registerCmd(r.pos)
+	r.conn.bufferNotification(n)
+	// Notifications are asynchronous messages which are delivered as soon as
+	// possible.
+	r.typ = flush
+}
+
 // ResetStmtType is part of the CommandResult interface.
 func (r *commandResult) ResetStmtType(stmt tree.Statement) {
 	r.stmtType = stmt.StatementType()
@@ -53,6 +53,9 @@ import (
 const (
 	authOK                int32 = 0
 	authCleartextPassword int32 = 3
+	authSASL              int32 = 10
+	authSASLContinue      int32 = 11
+	authSASLFinal         int32 = 12
 )
 
 // conn implements a pgwire network connection (version 3 of the protocol,
@@ -627,6 +630,17 @@ func (c *conn) sendInitialConnData(
 		return sql.ConnectionHandler{}, err
 	}
 
+	// Send the key that the client can use to cancel the queries of this
+	// connection through a CancelRequest. The 64 bits of the key fill both the
+	// process ID and the secret key of a Postgres backend.
+	cancelKey := connHandler.GetCancelKey()
+	c.msgBuilder.initMsg(pgwirebase.ServerMsgBackendKeyData)
+	c.msgBuilder.putInt32(int32(cancelKey >> 32))
+	c.msgBuilder.putInt32(int32(cancelKey))
+	if err := c.msgBuilder.finishMsg(c.conn); err != nil {
+		return sql.ConnectionHandler{}, err
+	}
+
 	// An initial readyForQuery message is part of the handshake.
 	c.msgBuilder.initMsg(pgwirebase.ServerMsgReady)
 	c.msgBuilder.writeByte(byte(sql.IdleTxnBlock))
@@ -985,12 +999,14 @@ func (c *conn) handleFlush(ctx context.Context) error {
 }
 
 // BeginCopyIn is part of the pgwirebase.Conn interface.
-func (c *conn) BeginCopyIn(ctx context.Context, columns []sqlbase.ResultColumn) error {
+func (c *conn) BeginCopyIn(
+	ctx context.Context, columns []sqlbase.ResultColumn, format pgwirebase.FormatCode,
+) error {
 	c.msgBuilder.initMsg(pgwirebase.ServerMsgCopyInResponse)
-	c.msgBuilder.writeByte(byte(pgwirebase.FormatText))
+	c.msgBuilder.writeByte(byte(format))
 	c.msgBuilder.putInt16(int16(len(columns)))
 	for range columns {
-		c.msgBuilder.putInt16(int16(pgwirebase.FormatText))
+		c.msgBuilder.putInt16(int16(format))
 	}
 	return c.msgBuilder.finishMsg(c.conn)
 }
@@ -1127,6 +1143,35 @@ func (c *conn) bufferRow(
 	}
 }
 
+// bufferCopyOutResponse adds a CopyOutResponse message to the buffer, starting
+// the Copy-out subprotocol (COPY ... TO STDOUT).
+func (c *conn) bufferCopyOutResponse(format pgwirebase.FormatCode, numCols int) {
+	c.msgBuilder.initMsg(pgwirebase.ServerMsgCopyOutResponse)
+	c.msgBuilder.writeByte(byte(format))
+	c.msgBuilder.putInt16(int16(numCols))
+	for i := 0; i < numCols; i++ {
+		c.msgBuilder.putInt16(int16(format))
+	}
+	if err := c.msgBuilder.finishMsg(&c.writerState.buf); err != nil {
+		panic(fmt.Sprintf("unexpected err from buffer: %s", err))
+	}
+}
+
+func (c *conn) bufferCopyData(data []byte) {
+	c.msgBuilder.initMsg(pgwirebase.ServerMsgCopyData)
+	c.msgBuilder.write(data)
+	if err := c.msgBuilder.finishMsg(&c.writerState.buf); err != nil {
+		panic(fmt.Sprintf("unexpected err from buffer: %s", err))
+	}
+}
+
+func (c *conn) bufferCopyDone() {
+	c.msgBuilder.initMsg(pgwirebase.ServerMsgCopyDone)
+	if err := c.msgBuilder.finishMsg(&c.writerState.buf); err != nil {
+		panic(fmt.Sprintf("unexpected err from buffer: %s", err))
+	}
+}
+
 func (c *conn) bufferReadyForQuery(txnStatus byte) {
 	c.msgBuilder.initMsg(pgwirebase.ServerMsgReady)
 	c.msgBuilder.writeByte(txnStatus)
@@ -1156,6 +1201,25 @@ func (c *conn) bufferCloseComplete() {
 	}
 }
 
+func (c *conn) bufferPortalSuspended() {
+	c.msgBuilder.initMsg(pgwirebase.ServerMsgPortalSuspended)
+	if err := c.msgBuilder.finishMsg(&c.writerState.buf); err != nil {
+		panic(fmt.Sprintf("unexpected err from buffer: %s", err))
+	}
+}
+
+func (c *conn) bufferNotification(n sql.Notification) {
+	c.msgBuilder.initMsg(pgwirebase.ServerMsgNotificationResponse)
+	// The process ID of the notifying session is reported as its node ID, as
+	// in the BackendKeyData message.
+	c.msgBuilder.putInt32(int32(n.NodeID))
+	c.msgBuilder.writeTerminatedString(n.Channel)
+	c.msgBuilder.writeTerminatedString(n.Payload)
+	if err := c.msgBuilder.finishMsg(&c.writerState.buf); err != nil {
+		panic(fmt.Sprintf("unexpected err from buffer: %s", err))
+	}
+}
+
 func (c *conn) bufferCommandComplete(tag []byte) {
 	c.msgBuilder.initMsg(pgwirebase.ServerMsgCommandComplete)
 	c.msgBuilder.write(tag)
@@ -1423,6 +1487,13 @@ func (c *conn) CreateDrainResult(pos sql.CmdPos) sql.DrainResult {
 	return &res
 }
 
+// CreateNotificationResult is part of the sql.ClientComm interface.
+func (c *conn) CreateNotificationResult(pos sql.CmdPos) sql.NotificationResult {
+	// The result only flushes once a notification is added to it.
+	res := c.makeMiscResult(pos, noCompletionMsg)
+	return &res
+}
+
 // CreateBindResult is part of the sql.ClientComm interface.
 func (c *conn) CreateBindResult(pos sql.CmdPos) sql.BindResult {
 	res := c.makeMiscResult(pos, bindComplete)
@@ -1813,9 +1884,19 @@ func authPassword(
 	if err != nil {
 		return nil, err
 	}
-	return security.UserAuthPasswordHook(
-		insecure, password, hashedPassword,
-	), nil
+	hook := security.UserAuthPasswordHook(insecure, password, hashedPassword)
+	return func(requestedUser string, clientConnection bool) error {
+		if err := hook(requestedUser, clientConnection); err != nil {
+			return err
+		}
+		if !insecure {
+			// Now that the password has been verified, take the opportunity to
+			// store it as a SCRAM-SHA-256 hash, if it isn't one already.
+			ctx := execCfg.AmbientCtx.AnnotateCtx(context.Background())
+			sql.MaybeUpgradeUserPasswordHash(ctx, execCfg, requestedUser, password, hashedPassword)
+		}
+		return nil
+	}, nil
 }
 
 func authCert(
@@ -1857,6 +1938,7 @@ func init() {
 	RegisterAuthMethod("password", authPassword, nil)
 	RegisterAuthMethod("cert", authCert, nil)
 	RegisterAuthMethod("cert-password", authCertPassword, nil)
+	RegisterAuthMethod("scram-sha-256", authScram, nil)
 }
 
 // statusReportParams is a list of session variables that are also
@@ -21,6 +21,8 @@ import (
 	"io/ioutil"
 	"net"
 	"net/url"
+	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -977,3 +979,342 @@ func TestConnCloseCancelsAuth(t *testing.T) {
 	// Check that the auth process indeed noticed the cancelation.
 	<-authBlocked
 }
+
+// Test that portals executed with a row limit inside an explicit transaction
+// are suspended and can be resumed, interleaved with other portals, and that
+// cursors can be declared, fetched from and closed.
+func TestSuspendedPortalsAndCursors(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	s, _, _ := serverutils.StartServer(t, base.TestServerArgs{Insecure: true})
+	ctx := context.Background()
+	defer s.Stopper().Stop(ctx)
+
+	conn, err := net.Dial("tcp", s.ServingAddr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	fe, err := pgproto3.NewFrontend(conn, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	send := func(msgs ...pgproto3.FrontendMessage) {
+		t.Helper()
+		for _, msg := range msgs {
+			if err := fe.Send(msg); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	// recv reads messages until a ReadyForQuery and returns a description of
+	// each of them.
+	recv := func() []string {
+		t.Helper()
+		var res []string
+		for {
+			msg, err := fe.Receive()
+			if err != nil {
+				t.Fatal(err)
+			}
+			switch msg := msg.(type) {
+			case *pgproto3.Authentication, *pgproto3.ParameterStatus, *pgproto3.BackendKeyData:
+			case *pgproto3.ParseComplete:
+				res = append(res, "ParseComplete")
+			case *pgproto3.BindComplete:
+				res = append(res, "BindComplete")
+			case *pgproto3.RowDescription:
+				res = append(res, "RowDescription")
+			case *pgproto3.DataRow:
+				res = append(res, fmt.Sprintf("DataRow %s", msg.Values[0]))
+			case *pgproto3.PortalSuspended:
+				res = append(res, "PortalSuspended")
+			case *pgproto3.CommandComplete:
+				res = append(res, fmt.Sprintf("CommandComplete %s", msg.CommandTag))
+			case *pgproto3.ErrorResponse:
+				res = append(res, fmt.Sprintf("ErrorResponse %s", msg.Code))
+			case *pgproto3.ReadyForQuery:
+				return append(res, fmt.Sprintf("ReadyForQuery %c", msg.TxStatus))
+			default:
+				t.Fatalf("unexpected message: %T", msg)
+			}
+		}
+	}
+	expect := func(expected ...string) {
+		t.Helper()
+		if res := recv(); !reflect.DeepEqual(res, expected) {
+			t.Fatalf("expected:\n%s\ngot:\n%s",
+				strings.Join(expected, "\n"), strings.Join(res, "\n"))
+		}
+	}
+
+	send(&pgproto3.StartupMessage{
+		ProtocolVersion: version30,
+		Parameters:      map[string]string{"user": security.RootUser},
+	})
+	expect("ReadyForQuery I")
+
+	send(&pgproto3.Query{String: "BEGIN"})
+	expect("CommandComplete BEGIN", "ReadyForQuery T")
+
+	send(
+		&pgproto3.Parse{Name: "s", Query: "SELECT generate_series(1, 5)"},
+		&pgproto3.Bind{DestinationPortal: "p1", PreparedStatement: "s"},
+		&pgproto3.Bind{DestinationPortal: "p2", PreparedStatement: "s"},
+		&pgproto3.Execute{Portal: "p1", MaxRows: 2},
+		&pgproto3.Execute{Portal: "p2", MaxRows: 3},
+		&pgproto3.Execute{Portal: "p1", MaxRows: 2},
+		&pgproto3.Execute{Portal: "p1", MaxRows: 2},
+		&pgproto3.Execute{Portal: "p2"},
+		&pgproto3.Execute{Portal: "p2", MaxRows: 1},
+		&pgproto3.Sync{},
+	)
+	expect(
+		"ParseComplete", "BindComplete", "BindComplete",
+		"DataRow 1", "DataRow 2", "PortalSuspended",
+		"DataRow 1", "DataRow 2", "DataRow 3", "PortalSuspended",
+		"DataRow 3", "DataRow 4", "PortalSuspended",
+		"DataRow 5", "CommandComplete SELECT 1",
+		"DataRow 4", "DataRow 5", "CommandComplete SELECT 2",
+		"CommandComplete SELECT 0",
+		"ReadyForQuery T",
+	)
+
+	send(&pgproto3.Query{String: "DECLARE c CURSOR FOR SELECT generate_series(1, 3)"})
+	expect("CommandComplete DECLARE CURSOR", "ReadyForQuery T")
+	send(&pgproto3.Query{String: "DECLARE c CURSOR FOR SELECT 1"})
+	expect("ErrorResponse 42P03", "ReadyForQuery E")
+	send(&pgproto3.Query{String: "ROLLBACK"})
+	expect("CommandComplete ROLLBACK", "ReadyForQuery I")
+
+	send(&pgproto3.Query{String: "DECLARE c CURSOR FOR SELECT 1"})
+	expect("ErrorResponse 25P01", "ReadyForQuery I")
+
+	send(&pgproto3.Query{String: `BEGIN;
+DECLARE c CURSOR FOR SELECT generate_series(1, 3);
+FETCH 2 FROM c;
+FETCH ALL FROM c;
+FETCH 1 FROM c;
+CLOSE c;
+COMMIT`})
+	expect(
+		"CommandComplete BEGIN",
+		"CommandComplete DECLARE CURSOR",
+		"RowDescription", "DataRow 1", "DataRow 2", "CommandComplete FETCH 2",
+		"RowDescription", "DataRow 3", "CommandComplete FETCH 1",
+		"RowDescription", "CommandComplete FETCH 0",
+		"CommandComplete CLOSE CURSOR",
+		"CommandComplete COMMIT",
+		"ReadyForQuery I",
+	)
+
+	send(&pgproto3.Query{String: "BEGIN; FETCH 1 FROM c"})
+	expect("CommandComplete BEGIN", "ErrorResponse 34000", "ReadyForQuery E")
+}
+
+// Test that a cursor's query only runs as far as needed for the rows fetched
+// so far: its flow is suspended between FETCH statements, while other
+// statements run in the transaction.
+func TestCursorIsSuspended(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	s, db, _ := serverutils.StartServer(t, base.TestServerArgs{})
+	ctx := context.Background()
+	defer s.Stopper().Stop(ctx)
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	// Running this query to completion would take forever, and would fail with
+	// a division by zero at the fourth row.
+	if _, err := tx.Exec(
+		`DECLARE c CURSOR FOR SELECT 12 // (4 - i) FROM generate_series(1, 1000000000000) AS g(i)`,
+	); err != nil {
+		t.Fatal(err)
+	}
+	fetch := func(query string) ([]int, error) {
+		rows, err := tx.Query(query)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		var res []int
+		for rows.Next() {
+			var i int
+			if err := rows.Scan(&i); err != nil {
+				return nil, err
+			}
+			res = append(res, i)
+		}
+		return res, rows.Err()
+	}
+	if res, err := fetch(`FETCH 2 FROM c`); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(res, []int{4, 6}) {
+		t.Fatalf("expected [4 6], found %v", res)
+	}
+	if res, err := fetch(`SELECT count(*) FROM generate_series(1, 10)`); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(res, []int{10}) {
+		t.Fatalf("expected [10], found %v", res)
+	}
+	// The third row is produced before the cursor is suspended, and the
+	// division by zero happens when the fourth one is.
+	if _, err := fetch(`FETCH 1 FROM c`); !testutils.IsError(err, "division by zero") {
+		t.Fatalf("expected division by zero, found %v", err)
+	}
+}
+
+// Test that notifications sent with NOTIFY are delivered to the sessions
+// listening on their channel once the notifying transaction commits, and only
+// while the listening session is not in a transaction.
+func TestListenNotify(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	s, db, _ := serverutils.StartServer(t, base.TestServerArgs{Insecure: true})
+	ctx := context.Background()
+	defer s.Stopper().Stop(ctx)
+	sqlDB := sqlutils.MakeSQLRunner(db)
+	sqlDB.Exec(t, `SET CLUSTER SETTING kv.rangefeed.enabled = true`)
+
+	conn, err := net.Dial("tcp", s.ServingAddr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	fe, err := pgproto3.NewFrontend(conn, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	send := func(msgs ...pgproto3.FrontendMessage) {
+		t.Helper()
+		for _, msg := range msgs {
+			if err := fe.Send(msg); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	// notifications accumulates the notifications received, which can arrive
+	// at any point between the results of queries.
+	var notifications []string
+	// receive reads a message. Notifications are added to notifications, in
+	// which case nil is returned.
+	receive := func() pgproto3.BackendMessage {
+		t.Helper()
+		msg, err := fe.Receive()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n, ok := msg.(*pgproto3.NotificationResponse); ok {
+			if n.PID != uint32(s.NodeID()) {
+				t.Fatalf("expected PID %d, got %d", s.NodeID(), n.PID)
+			}
+			notifications = append(notifications, fmt.Sprintf("%s %s", n.Channel, n.Payload))
+			return nil
+		}
+		return msg
+	}
+	// expect reads messages other than notifications until a ReadyForQuery
+	// and checks their description.
+	expect := func(expected ...string) {
+		t.Helper()
+		var res []string
+		for done := false; !done; {
+			switch msg := receive().(type) {
+			case nil:
+			case *pgproto3.Authentication, *pgproto3.ParameterStatus, *pgproto3.BackendKeyData:
+			case *pgproto3.RowDescription:
+			case *pgproto3.DataRow:
+				res = append(res, fmt.Sprintf("DataRow %s", msg.Values[0]))
+			case *pgproto3.CommandComplete:
+				res = append(res, fmt.Sprintf("CommandComplete %s", msg.CommandTag))
+			case *pgproto3.ErrorResponse:
+				res = append(res, fmt.Sprintf("ErrorResponse %s", msg.Code))
+			case *pgproto3.ReadyForQuery:
+				res = append(res, fmt.Sprintf("ReadyForQuery %c", msg.TxStatus))
+				done = true
+			default:
+				t.Fatalf("unexpected message: %T", msg)
+			}
+		}
+		if !reflect.DeepEqual(res, expected) {
+			t.Fatalf("expected:\n%s\ngot:\n%s",
+				strings.Join(expected, "\n"), strings.Join(res, "\n"))
+		}
+	}
+	// expectNotifications waits until the given notifications, and no others,
+	// have been received.
+	expectNotifications := func(expected ...string) {
+		t.Helper()
+		for len(notifications) < len(expected) {
+			if msg := receive(); msg != nil {
+				t.Fatalf("unexpected message: %T", msg)
+			}
+		}
+		sort.Strings(notifications)
+		sort.Strings(expected)
+		if !reflect.DeepEqual(notifications, expected) {
+			t.Fatalf("expected notifications %v, got %v", expected, notifications)
+		}
+		notifications = nil
+	}
+
+	send(&pgproto3.StartupMessage{
+		ProtocolVersion: version30,
+		Parameters:      map[string]string{"user": security.RootUser},
+	})
+	expect("ReadyForQuery I")
+
+	// LISTEN only takes effect once its transaction commits.
+	send(&pgproto3.Query{String: "BEGIN; LISTEN foo; LISTEN bar; ROLLBACK; LISTEN foo"})
+	expect(
+		"CommandComplete BEGIN", "CommandComplete LISTEN", "CommandComplete LISTEN",
+		"CommandComplete ROLLBACK", "CommandComplete LISTEN", "ReadyForQuery I",
+	)
+
+	sqlDB.Exec(t, `NOTIFY foo, 'a'`)
+	sqlDB.Exec(t, `SELECT pg_notify('foo', 'b')`)
+	expectNotifications("foo a", "foo b")
+
+	// Notifications are only visible once the notifying transaction commits,
+	// and are delivered once the listening session isn't in a transaction.
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Exec(`NOTIFY foo, 'c'; NOTIFY bar, 'd'`); err != nil {
+		t.Fatal(err)
+	}
+	send(&pgproto3.Query{String: "BEGIN; SELECT 1"})
+	expect("CommandComplete BEGIN", "DataRow 1", "CommandComplete SELECT 1", "ReadyForQuery T")
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	testutils.SucceedsSoon(t, func() error {
+		send(&pgproto3.Query{String: "SELECT 1"})
+		expect("DataRow 1", "CommandComplete SELECT 1", "ReadyForQuery T")
+		if len(notifications) != 0 {
+			t.Fatalf("unexpected notifications in transaction: %v", notifications)
+		}
+		var n int
+		sqlDB.QueryRow(t,
+			`SELECT count(*) FROM system.notifications WHERE payload = 'c'`,
+		).Scan(&n)
+		if n != 1 {
+			return errors.New("notification not committed yet")
+		}
+		return nil
+	})
+	send(&pgproto3.Query{String: "COMMIT"})
+	expect("CommandComplete COMMIT", "ReadyForQuery I")
+	expectNotifications("foo c")
+
+	// Once UNLISTEN has run, notifications on the channel are no longer
+	// delivered.
+	send(&pgproto3.Query{String: "UNLISTEN *; LISTEN bar"})
+	expect("CommandComplete UNLISTEN", "CommandComplete LISTEN", "ReadyForQuery I")
+	sqlDB.Exec(t, `NOTIFY foo, 'e'`)
+	sqlDB.Exec(t, `NOTIFY bar, 'f'`)
+	expectNotifications("bar f")
+}
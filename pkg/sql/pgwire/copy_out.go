@@ -0,0 +1,201 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package pgwire
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgwirebase"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sessiondata"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/lib/pq/oid"
+)
+
+// copyBinarySignature starts the header of the binary COPY format. It is
+// followed by a 32-bit flags field and a 32-bit header extension length, both
+// zero.
+const copyBinarySignature = "PGCOPY\n\377\r\n\000"
+
+// copyOutEncoder encodes the rows produced by a COPY TO STDOUT statement. Each
+// row becomes the payload of a CopyData message.
+//
+// See: https://www.postgresql.org/docs/current/static/sql-copy.html#id-1.9.3.55.9
+type copyOutEncoder struct {
+	// opts are the options of the statement, with the defaults filled in.
+	opts tree.CopyOptions
+	// scratch is used to encode values; the text encoding of a value is
+	// written in scratch before being escaped into buf.
+	scratch *writeBuffer
+	// buf accumulates the encoding of a row.
+	buf bytes.Buffer
+}
+
+func newCopyOutEncoder(opts tree.CopyOptions) *copyOutEncoder {
+	return &copyOutEncoder{
+		opts:    opts.WithDefaults(),
+		scratch: newWriteBuffer(nil /* bytecount */),
+	}
+}
+
+// formatCode returns the format announced in the CopyOutResponse message.
+func (e *copyOutEncoder) formatCode() pgwirebase.FormatCode {
+	if e.opts.FileFormat == tree.CopyFormatBinary {
+		return pgwirebase.FormatBinary
+	}
+	return pgwirebase.FormatText
+}
+
+// header returns the data preceding the rows, or nil if there is none: the
+// signature of the binary format, or the header line of the CSV format.
+func (e *copyOutEncoder) header(cols sqlbase.ResultColumns) []byte {
+	e.buf.Reset()
+	switch e.opts.FileFormat {
+	case tree.CopyFormatBinary:
+		e.buf.WriteString(copyBinarySignature)
+		e.buf.Write([]byte{0, 0, 0, 0, 0, 0, 0, 0})
+	case tree.CopyFormatCSV:
+		if !e.opts.Header {
+			return nil
+		}
+		for i, col := range cols {
+			if i > 0 {
+				e.buf.WriteByte(e.opts.Delimiter[0])
+			}
+			e.writeCSVField([]byte(col.Name))
+		}
+		e.buf.WriteByte('\n')
+	default:
+		return nil
+	}
+	return e.buf.Bytes()
+}
+
+// trailer returns the data following the rows, or nil if there is none.
+func (e *copyOutEncoder) trailer() []byte {
+	if e.opts.FileFormat == tree.CopyFormatBinary {
+		// A field count of -1.
+		return []byte{0xff, 0xff}
+	}
+	return nil
+}
+
+// encodeRow returns the encoding of a row. The result is only valid until the
+// next call.
+func (e *copyOutEncoder) encodeRow(
+	ctx context.Context, row tree.Datums, conv sessiondata.DataConversionConfig, oids []oid.Oid,
+) ([]byte, error) {
+	if e.opts.FileFormat == tree.CopyFormatBinary {
+		e.scratch.reset()
+		e.scratch.putInt16(int16(len(row)))
+		for i, d := range row {
+			e.scratch.writeBinaryDatum(ctx, d, conv.Location, oids[i])
+		}
+		if e.scratch.err != nil {
+			return nil, e.scratch.err
+		}
+		return e.scratch.wrapped.Bytes(), nil
+	}
+
+	e.buf.Reset()
+	for i, d := range row {
+		if i > 0 {
+			e.buf.WriteByte(e.opts.Delimiter[0])
+		}
+		if d == tree.DNull {
+			e.buf.WriteString(e.opts.Null)
+			continue
+		}
+		e.scratch.reset()
+		e.scratch.writeTextDatum(ctx, d, conv)
+		if e.scratch.err != nil {
+			return nil, e.scratch.err
+		}
+		// Skip the length prefix.
+		val := e.scratch.wrapped.Bytes()[4:]
+		if e.opts.FileFormat == tree.CopyFormatCSV {
+			e.writeCSVField(val)
+		} else {
+			e.writeTextField(val)
+		}
+	}
+	e.buf.WriteByte('\n')
+	return e.buf.Bytes(), nil
+}
+
+// writeTextField writes a value in the text format, escaping backslashes,
+// control characters and the delimiter with backslashes.
+func (e *copyOutEncoder) writeTextField(val []byte) {
+	delim := e.opts.Delimiter[0]
+	start := 0
+	for i, c := range val {
+		var esc byte
+		switch c {
+		case '\\':
+			esc = '\\'
+		case '\b':
+			esc = 'b'
+		case '\f':
+			esc = 'f'
+		case '\n':
+			esc = 'n'
+		case '\r':
+			esc = 'r'
+		case '\t':
+			esc = 't'
+		case '\v':
+			esc = 'v'
+		default:
+			if c != delim {
+				continue
+			}
+			esc = c
+		}
+		e.buf.Write(val[start:i])
+		e.buf.WriteByte('\\')
+		e.buf.WriteByte(esc)
+		start = i + 1
+	}
+	e.buf.Write(val[start:])
+}
+
+// writeCSVField writes a value in the CSV format. Values are quoted if they
+// contain the delimiter, the quote character or a line break, and if they
+// could be mistaken for NULL or for the end-of-data marker.
+func (e *copyOutEncoder) writeCSVField(val []byte) {
+	delim, quote, escape := e.opts.Delimiter[0], e.opts.Quote[0], e.opts.Escape[0]
+	needsQuotes := string(val) == e.opts.Null || string(val) == `\.`
+	for _, c := range val {
+		if c == delim || c == quote || c == '\n' || c == '\r' {
+			needsQuotes = true
+			break
+		}
+	}
+	if !needsQuotes {
+		e.buf.Write(val)
+		return
+	}
+	e.buf.WriteByte(quote)
+	start := 0
+	for i, c := range val {
+		if c != quote && c != escape {
+			continue
+		}
+		e.buf.Write(val[start:i])
+		e.buf.WriteByte(escape)
+		start = i
+	}
+	e.buf.Write(val[start:])
+	e.buf.WriteByte(quote)
+}
@@ -20,7 +20,7 @@ import (
 	"github.com/pkg/errors"
 )
 
-func Parse(input string) (*Conf, error) {
+func parse(input string) (*Conf, error) {
 	if !utf8.ValidString(input) {
 		return nil, errors.New("invalid UTF-8")
 	}
@@ -94,7 +94,11 @@ func (h Entry) String() string {
 	}
 	fmt.Fprintf(&sb, " %s %s", h.Address, h.Method)
 	for _, opt := range h.Options {
-		fmt.Fprintf(&sb, " %s=%s", opt[0], opt[1])
+		if isToken(opt[1]) {
+			fmt.Fprintf(&sb, " %s=%s", opt[0], opt[1])
+		} else {
+			fmt.Fprintf(&sb, ` %s="%s"`, opt[0], opt[1])
+		}
 	}
 	return sb.String()
 }
@@ -0,0 +1,106 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package hba
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Parse parses the contents of a pg_hba.conf file.
+//
+// As in PostgreSQL, option values can be double-quoted so that they can
+// contain characters other than letters, digits, '.', '_' and '-', which LDAP
+// distinguished names and search filters need. The ragel grammar in conf.rl
+// only accepts unquoted values, so quoted values are replaced by placeholder
+// tokens before the input is handed to it, and put back into the options of
+// the parsed entries afterwards.
+func Parse(input string) (*Conf, error) {
+	input, quoted := replaceQuotedOptions(input)
+	conf, err := parse(input)
+	if err != nil {
+		return nil, err
+	}
+	if len(quoted) > 0 {
+		for i := range conf.Entries {
+			for j, opt := range conf.Entries[i].Options {
+				if val, ok := quoted[opt[1]]; ok {
+					conf.Entries[i].Options[j][1] = val
+				}
+			}
+		}
+	}
+	return conf, nil
+}
+
+// replaceQuotedOptions replaces the double-quoted values of options (a
+// whitespace-preceded token followed by '=') in input with placeholder tokens
+// that do not otherwise appear in input. It returns the new input and a map
+// from the placeholders to the unquoted values. Values that are not terminated
+// on the same line are left alone for the parser to reject.
+func replaceQuotedOptions(input string) (string, map[string]string) {
+	prefix := "quoted"
+	for strings.Contains(input, prefix) {
+		prefix += "_"
+	}
+	var sb strings.Builder
+	var quoted map[string]string
+	for {
+		i := strings.Index(input, `="`)
+		if i == -1 {
+			break
+		}
+		start := i + len(`="`)
+		end := strings.IndexAny(input[start:], "\"\n")
+		if end == -1 || input[start+end] != '"' || !isOptionName(input[:i]) ||
+			!isOptionEnd(input[start+end+1:]) {
+			sb.WriteString(input[:start])
+			input = input[start:]
+			continue
+		}
+		if quoted == nil {
+			quoted = make(map[string]string)
+		}
+		placeholder := prefix + strconv.Itoa(len(quoted))
+		quoted[placeholder] = input[start : start+end]
+		sb.WriteString(input[:i+1])
+		sb.WriteString(placeholder)
+		input = input[start+end+1:]
+	}
+	sb.WriteString(input)
+	return sb.String(), quoted
+}
+
+// isOptionName returns whether s ends with whitespace followed by a token.
+func isOptionName(s string) bool {
+	i := strings.LastIndexAny(s, " \t")
+	return i != -1 && isToken(s[i+1:])
+}
+
+// isOptionEnd returns whether s starts with something that can follow an
+// option.
+func isOptionEnd(s string) bool {
+	return s == "" || strings.IndexByte(" \t\n#", s[0]) != -1
+}
+
+// isToken returns whether s is a non-empty string of letters, digits, '.', '_'
+// and '-'.
+func isToken(s string) bool {
+	for _, c := range s {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+			c == '.' || c == '_' || c == '-') {
+			return false
+		}
+	}
+	return s != ""
+}
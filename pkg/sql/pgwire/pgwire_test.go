@@ -16,6 +16,7 @@ import (
 	"context"
 	gosql "database/sql"
 	"database/sql/driver"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -38,6 +39,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/server/serverpb"
 	"github.com/cockroachdb/cockroach/pkg/server/telemetry"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
@@ -2305,11 +2307,107 @@ func TestCancelRequest(t *testing.T) {
 	if _, err := fe.Receive(); err != io.EOF {
 		t.Fatalf("unexpected: %v", err)
 	}
-	if count := telemetry.GetRawFeatureCounts()["pgwire.unimplemented.cancel_request"]; count != 1 {
+	if count := telemetry.GetRawFeatureCounts()["pgwire.cancel_request"]; count != 1 {
 		t.Fatalf("expected 1 cancel request, got %d", count)
 	}
 }
 
+// TestCancelRequestCancelsQuery verifies that a CancelRequest carrying the key
+// sent in the BackendKeyData message cancels the query running on the
+// connection.
+func TestCancelRequestCancelsQuery(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	params := base.TestServerArgs{Insecure: true}
+	s, _, _ := serverutils.StartServer(t, params)
+
+	ctx := context.TODO()
+	defer s.Stopper().Stop(ctx)
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", s.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	fe, err := pgproto3.NewFrontend(conn, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fe.Send(&pgproto3.StartupMessage{
+		ProtocolVersion: 196608,
+		Parameters:      map[string]string{"user": security.RootUser},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	var keyData *pgproto3.BackendKeyData
+	for {
+		msg, err := fe.Receive()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m, ok := msg.(*pgproto3.BackendKeyData); ok {
+			keyData = m
+		}
+		if _, ok := msg.(*pgproto3.ReadyForQuery); ok {
+			break
+		}
+	}
+	if keyData == nil {
+		t.Fatal("expected BackendKeyData message during the handshake")
+	}
+
+	if err := fe.Send(&pgproto3.Query{String: "SELECT pg_sleep(300)"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Send the CancelRequest over a separate connection until the query has
+	// started and is canceled.
+	const versionCancel = 80877102
+	cancelMsg := make([]byte, 16)
+	binary.BigEndian.PutUint32(cancelMsg[0:], uint32(len(cancelMsg)))
+	binary.BigEndian.PutUint32(cancelMsg[4:], versionCancel)
+	binary.BigEndian.PutUint32(cancelMsg[8:], keyData.ProcessID)
+	binary.BigEndian.PutUint32(cancelMsg[12:], keyData.SecretKey)
+	errCh := make(chan *pgproto3.ErrorResponse, 1)
+	go func() {
+		for {
+			msg, err := fe.Receive()
+			if err != nil {
+				close(errCh)
+				return
+			}
+			if m, ok := msg.(*pgproto3.ErrorResponse); ok {
+				errCh <- m
+				return
+			}
+		}
+	}()
+	testutils.SucceedsSoon(t, func() error {
+		cancelConn, err := d.DialContext(ctx, "tcp", s.Addr())
+		if err != nil {
+			return err
+		}
+		defer cancelConn.Close()
+		if _, err := cancelConn.Write(cancelMsg); err != nil {
+			return err
+		}
+		select {
+		case m, ok := <-errCh:
+			if !ok {
+				t.Fatal("connection closed before the query was canceled")
+			}
+			if m.Code != pgerror.CodeQueryCanceledError {
+				t.Fatalf("expected query canceled error, got %v", m)
+			}
+			return nil
+		case <-time.After(100 * time.Millisecond):
+			return errors.New("query not canceled yet")
+		}
+	})
+}
+
 func TestFailPrepareFailsTxn(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
@@ -2341,3 +2439,109 @@ func TestFailPrepareFailsTxn(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// TestCopyFormats verifies COPY FROM STDIN and COPY TO STDOUT in the text,
+// CSV and binary formats.
+func TestCopyFormats(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	params := base.TestServerArgs{Insecure: true}
+	s, _, _ := serverutils.StartServer(t, params)
+
+	ctx := context.TODO()
+	defer s.Stopper().Stop(ctx)
+
+	host, ports, _ := net.SplitHostPort(s.ServingAddr())
+	port, _ := strconv.Atoi(ports)
+
+	conn, err := pgx.Connect(pgx.ConnConfig{
+		Host:      host,
+		Port:      uint16(port),
+		User:      security.RootUser,
+		Database:  "defaultdb",
+		TLSConfig: nil, // insecure
+		Logger:    pgxTestLogger{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	if _, err := conn.Exec(`CREATE TABLE t (i INT PRIMARY KEY, s STRING)`); err != nil {
+		t.Fatal(err)
+	}
+
+	// pgx.CopyFrom uses the binary format.
+	n, err := conn.CopyFrom(pgx.Identifier{"t"}, []string{"i", "s"}, pgx.CopyFromRows([][]interface{}{
+		{1, "a\tb"},
+		{2, nil},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Fatalf("expected 2 rows, got %d", n)
+	}
+	csvIn := "i,s\n3,\"c,\"\"d\"\"\"\n4,\n5,\"\"\n"
+	if _, err := conn.CopyFromReader(
+		strings.NewReader(csvIn), `COPY t FROM STDIN WITH (FORMAT csv, HEADER)`,
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		stmt     string
+		expected string
+	}{
+		{
+			stmt:     `COPY t TO STDOUT`,
+			expected: "1\ta\\tb\n2\t\\N\n3\tc,\"d\"\n4\t\\N\n5\t\n",
+		},
+		{
+			stmt:     `COPY t (s) TO STDOUT WITH (NULL 'null')`,
+			expected: "a\\tb\nnull\nc,\"d\"\nnull\n\n",
+		},
+		{
+			stmt:     `COPY t TO STDOUT WITH (FORMAT csv, HEADER)`,
+			expected: "i,s\n1,a\tb\n2,\n3,\"c,\"\"d\"\"\"\n4,\n5,\"\"\n",
+		},
+		{
+			stmt:     `COPY (SELECT i FROM t WHERE i > 3) TO STDOUT CSV DELIMITER '|'`,
+			expected: "4\n5\n",
+		},
+		{
+			stmt: `COPY (SELECT 7::INT2) TO STDOUT BINARY`,
+			expected: "PGCOPY\n\377\r\n\000" + "\x00\x00\x00\x00" + "\x00\x00\x00\x00" +
+				"\x00\x01" + "\x00\x00\x00\x02" + "\x00\x07" + "\xff\xff",
+		},
+	} {
+		t.Run(tc.stmt, func(t *testing.T) {
+			var buf strings.Builder
+			tag, err := conn.CopyToWriter(&buf, tc.stmt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(string(tag), "COPY ") {
+				t.Errorf("unexpected command tag %q", tag)
+			}
+			if buf.String() != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, buf.String())
+			}
+		})
+	}
+
+	for _, tc := range []struct {
+		stmt        string
+		expectedErr string
+	}{
+		{`COPY t FROM STDIN WITH (FORMAT json)`, `COPY format "json" not recognized`},
+		{`COPY t FROM STDIN WITH (FORMAT binary, DELIMITER ',')`, `cannot specify DELIMITER or NULL in BINARY mode`},
+		{`COPY t FROM STDIN WITH (HEADER)`, `COPY HEADER available only in CSV mode`},
+		{`COPY t FROM STDIN WITH (FORMAT csv, DELIMITER '"')`, `COPY delimiter and quote must be different`},
+		{`COPY t FROM STDIN WITH (NULL 'a', NULL 'b')`, `conflicting or redundant options`},
+	} {
+		if _, err := conn.CopyFromReader(strings.NewReader(""), tc.stmt); !testutils.IsError(err, tc.expectedErr) {
+			t.Errorf("%s: expected %q, got %v", tc.stmt, tc.expectedErr, err)
+		}
+	}
+}
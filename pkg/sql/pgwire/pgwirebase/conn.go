@@ -30,11 +30,12 @@ type Conn interface {
 
 	// BeginCopyIn sends the message server message initiating the Copy-in
 	// subprotocol (COPY ... FROM STDIN). This message informs the client about
-	// the columns that are expected for the rows to be inserted.
+	// the columns that are expected for the rows to be inserted, and about the
+	// format of the data: FormatText for the text and CSV formats, FormatBinary
+	// for the binary format.
 	//
-	// Currently, we only support the "text" format for COPY IN.
 	// See: https://www.postgresql.org/docs/current/static/protocol-flow.html#PROTOCOL-COPY
-	BeginCopyIn(ctx context.Context, columns []sqlbase.ResultColumn) error
+	BeginCopyIn(ctx context.Context, columns []sqlbase.ResultColumn, format FormatCode) error
 
 	// SendCommandComplete sends a serverMsgCommandComplete with the given
 	// payload.
@@ -0,0 +1,228 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package pgwire
+
+import (
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/hba"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgwirebase"
+	"github.com/pkg/errors"
+)
+
+// scramMechanism is the name of the only SASL mechanism we support.
+const scramMechanism = "SCRAM-SHA-256"
+
+// scramNonceLength is the number of random bytes in the server's part of the
+// SCRAM nonce.
+const scramNonceLength = 18
+
+// authScram authenticates the client with the SCRAM-SHA-256 SASL mechanism
+// (RFC 5802, RFC 7677), which lets the client prove that it knows the password
+// without ever sending it to the server. Only users whose password is stored
+// as a SCRAM-SHA-256 hash can authenticate this way; bcrypt hashes are upgraded
+// the next time the user logs in with password authentication.
+//
+// Channel binding is not supported.
+func authScram(
+	c AuthConn,
+	tlsState tls.ConnectionState,
+	insecure bool,
+	hashedPassword []byte,
+	execCfg *sql.ExecutorConfig,
+	entry *hba.Entry,
+) (security.UserAuthHook, error) {
+	// The list of mechanisms is terminated by an empty string.
+	if err := c.SendAuthRequest(authSASL, []byte(scramMechanism+"\x00\x00")); err != nil {
+		return nil, err
+	}
+
+	// Read the SASLInitialResponse message.
+	initialResp, err := c.GetPwdData()
+	if err != nil {
+		return nil, err
+	}
+	buf := pgwirebase.ReadBuffer{Msg: initialResp}
+	mechanism, err := buf.GetString()
+	if err != nil {
+		return nil, err
+	}
+	if mechanism != scramMechanism {
+		return nil, pgwirebase.NewProtocolViolationErrorf(
+			"client selected an invalid SASL authentication mechanism: %q", mechanism)
+	}
+	n, err := buf.GetUint32()
+	if err != nil {
+		return nil, err
+	}
+	// A length of -1 means that there is no initial response, which the
+	// SCRAM mechanism doesn't permit.
+	if int32(n) < 0 {
+		return nil, pgwirebase.NewProtocolViolationErrorf(
+			"invalid SASL initial response length: %d", int32(n))
+	}
+	clientFirst, err := buf.GetBytes(int(n))
+	if err != nil {
+		return nil, err
+	}
+	gs2Header, clientFirstBare, clientNonce, err := parseScramClientFirst(string(clientFirst))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(hashedPassword) == 0 {
+		return nil, errors.New("user has no password defined")
+	}
+	if !security.IsScramHash(hashedPassword) {
+		return nil, errors.New("the password of the user is not stored as a SCRAM-SHA-256 hash; " +
+			"log in once using password authentication or set the password again")
+	}
+	creds, err := security.ParseScramHash(hashedPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	// Send the server-first-message.
+	serverNonce := make([]byte, scramNonceLength)
+	if _, err := rand.Read(serverNonce); err != nil {
+		return nil, err
+	}
+	nonce := clientNonce + base64.StdEncoding.EncodeToString(serverNonce)
+	serverFirst := fmt.Sprintf("r=%s,s=%s,i=%d",
+		nonce, base64.StdEncoding.EncodeToString(creds.Salt), creds.Iterations)
+	if err := c.SendAuthRequest(authSASLContinue, []byte(serverFirst)); err != nil {
+		return nil, err
+	}
+
+	// Read the client-final-message from the SASLResponse message.
+	clientFinal, err := c.GetPwdData()
+	if err != nil {
+		return nil, err
+	}
+	clientFinalWithoutProof, proof, err := parseScramClientFinal(string(clientFinal), gs2Header, nonce)
+	if err != nil {
+		return nil, err
+	}
+	authMessage := []byte(clientFirstBare + "," + serverFirst + "," + clientFinalWithoutProof)
+	verified := creds.VerifyClientProof(authMessage, proof)
+	if verified {
+		// Prove to the client that we know its credentials too.
+		serverFinal := "v=" + base64.StdEncoding.EncodeToString(creds.ServerSignature(authMessage))
+		if err := c.SendAuthRequest(authSASLFinal, []byte(serverFinal)); err != nil {
+			return nil, err
+		}
+	}
+
+	return func(requestedUser string, clientConnection bool) error {
+		if len(requestedUser) == 0 {
+			return errors.New("user is missing")
+		}
+		if !clientConnection {
+			return errors.New("password authentication is only available for client connections")
+		}
+		if insecure {
+			return nil
+		}
+		if requestedUser == security.RootUser {
+			return errors.Errorf("user %s must use certificate authentication instead of password authentication", security.RootUser)
+		}
+		if !verified {
+			return errors.Errorf(security.ErrPasswordUserAuthFailed, requestedUser)
+		}
+		return nil
+	}, nil
+}
+
+// parseScramClientFirst parses a SCRAM client-first-message. It returns the
+// GS2 header, the rest of the message (client-first-message-bare) and the
+// client's nonce.
+func parseScramClientFirst(
+	msg string,
+) (gs2Header, clientFirstBare, clientNonce string, err error) {
+	// The message starts with the GS2 header: a channel binding flag and an
+	// authorization identity, followed by a comma each.
+	parts := strings.SplitN(msg, ",", 3)
+	if len(parts) != 3 {
+		return "", "", "", pgwirebase.NewProtocolViolationErrorf("malformed SCRAM message")
+	}
+	switch {
+	case parts[0] == "n", parts[0] == "y":
+		// The client doesn't support channel binding, or thinks that the server
+		// doesn't.
+	case strings.HasPrefix(parts[0], "p="):
+		return "", "", "", pgwirebase.NewProtocolViolationErrorf(
+			"SCRAM channel binding is not supported")
+	default:
+		return "", "", "", pgwirebase.NewProtocolViolationErrorf(
+			"malformed SCRAM message: unexpected channel binding flag %q", parts[0])
+	}
+	if parts[1] != "" {
+		return "", "", "", pgwirebase.NewProtocolViolationErrorf(
+			"SCRAM authorization identities are not supported")
+	}
+	gs2Header = parts[0] + "," + parts[1] + ","
+	clientFirstBare = parts[2]
+
+	// The user name is ignored, as the user has already been specified in the
+	// startup message. Like PostgreSQL, clients usually leave it empty.
+	attrs := strings.Split(clientFirstBare, ",")
+	if strings.HasPrefix(attrs[0], "m=") {
+		return "", "", "", pgwirebase.NewProtocolViolationErrorf(
+			"SCRAM mandatory extensions are not supported")
+	}
+	if len(attrs) < 2 || !strings.HasPrefix(attrs[0], "n=") || !strings.HasPrefix(attrs[1], "r=") {
+		return "", "", "", pgwirebase.NewProtocolViolationErrorf("malformed SCRAM message")
+	}
+	clientNonce = attrs[1][len("r="):]
+	if clientNonce == "" {
+		return "", "", "", pgwirebase.NewProtocolViolationErrorf("malformed SCRAM nonce")
+	}
+	return gs2Header, clientFirstBare, clientNonce, nil
+}
+
+// parseScramClientFinal parses a SCRAM client-final-message, checking that it
+// echoes the GS2 header and the combined nonce of the exchange. It returns the
+// message without its proof, which is part of the AuthMessage that is signed,
+// and the decoded proof.
+func parseScramClientFinal(
+	msg string, gs2Header string, nonce string,
+) (clientFinalWithoutProof string, proof []byte, err error) {
+	idx := strings.LastIndex(msg, ",p=")
+	if idx == -1 {
+		return "", nil, pgwirebase.NewProtocolViolationErrorf("malformed SCRAM message: missing proof")
+	}
+	clientFinalWithoutProof = msg[:idx]
+	if proof, err = base64.StdEncoding.DecodeString(msg[idx+len(",p="):]); err != nil {
+		return "", nil, pgwirebase.NewProtocolViolationErrorf("malformed SCRAM proof")
+	}
+
+	attrs := strings.Split(clientFinalWithoutProof, ",")
+	if len(attrs) < 2 || !strings.HasPrefix(attrs[0], "c=") || !strings.HasPrefix(attrs[1], "r=") {
+		return "", nil, pgwirebase.NewProtocolViolationErrorf("malformed SCRAM message")
+	}
+	binding, err := base64.StdEncoding.DecodeString(attrs[0][len("c="):])
+	if err != nil || string(binding) != gs2Header {
+		return "", nil, pgwirebase.NewProtocolViolationErrorf(
+			"unexpected SCRAM channel binding data")
+	}
+	if attrs[1][len("r="):] != nonce {
+		return "", nil, pgwirebase.NewProtocolViolationErrorf("SCRAM nonce does not match")
+	}
+	return clientFinalWithoutProof, proof, nil
+}
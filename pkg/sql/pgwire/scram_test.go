@@ -0,0 +1,209 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package pgwire
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// scramTestClient implements AuthConn by playing the client's side of a
+// SCRAM-SHA-256 exchange.
+type scramTestClient struct {
+	password    string
+	clientNonce string
+	// initialResponse, if set, replaces the SASLInitialResponse message.
+	initialResponse []byte
+
+	authTypes   []int32
+	serverFirst string
+	serverFinal string
+	// serverSignature is the signature the client expects in serverFinal.
+	serverSignature string
+	step            int
+}
+
+var _ AuthConn = &scramTestClient{}
+
+func (c *scramTestClient) SendAuthRequest(authType int32, data []byte) error {
+	c.authTypes = append(c.authTypes, authType)
+	switch authType {
+	case authSASLContinue:
+		c.serverFirst = string(data)
+	case authSASLFinal:
+		c.serverFinal = string(data)
+	}
+	return nil
+}
+
+func (c *scramTestClient) GetPwdData() ([]byte, error) {
+	const clientFirstBare = "n=,r="
+	c.step++
+	switch c.step {
+	case 1:
+		if c.initialResponse != nil {
+			return c.initialResponse, nil
+		}
+		clientFirst := "n,," + clientFirstBare + c.clientNonce
+		buf := []byte(scramMechanism + "\x00")
+		buf = append(buf, 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(buf[len(buf)-4:], uint32(len(clientFirst)))
+		return append(buf, clientFirst...), nil
+
+	case 2:
+		var nonce, salt string
+		var iters int
+		for _, attr := range strings.Split(c.serverFirst, ",") {
+			switch {
+			case strings.HasPrefix(attr, "r="):
+				nonce = attr[2:]
+			case strings.HasPrefix(attr, "s="):
+				salt = attr[2:]
+			case strings.HasPrefix(attr, "i="):
+				var err error
+				if iters, err = strconv.Atoi(attr[2:]); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if !strings.HasPrefix(nonce, c.clientNonce) {
+			return nil, errors.Errorf("unexpected nonce %q", nonce)
+		}
+		saltBytes, err := base64.StdEncoding.DecodeString(salt)
+		if err != nil {
+			return nil, err
+		}
+		hmacSum := func(key []byte, msg string) []byte {
+			h := hmac.New(sha256.New, key)
+			_, _ = h.Write([]byte(msg))
+			return h.Sum(nil)
+		}
+		saltedPassword := pbkdf2.Key([]byte(c.password), saltBytes, iters, sha256.Size, sha256.New)
+		clientKey := hmacSum(saltedPassword, "Client Key")
+		storedKey := sha256.Sum256(clientKey)
+		clientFinalWithoutProof := "c=biws,r=" + nonce
+		authMessage := clientFirstBare + c.clientNonce + "," + c.serverFirst + "," + clientFinalWithoutProof
+		clientSignature := hmacSum(storedKey[:], authMessage)
+		proof := make([]byte, len(clientKey))
+		for i := range clientKey {
+			proof[i] = clientKey[i] ^ clientSignature[i]
+		}
+		c.serverSignature = base64.StdEncoding.EncodeToString(
+			hmacSum(hmacSum(saltedPassword, "Server Key"), authMessage))
+		return []byte(clientFinalWithoutProof + ",p=" + base64.StdEncoding.EncodeToString(proof)), nil
+	}
+	return nil, errors.New("unexpected auth data request")
+}
+
+func (c *scramTestClient) AuthOK(unqualifiedIntSizer) {}
+func (c *scramTestClient) AuthFail(error)             {}
+
+func TestAuthScram(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	scramHash, err := security.HashPasswordScram("pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bcryptHash, err := security.HashPassword("pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		password       string
+		hashedPassword []byte
+		// Error returned by the exchange.
+		err string
+		// Error returned by the authentication hook.
+		hookErr string
+	}{
+		{password: "pass", hashedPassword: scramHash},
+		{password: "wrong", hashedPassword: scramHash, hookErr: "password authentication failed"},
+		{password: "pass", hashedPassword: bcryptHash, err: "not stored as a SCRAM-SHA-256 hash"},
+		{password: "pass", hashedPassword: nil, err: "no password defined"},
+	}
+	for i, tc := range testCases {
+		c := &scramTestClient{password: tc.password, clientNonce: "rOprNGfwEbeRWgbNEkqO"}
+		hook, err := authScram(c, tls.ConnectionState{}, false /* insecure */, tc.hashedPassword,
+			nil /* execCfg */, nil /* entry */)
+		if !testutils.IsError(err, tc.err) {
+			t.Fatalf("%d: expected error %q, got %v", i, tc.err, err)
+		}
+		if err != nil {
+			continue
+		}
+		if err := hook("testuser", true /* clientConnection */); !testutils.IsError(err, tc.hookErr) {
+			t.Fatalf("%d: expected hook error %q, got %v", i, tc.hookErr, err)
+		}
+		if tc.hookErr != "" {
+			if c.serverFinal != "" {
+				t.Fatalf("%d: unexpected server-final-message %q", i, c.serverFinal)
+			}
+			continue
+		}
+		if expected := "v=" + c.serverSignature; c.serverFinal != expected {
+			t.Fatalf("%d: expected server-final-message %q, got %q", i, expected, c.serverFinal)
+		}
+		if expected := []int32{authSASL, authSASLContinue, authSASLFinal}; len(c.authTypes) != 3 ||
+			c.authTypes[0] != expected[0] || c.authTypes[1] != expected[1] || c.authTypes[2] != expected[2] {
+			t.Fatalf("%d: expected auth requests %v, got %v", i, expected, c.authTypes)
+		}
+	}
+}
+
+func TestAuthScramMalformedInitialResponse(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	scramHash, err := security.HashPasswordScram("pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const clientFirst = "n,,n=,r=rOprNGfwEbeRWgbNEkqO"
+	initialResponse := func(n uint32) []byte {
+		buf := []byte(scramMechanism + "\x00")
+		buf = append(buf, 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(buf[len(buf)-4:], n)
+		return append(buf, clientFirst...)
+	}
+
+	testCases := []struct {
+		initialResponse []byte
+		err             string
+	}{
+		{initialResponse: initialResponse(0xffffffff), err: "invalid SASL initial response length: -1"},
+		{initialResponse: initialResponse(0x80000000), err: "invalid SASL initial response length: -2147483648"},
+		{initialResponse: initialResponse(uint32(len(clientFirst) + 1)), err: "insufficient data"},
+		{initialResponse: []byte("SCRAM-SHA-1\x00"), err: "invalid SASL authentication mechanism"},
+		{initialResponse: []byte(scramMechanism + "\x00\x00"), err: "insufficient data"},
+	}
+	for i, tc := range testCases {
+		c := &scramTestClient{initialResponse: tc.initialResponse}
+		if _, err := authScram(c, tls.ConnectionState{}, false /* insecure */, scramHash,
+			nil /* execCfg */, nil /* entry */); !testutils.IsError(err, tc.err) {
+			t.Fatalf("%d: expected error %q, got %v", i, tc.err, err)
+		}
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/server/serverpb"
 	"github.com/cockroachdb/cockroach/pkg/server/telemetry"
 	"github.com/cockroachdb/cockroach/pkg/settings"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
@@ -114,6 +115,16 @@ const (
 // react to cancellation and return before a forceful shutdown.
 const cancelMaxWait = 1 * time.Second
 
+// maxConcurrentCancelRequests bounds the number of CancelRequests handled
+// concurrently by a server. Requests exceeding it are dropped.
+const maxConcurrentCancelRequests = 16
+
+// failedCancelRequestPenalty is the amount of time for which a CancelRequest
+// that did not match any session keeps occupying its slot among the
+// maxConcurrentCancelRequests, which bounds the rate at which a client can
+// guess cancel keys.
+const failedCancelRequestPenalty = 1 * time.Second
+
 // baseSQLMemoryBudget is the amount of memory pre-allocated in each connection.
 var baseSQLMemoryBudget = envutil.EnvOrDefaultInt64("COCKROACH_BASE_SQL_MEMORY_BUDGET",
 	int64(2.1*float64(mon.DefaultPoolAllocationSize)))
@@ -158,6 +169,10 @@ type Server struct {
 	sqlMemoryPool mon.BytesMonitor
 	connMonitor   mon.BytesMonitor
 
+	// cancelSem limits the concurrency of CancelRequests. See
+	// maxConcurrentCancelRequests.
+	cancelSem chan struct{}
+
 	stopper *stop.Stopper
 }
 
@@ -211,6 +226,7 @@ func MakeServer(
 		cfg:        cfg,
 		execCfg:    executorConfig,
 		metrics:    makeServerMetrics(sqlMemMetrics, histogramWindow),
+		cancelSem:  make(chan struct{}, maxConcurrentCancelRequests),
 	}
 	server.sqlMemoryPool = mon.MakeMonitor("sql",
 		mon.MemoryResource,
@@ -494,7 +510,24 @@ func (s *Server) ServeConn(ctx context.Context, conn net.Conn) error {
 	if version != version30 {
 		if version == versionCancel {
 			telemetry.Inc(sqltelemetry.CancelRequestCounter)
+			// The client does not expect any response to a CancelRequest, so we
+			// close the connection right away and report errors only to the log.
 			_ = conn.Close()
+			select {
+			case s.cancelSem <- struct{}{}:
+			default:
+				log.Infof(ctx, "dropping CancelRequest: too many concurrent requests")
+				return nil
+			}
+			defer func() { <-s.cancelSem }()
+			if err := s.handleCancel(ctx, &buf); err != nil {
+				log.Infof(ctx, "error handling CancelRequest: %v", err)
+				// Penalize the failed request to slow down the guessing of keys.
+				select {
+				case <-time.After(failedCancelRequestPenalty):
+				case <-s.stopper.ShouldQuiesce():
+				}
+			}
 			return nil
 		}
 		return sendErr(fmt.Errorf("unknown protocol version %d", version))
@@ -548,6 +581,35 @@ func (s *Server) ServeConn(ctx context.Context, conn net.Conn) error {
 	return nil
 }
 
+// handleCancel handles a CancelRequest message, whose body (following the
+// protocol version) contains the cancel key that was sent to the client in the
+// BackendKeyData message when its connection was established. The session
+// with that key, which is looked up on all the nodes, has the queries it is
+// currently running canceled.
+func (s *Server) handleCancel(ctx context.Context, buf *pgwirebase.ReadBuffer) error {
+	hi, err := buf.GetUint32()
+	if err != nil {
+		return err
+	}
+	lo, err := buf.GetUint32()
+	if err != nil {
+		return err
+	}
+	if s.execCfg.StatusServer == nil {
+		return errors.New("status server not available")
+	}
+	resp, err := s.execCfg.StatusServer.CancelQueryByKey(ctx, &serverpb.CancelQueryByKeyRequest{
+		CancelKey: uint64(hi)<<32 | uint64(lo),
+	})
+	if err != nil {
+		return err
+	}
+	if resp.Error != "" {
+		return errors.New(resp.Error)
+	}
+	return nil
+}
+
 // -1 for the sentinel in case someone wants to set it to 0.
 const connResultsBufferSizeUnsetSentinel = -1
 
@@ -173,6 +173,7 @@ var _ planNode = &cancelQueriesNode{}
 var _ planNode = &cancelSessionsNode{}
 var _ planNode = &createDatabaseNode{}
 var _ planNode = &createIndexNode{}
+var _ planNode = &createPolicyNode{}
 var _ planNode = &createSequenceNode{}
 var _ planNode = &createStatsNode{}
 var _ planNode = &createTableNode{}
@@ -184,6 +185,7 @@ var _ planNode = &deleteRangeNode{}
 var _ planNode = &distinctNode{}
 var _ planNode = &dropDatabaseNode{}
 var _ planNode = &dropIndexNode{}
+var _ planNode = &dropPolicyNode{}
 var _ planNode = &dropSequenceNode{}
 var _ planNode = &dropTableNode{}
 var _ planNode = &DropUserNode{}
@@ -630,6 +632,8 @@ func (p *planner) newPlan(
 		return p.CreateDatabase(ctx, n)
 	case *tree.CreateIndex:
 		return p.CreateIndex(ctx, n)
+	case *tree.CreatePolicy:
+		return p.CreatePolicy(ctx, n)
 	case *tree.CreateTable:
 		return p.CreateTable(ctx, n)
 	case *tree.CreateUser:
@@ -640,6 +644,8 @@ func (p *planner) newPlan(
 		return p.CreateSequence(ctx, n)
 	case *tree.CreateStats:
 		return p.CreateStatistics(ctx, n)
+	case *tree.CreateStmtHints:
+		return p.CreateStmtHints(ctx, n)
 	case *tree.Deallocate:
 		return p.Deallocate(ctx, n)
 	case *tree.Delete:
@@ -650,12 +656,16 @@ func (p *planner) newPlan(
 		return p.DropDatabase(ctx, n)
 	case *tree.DropIndex:
 		return p.DropIndex(ctx, n)
+	case *tree.DropPolicy:
+		return p.DropPolicy(ctx, n)
 	case *tree.DropTable:
 		return p.DropTable(ctx, n)
 	case *tree.DropView:
 		return p.DropView(ctx, n)
 	case *tree.DropSequence:
 		return p.DropSequence(ctx, n)
+	case *tree.DropStmtHints:
+		return p.DropStmtHints(ctx, n)
 	case *tree.DropUser:
 		return p.DropUser(ctx, n)
 	case *tree.Explain:
@@ -664,6 +674,8 @@ func (p *planner) newPlan(
 		return p.Grant(ctx, n)
 	case *tree.Insert:
 		return p.Insert(ctx, n, desiredTypes)
+	case *tree.Notify:
+		return p.Notify(ctx, n)
 	case *tree.ParenSelect:
 		return p.newPlan(ctx, n.Select, desiredTypes)
 	case *tree.Relocate:
@@ -874,6 +886,10 @@ const (
 	// did not find one.
 	planFlagOptCacheMiss
 
+	// planFlagOptStmtHints is set if the plan was created using hints from
+	// system.statement_hints.
+	planFlagOptStmtHints
+
 	// planFlagDistributed is set if the plan is for the DistSQL engine, in
 	// distributed mode.
 	planFlagDistributed
@@ -93,6 +93,8 @@ func getPlanColumns(plan planNode, mut bool) sqlbase.ResultColumns {
 		return n.columns
 	case *lookupJoinNode:
 		return n.columns
+	case *invertedJoinNode:
+		return n.columns
 	case *zigzagJoinNode:
 		return n.columns
 
@@ -100,6 +102,9 @@ func getPlanColumns(plan planNode, mut bool) sqlbase.ResultColumns {
 	case *scrubNode:
 		return n.getColumns(mut, scrubColumns)
 	case *explainDistSQLNode:
+		if n.debug {
+			return n.getColumns(mut, sqlbase.ExplainAnalyzeDebugColumns)
+		}
 		return n.getColumns(mut, sqlbase.ExplainDistSQLColumns)
 	case *relocateNode:
 		return n.getColumns(mut, relocateNodeColumns)
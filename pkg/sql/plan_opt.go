@@ -14,9 +14,11 @@ package sql
 
 import (
 	"context"
+	"strings"
 
 	"github.com/cockroachdb/cockroach/pkg/security"
 	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/sql/opt"
 	"github.com/cockroachdb/cockroach/pkg/sql/opt/exec/execbuilder"
 	"github.com/cockroachdb/cockroach/pkg/sql/opt/memo"
 	"github.com/cockroachdb/cockroach/pkg/sql/opt/optbuilder"
@@ -147,6 +149,13 @@ func (p *planner) makeOptimizerPlan(ctx context.Context) (_ *planTop, isCorrelat
 		return nil, isCorrelated, err
 	}
 
+	if p.diagnostics == nil && isExplainAnalyzeDebug(stmt.AST) {
+		p.diagnostics = &stmtDiagnosticsCollector{}
+	}
+	if p.diagnostics != nil {
+		p.diagnostics.recordOptimizerPlan(opc, execMemo)
+	}
+
 	// Build the plan tree.
 	root := execMemo.RootExpr()
 	execFactory := makeExecFactory(p)
@@ -189,6 +198,10 @@ type optPlanningCtx struct {
 	// allowMemoReuse is false.
 	useCache bool
 
+	// hints are the statement hints for the fingerprint of the statement, if
+	// any (see system.statement_hints).
+	hints *opt.StmtHints
+
 	flags planFlags
 }
 
@@ -232,6 +245,25 @@ func (opc *optPlanningCtx) reset() {
 		opc.allowMemoReuse = false
 		opc.useCache = false
 	}
+
+	// Look up the statement hints for the statement. Statements issued
+	// internally are never hinted.
+	opc.hints = nil
+	if c := p.execCfg.StmtHintsCache; c != nil &&
+		!strings.HasPrefix(p.SessionData().ApplicationName, sqlbase.InternalAppNamePrefix) {
+		if hints, ok := c.lookup(p.stmt.AST); ok {
+			opc.hints = hints
+			opc.flags.Set(planFlagOptStmtHints)
+			opc.optimizer.DisableRules(hints.DisabledRuleSet())
+			if hints.ReorderJoinsLimit != nil {
+				opc.optimizer.SetReorderJoinsLimit(*hints.ReorderJoinsLimit)
+			}
+			// The hints can change at any time, and they aren't tracked by the
+			// staleness checks of cached memos.
+			opc.allowMemoReuse = false
+			opc.useCache = false
+		}
+	}
 }
 
 func (opc *optPlanningCtx) log(ctx context.Context, msg string) {
@@ -283,6 +315,7 @@ func (opc *optPlanningCtx) buildReusableMemo(
 	//
 	f := opc.optimizer.Factory()
 	bld := optbuilder.New(ctx, &p.semaCtx, p.EvalContext(), &opc.catalog, f, opc.p.stmt.AST)
+	bld.StmtHints = opc.hints
 	bld.KeepPlaceholders = true
 	if err := bld.Build(); err != nil {
 		return nil, bld.IsCorrelated, err
@@ -410,6 +443,7 @@ func (opc *optPlanningCtx) buildExecMemo(
 	// available.
 	f := opc.optimizer.Factory()
 	bld := optbuilder.New(ctx, &p.semaCtx, p.EvalContext(), &opc.catalog, f, opc.p.stmt.AST)
+	bld.StmtHints = opc.hints
 	if err := bld.Build(); err != nil {
 		return nil, bld.IsCorrelated, err
 	}
@@ -106,9 +106,13 @@ func planPhysicalProps(plan planNode) physicalProps {
 	case *commentOnTableNode:
 	case *commentOnColumnNode:
 	case *commentOnDatabaseNode:
+	case *createStmtHintsNode:
+	case *dropStmtHintsNode:
+	case *notifyNode:
 	case *controlJobsNode:
 	case *createDatabaseNode:
 	case *createIndexNode:
+	case *createPolicyNode:
 	case *createSequenceNode:
 	case *createStatsNode:
 	case *createTableNode:
@@ -117,12 +121,14 @@ func planPhysicalProps(plan planNode) physicalProps {
 	case *deleteRangeNode:
 	case *dropDatabaseNode:
 	case *dropIndexNode:
+	case *dropPolicyNode:
 	case *dropSequenceNode:
 	case *dropTableNode:
 	case *dropViewNode:
 	case *errorIfRowsNode:
 	case *explainDistSQLNode:
 	case *hookFnNode:
+	case *invertedJoinNode:
 	case *relocateNode:
 	case *renameColumnNode:
 	case *renameDatabaseNode:
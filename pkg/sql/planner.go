@@ -171,6 +171,12 @@ type planner struct {
 	optPlanningCtx optPlanningCtx
 
 	queryCacheSession querycache.Session
+
+	// diagnostics, if set, collects the planning information included in the
+	// statement diagnostics bundle of the current statement. It is set by
+	// EXPLAIN ANALYZE (DEBUG) and for statements for which a bundle was
+	// requested.
+	diagnostics *stmtDiagnosticsCollector
 }
 
 // noteworthyInternalMemoryUsageBytes is the minimum size tracked by each
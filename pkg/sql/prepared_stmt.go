@@ -107,7 +107,8 @@ type preparedStatementsAccessor interface {
 // (but they allow one to move back and forth through the results). Our portals
 // can be used to execute a query multiple times, which is a bug (executing an
 // exhausted portal in Postres returns 0 results; in CRDB executing a portal a
-// second time always restarts the query).
+// second time always restarts the query). The exception are portals that have
+// been suspended, or that back a cursor; see portalExec.
 type PreparedPortal struct {
 	Stmt  *PreparedStatement
 	Qargs tree.QueryArguments
@@ -115,6 +116,11 @@ type PreparedPortal struct {
 	// OutFormats contains the requested formats for the output columns.
 	OutFormats []pgwirebase.FormatCode
 
+	// exec, if set, is the execution of the statement of a portal that can be
+	// suspended, or of a cursor. Further executions of the portal resume it
+	// instead of running the statement again.
+	exec *portalExec
+
 	// refCount keeps track of the number of references to this PreparedStatement.
 	// New references are registered through incRef().
 	// Once refCount hits 0 (through calls to decRef()), the following memAcc is
@@ -167,7 +173,16 @@ func (p *PreparedPortal) decRef(ctx context.Context) {
 	p.refCount--
 
 	if p.refCount == 0 {
+		p.closeExec()
 		p.memAcc.Close(ctx)
 		p.Stmt.decRef(ctx)
 	}
 }
+
+// closeExec stops the portal's statement if it is suspended.
+func (p *PreparedPortal) closeExec() {
+	if p.exec != nil {
+		p.exec.close()
+		p.exec = nil
+	}
+}
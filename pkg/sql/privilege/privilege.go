@@ -44,6 +44,9 @@ const (
 var (
 	ReadData      = List{GRANT, SELECT}
 	ReadWriteData = List{GRANT, SELECT, INSERT, DELETE, UPDATE}
+	// ColumnPrivileges are the privileges which can be granted on individual
+	// columns of a table.
+	ColumnPrivileges = List{SELECT, INSERT, UPDATE}
 )
 
 // Mask returns the bitmask for a given privilege.
@@ -84,6 +87,16 @@ func (pl List) Less(i, j int) bool {
 	return pl[i] < pl[j]
 }
 
+// Contains returns true if the list contains the given privilege.
+func (pl List) Contains(k Kind) bool {
+	for _, p := range pl {
+		if p == k {
+			return true
+		}
+	}
+	return false
+}
+
 // names returns a list of privilege names in the same
 // order as 'pl'.
 func (pl List) names() []string {
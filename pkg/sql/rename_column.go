@@ -146,6 +146,22 @@ func (p *planner) renameColumn(
 		}
 	}
 
+	// Rename the column in row-level security policies.
+	for i := range tableDesc.Policies {
+		policy := &tableDesc.Policies[i]
+		var err error
+		if policy.UsingExpr != "" {
+			if policy.UsingExpr, err = renameIn(policy.UsingExpr); err != nil {
+				return false, err
+			}
+		}
+		if policy.WithCheckExpr != "" {
+			if policy.WithCheckExpr, err = renameIn(policy.WithCheckExpr); err != nil {
+				return false, err
+			}
+		}
+	}
+
 	// Rename the column in computed columns.
 	for i := range tableDesc.Columns {
 		if tableDesc.Columns[i].IsComputed() {
@@ -0,0 +1,139 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package sql
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util"
+)
+
+// rowLevelSecurityPolicies returns whether the row-level security policies of
+// the given table are enforced for the current user and, if so, the ordinals
+// of the policies that apply to the user.
+//
+// Policies are not enforced if row-level security is disabled on the table,
+// or if the user has the ALL privilege on the table, either directly or
+// through a role. This exempts the owner of the table as well as admins.
+// When policies are enforced and none of them applies to the user, no rows
+// are visible or can be modified.
+func (p *planner) rowLevelSecurityPolicies(
+	ctx context.Context, desc *sqlbase.TableDescriptor,
+) (enforced bool, policies util.FastIntSet, err error) {
+	if !desc.RowLevelSecurity {
+		return false, policies, nil
+	}
+
+	// Deliberately don't use p.CheckPrivilege, which records audit events.
+	user := p.SessionData().User
+	privs := desc.GetPrivileges()
+	if privs.CheckPrivilege(user, privilege.ALL) ||
+		privs.CheckPrivilege(sqlbase.PublicRole, privilege.ALL) {
+		return false, policies, nil
+	}
+	memberOf, err := p.MemberOfWithAdminOption(ctx, user)
+	if err != nil {
+		return false, policies, err
+	}
+	for role := range memberOf {
+		if privs.CheckPrivilege(role, privilege.ALL) {
+			return false, policies, nil
+		}
+	}
+
+	for i := range desc.Policies {
+		roles := desc.Policies[i].Roles
+		applies := len(roles) == 0
+		for _, role := range roles {
+			if _, ok := memberOf[role]; ok || role == user || role == sqlbase.PublicRole {
+				applies = true
+				break
+			}
+		}
+		if applies {
+			policies.Add(i)
+		}
+	}
+	return true, policies, nil
+}
+
+// checkRowLevelSecurityUnsupported returns an error if the row-level security
+// policies of the given table are enforced for the current user. It is used by
+// the heuristic planner, which does not support row-level security.
+func (p *planner) checkRowLevelSecurityUnsupported(
+	ctx context.Context, desc *sqlbase.TableDescriptor,
+) error {
+	enforced, _, err := p.rowLevelSecurityPolicies(ctx, desc)
+	if err != nil {
+		return err
+	}
+	if enforced {
+		return sqlbase.NewUnsupportedRowLevelSecurityError(desc.Name)
+	}
+	return nil
+}
+
+// checkRowLevelSecuritySupported returns an error if the cluster version does
+// not permit enabling row-level security or defining policies yet. Nodes
+// running older versions would ignore the policies, and drop them if they
+// rewrote the table descriptor.
+func (p *planner) checkRowLevelSecuritySupported(op string) error {
+	if !p.ExecCfg().Settings.Version.IsActive(cluster.VersionRowLevelSecurity) {
+		return pgerror.Newf(pgerror.CodeObjectNotInPrerequisiteStateError,
+			`%s requires all nodes to be upgraded to %s`,
+			op, cluster.VersionByKey(cluster.VersionRowLevelSecurity),
+		)
+	}
+	return nil
+}
+
+// checkRowLevelSecurityPrivilege returns an error if the current user is not
+// allowed to change the row-level security policies of the given table. This
+// requires the ALL privilege, which exempts the user from the policies: users
+// subject to them must not be able to lift them.
+func (p *planner) checkRowLevelSecurityPrivilege(
+	ctx context.Context, desc *sqlbase.MutableTableDescriptor,
+) error {
+	return p.CheckPrivilege(ctx, desc, privilege.ALL)
+}
+
+// policyCommandPrivileges maps each policy command to the privilege required
+// by the statements it applies to.
+var policyCommandPrivileges = [...]privilege.Kind{
+	sqlbase.TableDescriptor_Policy_ALL:    privilege.ALL,
+	sqlbase.TableDescriptor_Policy_SELECT: privilege.SELECT,
+	sqlbase.TableDescriptor_Policy_INSERT: privilege.INSERT,
+	sqlbase.TableDescriptor_Policy_UPDATE: privilege.UPDATE,
+	sqlbase.TableDescriptor_Policy_DELETE: privilege.DELETE,
+}
+
+// policyCommandFromPrivilege returns the policy command that applies to
+// statements requiring the given privilege.
+func policyCommandFromPrivilege(kind privilege.Kind) sqlbase.TableDescriptor_Policy_Command {
+	switch kind {
+	case privilege.SELECT:
+		return sqlbase.TableDescriptor_Policy_SELECT
+	case privilege.INSERT:
+		return sqlbase.TableDescriptor_Policy_INSERT
+	case privilege.UPDATE:
+		return sqlbase.TableDescriptor_Policy_UPDATE
+	case privilege.DELETE:
+		return sqlbase.TableDescriptor_Policy_DELETE
+	default:
+		return sqlbase.TableDescriptor_Policy_ALL
+	}
+}
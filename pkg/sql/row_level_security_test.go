@@ -0,0 +1,57 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package sql_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/server"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/tests"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+// TestRowLevelSecurityClusterVersion verifies that row-level security can't be
+// enabled, nor policies created, until all the nodes know about them.
+func TestRowLevelSecurityClusterVersion(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	params, _ := tests.CreateTestServerParams()
+	bootstrapVersion := cluster.ClusterVersion{
+		Version: cluster.VersionByKey(cluster.VersionRowLevelSecurity - 1),
+	}
+	params.Knobs.Store.(*storage.StoreTestingKnobs).BootstrapVersion = &bootstrapVersion
+	params.Knobs.Server = &server.TestingKnobs{DisableAutomaticVersionUpgrade: 1}
+	s, db, _ := serverutils.StartServer(t, params)
+	defer s.Stopper().Stop(context.TODO())
+
+	sqlDB := sqlutils.MakeSQLRunner(db)
+	sqlDB.Exec(t, `CREATE DATABASE d`)
+	sqlDB.Exec(t, `CREATE TABLE d.t (a INT PRIMARY KEY, b STRING)`)
+
+	sqlDB.ExpectErr(t, `ENABLE ROW LEVEL SECURITY requires all nodes to be upgraded`,
+		`ALTER TABLE d.t ENABLE ROW LEVEL SECURITY`)
+	sqlDB.ExpectErr(t, `CREATE POLICY requires all nodes to be upgraded`,
+		`CREATE POLICY p ON d.t USING (b = current_user())`)
+	// Disabling row-level security is harmless.
+	sqlDB.Exec(t, `ALTER TABLE d.t DISABLE ROW LEVEL SECURITY`)
+
+	sqlDB.Exec(t, `SET CLUSTER SETTING version = $1`,
+		cluster.VersionByKey(cluster.VersionRowLevelSecurity).String())
+	sqlDB.Exec(t, `ALTER TABLE d.t ENABLE ROW LEVEL SECURITY`)
+	sqlDB.Exec(t, `CREATE POLICY p ON d.t USING (b = current_user())`)
+}
@@ -2996,6 +2996,37 @@ may increase either contention or retry errors, or both.`,
 		},
 	),
 
+	"crdb_internal.request_statement_bundle": makeBuiltin(
+		tree.FunctionProperties{
+			Category: categorySystemInfo,
+			Impure:   true,
+		},
+		tree.Overload{
+			Types:      tree.ArgTypes{{"stmt_fingerprint", types.String}},
+			ReturnType: tree.FixedReturnType(types.Int),
+			Fn: func(ctx *tree.EvalContext, args tree.Datums) (tree.Datum, error) {
+				if err := checkPrivilegedUser(ctx); err != nil {
+					return nil, err
+				}
+				if ctx.StmtDiagnosticsRequestInserter == nil {
+					return nil, pgerror.AssertionFailedf("statement diagnostics are not available")
+				}
+				id, err := ctx.StmtDiagnosticsRequestInserter(
+					ctx.Context, string(tree.MustBeDString(args[0])),
+				)
+				if err != nil {
+					return nil, err
+				}
+				return tree.NewDInt(tree.DInt(id)), nil
+			},
+			Info: "Requests a statement diagnostics bundle for the next execution of a statement " +
+				"with the given fingerprint on any node. " +
+				"The fingerprint is the key of the statement in " +
+				"`crdb_internal.node_statement_statistics`. Returns the ID of the request. " +
+				"The collected bundles are listed in `crdb_internal.statement_diagnostics`.",
+		},
+	),
+
 	// Returns the number of distinct inverted index entries that would be generated for a JSON value.
 	"crdb_internal.json_num_index_entries": makeBuiltin(
 		tree.FunctionProperties{
@@ -1540,6 +1540,30 @@ SELECT description
 		},
 	),
 
+	// See https://www.postgresql.org/docs/10/functions-info.html#FUNCTIONS-NOTIFY
+	"pg_notify": makeBuiltin(
+		tree.FunctionProperties{
+			Category:         categorySystemInfo,
+			DistsqlBlacklist: true,
+			Impure:           true,
+		},
+		tree.Overload{
+			Types:      tree.ArgTypes{{"channel", types.String}, {"payload", types.String}},
+			ReturnType: tree.FixedReturnType(types.Unknown),
+			Fn: func(ctx *tree.EvalContext, args tree.Datums) (tree.Datum, error) {
+				if err := ctx.Planner.SendNotification(
+					ctx.Ctx(), string(tree.MustBeDString(args[0])), string(tree.MustBeDString(args[1])),
+				); err != nil {
+					return nil, err
+				}
+				return tree.DNull, nil
+			},
+			Info: "Sends a notification with the given payload to the sessions listening " +
+				"on the given channel once the current transaction commits. Equivalent to " +
+				"`NOTIFY channel, payload`.",
+		},
+	),
+
 	// inet_{client,server}_{addr,port} return either an INet address or integer
 	// port that corresponds to either the client or server side of the current
 	// session's connection.
@@ -12,11 +12,17 @@
 
 package tree
 
+import (
+	"github.com/cockroachdb/cockroach/pkg/sql/lex"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+)
+
 // CopyFrom represents a COPY FROM statement.
 type CopyFrom struct {
 	Table   TableName
 	Columns NameList
 	Stdin   bool
+	Options CopyOptions
 }
 
 // Format implements the NodeFormatter interface.
@@ -32,4 +38,290 @@ func (node *CopyFrom) Format(ctx *FmtCtx) {
 	if node.Stdin {
 		ctx.WriteString("STDIN")
 	}
+	ctx.FormatNode(&node.Options)
+}
+
+// CopyTo represents a COPY TO STDOUT statement. Either Table or Statement is
+// set.
+type CopyTo struct {
+	Table     TableName
+	Columns   NameList
+	Statement *Select
+	Options   CopyOptions
+}
+
+// Format implements the NodeFormatter interface.
+func (node *CopyTo) Format(ctx *FmtCtx) {
+	ctx.WriteString("COPY ")
+	if node.Statement != nil {
+		ctx.WriteByte('(')
+		ctx.FormatNode(node.Statement)
+		ctx.WriteByte(')')
+	} else {
+		ctx.FormatNode(&node.Table)
+		if len(node.Columns) > 0 {
+			ctx.WriteString(" (")
+			ctx.FormatNode(&node.Columns)
+			ctx.WriteString(")")
+		}
+	}
+	ctx.WriteString(" TO STDOUT")
+	ctx.FormatNode(&node.Options)
+}
+
+// Select returns the query whose results are copied: either the query of a
+// COPY (query) TO statement, or a SELECT of the columns of the table.
+func (node *CopyTo) Select() *Select {
+	if node.Statement != nil {
+		return node.Statement
+	}
+	exprs := SelectExprs{StarSelectExpr()}
+	if len(node.Columns) > 0 {
+		exprs = make(SelectExprs, len(node.Columns))
+		for i, col := range node.Columns {
+			exprs[i] = SelectExpr{Expr: NewUnresolvedName(string(col))}
+		}
+	}
+	// Name resolution qualifies the table name in place.
+	table := node.Table
+	return &Select{
+		Select: &SelectClause{
+			Exprs: exprs,
+			From:  &From{Tables: TableExprs{&table}},
+		},
+	}
+}
+
+// CopyFormat is the format of the data exchanged by a COPY statement.
+type CopyFormat int
+
+// CopyFormat values.
+const (
+	CopyFormatText CopyFormat = iota
+	CopyFormatCSV
+	CopyFormatBinary
+)
+
+var copyFormatName = [...]string{
+	CopyFormatText:   "text",
+	CopyFormatCSV:    "csv",
+	CopyFormatBinary: "binary",
+}
+
+func (f CopyFormat) String() string {
+	return copyFormatName[f]
+}
+
+// Bits set in CopyOptions.specified.
+const (
+	copyOptFormat = 1 << iota
+	copyOptDelimiter
+	copyOptNull
+	copyOptHeader
+	copyOptQuote
+	copyOptEscape
+)
+
+// CopyOptions holds the options of a COPY statement. The zero value is the
+// default text format.
+type CopyOptions struct {
+	FileFormat CopyFormat
+	Delimiter  string
+	Null       string
+	Header     bool
+	Quote      string
+	Escape     string
+
+	// specified records which options were given explicitly; the other fields
+	// are not meaningful.
+	specified int
+}
+
+// MakeCopyOption returns the options with a single option set. The value of
+// boolean options may be empty.
+func MakeCopyOption(name, value string) (CopyOptions, error) {
+	var o CopyOptions
+	switch name {
+	case "format":
+		o.specified = copyOptFormat
+		switch value {
+		case "text":
+			o.FileFormat = CopyFormatText
+		case "csv":
+			o.FileFormat = CopyFormatCSV
+		case "binary":
+			o.FileFormat = CopyFormatBinary
+		default:
+			return o, pgerror.Newf(pgerror.CodeInvalidParameterValueError,
+				"COPY format %q not recognized", value)
+		}
+	case "delimiter":
+		o.specified, o.Delimiter = copyOptDelimiter, value
+	case "null":
+		o.specified, o.Null = copyOptNull, value
+	case "header":
+		o.specified = copyOptHeader
+		switch value {
+		case "", "true", "on":
+			o.Header = true
+		case "false", "off":
+		default:
+			return o, pgerror.Newf(pgerror.CodeInvalidParameterValueError,
+				"header requires a Boolean value")
+		}
+	case "quote":
+		o.specified, o.Quote = copyOptQuote, value
+	case "escape":
+		o.specified, o.Escape = copyOptEscape, value
+	default:
+		return o, pgerror.Newf(pgerror.CodeSyntaxError, "option %q not recognized", name)
+	}
+	return o, nil
+}
+
+// CombineWith merges the options set in other into o.
+func (o *CopyOptions) CombineWith(other CopyOptions) error {
+	if o.specified&other.specified != 0 {
+		return pgerror.New(pgerror.CodeSyntaxError, "conflicting or redundant options")
+	}
+	if other.specified&copyOptFormat != 0 {
+		o.FileFormat = other.FileFormat
+	}
+	if other.specified&copyOptDelimiter != 0 {
+		o.Delimiter = other.Delimiter
+	}
+	if other.specified&copyOptNull != 0 {
+		o.Null = other.Null
+	}
+	if other.specified&copyOptHeader != 0 {
+		o.Header = other.Header
+	}
+	if other.specified&copyOptQuote != 0 {
+		o.Quote = other.Quote
+	}
+	if other.specified&copyOptEscape != 0 {
+		o.Escape = other.Escape
+	}
+	o.specified |= other.specified
+	return nil
+}
+
+// WithDefaults returns the options with the defaults of the format filled in
+// for the options that were not specified.
+func (o CopyOptions) WithDefaults() CopyOptions {
+	if o.specified&copyOptDelimiter == 0 {
+		o.Delimiter = "\t"
+		if o.FileFormat == CopyFormatCSV {
+			o.Delimiter = ","
+		}
+	}
+	if o.specified&copyOptNull == 0 {
+		o.Null = `\N`
+		if o.FileFormat == CopyFormatCSV {
+			o.Null = ""
+		}
+	}
+	if o.specified&copyOptQuote == 0 {
+		o.Quote = `"`
+	}
+	if o.specified&copyOptEscape == 0 {
+		o.Escape = o.Quote
+	}
+	return o
+}
+
+// Validate checks that the options are consistent with each other.
+func (o CopyOptions) Validate() error {
+	if o.FileFormat == CopyFormatBinary {
+		if o.specified&(copyOptDelimiter|copyOptNull) != 0 {
+			return pgerror.New(pgerror.CodeSyntaxError,
+				"cannot specify DELIMITER or NULL in BINARY mode")
+		}
+	}
+	if o.FileFormat != CopyFormatCSV {
+		if o.specified&copyOptHeader != 0 {
+			return pgerror.New(pgerror.CodeFeatureNotSupportedError,
+				"COPY HEADER available only in CSV mode")
+		}
+		if o.specified&copyOptQuote != 0 {
+			return pgerror.New(pgerror.CodeFeatureNotSupportedError,
+				"COPY quote available only in CSV mode")
+		}
+		if o.specified&copyOptEscape != 0 {
+			return pgerror.New(pgerror.CodeFeatureNotSupportedError,
+				"COPY escape available only in CSV mode")
+		}
+	}
+	d := o.WithDefaults()
+	if len(d.Delimiter) != 1 {
+		return pgerror.New(pgerror.CodeFeatureNotSupportedError,
+			"COPY delimiter must be a single one-byte character")
+	}
+	if d.Delimiter[0] == '\n' || d.Delimiter[0] == '\r' {
+		return pgerror.New(pgerror.CodeInvalidParameterValueError,
+			"COPY delimiter cannot be newline or carriage return")
+	}
+	if o.FileFormat == CopyFormatCSV {
+		if len(d.Quote) != 1 {
+			return pgerror.New(pgerror.CodeFeatureNotSupportedError,
+				"COPY quote must be a single one-byte character")
+		}
+		if len(d.Escape) != 1 {
+			return pgerror.New(pgerror.CodeFeatureNotSupportedError,
+				"COPY escape must be a single one-byte character")
+		}
+		if d.Delimiter == d.Quote {
+			return pgerror.New(pgerror.CodeInvalidParameterValueError,
+				"COPY delimiter and quote must be different")
+		}
+	}
+	return nil
+}
+
+// Format implements the NodeFormatter interface. Only the options that were
+// specified are printed.
+func (o *CopyOptions) Format(ctx *FmtCtx) {
+	if o.specified == 0 {
+		return
+	}
+	ctx.WriteString(" WITH (")
+	sep := ""
+	opt := func(name string) {
+		ctx.WriteString(sep)
+		ctx.WriteString(name)
+		sep = ", "
+	}
+	str := func(s string) {
+		ctx.WriteByte(' ')
+		lex.EncodeSQLStringWithFlags(&ctx.Buffer, s, ctx.flags.EncodeFlags())
+	}
+	if o.specified&copyOptFormat != 0 {
+		opt("FORMAT ")
+		ctx.WriteString(o.FileFormat.String())
+	}
+	if o.specified&copyOptDelimiter != 0 {
+		opt("DELIMITER")
+		str(o.Delimiter)
+	}
+	if o.specified&copyOptNull != 0 {
+		opt("NULL")
+		str(o.Null)
+	}
+	if o.specified&copyOptHeader != 0 {
+		opt("HEADER ")
+		if o.Header {
+			ctx.WriteString("true")
+		} else {
+			ctx.WriteString("false")
+		}
+	}
+	if o.specified&copyOptQuote != 0 {
+		opt("QUOTE")
+		str(o.Quote)
+	}
+	if o.specified&copyOptEscape != 0 {
+		opt("ESCAPE")
+		str(o.Escape)
+	}
+	ctx.WriteByte(')')
 }
@@ -1989,10 +1989,15 @@ func compareTimestamps(ctx *EvalContext, l Datum, r Datum) int {
 	if !lOk || !rOk {
 		panic(makeUnsupportedComparisonMessage(l, r))
 	}
-	if lTime.Before(rTime) {
+	return CompareTimes(lTime, rTime)
+}
+
+// CompareTimes compares the input times according to the SQL comparison rules.
+func CompareTimes(d, v time.Time) int {
+	if d.Before(v) {
 		return -1
 	}
-	if rTime.Before(lTime) {
+	if v.Before(d) {
 		return 1
 	}
 	return 0
@@ -2514,10 +2519,16 @@ func (d *DJSON) Compare(ctx *EvalContext, other Datum) int {
 	if !ok {
 		panic(makeUnsupportedComparisonMessage(d, other))
 	}
+	return CompareJSON(d.JSON, v.JSON)
+}
+
+// CompareJSON compares the input JSON values according to the SQL comparison
+// rules.
+func CompareJSON(d, v json.JSON) int {
 	// No avenue for us to pass up this error here at the moment, but Compare
 	// only errors for invalid encoded data.
 	// TODO(justin): modify Compare to allow passing up errors.
-	c, err := d.JSON.Compare(v.JSON)
+	c, err := d.Compare(v)
 	if err != nil {
 		panic(err)
 	}
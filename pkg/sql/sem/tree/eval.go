@@ -2455,6 +2455,10 @@ type EvalPlanner interface {
 
 	// EvalSubquery returns the Datum for the given subquery node.
 	EvalSubquery(expr *Subquery) (Datum, error)
+
+	// SendNotification sends a notification on the given channel once the
+	// current transaction commits. It is used by pg_notify().
+	SendNotification(ctx context.Context, channel, payload string) error
 }
 
 // EvalSessionAccessor is a limited interface to access session variables.
@@ -2485,6 +2489,11 @@ type SessionBoundInternalExecutor interface {
 	) (Datums, error)
 }
 
+// StmtDiagnosticsRequestInserterFunc inserts a request for a statement
+// diagnostics bundle for the given statement fingerprint and returns the ID of
+// the request.
+type StmtDiagnosticsRequestInserterFunc func(ctx context.Context, stmtFingerprint string) (int64, error)
+
 // SequenceOperators is used for various sql related functions that can
 // be used from EvalContext.
 type SequenceOperators interface {
@@ -2613,6 +2622,11 @@ type EvalContext struct {
 
 	Sequence SequenceOperators
 
+	// StmtDiagnosticsRequestInserter is used by the
+	// crdb_internal.request_statement_bundle builtin to request a statement
+	// diagnostics bundle for a statement fingerprint.
+	StmtDiagnosticsRequestInserter StmtDiagnosticsRequestInserterFunc
+
 	// The transaction in which the statement is executing.
 	Txn *client.Txn
 	// A handle to the database.
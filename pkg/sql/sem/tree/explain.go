@@ -67,8 +67,8 @@ type ExplainOptions struct {
 	Flags util.FastIntSet
 }
 
-// ExplainMode indicates the mode of the explain. Currently there are two modes:
-// PLAN (the default) and DISTSQL.
+// ExplainMode indicates the mode of the explain. Currently there are four
+// modes: PLAN (the default), DISTSQL, OPT and DEBUG.
 type ExplainMode uint8
 
 const (
@@ -83,12 +83,19 @@ const (
 	// ExplainOpt shows the optimized relational expression (from the cost-based
 	// optimizer).
 	ExplainOpt
+
+	// ExplainDebug generates a statement diagnostics bundle with information
+	// (like the optimizer memo, the schema, the table statistics and the trace)
+	// useful for troubleshooting a query. It is only supported with ANALYZE.
+	// See sql/explain_bundle.go for details.
+	ExplainDebug
 )
 
 var explainModeStrings = map[string]ExplainMode{
 	"plan":    ExplainPlan,
 	"distsql": ExplainDistSQL,
 	"opt":     ExplainOpt,
+	"debug":   ExplainDebug,
 }
 
 // ExplainModeName returns the human-readable name of a given ExplainMode.
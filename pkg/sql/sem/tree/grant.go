@@ -25,9 +25,50 @@ import "github.com/cockroachdb/cockroach/pkg/sql/privilege"
 
 // Grant represents a GRANT statement.
 type Grant struct {
-	Privileges privilege.List
-	Targets    TargetList
-	Grantees   NameList
+	Privileges       privilege.List
+	ColumnPrivileges ColumnPrivileges
+	Targets          TargetList
+	Grantees         NameList
+}
+
+// ColumnPrivilege represents a privilege granted or revoked on some of the
+// columns of the targets, as in GRANT SELECT (a, b) ON t TO u.
+type ColumnPrivilege struct {
+	Privilege privilege.Kind
+	Columns   NameList
+}
+
+// Format implements the NodeFormatter interface.
+func (node *ColumnPrivilege) Format(ctx *FmtCtx) {
+	ctx.WriteString(node.Privilege.String())
+	ctx.WriteString(" (")
+	ctx.FormatNode(&node.Columns)
+	ctx.WriteByte(')')
+}
+
+// ColumnPrivileges represents a list of column privileges.
+type ColumnPrivileges []ColumnPrivilege
+
+// Format implements the NodeFormatter interface.
+func (node *ColumnPrivileges) Format(ctx *FmtCtx) {
+	for i := range *node {
+		if i > 0 {
+			ctx.WriteString(", ")
+		}
+		ctx.FormatNode(&(*node)[i])
+	}
+}
+
+// formatPrivileges prints out the privileges of a GRANT or REVOKE statement,
+// table-level ones first.
+func formatPrivileges(ctx *FmtCtx, privs privilege.List, colPrivs ColumnPrivileges) {
+	privs.Format(&ctx.Buffer)
+	if len(colPrivs) > 0 {
+		if len(privs) > 0 {
+			ctx.WriteString(", ")
+		}
+		ctx.FormatNode(&colPrivs)
+	}
 }
 
 // TargetList represents a list of targets.
@@ -57,7 +98,7 @@ func (tl *TargetList) Format(ctx *FmtCtx) {
 // Format implements the NodeFormatter interface.
 func (node *Grant) Format(ctx *FmtCtx) {
 	ctx.WriteString("GRANT ")
-	node.Privileges.Format(&ctx.Buffer)
+	formatPrivileges(ctx, node.Privileges, node.ColumnPrivileges)
 	ctx.WriteString(" ON ")
 	ctx.FormatNode(&node.Targets)
 	ctx.WriteString(" TO ")
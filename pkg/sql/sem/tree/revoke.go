@@ -26,15 +26,16 @@ import "github.com/cockroachdb/cockroach/pkg/sql/privilege"
 // Revoke represents a REVOKE statement.
 // PrivilegeList and TargetList are defined in grant.go
 type Revoke struct {
-	Privileges privilege.List
-	Targets    TargetList
-	Grantees   NameList
+	Privileges       privilege.List
+	ColumnPrivileges ColumnPrivileges
+	Targets          TargetList
+	Grantees         NameList
 }
 
 // Format implements the NodeFormatter interface.
 func (node *Revoke) Format(ctx *FmtCtx) {
 	ctx.WriteString("REVOKE ")
-	node.Privileges.Format(&ctx.Buffer)
+	formatPrivileges(ctx, node.Privileges, node.ColumnPrivileges)
 	ctx.WriteString(" ON ")
 	ctx.FormatNode(&node.Targets)
 	ctx.WriteString(" FROM ")
@@ -0,0 +1,53 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package tree
+
+import "github.com/cockroachdb/cockroach/pkg/sql/lex"
+
+// CreateStmtHints represents a CREATE STATEMENT HINTS statement.
+type CreateStmtHints struct {
+	Fingerprint string
+	Hints       string
+}
+
+// Format implements the NodeFormatter interface.
+func (n *CreateStmtHints) Format(ctx *FmtCtx) {
+	ctx.WriteString("CREATE STATEMENT HINTS FOR ")
+	lex.EncodeSQLStringWithFlags(&ctx.Buffer, n.Fingerprint, ctx.flags.EncodeFlags())
+	ctx.WriteString(" AS ")
+	lex.EncodeSQLStringWithFlags(&ctx.Buffer, n.Hints, ctx.flags.EncodeFlags())
+}
+
+// DropStmtHints represents a DROP STATEMENT HINTS statement.
+type DropStmtHints struct {
+	Fingerprint string
+	IfExists    bool
+}
+
+// Format implements the NodeFormatter interface.
+func (n *DropStmtHints) Format(ctx *FmtCtx) {
+	ctx.WriteString("DROP STATEMENT HINTS ")
+	if n.IfExists {
+		ctx.WriteString("IF EXISTS ")
+	}
+	ctx.WriteString("FOR ")
+	lex.EncodeSQLStringWithFlags(&ctx.Buffer, n.Fingerprint, ctx.flags.EncodeFlags())
+}
+
+// ShowStmtHints represents a SHOW STATEMENT HINTS statement.
+type ShowStmtHints struct{}
+
+// Format implements the NodeFormatter interface.
+func (n *ShowStmtHints) Format(ctx *FmtCtx) {
+	ctx.WriteString("SHOW STATEMENT HINTS")
+}
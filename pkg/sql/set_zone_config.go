@@ -72,6 +72,9 @@ var supportedZoneConfigOptions = map[tree.Name]struct {
 		loadYAML(&c.LeasePreferences, string(tree.MustBeDString(d)))
 		c.InheritedLeasePreferences = false
 	}},
+	"global_reads": {types.Bool, func(c *config.ZoneConfig, d tree.Datum) {
+		c.GlobalReads = proto.Bool(bool(tree.MustBeDBool(d)))
+	}},
 }
 
 // zoneOptionKeys contains the keys from suportedZoneConfigOptions in
@@ -467,6 +470,12 @@ func (n *setZoneConfigNode) startExec(params runParams) error {
 	if !execConfig.Settings.Version.IsActive(cluster.VersionCascadingZoneConfigs) {
 		zoneToWrite = completeZone
 	}
+	if zoneToWrite.GlobalReads != nil &&
+		!execConfig.Settings.Version.IsActive(cluster.VersionGlobalReads) {
+		return pgerror.Newf(pgerror.CodeFeatureNotSupportedError,
+			"global_reads requires all nodes to be upgraded to %s",
+			cluster.VersionByKey(cluster.VersionGlobalReads))
+	}
 
 	// Finally check for the extra protection partial zone configs would
 	// require from changes made to parent zones. The extra protections are:
@@ -214,6 +214,11 @@ func generateZoneConfigIntrospectionValues(
 		if !zone.InheritedLeasePreferences {
 			writeComma(f, useComma)
 			f.Printf("\tlease_preferences = %s", lex.EscapeSQLString(prefs))
+			useComma = true
+		}
+		if zone.GlobalReads != nil {
+			writeComma(f, useComma)
+			f.Printf("\tglobal_reads = %t", *zone.GlobalReads)
 		}
 		values[configSQLCol] = tree.NewDString(f.String())
 	}
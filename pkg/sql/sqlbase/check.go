@@ -205,13 +205,18 @@ func (c *CheckHelper) CheckEval(ctx *tree.EvalContext) error {
 // CheckInput expects checkVals to already contain the boolean result of
 // evaluating each check constraint. If any of the boolean values is false, then
 // CheckInput reports a constraint violation error.
+//
+// checkVals can contain one more value than there are check constraints, which
+// is the result of evaluating the row-level security policies of the table.
+// Unlike check constraints, a NULL result violates the policies.
 func (c *CheckHelper) CheckInput(checkVals tree.Datums) error {
 	if len(checkVals) != c.checkSet.Len() {
 		return pgerror.AssertionFailedf(
 			"mismatched check constraint columns: expected %d, got %d", c.checkSet.Len(), len(checkVals))
 	}
 
-	for i, check := range c.tableDesc.ActiveChecks() {
+	checks := c.tableDesc.ActiveChecks()
+	for i, check := range checks {
 		if !c.checkSet.Contains(i) {
 			continue
 		}
@@ -224,5 +229,13 @@ func (c *CheckHelper) CheckInput(checkVals tree.Datums) error {
 				"failed to satisfy CHECK constraint (%s)", check.Expr)
 		}
 	}
+	if i := len(checks); i < len(checkVals) && c.checkSet.Contains(i) {
+		if res, err := tree.GetBool(checkVals[i]); err != nil {
+			return err
+		} else if !res {
+			// Failed to satisfy the row-level security policies.
+			return NewRowLevelSecurityViolationError(c.tableDesc.Name)
+		}
+	}
 	return nil
 }
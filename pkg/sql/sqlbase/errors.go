@@ -56,6 +56,21 @@ func NewNonNullViolationError(columnName string) error {
 	return pgerror.Newf(pgerror.CodeNotNullViolationError, "null value in column %q violates not-null constraint", columnName)
 }
 
+// NewRowLevelSecurityViolationError creates an error for a row added or
+// modified by a statement that violates the row-level security policies of
+// a table.
+func NewRowLevelSecurityViolationError(tableName string) error {
+	return pgerror.Newf(pgerror.CodeInsufficientPrivilegeError,
+		"new row violates row-level security policy for table %q", tableName)
+}
+
+// NewUnsupportedRowLevelSecurityError creates an error for a statement that
+// cannot enforce the row-level security policies of a table.
+func NewUnsupportedRowLevelSecurityError(tableName string) error {
+	return pgerror.Newf(pgerror.CodeFeatureNotSupportedError,
+		"statement not supported on table %q with row-level security enabled", tableName)
+}
+
 // NewInvalidSchemaDefinitionError creates an error for an invalid schema
 // definition such as a schema definition that doesn't parse.
 func NewInvalidSchemaDefinitionError(err error) error {
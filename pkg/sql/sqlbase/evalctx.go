@@ -106,6 +106,11 @@ func (ep *DummyEvalPlanner) EvalSubquery(expr *tree.Subquery) (tree.Datum, error
 	return nil, errEvalPlanner
 }
 
+// SendNotification is part of the tree.EvalPlanner interface.
+func (ep *DummyEvalPlanner) SendNotification(ctx context.Context, channel, payload string) error {
+	return errEvalPlanner
+}
+
 // DummySessionAccessor implements the tree.EvalSessionAccessor interface by returning errors.
 type DummySessionAccessor struct{}
 
@@ -1407,6 +1407,18 @@ func (desc *TableDescriptor) ValidateTable(st *cluster.Settings) error {
 		}
 	}
 
+	policyNames := make(map[string]struct{}, len(desc.Policies))
+	for i := range desc.Policies {
+		name := desc.Policies[i].Name
+		if err := validateName(name, "policy"); err != nil {
+			return err
+		}
+		if _, ok := policyNames[name]; ok {
+			return fmt.Errorf("duplicate policy name: %q", name)
+		}
+		policyNames[name] = struct{}{}
+	}
+
 	// Fill in any incorrect privileges that may have been missed due to mixed-versions.
 	// TODO(mberhault): remove this in 2.1 (maybe 2.2) when privilege-fixing migrations have been
 	// run again and mixed-version clusters always write "good" descriptors.
@@ -2168,6 +2180,17 @@ func (desc *TableDescriptor) FindCheckByName(
 	return nil, fmt.Errorf("check %q does not exist", name)
 }
 
+// FindPolicyByName returns the ordinal of the row-level security policy with
+// the specified name, or -1 if there is no such policy.
+func (desc *TableDescriptor) FindPolicyByName(name string) int {
+	for i := range desc.Policies {
+		if desc.Policies[i].Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
 // RenameIndexDescriptor renames an index descriptor.
 func (desc *MutableTableDescriptor) RenameIndexDescriptor(
 	index *IndexDescriptor, name string,
@@ -2812,6 +2835,39 @@ func (cc *TableDescriptor_CheckConstraint) UsesColumn(
 	return i < len(colsUsed) && colsUsed[i] == colID, nil
 }
 
+// UsesColumn returns whether the USING or WITH CHECK expression of the policy
+// references the column with the specified name.
+func (p *TableDescriptor_Policy) UsesColumn(name tree.Name) (bool, error) {
+	used := false
+	visitFn := func(expr tree.Expr) (recurse bool, newExpr tree.Expr, err error) {
+		if vBase, ok := expr.(tree.VarName); ok {
+			v, err := vBase.NormalizeVarName()
+			if err != nil {
+				return false, nil, err
+			}
+			if c, ok := v.(*tree.ColumnItem); ok && c.ColumnName == name {
+				used = true
+			}
+			return false, v, nil
+		}
+		return true, expr, nil
+	}
+	for _, expr := range []string{p.UsingExpr, p.WithCheckExpr} {
+		if expr == "" {
+			continue
+		}
+		parsed, err := parser.ParseExpr(expr)
+		if err != nil {
+			return false, pgerror.Wrapf(err, pgerror.CodeSyntaxError,
+				"could not parse policy expression %s", expr)
+		}
+		if _, err := tree.SimpleVisit(parsed, visitFn); err != nil {
+			return false, err
+		}
+	}
+	return used, nil
+}
+
 // CompositeKeyMatchMethodValue allows the conversion from a
 // tree.ReferenceCompositeKeyMatchMethod to a ForeignKeyReference_Match.
 var CompositeKeyMatchMethodValue = [...]ForeignKeyReference_Match{
@@ -226,6 +226,58 @@ CREATE TABLE system.comments (
    comment   STRING NOT NULL, -- the comment
    PRIMARY KEY (type, object_id, sub_id)
 );`
+
+	// statement_hints stores the hints applied by the optimizer when planning
+	// statements with a given fingerprint.
+	StatementHintsTableSchema = `
+CREATE TABLE system.statement_hints (
+  fingerprint STRING NOT NULL PRIMARY KEY, -- the statement fingerprint, as in crdb_internal.node_statement_statistics
+  hints       JSONB NOT NULL,              -- the hints, see opt.StmtHints
+  created_at  TIMESTAMP NOT NULL DEFAULT now(),
+  FAMILY "primary" (fingerprint, hints, created_at)
+);`
+
+	// notifications stores the notifications sent with NOTIFY, which are
+	// delivered to the sessions listening on their channel through a rangefeed.
+	NotificationsTableSchema = `
+CREATE TABLE system.notifications (
+  id         INT8 NOT NULL DEFAULT unique_rowid(),
+  channel    STRING NOT NULL,
+  payload    STRING NOT NULL,
+  node_id    INT8 NOT NULL, -- the gateway node of the notifying session
+  created_at TIMESTAMP NOT NULL DEFAULT now(),
+  PRIMARY KEY (id),
+  FAMILY "primary" (id, channel, payload, node_id, created_at)
+);`
+
+	// statement_diagnostics_requests stores the pending and completed requests
+	// for statement diagnostics bundles, made with
+	// crdb_internal.request_statement_bundle().
+	StmtDiagRequestsTableSchema = `
+CREATE TABLE system.statement_diagnostics_requests (
+  id                       INT8 NOT NULL DEFAULT unique_rowid(),
+  completed                BOOL NOT NULL DEFAULT false,
+  statement_fingerprint    STRING NOT NULL,
+  statement_diagnostics_id INT8, -- the collected bundle, once completed
+  requested_at             TIMESTAMP NOT NULL DEFAULT now(),
+  PRIMARY KEY (id),
+  FAMILY "primary" (id, completed, statement_fingerprint, statement_diagnostics_id, requested_at)
+);`
+
+	// statement_diagnostics stores the statement diagnostics bundles. The
+	// bundles are kept in their own column family so that they are only read
+	// when downloaded.
+	StmtDiagTableSchema = `
+CREATE TABLE system.statement_diagnostics (
+  id                    INT8 NOT NULL DEFAULT unique_rowid(),
+  statement_fingerprint STRING NOT NULL,
+  statement             STRING NOT NULL,
+  collected_at          TIMESTAMP NOT NULL DEFAULT now(),
+  bundle                BYTES NOT NULL, -- the bundle as a zip archive
+  PRIMARY KEY (id),
+  FAMILY "primary" (id, statement_fingerprint, statement, collected_at),
+  FAMILY bundle (bundle)
+);`
 )
 
 func pk(name string) IndexDescriptor {
@@ -260,12 +312,16 @@ var SystemAllowedPrivileges = map[ID]privilege.List{
 	// users will be able to modify system tables' schemas at will. CREATE and
 	// DROP privileges are allowed on the above system tables for backwards
 	// compatibility reasons only!
-	keys.JobsTableID:            privilege.ReadWriteData,
-	keys.WebSessionsTableID:     privilege.ReadWriteData,
-	keys.TableStatisticsTableID: privilege.ReadWriteData,
-	keys.LocationsTableID:       privilege.ReadWriteData,
-	keys.RoleMembersTableID:     privilege.ReadWriteData,
-	keys.CommentsTableID:        privilege.ReadWriteData,
+	keys.JobsTableID:             privilege.ReadWriteData,
+	keys.WebSessionsTableID:      privilege.ReadWriteData,
+	keys.TableStatisticsTableID:  privilege.ReadWriteData,
+	keys.LocationsTableID:        privilege.ReadWriteData,
+	keys.RoleMembersTableID:      privilege.ReadWriteData,
+	keys.CommentsTableID:         privilege.ReadWriteData,
+	keys.StatementHintsTableID:   privilege.ReadWriteData,
+	keys.NotificationsTableID:    privilege.ReadWriteData,
+	keys.StmtDiagRequestsTableID: privilege.ReadWriteData,
+	keys.StmtDiagTableID:         privilege.ReadWriteData,
 }
 
 // Helpers used to make some of the TableDescriptor literals below more concise.
@@ -862,6 +918,132 @@ var (
 		FormatVersion:  InterleavedFormatVersion,
 		NextMutationID: 1,
 	}
+
+	// StatementHintsTable is the descriptor for the statement_hints table.
+	StatementHintsTable = TableDescriptor{
+		Name:     "statement_hints",
+		ID:       keys.StatementHintsTableID,
+		ParentID: keys.SystemDatabaseID,
+		Version:  1,
+		Columns: []ColumnDescriptor{
+			{Name: "fingerprint", ID: 1, Type: *types.String},
+			{Name: "hints", ID: 2, Type: *types.Jsonb},
+			{Name: "created_at", ID: 3, Type: *types.Timestamp, DefaultExpr: &nowString},
+		},
+		NextColumnID: 4,
+		Families: []ColumnFamilyDescriptor{
+			{
+				Name:        "primary",
+				ID:          0,
+				ColumnNames: []string{"fingerprint", "hints", "created_at"},
+				ColumnIDs:   []ColumnID{1, 2, 3},
+			},
+		},
+		NextFamilyID:   1,
+		PrimaryIndex:   pk("fingerprint"),
+		NextIndexID:    2,
+		Privileges:     NewCustomSuperuserPrivilegeDescriptor(SystemAllowedPrivileges[keys.StatementHintsTableID]),
+		FormatVersion:  InterleavedFormatVersion,
+		NextMutationID: 1,
+	}
+
+	// NotificationsTable is the descriptor for the notifications table.
+	NotificationsTable = TableDescriptor{
+		Name:     "notifications",
+		ID:       keys.NotificationsTableID,
+		ParentID: keys.SystemDatabaseID,
+		Version:  1,
+		Columns: []ColumnDescriptor{
+			{Name: "id", ID: 1, Type: *types.Int, DefaultExpr: &uniqueRowIDString},
+			{Name: "channel", ID: 2, Type: *types.String},
+			{Name: "payload", ID: 3, Type: *types.String},
+			{Name: "node_id", ID: 4, Type: *types.Int},
+			{Name: "created_at", ID: 5, Type: *types.Timestamp, DefaultExpr: &nowString},
+		},
+		NextColumnID: 6,
+		Families: []ColumnFamilyDescriptor{
+			{
+				Name:        "primary",
+				ID:          0,
+				ColumnNames: []string{"id", "channel", "payload", "node_id", "created_at"},
+				ColumnIDs:   []ColumnID{1, 2, 3, 4, 5},
+			},
+		},
+		NextFamilyID:   1,
+		PrimaryIndex:   pk("id"),
+		NextIndexID:    2,
+		Privileges:     NewCustomSuperuserPrivilegeDescriptor(SystemAllowedPrivileges[keys.NotificationsTableID]),
+		FormatVersion:  InterleavedFormatVersion,
+		NextMutationID: 1,
+	}
+
+	// StmtDiagRequestsTable is the descriptor for the
+	// statement_diagnostics_requests table.
+	StmtDiagRequestsTable = TableDescriptor{
+		Name:     "statement_diagnostics_requests",
+		ID:       keys.StmtDiagRequestsTableID,
+		ParentID: keys.SystemDatabaseID,
+		Version:  1,
+		Columns: []ColumnDescriptor{
+			{Name: "id", ID: 1, Type: *types.Int, DefaultExpr: &uniqueRowIDString},
+			{Name: "completed", ID: 2, Type: *types.Bool, DefaultExpr: &falseBoolString},
+			{Name: "statement_fingerprint", ID: 3, Type: *types.String},
+			{Name: "statement_diagnostics_id", ID: 4, Type: *types.Int, Nullable: true},
+			{Name: "requested_at", ID: 5, Type: *types.Timestamp, DefaultExpr: &nowString},
+		},
+		NextColumnID: 6,
+		Families: []ColumnFamilyDescriptor{
+			{
+				Name:        "primary",
+				ID:          0,
+				ColumnNames: []string{"id", "completed", "statement_fingerprint", "statement_diagnostics_id", "requested_at"},
+				ColumnIDs:   []ColumnID{1, 2, 3, 4, 5},
+			},
+		},
+		NextFamilyID:   1,
+		PrimaryIndex:   pk("id"),
+		NextIndexID:    2,
+		Privileges:     NewCustomSuperuserPrivilegeDescriptor(SystemAllowedPrivileges[keys.StmtDiagRequestsTableID]),
+		FormatVersion:  InterleavedFormatVersion,
+		NextMutationID: 1,
+	}
+
+	// StmtDiagTable is the descriptor for the statement_diagnostics table.
+	StmtDiagTable = TableDescriptor{
+		Name:     "statement_diagnostics",
+		ID:       keys.StmtDiagTableID,
+		ParentID: keys.SystemDatabaseID,
+		Version:  1,
+		Columns: []ColumnDescriptor{
+			{Name: "id", ID: 1, Type: *types.Int, DefaultExpr: &uniqueRowIDString},
+			{Name: "statement_fingerprint", ID: 2, Type: *types.String},
+			{Name: "statement", ID: 3, Type: *types.String},
+			{Name: "collected_at", ID: 4, Type: *types.Timestamp, DefaultExpr: &nowString},
+			{Name: "bundle", ID: 5, Type: *types.Bytes},
+		},
+		NextColumnID: 6,
+		Families: []ColumnFamilyDescriptor{
+			{
+				Name:        "primary",
+				ID:          0,
+				ColumnNames: []string{"id", "statement_fingerprint", "statement", "collected_at"},
+				ColumnIDs:   []ColumnID{1, 2, 3, 4},
+			},
+			{
+				Name:            "bundle",
+				ID:              1,
+				ColumnNames:     []string{"bundle"},
+				ColumnIDs:       []ColumnID{5},
+				DefaultColumnID: 5,
+			},
+		},
+		NextFamilyID:   2,
+		PrimaryIndex:   pk("id"),
+		NextIndexID:    2,
+		Privileges:     NewCustomSuperuserPrivilegeDescriptor(SystemAllowedPrivileges[keys.StmtDiagTableID]),
+		FormatVersion:  InterleavedFormatVersion,
+		NextMutationID: 1,
+	}
 )
 
 // Create a kv pair for the zone config for the given key and config value.
@@ -907,6 +1089,19 @@ func addSystemDescriptorsToSchema(target *MetadataSchema) {
 	// The CommentsTable has been introduced in 2.2. It was added here since it
 	// was introduced, but it's also created as a migration for older clusters.
 	target.AddDescriptor(keys.SystemDatabaseID, &CommentsTable)
+
+	// The StatementHintsTable has been introduced in 19.2. It is also created
+	// as a migration for older clusters.
+	target.AddDescriptor(keys.SystemDatabaseID, &StatementHintsTable)
+
+	// The NotificationsTable has been introduced in 19.2. It is also created
+	// as a migration for older clusters.
+	target.AddDescriptor(keys.SystemDatabaseID, &NotificationsTable)
+
+	// The statement diagnostics tables have been introduced in 19.2. They are
+	// also created as a migration for older clusters.
+	target.AddDescriptor(keys.SystemDatabaseID, &StmtDiagRequestsTable)
+	target.AddDescriptor(keys.SystemDatabaseID, &StmtDiagTable)
 }
 
 // addSystemDatabaseToSchema populates the supplied MetadataSchema with the
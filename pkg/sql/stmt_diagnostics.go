@@ -0,0 +1,371 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package sql
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/gossip"
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/server/telemetry"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqltelemetry"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+// maxStmtBundles is the number of statement diagnostics bundles retained in
+// system.statement_diagnostics. Older bundles are discarded first.
+const maxStmtBundles = 32
+
+// stmtDiagnosticsReloadRetryInterval is the interval after which a failed
+// reload of the pending requests is retried.
+const stmtDiagnosticsReloadRetryInterval = 10 * time.Second
+
+// StmtBundleURLPrefix is the path of the admin server endpoint from which a
+// bundle can be downloaded; it is followed by the ID of the bundle.
+const StmtBundleURLPrefix = "/_admin/v1/stmtbundle/"
+
+// StmtBundle is a statement diagnostics bundle: a zip archive containing the
+// information needed to debug the planning and execution of a statement.
+type StmtBundle struct {
+	// Fingerprint is the anonymized statement that was diagnosed.
+	Fingerprint string
+	// Statement is the text of the statement that was diagnosed.
+	Statement   string
+	CollectedAt time.Time
+	Zip         []byte
+}
+
+// StmtDiagnosticsRegistry keeps track of the pending requests for statement
+// diagnostics, which are made on demand through
+// crdb_internal.request_statement_bundle(), and stores the bundles collected
+// by EXPLAIN ANALYZE (DEBUG) or in response to these requests.
+//
+// The requests are stored in system.statement_diagnostics_requests and the
+// bundles in system.statement_diagnostics, so that a request can be satisfied
+// by any node and the bundles survive restarts. Every node keeps an in-memory
+// copy of the pending requests, which is reloaded whenever they change: the
+// node which changes them gossips KeyStmtDiagnosticsRequestsChanged once its
+// transaction commits.
+type StmtDiagnosticsRegistry struct {
+	gossip *gossip.Gossip
+	db     *client.DB
+	ie     *InternalExecutor
+
+	// numPending is the number of pending requests. It is accessed atomically
+	// so that the common case of no requests doesn't need to lock mu or
+	// compute the fingerprint of the statement.
+	numPending int32
+
+	// reloadCh is signaled when the pending requests need to be reloaded.
+	reloadCh chan struct{}
+
+	mu struct {
+		syncutil.Mutex
+		// requests maps statement fingerprints to the IDs of the pending
+		// requests for them.
+		requests map[string]int64
+	}
+}
+
+// NewStmtDiagnosticsRegistry creates a new StmtDiagnosticsRegistry. Start
+// must be called for the pending requests to be loaded.
+func NewStmtDiagnosticsRegistry(
+	g *gossip.Gossip, db *client.DB, ie *InternalExecutor,
+) *StmtDiagnosticsRegistry {
+	r := &StmtDiagnosticsRegistry{
+		gossip:   g,
+		db:       db,
+		ie:       ie,
+		reloadCh: make(chan struct{}, 1),
+	}
+	r.mu.requests = make(map[string]int64)
+	// Gossip is only used to signal that the requests changed, not to
+	// propagate them; the callbacks must be redundant.
+	g.RegisterCallback(
+		gossip.KeyStmtDiagnosticsRequestsChanged,
+		func(string, roachpb.Value) { r.invalidate() },
+		gossip.Redundant,
+	)
+	return r
+}
+
+// Start starts the background worker which loads the pending requests, and
+// reloads them whenever they change.
+func (r *StmtDiagnosticsRegistry) Start(ctx context.Context, stopper *stop.Stopper) {
+	r.invalidate()
+	stopper.RunWorker(ctx, func(ctx context.Context) {
+		var timer timeutil.Timer
+		defer timer.Stop()
+		for {
+			select {
+			case <-r.reloadCh:
+			case <-timer.C:
+				timer.Read = true
+			case <-stopper.ShouldStop():
+				return
+			}
+			if err := r.reload(ctx); err != nil {
+				log.Warningf(ctx, "failed to load statement diagnostics requests: %v", err)
+				timer.Reset(stmtDiagnosticsReloadRetryInterval)
+			}
+		}
+	})
+}
+
+// invalidate schedules a reload of the pending requests.
+func (r *StmtDiagnosticsRegistry) invalidate() {
+	select {
+	case r.reloadCh <- struct{}{}:
+	default:
+		// A reload is already pending.
+	}
+}
+
+// reload replaces the in-memory pending requests with the ones stored in
+// system.statement_diagnostics_requests.
+func (r *StmtDiagnosticsRegistry) reload(ctx context.Context) error {
+	rows, err := r.ie.Query(
+		ctx, "load-stmt-diag-requests", nil, /* txn */
+		`SELECT id, statement_fingerprint FROM system.statement_diagnostics_requests
+WHERE completed = false`,
+	)
+	if err != nil {
+		return err
+	}
+	requests := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		requests[string(tree.MustBeDString(row[1]))] = int64(tree.MustBeDInt(row[0]))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.mu.requests = requests
+	atomic.StoreInt32(&r.numPending, int32(len(requests)))
+	return nil
+}
+
+// notifyChanged signals all the nodes, including this one, that the requests
+// in system.statement_diagnostics_requests changed. It must be called after
+// the transaction which changed them commits.
+func (r *StmtDiagnosticsRegistry) notifyChanged(ctx context.Context) {
+	r.invalidate()
+	if err := r.gossip.AddInfo(
+		gossip.KeyStmtDiagnosticsRequestsChanged, nil /* val */, 0, /* ttl */
+	); err != nil {
+		log.Warningf(ctx, "failed to gossip statement diagnostics requests change: %v", err)
+	}
+}
+
+// InsertRequest registers a request for a diagnostics bundle of the next
+// execution of a statement with the given fingerprint, on any node. Returns
+// the ID of the request; if a request for the fingerprint is already pending,
+// its ID is returned. It is used as the
+// tree.StmtDiagnosticsRequestInserterFunc of the eval context.
+func (r *StmtDiagnosticsRegistry) InsertRequest(
+	ctx context.Context, stmtFingerprint string,
+) (int64, error) {
+	if stmtFingerprint == "" {
+		return 0, pgerror.New(pgerror.CodeInvalidParameterValueError,
+			"statement fingerprint must not be empty")
+	}
+	var id int64
+	var inserted bool
+	err := r.db.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
+		row, err := r.ie.QueryRow(ctx, "stmt-diag-get-request", txn,
+			`SELECT id FROM system.statement_diagnostics_requests
+WHERE completed = false AND statement_fingerprint = $1 LIMIT 1`,
+			stmtFingerprint)
+		if err != nil {
+			return err
+		}
+		if row != nil {
+			id, inserted = int64(tree.MustBeDInt(row[0])), false
+			return nil
+		}
+		row, err = r.ie.QueryRow(ctx, "stmt-diag-insert-request", txn,
+			`INSERT INTO system.statement_diagnostics_requests (statement_fingerprint)
+VALUES ($1) RETURNING id`,
+			stmtFingerprint)
+		if err != nil {
+			return err
+		}
+		id, inserted = int64(tree.MustBeDInt(row[0])), true
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if inserted {
+		// Make the request visible to this node right away, without waiting
+		// for the reload.
+		r.mu.Lock()
+		if _, ok := r.mu.requests[stmtFingerprint]; !ok {
+			r.mu.requests[stmtFingerprint] = id
+			atomic.AddInt32(&r.numPending, 1)
+		}
+		r.mu.Unlock()
+		r.notifyChanged(ctx)
+	}
+	return id, nil
+}
+
+// shouldCollect returns the ID of the pending request for the fingerprint of
+// the given statement, if any. The request is removed from the in-memory
+// copy: it is satisfied by a single execution of the statement. Another node
+// may collect a bundle for the same request concurrently; only the first
+// bundle to be stored is kept (see storeBundle).
+func (r *StmtDiagnosticsRegistry) shouldCollect(stmt tree.Statement) (int64, bool) {
+	if atomic.LoadInt32(&r.numPending) == 0 {
+		return 0, false
+	}
+	stmtFingerprint := anonymizeStmt(stmt)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	id, ok := r.mu.requests[stmtFingerprint]
+	if ok {
+		delete(r.mu.requests, stmtFingerprint)
+		atomic.AddInt32(&r.numPending, -1)
+	}
+	return id, ok
+}
+
+// storeBundle stores a collected bundle in system.statement_diagnostics and,
+// if requestID is not zero, marks the corresponding request as completed. The
+// oldest bundles are discarded so that at most maxStmtBundles are retained.
+// Returns the ID of the bundle, or zero if the request had already been
+// completed by another node, in which case the bundle is discarded.
+func (r *StmtDiagnosticsRegistry) storeBundle(
+	ctx context.Context, requestID int64, b *StmtBundle,
+) (int64, error) {
+	if b.CollectedAt.IsZero() {
+		b.CollectedAt = timeutil.Now()
+	}
+	var id int64
+	err := r.db.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
+		id = 0
+		if requestID != 0 {
+			row, err := r.ie.QueryRow(ctx, "stmt-diag-check-request", txn,
+				`SELECT count(*) FROM system.statement_diagnostics_requests
+WHERE id = $1 AND completed = false`,
+				requestID)
+			if err != nil {
+				return err
+			}
+			if tree.MustBeDInt(row[0]) == 0 {
+				return nil
+			}
+		}
+		row, err := r.ie.QueryRow(ctx, "stmt-diag-insert-bundle", txn,
+			`INSERT INTO system.statement_diagnostics
+  (statement_fingerprint, statement, collected_at, bundle)
+VALUES ($1, $2, $3, $4) RETURNING id`,
+			b.Fingerprint, b.Statement, b.CollectedAt, b.Zip)
+		if err != nil {
+			return err
+		}
+		id = int64(tree.MustBeDInt(row[0]))
+		if requestID != 0 {
+			if _, err := r.ie.Exec(ctx, "stmt-diag-complete-request", txn,
+				`UPDATE system.statement_diagnostics_requests
+SET completed = true, statement_diagnostics_id = $1 WHERE id = $2`,
+				id, requestID); err != nil {
+				return err
+			}
+		}
+		_, err = r.ie.Exec(ctx, "stmt-diag-prune-bundles", txn,
+			`DELETE FROM system.statement_diagnostics WHERE id IN (
+  SELECT id FROM system.statement_diagnostics
+  ORDER BY collected_at DESC, id DESC OFFSET $1
+)`,
+			maxStmtBundles)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	if requestID != 0 {
+		r.notifyChanged(ctx)
+	}
+	return id, nil
+}
+
+// GetBundle returns the zip archive of the bundle with the given ID, or nil if
+// there is no such bundle (or it was discarded).
+func (r *StmtDiagnosticsRegistry) GetBundle(ctx context.Context, id int64) ([]byte, error) {
+	row, err := r.ie.QueryRow(ctx, "stmt-diag-get-bundle", nil, /* txn */
+		`SELECT bundle FROM system.statement_diagnostics WHERE id = $1`, id)
+	if err != nil || row == nil {
+		return nil, err
+	}
+	return []byte(tree.MustBeDBytes(row[0])), nil
+}
+
+// startStmtDiagnostics starts collecting the diagnostics bundle requested with
+// the given ID for the statement executed by p. The returned context, which
+// records a trace, must be used to execute the statement; the returned
+// function must be called once the statement has been executed and stores the
+// bundle.
+func startStmtDiagnostics(
+	ctx context.Context, p *planner, registry *StmtDiagnosticsRegistry, id int64,
+) (context.Context, func()) {
+	p.diagnostics = &stmtDiagnosticsCollector{}
+
+	var sp opentracing.Span
+	if parentSp := opentracing.SpanFromContext(ctx); parentSp != nil &&
+		!tracing.IsRecording(parentSp) {
+		sp = parentSp.Tracer().StartSpan(
+			"stmt-diagnostics", tracing.Recordable,
+			opentracing.ChildOf(parentSp.Context()),
+			tracing.LogTagsFromCtx(ctx))
+	} else {
+		sp = p.ExecCfg().AmbientCtx.Tracer.StartSpan(
+			"stmt-diagnostics", tracing.Recordable,
+			tracing.LogTagsFromCtx(ctx))
+	}
+	tracing.StartRecording(sp, tracing.SnowballRecording)
+	origCtx := ctx
+	ctx = opentracing.ContextWithSpan(ctx, sp)
+	origEvalCtx := p.extendedEvalCtx.Context
+	p.extendedEvalCtx.Context = ctx
+
+	return ctx, func() {
+		p.extendedEvalCtx.Context = origEvalCtx
+		sp.Finish()
+		spans := tracing.GetRecording(sp)
+		bundle, err := buildStmtBundle(origCtx, p, spans, "" /* planJSON */)
+		if err != nil {
+			log.Warningf(origCtx, "failed to build statement diagnostics bundle %d: %v", id, err)
+			return
+		}
+		bundleID, err := registry.storeBundle(origCtx, id, bundle)
+		if err != nil {
+			log.Warningf(origCtx, "failed to store statement diagnostics bundle %d: %v", id, err)
+			return
+		}
+		if bundleID == 0 {
+			log.VEventf(origCtx, 1, "statement diagnostics request %d was completed by another node", id)
+			return
+		}
+		telemetry.Inc(sqltelemetry.StatementDiagnosticsCollectedCounter)
+	}
+}
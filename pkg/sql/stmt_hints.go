@@ -0,0 +1,289 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package sql
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/gossip"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/opt"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+// stmtHintsReloadRetryInterval is the interval after which a failed reload of
+// the statement hints is retried.
+const stmtHintsReloadRetryInterval = 10 * time.Second
+
+// StmtHintsCache keeps an in-memory copy of system.statement_hints, which
+// maps statement fingerprints to the hints used when planning them (see
+// opt.StmtHints).
+//
+// The cache is reloaded in the background whenever the hints change: the node
+// which changes them gossips KeyStatementHintsChanged once its transaction
+// commits, and every node reloads its cache when it sees the key.
+type StmtHintsCache struct {
+	gossip *gossip.Gossip
+	ie     *InternalExecutor
+
+	// numHints is the number of cached hints. It is accessed atomically so that
+	// the common case of no hints doesn't need to lock mu or compute the
+	// fingerprint of the statement.
+	numHints int32
+
+	// reloadCh is signaled when the cache needs to be reloaded.
+	reloadCh chan struct{}
+
+	mu struct {
+		syncutil.Mutex
+		// hints maps statement fingerprints to their hints.
+		hints map[string]*opt.StmtHints
+	}
+}
+
+// NewStmtHintsCache creates a new StmtHintsCache. Start must be called for the
+// cache to be populated.
+func NewStmtHintsCache(g *gossip.Gossip, ie *InternalExecutor) *StmtHintsCache {
+	c := &StmtHintsCache{
+		gossip:   g,
+		ie:       ie,
+		reloadCh: make(chan struct{}, 1),
+	}
+	// Like the table statistics cache, the statement hints cache uses gossip to
+	// signal that the hints changed, not to propagate them; the callbacks must
+	// be redundant.
+	g.RegisterCallback(
+		gossip.KeyStatementHintsChanged,
+		func(string, roachpb.Value) { c.invalidate() },
+		gossip.Redundant,
+	)
+	return c
+}
+
+// Start starts the background worker which loads the hints into the cache,
+// and reloads them whenever they change.
+func (c *StmtHintsCache) Start(ctx context.Context, stopper *stop.Stopper) {
+	c.invalidate()
+	stopper.RunWorker(ctx, func(ctx context.Context) {
+		var timer timeutil.Timer
+		defer timer.Stop()
+		for {
+			select {
+			case <-c.reloadCh:
+			case <-timer.C:
+				timer.Read = true
+			case <-stopper.ShouldStop():
+				return
+			}
+			if err := c.reload(ctx); err != nil {
+				log.Warningf(ctx, "failed to load statement hints: %v", err)
+				timer.Reset(stmtHintsReloadRetryInterval)
+			}
+		}
+	})
+}
+
+// invalidate schedules a reload of the cache.
+func (c *StmtHintsCache) invalidate() {
+	select {
+	case c.reloadCh <- struct{}{}:
+	default:
+		// A reload is already pending.
+	}
+}
+
+// reload replaces the contents of the cache with the hints stored in
+// system.statement_hints. Invalid hints are skipped.
+func (c *StmtHintsCache) reload(ctx context.Context) error {
+	rows, err := c.ie.Query(
+		ctx, "load-statement-hints", nil, /* txn */
+		`SELECT fingerprint, hints::STRING FROM system.statement_hints`,
+	)
+	if err != nil {
+		return err
+	}
+	hints := make(map[string]*opt.StmtHints, len(rows))
+	for _, row := range rows {
+		fingerprint := string(tree.MustBeDString(row[0]))
+		h, err := opt.ParseStmtHints(string(tree.MustBeDString(row[1])))
+		if err != nil {
+			log.Warningf(ctx, "ignoring statement hints for %q: %v", fingerprint, err)
+			continue
+		}
+		hints[fingerprint] = h
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.mu.hints = hints
+	atomic.StoreInt32(&c.numHints, int32(len(hints)))
+	return nil
+}
+
+// notifyChanged signals all the nodes, including this one, that the hints in
+// system.statement_hints changed. It must be called after the transaction
+// which changed them commits.
+func (c *StmtHintsCache) notifyChanged(ctx context.Context) {
+	c.invalidate()
+	if err := c.gossip.AddInfo(gossip.KeyStatementHintsChanged, nil /* val */, 0 /* ttl */); err != nil {
+		log.Warningf(ctx, "failed to gossip statement hints change: %v", err)
+	}
+}
+
+// lookup returns the hints for the fingerprint of the given statement, if
+// any.
+func (c *StmtHintsCache) lookup(stmt tree.Statement) (*opt.StmtHints, bool) {
+	if atomic.LoadInt32(&c.numHints) == 0 {
+		return nil, false
+	}
+	stmtFingerprint := anonymizeStmt(stmt)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	h, ok := c.mu.hints[stmtFingerprint]
+	return h, ok
+}
+
+// normalizeStmtFingerprint parses a statement fingerprint and formats it the
+// way fingerprints are computed, so that fingerprints which differ only in
+// their formatting designate the same statements.
+func normalizeStmtFingerprint(fingerprint string) (string, error) {
+	stmt, err := parser.ParseOne(fingerprint)
+	if err != nil {
+		return "", pgerror.Wrapf(err, pgerror.CodeInvalidParameterValueError,
+			"invalid statement fingerprint %q", fingerprint)
+	}
+	return anonymizeStmt(stmt.AST), nil
+}
+
+func (p *planner) checkStmtHintsSupported(op string) error {
+	if !p.ExecCfg().Settings.Version.IsActive(cluster.VersionStatementHints) {
+		return pgerror.Newf(pgerror.CodeObjectNotInPrerequisiteStateError,
+			`%s requires all nodes to be upgraded to %s`,
+			op, cluster.VersionByKey(cluster.VersionStatementHints),
+		)
+	}
+	return nil
+}
+
+// notifyStmtHintsChangedOnCommit arranges for the statement hints caches to be
+// reloaded once the current transaction commits.
+func (p *planner) notifyStmtHintsChangedOnCommit() {
+	c := p.ExecCfg().StmtHintsCache
+	if c == nil {
+		return
+	}
+	p.txn.AddCommitTrigger(c.notifyChanged)
+}
+
+type createStmtHintsNode struct {
+	fingerprint string
+	hints       *opt.StmtHints
+}
+
+// CreateStmtHints creates or replaces the hints of the statements with a
+// given fingerprint.
+// Privileges: superuser.
+func (p *planner) CreateStmtHints(ctx context.Context, n *tree.CreateStmtHints) (planNode, error) {
+	if err := p.RequireSuperUser(ctx, "create statement hints"); err != nil {
+		return nil, err
+	}
+	if err := p.checkStmtHintsSupported("CREATE STATEMENT HINTS"); err != nil {
+		return nil, err
+	}
+	fingerprint, err := normalizeStmtFingerprint(n.Fingerprint)
+	if err != nil {
+		return nil, err
+	}
+	hints, err := opt.ParseStmtHints(n.Hints)
+	if err != nil {
+		return nil, err
+	}
+	return &createStmtHintsNode{fingerprint: fingerprint, hints: hints}, nil
+}
+
+func (n *createStmtHintsNode) startExec(params runParams) error {
+	_, err := params.p.ExecCfg().InternalExecutor.Exec(
+		params.ctx,
+		"create-statement-hints",
+		params.p.txn,
+		`UPSERT INTO system.statement_hints (fingerprint, hints, created_at)
+VALUES ($1, $2::JSONB, now())`,
+		n.fingerprint,
+		n.hints.String(),
+	)
+	if err != nil {
+		return err
+	}
+	params.p.notifyStmtHintsChangedOnCommit()
+	return nil
+}
+
+func (n *createStmtHintsNode) Next(runParams) (bool, error) { return false, nil }
+func (n *createStmtHintsNode) Values() tree.Datums          { return tree.Datums{} }
+func (n *createStmtHintsNode) Close(context.Context)        {}
+
+type dropStmtHintsNode struct {
+	fingerprint string
+	ifExists    bool
+}
+
+// DropStmtHints removes the hints of the statements with a given fingerprint.
+// Privileges: superuser.
+func (p *planner) DropStmtHints(ctx context.Context, n *tree.DropStmtHints) (planNode, error) {
+	if err := p.RequireSuperUser(ctx, "drop statement hints"); err != nil {
+		return nil, err
+	}
+	if err := p.checkStmtHintsSupported("DROP STATEMENT HINTS"); err != nil {
+		return nil, err
+	}
+	fingerprint, err := normalizeStmtFingerprint(n.Fingerprint)
+	if err != nil {
+		return nil, err
+	}
+	return &dropStmtHintsNode{fingerprint: fingerprint, ifExists: n.IfExists}, nil
+}
+
+func (n *dropStmtHintsNode) startExec(params runParams) error {
+	numRows, err := params.p.ExecCfg().InternalExecutor.Exec(
+		params.ctx,
+		"drop-statement-hints",
+		params.p.txn,
+		`DELETE FROM system.statement_hints WHERE fingerprint = $1`,
+		n.fingerprint,
+	)
+	if err != nil {
+		return err
+	}
+	if numRows == 0 {
+		if n.ifExists {
+			return nil
+		}
+		return pgerror.Newf(pgerror.CodeUndefinedObjectError,
+			"no statement hints for %q", n.fingerprint)
+	}
+	params.p.notifyStmtHintsChangedOnCommit()
+	return nil
+}
+
+func (n *dropStmtHintsNode) Next(runParams) (bool, error) { return false, nil }
+func (n *dropStmtHintsNode) Values() tree.Datums          { return tree.Datums{} }
+func (n *dropStmtHintsNode) Close(context.Context)        {}
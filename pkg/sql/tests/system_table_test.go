@@ -119,6 +119,10 @@ func TestSystemTableLiterals(t *testing.T) {
 		{keys.LocationsTableID, sqlbase.LocationsTableSchema, sqlbase.LocationsTable},
 		{keys.RoleMembersTableID, sqlbase.RoleMembersTableSchema, sqlbase.RoleMembersTable},
 		{keys.CommentsTableID, sqlbase.CommentsTableSchema, sqlbase.CommentsTable},
+		{keys.StatementHintsTableID, sqlbase.StatementHintsTableSchema, sqlbase.StatementHintsTable},
+		{keys.NotificationsTableID, sqlbase.NotificationsTableSchema, sqlbase.NotificationsTable},
+		{keys.StmtDiagRequestsTableID, sqlbase.StmtDiagRequestsTableSchema, sqlbase.StmtDiagRequestsTable},
+		{keys.StmtDiagTableID, sqlbase.StmtDiagTableSchema, sqlbase.StmtDiagTable},
 	} {
 		privs := *test.pkg.Privileges
 		gen, err := sql.CreateTestTableDescriptor(
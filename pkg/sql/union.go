@@ -78,6 +78,13 @@ type unionNode struct {
 	unionType tree.UnionType
 	// all indicates if the operation is the ALL or DISTINCT version
 	all bool
+
+	// hardLimit is set for UNION ALL operations which implement a locality
+	// optimized search (see opt.LocalityOptimizedSearchOp). In that case the
+	// rows of the left operand (the local one, in the input SQL syntax) are
+	// returned before the rows of the right operand, which is only executed if
+	// the left operand returns fewer than hardLimit rows.
+	hardLimit uint64
 }
 
 // Union constructs a planNode from a UNION/INTERSECT/EXCEPT expression.
@@ -162,6 +169,23 @@ func (p *planner) newUnionNode(
 	return node, nil
 }
 
+// newLocalityOptimizedSearchNode constructs a unionNode which returns the
+// rows of local followed by the rows of remote, up to hardLimit rows in total.
+// The remote plan is only executed if the local plan returns fewer rows.
+func (p *planner) newLocalityOptimizedSearchNode(
+	local, remote planNode, hardLimit int64,
+) (planNode, error) {
+	if hardLimit <= 0 {
+		return nil, pgerror.AssertionFailedf("invalid locality optimized search limit %d", hardLimit)
+	}
+	plan, err := p.newUnionNode(tree.UnionOp, true /* all */, local, remote)
+	if err != nil {
+		return nil, err
+	}
+	plan.(*unionNode).hardLimit = uint64(hardLimit)
+	return plan, nil
+}
+
 func (n *unionNode) startExec(params runParams) error {
 	panic("unionNode cannot be run in local mode")
 }
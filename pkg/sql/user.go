@@ -16,11 +16,83 @@ import (
 	"context"
 
 	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
 )
 
+const (
+	passwordEncryptionBcrypt = iota
+	passwordEncryptionScramSHA256
+)
+
+// passwordEncryption controls the hash method used to store new passwords in
+// system.users.
+var passwordEncryption = settings.RegisterEnumSetting(
+	"server.user_login.password_encryption",
+	"the hash method used to store new passwords in system.users",
+	"scram-sha-256",
+	map[int64]string{
+		passwordEncryptionBcrypt:      "bcrypt",
+		passwordEncryptionScramSHA256: "scram-sha-256",
+	},
+)
+
+// usesScramPasswords returns true iff new passwords are to be stored as
+// SCRAM-SHA-256 hashes. Nodes running older versions cannot verify these, so
+// bcrypt hashes are used until the cluster version permits them.
+func usesScramPasswords(st *cluster.Settings) bool {
+	return passwordEncryption.Get(&st.SV) == passwordEncryptionScramSHA256 &&
+		st.Version.IsActive(cluster.VersionScramPasswords)
+}
+
+// hashPassword hashes a password for storage in system.users.
+func hashPassword(st *cluster.Settings, password string) ([]byte, error) {
+	if usesScramPasswords(st) {
+		return security.HashPasswordScram(password)
+	}
+	return security.HashPassword(password)
+}
+
+// MaybeUpgradeUserPasswordHash replaces the bcrypt hash of a user's password
+// with a SCRAM-SHA-256 hash, so that the user can subsequently authenticate
+// without sending the password in cleartext. It must only be called after the
+// password has been verified against hashedPassword, which is the only time the
+// password is known to the server.
+//
+// Failures are logged but not returned, as they should not prevent the user
+// from logging in; the upgrade is simply attempted again on the next login.
+func MaybeUpgradeUserPasswordHash(
+	ctx context.Context,
+	execCfg *ExecutorConfig,
+	username string,
+	password string,
+	hashedPassword []byte,
+) {
+	if len(hashedPassword) == 0 || security.IsScramHash(hashedPassword) ||
+		!usesScramPasswords(execCfg.Settings) {
+		return
+	}
+	newHashedPassword, err := security.HashPasswordScram(password)
+	if err != nil {
+		log.Warningf(ctx, "unable to hash password of user %s: %v", username, err)
+		return
+	}
+	// The update is conditional on the old hash so that a password changed
+	// concurrently is not overwritten.
+	if _, err := execCfg.InternalExecutor.Exec(
+		ctx, "upgrade-user-password", nil, /* txn */
+		`UPDATE system.users SET "hashedPassword" = $3 `+
+			`WHERE username = $1 AND "hashedPassword" = $2 AND "isRole" = false`,
+		tree.Name(username).Normalize(), hashedPassword, newHashedPassword,
+	); err != nil {
+		log.Warningf(ctx, "unable to upgrade password hash of user %s: %v", username, err)
+	}
+}
+
 // GetUserHashedPassword returns the hashedPassword for the given username if
 // found in system.users.
 func GetUserHashedPassword(
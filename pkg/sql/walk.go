@@ -240,6 +240,13 @@ func (v *planVisitor) visitInternal(plan planNode, name string) {
 		}
 		n.input = v.visit(n.input)
 
+	case *invertedJoinNode:
+		if v.observer.attr != nil {
+			v.observer.attr(name, "table", fmt.Sprintf("%s@%s", n.table.desc.Name, n.table.index.Name))
+			v.observer.attr(name, "input column", fmt.Sprintf("%d", n.inputCol))
+		}
+		n.input = v.visit(n.input)
+
 	case *zigzagJoinNode:
 		if v.observer.attr != nil {
 			v.observer.attr(name, "type", joinTypeStr(sqlbase.InnerJoin))
@@ -412,6 +419,9 @@ func (v *planVisitor) visitInternal(plan planNode, name string) {
 		n.plan = v.visit(n.plan)
 
 	case *unionNode:
+		if n.hardLimit > 0 && v.observer.attr != nil {
+			v.observer.attr(name, "limit", fmt.Sprintf("%d", n.hardLimit))
+		}
 		n.left = v.visit(n.left)
 		n.right = v.visit(n.right)
 
@@ -667,6 +677,9 @@ func nodeName(plan planNode) string {
 			return "revscan"
 		}
 	case *unionNode:
+		if n.hardLimit > 0 {
+			return "locality-optimized-search"
+		}
 		if n.emitAll {
 			return "append"
 		}
@@ -722,8 +735,10 @@ var planNodeNames = map[reflect.Type]string{
 	reflect.TypeOf(&controlJobsNode{}):          "control jobs",
 	reflect.TypeOf(&createDatabaseNode{}):       "create database",
 	reflect.TypeOf(&createIndexNode{}):          "create index",
+	reflect.TypeOf(&createPolicyNode{}):         "create policy",
 	reflect.TypeOf(&createSequenceNode{}):       "create sequence",
 	reflect.TypeOf(&createStatsNode{}):          "create statistics",
+	reflect.TypeOf(&createStmtHintsNode{}):      "create statement hints",
 	reflect.TypeOf(&createTableNode{}):          "create table",
 	reflect.TypeOf(&CreateUserNode{}):           "create user/role",
 	reflect.TypeOf(&createViewNode{}):           "create view",
@@ -733,7 +748,9 @@ var planNodeNames = map[reflect.Type]string{
 	reflect.TypeOf(&distinctNode{}):             "distinct",
 	reflect.TypeOf(&dropDatabaseNode{}):         "drop database",
 	reflect.TypeOf(&dropIndexNode{}):            "drop index",
+	reflect.TypeOf(&dropPolicyNode{}):           "drop policy",
 	reflect.TypeOf(&dropSequenceNode{}):         "drop sequence",
+	reflect.TypeOf(&dropStmtHintsNode{}):        "drop statement hints",
 	reflect.TypeOf(&dropTableNode{}):            "drop table",
 	reflect.TypeOf(&DropUserNode{}):             "drop user/role",
 	reflect.TypeOf(&dropViewNode{}):             "drop view",
@@ -745,10 +762,12 @@ var planNodeNames = map[reflect.Type]string{
 	reflect.TypeOf(&hookFnNode{}):               "plugin",
 	reflect.TypeOf(&indexJoinNode{}):            "index-join",
 	reflect.TypeOf(&insertNode{}):               "insert",
+	reflect.TypeOf(&invertedJoinNode{}):         "inverted-join",
 	reflect.TypeOf(&joinNode{}):                 "join",
 	reflect.TypeOf(&limitNode{}):                "limit",
 	reflect.TypeOf(&lookupJoinNode{}):           "lookup-join",
 	reflect.TypeOf(&max1RowNode{}):              "max1row",
+	reflect.TypeOf(&notifyNode{}):               "notify",
 	reflect.TypeOf(&ordinalityNode{}):           "ordinality",
 	reflect.TypeOf(&projectSetNode{}):           "project set",
 	reflect.TypeOf(&relocateNode{}):             "relocate",
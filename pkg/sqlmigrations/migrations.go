@@ -208,6 +208,27 @@ var backwardCompatibleMigrations = []migrationDescriptor{
 		name:   "propagate the ts purge interval to the new setting names",
 		workFn: retireOldTsPurgeIntervalSettings,
 	},
+	{
+		// Introduced in v19.2.
+		name:                "create system.statement_hints table",
+		workFn:              createStatementHintsTable,
+		includedInBootstrap: true,
+		newDescriptorIDs:    staticIDs(keys.StatementHintsTableID),
+	},
+	{
+		// Introduced in v19.2.
+		name:                "create system.notifications table",
+		workFn:              createNotificationsTable,
+		includedInBootstrap: true,
+		newDescriptorIDs:    staticIDs(keys.NotificationsTableID),
+	},
+	{
+		// Introduced in v19.2.
+		name:                "create system.statement_diagnostics_requests and system.statement_diagnostics tables",
+		workFn:              createStmtDiagTables,
+		includedInBootstrap: true,
+		newDescriptorIDs:    staticIDs(keys.StmtDiagRequestsTableID, keys.StmtDiagTableID),
+	},
 }
 
 func staticIDs(ids ...sqlbase.ID) func(ctx context.Context, db db) ([]sqlbase.ID, error) {
@@ -552,6 +573,21 @@ func createCommentTable(ctx context.Context, r runner) error {
 	return createSystemTable(ctx, r, sqlbase.CommentsTable)
 }
 
+func createStatementHintsTable(ctx context.Context, r runner) error {
+	return createSystemTable(ctx, r, sqlbase.StatementHintsTable)
+}
+
+func createNotificationsTable(ctx context.Context, r runner) error {
+	return createSystemTable(ctx, r, sqlbase.NotificationsTable)
+}
+
+func createStmtDiagTables(ctx context.Context, r runner) error {
+	if err := createSystemTable(ctx, r, sqlbase.StmtDiagRequestsTable); err != nil {
+		return err
+	}
+	return createSystemTable(ctx, r, sqlbase.StmtDiagTable)
+}
+
 var reportingOptOut = envutil.EnvOrDefaultBool("COCKROACH_SKIP_ENABLING_DIAGNOSTIC_REPORTING", false)
 
 func runStmtAsRootWithRetry(
@@ -56,6 +56,9 @@ func (m *mockEvalCtx) EvalKnobs() storagebase.BatchEvalTestingKnobs {
 func (m *mockEvalCtx) Engine() engine.Engine {
 	panic("unimplemented")
 }
+func (m *mockEvalCtx) RaftEngine() engine.Engine {
+	panic("unimplemented")
+}
 func (m *mockEvalCtx) Clock() *hlc.Clock {
 	return m.clock
 }
@@ -119,7 +119,14 @@ func TruncateLog(
 	// bugs that let it diverge. It might be easier to compute the stats
 	// from scratch, stopping when 4mb (defaultRaftLogTruncationThreshold)
 	// is reached as at that point we'll truncate aggressively anyway.
-	iter := batch.NewIterator(engine.IterOptions{UpperBound: end.Key})
+	//
+	// If the Raft log is kept in a dedicated engine, it isn't visible to the
+	// batch and is read directly from that engine.
+	reader := engine.Reader(batch)
+	if raftEng := cArgs.EvalCtx.RaftEngine(); raftEng != cArgs.EvalCtx.Engine() {
+		reader = raftEng
+	}
+	iter := reader.NewIterator(engine.IterOptions{UpperBound: end.Key})
 	defer iter.Close()
 	// We can pass zero as nowNanos because we're only interested in SysBytes.
 	ms, err := iter.ComputeStats(start, end, 0 /* nowNanos */)
@@ -52,6 +52,9 @@ type EvalContext interface {
 	EvalKnobs() storagebase.BatchEvalTestingKnobs
 
 	Engine() engine.Engine
+	// RaftEngine returns the engine holding the Raft log, which is the same
+	// as Engine() unless the store keeps its Raft log in a dedicated engine.
+	RaftEngine() engine.Engine
 	Clock() *hlc.Clock
 	DB() *client.DB
 	AbortSpan() *abortspan.AbortSpan
@@ -22,6 +22,7 @@ import (
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
@@ -369,6 +370,74 @@ func TestClosedTimestampCantServeForNonTransactionalReadRequest(t *testing.T) {
 	verifyNotLeaseHolderErrors(t, baQueryTxn, repls, 2)
 }
 
+// TestClosedTimestampGlobalReads verifies that the followers of a range with
+// global reads serve present time reads, and that transactions writing to the
+// range aren't acknowledged before their commit timestamp, which is in the
+// future, has passed; the reads therefore observe the writes which preceded
+// them.
+func TestClosedTimestampGlobalReads(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	if util.RaceEnabled {
+		// Limiting how long transactions can run does not work
+		// well with race unless we're extremely lenient, which
+		// drives up the test duration.
+		t.Skip("skipping under race")
+	}
+
+	ctx := context.Background()
+	// Set up the target duration to be very long, so that only the closed
+	// timestamps carried by the Raft commands of the range with global reads
+	// allow its followers to serve present time reads.
+	tc, db0, desc, repls := setupTestClusterForClosedTimestampTesting(ctx, t, time.Hour)
+	defer tc.Stopper().Stop(ctx)
+
+	if _, err := db0.Exec(`ALTER TABLE cttest.kv CONFIGURE ZONE USING global_reads = true`); err != nil {
+		t.Fatal(err)
+	}
+
+	clock := tc.Server(0).Clock()
+	testutils.SucceedsSoon(t, func() error {
+		baRead := makeReadBatchRequestForDesc(desc, clock.Now())
+		return verifyCanReadFromAllRepls(ctx, t, baRead, repls, expectRows(0))
+	})
+
+	// Write to the range. The write is performed in the future, and the
+	// transaction waits until its commit timestamp has passed.
+	start := clock.Now()
+	key := desc.StartKey.AsRawKey()
+	var commitTS hlc.Timestamp
+	if err := tc.Server(0).DB().Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
+		b := txn.NewBatch()
+		b.Put(key, "foo")
+		if err := txn.CommitInBatch(ctx, b); err != nil {
+			return err
+		}
+		commitTS = txn.Serialize().Timestamp
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	now := clock.Now()
+	if maxOffset := clock.MaxOffset().Nanoseconds(); commitTS.WallTime <= start.WallTime+maxOffset {
+		t.Fatalf("expected the write to be performed in the future, found commit timestamp %s "+
+			"with start timestamp %s", commitTS, start)
+	}
+	if now.Less(commitTS) {
+		t.Fatalf("transaction with commit timestamp %s acknowledged at %s", commitTS, now)
+	}
+
+	// Present time reads on all replicas observe the write. Followers may not
+	// have applied it yet, in which case they can't serve the read and
+	// redirect it to the leaseholder; they catch up quickly though.
+	baRead := makeReadBatchRequestForDesc(desc, clock.Now())
+	if err := verifyCanReadFromAllRepls(
+		ctx, t, baRead, repls, respFuncs(retryOnNotLeaseHolder, expectRows(1)),
+	); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func verifyNotLeaseHolderErrors(
 	t *testing.T, ba roachpb.BatchRequest, repls []*storage.Replica, expectedNLEs int,
 ) {
@@ -561,6 +630,11 @@ var retryOnRangeKeyMismatch = retryOnError(func(pErr *roachpb.Error) bool {
 	return isRangeKeyMismatch
 })
 
+var retryOnNotLeaseHolder = retryOnError(func(pErr *roachpb.Error) bool {
+	_, isNotLeaseHolder := pErr.GetDetail().(*roachpb.NotLeaseHolderError)
+	return isNotLeaseHolder
+})
+
 var retryOnRangeNotFound = retryOnError(func(pErr *roachpb.Error) bool {
 	_, isRangeNotFound := pErr.Detail.Value.(*roachpb.ErrorDetail_RangeNotFound)
 	return isRangeNotFound
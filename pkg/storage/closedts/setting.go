@@ -38,3 +38,23 @@ var CloseFraction = settings.RegisterValidatedFloatSetting(
 		}
 		return nil
 	})
+
+// GlobalReadsLead is the duration, in addition to the maximum clock offset, by
+// which the closed timestamps of ranges with global reads lead present time.
+// Writes to these ranges are performed above their closed timestamp and
+// consequently wait out approximately this lead before being acknowledged.
+var GlobalReadsLead = settings.RegisterNonNegativeDurationSetting(
+	"kv.closed_timestamp.global_reads_lead",
+	"the duration, in addition to the maximum clock offset, by which the closed timestamp of "+
+		"ranges with global_reads leads present time",
+	400*time.Millisecond,
+)
+
+// GlobalReadsCloseInterval is the interval at which leaseholders of idle
+// ranges with global reads propose Raft commands advancing their closed
+// timestamp.
+var GlobalReadsCloseInterval = settings.RegisterNonNegativeDurationSetting(
+	"kv.closed_timestamp.global_reads_close_interval",
+	"the interval at which the closed timestamp of idle ranges with global_reads is advanced",
+	200*time.Millisecond,
+)
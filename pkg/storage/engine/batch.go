@@ -279,6 +279,14 @@ func (b *RocksDBBatchBuilder) SingleClear(key MVCCKey) {
 	b.repr[pos] = byte(BatchTypeSingleDeletion)
 }
 
+// ClearRange removes all of the items in the db with keys in [start, end).
+//
+// It is safe to modify the contents of the arguments after ClearRange
+// returns.
+func (b *RocksDBBatchBuilder) ClearRange(start, end MVCCKey) {
+	b.encodeKeyValue(start, EncodeKey(end), BatchTypeRangeDeletion)
+}
+
 // LogData adds a blob of log data to the batch. It will be written to the WAL,
 // but otherwise uninterpreted by RocksDB.
 //
@@ -521,6 +529,14 @@ func (r *RocksDBBatchReader) Next() bool {
 		if r.value, r.err = r.varstring(); r.err != nil {
 			return false
 		}
+	case BatchTypeLogData:
+		// Log data is not counted as an entry in the batch and is otherwise
+		// uninterpreted, so skip over it.
+		if _, r.err = r.varstring(); r.err != nil {
+			return false
+		}
+		r.offset--
+		return r.Next()
 	default:
 		r.err = errors.Errorf("unexpected type %d", r.typ)
 		return false
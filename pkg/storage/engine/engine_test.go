@@ -63,6 +63,9 @@ func runWithAllEngines(test func(e Engine, t *testing.T), t *testing.T) {
 	inMem := NewInMem(inMemAttrs, testCacheSize)
 	stopper.AddCloser(inMem)
 	test(inMem, t)
+	goInMem := NewGoInMem(inMemAttrs, testCacheSize)
+	stopper.AddCloser(goInMem)
+	test(goInMem, t)
 }
 
 // TestEngineBatchCommit writes a batch containing 10K rows (all the
@@ -161,7 +164,7 @@ func TestEngineBatchStaleCachedIterator(t *testing.T) {
 
 		// Higher-level failure mode. Mostly for documentation.
 		{
-			batch := eng.NewBatch().(*rocksDBBatch)
+			batch := eng.NewBatch()
 			defer batch.Close()
 
 			key := roachpb.Key("z")
@@ -0,0 +1,1069 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package engine
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/google/btree"
+	"github.com/pkg/errors"
+)
+
+// goMemBTreeDegree is the degree of the btrees backing GoInMem engines.
+const goMemBTreeDegree = 32
+
+// GoInMem is a pure-Go, in-memory implementation of the Engine interface. It
+// does not use cgo and is intended for tests and simulations which want to
+// avoid the cost of RocksDB or which want to inject faults below the Engine
+// interface.
+//
+// The data is kept in a copy-on-write btree ordered by MVCC key. Snapshots
+// and iterators are cheap clones of the btree, so they observe a consistent
+// view of the data regardless of concurrent writes. Merges are applied
+// eagerly using a Go port of the libroach merge operator, and the auxiliary
+// file system is a simple in-memory map.
+type GoInMem struct {
+	attrs        roachpb.Attributes
+	maxSizeBytes int64
+	auxDir       string
+
+	mu struct {
+		syncutil.RWMutex
+		closed bool
+		data   *btree.BTree
+		// seq is incremented on every mutation of data and is used by batches
+		// to detect that their cached view of the engine is stale.
+		seq   int64
+		files map[string][]byte
+	}
+}
+
+var _ Engine = &GoInMem{}
+
+// NewGoInMem allocates and returns a new, opened GoInMem engine. The
+// maxSizeBytes parameter is only used to report the capacity of the engine.
+// The caller must call the engine's Close method when the engine is no longer
+// needed.
+func NewGoInMem(attrs roachpb.Attributes, maxSizeBytes int64) *GoInMem {
+	// Like the in-memory RocksDB engine, use a temporary directory on disk as
+	// the auxiliary directory since some users (e.g. sideloaded storage)
+	// create subdirectories in it directly.
+	auxDir, err := ioutil.TempDir(os.TempDir(), "cockroach-auxiliary")
+	if err != nil {
+		panic(err)
+	}
+	e := &GoInMem{
+		attrs:        attrs,
+		maxSizeBytes: maxSizeBytes,
+		auxDir:       auxDir,
+	}
+	e.mu.data = btree.New(goMemBTreeDegree)
+	e.mu.files = make(map[string][]byte)
+	return e
+}
+
+// String formatter.
+func (e *GoInMem) String() string {
+	return "go-mem"
+}
+
+// Close implements the Engine interface.
+func (e *GoInMem) Close() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.mu.closed {
+		return
+	}
+	e.mu.closed = true
+	e.mu.data = btree.New(goMemBTreeDegree)
+	e.mu.files = make(map[string][]byte)
+	if err := os.RemoveAll(e.auxDir); err != nil {
+		log.Warningf(context.TODO(), "could not remove auxiliary directory: %s", err)
+	}
+}
+
+// Closed implements the Engine interface.
+func (e *GoInMem) Closed() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.mu.closed
+}
+
+// snapshot returns an immutable clone of the engine's data along with the
+// sequence number at which it was taken.
+func (e *GoInMem) snapshot() (*btree.BTree, int64) {
+	// Cloning lazily marks the nodes of the original tree as shared, so it
+	// must not race with other operations on the original.
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.mu.closed {
+		panic("using a closed GoInMem engine")
+	}
+	return e.mu.data.Clone(), e.mu.seq
+}
+
+// mutate runs fn on the engine's data under the lock.
+func (e *GoInMem) mutate(fn func(tree *btree.BTree) error) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.mu.closed {
+		return errors.New("GoInMem engine is closed")
+	}
+	e.mu.seq++
+	return fn(e.mu.data)
+}
+
+// Attrs implements the Engine interface.
+func (e *GoInMem) Attrs() roachpb.Attributes {
+	return e.attrs
+}
+
+// Capacity implements the Engine interface. Like the in-memory RocksDB
+// engine, GoInMem pretends to be empty.
+func (e *GoInMem) Capacity() (roachpb.StoreCapacity, error) {
+	return roachpb.StoreCapacity{
+		Capacity:  e.maxSizeBytes,
+		Available: e.maxSizeBytes,
+	}, nil
+}
+
+// Put implements the Engine interface.
+func (e *GoInMem) Put(key MVCCKey, value []byte) error {
+	if len(key.Key) == 0 {
+		return emptyKeyError()
+	}
+	return e.mutate(func(tree *btree.BTree) error {
+		goMemPut(tree, key, value)
+		return nil
+	})
+}
+
+// Merge implements the Engine interface.
+func (e *GoInMem) Merge(key MVCCKey, value []byte) error {
+	if len(key.Key) == 0 {
+		return emptyKeyError()
+	}
+	return e.mutate(func(tree *btree.BTree) error {
+		return goMemMerge(tree, key, value)
+	})
+}
+
+// LogData implements the Engine interface. GoInMem has no write-ahead log, so
+// this is a no-op.
+func (e *GoInMem) LogData(data []byte) error {
+	return nil
+}
+
+// LogLogicalOp implements the Engine interface.
+func (e *GoInMem) LogLogicalOp(op MVCCLogicalOpType, details MVCCLogicalOpDetails) {
+	// No-op. Logical logging disabled.
+}
+
+// ApplyBatchRepr implements the Engine interface.
+func (e *GoInMem) ApplyBatchRepr(repr []byte, sync bool) error {
+	// Validate the repr before mutating anything so that the application is
+	// atomic.
+	if _, err := RocksDBBatchCount(repr); err != nil {
+		return err
+	}
+	return e.mutate(func(tree *btree.BTree) error {
+		return goMemApplyBatchRepr(tree, repr)
+	})
+}
+
+// Get implements the Engine interface.
+func (e *GoInMem) Get(key MVCCKey) ([]byte, error) {
+	tree, _ := e.snapshot()
+	return goMemGet(tree, key)
+}
+
+// GetProto implements the Engine interface.
+func (e *GoInMem) GetProto(
+	key MVCCKey, msg protoutil.Message,
+) (ok bool, keyBytes, valBytes int64, err error) {
+	tree, _ := e.snapshot()
+	return goMemGetProto(tree, key, msg)
+}
+
+// Clear implements the Engine interface.
+func (e *GoInMem) Clear(key MVCCKey) error {
+	if len(key.Key) == 0 {
+		return emptyKeyError()
+	}
+	return e.mutate(func(tree *btree.BTree) error {
+		tree.Delete(&goMemEntry{key: key})
+		return nil
+	})
+}
+
+// SingleClear implements the Engine interface.
+func (e *GoInMem) SingleClear(key MVCCKey) error {
+	return e.Clear(key)
+}
+
+// ClearRange implements the Engine interface.
+func (e *GoInMem) ClearRange(start, end MVCCKey) error {
+	return e.mutate(func(tree *btree.BTree) error {
+		goMemClearRange(tree, start, end)
+		return nil
+	})
+}
+
+// ClearIterRange implements the Engine interface.
+func (e *GoInMem) ClearIterRange(iter Iterator, start, end MVCCKey) error {
+	return goClearIterRange(e, iter, start, end)
+}
+
+// Iterate implements the Engine interface.
+func (e *GoInMem) Iterate(start, end MVCCKey, f func(MVCCKeyValue) (bool, error)) error {
+	tree, _ := e.snapshot()
+	return goMemIterate(tree, start, end, f)
+}
+
+// NewIterator implements the Engine interface. The iterator observes the
+// data as of the time of its creation.
+func (e *GoInMem) NewIterator(opts IterOptions) Iterator {
+	tree, _ := e.snapshot()
+	return newGoMemIterator(tree, opts)
+}
+
+// NewSnapshot implements the Engine interface.
+func (e *GoInMem) NewSnapshot() Reader {
+	tree, _ := e.snapshot()
+	return &goMemSnapshot{tree: tree}
+}
+
+// NewReadOnly implements the Engine interface.
+func (e *GoInMem) NewReadOnly() ReadWriter {
+	return &goMemReadOnly{parent: e}
+}
+
+// NewBatch implements the Engine interface.
+func (e *GoInMem) NewBatch() Batch {
+	return newGoMemBatch(e, false /* writeOnly */)
+}
+
+// NewWriteOnlyBatch implements the Engine interface.
+func (e *GoInMem) NewWriteOnlyBatch() Batch {
+	return newGoMemBatch(e, true /* writeOnly */)
+}
+
+// Flush implements the Engine interface.
+func (e *GoInMem) Flush() error {
+	return nil
+}
+
+// GetStats implements the Engine interface.
+func (e *GoInMem) GetStats() (*Stats, error) {
+	return &Stats{}, nil
+}
+
+// GetEnvStats implements the Engine interface.
+func (e *GoInMem) GetEnvStats() (*EnvStats, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	stats := &EnvStats{TotalFiles: uint64(len(e.mu.files))}
+	for _, data := range e.mu.files {
+		stats.TotalBytes += uint64(len(data))
+	}
+	stats.ActiveKeyFiles = stats.TotalFiles
+	stats.ActiveKeyBytes = stats.TotalBytes
+	return stats, nil
+}
+
+// GetAuxiliaryDir implements the Engine interface.
+func (e *GoInMem) GetAuxiliaryDir() string {
+	return e.auxDir
+}
+
+// IngestExternalFiles implements the Engine interface. The files are read
+// from the engine's in-memory file system, falling back to the real file
+// system, and their contents are applied atomically. The files are never
+// modified.
+func (e *GoInMem) IngestExternalFiles(
+	ctx context.Context, paths []string, skipWritingSeqNo, allowFileModifications bool,
+) error {
+	var kvs []MVCCKeyValue
+	for _, path := range paths {
+		data, err := e.ReadFile(path)
+		if os.IsNotExist(err) {
+			data, err = ioutil.ReadFile(path)
+		}
+		if err != nil {
+			return err
+		}
+		iter, err := NewMemSSTIterator(data, false /* verify */)
+		if err != nil {
+			return err
+		}
+		for iter.Seek(MVCCKey{}); ; iter.Next() {
+			if ok, err := iter.Valid(); err != nil {
+				iter.Close()
+				return err
+			} else if !ok {
+				break
+			}
+			kvs = append(kvs, MVCCKeyValue{
+				Key: MVCCKey{
+					Key:       append(roachpb.Key(nil), iter.UnsafeKey().Key...),
+					Timestamp: iter.UnsafeKey().Timestamp,
+				},
+				Value: append([]byte(nil), iter.UnsafeValue()...),
+			})
+		}
+		iter.Close()
+	}
+	return e.mutate(func(tree *btree.BTree) error {
+		for _, kv := range kvs {
+			tree.ReplaceOrInsert(&goMemEntry{key: kv.Key, value: kv.Value})
+		}
+		return nil
+	})
+}
+
+// PreIngestDelay implements the Engine interface.
+func (e *GoInMem) PreIngestDelay(ctx context.Context) {}
+
+// ApproximateDiskBytes implements the Engine interface. It returns the
+// encoded size of the key/value pairs in the span.
+func (e *GoInMem) ApproximateDiskBytes(from, to roachpb.Key) (uint64, error) {
+	tree, _ := e.snapshot()
+	var size uint64
+	err := goMemIterate(tree, MakeMVCCMetadataKey(from), MakeMVCCMetadataKey(to),
+		func(kv MVCCKeyValue) (bool, error) {
+			size += uint64(kv.Key.EncodedSize() + len(kv.Value))
+			return false, nil
+		})
+	return size, err
+}
+
+// CompactRange implements the Engine interface.
+func (e *GoInMem) CompactRange(start, end roachpb.Key, forceBottommost bool) error {
+	return nil
+}
+
+// OpenFile implements the Engine interface. The file is created (or
+// truncated) in the engine's in-memory file system.
+func (e *GoInMem) OpenFile(filename string) (DBFile, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.mu.files[filename] = nil
+	return &goMemFile{parent: e, name: filename}, nil
+}
+
+// ReadFile implements the Engine interface.
+func (e *GoInMem) ReadFile(filename string) ([]byte, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	data, ok := e.mu.files[filename]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return append([]byte(nil), data...), nil
+}
+
+// DeleteFile implements the Engine interface.
+func (e *GoInMem) DeleteFile(filename string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, ok := e.mu.files[filename]; !ok {
+		return os.ErrNotExist
+	}
+	delete(e.mu.files, filename)
+	return nil
+}
+
+// DeleteDirAndFiles implements the Engine interface.
+func (e *GoInMem) DeleteDirAndFiles(dir string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	dir = filepath.Clean(dir)
+	for name := range e.mu.files {
+		if filepath.Dir(name) == dir {
+			delete(e.mu.files, name)
+		}
+	}
+	return nil
+}
+
+// LinkFile implements the Engine interface. The in-memory file system has no
+// notion of links, so the file is copied.
+func (e *GoInMem) LinkFile(oldname, newname string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	data, ok := e.mu.files[oldname]
+	if !ok {
+		return &os.LinkError{Op: "link", Old: oldname, New: newname, Err: os.ErrNotExist}
+	}
+	if _, ok := e.mu.files[newname]; ok {
+		return &os.LinkError{Op: "link", Old: oldname, New: newname, Err: os.ErrExist}
+	}
+	e.mu.files[newname] = data
+	return nil
+}
+
+// CreateCheckpoint implements the Engine interface.
+func (e *GoInMem) CreateCheckpoint(dir string) error {
+	return errors.New("checkpoints are not supported by in-memory engines")
+}
+
+// goMemFile implements the DBFile interface for the in-memory file system of a
+// GoInMem engine.
+type goMemFile struct {
+	parent *GoInMem
+	name   string
+}
+
+var _ DBFile = &goMemFile{}
+
+// Append implements the DBFile interface.
+func (f *goMemFile) Append(data []byte) error {
+	f.parent.mu.Lock()
+	defer f.parent.mu.Unlock()
+	cur, ok := f.parent.mu.files[f.name]
+	if !ok {
+		return os.ErrNotExist
+	}
+	// Never append in place: the slice may be shared with a linked file.
+	f.parent.mu.files[f.name] = append(cur[:len(cur):len(cur)], data...)
+	return nil
+}
+
+// Close implements the DBFile interface.
+func (f *goMemFile) Close() error {
+	return nil
+}
+
+// Sync implements the DBFile interface.
+func (f *goMemFile) Sync() error {
+	return nil
+}
+
+// goMemSnapshot is a read-only view of a GoInMem engine.
+type goMemSnapshot struct {
+	tree   *btree.BTree
+	closed bool
+}
+
+var _ Reader = &goMemSnapshot{}
+
+// Close implements the Reader interface.
+func (s *goMemSnapshot) Close() {
+	s.closed = true
+	s.tree = nil
+}
+
+// Closed implements the Reader interface.
+func (s *goMemSnapshot) Closed() bool {
+	return s.closed
+}
+
+// Get implements the Reader interface.
+func (s *goMemSnapshot) Get(key MVCCKey) ([]byte, error) {
+	return goMemGet(s.tree, key)
+}
+
+// GetProto implements the Reader interface.
+func (s *goMemSnapshot) GetProto(
+	key MVCCKey, msg protoutil.Message,
+) (ok bool, keyBytes, valBytes int64, err error) {
+	return goMemGetProto(s.tree, key, msg)
+}
+
+// Iterate implements the Reader interface.
+func (s *goMemSnapshot) Iterate(start, end MVCCKey, f func(MVCCKeyValue) (bool, error)) error {
+	return goMemIterate(s.tree, start, end, f)
+}
+
+// NewIterator implements the Reader interface.
+func (s *goMemSnapshot) NewIterator(opts IterOptions) Iterator {
+	return newGoMemIterator(s.tree, opts)
+}
+
+// goMemReadOnly is the ReadWriter returned by GoInMem.NewReadOnly. Like
+// rocksDBReadOnly, its write methods panic.
+type goMemReadOnly struct {
+	parent   *GoInMem
+	isClosed bool
+}
+
+var _ ReadWriter = &goMemReadOnly{}
+
+func (r *goMemReadOnly) Close() {
+	if r.isClosed {
+		panic("closing an already-closed goMemReadOnly")
+	}
+	r.isClosed = true
+}
+
+func (r *goMemReadOnly) Closed() bool {
+	return r.isClosed
+}
+
+func (r *goMemReadOnly) Get(key MVCCKey) ([]byte, error) {
+	if r.isClosed {
+		panic("using a closed goMemReadOnly")
+	}
+	return r.parent.Get(key)
+}
+
+func (r *goMemReadOnly) GetProto(
+	key MVCCKey, msg protoutil.Message,
+) (ok bool, keyBytes, valBytes int64, err error) {
+	if r.isClosed {
+		panic("using a closed goMemReadOnly")
+	}
+	return r.parent.GetProto(key, msg)
+}
+
+func (r *goMemReadOnly) Iterate(start, end MVCCKey, f func(MVCCKeyValue) (bool, error)) error {
+	if r.isClosed {
+		panic("using a closed goMemReadOnly")
+	}
+	return r.parent.Iterate(start, end, f)
+}
+
+func (r *goMemReadOnly) NewIterator(opts IterOptions) Iterator {
+	if r.isClosed {
+		panic("using a closed goMemReadOnly")
+	}
+	return r.parent.NewIterator(opts)
+}
+
+func (r *goMemReadOnly) ApplyBatchRepr(repr []byte, sync bool) error {
+	panic("not implemented")
+}
+
+func (r *goMemReadOnly) Clear(key MVCCKey) error {
+	panic("not implemented")
+}
+
+func (r *goMemReadOnly) SingleClear(key MVCCKey) error {
+	panic("not implemented")
+}
+
+func (r *goMemReadOnly) ClearRange(start, end MVCCKey) error {
+	panic("not implemented")
+}
+
+func (r *goMemReadOnly) ClearIterRange(iter Iterator, start, end MVCCKey) error {
+	panic("not implemented")
+}
+
+func (r *goMemReadOnly) Merge(key MVCCKey, value []byte) error {
+	panic("not implemented")
+}
+
+func (r *goMemReadOnly) Put(key MVCCKey, value []byte) error {
+	panic("not implemented")
+}
+
+func (r *goMemReadOnly) LogData(data []byte) error {
+	panic("not implemented")
+}
+
+func (r *goMemReadOnly) LogLogicalOp(op MVCCLogicalOpType, details MVCCLogicalOpDetails) {
+	panic("not implemented")
+}
+
+// goMemBatch implements the Batch interface for GoInMem engines. Mutations
+// are accumulated in a RocksDBBatchBuilder, so Repr is compatible with
+// RocksDB. Reads are served from a view: a clone of the engine's data with the
+// batch's mutations applied. The view is rebuilt whenever the engine has been
+// modified since it was built, so that, as with RocksDB batches, reads observe
+// the engine's latest data.
+type goMemBatch struct {
+	parent    *GoInMem
+	builder   RocksDBBatchBuilder
+	writeOnly bool
+
+	view    *btree.BTree
+	viewSeq int64
+
+	distinct     goMemDistinctBatch
+	distinctOpen bool
+	closed       bool
+	committed    bool
+}
+
+var _ Batch = &goMemBatch{}
+
+func newGoMemBatch(parent *GoInMem, writeOnly bool) *goMemBatch {
+	b := &goMemBatch{parent: parent, writeOnly: writeOnly}
+	b.distinct.batch = b
+	return b
+}
+
+// getView returns an up-to-date view of the engine with the batch's mutations
+// applied.
+func (b *goMemBatch) getView() *btree.BTree {
+	if b.view != nil {
+		b.parent.mu.RLock()
+		seq := b.parent.mu.seq
+		b.parent.mu.RUnlock()
+		if seq == b.viewSeq {
+			return b.view
+		}
+	}
+	b.view, b.viewSeq = b.parent.snapshot()
+	if b.builder.count > 0 {
+		if err := goMemApplyBatchRepr(b.view, b.builder.getRepr()); err != nil {
+			panic(err)
+		}
+	}
+	return b.view
+}
+
+// record applies a mutation to the view, if there is one, after it has been
+// added to the builder.
+func (b *goMemBatch) record(fn func(tree *btree.BTree) error) error {
+	if b.view == nil {
+		return nil
+	}
+	// The view is shared with any open iterators, so mutate a clone.
+	b.view = b.view.Clone()
+	return fn(b.view)
+}
+
+func (b *goMemBatch) checkReadable() {
+	if b.writeOnly {
+		panic("write-only batch")
+	}
+	if b.distinctOpen {
+		panic("distinct batch open")
+	}
+}
+
+func (b *goMemBatch) checkWritable() {
+	if b.distinctOpen {
+		panic("distinct batch open")
+	}
+}
+
+// Close implements the Batch interface.
+func (b *goMemBatch) Close() {
+	if b.closed {
+		panic("this batch was already closed")
+	}
+	b.closed = true
+	b.view = nil
+	b.builder.reset()
+}
+
+// Closed implements the Batch interface.
+func (b *goMemBatch) Closed() bool {
+	return b.closed || b.committed
+}
+
+// Put implements the Batch interface.
+func (b *goMemBatch) Put(key MVCCKey, value []byte) error {
+	b.checkWritable()
+	if len(key.Key) == 0 {
+		return emptyKeyError()
+	}
+	b.builder.Put(key, value)
+	return b.record(func(tree *btree.BTree) error {
+		goMemPut(tree, key, value)
+		return nil
+	})
+}
+
+// Merge implements the Batch interface.
+func (b *goMemBatch) Merge(key MVCCKey, value []byte) error {
+	b.checkWritable()
+	if len(key.Key) == 0 {
+		return emptyKeyError()
+	}
+	b.builder.Merge(key, value)
+	return b.record(func(tree *btree.BTree) error {
+		return goMemMerge(tree, key, value)
+	})
+}
+
+// LogData implements the Batch interface.
+func (b *goMemBatch) LogData(data []byte) error {
+	b.checkWritable()
+	b.builder.LogData(data)
+	return nil
+}
+
+// LogLogicalOp implements the Batch interface.
+func (b *goMemBatch) LogLogicalOp(op MVCCLogicalOpType, details MVCCLogicalOpDetails) {
+	// No-op. Logical logging disabled.
+}
+
+// ApplyBatchRepr implements the Batch interface.
+func (b *goMemBatch) ApplyBatchRepr(repr []byte, sync bool) error {
+	b.checkWritable()
+	if err := b.builder.ApplyRepr(repr); err != nil {
+		return err
+	}
+	return b.record(func(tree *btree.BTree) error {
+		return goMemApplyBatchRepr(tree, repr)
+	})
+}
+
+// Clear implements the Batch interface.
+func (b *goMemBatch) Clear(key MVCCKey) error {
+	b.checkWritable()
+	if len(key.Key) == 0 {
+		return emptyKeyError()
+	}
+	b.builder.Clear(key)
+	return b.record(func(tree *btree.BTree) error {
+		tree.Delete(&goMemEntry{key: key})
+		return nil
+	})
+}
+
+// SingleClear implements the Batch interface.
+func (b *goMemBatch) SingleClear(key MVCCKey) error {
+	b.checkWritable()
+	if len(key.Key) == 0 {
+		return emptyKeyError()
+	}
+	b.builder.SingleClear(key)
+	return b.record(func(tree *btree.BTree) error {
+		tree.Delete(&goMemEntry{key: key})
+		return nil
+	})
+}
+
+// ClearRange implements the Batch interface.
+func (b *goMemBatch) ClearRange(start, end MVCCKey) error {
+	b.checkWritable()
+	b.builder.ClearRange(start, end)
+	return b.record(func(tree *btree.BTree) error {
+		goMemClearRange(tree, start, end)
+		return nil
+	})
+}
+
+// ClearIterRange implements the Batch interface.
+func (b *goMemBatch) ClearIterRange(iter Iterator, start, end MVCCKey) error {
+	b.checkWritable()
+	return goClearIterRange(b, iter, start, end)
+}
+
+// Get implements the Batch interface.
+func (b *goMemBatch) Get(key MVCCKey) ([]byte, error) {
+	b.checkReadable()
+	return goMemGet(b.getView(), key)
+}
+
+// GetProto implements the Batch interface.
+func (b *goMemBatch) GetProto(
+	key MVCCKey, msg protoutil.Message,
+) (ok bool, keyBytes, valBytes int64, err error) {
+	b.checkReadable()
+	return goMemGetProto(b.getView(), key, msg)
+}
+
+// Iterate implements the Batch interface.
+func (b *goMemBatch) Iterate(start, end MVCCKey, f func(MVCCKeyValue) (bool, error)) error {
+	b.checkReadable()
+	return goMemIterate(b.getView(), start, end, f)
+}
+
+// NewIterator implements the Batch interface. The returned iterator observes
+// writes to the batch performed before each seek.
+func (b *goMemBatch) NewIterator(opts IterOptions) Iterator {
+	b.checkReadable()
+	iter := newGoMemIterator(b.getView(), opts)
+	iter.refresh = b.getView
+	return iter
+}
+
+// Commit implements the Batch interface.
+func (b *goMemBatch) Commit(sync bool) error {
+	if b.Empty() {
+		return nil
+	}
+	if b.committed {
+		panic("this batch was already committed")
+	}
+	if b.builder.count > 0 {
+		if err := b.parent.ApplyBatchRepr(b.builder.getRepr(), sync); err != nil {
+			return err
+		}
+	}
+	b.committed = true
+	return nil
+}
+
+// Distinct implements the Batch interface.
+func (b *goMemBatch) Distinct() ReadWriter {
+	if b.distinctOpen {
+		panic("distinct batch already open")
+	}
+	if b.writeOnly {
+		b.distinct.view, _ = b.parent.snapshot()
+	} else {
+		b.distinct.view = b.getView()
+	}
+	b.distinctOpen = true
+	return &b.distinct
+}
+
+// Empty implements the Batch interface.
+func (b *goMemBatch) Empty() bool {
+	return b.builder.count == 0 && !b.builder.logData
+}
+
+// Len implements the Batch interface.
+func (b *goMemBatch) Len() int {
+	return len(b.builder.getRepr())
+}
+
+// Repr implements the Batch interface.
+func (b *goMemBatch) Repr() []byte {
+	repr := b.builder.getRepr()
+	cpy := make([]byte, len(repr))
+	copy(cpy, repr)
+	return cpy
+}
+
+// goMemDistinctBatch is the ReadWriter returned by goMemBatch.Distinct. It
+// reads from a view of the parent batch frozen at the time of the Distinct
+// call and writes through to the parent batch.
+type goMemDistinctBatch struct {
+	batch *goMemBatch
+	view  *btree.BTree
+}
+
+var _ ReadWriter = &goMemDistinctBatch{}
+
+func (d *goMemDistinctBatch) Close() {
+	if !d.batch.distinctOpen {
+		panic("distinct batch not open")
+	}
+	d.batch.distinctOpen = false
+	d.view = nil
+}
+
+func (d *goMemDistinctBatch) Closed() bool {
+	return !d.batch.distinctOpen
+}
+
+func (d *goMemDistinctBatch) Get(key MVCCKey) ([]byte, error) {
+	return goMemGet(d.view, key)
+}
+
+func (d *goMemDistinctBatch) GetProto(
+	key MVCCKey, msg protoutil.Message,
+) (ok bool, keyBytes, valBytes int64, err error) {
+	return goMemGetProto(d.view, key, msg)
+}
+
+func (d *goMemDistinctBatch) Iterate(
+	start, end MVCCKey, f func(MVCCKeyValue) (bool, error),
+) error {
+	return goMemIterate(d.view, start, end, f)
+}
+
+func (d *goMemDistinctBatch) NewIterator(opts IterOptions) Iterator {
+	return newGoMemIterator(d.view, opts)
+}
+
+// withParent runs fn against the parent batch, temporarily marking the
+// distinct batch as closed so that the parent accepts the write.
+func (d *goMemDistinctBatch) withParent(fn func(b *goMemBatch) error) error {
+	d.batch.distinctOpen = false
+	defer func() { d.batch.distinctOpen = true }()
+	return fn(d.batch)
+}
+
+func (d *goMemDistinctBatch) ApplyBatchRepr(repr []byte, sync bool) error {
+	return d.withParent(func(b *goMemBatch) error { return b.ApplyBatchRepr(repr, sync) })
+}
+
+func (d *goMemDistinctBatch) Clear(key MVCCKey) error {
+	return d.withParent(func(b *goMemBatch) error { return b.Clear(key) })
+}
+
+func (d *goMemDistinctBatch) SingleClear(key MVCCKey) error {
+	return d.withParent(func(b *goMemBatch) error { return b.SingleClear(key) })
+}
+
+func (d *goMemDistinctBatch) ClearRange(start, end MVCCKey) error {
+	return d.withParent(func(b *goMemBatch) error { return b.ClearRange(start, end) })
+}
+
+func (d *goMemDistinctBatch) ClearIterRange(iter Iterator, start, end MVCCKey) error {
+	return d.withParent(func(b *goMemBatch) error { return b.ClearIterRange(iter, start, end) })
+}
+
+func (d *goMemDistinctBatch) Merge(key MVCCKey, value []byte) error {
+	return d.withParent(func(b *goMemBatch) error { return b.Merge(key, value) })
+}
+
+func (d *goMemDistinctBatch) Put(key MVCCKey, value []byte) error {
+	return d.withParent(func(b *goMemBatch) error { return b.Put(key, value) })
+}
+
+func (d *goMemDistinctBatch) LogData(data []byte) error {
+	return d.withParent(func(b *goMemBatch) error { return b.LogData(data) })
+}
+
+func (d *goMemDistinctBatch) LogLogicalOp(op MVCCLogicalOpType, details MVCCLogicalOpDetails) {
+	// No-op. Logical logging disabled.
+}
+
+// goMemPut inserts a copy of the key/value pair into the tree.
+func goMemPut(tree *btree.BTree, key MVCCKey, value []byte) {
+	key.Key = append(roachpb.Key(nil), key.Key...)
+	tree.ReplaceOrInsert(&goMemEntry{key: key, value: append([]byte(nil), value...)})
+}
+
+// goMemMerge merges value into the existing value for key, if any.
+func goMemMerge(tree *btree.BTree, key MVCCKey, value []byte) error {
+	var existing []byte
+	if item := tree.Get(&goMemEntry{key: key}); item != nil {
+		existing = item.(*goMemEntry).value
+	}
+	merged, err := goMergeValues(existing, value)
+	if err != nil {
+		return err
+	}
+	key.Key = append(roachpb.Key(nil), key.Key...)
+	tree.ReplaceOrInsert(&goMemEntry{key: key, value: merged})
+	return nil
+}
+
+// goMemClearRange removes all of the entries in [start, end) from the tree.
+func goMemClearRange(tree *btree.BTree, start, end MVCCKey) {
+	var toDelete []btree.Item
+	tree.AscendRange(&goMemEntry{key: start}, &goMemEntry{key: end}, func(item btree.Item) bool {
+		toDelete = append(toDelete, item)
+		return true
+	})
+	for _, item := range toDelete {
+		tree.Delete(item)
+	}
+}
+
+// goMemApplyBatchRepr applies the mutations in a RocksDB batch repr to the
+// tree.
+func goMemApplyBatchRepr(tree *btree.BTree, repr []byte) error {
+	r, err := NewRocksDBBatchReader(repr)
+	if err != nil {
+		return err
+	}
+	for r.Next() {
+		key, err := r.MVCCKey()
+		if err != nil {
+			return err
+		}
+		switch r.BatchType() {
+		case BatchTypeValue:
+			goMemPut(tree, key, r.Value())
+		case BatchTypeMerge:
+			if err := goMemMerge(tree, key, r.Value()); err != nil {
+				return err
+			}
+		case BatchTypeDeletion, BatchTypeSingleDeletion:
+			tree.Delete(&goMemEntry{key: key})
+		case BatchTypeRangeDeletion:
+			end, err := r.MVCCEndKey()
+			if err != nil {
+				return err
+			}
+			goMemClearRange(tree, key, end)
+		default:
+			return errors.Errorf("unexpected batch entry type %d", r.BatchType())
+		}
+	}
+	return r.Error()
+}
+
+func goMemGet(tree *btree.BTree, key MVCCKey) ([]byte, error) {
+	if len(key.Key) == 0 {
+		return nil, emptyKeyError()
+	}
+	item := tree.Get(&goMemEntry{key: key})
+	if item == nil {
+		return nil, nil
+	}
+	return append([]byte(nil), item.(*goMemEntry).value...), nil
+}
+
+func goMemGetProto(
+	tree *btree.BTree, key MVCCKey, msg protoutil.Message,
+) (ok bool, keyBytes, valBytes int64, err error) {
+	if len(key.Key) == 0 {
+		err = emptyKeyError()
+		return
+	}
+	item := tree.Get(&goMemEntry{key: key})
+	if item == nil {
+		if msg != nil {
+			msg.Reset()
+		}
+		return
+	}
+	value := item.(*goMemEntry).value
+	ok = true
+	if msg != nil {
+		err = protoutil.Unmarshal(value, msg)
+	}
+	keyBytes = int64(key.EncodedSize())
+	valBytes = int64(len(value))
+	return
+}
+
+func goMemIterate(
+	tree *btree.BTree, start, end MVCCKey, f func(MVCCKeyValue) (bool, error),
+) error {
+	if !start.Less(end) {
+		return nil
+	}
+	var err error
+	tree.AscendRange(&goMemEntry{key: start}, &goMemEntry{key: end}, func(item btree.Item) bool {
+		e := item.(*goMemEntry)
+		kv := MVCCKeyValue{
+			Key:   MVCCKey{Key: append(roachpb.Key(nil), e.key.Key...), Timestamp: e.key.Timestamp},
+			Value: append([]byte(nil), e.value...),
+		}
+		var done bool
+		done, err = f(kv)
+		return !done && err == nil
+	})
+	return err
+}
+
+// goClearIterRange clears the keys in [start, end) found by iter from w. It
+// is the Go equivalent of DBDeleteIterRange in libroach.
+func goClearIterRange(w Writer, iter Iterator, start, end MVCCKey) error {
+	var keys []MVCCKey
+	for iter.Seek(start); ; iter.Next() {
+		if ok, err := iter.Valid(); err != nil {
+			return err
+		} else if !ok || !iter.UnsafeKey().Less(end) {
+			break
+		}
+		keys = append(keys, iter.Key())
+	}
+	for _, key := range keys {
+		if err := w.Clear(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,263 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package engine
+
+import (
+	"math"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/google/btree"
+)
+
+// goMemEntry is a key/value pair stored in the btree of a GoInMem engine. The
+// entries are ordered by MVCC key.
+type goMemEntry struct {
+	key   MVCCKey
+	value []byte
+}
+
+var _ btree.Item = &goMemEntry{}
+
+// Less implements the btree.Item interface.
+func (e *goMemEntry) Less(than btree.Item) bool {
+	return e.key.Less(than.(*goMemEntry).key)
+}
+
+// goMemIterator implements the Iterator interface over an immutable btree
+// (i.e. a clone of an engine's or batch's data). Every positioning operation
+// is a logarithmic btree search.
+type goMemIterator struct {
+	tree       *btree.BTree
+	lowerBound roachpb.Key
+	upperBound roachpb.Key
+	cur        *goMemEntry
+	// refresh, if set, is called on every seek to obtain the latest version
+	// of the tree. It is used by batch iterators so that, as with RocksDB,
+	// they observe writes to the batch made after their creation.
+	refresh func() *btree.BTree
+}
+
+var _ Iterator = &goMemIterator{}
+
+func newGoMemIterator(tree *btree.BTree, opts IterOptions) *goMemIterator {
+	return &goMemIterator{
+		tree:       tree,
+		lowerBound: opts.LowerBound,
+		upperBound: opts.UpperBound,
+	}
+}
+
+// Close implements the Iterator interface.
+func (i *goMemIterator) Close() {
+	i.tree = nil
+	i.cur = nil
+	i.refresh = nil
+}
+
+// Stats implements the Iterator interface.
+func (i *goMemIterator) Stats() IteratorStats {
+	return IteratorStats{}
+}
+
+// Seek implements the Iterator interface.
+func (i *goMemIterator) Seek(key MVCCKey) {
+	i.maybeRefresh()
+	if i.lowerBound != nil && key.Key.Compare(i.lowerBound) < 0 {
+		key = MakeMVCCMetadataKey(i.lowerBound)
+	}
+	i.seekGE(&goMemEntry{key: key}, false /* exclusive */)
+}
+
+// SeekReverse implements the Iterator interface.
+func (i *goMemIterator) SeekReverse(key MVCCKey) {
+	i.maybeRefresh()
+	if i.upperBound != nil && key.Key.Compare(i.upperBound) >= 0 {
+		i.seekLE(&goMemEntry{key: MakeMVCCMetadataKey(i.upperBound)}, true /* exclusive */)
+		return
+	}
+	i.seekLE(&goMemEntry{key: key}, false /* exclusive */)
+}
+
+func (i *goMemIterator) maybeRefresh() {
+	if i.refresh != nil {
+		i.tree = i.refresh()
+	}
+}
+
+// Valid implements the Iterator interface.
+func (i *goMemIterator) Valid() (bool, error) {
+	return i.cur != nil, nil
+}
+
+// Next implements the Iterator interface.
+func (i *goMemIterator) Next() {
+	if i.cur == nil {
+		return
+	}
+	i.seekGE(i.cur, true /* exclusive */)
+}
+
+// Prev implements the Iterator interface.
+func (i *goMemIterator) Prev() {
+	if i.cur == nil {
+		return
+	}
+	i.seekLE(i.cur, true /* exclusive */)
+}
+
+// NextKey implements the Iterator interface.
+func (i *goMemIterator) NextKey() {
+	if i.cur == nil {
+		return
+	}
+	i.seekGE(&goMemEntry{key: MakeMVCCMetadataKey(i.cur.key.Key.Next())}, false /* exclusive */)
+}
+
+// PrevKey implements the Iterator interface.
+func (i *goMemIterator) PrevKey() {
+	if i.cur == nil {
+		return
+	}
+	i.seekLE(&goMemEntry{key: MakeMVCCMetadataKey(i.cur.key.Key)}, true /* exclusive */)
+}
+
+func (i *goMemIterator) seekGE(pivot *goMemEntry, exclusive bool) {
+	i.cur = nil
+	i.tree.AscendGreaterOrEqual(pivot, func(item btree.Item) bool {
+		e := item.(*goMemEntry)
+		if exclusive && !pivot.Less(e) {
+			return true
+		}
+		i.cur = e
+		return false
+	})
+	if i.cur != nil && i.upperBound != nil && i.cur.key.Key.Compare(i.upperBound) >= 0 {
+		i.cur = nil
+	}
+}
+
+func (i *goMemIterator) seekLE(pivot *goMemEntry, exclusive bool) {
+	i.cur = nil
+	i.tree.DescendLessOrEqual(pivot, func(item btree.Item) bool {
+		e := item.(*goMemEntry)
+		if exclusive && !e.Less(pivot) {
+			return true
+		}
+		i.cur = e
+		return false
+	})
+	if i.cur != nil && i.lowerBound != nil && i.cur.key.Key.Compare(i.lowerBound) < 0 {
+		i.cur = nil
+	}
+}
+
+// Key implements the Iterator interface.
+func (i *goMemIterator) Key() MVCCKey {
+	key := i.cur.key
+	key.Key = append(roachpb.Key(nil), key.Key...)
+	return key
+}
+
+// Value implements the Iterator interface.
+func (i *goMemIterator) Value() []byte {
+	return append([]byte(nil), i.cur.value...)
+}
+
+// ValueProto implements the Iterator interface.
+func (i *goMemIterator) ValueProto(msg protoutil.Message) error {
+	return protoutil.Unmarshal(i.cur.value, msg)
+}
+
+// UnsafeKey implements the Iterator interface. Entries in the tree are never
+// mutated, so the returned key remains valid for the lifetime of the
+// iterator.
+func (i *goMemIterator) UnsafeKey() MVCCKey {
+	return i.cur.key
+}
+
+// UnsafeValue implements the Iterator interface.
+func (i *goMemIterator) UnsafeValue() []byte {
+	return i.cur.value
+}
+
+// ComputeStats implements the Iterator interface.
+func (i *goMemIterator) ComputeStats(
+	start, end MVCCKey, nowNanos int64,
+) (enginepb.MVCCStats, error) {
+	return ComputeStatsGo(i, start, end, nowNanos)
+}
+
+// FindSplitKey implements the Iterator interface. It is a port of
+// MVCCFindSplitKey in libroach/mvcc.cc.
+func (i *goMemIterator) FindSplitKey(
+	start, end, minSplitKey MVCCKey, targetSize int64,
+) (MVCCKey, error) {
+	var sizeSoFar int64
+	var bestSplitKey roachpb.Key
+	bestSplitDiff := int64(math.MaxInt64)
+	var prevKey roachpb.Key
+
+	for i.Seek(start); i.cur != nil && i.cur.key.Less(end); i.Next() {
+		key := i.cur.key
+		valid := IsValidSplitKey(key.Key) && key.Key.Compare(minSplitKey.Key) >= 0
+		diff := targetSize - sizeSoFar
+		if diff < 0 {
+			diff = -diff
+		}
+		if valid && diff < bestSplitDiff {
+			bestSplitKey = key.Key
+			bestSplitDiff = diff
+		}
+		// If diff is increasing, that means we've passed the ideal split point
+		// and should return the first key that we can.
+		if diff > bestSplitDiff && bestSplitKey != nil {
+			break
+		}
+
+		if key.IsValue() && key.Key.Equal(prevKey) {
+			sizeSoFar += mvccVersionTimestampSize + int64(len(i.cur.value))
+		} else {
+			sizeSoFar += int64(len(key.Key)) + 1 + int64(len(i.cur.value))
+			if key.IsValue() {
+				sizeSoFar += mvccVersionTimestampSize
+			}
+		}
+		prevKey = key.Key
+	}
+	if bestSplitKey == nil {
+		return MVCCKey{}, nil
+	}
+	return MVCCKey{Key: append(roachpb.Key(nil), bestSplitKey...)}, nil
+}
+
+// MVCCGet implements the Iterator interface.
+func (i *goMemIterator) MVCCGet(
+	key roachpb.Key, timestamp hlc.Timestamp, opts MVCCGetOptions,
+) (*roachpb.Value, *roachpb.Intent, error) {
+	return goMVCCGet(i, key, timestamp, opts)
+}
+
+// MVCCScan implements the Iterator interface.
+func (i *goMemIterator) MVCCScan(
+	start, end roachpb.Key, max int64, timestamp hlc.Timestamp, opts MVCCScanOptions,
+) (kvData []byte, numKVs int64, resumeSpan *roachpb.Span, intents []roachpb.Intent, err error) {
+	return goMVCCScan(i, start, end, max, timestamp, opts)
+}
+
+// SetUpperBound implements the Iterator interface.
+func (i *goMemIterator) SetUpperBound(key roachpb.Key) {
+	i.upperBound = key
+}
@@ -0,0 +1,218 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package engine
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+)
+
+// TestGoInMemMVCCScanMatchesRocksDB writes the same random MVCC data to a
+// RocksDB engine and a GoInMem engine and verifies that MVCC scans return
+// identical results.
+func TestGoInMemMVCCScanMatchesRocksDB(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	rng, _ := randutil.NewPseudoRand()
+
+	rocks := NewInMem(inMemAttrs, testCacheSize)
+	defer rocks.Close()
+	goEng := NewGoInMem(inMemAttrs, testCacheSize)
+	defer goEng.Close()
+	engines := []Engine{rocks, goEng}
+
+	const numKeys = 20
+	key := func(i int) roachpb.Key {
+		return roachpb.Key(fmt.Sprintf("%03d", i))
+	}
+	for i := 0; i < 200; i++ {
+		k := key(rng.Intn(numKeys))
+		ts := hlc.Timestamp{WallTime: int64(rng.Intn(10) + 1), Logical: int32(rng.Intn(2))}
+		var txn *roachpb.Transaction
+		if rng.Intn(10) == 0 {
+			txn = makeTxn(*txn1, ts)
+		}
+		del := rng.Intn(5) == 0
+		// Writes may legitimately fail (e.g. because of an existing intent or
+		// a newer value), but they must fail identically.
+		var errs [2]string
+		for j, eng := range engines {
+			var err error
+			if del {
+				err = MVCCDelete(ctx, eng, nil, k, ts, txn)
+			} else {
+				err = MVCCPut(ctx, eng, nil, k, ts, roachpb.MakeValueFromString(fmt.Sprint(i)), txn)
+			}
+			if err != nil {
+				errs[j] = err.Error()
+			}
+		}
+		if errs[0] != errs[1] {
+			t.Fatalf("%d: write to %s at %s: rocksdb error %q, go error %q", i, k, ts, errs[0], errs[1])
+		}
+	}
+
+	for i := 0; i < 100; i++ {
+		start, end := key(rng.Intn(numKeys)), key(rng.Intn(numKeys+1))
+		ts := hlc.Timestamp{WallTime: int64(rng.Intn(12))}
+		max := int64(rng.Intn(numKeys + 1))
+		opts := MVCCScanOptions{
+			Inconsistent: rng.Intn(2) == 0,
+			Tombstones:   rng.Intn(2) == 0,
+			Reverse:      rng.Intn(2) == 0,
+		}
+		if !opts.Inconsistent && rng.Intn(2) == 0 {
+			opts.Txn = makeTxn(*txn1, ts)
+		}
+
+		type result struct {
+			kvs     []roachpb.KeyValue
+			resume  *roachpb.Span
+			intents []roachpb.Intent
+			err     string
+		}
+		var results []result
+		for _, eng := range engines {
+			kvs, resume, intents, err := MVCCScan(ctx, eng, start, end, max, ts, opts)
+			r := result{kvs: kvs, resume: resume, intents: intents}
+			if err != nil {
+				r.err = err.Error()
+			}
+			results = append(results, r)
+		}
+		if !reflect.DeepEqual(results[0], results[1]) {
+			t.Errorf("%d: scan [%s,%s) max=%d ts=%s opts=%+v:\nrocksdb: %+v\ngo:      %+v",
+				i, start, end, max, ts, opts, results[0], results[1])
+		}
+	}
+}
+
+func TestGoInMemBatchRepr(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	var builder RocksDBBatchBuilder
+	builder.Put(mvccKey("a"), []byte("a"))
+	builder.Put(mvccKey("b"), []byte("b"))
+	builder.LogData([]byte("log"))
+	builder.Put(mvccKey("c"), []byte("c"))
+	builder.Put(mvccKey("d"), []byte("d"))
+	builder.ClearRange(mvccKey("b"), mvccKey("d"))
+	builder.Merge(mvccKey("e"), appender("x"))
+	builder.Merge(mvccKey("e"), appender("y"))
+	repr := builder.Finish()
+
+	eng := NewGoInMem(inMemAttrs, testCacheSize)
+	defer eng.Close()
+	if err := eng.ApplyBatchRepr(repr, false /* sync */); err != nil {
+		t.Fatal(err)
+	}
+	kvs, err := Scan(eng, mvccKey(roachpb.KeyMin), mvccKey(roachpb.KeyMax), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []MVCCKeyValue{
+		{Key: mvccKey("a"), Value: []byte("a")},
+		{Key: mvccKey("d"), Value: []byte("d")},
+		{Key: mvccKey("e"), Value: appender("xy")},
+	}
+	if !reflect.DeepEqual(expected, kvs) {
+		t.Fatalf("expected %v, but found %v", expected, kvs)
+	}
+}
+
+func TestGoInMemBatchAndSnapshotIsolation(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	eng := NewGoInMem(inMemAttrs, testCacheSize)
+	defer eng.Close()
+	if err := eng.Put(mvccKey("a"), []byte("1")); err != nil {
+		t.Fatal(err)
+	}
+
+	snap := eng.NewSnapshot()
+	defer snap.Close()
+	b := eng.NewBatch()
+	defer b.Close()
+	if err := b.Put(mvccKey("a"), []byte("2")); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := func(r Reader, expected string) {
+		t.Helper()
+		if v, err := r.Get(mvccKey("a")); err != nil {
+			t.Fatal(err)
+		} else if string(v) != expected {
+			t.Fatalf("expected %q, but found %q", expected, v)
+		}
+	}
+	expect(eng, "1")
+	expect(snap, "1")
+	expect(b, "2")
+
+	if err := b.Commit(false /* sync */); err != nil {
+		t.Fatal(err)
+	}
+	expect(eng, "2")
+	expect(snap, "1")
+}
+
+func TestGoInMemIngestExternalFiles(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	sst, err := MakeRocksDBSstFileWriter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sst.Close()
+	for _, k := range []string{"a", "b", "c"} {
+		if err := sst.Add(MVCCKeyValue{Key: mvccKey(k), Value: []byte(k)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := sst.Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	eng := NewGoInMem(inMemAttrs, testCacheSize)
+	defer eng.Close()
+	f, err := eng.OpenFile("ingest.sst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Append(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := eng.IngestExternalFiles(
+		context.Background(), []string{"ingest.sst"}, true /* skipWritingSeqNo */, true, /* modify */
+	); err != nil {
+		t.Fatal(err)
+	}
+	kvs, err := Scan(eng, mvccKey(roachpb.KeyMin), mvccKey(roachpb.KeyMax), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kvs) != 3 {
+		t.Fatalf("expected 3 keys, but found %v", kvs)
+	}
+}
@@ -0,0 +1,205 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package engine
+
+import (
+	"sort"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/pkg/errors"
+)
+
+// goMergeValues is a pure-Go port of the merge operator in libroach/merge.cc.
+// existing and update are marshaled enginepb.MVCCMetadata protos; existing
+// may be nil if the key has no value. The result is always a full merge: time
+// series data is sorted and deduplicated.
+func goMergeValues(existing, update []byte) ([]byte, error) {
+	var meta enginepb.MVCCMetadata
+	if existing != nil {
+		if err := protoutil.Unmarshal(existing, &meta); err != nil {
+			return nil, errors.Wrap(err, "corrupted existing value")
+		}
+	}
+	var operand enginepb.MVCCMetadata
+	if err := protoutil.Unmarshal(update, &operand); err != nil {
+		return nil, errors.Wrap(err, "corrupted operand value")
+	}
+	if err := goMergeMeta(&meta, &operand); err != nil {
+		return nil, err
+	}
+	return protoutil.Marshal(&meta)
+}
+
+func goMergeMeta(left, right *enginepb.MVCCMetadata) error {
+	if left.RawBytes == nil {
+		left.RawBytes = append([]byte(nil), right.RawBytes...)
+		if right.MergeTimestamp != nil {
+			ts := *right.MergeTimestamp
+			left.MergeTimestamp = &ts
+		}
+		if isTimeSeriesData(left.RawBytes) {
+			return consolidateTimeSeriesValue(left)
+		}
+		return nil
+	}
+	if right.RawBytes == nil {
+		return errors.New("inconsistent value types for merge (left = bytes, right = ?)")
+	}
+	leftTS, rightTS := isTimeSeriesData(left.RawBytes), isTimeSeriesData(right.RawBytes)
+	if leftTS || rightTS {
+		if !leftTS || !rightTS {
+			return errors.New("inconsistent value types for merging time series data " +
+				"(type(left) != type(right))")
+		}
+		return mergeTimeSeriesValues(left, right)
+	}
+	if len(right.RawBytes) > roachpbValueHeaderSize {
+		left.RawBytes = append(left.RawBytes, right.RawBytes[roachpbValueHeaderSize:]...)
+	}
+	return nil
+}
+
+// roachpbValueHeaderSize is the size of the checksum and tag prefix of
+// roachpb.Value.RawBytes.
+const roachpbValueHeaderSize = 5
+
+func isTimeSeriesData(rawBytes []byte) bool {
+	return roachpb.Value{RawBytes: rawBytes}.GetTag() == roachpb.ValueType_TIMESERIES
+}
+
+func mergeTimeSeriesValues(left, right *enginepb.MVCCMetadata) error {
+	leftTS, err := roachpb.Value{RawBytes: left.RawBytes}.GetTimeseries()
+	if err != nil {
+		return errors.Wrap(err, "left InternalTimeSeriesData could not be parsed from bytes")
+	}
+	rightTS, err := roachpb.Value{RawBytes: right.RawBytes}.GetTimeseries()
+	if err != nil {
+		return errors.Wrap(err, "right InternalTimeSeriesData could not be parsed from bytes")
+	}
+	if leftTS.StartTimestampNanos != rightTS.StartTimestampNanos {
+		return errors.New("time series merge failed due to mismatched start timestamps")
+	}
+	if leftTS.SampleDurationNanos != rightTS.SampleDurationNanos {
+		return errors.New("time series merge failed due to mismatched sample durations")
+	}
+
+	if len(leftTS.Last) > 0 || len(rightTS.Last) > 0 {
+		convertToColumnar(&leftTS)
+		convertToColumnar(&rightTS)
+		leftTS.Offset = append(leftTS.Offset, rightTS.Offset...)
+		leftTS.Last = append(leftTS.Last, rightTS.Last...)
+		leftTS.Count = append(leftTS.Count, rightTS.Count...)
+		leftTS.Sum = append(leftTS.Sum, rightTS.Sum...)
+		leftTS.Max = append(leftTS.Max, rightTS.Max...)
+		leftTS.Min = append(leftTS.Min, rightTS.Min...)
+		leftTS.First = append(leftTS.First, rightTS.First...)
+		leftTS.Variance = append(leftTS.Variance, rightTS.Variance...)
+		sortAndDeduplicateColumns(&leftTS)
+	} else {
+		leftTS.Samples = append(leftTS.Samples, rightTS.Samples...)
+		sortAndDeduplicateRows(&leftTS)
+	}
+	return setTimeSeriesRawBytes(left, &leftTS)
+}
+
+func consolidateTimeSeriesValue(meta *enginepb.MVCCMetadata) error {
+	ts, err := roachpb.Value{RawBytes: meta.RawBytes}.GetTimeseries()
+	if err != nil {
+		return errors.Wrap(err, "InternalTimeSeriesData could not be parsed from bytes")
+	}
+	if len(ts.Offset) > 0 {
+		convertToColumnar(&ts)
+		sortAndDeduplicateColumns(&ts)
+	} else {
+		sortAndDeduplicateRows(&ts)
+	}
+	return setTimeSeriesRawBytes(meta, &ts)
+}
+
+func setTimeSeriesRawBytes(meta *enginepb.MVCCMetadata, ts *roachpb.InternalTimeSeriesData) error {
+	var v roachpb.Value
+	if err := v.SetProto(ts); err != nil {
+		return err
+	}
+	meta.RawBytes = v.RawBytes
+	return nil
+}
+
+// convertToColumnar converts any samples in the deprecated row format into
+// the columnar format. See the comment on the C++ function of the same name.
+func convertToColumnar(ts *roachpb.InternalTimeSeriesData) {
+	if len(ts.Samples) == 0 {
+		return
+	}
+	for _, sample := range ts.Samples {
+		ts.Offset = append(ts.Offset, sample.Offset)
+		ts.Last = append(ts.Last, sample.Sum)
+	}
+	ts.Samples = ts.Samples[:0]
+}
+
+// sortAndDeduplicateRows stably sorts the samples by offset, keeping only the
+// last sample merged for any given offset.
+func sortAndDeduplicateRows(ts *roachpb.InternalTimeSeriesData) {
+	samples := ts.Samples
+	sort.SliceStable(samples, func(i, j int) bool {
+		return samples[i].Offset < samples[j].Offset
+	})
+	out := samples[:0]
+	for i := range samples {
+		if i+1 < len(samples) && samples[i+1].Offset == samples[i].Offset {
+			continue
+		}
+		out = append(out, samples[i])
+	}
+	ts.Samples = out
+}
+
+// sortAndDeduplicateColumns stably sorts all columns by the offset column,
+// keeping only the last entry merged for any given offset.
+func sortAndDeduplicateColumns(ts *roachpb.InternalTimeSeriesData) {
+	order := make([]int, len(ts.Offset))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return ts.Offset[order[i]] < ts.Offset[order[j]]
+	})
+	dedup := order[:0]
+	for i := range order {
+		if i+1 < len(order) && ts.Offset[order[i+1]] == ts.Offset[order[i]] {
+			continue
+		}
+		dedup = append(dedup, order[i])
+	}
+	rollup := len(ts.Count) > 0
+
+	var out roachpb.InternalTimeSeriesData
+	out.StartTimestampNanos = ts.StartTimestampNanos
+	out.SampleDurationNanos = ts.SampleDurationNanos
+	for _, idx := range dedup {
+		out.Offset = append(out.Offset, ts.Offset[idx])
+		out.Last = append(out.Last, ts.Last[idx])
+		if rollup {
+			out.Count = append(out.Count, ts.Count[idx])
+			out.Sum = append(out.Sum, ts.Sum[idx])
+			out.Max = append(out.Max, ts.Max[idx])
+			out.Min = append(out.Min, ts.Min[idx])
+			out.First = append(out.First, ts.First[idx])
+			out.Variance = append(out.Variance, ts.Variance[idx])
+		}
+	}
+	*ts = out
+}
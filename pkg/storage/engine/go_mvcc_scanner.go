@@ -0,0 +1,389 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package engine
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/pkg/errors"
+)
+
+// goMVCCScanner is a pure-Go implementation of the mvccScanner in
+// libroach/mvcc.h. It is used by engines which are not backed by RocksDB and
+// operates on any Iterator. Unlike its C++ counterpart it does not attempt to
+// minimize the number of seeks: all versions of a key are buffered before the
+// key is processed, which is adequate for the in-memory engines that use it.
+//
+// See the comment on mvccScanner for a description of the MVCC data layout and
+// of the numbered cases in getOne.
+type goMVCCScanner struct {
+	iter       Iterator
+	start, end roachpb.Key
+	ts         hlc.Timestamp
+	maxKeys    int64
+	txn        *roachpb.Transaction
+
+	inconsistent     bool
+	tombstones       bool
+	ignoreSequence   bool
+	reverse          bool
+	checkUncertainty bool
+
+	// versions buffers all of the entries for the current key, with the
+	// metadata key (if any) first followed by versions in descending timestamp
+	// order.
+	versions []MVCCKeyValue
+	meta     enginepb.MVCCMetadata
+
+	// Results.
+	kvData           []byte
+	numKVs           int64
+	intents          []roachpb.Intent
+	resumeKey        roachpb.Key
+	uncertaintyTS    hlc.Timestamp
+	uncertaintyFound bool
+	err              error
+}
+
+func newGoMVCCScanner(
+	iter Iterator,
+	start, end roachpb.Key,
+	timestamp hlc.Timestamp,
+	maxKeys int64,
+	txn *roachpb.Transaction,
+	inconsistent, tombstones, ignoreSequence, reverse bool,
+) *goMVCCScanner {
+	return &goMVCCScanner{
+		iter:             iter,
+		start:            start,
+		end:              end,
+		ts:               timestamp,
+		maxKeys:          maxKeys,
+		txn:              txn,
+		inconsistent:     inconsistent,
+		tombstones:       tombstones,
+		ignoreSequence:   ignoreSequence,
+		reverse:          reverse,
+		checkUncertainty: txn != nil && timestamp.Less(txn.MaxTimestamp),
+	}
+}
+
+// scan runs the scanner to completion. The results are left in the kvData,
+// numKVs, intents, resumeKey, uncertainty and err fields.
+func (s *goMVCCScanner) scan() {
+	if s.reverse {
+		s.iter.SeekReverse(MakeMVCCMetadataKey(s.end))
+	} else {
+		s.iter.Seek(MakeMVCCMetadataKey(s.start))
+	}
+	for s.loadKey() {
+		if s.numKVs == s.maxKeys {
+			s.resumeKey = append(roachpb.Key(nil), s.versions[0].Key.Key...)
+			return
+		}
+		if !s.getOne() {
+			return
+		}
+	}
+}
+
+// loadKey buffers all of the entries of the next key in the scan direction.
+// Returns false if there are no more keys in the span or if an error occurred.
+func (s *goMVCCScanner) loadKey() bool {
+	s.versions = s.versions[:0]
+	for {
+		if ok, err := s.iter.Valid(); err != nil {
+			s.err = err
+			return false
+		} else if !ok {
+			break
+		}
+		key := s.iter.UnsafeKey()
+		if s.reverse && bytes.Compare(key.Key, s.end) >= 0 {
+			// SeekReverse may land on the metadata key of the end key.
+			s.iter.Prev()
+			continue
+		}
+		if !s.reverse && bytes.Compare(key.Key, s.end) >= 0 {
+			break
+		}
+		if s.reverse && bytes.Compare(key.Key, s.start) < 0 {
+			break
+		}
+		if len(s.versions) > 0 && !bytes.Equal(key.Key, s.versions[0].Key.Key) {
+			break
+		}
+		s.versions = append(s.versions, MVCCKeyValue{Key: s.iter.Key(), Value: s.iter.Value()})
+		if s.reverse {
+			s.iter.Prev()
+		} else {
+			s.iter.Next()
+		}
+	}
+	if s.reverse {
+		for i, j := 0, len(s.versions)-1; i < j; i, j = i+1, j-1 {
+			s.versions[i], s.versions[j] = s.versions[j], s.versions[i]
+		}
+	}
+	return len(s.versions) > 0
+}
+
+// getOne processes the buffered key. Returns false if the scan should stop
+// because of an error or an uncertainty restart.
+func (s *goMVCCScanner) getOne() bool {
+	cur := s.versions[0]
+	if cur.Key.IsValue() {
+		if !s.ts.Less(cur.Key.Timestamp) {
+			// 1. Fast path: there is no intent and our read timestamp is newer than
+			// the most recent version's timestamp.
+			return s.add(cur.Key, cur.Value)
+		}
+		if s.checkUncertainty {
+			// 2. Our txn's read timestamp is less than the max timestamp seen by
+			// the txn. We need to check for clock uncertainty errors.
+			if !s.txn.MaxTimestamp.Less(cur.Key.Timestamp) {
+				return s.uncertaintyError(cur.Key.Timestamp)
+			}
+			return s.seekVersion(s.versions, s.txn.MaxTimestamp, true)
+		}
+		// 3. Seek to the desired version of the value.
+		return s.seekVersion(s.versions, s.ts, false)
+	}
+
+	if len(cur.Value) == 0 {
+		s.err = errors.Errorf("zero-length mvcc metadata")
+		return false
+	}
+	s.meta.Reset()
+	if err := protoutil.Unmarshal(cur.Value, &s.meta); err != nil {
+		s.err = errors.Wrap(err, "unable to decode MVCCMetadata")
+		return false
+	}
+	if s.meta.RawBytes != nil {
+		// 4. Emit immediately if the value is inline.
+		return s.add(cur.Key, s.meta.RawBytes)
+	}
+	if s.meta.Txn == nil {
+		s.err = errors.Errorf("intent without transaction")
+		return false
+	}
+
+	versions := s.versions[1:]
+	ownIntent := s.txn != nil && s.meta.Txn.ID == s.txn.ID
+	metaTS := hlc.Timestamp(s.meta.Timestamp)
+	if s.ts.Less(metaTS) && !ownIntent {
+		// 5. The key contains an intent, but we're reading before the intent.
+		return s.seekVersion(versions, s.ts, false)
+	}
+
+	if s.inconsistent {
+		// 6. Inconsistent read at a timestamp newer than the intent. Return the
+		// intent separately and read the version just below it.
+		s.addIntent(cur.Key.Key)
+		return s.seekVersion(versions, metaTS.Prev(), false)
+	}
+
+	if !ownIntent {
+		// 7. An intent written by another transaction. Keep scanning so that all
+		// of the intents in the span are returned.
+		s.addIntent(cur.Key.Key)
+		return true
+	}
+
+	if s.txn.Epoch == s.meta.Txn.Epoch {
+		if s.ignoreSequence || s.txn.Sequence >= s.meta.Txn.Sequence {
+			// 8. Reading our own intent at an equal or higher sequence.
+			return s.seekVersion(versions, metaTS, false)
+		}
+		// 9. Reading our own intent at a lower sequence; consult the intent
+		// history.
+		if s.getFromIntentHistory(cur.Key) {
+			return true
+		}
+		// 10. No earlier write of ours is visible; ignore the intent.
+		return s.seekVersion(versions, metaTS.Prev(), false)
+	}
+
+	if s.txn.Epoch < s.meta.Txn.Epoch {
+		// 11. The intent was written by a later epoch of our transaction.
+		s.err = errors.Errorf("failed to read with epoch %d due to a write intent with epoch %d",
+			s.txn.Epoch, s.meta.Txn.Epoch)
+		return false
+	}
+
+	// 12. The intent was written by an earlier epoch of our transaction. Ignore
+	// it and read the previous value.
+	return s.seekVersion(versions, metaTS.Prev(), false)
+}
+
+// seekVersion emits the newest version at or below desired. If
+// checkUncertainty is true, emitting a version newer than the read timestamp
+// results in an uncertainty error instead.
+func (s *goMVCCScanner) seekVersion(
+	versions []MVCCKeyValue, desired hlc.Timestamp, checkUncertainty bool,
+) bool {
+	for _, v := range versions {
+		if desired.Less(v.Key.Timestamp) {
+			continue
+		}
+		if checkUncertainty && s.ts.Less(v.Key.Timestamp) {
+			return s.uncertaintyError(v.Key.Timestamp)
+		}
+		return s.add(v.Key, v.Value)
+	}
+	return true
+}
+
+func (s *goMVCCScanner) getFromIntentHistory(key MVCCKey) bool {
+	var found *enginepb.MVCCMetadata_SequencedIntent
+	for i := range s.meta.IntentHistory {
+		if s.meta.IntentHistory[i].Sequence > s.txn.Sequence {
+			break
+		}
+		found = &s.meta.IntentHistory[i]
+	}
+	if found == nil {
+		return false
+	}
+	s.add(key, found.Value)
+	return true
+}
+
+func (s *goMVCCScanner) add(key MVCCKey, value []byte) bool {
+	// Don't include deleted versions unless we've been instructed to include
+	// tombstones in the results.
+	if len(value) == 0 && !s.tombstones {
+		return true
+	}
+	encKey := EncodeKey(key)
+	var header [8]byte
+	binary.LittleEndian.PutUint32(header[:4], uint32(len(value)))
+	binary.LittleEndian.PutUint32(header[4:], uint32(len(encKey)))
+	s.kvData = append(s.kvData, header[:]...)
+	s.kvData = append(s.kvData, encKey...)
+	s.kvData = append(s.kvData, value...)
+	s.numKVs++
+	return true
+}
+
+func (s *goMVCCScanner) addIntent(key roachpb.Key) {
+	s.intents = append(s.intents, roachpb.Intent{
+		Span:   roachpb.Span{Key: key},
+		Status: roachpb.PENDING,
+		Txn:    *s.meta.Txn,
+	})
+}
+
+func (s *goMVCCScanner) uncertaintyError(ts hlc.Timestamp) bool {
+	s.uncertaintyFound = true
+	s.uncertaintyTS = ts
+	s.kvData = nil
+	s.numKVs = 0
+	s.intents = nil
+	return false
+}
+
+// goMVCCGet implements Iterator.MVCCGet on top of goMVCCScanner.
+func goMVCCGet(
+	iter Iterator, key roachpb.Key, timestamp hlc.Timestamp, opts MVCCGetOptions,
+) (*roachpb.Value, *roachpb.Intent, error) {
+	if opts.Inconsistent && opts.Txn != nil {
+		return nil, nil, errors.Errorf("cannot allow inconsistent reads within a transaction")
+	}
+	if len(key) == 0 {
+		return nil, nil, emptyKeyError()
+	}
+
+	s := newGoMVCCScanner(iter, key, key.Next(), timestamp, 1 /* maxKeys */, opts.Txn,
+		opts.Inconsistent, opts.Tombstones, opts.IgnoreSequence, false /* reverse */)
+	s.scan()
+	if s.err != nil {
+		return nil, nil, s.err
+	}
+	if s.uncertaintyFound {
+		return nil, nil, roachpb.NewReadWithinUncertaintyIntervalError(
+			timestamp, s.uncertaintyTS, opts.Txn)
+	}
+	if !opts.Inconsistent && len(s.intents) > 0 {
+		return nil, nil, &roachpb.WriteIntentError{Intents: s.intents}
+	}
+
+	var intent *roachpb.Intent
+	if len(s.intents) > 1 {
+		return nil, nil, errors.Errorf("expected 0 or 1 intents, got %d", len(s.intents))
+	} else if len(s.intents) == 1 {
+		intent = &s.intents[0]
+	}
+	if s.numKVs == 0 {
+		return nil, intent, nil
+	}
+	mvccKey, rawValue, _, err := MVCCScanDecodeKeyValue(s.kvData)
+	if err != nil {
+		return nil, nil, err
+	}
+	value := &roachpb.Value{
+		RawBytes:  rawValue,
+		Timestamp: mvccKey.Timestamp,
+	}
+	return value, intent, nil
+}
+
+// goMVCCScan implements Iterator.MVCCScan on top of goMVCCScanner.
+func goMVCCScan(
+	iter Iterator,
+	start, end roachpb.Key,
+	max int64,
+	timestamp hlc.Timestamp,
+	opts MVCCScanOptions,
+) (kvData []byte, numKVs int64, resumeSpan *roachpb.Span, intents []roachpb.Intent, err error) {
+	if opts.Inconsistent && opts.Txn != nil {
+		return nil, 0, nil, nil, errors.Errorf("cannot allow inconsistent reads within a transaction")
+	}
+	if len(end) == 0 {
+		return nil, 0, nil, nil, emptyKeyError()
+	}
+	if max == 0 {
+		resumeSpan = &roachpb.Span{Key: start, EndKey: end}
+		return nil, 0, resumeSpan, nil, nil
+	}
+
+	s := newGoMVCCScanner(iter, start, end, timestamp, max, opts.Txn,
+		opts.Inconsistent, opts.Tombstones, opts.IgnoreSequence, opts.Reverse)
+	s.scan()
+	if s.err != nil {
+		return nil, 0, nil, nil, s.err
+	}
+	if s.uncertaintyFound {
+		return nil, 0, nil, nil, roachpb.NewReadWithinUncertaintyIntervalError(
+			timestamp, s.uncertaintyTS, opts.Txn)
+	}
+
+	if s.resumeKey != nil {
+		if opts.Reverse {
+			resumeSpan = &roachpb.Span{Key: start, EndKey: s.resumeKey.Next()}
+		} else {
+			resumeSpan = &roachpb.Span{Key: s.resumeKey, EndKey: end}
+		}
+	}
+	if !opts.Inconsistent && len(s.intents) > 0 {
+		// When encountering intents during a consistent scan we still need to
+		// return the resume key.
+		return nil, 0, resumeSpan, nil, &roachpb.WriteIntentError{Intents: s.intents}
+	}
+	return s.kvData, s.numKVs, resumeSpan, s.intents, nil
+}
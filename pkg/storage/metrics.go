@@ -366,6 +366,32 @@ var (
 		Unit:        metric.Unit_COUNT,
 	}
 
+	// Disk health metrics.
+	metaDiskProbeLatency = metric.Metadata{
+		Name:        "disk.probe.latency",
+		Help:        "Latency histogram for writing and syncing the disk health probe file",
+		Measurement: "Latency",
+		Unit:        metric.Unit_NANOSECONDS,
+	}
+	metaDiskWALSyncLatency = metric.Metadata{
+		Name:        "disk.wal-sync.latency",
+		Help:        "Latency histogram for syncing the write-ahead log in disk health probes",
+		Measurement: "Latency",
+		Unit:        metric.Unit_NANOSECONDS,
+	}
+	metaDiskStalls = metric.Metadata{
+		Name:        "disk.stalls",
+		Help:        "Number of disk stalls detected",
+		Measurement: "Stalls",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaDiskStalled = metric.Metadata{
+		Name:        "disk.stalled",
+		Help:        "Whether the disk is currently considered stalled (1) or not (0)",
+		Measurement: "Stalled",
+		Unit:        metric.Unit_COUNT,
+	}
+
 	// Range event metrics.
 	metaRangeSplits = metric.Metadata{
 		Name:        "range.splits",
@@ -1032,6 +1058,12 @@ type StoreMetrics struct {
 	RdbReadAmplification        *metric.Gauge
 	RdbNumSSTables              *metric.Gauge
 
+	// Disk health metrics.
+	DiskProbeLatency   *metric.Histogram
+	DiskWALSyncLatency *metric.Histogram
+	DiskStalls         *metric.Counter
+	DiskStalled        *metric.Gauge
+
 	// TODO(mrtracy): This should be removed as part of #4465. This is only
 	// maintained to keep the current structure of NodeStatus; it would be
 	// better to convert the Gauges above into counters which are adjusted
@@ -1246,6 +1278,12 @@ func newStoreMetrics(histogramWindow time.Duration) *StoreMetrics {
 		RdbReadAmplification:        metric.NewGauge(metaRdbReadAmplification),
 		RdbNumSSTables:              metric.NewGauge(metaRdbNumSSTables),
 
+		// Disk health metrics.
+		DiskProbeLatency:   metric.NewLatency(metaDiskProbeLatency, histogramWindow),
+		DiskWALSyncLatency: metric.NewLatency(metaDiskWALSyncLatency, histogramWindow),
+		DiskStalls:         metric.NewCounter(metaDiskStalls),
+		DiskStalled:        metric.NewGauge(metaDiskStalled),
+
 		// Range event metrics.
 		RangeSplits:                     metric.NewCounter(metaRangeSplits),
 		RangeMerges:                     metric.NewCounter(metaRangeMerges),
@@ -209,6 +209,10 @@ type Replica struct {
 	// the rest (e.g. RangeDescriptor, transaction record, Lease, ...).
 	latchMgr spanlatch.Manager
 
+	// raftClosed tracks the closed timestamps carried in the Raft commands of
+	// ranges with global reads. See replica_closedts.go.
+	raftClosed raftClosedTracker
+
 	mu struct {
 		// Protects all fields in the mu struct.
 		syncutil.RWMutex
@@ -688,6 +692,11 @@ func (r *Replica) Engine() engine.Engine {
 	return r.store.Engine()
 }
 
+// RaftEngine returns the Replica's underlying Engine holding its Raft log.
+func (r *Replica) RaftEngine() engine.Engine {
+	return r.store.RaftEngine()
+}
+
 // AbortSpan returns the Replica's AbortSpan.
 func (r *Replica) AbortSpan() *abortspan.AbortSpan {
 	// Despite its name, the AbortSpan doesn't hold on-disk data in
@@ -14,8 +14,18 @@ package storage
 
 import (
 	"context"
+	"time"
 
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/storage/batcheval/result"
+	"github.com/cockroachdb/cockroach/pkg/storage/closedts"
 	"github.com/cockroachdb/cockroach/pkg/storage/closedts/ctpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/storagepb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 )
 
 // EmitMLAI registers the replica's last assigned max lease index with the
@@ -34,3 +44,306 @@ func (r *Replica) EmitMLAI() {
 	_, untrack := r.store.cfg.ClosedTimestamp.Tracker.Track(ctx)
 	untrack(ctx, ctpb.Epoch(epoch), r.RangeID, ctpb.LAI(lai))
 }
+
+// Ranges with global reads (see config.ZoneConfig.GlobalReads) serve
+// consistent reads at present time from all of their replicas. To do so,
+// their leaseholder closes timestamps in the future: Raft commands carry a
+// closed timestamp (storagepb.RaftCommand.ClosedTimestamp) which leads the
+// proposer's clock by the maximum clock offset plus
+// kv.closed_timestamp.global_reads_lead, and below which the leaseholder
+// promises not to propose further writes. Writes are consequently performed
+// at future timestamps and wait until their timestamp has passed before being
+// acknowledged (commit-wait), so that no causally later reader can miss them.
+//
+// Once a command applies, its closed timestamp becomes part of the replicated
+// state (storagepb.ReplicaState.RaftClosedTimestamp) on every replica, which
+// uses it to serve follower reads. Leaseholders of idle ranges periodically
+// propose empty commands to keep the closed timestamp ahead of present time.
+//
+// Unlike the node-wide closed timestamps of the closedts subsystem, these
+// closed timestamps are sequenced with the commands themselves, so they
+// don't rely on lease applied indexes being communicated to followers.
+
+// raftClosedTracker tracks the closed timestamps carried in a replica's Raft
+// commands, along with the writes being evaluated by the replica. It ensures
+// that a closed timestamp is never proposed at or above the timestamp of a
+// write that has yet to be proposed, and that writes are never evaluated at
+// or below a timestamp that has been closed.
+type raftClosedTracker struct {
+	mu struct {
+		syncutil.Mutex
+		// floor is the highest closed timestamp proposed by or applied to the
+		// replica. Writes must be performed above it.
+		floor hlc.Timestamp
+		// writes counts the writes being evaluated or replicated by the lower
+		// bound of their timestamps.
+		writes map[hlc.Timestamp]int
+	}
+}
+
+// track registers a write whose timestamp will exceed minTS. It returns the
+// (forwarded) timestamp the write must be performed above, and a function
+// which must be called once the write no longer needs to be tracked.
+func (t *raftClosedTracker) track(minTS hlc.Timestamp) (hlc.Timestamp, func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	minTS.Forward(t.mu.floor)
+	if t.mu.writes == nil {
+		t.mu.writes = map[hlc.Timestamp]int{}
+	}
+	t.mu.writes[minTS]++
+	var released bool
+	return minTS, func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if released {
+			return
+		}
+		released = true
+		if t.mu.writes[minTS]--; t.mu.writes[minTS] == 0 {
+			delete(t.mu.writes, minTS)
+		}
+	}
+}
+
+// close returns a timestamp to close in a proposal. It is the highest
+// timestamp not exceeding target that is below all of the tracked writes, or
+// the previously closed timestamp if that is higher.
+func (t *raftClosedTracker) close(target hlc.Timestamp) hlc.Timestamp {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for ts := range t.mu.writes {
+		target.Backward(ts)
+	}
+	t.mu.floor.Forward(target)
+	return t.mu.floor
+}
+
+// forward forwards the closed timestamp known to the tracker.
+func (t *raftClosedTracker) forward(ts hlc.Timestamp) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.mu.floor.Forward(ts)
+}
+
+// get returns the highest closed timestamp known to the tracker.
+func (t *raftClosedTracker) get() hlc.Timestamp {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.mu.floor
+}
+
+// globalReadsLead returns the duration by which closed timestamps of ranges
+// with global reads lead present time.
+func (s *Store) globalReadsLead() time.Duration {
+	return s.Clock().MaxOffset() + closedts.GlobalReadsLead.Get(&s.cfg.Settings.SV)
+}
+
+// hasGlobalReadsRLocked returns whether the range serves consistent present
+// time reads from all of its replicas.
+func (r *Replica) hasGlobalReadsRLocked() bool {
+	return r.mu.zone.HasGlobalReads() &&
+		r.store.cfg.Settings.Version.IsActive(cluster.VersionGlobalReads)
+}
+
+// hasGlobalReads is like hasGlobalReadsRLocked, but acquires r.mu.
+func (r *Replica) hasGlobalReads() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.hasGlobalReadsRLocked()
+}
+
+// raftClosedTimestampRLocked returns the closed timestamp of the Raft commands
+// applied to the replica.
+func (r *Replica) raftClosedTimestampRLocked() hlc.Timestamp {
+	if ts := r.mu.state.RaftClosedTimestamp; ts != nil {
+		return *ts
+	}
+	return hlc.Timestamp{}
+}
+
+// trackRaftClosed registers a write with the replica's raftClosedTracker. The
+// returned timestamp, which the write must be performed above, is forwarded
+// past the closed timestamp that the range will propose next if it has global
+// reads.
+func (r *Replica) trackRaftClosed(minTS hlc.Timestamp) (hlc.Timestamp, func()) {
+	if r.hasGlobalReads() {
+		minTS.Forward(r.store.Clock().Now().Add(r.store.globalReadsLead().Nanoseconds(), 0))
+	}
+	return r.raftClosed.track(minTS)
+}
+
+// initRaftClosedRLocked initializes the replica's raftClosedTracker from the
+// replicated state after it has been loaded from disk. A closed timestamp may
+// have been proposed before a restart but not applied yet; such a timestamp
+// may still apply and can't exceed present time by more than the lead (and
+// the maximum clock offset, in case the clock regressed across the restart),
+// so the tracker is forwarded accordingly. This leaves a window for ranges
+// whose first closed timestamp was in flight across a restart, which is
+// closed by the time the restart took more than the lead.
+func (r *Replica) initRaftClosedRLocked() {
+	closed := r.raftClosedTimestampRLocked()
+	if closed == (hlc.Timestamp{}) {
+		return
+	}
+	r.raftClosed.forward(closed)
+	lead := r.store.globalReadsLead() + r.store.Clock().MaxOffset()
+	r.raftClosed.forward(r.store.Clock().Now().Add(lead.Nanoseconds(), 0))
+}
+
+// proposalWriteTimestamp returns the timestamp above which the proposal's
+// writes were forced by the replica's raftClosedTracker, if any. Only requests
+// consulting the timestamp cache are affected by closed timestamps; others
+// (e.g. intent resolutions) only operate on existing intents, which conflict
+// with reads at higher timestamps.
+func proposalWriteTimestamp(p *ProposalData) (hlc.Timestamp, bool) {
+	ba := p.Request
+	if ba == nil || ba.IsLeaseRequest() {
+		return hlc.Timestamp{}, false
+	}
+	for _, union := range ba.Requests {
+		if roachpb.ConsultsTimestampCache(union.GetInner()) {
+			if ba.Txn != nil {
+				return ba.Txn.Timestamp, true
+			}
+			return ba.Timestamp, true
+		}
+	}
+	return hlc.Timestamp{}, false
+}
+
+// maybeAttachClosedTimestampLocked attaches a closed timestamp to a proposal
+// of a range with global reads. It is called whenever a command is
+// (re)proposed with a new lease index.
+//
+// The replica mutex must be held.
+func (r *Replica) maybeAttachClosedTimestampLocked(p *ProposalData) {
+	p.command.ClosedTimestamp = nil
+	if !r.hasGlobalReadsRLocked() || !r.mu.state.UsingAppliedStateKey ||
+		(p.Request != nil && p.Request.IsLeaseRequest()) {
+		return
+	}
+	target := r.store.Clock().Now().Add(r.store.globalReadsLead().Nanoseconds(), 0)
+	if r.mergeInProgressRLocked() {
+		// Once the range has been subsumed, its closed timestamp must not
+		// advance past the one the left-hand side inherits.
+		target = hlc.Timestamp{}
+	}
+	if ts, ok := proposalWriteTimestamp(p); ok {
+		target.Backward(ts.Prev())
+	}
+	closed := r.raftClosed.close(target)
+	if closed == (hlc.Timestamp{}) {
+		return
+	}
+	p.command.ClosedTimestamp = &closed
+}
+
+// canReproposeWithClosedTimestampLocked returns an error if a proposal that
+// failed to apply at its lease index can't be reproposed because its writes
+// are no longer above the closed timestamp.
+func (r *Replica) canReproposeWithClosedTimestampLocked(p *ProposalData) *roachpb.Error {
+	ts, ok := proposalWriteTimestamp(p)
+	if !ok || r.raftClosed.get().Less(ts) {
+		return nil
+	}
+	err := roachpb.NewTransactionRetryError(roachpb.RETRY_SERIALIZABLE,
+		"reproposal failed due to closed timestamp")
+	if p.Request.Txn == nil {
+		return roachpb.NewError(roachpb.NewAmbiguousResultError(err.Error()))
+	}
+	return roachpb.NewErrorWithTxn(err, p.Request.Txn)
+}
+
+// maybeProposeClosedTimestampLocked proposes an empty command advancing the
+// closed timestamp of a range with global reads if its leaseholder hasn't
+// proposed one within kv.closed_timestamp.global_reads_close_interval. It is
+// called on every Raft tick.
+//
+// Both raftMu and the replica mutex must be held.
+func (r *Replica) maybeProposeClosedTimestampLocked(ctx context.Context) {
+	if !r.hasGlobalReadsRLocked() || !r.mu.state.UsingAppliedStateKey ||
+		r.mergeInProgressRLocked() || len(r.mu.proposals) > 0 {
+		// NB: the command must not race with the subsumption of the range,
+		// whose final closed timestamp is inherited by the left-hand side.
+		// Subsume requests are pending proposals until they have applied, at
+		// which point the merge is in progress.
+		return
+	}
+	now := r.store.Clock().Now()
+	if !r.ownsValidLeaseRLocked(now) {
+		return
+	}
+	interval := closedts.GlobalReadsCloseInterval.Get(&r.store.cfg.Settings.SV)
+	target := now.Add(r.store.globalReadsLead().Nanoseconds(), 0)
+	if target.Less(r.raftClosed.get().Add(interval.Nanoseconds(), 0)) {
+		return
+	}
+	repDesc, err := r.getReplicaDescriptorRLocked()
+	if err != nil {
+		return
+	}
+	ctx = r.AnnotateCtx(ctx)
+	proposal := &ProposalData{
+		ctx:     ctx,
+		idKey:   makeIDKey(),
+		doneCh:  make(chan proposalResult, 1),
+		Local:   &result.LocalResult{Reply: &roachpb.BatchResponse{}},
+		Request: &roachpb.BatchRequest{},
+		command: &storagepb.RaftCommand{
+			ProposerReplica:       repDesc,
+			ProposerLeaseSequence: r.mu.state.Lease.Sequence,
+			ReplicatedEvalResult:  storagepb.ReplicatedEvalResult{Timestamp: now},
+		},
+	}
+	if _, pErr := r.proposeLocked(ctx, proposal); pErr != nil {
+		log.VEventf(ctx, 2, "unable to propose closed timestamp: %s", pErr)
+	}
+}
+
+// updateClock updates the store's clock with a timestamp observed in a
+// request or in a Raft command. Writes to ranges with global reads are
+// performed at future timestamps, which are ignored if they exceed physical
+// time by more than the maximum clock offset: advancing the clock to them
+// would push it ahead of the clocks of other nodes by more than they
+// tolerate.
+func (s *Store) updateClock(ts hlc.Timestamp) hlc.Timestamp {
+	if s.isFutureTimestamp(ts) {
+		return s.cfg.Clock.Now()
+	}
+	return s.cfg.Clock.Update(ts)
+}
+
+// isFutureTimestamp returns whether a timestamp exceeds physical time by more
+// than the maximum clock offset.
+func (s *Store) isFutureTimestamp(ts hlc.Timestamp) bool {
+	maxOffset := s.cfg.Clock.MaxOffset()
+	if maxOffset == 0 || maxOffset == timeutil.ClocklessMaxOffset {
+		return false
+	}
+	return ts.WallTime > s.cfg.Clock.PhysicalNow()+maxOffset.Nanoseconds()
+}
+
+// withinGlobalReadsLead returns whether a timestamp exceeds physical time by
+// no more than the maximum clock offset and the lead of the closed timestamps
+// of ranges with global reads, i.e. whether it may be the timestamp of a write
+// to such a range.
+func (s *Store) withinGlobalReadsLead(ts hlc.Timestamp) bool {
+	lead := s.cfg.Clock.MaxOffset() + s.globalReadsLead()
+	return ts.WallTime <= s.cfg.Clock.PhysicalNow()+lead.Nanoseconds()
+}
+
+// commitWait blocks until the store's clock exceeds the given timestamp, at
+// which point a write performed at that timestamp can be acknowledged.
+func (s *Store) commitWait(ctx context.Context, ts hlc.Timestamp) {
+	wait := time.Duration(ts.WallTime - s.cfg.Clock.Now().WallTime)
+	if wait <= 0 {
+		return
+	}
+	log.VEventf(ctx, 2, "waiting %s for write timestamp %s", wait, ts)
+	select {
+	case <-time.After(wait):
+	case <-ctx.Done():
+	case <-s.stopper.ShouldQuiesce():
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package storage
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestRaftClosedTracker(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ts := func(wallTime int64) hlc.Timestamp {
+		return hlc.Timestamp{WallTime: wallTime}
+	}
+	var tr raftClosedTracker
+
+	// Without tracked writes, the target is closed.
+	if closed := tr.close(ts(10)); closed != ts(10) {
+		t.Fatalf("expected %s to be closed, got %s", ts(10), closed)
+	}
+	// Writes are forced above the closed timestamp.
+	minTS, release1 := tr.track(ts(5))
+	if minTS != ts(10) {
+		t.Fatalf("expected write to be forwarded to %s, got %s", ts(10), minTS)
+	}
+	minTS, release2 := tr.track(ts(20))
+	if minTS != ts(20) {
+		t.Fatalf("expected write to remain at %s, got %s", ts(20), minTS)
+	}
+	// Closed timestamps don't pass tracked writes, and never regress.
+	if closed := tr.close(ts(30)); closed != ts(10) {
+		t.Fatalf("expected %s to be closed, got %s", ts(10), closed)
+	}
+	release1()
+	release1() // releasing twice is a no-op
+	if closed := tr.close(ts(30)); closed != ts(20) {
+		t.Fatalf("expected %s to be closed, got %s", ts(20), closed)
+	}
+	release2()
+	if closed := tr.close(ts(30)); closed != ts(30) {
+		t.Fatalf("expected %s to be closed, got %s", ts(30), closed)
+	}
+	if closed := tr.close(ts(25)); closed != ts(30) {
+		t.Fatalf("expected %s to remain closed, got %s", ts(30), closed)
+	}
+	tr.forward(ts(40))
+	if closed := tr.get(); closed != ts(40) {
+		t.Fatalf("expected %s to be closed, got %s", ts(40), closed)
+	}
+}
@@ -14,6 +14,7 @@ package storage
 
 import (
 	"context"
+	"math"
 
 	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
@@ -100,6 +101,19 @@ func (r *Replica) postDestroyRaftMuLocked(ctx context.Context, ms enginepb.MVCCS
 		},
 	})
 
+	// If the store keeps its Raft log in a dedicated engine, the log wasn't
+	// removed along with the rest of the replica's data. The removal of the
+	// replica must be durable before the log can be removed, since the two
+	// engines aren't synced together.
+	if r.store.hasDedicatedRaftEngine() {
+		if err := engine.WriteSyncNoop(ctx, r.store.Engine()); err != nil {
+			return err
+		}
+		if err := r.store.truncateDedicatedRaftLog(r.RangeID, math.MaxUint64); err != nil {
+			return err
+		}
+	}
+
 	// NB: we need the nil check below because it's possible that we're GC'ing a
 	// Replica without a replicaID, in which case it does not have a sideloaded
 	// storage.
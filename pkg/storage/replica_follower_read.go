@@ -41,7 +41,10 @@ func (r *Replica) canServeFollowerRead(
 ) *roachpb.Error {
 	canServeFollowerRead := false
 	if lErr, ok := pErr.GetDetail().(*roachpb.NotLeaseHolderError); ok &&
-		lErr.LeaseHolder != nil && lErr.Lease.Type() == roachpb.LeaseEpoch &&
+		lErr.LeaseHolder != nil &&
+		// Closed timestamps carried by Raft commands don't depend on the type
+		// of the lease, unlike those of the closed timestamp subsystem.
+		(lErr.Lease.Type() == roachpb.LeaseEpoch || r.hasGlobalReads()) &&
 		ba.IsAllTransactional() && // followerreadsccl.batchCanBeEvaluatedOnFollower
 		(ba.Txn == nil || !ba.Txn.IsWriting()) && // followerreadsccl.txnCanPerformFollowerRead
 		FollowerReadsEnabled.Get(&r.store.cfg.Settings.SV) {
@@ -89,15 +92,19 @@ func (r *Replica) canServeFollowerRead(
 // start time of the current lease because leasePostApply bumps the timestamp
 // cache forward to at least the new lease start time. Using this combination
 // allows the closed timestamp mechanism to be robust to lease transfers.
+// Ranges with global reads additionally close timestamps in their Raft
+// commands (see replica_closedts.go).
 func (r *Replica) maxClosed(ctx context.Context) hlc.Timestamp {
 	r.mu.RLock()
 	lai := r.mu.state.LeaseAppliedIndex
 	lease := *r.mu.state.Lease
 	initialMaxClosed := r.mu.initialMaxClosed
+	raftClosed := r.raftClosedTimestampRLocked()
 	r.mu.RUnlock()
 	maxClosed := r.store.cfg.ClosedTimestamp.Provider.MaxClosed(
 		lease.Replica.NodeID, r.RangeID, ctpb.Epoch(lease.Epoch), ctpb.LAI(lai))
 	maxClosed.Forward(lease.Start)
 	maxClosed.Forward(initialMaxClosed)
+	maxClosed.Forward(raftClosed)
 	return maxClosed
 }
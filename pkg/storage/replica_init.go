@@ -111,6 +111,7 @@ func (r *Replica) initRaftMuLockedReplicaMuLocked(
 	if r.mu.state, err = r.mu.stateLoader.Load(ctx, r.store.Engine(), desc); err != nil {
 		return err
 	}
+	r.initRaftClosedRLocked()
 
 	// Init the minLeaseProposedTS such that we won't use an existing lease (if
 	// any). This is so that, after a restart, we don't propose under old leases.
@@ -132,7 +133,13 @@ func (r *Replica) initRaftMuLockedReplicaMuLocked(
 
 	r.rangeStr.store(0, r.mu.state.Desc)
 
-	r.mu.lastIndex, err = r.mu.stateLoader.LoadLastIndex(ctx, r.store.Engine())
+	// A dedicated Raft engine may still hold entries which were truncated
+	// away before a crash. They may not match the truncated state, so remove
+	// them before anyone reads the log.
+	if err := r.store.truncateDedicatedRaftLog(r.RangeID, r.mu.state.TruncatedState.Index+1); err != nil {
+		return err
+	}
+	r.mu.lastIndex, err = r.mu.stateLoader.LoadLastIndex(ctx, r.store.Engine(), r.store.RaftEngine())
 	if err != nil {
 		return err
 	}
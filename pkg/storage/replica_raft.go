@@ -60,6 +60,7 @@ func (r *Replica) insertProposalLocked(proposal *ProposalData) int64 {
 		r.mu.lastAssignedLeaseIndex++
 	}
 	proposal.command.MaxLeaseIndex = r.mu.lastAssignedLeaseIndex
+	r.maybeAttachClosedTimestampLocked(proposal)
 	if proposal.command.ProposerReplica == (roachpb.ReplicaDescriptor{}) {
 		// 0 is a valid LeaseSequence value so we can't actually enforce it.
 		log.Fatalf(context.TODO(), "ProposerReplica and ProposerLeaseSequence must be filled in")
@@ -676,6 +677,16 @@ func (r *Replica) handleRaftReadyRaftMuLocked(
 
 	// We know that all of the writes from here forward will be to distinct keys.
 	writer := batch.Distinct()
+
+	// If the store keeps its Raft log in a dedicated engine, the log entries
+	// are written to a separate batch, which is committed before the batch
+	// containing the HardState.
+	raftBatch, raftWriter := batch, writer
+	if r.store.hasDedicatedRaftEngine() {
+		raftBatch = r.store.RaftEngine().NewWriteOnlyBatch()
+		defer raftBatch.Close()
+		raftWriter = raftBatch.Distinct()
+	}
 	prevLastIndex := lastIndex
 	if len(rd.Entries) > 0 {
 		// All of the entries are appended to distinct keys, returning a new
@@ -687,7 +698,7 @@ func (r *Replica) handleRaftReadyRaftMuLocked(
 		}
 		raftLogSize += sideLoadedEntriesSize
 		if lastIndex, lastTerm, raftLogSize, err = r.append(
-			ctx, writer, lastIndex, lastTerm, raftLogSize, thinEntries,
+			ctx, raftWriter, lastIndex, lastTerm, raftLogSize, thinEntries,
 		); err != nil {
 			const expl = "during append"
 			return stats, expl, errors.Wrap(err, expl)
@@ -703,6 +714,9 @@ func (r *Replica) handleRaftReadyRaftMuLocked(
 		}
 	}
 	writer.Close()
+	if raftWriter != writer {
+		raftWriter.Close()
+	}
 	// Synchronously commit the batch with the Raft log entries and Raft hard
 	// state as we're promising not to lose this data.
 	//
@@ -716,8 +730,20 @@ func (r *Replica) handleRaftReadyRaftMuLocked(
 	// uncommitted log entries, and even if they did include log entries that
 	// were not persisted to disk, it wouldn't be a problem because raft does not
 	// infer the that entries are persisted on the node that sends a snapshot.
+	//
+	// With a dedicated Raft engine, the log entries must be durable before the
+	// HardState (whose commit index may refer to them) is committed. Note that
+	// this syncs both engines when MustSync is set, even if only one of them
+	// has changes that need to be durable.
 	commitStart := timeutil.Now()
-	if err := batch.Commit(rd.MustSync && !disableSyncRaftLog.Get(&r.store.cfg.Settings.SV)); err != nil {
+	sync := rd.MustSync && !disableSyncRaftLog.Get(&r.store.cfg.Settings.SV)
+	if raftBatch != batch && len(rd.Entries) > 0 {
+		if err := raftBatch.Commit(sync); err != nil {
+			const expl = "while committing raft log batch"
+			return stats, expl, errors.Wrap(err, expl)
+		}
+	}
+	if err := batch.Commit(sync); err != nil {
 		const expl = "while committing batch"
 		return stats, expl, errors.Wrap(err, expl)
 	}
@@ -954,6 +980,10 @@ func (r *Replica) tick(livenessMap IsLiveMap) (bool, error) {
 		r.mu.internalRaftGroup.ReportUnreachable(uint64(remoteReplica))
 	}
 
+	// The leaseholder of a range with global reads periodically proposes
+	// closed timestamps, which wakes the range up if it is quiescent.
+	r.maybeProposeClosedTimestampLocked(ctx)
+
 	if r.mu.quiescent {
 		return false, nil
 	}
@@ -1840,13 +1870,21 @@ func (r *Replica) processRaftCommand(
 			raftCmd.ReplicatedEvalResult = storagepb.ReplicatedEvalResult{}
 			raftCmd.WriteBatch = nil
 			raftCmd.LogicalOpLog = nil
+			raftCmd.ClosedTimestamp = nil
 		}
 
 		// Update the node clock with the serviced request. This maintains
 		// a high water mark for all ops serviced, so that received ops without
 		// a timestamp specified are guaranteed one higher than any op already
 		// executed for overlapping keys.
-		r.store.Clock().Update(ts)
+		r.store.updateClock(ts)
+
+		// The closed timestamp carried by the command, if any, is applied
+		// along with the command. See replica_closedts.go.
+		var closedTS hlc.Timestamp
+		if raftCmd.ClosedTimestamp != nil {
+			closedTS = *raftCmd.ClosedTimestamp
+		}
 
 		var pErr *roachpb.Error
 		if raftCmd.WriteBatch != nil {
@@ -1898,7 +1936,13 @@ func (r *Replica) processRaftCommand(
 			if err := tmpBatch.ApplyBatchRepr(writeBatch.Data, false); err != nil {
 				log.Fatal(ctx, err)
 			}
-			splitPreApply(ctx, tmpBatch, raftCmd.ReplicatedEvalResult.Split.SplitTrigger)
+			// The right-hand side inherits the closed timestamp of the
+			// left-hand side.
+			r.mu.RLock()
+			rhsClosedTS := r.raftClosedTimestampRLocked()
+			r.mu.RUnlock()
+			rhsClosedTS.Forward(closedTS)
+			splitPreApply(ctx, tmpBatch, raftCmd.ReplicatedEvalResult.Split.SplitTrigger, rhsClosedTS)
 			writeBatch.Data = tmpBatch.Repr()
 			tmpBatch.Close()
 		}
@@ -1917,6 +1961,11 @@ func (r *Replica) processRaftCommand(
 			if err != nil {
 				log.Fatal(ctx, err)
 			}
+			// The left-hand side inherits the closed timestamp of the subsumed
+			// right-hand side, which may have served reads below it.
+			rhsRepl.mu.RLock()
+			closedTS.Forward(rhsRepl.raftClosedTimestampRLocked())
+			rhsRepl.mu.RUnlock()
 			const destroyData = false
 			err = rhsRepl.preDestroyRaftMuLocked(ctx, tmpBatch, tmpBatch, merge.RightDesc.NextReplicaID, destroyData)
 			if err != nil {
@@ -1929,7 +1978,7 @@ func (r *Replica) processRaftCommand(
 		{
 			var err error
 			raftCmd.ReplicatedEvalResult, err = r.applyRaftCommand(
-				ctx, idKey, raftCmd.ReplicatedEvalResult, raftIndex, leaseIndex, writeBatch)
+				ctx, idKey, raftCmd.ReplicatedEvalResult, raftIndex, leaseIndex, closedTS, writeBatch)
 
 			// applyRaftCommand returned an error, which usually indicates
 			// either a serious logic bug in CockroachDB or a disk
@@ -2057,8 +2106,14 @@ func (r *Replica) processRaftCommand(
 		// eventually apply the proposal would be a user-visible error.
 		// TODO(nvanbenschoten): This reproposal is not tracked by the
 		// quota pool. We should fix that.
-		if proposalRetry == proposalIllegalLeaseIndex && r.tryReproposeWithNewLeaseIndex(proposal) {
-			return false
+		if proposalRetry == proposalIllegalLeaseIndex {
+			reproposed, pErr := r.tryReproposeWithNewLeaseIndex(proposal)
+			if reproposed {
+				return false
+			}
+			if pErr != nil {
+				response.Err = pErr
+			}
 		}
 		// Otherwise, signal the command's status to the client.
 		proposal.finishApplication(response)
@@ -2080,8 +2135,10 @@ func (r *Replica) processRaftCommand(
 // function so that it can avoid the below_raft_protos check. Returns
 // true if the command has been successfully reproposed (not
 // necessarily by this method! But if this method returns true, the
-// command will be in the local proposals map).
-func (r *Replica) tryReproposeWithNewLeaseIndex(proposal *ProposalData) bool {
+// command will be in the local proposals map). If the command can't
+// be reproposed because its timestamp has since been closed, the
+// returned error is to be returned to the client.
+func (r *Replica) tryReproposeWithNewLeaseIndex(proposal *ProposalData) (bool, *roachpb.Error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	// Note that we don't need to validate anything about the proposal's
@@ -2103,15 +2160,19 @@ func (r *Replica) tryReproposeWithNewLeaseIndex(proposal *ProposalData) bool {
 		log.VEventf(proposal.ctx, 2, "skipping reproposal, already reproposed at index %d",
 			proposal.command.MaxLeaseIndex)
 		r.mu.proposals[proposal.idKey] = proposal
-		return true
+		return true, nil
+	}
+	if pErr := r.canReproposeWithClosedTimestampLocked(proposal); pErr != nil {
+		log.VEventf(proposal.ctx, 2, "not reproposing: %s", pErr)
+		return false, pErr
 	}
 	// Some tests check for this log message in the trace.
 	log.VEventf(proposal.ctx, 2, "retry: proposalIllegalLeaseIndex")
 	if _, pErr := r.proposeLocked(proposal.ctx, proposal); pErr != nil {
 		log.Warningf(proposal.ctx, "failed to repropose with new lease index: %s", pErr)
-		return false
+		return false, nil
 	}
-	return true
+	return true, nil
 }
 
 // maybeAcquireSnapshotMergeLock checks whether the incoming snapshot subsumes
@@ -2256,6 +2317,7 @@ func (r *Replica) applyRaftCommand(
 	idKey storagebase.CmdIDKey,
 	rResult storagepb.ReplicatedEvalResult,
 	raftAppliedIndex, leaseAppliedIndex uint64,
+	closedTS hlc.Timestamp,
 	writeBatch *storagepb.WriteBatch,
 ) (storagepb.ReplicatedEvalResult, error) {
 	if writeBatch != nil && len(writeBatch.Data) > 0 {
@@ -2274,6 +2336,7 @@ func (r *Replica) applyRaftCommand(
 	oldRaftAppliedIndex := r.mu.state.RaftAppliedIndex
 	oldLeaseAppliedIndex := r.mu.state.LeaseAppliedIndex
 	oldTruncatedState := r.mu.state.TruncatedState
+	oldClosedTS := r.raftClosedTimestampRLocked()
 
 	// Exploit the fact that a split will result in a full stats
 	// recomputation to reset the ContainsEstimates flag.
@@ -2291,6 +2354,10 @@ func (r *Replica) applyRaftCommand(
 	ms := *r.mu.state.Stats
 	r.mu.Unlock()
 
+	// Closed timestamps never regress.
+	newClosedTS := oldClosedTS
+	newClosedTS.Forward(closedTS)
+
 	if raftAppliedIndex != oldRaftAppliedIndex+1 {
 		// If we have an out of order index, there's corruption. No sense in
 		// trying to update anything or running the command. Simply return
@@ -2346,7 +2413,7 @@ func (r *Replica) applyRaftCommand(
 		// Set the range applied state, which includes the last applied raft and
 		// lease index along with the mvcc stats, all in one key.
 		if err := r.raftMu.stateLoader.SetRangeAppliedState(ctx, writer,
-			raftAppliedIndex, leaseAppliedIndex, &ms); err != nil {
+			raftAppliedIndex, leaseAppliedIndex, &ms, newClosedTS); err != nil {
 			return storagepb.ReplicatedEvalResult{}, errors.Wrap(err, "unable to set range applied state")
 		}
 	} else {
@@ -2371,7 +2438,15 @@ func (r *Replica) applyRaftCommand(
 	}
 
 	if haveTruncatedState {
-		apply, err := handleTruncatedStateBelowRaft(ctx, oldTruncatedState, rResult.State.TruncatedState, r.raftMu.stateLoader, writer)
+		// If the store keeps its Raft log in a dedicated engine, the truncated
+		// entries are removed from it after the batch has been committed.
+		raftLogWriter := engine.Writer(writer)
+		if r.store.hasDedicatedRaftEngine() {
+			raftLogWriter = nil
+		}
+		apply, err := handleTruncatedStateBelowRaft(
+			ctx, oldTruncatedState, rResult.State.TruncatedState, r.raftMu.stateLoader, writer, raftLogWriter,
+		)
 		if err != nil {
 			return storagepb.ReplicatedEvalResult{}, err
 		}
@@ -2414,6 +2489,27 @@ func (r *Replica) applyRaftCommand(
 		return storagepb.ReplicatedEvalResult{}, errors.Wrap(err, "could not commit batch")
 	}
 
+	if usingAppliedStateKey && oldClosedTS.Less(newClosedTS) {
+		r.mu.Lock()
+		r.mu.state.RaftClosedTimestamp = &newClosedTS
+		r.mu.Unlock()
+		r.raftClosed.forward(newClosedTS)
+	}
+
+	if haveTruncatedState && rResult.State.TruncatedState != nil && r.store.hasDedicatedRaftEngine() {
+		// The new TruncatedState (and the applied index, which is at least as
+		// large) must be durable before the entries are removed from the Raft
+		// engine, since the two engines aren't synced together.
+		if err := engine.WriteSyncNoop(ctx, r.store.Engine()); err != nil {
+			return storagepb.ReplicatedEvalResult{}, errors.Wrap(err, "could not sync truncated state")
+		}
+		if err := r.store.truncateDedicatedRaftLog(
+			r.RangeID, rResult.State.TruncatedState.Index+1,
+		); err != nil {
+			return storagepb.ReplicatedEvalResult{}, errors.Wrap(err, "unable to truncate raft log")
+		}
+	}
+
 	if assertHS != nil {
 		// Load the HardState that was just committed (if any).
 		rsl := stateloader.Make(rResult.Split.RightDesc.RangeID)
@@ -2455,6 +2551,7 @@ func handleTruncatedStateBelowRaft(
 	oldTruncatedState, newTruncatedState *roachpb.RaftTruncatedState,
 	loader stateloader.StateLoader,
 	distinctEng engine.ReadWriter,
+	raftLogWriter engine.Writer,
 ) (_apply bool, _ error) {
 	// If this is a log truncation, load the resulting unreplicated or legacy
 	// replicated truncated state (in that order). If the migration is happening
@@ -2481,13 +2578,18 @@ func handleTruncatedStateBelowRaft(
 	// deletion tombstones. There is a chance that ClearRange will
 	// perform well here because the tombstones could be "collapsed",
 	// but it is hardly worth the risk at this point.
+	//
+	// If raftLogWriter is nil, the Raft log is kept in a dedicated engine and
+	// the caller is responsible for removing the entries.
 	prefixBuf := &loader.RangeIDPrefixBuf
-	for idx := oldTruncatedState.Index + 1; idx <= newTruncatedState.Index; idx++ {
-		// NB: RangeIDPrefixBufs have sufficient capacity (32 bytes) to
-		// avoid allocating when constructing Raft log keys (16 bytes).
-		unsafeKey := prefixBuf.RaftLogKey(idx)
-		if err := distinctEng.Clear(engine.MakeMVCCMetadataKey(unsafeKey)); err != nil {
-			return false, errors.Wrapf(err, "unable to clear truncated Raft entries for %+v", newTruncatedState)
+	if raftLogWriter != nil {
+		for idx := oldTruncatedState.Index + 1; idx <= newTruncatedState.Index; idx++ {
+			// NB: RangeIDPrefixBufs have sufficient capacity (32 bytes) to
+			// avoid allocating when constructing Raft log keys (16 bytes).
+			unsafeKey := prefixBuf.RaftLogKey(idx)
+			if err := raftLogWriter.Clear(engine.MakeMVCCMetadataKey(unsafeKey)); err != nil {
+				return false, errors.Wrapf(err, "unable to clear truncated Raft entries for %+v", newTruncatedState)
+			}
 		}
 	}
 
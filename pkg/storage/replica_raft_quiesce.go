@@ -158,6 +158,10 @@ func (r *Replica) unquiesceAndWakeLeaderLocked() {
 // elections which will cause throughput hiccups to the range, but not
 // correctness issues.
 func (r *Replica) maybeQuiesceLocked(ctx context.Context, livenessMap IsLiveMap) bool {
+	if r.hasGlobalReadsRLocked() {
+		// Ranges with global reads keep proposing closed timestamps.
+		return false
+	}
 	status, ok := shouldReplicaQuiesce(ctx, r, r.store.Clock().Now(), len(r.mu.proposals), livenessMap)
 	if !ok {
 		return false
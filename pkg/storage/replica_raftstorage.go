@@ -79,13 +79,13 @@ func (r *replicaRaftStorage) InitialState() (raftpb.HardState, raftpb.ConfState,
 // and this method will always return at least one entry even if it exceeds
 // maxBytes. Sideloaded proposals count towards maxBytes with their payloads inlined.
 func (r *replicaRaftStorage) Entries(lo, hi, maxBytes uint64) ([]raftpb.Entry, error) {
-	readonly := r.store.Engine().NewReadOnly()
-	defer readonly.Close()
+	readonly, raftReadonly, release := r.store.newRaftReadOnly()
+	defer release()
 	ctx := r.AnnotateCtx(context.TODO())
 	if r.raftMu.sideloaded == nil {
 		return nil, errors.New("sideloaded storage is uninitialized")
 	}
-	return entries(ctx, r.mu.stateLoader, readonly, r.RangeID, r.store.raftEntryCache,
+	return entries(ctx, r.mu.stateLoader, readonly, raftReadonly, r.RangeID, r.store.raftEntryCache,
 		r.raftMu.sideloaded, lo, hi, maxBytes)
 }
 
@@ -94,7 +94,9 @@ func (r *Replica) raftEntriesLocked(lo, hi, maxBytes uint64) ([]raftpb.Entry, er
 	return (*replicaRaftStorage)(r).Entries(lo, hi, maxBytes)
 }
 
-// entries retrieves entries from the engine. To accommodate loading the term,
+// entries retrieves entries from the engine. The entries are read from raftEng
+// and the remaining Raft state from e, which are the same unless the store
+// keeps its Raft log in a dedicated engine. To accommodate loading the term,
 // `sideloaded` can be supplied as nil, in which case sideloaded entries will
 // not be inlined, the raft entry cache will not be populated with *any* of the
 // loaded entries, and maxBytes will not be applied to the payloads.
@@ -102,6 +104,7 @@ func entries(
 	ctx context.Context,
 	rsl stateloader.StateLoader,
 	e engine.Reader,
+	raftEng engine.Reader,
 	rangeID roachpb.RangeID,
 	eCache *raftentry.Cache,
 	sideloaded SideloadStorage,
@@ -171,7 +174,7 @@ func entries(
 		return exceededMaxBytes, nil
 	}
 
-	if err := iterateEntries(ctx, e, rangeID, expectedIndex, hi, scanFunc); err != nil {
+	if err := iterateEntries(ctx, raftEng, rangeID, expectedIndex, hi, scanFunc); err != nil {
 		return nil, err
 	}
 	// Cache the fetched entries, if we may.
@@ -197,7 +200,7 @@ func entries(
 		}
 
 		// Was the missing index after the last index?
-		lastIndex, err := rsl.LoadLastIndex(ctx, e)
+		lastIndex, err := rsl.LoadLastIndex(ctx, e, raftEng)
 		if err != nil {
 			return nil, err
 		}
@@ -256,10 +259,10 @@ func (r *replicaRaftStorage) Term(i uint64) (uint64, error) {
 	if e, ok := r.store.raftEntryCache.Get(r.RangeID, i); ok {
 		return e.Term, nil
 	}
-	readonly := r.store.Engine().NewReadOnly()
-	defer readonly.Close()
+	readonly, raftReadonly, release := r.store.newRaftReadOnly()
+	defer release()
 	ctx := r.AnnotateCtx(context.TODO())
-	return term(ctx, r.mu.stateLoader, readonly, r.RangeID, r.store.raftEntryCache, i)
+	return term(ctx, r.mu.stateLoader, readonly, raftReadonly, r.RangeID, r.store.raftEntryCache, i)
 }
 
 // raftTermLocked requires that r.mu is locked for reading.
@@ -271,13 +274,14 @@ func term(
 	ctx context.Context,
 	rsl stateloader.StateLoader,
 	eng engine.Reader,
+	raftEng engine.Reader,
 	rangeID roachpb.RangeID,
 	eCache *raftentry.Cache,
 	i uint64,
 ) (uint64, error) {
 	// entries() accepts a `nil` sideloaded storage and will skip inlining of
 	// sideloaded entries. We only need the term, so this is what we do.
-	ents, err := entries(ctx, rsl, eng, rangeID, eCache, nil /* sideloaded */, i, i+1, math.MaxUint64 /* maxBytes */)
+	ents, err := entries(ctx, rsl, eng, raftEng, rangeID, eCache, nil /* sideloaded */, i, i+1, math.MaxUint64 /* maxBytes */)
 	if err == raft.ErrCompacted {
 		ts, _, err := rsl.LoadRaftTruncatedState(ctx, eng)
 		if err != nil {
@@ -392,6 +396,10 @@ func (r *Replica) GetSnapshot(
 	// the corresponding Raft command not applied yet).
 	r.raftMu.Lock()
 	snap := r.store.engine.NewSnapshot()
+	raftSnap := snap
+	if r.store.hasDedicatedRaftEngine() {
+		raftSnap = r.store.raftEngine.NewSnapshot()
+	}
 	r.mu.Lock()
 	appliedIndex := r.mu.state.RaftAppliedIndex
 	r.addSnapshotLogTruncationConstraintLocked(ctx, snapUUID, appliedIndex) // cleared when OutgoingSnapshot closes
@@ -406,6 +414,9 @@ func (r *Replica) GetSnapshot(
 		if err != nil {
 			release()
 			snap.Close()
+			if raftSnap != snap {
+				raftSnap.Close()
+			}
 		}
 	}()
 
@@ -432,7 +443,7 @@ func (r *Replica) GetSnapshot(
 	// create a new state loader.
 	snapData, err := snapshot(
 		ctx, snapUUID, stateloader.Make(rangeID), snapType,
-		snap, rangeID, r.store.raftEntryCache, withSideloaded, startKey,
+		snap, raftSnap, rangeID, r.store.raftEntryCache, withSideloaded, startKey,
 	)
 	if err != nil {
 		log.Errorf(ctx, "error generating snapshot: %s", err)
@@ -451,6 +462,10 @@ type OutgoingSnapshot struct {
 	RaftSnap raftpb.Snapshot
 	// The RocksDB snapshot that will be streamed from.
 	EngineSnap engine.Reader
+	// The snapshot of the engine holding the Raft log, from which the log
+	// entries are streamed. This is EngineSnap unless the store keeps its Raft
+	// log in a dedicated engine.
+	RaftEngineSnap engine.Reader
 	// The complete range iterator for the snapshot to stream.
 	Iter *rditer.ReplicaDataIterator
 	// The replica state within the snapshot.
@@ -473,6 +488,9 @@ func (s *OutgoingSnapshot) String() string {
 func (s *OutgoingSnapshot) Close() {
 	s.Iter.Close()
 	s.EngineSnap.Close()
+	if s.RaftEngineSnap != s.EngineSnap {
+		s.RaftEngineSnap.Close()
+	}
 	if s.onClose != nil {
 		s.onClose()
 	}
@@ -507,6 +525,7 @@ func snapshot(
 	rsl stateloader.StateLoader,
 	snapType string,
 	snap engine.Reader,
+	raftSnap engine.Reader,
 	rangeID roachpb.RangeID,
 	eCache *raftentry.Cache,
 	withSideloaded func(func(SideloadStorage) error) error,
@@ -542,7 +561,7 @@ func snapshot(
 		cs.Nodes = append(cs.Nodes, uint64(rep.ReplicaID))
 	}
 
-	term, err := term(ctx, rsl, snap, rangeID, eCache, appliedIndex)
+	term, err := term(ctx, rsl, snap, raftSnap, rangeID, eCache, appliedIndex)
 	if err != nil {
 		return OutgoingSnapshot{}, errors.Errorf("failed to fetch term of %d: %s", appliedIndex, err)
 	}
@@ -560,6 +579,7 @@ func snapshot(
 		RaftEntryCache: eCache,
 		WithSideloaded: withSideloaded,
 		EngineSnap:     snap,
+		RaftEngineSnap: raftSnap,
 		Iter:           iter,
 		State:          state,
 		SnapUUID:       snapUUID,
@@ -828,6 +848,23 @@ func (r *Replica) applySnapshot(
 	batch := r.store.Engine().NewWriteOnlyBatch()
 	defer batch.Close()
 
+	// If the store keeps its Raft log in a dedicated engine, the snapshot's
+	// log entries are written to it in a separate batch, which is committed
+	// before the rest of the snapshot. The log above the snapshot's truncated
+	// state is replaced by committed entries, so the log remains valid for
+	// the replica's current state should the second commit not happen; the
+	// log below is removed once the snapshot has been committed.
+	raftBatch := batch
+	if r.store.hasDedicatedRaftEngine() {
+		raftBatch = r.store.RaftEngine().NewWriteOnlyBatch()
+		defer raftBatch.Close()
+		if err := clearRaftLogEntries(
+			r.store.RaftEngine(), raftBatch, r.RangeID, s.TruncatedState.Index+1, math.MaxUint64,
+		); err != nil {
+			return err
+		}
+	}
+
 	// If we're subsuming a replica below, we don't have its last NextReplicaID,
 	// nor can we obtain it. That's OK: we can just be conservative and use the
 	// maximum possible replica ID. preDestroyRaftMuLocked will write a replica
@@ -906,9 +943,13 @@ func (r *Replica) applySnapshot(
 	}
 
 	// Write the snapshot's Raft log into the range.
+	raftLogWriter := engine.ReadWriter(distinctBatch)
+	if raftBatch != batch {
+		raftLogWriter = raftBatch
+	}
 	var lastTerm uint64
 	_, lastTerm, raftLogSize, err = r.append(
-		ctx, distinctBatch, 0, invalidLastTerm, raftLogSize, thinEntries,
+		ctx, raftLogWriter, 0, invalidLastTerm, raftLogSize, thinEntries,
 	)
 	if err != nil {
 		return err
@@ -934,7 +975,13 @@ func (r *Replica) applySnapshot(
 	}
 
 	// We've written Raft log entries, so we need to sync the WAL.
-	if err := batch.Commit(!disableSyncRaftLog.Get(&r.store.cfg.Settings.SV)); err != nil {
+	syncRaftLog := !disableSyncRaftLog.Get(&r.store.cfg.Settings.SV)
+	if raftBatch != batch {
+		if err := raftBatch.Commit(syncRaftLog); err != nil {
+			return err
+		}
+	}
+	if err := batch.Commit(syncRaftLog); err != nil {
 		return err
 	}
 	stats.commit = timeutil.Now()
@@ -943,6 +990,10 @@ func (r *Replica) applySnapshot(
 	// has not yet been updated. Any errors past this point must therefore be
 	// treated as fatal.
 
+	if err := r.store.truncateDedicatedRaftLog(r.RangeID, s.TruncatedState.Index+1); err != nil {
+		log.Fatalf(ctx, "unable to truncate raft log while applying snapshot: %s", err)
+	}
+
 	for _, sr := range subsumedRepls {
 		// We removed sr's data when we committed the batch. Finish subsumption by
 		// updating the in-memory bookkeping.
@@ -1000,6 +1051,7 @@ func (r *Replica) applySnapshot(
 	// by r.leasePostApply, but we called those above, so now it's safe to
 	// wholesale replace r.mu.state.
 	r.mu.state = s
+	r.raftClosed.forward(r.raftClosedTimestampRLocked())
 	// Snapshots typically have fewer log entries than the leaseholder. The next
 	// time we hold the lease, recompute the log size before making decisions.
 	r.mu.raftLogSizeTrusted = false
@@ -120,6 +120,10 @@ func (r *Replica) executeWriteBatch(
 
 	minTS, untrack := r.store.cfg.ClosedTimestamp.Tracker.Track(ctx)
 	defer untrack(ctx, 0, 0, 0) // covers all error returns below
+	// Ranges with global reads additionally close timestamps in their Raft
+	// commands, which the command must be proposed above.
+	minTS, release := r.trackRaftClosed(minTS)
+	defer release() // covers all error returns below
 
 	// Examine the read and write timestamp caches for preceding
 	// commands which require this command to move its timestamp
@@ -165,6 +169,10 @@ func (r *Replica) executeWriteBatch(
 	if maxLeaseIndex != 0 {
 		untrack(ctx, ctpb.Epoch(lease.Epoch), r.RangeID, ctpb.LAI(maxLeaseIndex))
 	}
+	// Closed timestamps are attached to proposals with a higher lease index
+	// from now on, so they may exceed the command's timestamp. Should the
+	// command be reproposed, it is rejected if its timestamp has been closed.
+	release()
 
 	// After the command is proposed to Raft, invoking endCmds.done is now the
 	// responsibility of processRaftCommand.
@@ -200,6 +208,14 @@ func (r *Replica) executeWriteBatch(
 					log.Warning(ctx, err)
 				}
 			}
+			if propResult.Err == nil && ba.Txn == nil && r.hasGlobalReads() {
+				// Non-transactional writes to ranges with global reads are
+				// performed at future timestamps. They are acknowledged only
+				// once their timestamp has passed, so that causally dependent
+				// reads observe them. Transactional writes wait in the
+				// TxnCoordSender after committing.
+				r.store.commitWait(ctx, propResult.Reply.Timestamp)
+			}
 			return propResult.Reply, propResult.Err
 		case <-slowTimer.C:
 			slowTimer.Read = true
@@ -21,6 +21,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/storage/spanset"
 	"github.com/cockroachdb/cockroach/pkg/storage/storagepb"
+	"github.com/cockroachdb/cockroach/pkg/util/admission"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/metric"
@@ -332,12 +333,15 @@ func ifGlobal(ts hlc.Timestamp, s spanset.SpanScope) hlc.Timestamp {
 }
 
 // wait waits for all interfering latches in the provided snapshot to complete
-// before returning.
+// before returning. The CPU slot of the waiting request, if any, is released
+// while it waits, since the requests holding the latches may themselves be
+// waiting for a slot, and it is reacquired once all latches are released.
 func (m *Manager) wait(ctx context.Context, lg *Guard, snap snapshot) error {
 	timer := timeutil.NewTimer()
 	timer.Reset(base.SlowRequestThreshold)
 	defer timer.Stop()
 
+	var reacquire func() error
 	for s := spanset.SpanScope(0); s < spanset.NumSpanScope; s++ {
 		tr := &snap.trees[s]
 		for a := spanset.SpanAccess(0); a < spanset.NumSpanAccess; a++ {
@@ -348,7 +352,7 @@ func (m *Manager) wait(ctx context.Context, lg *Guard, snap snapshot) error {
 				case spanset.SpanReadOnly:
 					// Wait for writes at equal or lower timestamps.
 					it := tr[spanset.SpanReadWrite].MakeIter()
-					if err := m.iterAndWait(ctx, timer, &it, latch, ignoreLater); err != nil {
+					if err := m.iterAndWait(ctx, timer, &it, latch, ignoreLater, &reacquire); err != nil {
 						return err
 					}
 				case spanset.SpanReadWrite:
@@ -359,12 +363,12 @@ func (m *Manager) wait(ctx context.Context, lg *Guard, snap snapshot) error {
 					// latches first. We expect writes to take longer than reads
 					// to release their latches, so we wait on them first.
 					it := tr[spanset.SpanReadWrite].MakeIter()
-					if err := m.iterAndWait(ctx, timer, &it, latch, ignoreNothing); err != nil {
+					if err := m.iterAndWait(ctx, timer, &it, latch, ignoreNothing, &reacquire); err != nil {
 						return err
 					}
 					// Wait for reads at equal or higher timestamps.
 					it = tr[spanset.SpanReadOnly].MakeIter()
-					if err := m.iterAndWait(ctx, timer, &it, latch, ignoreEarlier); err != nil {
+					if err := m.iterAndWait(ctx, timer, &it, latch, ignoreEarlier, &reacquire); err != nil {
 						return err
 					}
 				default:
@@ -373,14 +377,24 @@ func (m *Manager) wait(ctx context.Context, lg *Guard, snap snapshot) error {
 			}
 		}
 	}
+	if reacquire != nil {
+		return reacquire()
+	}
 	return nil
 }
 
 // iterAndWait uses the provided iterator to wait on all latches that overlap
 // with the search latch and which should not be ignored given their timestamp
-// and the supplied ignoreFn.
+// and the supplied ignoreFn. Before first blocking, the CPU slot of the
+// waiting request is released and reacquire is set to the function
+// reacquiring it.
 func (m *Manager) iterAndWait(
-	ctx context.Context, t *timeutil.Timer, it *iterator, wait *latch, ignore ignoreFn,
+	ctx context.Context,
+	t *timeutil.Timer,
+	it *iterator,
+	wait *latch,
+	ignore ignoreFn,
+	reacquire *func() error,
 ) error {
 	for it.FirstOverlap(wait); it.Valid(); it.NextOverlap() {
 		held := it.Cur()
@@ -390,6 +404,9 @@ func (m *Manager) iterAndWait(
 		if ignore(wait.ts, held.ts) {
 			continue
 		}
+		if *reacquire == nil {
+			*reacquire = admission.ReleaseSlot(ctx)
+		}
 		if err := m.waitForSignal(ctx, t, wait, held); err != nil {
 			return err
 		}
@@ -89,6 +89,11 @@ func (rsl StateLoader) Load(
 
 		ms := as.RangeStats.ToStats()
 		s.Stats = &ms
+
+		if as.RaftClosedTimestamp != nil && *as.RaftClosedTimestamp != (hlc.Timestamp{}) {
+			ts := *as.RaftClosedTimestamp
+			s.RaftClosedTimestamp = &ts
+		}
 	} else {
 		if s.RaftAppliedIndex, s.LeaseAppliedIndex, err = rsl.LoadAppliedIndex(ctx, reader); err != nil {
 			return storagepb.ReplicaState{}, err
@@ -161,7 +166,11 @@ func (rsl StateLoader) Save(
 	}
 	if state.UsingAppliedStateKey {
 		rai, lai := state.RaftAppliedIndex, state.LeaseAppliedIndex
-		if err := rsl.SetRangeAppliedState(ctx, eng, rai, lai, ms); err != nil {
+		var closedTS hlc.Timestamp
+		if state.RaftClosedTimestamp != nil {
+			closedTS = *state.RaftClosedTimestamp
+		}
+		if err := rsl.SetRangeAppliedState(ctx, eng, rai, lai, ms, closedTS); err != nil {
 			return enginepb.MVCCStats{}, err
 		}
 	} else {
@@ -280,7 +289,9 @@ func (rsl StateLoader) LoadMVCCStats(
 }
 
 // SetRangeAppliedState overwrites the range applied state. This state is a
-// combination of the Raft and lease applied indices, along with the MVCC stats.
+// combination of the Raft and lease applied indices, along with the MVCC stats
+// and the highest closed timestamp carried by an applied Raft command (which is
+// omitted if zero).
 //
 // The applied indices and the stats used to be stored separately in different
 // keys. We now deem those keys to be "legacy" because they have been replaced
@@ -290,12 +301,16 @@ func (rsl StateLoader) SetRangeAppliedState(
 	eng engine.ReadWriter,
 	appliedIndex, leaseAppliedIndex uint64,
 	newMS *enginepb.MVCCStats,
+	raftClosedTimestamp hlc.Timestamp,
 ) error {
 	as := enginepb.RangeAppliedState{
 		RaftAppliedIndex:  appliedIndex,
 		LeaseAppliedIndex: leaseAppliedIndex,
 		RangeStats:        newMS.ToPersistentStats(),
 	}
+	if raftClosedTimestamp != (hlc.Timestamp{}) {
+		as.RaftClosedTimestamp = &raftClosedTimestamp
+	}
 	// The RangeAppliedStateKey is not included in stats. This is also reflected
 	// in C.MVCCComputeStats and ComputeStatsGo.
 	ms := (*enginepb.MVCCStats)(nil)
@@ -428,7 +443,13 @@ func (rsl StateLoader) SetMVCCStats(
 	if as, err := rsl.LoadRangeAppliedState(ctx, eng); err != nil {
 		return err
 	} else if as != nil {
-		return rsl.SetRangeAppliedState(ctx, eng, as.RaftAppliedIndex, as.LeaseAppliedIndex, newMS)
+		var closedTS hlc.Timestamp
+		if as.RaftClosedTimestamp != nil {
+			closedTS = *as.RaftClosedTimestamp
+		}
+		return rsl.SetRangeAppliedState(
+			ctx, eng, as.RaftAppliedIndex, as.LeaseAppliedIndex, newMS, closedTS,
+		)
 	}
 
 	return rsl.writeLegacyMVCCStatsInternal(ctx, eng, newMS)
@@ -493,10 +514,14 @@ func (rsl StateLoader) SetTxnSpanGCThreshold(
 
 // The rest is not technically part of ReplicaState.
 
-// LoadLastIndex loads the last index.
-func (rsl StateLoader) LoadLastIndex(ctx context.Context, reader engine.Reader) (uint64, error) {
+// LoadLastIndex loads the last index. The Raft log is read from raftReader
+// and the truncated state from reader, which are the same unless the Raft log
+// is kept in a dedicated engine.
+func (rsl StateLoader) LoadLastIndex(
+	ctx context.Context, reader, raftReader engine.Reader,
+) (uint64, error) {
 	prefix := rsl.RaftLogPrefix()
-	iter := reader.NewIterator(engine.IterOptions{LowerBound: prefix})
+	iter := raftReader.NewIterator(engine.IterOptions{LowerBound: prefix})
 	defer iter.Close()
 
 	var lastIndex uint64
@@ -510,13 +535,14 @@ func (rsl StateLoader) LoadLastIndex(ctx context.Context, reader engine.Reader)
 		}
 	}
 
-	if lastIndex == 0 {
-		// The log is empty, which means we are either starting from scratch
-		// or the entire log has been truncated away.
-		lastEnt, _, err := rsl.LoadRaftTruncatedState(ctx, reader)
-		if err != nil {
-			return 0, err
-		}
+	// If the log is empty, we are either starting from scratch or the entire
+	// log has been truncated away. A dedicated Raft engine may also briefly
+	// hold entries which precede the truncated state.
+	lastEnt, _, err := rsl.LoadRaftTruncatedState(ctx, reader)
+	if err != nil {
+		return 0, err
+	}
+	if lastEnt.Index > lastIndex {
 		lastIndex = lastEnt.Index
 	}
 	return lastIndex, nil
@@ -50,6 +50,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/storage/tscache"
 	"github.com/cockroachdb/cockroach/pkg/storage/txnrecovery"
 	"github.com/cockroachdb/cockroach/pkg/storage/txnwait"
+	"github.com/cockroachdb/cockroach/pkg/util/admission"
 	"github.com/cockroachdb/cockroach/pkg/util/contextutil"
 	"github.com/cockroachdb/cockroach/pkg/util/envutil"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
@@ -388,6 +389,7 @@ type Store struct {
 	cfg                StoreConfig
 	db                 *client.DB
 	engine             engine.Engine        // The underlying key-value store
+	raftEngine         engine.Engine        // The key-value store holding the Raft log
 	compactor          *compactor.Compactor // Schedules compaction of the engine
 	tsCache            tscache.Cache        // Most recent timestamps for keys / key ranges
 	allocator          Allocator            // Makes allocation decisions
@@ -410,6 +412,9 @@ type Store struct {
 	raftEntryCache     *raftentry.Cache
 	limiters           batcheval.Limiters
 	txnWaitMetrics     *txnwait.Metrics
+	// ioAdmissionQueue admits writes to the store. Nil if admission control
+	// is not configured.
+	ioAdmissionQueue *admission.WorkQueue
 
 	// gossipRangeCountdown and leaseRangeCountdown are countdowns of
 	// changes to range and leaseholder counts, after which the store
@@ -455,6 +460,16 @@ type Store struct {
 	// has likely improved).
 	draining atomic.Value
 
+	// diskStalled holds a bool which indicates whether the store's disk health
+	// monitor has detected a disk stall. See setDiskStalled().
+	diskStalled atomic.Value
+	// diskStallMu serializes the handling of disk stall transitions.
+	diskStallMu struct {
+		syncutil.Mutex
+		// drained is set if the store was drained because of a disk stall.
+		drained bool
+	}
+
 	// Locking notes: To avoid deadlocks, the following lock order must be
 	// obeyed: baseQueue.mu < Replica.raftMu < Replica.readOnlyCmdMu < Store.mu
 	// < Replica.mu < Replica.unreachablesMu < Store.coalescedMu < Store.scheduler.mu.
@@ -634,6 +649,10 @@ type StoreConfig struct {
 
 	ClosedTimestamp *container.Container
 
+	// AdmissionController, if set, subjects requests sent to the store to
+	// admission control.
+	AdmissionController *admission.GrantCoordinator
+
 	// SQLExecutor is used by the store to execute SQL statements.
 	SQLExecutor sqlutil.InternalExecutor
 
@@ -711,6 +730,12 @@ type StoreConfig struct {
 	// gossiped store capacity values which need be exceeded before the store will
 	// gossip immediately without waiting for the periodic gossip interval.
 	GossipWhenCapacityDeltaExceedsFraction float64
+
+	// RaftEngine, if set, is a dedicated engine in which the store keeps its
+	// Raft log, separate from the engine holding the replicated state machine.
+	// If nil, the Raft log is kept in the store's engine. Unlike the rest of
+	// the StoreConfig, this is specific to a single store.
+	RaftEngine engine.Engine
 }
 
 // ConsistencyTestingKnobs is a BatchEvalTestingKnobs struct used to control the
@@ -793,6 +818,10 @@ func NewStore(
 		nodeDesc: nodeDesc,
 		metrics:  newStoreMetrics(cfg.HistogramWindowInterval),
 	}
+	s.raftEngine = cfg.RaftEngine
+	if s.raftEngine == nil {
+		s.raftEngine = eng
+	}
 	if cfg.RPCContext != nil {
 		s.allocator = MakeAllocator(cfg.StorePool, cfg.RPCContext.RemoteClocks.Latency)
 	} else {
@@ -801,8 +830,12 @@ func NewStore(
 		})
 	}
 	s.replRankings = newReplicaRankings()
+	if cfg.AdmissionController != nil {
+		s.ioAdmissionQueue = cfg.AdmissionController.NewStoreWorkQueue(s.l0FileCount)
+	}
 
 	s.draining.Store(false)
+	s.diskStalled.Store(false)
 	s.scheduler = newRaftScheduler(s.metrics, s, storeSchedulerConcurrency)
 
 	s.raftEntryCache = raftentry.NewCache(cfg.RaftEntryCacheSize)
@@ -1277,6 +1310,10 @@ func (s *Store) Start(ctx context.Context, stopper *stop.Stopper) error {
 	ctx = s.AnnotateCtx(ctx)
 	log.Event(ctx, "read store identity")
 
+	if err := s.initRaftEngine(ctx); err != nil {
+		return errors.Wrap(err, "initializing raft engine")
+	}
+
 	// Add the store ID to the scanner's AmbientContext before starting it, since
 	// the AmbientContext provided during construction did not include it.
 	// Note that this is just a hacky way of getting around that without
@@ -1381,6 +1418,10 @@ func (s *Store) Start(ctx context.Context, stopper *stop.Stopper) error {
 		return err
 	}
 
+	if err := s.removeOrphanedRaftLogs(ctx); err != nil {
+		return errors.Wrap(err, "removing orphaned raft logs")
+	}
+
 	// Start Raft processing goroutines.
 	s.cfg.Transport.Listen(s.StoreID(), s)
 	s.processRaft(ctx)
@@ -1442,6 +1483,11 @@ func (s *Store) Start(ctx context.Context, stopper *stop.Stopper) error {
 		s.storeRebalancer.Start(ctx, s.stopper)
 	}
 
+	// Start monitoring the health of the store's disk.
+	newDiskHealthMonitor(
+		s.cfg.AmbientCtx, s.engine, s.cfg.Settings, s.metrics, s.setDiskStalled,
+	).Start(ctx, s.stopper)
+
 	// Start the storage engine compactor.
 	if envutil.EnvOrDefaultBool("COCKROACH_ENABLE_COMPACTOR", true) {
 		s.compactor.Start(s.AnnotateCtx(context.Background()), s.stopper)
@@ -2042,6 +2088,10 @@ func (s *Store) Clock() *hlc.Clock { return s.cfg.Clock }
 // Engine accessor.
 func (s *Store) Engine() engine.Engine { return s.engine }
 
+// RaftEngine accessor. This is the same as Engine() unless the store keeps
+// its Raft log in a dedicated engine.
+func (s *Store) RaftEngine() engine.Engine { return s.raftEngine }
+
 // DB accessor.
 func (s *Store) DB() *client.DB { return s.cfg.DB }
 
@@ -2089,8 +2139,10 @@ func (s *Store) NewRangeDescriptor(
 
 // splitPreApply is called when the raft command is applied. Any
 // changes to the given ReadWriter will be written atomically with the
-// split commit.
-func splitPreApply(ctx context.Context, eng engine.ReadWriter, split roachpb.SplitTrigger) {
+// split commit. The right-hand side inherits the given closed timestamp.
+func splitPreApply(
+	ctx context.Context, eng engine.ReadWriter, split roachpb.SplitTrigger, closedTS hlc.Timestamp,
+) {
 	// Update the raft HardState with the new Commit value now that the
 	// replica is initialized (combining it with existing or default
 	// Term and Vote).
@@ -2098,6 +2150,24 @@ func splitPreApply(ctx context.Context, eng engine.ReadWriter, split roachpb.Spl
 	if err := rsl.SynthesizeRaftState(ctx, eng); err != nil {
 		log.Fatal(ctx, err)
 	}
+	if closedTS == (hlc.Timestamp{}) {
+		return
+	}
+	as, err := rsl.LoadRangeAppliedState(ctx, eng)
+	if err != nil {
+		log.Fatal(ctx, err)
+	}
+	if as == nil {
+		// The right-hand side doesn't use the RangeAppliedState key, so it
+		// can't carry a closed timestamp.
+		return
+	}
+	ms := as.RangeStats.ToStats()
+	if err := rsl.SetRangeAppliedState(
+		ctx, eng, as.RaftAppliedIndex, as.LeaseAppliedIndex, &ms, closedTS,
+	); err != nil {
+		log.Fatal(ctx, err)
+	}
 }
 
 // splitPostApply is the part of the split trigger which coordinates the actual
@@ -2146,6 +2216,7 @@ func splitPostApply(
 	rightLease := *rightRng.mu.state.Lease
 	rightRng.mu.Unlock()
 	r.mu.Unlock()
+	rightRng.raftClosed.forward(r.raftClosed.get())
 
 	// We need to explicitly wake up the Raft group on the right-hand range or
 	// else the range could be underreplicated for an indefinite period of time.
@@ -2747,10 +2818,11 @@ func (s *Store) Descriptor(useCached bool) (*roachpb.StoreDescriptor, error) {
 
 	// Initialize the store descriptor.
 	return &roachpb.StoreDescriptor{
-		StoreID:  s.Ident.StoreID,
-		Attrs:    s.Attrs(),
-		Node:     *s.nodeDesc,
-		Capacity: capacity,
+		StoreID:     s.Ident.StoreID,
+		Attrs:       s.Attrs(),
+		Node:        *s.nodeDesc,
+		Capacity:    capacity,
+		DiskStalled: s.IsDiskStalled(),
 	}, nil
 }
 
@@ -2786,6 +2858,12 @@ func (s *Store) Send(
 		}
 	}
 
+	ctx, admissionDone, err := s.admitBatch(ctx, &ba)
+	if err != nil {
+		return nil, roachpb.NewError(err)
+	}
+	defer admissionDone()
+
 	// Limit the number of concurrent AddSSTable requests, since they're expensive
 	// and block all other writes to the same span.
 	if ba.IsSingleAddSSTableRequest() {
@@ -2842,6 +2920,11 @@ func (s *Store) Send(
 	var now hlc.Timestamp
 	if s.cfg.TestingKnobs.DisableMaxOffsetCheck {
 		now = s.cfg.Clock.Update(ba.Timestamp)
+	} else if s.isFutureTimestamp(ba.Timestamp) && s.withinGlobalReadsLead(ba.Timestamp) {
+		// Transactions which wrote to ranges with global reads carry future
+		// timestamps, which don't indicate a bad clock but mustn't advance
+		// ours either. See updateClock.
+		now = s.cfg.Clock.Now()
 	} else {
 		// If the command appears to come from a node with a bad clock,
 		// reject it now before we reach that point.
@@ -2874,7 +2957,7 @@ func (s *Store) Send(
 				// Update our clock with the outgoing response txn timestamp
 				// (if timestamp has been forwarded).
 				if ba.Timestamp.Less(br.Txn.Timestamp) {
-					s.cfg.Clock.Update(br.Txn.Timestamp)
+					s.updateClock(br.Txn.Timestamp)
 				}
 			}
 		} else {
@@ -2882,7 +2965,7 @@ func (s *Store) Send(
 				// Update our clock with the outgoing response timestamp.
 				// (if timestamp has been forwarded).
 				if ba.Timestamp.Less(br.Timestamp) {
-					s.cfg.Clock.Update(br.Timestamp)
+					s.updateClock(br.Timestamp)
 				}
 			}
 		}
@@ -3066,8 +3149,15 @@ func (s *Store) Send(
 				if cleanupAfterWriteIntentError != nil {
 					cleanupAfterWriteIntentError(t, nil)
 				}
-				if cleanupAfterWriteIntentError, pErr =
-					s.intentResolver.ProcessWriteIntentError(ctx, pErr, args, h, pushType); pErr != nil {
+				// Release the batch's CPU slot while it waits for the conflicting
+				// transaction, which may itself be waiting for a slot.
+				reacquire := admission.ReleaseSlot(ctx)
+				cleanupAfterWriteIntentError, pErr =
+					s.intentResolver.ProcessWriteIntentError(ctx, pErr, args, h, pushType)
+				if err := reacquire(); err != nil {
+					return nil, roachpb.NewError(err)
+				}
+				if pErr != nil {
 					// Do not propagate ambiguous results; assume success and retry original op.
 					if _, ok := pErr.GetDetail().(*roachpb.AmbiguousResultError); !ok {
 						// Preserve the error index.
@@ -3086,6 +3176,7 @@ func (s *Store) Send(
 			// its mergeComplete channel will be nil.
 			mergeCompleteCh := repl.getMergeCompleteCh()
 			if mergeCompleteCh != nil {
+				reacquire := admission.ReleaseSlot(ctx)
 				select {
 				case <-mergeCompleteCh:
 					// Merge complete. Retry the command.
@@ -3094,6 +3185,9 @@ func (s *Store) Send(
 				case <-s.stopper.ShouldQuiesce():
 					return nil, roachpb.NewError(&roachpb.NodeUnavailableError{})
 				}
+				if err := reacquire(); err != nil {
+					return nil, roachpb.NewError(err)
+				}
 			}
 			pErr = nil
 		}
@@ -3141,7 +3235,11 @@ func (s *Store) maybeWaitForPushee(
 	// txn response or else allow this request to proceed.
 	if ba.IsSinglePushTxnRequest() {
 		pushReq := ba.Requests[0].GetInner().(*roachpb.PushTxnRequest)
+		reacquire := admission.ReleaseSlot(ctx)
 		pushResp, pErr := repl.txnWaitQueue.MaybeWaitForPush(repl.AnnotateCtx(ctx), repl, pushReq)
+		if err := reacquire(); err != nil {
+			return nil, roachpb.NewError(err)
+		}
 		// Copy the request in anticipation of setting the force arg and
 		// updating the Now timestamp (see below).
 		pushReqCopy := *pushReq
@@ -3170,7 +3268,11 @@ func (s *Store) maybeWaitForPushee(
 		// For query txn requests, wait in the txn wait queue either for
 		// transaction update or for dependent transactions to change.
 		queryReq := ba.Requests[0].GetInner().(*roachpb.QueryTxnRequest)
+		reacquire := admission.ReleaseSlot(ctx)
 		pErr := repl.txnWaitQueue.MaybeWaitForQuery(repl.AnnotateCtx(ctx), repl, queryReq)
+		if err := reacquire(); err != nil {
+			return nil, roachpb.NewError(err)
+		}
 		if pErr != nil {
 			return nil, pErr
 		}
@@ -0,0 +1,115 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package storage
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/util/admission"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+// admitBatch subjects a batch to admission control, blocking until it is
+// admitted or the context is canceled. Writes are first admitted by the
+// store's IO queue, and all batches are then admitted to the node's CPU
+// slots. On success, the returned context carries the batch's slot, which is
+// released while the batch waits for latches, locks or other transactions
+// (see admission.ReleaseSlot), and the returned function must be called once
+// the batch has been evaluated.
+func (s *Store) admitBatch(
+	ctx context.Context, ba *roachpb.BatchRequest,
+) (context.Context, func(), error) {
+	ac := s.cfg.AdmissionController
+	if ac == nil || !admission.KVAdmissionControlEnabled.Get(&s.cfg.Settings.SV) {
+		// Hide the slot of any batch this one is sent on behalf of, which is
+		// not this batch's to release.
+		return admission.ContextWithAdmittedWork(ctx, nil), func() {}, nil
+	}
+	info := admissionWorkInfo(ba)
+	if !ba.IsReadOnly() && s.ioAdmissionQueue != nil {
+		if err := s.ioAdmissionQueue.Admit(ctx, info); err != nil {
+			return nil, nil, err
+		}
+	}
+	w, err := ac.CPUWorkQueue().AdmitWork(ctx, info)
+	if err != nil {
+		return nil, nil, err
+	}
+	return admission.ContextWithAdmittedWork(ctx, w), w.Done, nil
+}
+
+// l0FileCount returns the number of files in level 0 of the store's engine,
+// which is used to detect that writes to the store outpace compactions.
+func (s *Store) l0FileCount() int64 {
+	stats, err := s.engine.GetStats()
+	if err != nil {
+		log.Warningf(s.AnnotateCtx(context.TODO()), "unable to read engine stats: %s", err)
+		return 0
+	}
+	return stats.L0FileCount
+}
+
+// admissionWorkInfo returns the priority with which a batch is admitted.
+// Batches of high (low) priority transactions or with a high (low) user
+// priority are admitted with high (low) priority, and bulk operations are
+// always admitted with low priority.
+//
+// Batches which already admitted work may be waiting on, such as those
+// operating on system ranges, resolving conflicts between transactions or
+// performing admin commands (splits, merges, replication changes), bypass
+// admission: holding CPU slots while waiting on them could otherwise deadlock
+// the node.
+func admissionWorkInfo(ba *roachpb.BatchRequest) admission.WorkInfo {
+	info := admission.WorkInfo{Priority: admission.NormalPri}
+	if ba.Txn != nil {
+		info.CreateTime = ba.Txn.OrigTimestamp.WallTime
+		switch ba.Txn.Priority {
+		case enginepb.MaxTxnPriority:
+			info.Priority = admission.HighPri
+		case enginepb.MinTxnPriority:
+			info.Priority = admission.LowPri
+		}
+	} else {
+		info.CreateTime = timeutil.Now().UnixNano()
+		if p := ba.UserPriority; p >= roachpb.MaxUserPriority {
+			info.Priority = admission.HighPri
+		} else if p > 0 && p <= roachpb.MinUserPriority {
+			info.Priority = admission.LowPri
+		}
+	}
+
+	if ba.IsAdmin() {
+		info.BypassAdmission = true
+	}
+	for _, union := range ba.Requests {
+		switch union.GetInner().(type) {
+		case *roachpb.AddSSTableRequest, *roachpb.ExportRequest, *roachpb.ImportRequest:
+			info.Priority = admission.LowPri
+		case *roachpb.PushTxnRequest, *roachpb.QueryTxnRequest, *roachpb.RecoverTxnRequest,
+			*roachpb.HeartbeatTxnRequest, *roachpb.EndTransactionRequest,
+			*roachpb.ResolveIntentRequest, *roachpb.ResolveIntentRangeRequest,
+			*roachpb.QueryIntentRequest, *roachpb.RequestLeaseRequest,
+			*roachpb.TransferLeaseRequest, *roachpb.LeaseInfoRequest,
+			*roachpb.SubsumeRequest, *roachpb.GCRequest, *roachpb.ComputeChecksumRequest:
+			info.BypassAdmission = true
+		}
+	}
+	if rs, err := keys.Range(*ba); err == nil && rs.Key.Less(roachpb.RKey(keys.UserTableDataMin)) {
+		info.BypassAdmission = true
+	}
+	return info
+}
@@ -0,0 +1,170 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/admission"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/pkg/errors"
+)
+
+func TestAdmissionWorkInfo(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	userKey := roachpb.Key(keys.MakeTablePrefix(keys.MinUserDescID))
+	systemKey := roachpb.Key(keys.MakeTablePrefix(keys.DescriptorTableID))
+	header := roachpb.RequestHeader{Key: userKey}
+	txn := func(priority enginepb.TxnPriority) *roachpb.Transaction {
+		txn := roachpb.MakeTransaction("test", userKey, 0, hlc.Timestamp{WallTime: 1}, 0)
+		txn.Priority = priority
+		return &txn
+	}
+
+	testCases := []struct {
+		name     string
+		txn      *roachpb.Transaction
+		req      roachpb.Request
+		priority admission.WorkPriority
+		bypass   bool
+	}{
+		{name: "get", req: &roachpb.GetRequest{RequestHeader: header}, priority: admission.NormalPri},
+		{
+			name:     "high priority txn",
+			txn:      txn(enginepb.MaxTxnPriority),
+			req:      &roachpb.PutRequest{RequestHeader: header},
+			priority: admission.HighPri,
+		},
+		{
+			name:     "low priority txn",
+			txn:      txn(enginepb.MinTxnPriority),
+			req:      &roachpb.PutRequest{RequestHeader: header},
+			priority: admission.LowPri,
+		},
+		{name: "bulk", req: &roachpb.AddSSTableRequest{RequestHeader: header}, priority: admission.LowPri},
+		{
+			name:     "intent resolution",
+			req:      &roachpb.ResolveIntentRequest{RequestHeader: header},
+			priority: admission.NormalPri,
+			bypass:   true,
+		},
+		{
+			name:     "admin",
+			req:      &roachpb.AdminSplitRequest{RequestHeader: header, SplitKey: userKey},
+			priority: admission.NormalPri,
+			bypass:   true,
+		},
+		{
+			name:     "system range",
+			req:      &roachpb.GetRequest{RequestHeader: roachpb.RequestHeader{Key: systemKey}},
+			priority: admission.NormalPri,
+			bypass:   true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var ba roachpb.BatchRequest
+			ba.Txn = tc.txn
+			ba.Add(tc.req)
+			info := admissionWorkInfo(&ba)
+			if info.Priority != tc.priority {
+				t.Errorf("expected priority %s, found %s", tc.priority, info.Priority)
+			}
+			if info.BypassAdmission != tc.bypass {
+				t.Errorf("expected bypass %t, found %t", tc.bypass, info.BypassAdmission)
+			}
+		})
+	}
+}
+
+// TestStoreSendAdmission verifies that batches sent to a store wait for a CPU
+// slot, unless they bypass admission.
+func TestStoreSendAdmission(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	stopper := stop.NewStopper()
+	defer stopper.Stop(ctx)
+
+	// The coordinator isn't started, so that the number of slots doesn't change.
+	cfg := TestStoreConfig(nil)
+	coord := admission.NewGrantCoordinator(cfg.AmbientCtx, cfg.Settings, time.Minute)
+	cfg.AdmissionController = coord
+	store := createTestStoreWithConfig(t, stopper, testStoreOpts{createSystemRanges: true}, &cfg)
+	metrics := coord.Metrics()
+	q := coord.CPUWorkQueue()
+
+	userKey := roachpb.Key(keys.MakeTablePrefix(keys.MinUserDescID))
+	pArgs := putArgs(userKey, []byte("value"))
+	if _, pErr := client.SendWrapped(ctx, store.TestSender(), &pArgs); pErr != nil {
+		t.Fatal(pErr)
+	}
+
+	// Use up all slots.
+	totalSlots := int(metrics.TotalSlots.Value())
+	for i := 0; i < totalSlots; i++ {
+		if err := q.Admit(ctx, admission.WorkInfo{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Batches which bypass admission are served.
+	gArgs := getArgs(keys.SystemConfigSpan.Key)
+	if _, pErr := client.SendWrapped(ctx, store.TestSender(), &gArgs); pErr != nil {
+		t.Fatal(pErr)
+	}
+
+	// Other batches wait for a slot.
+	errCh := make(chan error, 1)
+	go func() {
+		gArgs := getArgs(userKey)
+		_, pErr := client.SendWrapped(ctx, store.TestSender(), &gArgs)
+		errCh <- pErr.GoError()
+	}()
+	testutils.SucceedsSoon(t, func() error {
+		if n := metrics.CPUQueue.WaitQueueLength.Value(); n != 1 {
+			return errors.Errorf("expected 1 batch waiting for admission, found %d", n)
+		}
+		return nil
+	})
+	select {
+	case err := <-errCh:
+		t.Fatalf("batch served without a slot: %v", err)
+	default:
+	}
+	q.AdmittedWorkDone()
+	if err := <-errCh; err != nil {
+		t.Fatal(err)
+	}
+
+	// The slot is released once the batch has been evaluated. Background work
+	// bypassing admission may hold slots briefly.
+	testutils.SucceedsSoon(t, func() error {
+		if used := int(metrics.UsedSlots.Value()); used != totalSlots-1 {
+			return errors.Errorf("expected %d slots to be used, found %d", totalSlots-1, used)
+		}
+		return nil
+	})
+	for i := 0; i < totalSlots-1; i++ {
+		q.AdmittedWorkDone()
+	}
+}
@@ -0,0 +1,315 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/util/envutil"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+// diskStallProbeInterval is the interval at which each store probes its disk.
+var diskStallProbeInterval = settings.RegisterNonNegativeDurationSetting(
+	"server.disk_stall.probe_interval",
+	"the interval at which each store writes and syncs a probe file and its "+
+		"write-ahead log to detect stalled disks",
+	10*time.Second,
+)
+
+// diskStallThreshold is the duration after which an outstanding disk probe
+// causes the store to be marked as stalled.
+var diskStallThreshold = settings.RegisterNonNegativeDurationSetting(
+	"server.disk_stall.threshold",
+	"the duration after which an outstanding write or sync causes a store to be "+
+		"marked as stalled, shedding its leases and rejecting new replicas; a store "+
+		"stalled for server.time_until_store_dead is considered dead (0 to disable)",
+	envutil.EnvOrDefaultDuration("COCKROACH_ENGINE_MAX_SYNC_DURATION", 10*time.Second),
+)
+
+// diskStallFatalThreshold is the duration after which an outstanding disk
+// probe terminates the process. It is disabled by default: a stalled store
+// already sheds its leases, and once it has been stalled for
+// server.time_until_store_dead, the other stores consider it dead and replace
+// its replicas. Terminating the process would also take down the node's other
+// stores and its SQL connections.
+var diskStallFatalThreshold = settings.RegisterNonNegativeDurationSetting(
+	"server.disk_stall.fatal_threshold",
+	"the duration after which an outstanding write or sync terminates the "+
+		"process (0 to disable)",
+	0,
+)
+
+// maxSyncDurationFatalOnExceeded, if set, terminates the process as soon as a
+// stall is detected rather than waiting for server.disk_stall.fatal_threshold.
+var maxSyncDurationFatalOnExceeded = envutil.EnvOrDefaultBool("COCKROACH_ENGINE_MAX_SYNC_DURATION_FATAL", false)
+
+// diskHealthProbeFilename is the name of the file written by disk probes in a
+// store's auxiliary directory.
+const diskHealthProbeFilename = "disk-health-probe"
+
+// diskHealthMonitor detects stalled disks. It periodically writes and syncs a
+// probe file and syncs the write-ahead log of an engine, recording the
+// latencies of both operations. A watchdog observes the operation in
+// progress; if it does not complete within server.disk_stall.threshold the
+// disk is considered stalled, and if it does not complete within
+// server.disk_stall.fatal_threshold (if set) the process is terminated. Because a
+// stalled write may never return, the watchdog runs independently from the
+// probes.
+type diskHealthMonitor struct {
+	log.AmbientContext
+	eng     engine.Engine
+	st      *cluster.Settings
+	metrics *StoreMetrics
+
+	// onStall is invoked from the watchdog or probe goroutine whenever the
+	// disk transitions into or out of the stalled state. It must not block.
+	onStall func(ctx context.Context, stalled bool)
+	// onFatal is invoked when a stall exceeds the fatal threshold. It is
+	// expected to terminate the process.
+	onFatal func(ctx context.Context, msg string)
+
+	mu struct {
+		syncutil.Mutex
+		// opName and opStart describe the outstanding probe operation. opStart
+		// is zero if no operation is outstanding.
+		opName  string
+		opStart time.Time
+		stalled bool
+	}
+}
+
+func newDiskHealthMonitor(
+	ambient log.AmbientContext,
+	eng engine.Engine,
+	st *cluster.Settings,
+	metrics *StoreMetrics,
+	onStall func(ctx context.Context, stalled bool),
+) *diskHealthMonitor {
+	return &diskHealthMonitor{
+		AmbientContext: ambient,
+		eng:            eng,
+		st:             st,
+		metrics:        metrics,
+		onStall:        onStall,
+		onFatal: func(ctx context.Context, msg string) {
+			// NB: log.Shout sets up a timer that guarantees process termination.
+			log.Shout(ctx, log.Severity_FATAL, msg)
+		},
+	}
+}
+
+// Start starts the probe and watchdog goroutines.
+func (m *diskHealthMonitor) Start(ctx context.Context, stopper *stop.Stopper) {
+	ctx = m.AnnotateCtx(ctx)
+	stopper.RunWorker(ctx, func(ctx context.Context) {
+		t := timeutil.NewTimer()
+		defer t.Stop()
+		t.Reset(0)
+		for {
+			select {
+			case <-t.C:
+				t.Read = true
+				m.probe(ctx)
+				t.Reset(diskStallProbeInterval.Get(&m.st.SV))
+			case <-stopper.ShouldQuiesce():
+				return
+			}
+		}
+	})
+	stopper.RunWorker(ctx, func(ctx context.Context) {
+		t := timeutil.NewTimer()
+		defer t.Stop()
+		t.Reset(0)
+		for {
+			select {
+			case <-t.C:
+				t.Read = true
+				m.check(ctx, timeutil.Now())
+				t.Reset(m.checkInterval())
+			case <-stopper.ShouldQuiesce():
+				return
+			}
+		}
+	})
+}
+
+// checkInterval returns the interval at which the watchdog checks for stalls.
+// It is a fraction of the stall threshold so that stalls are detected
+// promptly, but is bounded to avoid spinning.
+func (m *diskHealthMonitor) checkInterval() time.Duration {
+	interval := diskStallThreshold.Get(&m.st.SV) / 10
+	if interval < time.Millisecond {
+		interval = time.Millisecond
+	} else if interval > time.Second {
+		interval = time.Second
+	}
+	return interval
+}
+
+// probe writes and syncs the probe file and then syncs the WAL.
+func (m *diskHealthMonitor) probe(ctx context.Context) {
+	if err := m.runOp("probe file write", m.metrics.DiskProbeLatency, func() error {
+		return m.writeProbeFile()
+	}); err != nil {
+		log.Warningf(ctx, "unable to write disk health probe file: %s", err)
+	}
+	if err := m.runOp("WAL sync", m.metrics.DiskWALSyncLatency, func() error {
+		return engine.WriteSyncNoop(ctx, m.eng)
+	}); err != nil {
+		log.Fatal(ctx, err)
+	}
+
+	// Both operations completed, so the disk is not stalled (anymore).
+	m.mu.Lock()
+	wasStalled := m.mu.stalled
+	m.mu.stalled = false
+	m.mu.Unlock()
+	if wasStalled {
+		log.Infof(ctx, "disk stall resolved for %s", m.eng)
+		m.metrics.DiskStalled.Update(0)
+		m.onStall(ctx, false)
+	}
+}
+
+func (m *diskHealthMonitor) writeProbeFile() error {
+	filename := filepath.Join(m.eng.GetAuxiliaryDir(), diskHealthProbeFilename)
+	f, err := m.eng.OpenFile(filename)
+	if err != nil {
+		return err
+	}
+	if err := f.Append([]byte(timeutil.Now().String())); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return m.eng.DeleteFile(filename)
+}
+
+// runOp runs fn while exposing it to the watchdog and records its latency.
+func (m *diskHealthMonitor) runOp(name string, latency latencyRecorder, fn func() error) error {
+	start := timeutil.Now()
+	m.mu.Lock()
+	m.mu.opName = name
+	m.mu.opStart = start
+	m.mu.Unlock()
+
+	err := fn()
+
+	m.mu.Lock()
+	m.mu.opName = ""
+	m.mu.opStart = time.Time{}
+	m.mu.Unlock()
+	latency.RecordValue(timeutil.Since(start).Nanoseconds())
+	return err
+}
+
+// latencyRecorder is implemented by *metric.Histogram.
+type latencyRecorder interface {
+	RecordValue(int64)
+}
+
+// check inspects the outstanding probe operation, if any, and marks the disk
+// as stalled or terminates the process if it has been outstanding for too
+// long.
+func (m *diskHealthMonitor) check(ctx context.Context, now time.Time) {
+	threshold := diskStallThreshold.Get(&m.st.SV)
+	if threshold == 0 {
+		return
+	}
+	fatalThreshold := diskStallFatalThreshold.Get(&m.st.SV)
+	if maxSyncDurationFatalOnExceeded {
+		fatalThreshold = threshold
+	}
+
+	m.mu.Lock()
+	if m.mu.opStart.IsZero() {
+		m.mu.Unlock()
+		return
+	}
+	opName := m.mu.opName
+	outstanding := now.Sub(m.mu.opStart)
+	becameStalled := outstanding >= threshold && !m.mu.stalled
+	if becameStalled {
+		m.mu.stalled = true
+	}
+	m.mu.Unlock()
+
+	if fatalThreshold > 0 && outstanding >= fatalThreshold {
+		var stats string
+		if rocks, ok := m.eng.(*engine.RocksDB); ok {
+			stats = "\n" + rocks.GetCompactionStats()
+		}
+		// NB: the disk-stall-detected roachtest matches on this message.
+		m.onFatal(ctx, fmt.Sprintf("disk stall detected: unable to write to %s within %s %s",
+			m.eng, fatalThreshold, stats))
+		return
+	}
+	if becameStalled {
+		log.Warningf(ctx, "disk stall detected: unable to write to %s within %s (%s outstanding for %s); "+
+			"shedding leases", m.eng, threshold, opName, outstanding)
+		m.metrics.DiskStalls.Inc(1)
+		m.metrics.DiskStalled.Update(1)
+		m.onStall(ctx, true)
+	}
+}
+
+// IsDiskStalled returns true if the store's disk health monitor has detected
+// that the store's disk is stalled.
+func (s *Store) IsDiskStalled() bool {
+	return s.diskStalled.Load().(bool)
+}
+
+// setDiskStalled is called by the store's disk health monitor when the disk
+// transitions into or out of the stalled state. A stalled store sheds its
+// leases by draining, and gossips its updated descriptor so that StorePools
+// throughout the cluster stop considering it as a target for new replicas and
+// leases. Draining is only undone on recovery if it was initiated here.
+func (s *Store) setDiskStalled(ctx context.Context, stalled bool) {
+	s.diskStalled.Store(stalled)
+	if err := s.stopper.RunAsyncTask(ctx, "storage.Store: disk stall", func(ctx context.Context) {
+		s.diskStallMu.Lock()
+		defer s.diskStallMu.Unlock()
+		if stalled != s.IsDiskStalled() {
+			// Superseded by a later transition.
+			return
+		}
+		if err := s.GossipStore(ctx, true /* useCached */); err != nil {
+			log.Warningf(ctx, "unable to gossip store descriptor: %s", err)
+		}
+		if stalled && !s.IsDraining() {
+			s.diskStallMu.drained = true
+			s.SetDraining(true)
+		} else if !stalled && s.diskStallMu.drained {
+			s.diskStallMu.drained = false
+			s.SetDraining(false)
+		}
+	}); err != nil {
+		log.Warningf(ctx, "unable to handle disk stall transition: %s", err)
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/metric"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+)
+
+// slowSyncEngine is an engine whose files block on Sync until unblocked,
+// simulating a stalled disk.
+type slowSyncEngine struct {
+	engine.Engine
+	unblock chan struct{}
+}
+
+func (e *slowSyncEngine) OpenFile(filename string) (engine.DBFile, error) {
+	f, err := e.Engine.OpenFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return &slowSyncFile{DBFile: f, unblock: e.unblock}, nil
+}
+
+type slowSyncFile struct {
+	engine.DBFile
+	unblock chan struct{}
+}
+
+func (f *slowSyncFile) Sync() error {
+	<-f.unblock
+	return f.DBFile.Sync()
+}
+
+func startTestDiskHealthMonitor(
+	threshold, fatalThreshold time.Duration,
+) (
+	stopper *stop.Stopper,
+	eng *slowSyncEngine,
+	metrics *StoreMetrics,
+	stallC chan bool,
+	fatalC chan string,
+) {
+	st := cluster.MakeTestingClusterSettings()
+	diskStallProbeInterval.Override(&st.SV, time.Millisecond)
+	diskStallThreshold.Override(&st.SV, threshold)
+	diskStallFatalThreshold.Override(&st.SV, fatalThreshold)
+
+	stopper = stop.NewStopper()
+	goEng := engine.NewGoInMem(roachpb.Attributes{}, 1<<20)
+	stopper.AddCloser(goEng)
+	eng = &slowSyncEngine{Engine: goEng, unblock: make(chan struct{})}
+	metrics = newStoreMetrics(metric.TestSampleInterval)
+
+	stallC = make(chan bool, 10)
+	fatalC = make(chan string, 1)
+	m := newDiskHealthMonitor(log.AmbientContext{}, eng, st, metrics,
+		func(_ context.Context, stalled bool) {
+			stallC <- stalled
+		})
+	m.onFatal = func(_ context.Context, msg string) {
+		select {
+		case fatalC <- msg:
+		default:
+		}
+	}
+	m.Start(context.Background(), stopper)
+	return stopper, eng, metrics, stallC, fatalC
+}
+
+func TestDiskHealthMonitorDetectsStall(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	stopper, eng, metrics, stallC, fatalC := startTestDiskHealthMonitor(
+		10*time.Millisecond, 0 /* fatalThreshold */)
+	defer stopper.Stop(context.Background())
+
+	if stalled := <-stallC; !stalled {
+		t.Fatal("expected disk to be marked as stalled")
+	}
+	if v := metrics.DiskStalled.Value(); v != 1 {
+		t.Fatalf("expected disk.stalled to be 1, found %d", v)
+	}
+	if v := metrics.DiskStalls.Count(); v != 1 {
+		t.Fatalf("expected disk.stalls to be 1, found %d", v)
+	}
+
+	// Unblock the disk and verify that the stall is resolved.
+	close(eng.unblock)
+	if stalled := <-stallC; stalled {
+		t.Fatal("expected disk stall to be resolved")
+	}
+	if v := metrics.DiskStalled.Value(); v != 0 {
+		t.Fatalf("expected disk.stalled to be 0, found %d", v)
+	}
+	select {
+	case msg := <-fatalC:
+		t.Fatalf("unexpected fatal error: %s", msg)
+	default:
+	}
+}
+
+func TestDiskHealthMonitorFatal(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	stopper, eng, _, stallC, fatalC := startTestDiskHealthMonitor(
+		10*time.Millisecond, 50*time.Millisecond)
+	defer stopper.Stop(context.Background())
+	defer close(eng.unblock)
+
+	if stalled := <-stallC; !stalled {
+		t.Fatal("expected disk to be marked as stalled")
+	}
+	select {
+	case <-fatalC:
+	case <-time.After(10 * time.Second):
+		t.Fatal("expected stall to be fatal")
+	}
+}
+
+func TestDiskHealthMonitorDisabled(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	stopper, eng, metrics, stallC, _ := startTestDiskHealthMonitor(
+		0 /* threshold */, 0 /* fatalThreshold */)
+	defer stopper.Stop(context.Background())
+	defer close(eng.unblock)
+
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case <-stallC:
+		t.Fatal("unexpected disk stall with disabled threshold")
+	default:
+	}
+	if v := metrics.DiskStalls.Count(); v != 0 {
+		t.Fatalf("expected disk.stalls to be 0, found %d", v)
+	}
+}
@@ -115,6 +115,9 @@ type storeDetail struct {
 	// lastUpdatedTime is set when a store is first consulted and every time
 	// gossip arrives for a store.
 	lastUpdatedTime time.Time
+	// diskStalledSince is set when gossip first reports that the store's disk
+	// is stalled, and reset when it reports that it no longer is.
+	diskStalledSince time.Time
 }
 
 // isThrottled returns whether the store is currently throttled.
@@ -128,8 +131,9 @@ type storeStatus int
 // These are the possible values for a storeStatus.
 const (
 	_ storeStatus = iota
-	// The store's node is not live or no gossip has been received from
-	// the store for more than the timeUntilStoreDead threshold.
+	// The store's node is not live, no gossip has been received from
+	// the store for more than the timeUntilStoreDead threshold, or its disk
+	// has been stalled for more than that threshold.
 	storeStatusDead
 	// The store isn't available because it hasn't gossiped yet. This
 	// status lasts until either gossip is received from the store or
@@ -145,6 +149,9 @@ const (
 	storeStatusAvailable
 	// The store is decommissioning.
 	storeStatusDecommissioning
+	// The store is alive but has reported that its disk is stalled, for less
+	// than the timeUntilStoreDead threshold.
+	storeStatusDiskStalled
 )
 
 // status returns the current status of the store, including whether
@@ -177,6 +184,17 @@ func (sd *storeDetail) status(
 		return storeStatusUnknown
 	}
 
+	if sd.desc.DiskStalled {
+		// The process is not terminated by a stalled disk by default (see
+		// server.disk_stall.fatal_threshold), so a store which stays stalled is
+		// considered dead like one which stopped gossiping, and its replicas
+		// are replaced.
+		if now.After(sd.diskStalledSince.Add(threshold)) {
+			return storeStatusDead
+		}
+		return storeStatusDiskStalled
+	}
+
 	if sd.isThrottled(now) {
 		return storeStatusThrottled
 	}
@@ -300,6 +318,11 @@ func (sp *StorePool) storeGossipUpdate(_ string, content roachpb.Value) {
 	detail := sp.getStoreDetailLocked(storeDesc.StoreID)
 	detail.desc = &storeDesc
 	detail.lastUpdatedTime = sp.clock.PhysicalTime()
+	if !storeDesc.DiskStalled {
+		detail.diskStalledSince = time.Time{}
+	} else if detail.diskStalledSince.IsZero() {
+		detail.diskStalledSince = detail.lastUpdatedTime
+	}
 	sp.detailsMu.Unlock()
 
 	sp.localitiesMu.Lock()
@@ -455,11 +478,15 @@ func (sp *StorePool) liveAndDeadReplicas(
 		switch status {
 		case storeStatusDead:
 			deadReplicas = append(deadReplicas, repl)
-		case storeStatusAvailable, storeStatusThrottled, storeStatusDecommissioning:
+		case storeStatusAvailable, storeStatusThrottled, storeStatusDecommissioning,
+			storeStatusDiskStalled:
 			// We count both available and throttled stores to be live for the
 			// purpose of computing quorum.
 			// We count decommissioning replicas to be alive because they are readable
 			// and should be used for up-replication if necessary.
+			// We count replicas on stores with stalled disks to be alive because the
+			// stall may be transient; if it lasts for more than timeUntilStoreDead,
+			// the store is considered dead.
 			liveReplicas = append(liveReplicas, repl)
 		case storeStatusUnknown:
 		// No-op.
@@ -633,7 +660,7 @@ func (sp *StorePool) getStoreListFromIDsRLocked(
 			if filter != storeFilterThrottled {
 				storeDescriptors = append(storeDescriptors, *detail.desc)
 			}
-		case storeStatusReplicaCorrupted:
+		case storeStatusReplicaCorrupted, storeStatusDiskStalled:
 			aliveStoreCount++
 		case storeStatusAvailable:
 			aliveStoreCount++
@@ -237,6 +237,12 @@ func TestStorePoolGetStoreList(t *testing.T) {
 		Node:    roachpb.NodeDescriptor{NodeID: 6},
 		Attrs:   roachpb.Attributes{Attrs: required},
 	}
+	stalledStore := roachpb.StoreDescriptor{
+		StoreID:     7,
+		Node:        roachpb.NodeDescriptor{NodeID: 7},
+		Attrs:       roachpb.Attributes{Attrs: required},
+		DiskStalled: true,
+	}
 
 	const rangeID = roachpb.RangeID(1)
 
@@ -248,6 +254,7 @@ func TestStorePoolGetStoreList(t *testing.T) {
 		&emptyStore,
 		&deadStore,
 		&declinedStore,
+		&stalledStore,
 	}, t)
 	for i := 1; i <= 7; i++ {
 		mnl.setNodeStatus(roachpb.NodeID(i), storagepb.NodeLivenessStatus_LIVE)
@@ -270,7 +277,7 @@ func TestStorePoolGetStoreList(t *testing.T) {
 			int(declinedStore.StoreID),
 		},
 		storeFilterNone,
-		/* expectedAliveStoreCount */ 5,
+		/* expectedAliveStoreCount */ 6,
 		/* expectedThrottledStoreCount */ 1,
 	); err != nil {
 		t.Error(err)
@@ -285,7 +292,7 @@ func TestStorePoolGetStoreList(t *testing.T) {
 			int(supersetStore.StoreID),
 		},
 		storeFilterThrottled,
-		/* expectedAliveStoreCount */ 5,
+		/* expectedAliveStoreCount */ 6,
 		/* expectedThrottledStoreCount */ 1,
 	); err != nil {
 		t.Error(err)
@@ -910,3 +917,87 @@ func TestStorePoolDecommissioningReplicas(t *testing.T) {
 		t.Fatalf("expected decommissioning replicas %+v; got %+v", e, a)
 	}
 }
+
+func TestStorePoolDiskStalledReplicas(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	stopper, g, _, sp, mnl := createTestStorePool(
+		TestTimeUntilStoreDead, false, /* deterministic */
+		func() int { return 3 }, /* nodeCount */
+		storagepb.NodeLivenessStatus_DEAD)
+	defer stopper.Stop(context.TODO())
+	sg := gossiputil.NewStoreGossiper(g)
+
+	stores := []*roachpb.StoreDescriptor{
+		{
+			StoreID: 1,
+			Node:    roachpb.NodeDescriptor{NodeID: 1},
+		},
+		{
+			StoreID: 2,
+			Node:    roachpb.NodeDescriptor{NodeID: 2},
+		},
+		{
+			StoreID:     3,
+			Node:        roachpb.NodeDescriptor{NodeID: 3},
+			DiskStalled: true,
+		},
+	}
+
+	replicas := []roachpb.ReplicaDescriptor{
+		{
+			NodeID:    1,
+			StoreID:   1,
+			ReplicaID: 1,
+		},
+		{
+			NodeID:    2,
+			StoreID:   2,
+			ReplicaID: 2,
+		},
+		{
+			NodeID:    3,
+			StoreID:   3,
+			ReplicaID: 3,
+		},
+	}
+
+	sg.GossipStores(stores, t)
+	for i := 1; i <= 3; i++ {
+		mnl.setNodeStatus(roachpb.NodeID(i), storagepb.NodeLivenessStatus_LIVE)
+	}
+
+	// A recently stalled store's replicas are considered live.
+	liveReplicas, deadReplicas := sp.liveAndDeadReplicas(0, replicas)
+	if a, e := liveReplicas, replicas; !reflect.DeepEqual(a, e) {
+		t.Fatalf("expected live replicas %+v; got %+v", e, a)
+	}
+	if len(deadReplicas) > 0 {
+		t.Fatalf("expected no dead replicas initially, found %d (%v)", len(deadReplicas), deadReplicas)
+	}
+
+	// Once the store has been stalled for longer than timeUntilStoreDead, its
+	// replicas are considered dead.
+	sp.detailsMu.Lock()
+	sp.detailsMu.storeDetails[3].diskStalledSince = sp.clock.PhysicalTime().Add(-2 * TestTimeUntilStoreDead)
+	sp.detailsMu.Unlock()
+
+	liveReplicas, deadReplicas = sp.liveAndDeadReplicas(0, replicas)
+	if a, e := liveReplicas, replicas[:2]; !reflect.DeepEqual(a, e) {
+		t.Fatalf("expected live replicas %+v; got %+v", e, a)
+	}
+	if a, e := deadReplicas, replicas[2:]; !reflect.DeepEqual(a, e) {
+		t.Fatalf("expected dead replicas %+v; got %+v", e, a)
+	}
+
+	// When the stall clears, the replicas are live again.
+	stores[2].DiskStalled = false
+	sg.GossipStores(stores, t)
+
+	liveReplicas, deadReplicas = sp.liveAndDeadReplicas(0, replicas)
+	if a, e := liveReplicas, replicas; !reflect.DeepEqual(a, e) {
+		t.Fatalf("expected live replicas %+v; got %+v", e, a)
+	}
+	if len(deadReplicas) > 0 {
+		t.Fatalf("expected no dead replicas, found %d (%v)", len(deadReplicas), deadReplicas)
+	}
+}
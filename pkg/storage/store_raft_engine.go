@@ -0,0 +1,325 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"math"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/pkg/errors"
+)
+
+// A store may keep the Raft logs of its replicas in a dedicated engine (see
+// StoreConfig.RaftEngine), possibly on a separate device, so that log appends
+// and truncations don't compete with the state machine for compactions. Only
+// the log entries themselves live in the Raft engine; the HardState and the
+// TruncatedState remain in the store's engine next to the applied index, so
+// that the invariants relating them are maintained atomically.
+//
+// Since the two engines cannot be committed atomically, writes to them are
+// ordered so that the state visible after a crash is always usable:
+//
+// - Log entries are synced to the Raft engine before the HardState
+//   referencing them is committed to the store's engine.
+// - Log entries are removed from the Raft engine only after the state machine
+//   change making them obsolete (a new TruncatedState, or the removal of the
+//   replica) has been synced to the store's engine.
+// - When applying a snapshot, the Raft log above the snapshot's truncated
+//   state is replaced before the snapshot is committed to the store's engine.
+//   Those entries are committed, so the resulting log is valid for the
+//   replica's state both before and after the snapshot.
+//
+// Log entries at or below the truncated state that survive a crash are
+// removed when the replica is loaded, and the logs of replicas that no longer
+// exist are removed when the store starts.
+
+// hasDedicatedRaftEngine returns true if the store keeps its Raft log in an
+// engine separate from the one holding the replicated state.
+func (s *Store) hasDedicatedRaftEngine() bool {
+	return s.raftEngine != s.engine
+}
+
+// newRaftReadOnly returns ReadOnly views of the store's engine and of the
+// engine holding its Raft log, along with a function releasing both. The two
+// views are the same unless the store has a dedicated Raft engine.
+func (s *Store) newRaftReadOnly() (readonly, raftReadonly engine.ReadWriter, release func()) {
+	readonly = s.engine.NewReadOnly()
+	if !s.hasDedicatedRaftEngine() {
+		return readonly, readonly, readonly.Close
+	}
+	raftReadonly = s.raftEngine.NewReadOnly()
+	return readonly, raftReadonly, func() {
+		raftReadonly.Close()
+		readonly.Close()
+	}
+}
+
+// initRaftEngine verifies that the store is started with the Raft engine
+// holding its Raft log. If a dedicated Raft engine has not been used by the
+// store before, the Raft logs are moved into it from the store's engine.
+//
+// Once the logs have been moved, a marker is written to the store's engine
+// (see keys.StoreRaftEngineKey), and the store refuses to start without a
+// dedicated Raft engine, or with one that doesn't belong to it. Otherwise a
+// change to the store's configuration would silently lose its Raft logs.
+func (s *Store) initRaftEngine(ctx context.Context) error {
+	moved, err := hasDedicatedRaftEngineMarker(ctx, s.engine)
+	if err != nil {
+		return err
+	}
+	if !s.hasDedicatedRaftEngine() {
+		if moved {
+			return errors.Errorf("store %s keeps its raft log in a dedicated raft engine, "+
+				"but was started without one", s.Ident)
+		}
+		return nil
+	}
+	ident, err := ReadStoreIdent(ctx, s.raftEngine)
+	if err == nil {
+		if ident != *s.Ident {
+			return errors.Errorf("raft engine %s belongs to store %s, not %s", s.raftEngine, ident, s.Ident)
+		}
+	} else if _, ok := err.(*NotBootstrappedError); !ok {
+		return err
+	} else if moved {
+		return errors.Errorf("store %s keeps its raft log in a dedicated raft engine, "+
+			"but %s has not been used by it", s.Ident, s.raftEngine)
+	}
+	if moved {
+		// A crash may have left Raft logs behind in the store's engine after the
+		// marker was written; they are superseded by the Raft engine.
+		return clearRaftLogs(ctx, s.engine)
+	}
+
+	// The move is restarted from scratch until the marker has been written, so
+	// it is idempotent in case of a crash: anything in the Raft engine was left
+	// by an interrupted move, and the store's engine still holds all the logs.
+	if err := clearRaftLogs(ctx, s.raftEngine); err != nil {
+		return err
+	}
+	n, err := copyRaftLogs(ctx, s.engine, s.raftEngine)
+	if err != nil {
+		return errors.Wrap(err, "moving raft logs")
+	}
+	batch := s.raftEngine.NewBatch()
+	defer batch.Close()
+	if err := engine.MVCCPutProto(
+		ctx, batch, nil /* ms */, keys.StoreIdentKey(), hlc.Timestamp{}, nil /* txn */, s.Ident,
+	); err != nil {
+		return err
+	}
+	if err := batch.Commit(true /* sync */); err != nil {
+		return err
+	}
+	if err := writeDedicatedRaftEngineMarker(ctx, s.engine); err != nil {
+		return err
+	}
+	if err := clearRaftLogs(ctx, s.engine); err != nil {
+		return err
+	}
+	if n > 0 {
+		log.Infof(ctx, "moved the raft logs of %d ranges to %s", n, s.raftEngine)
+	}
+	return nil
+}
+
+// hasDedicatedRaftEngineMarker returns true if the store's engine records that
+// the store keeps its Raft log in a dedicated engine.
+func hasDedicatedRaftEngineMarker(ctx context.Context, reader engine.Reader) (bool, error) {
+	v, _, err := engine.MVCCGet(
+		ctx, reader, keys.StoreRaftEngineKey(), hlc.Timestamp{}, engine.MVCCGetOptions{},
+	)
+	if err != nil || v == nil {
+		return false, err
+	}
+	return v.GetBool()
+}
+
+// writeDedicatedRaftEngineMarker durably records in the store's engine that
+// the store keeps its Raft log in a dedicated engine.
+func writeDedicatedRaftEngineMarker(ctx context.Context, eng engine.Engine) error {
+	var v roachpb.Value
+	v.SetBool(true)
+	batch := eng.NewBatch()
+	defer batch.Close()
+	if err := engine.MVCCPut(
+		ctx, batch, nil /* ms */, keys.StoreRaftEngineKey(), hlc.Timestamp{}, v, nil, /* txn */
+	); err != nil {
+		return err
+	}
+	return batch.Commit(true /* sync */)
+}
+
+// copyRaftLogs copies all Raft log entries from one engine to another,
+// returning the number of ranges whose logs were copied. The entries are
+// durably written to the destination when it returns.
+func copyRaftLogs(ctx context.Context, from, to engine.Engine) (int, error) {
+	var rangeIDs []roachpb.RangeID
+	if err := iterateRaftLogRangeIDs(from, func(rangeID roachpb.RangeID) error {
+		rangeIDs = append(rangeIDs, rangeID)
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+	if len(rangeIDs) == 0 {
+		return 0, nil
+	}
+	for _, rangeID := range rangeIDs {
+		if err := copyRaftLog(from, to, rangeID); err != nil {
+			return 0, err
+		}
+	}
+	return len(rangeIDs), engine.WriteSyncNoop(ctx, to)
+}
+
+// clearRaftLogs durably removes all Raft log entries from the given engine.
+func clearRaftLogs(ctx context.Context, eng engine.Engine) error {
+	var rangeIDs []roachpb.RangeID
+	if err := iterateRaftLogRangeIDs(eng, func(rangeID roachpb.RangeID) error {
+		rangeIDs = append(rangeIDs, rangeID)
+		return nil
+	}); err != nil {
+		return err
+	}
+	if len(rangeIDs) == 0 {
+		return nil
+	}
+	for _, rangeID := range rangeIDs {
+		batch := eng.NewWriteOnlyBatch()
+		err := clearRaftLogEntries(eng, batch, rangeID, 0, math.MaxUint64)
+		if err == nil {
+			err = batch.Commit(false /* sync */)
+		}
+		batch.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return engine.WriteSyncNoop(ctx, eng)
+}
+
+// copyRaftLog copies the Raft log of the given range from one engine to
+// another.
+func copyRaftLog(from, to engine.Engine, rangeID roachpb.RangeID) error {
+	prefix := keys.RaftLogPrefix(rangeID)
+	iter := from.NewIterator(engine.IterOptions{UpperBound: prefix.PrefixEnd()})
+	defer iter.Close()
+	batch := to.NewWriteOnlyBatch()
+	defer batch.Close()
+	for iter.Seek(engine.MakeMVCCMetadataKey(prefix)); ; iter.Next() {
+		if ok, err := iter.Valid(); err != nil {
+			return err
+		} else if !ok {
+			break
+		}
+		if err := batch.Put(iter.Key(), iter.Value()); err != nil {
+			return err
+		}
+	}
+	return batch.Commit(false /* sync */)
+}
+
+// iterateRaftLogRangeIDs calls fn with the ID of every range that has Raft log
+// entries in the given reader.
+func iterateRaftLogRangeIDs(reader engine.Reader, fn func(roachpb.RangeID) error) error {
+	iter := reader.NewIterator(engine.IterOptions{
+		UpperBound: keys.LocalRangeIDPrefix.PrefixEnd().AsRawKey(),
+	})
+	defer iter.Close()
+
+	iter.Seek(engine.MakeMVCCMetadataKey(keys.LocalRangeIDPrefix.AsRawKey()))
+	for {
+		if ok, err := iter.Valid(); err != nil || !ok {
+			return err
+		}
+		rangeID, _, _, _, err := keys.DecodeRangeIDKey(iter.UnsafeKey().Key)
+		if err != nil {
+			return err
+		}
+		prefix := keys.RaftLogPrefix(rangeID)
+		iter.Seek(engine.MakeMVCCMetadataKey(prefix))
+		if ok, err := iter.Valid(); err != nil {
+			return err
+		} else if ok && bytes.HasPrefix(iter.UnsafeKey().Key, prefix) {
+			if err := fn(rangeID); err != nil {
+				return err
+			}
+		}
+		iter.Seek(engine.MakeMVCCMetadataKey(keys.MakeRangeIDPrefix(rangeID + 1)))
+	}
+}
+
+// clearRaftLogEntries clears the Raft log entries of the given range with an
+// index in [lo, hi) which are visible to the reader.
+func clearRaftLogEntries(
+	reader engine.Reader, writer engine.Writer, rangeID roachpb.RangeID, lo, hi uint64,
+) error {
+	start := engine.MakeMVCCMetadataKey(keys.RaftLogKey(rangeID, lo))
+	end := engine.MakeMVCCMetadataKey(keys.RaftLogKey(rangeID, hi))
+	iter := reader.NewIterator(engine.IterOptions{UpperBound: end.Key})
+	defer iter.Close()
+	return writer.ClearIterRange(iter, start, end)
+}
+
+// truncateDedicatedRaftLog removes the Raft log entries of the given range
+// with an index smaller than hi from the store's dedicated Raft engine. It is
+// a no-op if the store keeps its Raft log in its engine, where entries are
+// removed atomically with the state machine changes making them obsolete.
+//
+// The caller must ensure that these state machine changes are durable. The
+// removal itself isn't synced; entries that reappear after a crash are
+// removed again when the replica is loaded or the store is started.
+func (s *Store) truncateDedicatedRaftLog(rangeID roachpb.RangeID, hi uint64) error {
+	if !s.hasDedicatedRaftEngine() {
+		return nil
+	}
+	batch := s.raftEngine.NewWriteOnlyBatch()
+	defer batch.Close()
+	if err := clearRaftLogEntries(s.raftEngine, batch, rangeID, 0, hi); err != nil {
+		return err
+	}
+	return batch.Commit(false /* sync */)
+}
+
+// removeOrphanedRaftLogs removes the Raft logs of ranges which don't have a
+// replica on the store from the store's dedicated Raft engine. Such logs are
+// left behind if the store crashes while a replica is being removed.
+func (s *Store) removeOrphanedRaftLogs(ctx context.Context) error {
+	if !s.hasDedicatedRaftEngine() {
+		return nil
+	}
+	var orphaned []roachpb.RangeID
+	if err := iterateRaftLogRangeIDs(s.raftEngine, func(rangeID roachpb.RangeID) error {
+		if _, ok := s.mu.replicas.Load(int64(rangeID)); !ok {
+			orphaned = append(orphaned, rangeID)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	for _, rangeID := range orphaned {
+		if err := s.truncateDedicatedRaftLog(rangeID, math.MaxUint64); err != nil {
+			return err
+		}
+	}
+	if len(orphaned) > 0 {
+		log.Infof(ctx, "removed the orphaned raft logs of %d ranges", len(orphaned))
+	}
+	return nil
+}
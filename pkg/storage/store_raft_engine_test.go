@@ -0,0 +1,167 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+)
+
+func raftLogRangeIDs(t *testing.T, reader engine.Reader) []roachpb.RangeID {
+	t.Helper()
+	var rangeIDs []roachpb.RangeID
+	if err := iterateRaftLogRangeIDs(reader, func(rangeID roachpb.RangeID) error {
+		rangeIDs = append(rangeIDs, rangeID)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return rangeIDs
+}
+
+// TestStoreDedicatedRaftEngine verifies that a store configured with a
+// dedicated Raft engine moves its existing Raft logs into that engine on
+// startup and appends new entries to it.
+func TestStoreDedicatedRaftEngine(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	stopper := stop.NewStopper()
+	defer stopper.Stop(ctx)
+
+	raftEng := engine.NewInMem(roachpb.Attributes{}, 10<<20)
+	stopper.AddCloser(raftEng)
+	cfg := TestStoreConfig(hlc.NewClock(hlc.NewManualClock(123).UnixNano, time.Nanosecond))
+	cfg.RaftEngine = raftEng
+	store := createTestStoreWithConfig(t, stopper, testStoreOpts{createSystemRanges: true}, &cfg)
+
+	if rangeIDs := raftLogRangeIDs(t, store.Engine()); len(rangeIDs) != 0 {
+		t.Fatalf("expected no raft logs in the store's engine, found logs of %v", rangeIDs)
+	}
+	if rangeIDs := raftLogRangeIDs(t, raftEng); len(rangeIDs) != store.ReplicaCount() {
+		t.Fatalf("expected raft logs of %d ranges, found logs of %v", store.ReplicaCount(), rangeIDs)
+	}
+	if ident, err := ReadStoreIdent(ctx, raftEng); err != nil {
+		t.Fatal(err)
+	} else if ident != *store.Ident {
+		t.Fatalf("expected raft engine to belong to %s, found %s", store.Ident, ident)
+	}
+
+	key := roachpb.Key("a")
+	repl := store.LookupReplica(roachpb.RKey(key))
+	lastIndex, err := repl.GetLastIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pArgs := putArgs(key, []byte("value"))
+	if _, pErr := client.SendWrapped(ctx, store.TestSender(), &pArgs); pErr != nil {
+		t.Fatal(pErr)
+	}
+	newLastIndex, err := repl.GetLastIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newLastIndex <= lastIndex {
+		t.Fatalf("expected last index to advance past %d, found %d", lastIndex, newLastIndex)
+	}
+	entryKey := engine.MakeMVCCMetadataKey(keys.RaftLogKey(repl.RangeID, newLastIndex))
+	if v, err := raftEng.Get(entryKey); err != nil {
+		t.Fatal(err)
+	} else if v == nil {
+		t.Fatalf("expected raft entry %d in the raft engine", newLastIndex)
+	}
+	if rangeIDs := raftLogRangeIDs(t, store.Engine()); len(rangeIDs) != 0 {
+		t.Fatalf("expected no raft logs in the store's engine, found logs of %v", rangeIDs)
+	}
+}
+
+// TestStoreRaftEngineMarker verifies that once a store has moved its Raft log
+// into a dedicated engine, it refuses to start without that engine.
+func TestStoreRaftEngineMarker(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	stopper := stop.NewStopper()
+	defer stopper.Stop(ctx)
+
+	newEngine := func() engine.Engine {
+		eng := engine.NewInMem(roachpb.Attributes{}, 1<<20)
+		stopper.AddCloser(eng)
+		return eng
+	}
+	putEntry := func(eng engine.Engine, rangeID roachpb.RangeID, index uint64) {
+		t.Helper()
+		key := engine.MakeMVCCMetadataKey(keys.RaftLogKey(rangeID, index))
+		if err := eng.Put(key, []byte("entry")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	initRaftEngine := func(eng, raftEng engine.Engine, ident roachpb.StoreIdent) error {
+		s := &Store{engine: eng, raftEngine: raftEng, Ident: &ident}
+		return s.initRaftEngine(ctx)
+	}
+	ident := roachpb.StoreIdent{NodeID: 1, StoreID: 1}
+	otherIdent := roachpb.StoreIdent{NodeID: 1, StoreID: 2}
+
+	eng, raftEng := newEngine(), newEngine()
+	putEntry(eng, 1, 10)
+	putEntry(eng, 2, 10)
+	// A log left behind in the Raft engine by an interrupted move is discarded.
+	putEntry(raftEng, 3, 10)
+	if err := initRaftEngine(eng, raftEng, ident); err != nil {
+		t.Fatal(err)
+	}
+	if moved, err := hasDedicatedRaftEngineMarker(ctx, eng); err != nil {
+		t.Fatal(err)
+	} else if !moved {
+		t.Fatal("expected the store's engine to record the dedicated raft engine")
+	}
+	if rangeIDs := raftLogRangeIDs(t, eng); len(rangeIDs) != 0 {
+		t.Fatalf("expected no raft logs in the store's engine, found logs of %v", rangeIDs)
+	}
+	if rangeIDs := raftLogRangeIDs(t, raftEng); len(rangeIDs) != 2 {
+		t.Fatalf("expected the raft logs of 2 ranges, found logs of %v", rangeIDs)
+	}
+
+	// Restarting with the same Raft engine succeeds.
+	if err := initRaftEngine(eng, raftEng, ident); err != nil {
+		t.Fatal(err)
+	}
+
+	// Restarting without a dedicated Raft engine, with an empty one or with the
+	// Raft engine of another store fails.
+	otherRaftEng := newEngine()
+	if err := initRaftEngine(newEngine(), otherRaftEng, otherIdent); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		raftEng engine.Engine
+		expErr  string
+	}{
+		{eng, "was started without one"},
+		{newEngine(), "has not been used by it"},
+		{otherRaftEng, "belongs to store"},
+	} {
+		if err := initRaftEngine(eng, tc.raftEng, ident); !testutils.IsError(err, tc.expErr) {
+			t.Fatalf("expected error %q, found %v", tc.expErr, err)
+		}
+	}
+}
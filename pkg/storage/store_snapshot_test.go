@@ -75,9 +75,10 @@ func TestSnapshotRaftLogLimit(t *testing.T) {
 			iter := rditer.NewReplicaDataIterator(repl.Desc(), snap, true /* replicatedOnly */)
 			defer iter.Close()
 			outSnap := &OutgoingSnapshot{
-				Iter:       iter,
-				EngineSnap: snap,
-				snapType:   snapType,
+				Iter:           iter,
+				EngineSnap:     snap,
+				RaftEngineSnap: snap,
+				snapType:       snapType,
 				RaftSnap: raftpb.Snapshot{
 					Metadata: raftpb.SnapshotMetadata{
 						Index: lastIndex,
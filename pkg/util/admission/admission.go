@@ -0,0 +1,134 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+// Package admission implements admission control: work is queued before it
+// is allowed to consume a node's resources, so that an overloaded node keeps
+// serving important work with good latency while less important work (e.g.
+// bulk operations) waits.
+//
+// Work is admitted by a WorkQueue, which orders waiting work by priority and
+// then by creation time, and which receives grants from a granter tracking a
+// resource:
+//
+// - CPU slots bound the amount of work executing concurrently on a node. The
+//   number of slots is adjusted based on the CPU utilization of the process:
+//   it shrinks while the node is overloaded and grows while all slots are used
+//   without saturating the CPUs. Admitted work holds its slot until it has
+//   completed, except while it is blocked on other work (see ReleaseSlot).
+// - IO tokens bound the rate of writes to a store. They are unlimited unless
+//   the number of files in level 0 of the store's engine exceeds a threshold,
+//   in which case writes are admitted at a rate that is reduced in proportion
+//   to the overload. Tokens are consumed by admission and never returned.
+//
+// Work is not ordered by tenant or SQL user, since requests to the KV layer
+// identify neither.
+//
+// The GrantCoordinator owns the granters of a node and periodically adjusts
+// them.
+package admission
+
+import (
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/pkg/errors"
+)
+
+// KVAdmissionControlEnabled controls whether requests to the KV layer are
+// subject to admission control.
+var KVAdmissionControlEnabled = settings.RegisterBoolSetting(
+	"admission.kv.enabled",
+	"when true, work performed by the KV layer is subject to admission control",
+	true,
+)
+
+// DistSQLAdmissionControlEnabled controls whether DistSQL flows scheduled on
+// behalf of other nodes are subject to admission control.
+var DistSQLAdmissionControlEnabled = settings.RegisterBoolSetting(
+	"admission.distsql.enabled",
+	"when true, DistSQL flows set up by remote nodes are subject to admission control",
+	true,
+)
+
+// cpuUtilizationTarget is the CPU utilization the number of CPU slots is
+// adjusted towards.
+var cpuUtilizationTarget = settings.RegisterValidatedFloatSetting(
+	"admission.cpu_utilization_target",
+	"the fraction of the available CPU above which the number of concurrently "+
+		"admitted requests is reduced",
+	0.9,
+	func(v float64) error {
+		if v <= 0 || v > 1 {
+			return errors.Errorf("must be in (0, 1], got %f", v)
+		}
+		return nil
+	},
+)
+
+// l0FileCountOverloadThreshold is the number of files in level 0 of a store's
+// engine above which writes to the store are throttled.
+var l0FileCountOverloadThreshold = settings.RegisterPositiveIntSetting(
+	"admission.l0_file_count_overload_threshold",
+	"the number of files in level 0 of a store's engine above which writes to "+
+		"the store are throttled",
+	100,
+)
+
+// WorkPriority is the priority of work waiting for admission. Work of higher
+// priority is always admitted before work of lower priority.
+type WorkPriority int8
+
+const (
+	// LowPri is the priority of work which can tolerate long delays, such as
+	// bulk operations and low priority transactions.
+	LowPri WorkPriority = -1
+	// NormalPri is the default priority.
+	NormalPri WorkPriority = 0
+	// HighPri is the priority of high priority transactions.
+	HighPri WorkPriority = 1
+)
+
+func (p WorkPriority) String() string {
+	switch p {
+	case LowPri:
+		return "low"
+	case NormalPri:
+		return "normal"
+	case HighPri:
+		return "high"
+	default:
+		return "unknown"
+	}
+}
+
+// WorkInfo describes work seeking admission.
+type WorkInfo struct {
+	// Priority is the priority of the work.
+	Priority WorkPriority
+	// CreateTime orders work of the same priority: work created earlier (in
+	// nanoseconds since the epoch) is admitted first. Using the start time of a
+	// transaction favors transactions that are already underway.
+	CreateTime int64
+	// BypassAdmission is set for work which must not wait for admission, for
+	// instance because work that has already been admitted depends on it. Such
+	// work still consumes resources, and is accounted for.
+	BypassAdmission bool
+}
+
+const (
+	// cpuAdjustmentInterval is the interval at which the number of CPU slots
+	// is adjusted.
+	cpuAdjustmentInterval = 250 * time.Millisecond
+	// ioAdjustmentInterval is the interval at which the IO tokens of stores are
+	// replenished.
+	ioAdjustmentInterval = time.Second
+)
@@ -0,0 +1,182 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package admission
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/metric"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/elastic/gosigar"
+)
+
+// GrantCoordinator owns the granters of a node: the CPU slots shared by all
+// work executing on the node, and the IO tokens of each of its stores. It
+// periodically adjusts them based on runtime and engine metrics.
+type GrantCoordinator struct {
+	log.AmbientContext
+	settings *cluster.Settings
+
+	cpu      *slotGranter
+	cpuQueue *WorkQueue
+	// cpuTime returns the cumulative CPU time consumed by the process.
+	cpuTime func() (time.Duration, error)
+
+	metrics Metrics
+
+	mu struct {
+		syncutil.Mutex
+		stores []*tokenGranter
+	}
+}
+
+// Metrics are the metrics of a GrantCoordinator.
+type Metrics struct {
+	CPUQueue   WorkQueueMetrics
+	StoreQueue WorkQueueMetrics
+	TotalSlots *metric.Gauge
+	UsedSlots  *metric.Gauge
+}
+
+// MetricStruct implements the metric.Struct interface.
+func (Metrics) MetricStruct() {}
+
+var (
+	metaTotalSlots = metric.Metadata{
+		Name:        "admission.granter.total_slots.cpu",
+		Help:        "Number of slots for concurrently executing work",
+		Measurement: "Slots",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaUsedSlots = metric.Metadata{
+		Name:        "admission.granter.used_slots.cpu",
+		Help:        "Number of slots used by executing work",
+		Measurement: "Slots",
+		Unit:        metric.Unit_COUNT,
+	}
+)
+
+// NewGrantCoordinator creates a GrantCoordinator. It must be started with
+// Start for the CPU slots and IO tokens to adapt to the load of the node.
+func NewGrantCoordinator(
+	ambient log.AmbientContext, st *cluster.Settings, histogramWindow time.Duration,
+) *GrantCoordinator {
+	c := &GrantCoordinator{
+		AmbientContext: ambient,
+		settings:       st,
+		cpuTime:        processCPUTime,
+		metrics: Metrics{
+			CPUQueue:   makeWorkQueueMetrics("cpu", histogramWindow),
+			StoreQueue: makeWorkQueueMetrics("store", histogramWindow),
+			TotalSlots: metric.NewGauge(metaTotalSlots),
+			UsedSlots:  metric.NewGauge(metaUsedSlots),
+		},
+	}
+	procs := runtime.GOMAXPROCS(0)
+	c.cpu = &slotGranter{
+		minSlots:         procs,
+		maxSlots:         256 * procs,
+		totalSlotsMetric: c.metrics.TotalSlots,
+		usedSlotsMetric:  c.metrics.UsedSlots,
+	}
+	c.cpu.mu.total = 4 * procs
+	c.metrics.TotalSlots.Update(int64(c.cpu.mu.total))
+	c.cpuQueue = newWorkQueue("cpu", c.cpu, true /* usesSlots */, &c.metrics.CPUQueue)
+	c.cpu.q = c.cpuQueue
+	return c
+}
+
+// Metrics returns the metrics of the GrantCoordinator.
+func (c *GrantCoordinator) Metrics() *Metrics {
+	return &c.metrics
+}
+
+// CPUWorkQueue returns the queue admitting work to the node's CPU slots. Work
+// admitted by it must call AdmittedWorkDone once it has completed.
+func (c *GrantCoordinator) CPUWorkQueue() *WorkQueue {
+	return c.cpuQueue
+}
+
+// NewStoreWorkQueue returns a queue admitting writes to a store, given a
+// function returning the number of files in level 0 of the store's engine.
+func (c *GrantCoordinator) NewStoreWorkQueue(l0FileCount func() int64) *WorkQueue {
+	g := &tokenGranter{l0FileCount: l0FileCount}
+	g.mu.unlimited = true
+	q := newWorkQueue("store", g, false /* usesSlots */, &c.metrics.StoreQueue)
+	g.q = q
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.mu.stores = append(c.mu.stores, g)
+	return q
+}
+
+// Start starts the goroutine periodically adjusting the granters.
+func (c *GrantCoordinator) Start(ctx context.Context, stopper *stop.Stopper) {
+	ctx = c.AnnotateCtx(ctx)
+	stopper.RunWorker(ctx, func(ctx context.Context) {
+		cpuTicker := time.NewTicker(cpuAdjustmentInterval)
+		defer cpuTicker.Stop()
+		ioTicker := time.NewTicker(ioAdjustmentInterval)
+		defer ioTicker.Stop()
+
+		lastSample := timeutil.Now()
+		lastCPUTime, err := c.cpuTime()
+		if err != nil {
+			log.Warningf(ctx, "unable to read CPU time: %s", err)
+		}
+		for {
+			select {
+			case <-cpuTicker.C:
+				now := timeutil.Now()
+				cpuTime, err := c.cpuTime()
+				if err != nil {
+					// NB: a warning was logged on startup.
+					continue
+				}
+				elapsed := now.Sub(lastSample) * time.Duration(runtime.GOMAXPROCS(0))
+				if elapsed > 0 {
+					utilization := float64(cpuTime-lastCPUTime) / float64(elapsed)
+					c.cpu.adjust(utilization, cpuUtilizationTarget.Get(&c.settings.SV))
+				}
+				lastSample, lastCPUTime = now, cpuTime
+			case <-ioTicker.C:
+				threshold := l0FileCountOverloadThreshold.Get(&c.settings.SV)
+				c.mu.Lock()
+				stores := c.mu.stores
+				c.mu.Unlock()
+				for _, g := range stores {
+					g.adjust(threshold)
+				}
+			case <-stopper.ShouldStop():
+				return
+			}
+		}
+	})
+}
+
+// processCPUTime returns the cumulative user and system CPU time consumed by
+// the process.
+func processCPUTime() (time.Duration, error) {
+	var cpuTime gosigar.ProcTime
+	if err := cpuTime.Get(os.Getpid()); err != nil {
+		return 0, err
+	}
+	return time.Duration(cpuTime.User+cpuTime.Sys) * time.Millisecond, nil
+}
@@ -0,0 +1,175 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package admission
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/util/metric"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+// slotGranter grants a bounded number of slots for concurrently executing
+// work. The number of slots is adjusted by the GrantCoordinator based on CPU
+// utilization.
+type slotGranter struct {
+	q *WorkQueue
+	// minSlots and maxSlots bound the number of slots.
+	minSlots, maxSlots int
+
+	totalSlotsMetric *metric.Gauge
+	usedSlotsMetric  *metric.Gauge
+
+	mu struct {
+		syncutil.Mutex
+		used, total int
+	}
+}
+
+var _ granter = &slotGranter{}
+
+func (g *slotGranter) tryGet() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.mu.used >= g.mu.total {
+		return false
+	}
+	g.mu.used++
+	g.usedSlotsMetric.Update(int64(g.mu.used))
+	return true
+}
+
+func (g *slotGranter) returnGrant() {
+	g.putBack()
+	grantWaiting(g, g.q)
+}
+
+func (g *slotGranter) putBack() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.mu.used--
+	g.usedSlotsMetric.Update(int64(g.mu.used))
+}
+
+func (g *slotGranter) tookWithoutPermission() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.mu.used++
+	g.usedSlotsMetric.Update(int64(g.mu.used))
+}
+
+// adjust adjusts the number of slots given the CPU utilization of the process
+// (as a fraction of the available CPUs) over the last interval. Slots are
+// removed one at a time while the utilization exceeds the target, and added
+// more aggressively while all slots are used but the utilization is below the
+// target, since work commonly blocks (e.g. on IO or latches) while holding a
+// slot.
+func (g *slotGranter) adjust(utilization, target float64) {
+	g.mu.Lock()
+	if utilization > target {
+		if g.mu.total > g.minSlots {
+			g.mu.total--
+		}
+	} else if g.mu.used >= g.mu.total {
+		g.mu.total += 1 + g.mu.total/8
+		if g.mu.total > g.maxSlots {
+			g.mu.total = g.maxSlots
+		}
+	}
+	g.totalSlotsMetric.Update(int64(g.mu.total))
+	g.mu.Unlock()
+	grantWaiting(g, g.q)
+}
+
+// tokenGranter grants tokens for writes to a store. Tokens are unlimited while
+// the store isn't overloaded; otherwise, a limited number of tokens is made
+// available every ioAdjustmentInterval by the GrantCoordinator.
+type tokenGranter struct {
+	q *WorkQueue
+	// l0FileCount returns the number of files in level 0 of the store's engine.
+	l0FileCount func() int64
+
+	mu struct {
+		syncutil.Mutex
+		unlimited bool
+		available int64
+		// admitted counts the tokens consumed since the last adjustment.
+		admitted int64
+	}
+}
+
+var _ granter = &tokenGranter{}
+
+func (g *tokenGranter) tryGet() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.mu.unlimited {
+		if g.mu.available <= 0 {
+			return false
+		}
+		g.mu.available--
+	}
+	g.mu.admitted++
+	return true
+}
+
+func (g *tokenGranter) returnGrant() {
+	g.putBack()
+	grantWaiting(g, g.q)
+}
+
+func (g *tokenGranter) putBack() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.mu.unlimited {
+		g.mu.available++
+	}
+	g.mu.admitted--
+}
+
+func (g *tokenGranter) tookWithoutPermission() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.mu.unlimited {
+		// NB: this may leave a negative number of tokens available, which
+		// delays the admission of other work.
+		g.mu.available--
+	}
+	g.mu.admitted++
+}
+
+// adjust makes tokens available for the next interval. If the number of
+// files in level 0 exceeds the threshold, the number of tokens is the number
+// of writes admitted during the last interval scaled down by the overload, so
+// that the rate of writes decreases for as long as compactions don't keep up.
+func (g *tokenGranter) adjust(threshold int64) {
+	l0FileCount := g.l0FileCount()
+	g.mu.Lock()
+	if l0FileCount <= threshold {
+		g.mu.unlimited = true
+		g.mu.available = 0
+	} else {
+		tokens := g.mu.admitted * threshold / l0FileCount
+		if tokens < 1 {
+			tokens = 1
+		}
+		g.mu.unlimited = false
+		// Unused tokens don't carry over, but debts incurred by work bypassing
+		// admission do.
+		if g.mu.available > 0 {
+			g.mu.available = 0
+		}
+		g.mu.available += tokens
+	}
+	g.mu.admitted = 0
+	g.mu.Unlock()
+	grantWaiting(g, g.q)
+}
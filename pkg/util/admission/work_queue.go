@@ -0,0 +1,350 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package admission
+
+import (
+	"container/heap"
+	"context"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/metric"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/pkg/errors"
+)
+
+// granter is the interface between a WorkQueue and the resource it admits
+// work to. A granter must never call into its WorkQueue while holding its own
+// lock, since the WorkQueue calls into the granter while holding its lock.
+type granter interface {
+	// tryGet attempts to acquire a grant, returning true on success.
+	tryGet() bool
+	// returnGrant returns a grant, handing it to waiting work if any.
+	returnGrant()
+	// putBack returns a grant acquired by grantWaiting which could not be
+	// handed to waiting work.
+	putBack()
+	// tookWithoutPermission informs the granter that work bypassing admission
+	// consumed a grant.
+	tookWithoutPermission()
+}
+
+// WorkQueue admits work in order of priority, and then in order of creation,
+// as the resource managed by its granter becomes available.
+type WorkQueue struct {
+	name    string
+	granter granter
+	// usesSlots is set if admitted work must call AdmittedWorkDone, which
+	// returns the grant.
+	usesSlots bool
+	metrics   *WorkQueueMetrics
+
+	mu struct {
+		syncutil.Mutex
+		waiting waitingWorkHeap
+	}
+}
+
+func newWorkQueue(name string, g granter, usesSlots bool, metrics *WorkQueueMetrics) *WorkQueue {
+	return &WorkQueue{
+		name:      name,
+		granter:   g,
+		usesSlots: usesSlots,
+		metrics:   metrics,
+	}
+}
+
+// Admit blocks until the work is admitted, or the context is canceled. If the
+// work is admitted and the queue uses slots, the caller must call
+// AdmittedWorkDone once the work has completed.
+func (q *WorkQueue) Admit(ctx context.Context, info WorkInfo) error {
+	q.metrics.Requested.Inc(1)
+	if info.BypassAdmission {
+		q.granter.tookWithoutPermission()
+		q.metrics.Admitted.Inc(1)
+		return nil
+	}
+
+	q.mu.Lock()
+	// Work may only skip the queue if nothing is waiting, or it could overtake
+	// work of higher priority.
+	if len(q.mu.waiting) == 0 && q.granter.tryGet() {
+		q.mu.Unlock()
+		q.metrics.Admitted.Inc(1)
+		return nil
+	}
+	w := &waitingWork{
+		info:        info,
+		enqueueTime: timeutil.Now(),
+		grantCh:     make(chan struct{}, 1),
+	}
+	heap.Push(&q.mu.waiting, w)
+	q.mu.Unlock()
+	// A grant may have become available since tryGet failed.
+	grantWaiting(q.granter, q)
+	q.metrics.WaitQueueLength.Inc(1)
+	defer q.metrics.WaitQueueLength.Dec(1)
+	log.VEventf(ctx, 2, "waiting for admission to %s queue at %s priority", q.name, info.Priority)
+
+	select {
+	case <-w.grantCh:
+		wait := timeutil.Since(w.enqueueTime)
+		q.metrics.Admitted.Inc(1)
+		q.metrics.WaitDurations.RecordValue(wait.Nanoseconds())
+		log.VEventf(ctx, 2, "admitted to %s queue after %s", q.name, wait)
+		return nil
+	case <-ctx.Done():
+		q.mu.Lock()
+		if w.heapIndex >= 0 {
+			heap.Remove(&q.mu.waiting, w.heapIndex)
+			q.mu.Unlock()
+		} else {
+			// The work was granted concurrently with the cancellation, so the
+			// grant must be handed back.
+			q.mu.Unlock()
+			<-w.grantCh
+			q.granter.returnGrant()
+		}
+		q.metrics.Errored.Inc(1)
+		return errors.Wrapf(ctx.Err(), "waiting for admission to %s queue", q.name)
+	}
+}
+
+// AdmittedWorkDone is called once admitted work has completed. It must only be
+// called for queues using slots.
+func (q *WorkQueue) AdmittedWorkDone() {
+	if !q.usesSlots {
+		panic("AdmittedWorkDone called on a queue that doesn't use slots")
+	}
+	q.granter.returnGrant()
+}
+
+// AdmittedWork is the slot held by work admitted by a WorkQueue using slots.
+// Admitted work which blocks on other work, for instance on latches or locks
+// held by other requests, hands its slot back while it is blocked (see
+// ReleaseSlot): the work it waits for may itself be waiting for a slot.
+type AdmittedWork struct {
+	q    *WorkQueue
+	info WorkInfo
+
+	mu struct {
+		syncutil.Mutex
+		// holding is set while the work holds its slot.
+		holding bool
+	}
+}
+
+// AdmitWork is like Admit, but returns the slot held by the admitted work,
+// which must be returned by calling Done once the work has completed. It must
+// only be called for queues using slots.
+func (q *WorkQueue) AdmitWork(ctx context.Context, info WorkInfo) (*AdmittedWork, error) {
+	if !q.usesSlots {
+		panic("AdmitWork called on a queue that doesn't use slots")
+	}
+	if err := q.Admit(ctx, info); err != nil {
+		return nil, err
+	}
+	w := &AdmittedWork{q: q, info: info}
+	w.mu.holding = true
+	return w, nil
+}
+
+// Done is called once the admitted work has completed, returning its slot
+// unless the work failed to reacquire it.
+func (w *AdmittedWork) Done() {
+	w.mu.Lock()
+	holding := w.mu.holding
+	w.mu.holding = false
+	w.mu.Unlock()
+	if holding {
+		w.q.AdmittedWorkDone()
+	}
+}
+
+type admittedWorkKey struct{}
+
+// ContextWithAdmittedWork returns a context carrying the slot of admitted
+// work, which is released by ReleaseSlot while the work is blocked. A nil
+// AdmittedWork hides any slot carried by the parent context, which belongs to
+// different work.
+func ContextWithAdmittedWork(ctx context.Context, w *AdmittedWork) context.Context {
+	return context.WithValue(ctx, admittedWorkKey{}, w)
+}
+
+// ReleaseSlot is called before the admitted work carried by the context, if
+// any, blocks on other work. It returns the work's slot, and returns a
+// function which must be called once the work is unblocked to reacquire it.
+// The slot is reacquired with the work's original priority and creation time,
+// so the work goes ahead of work which was created after it; if the context is
+// canceled while waiting, the work continues without a slot and an error is
+// returned.
+func ReleaseSlot(ctx context.Context) (reacquire func() error) {
+	w, _ := ctx.Value(admittedWorkKey{}).(*AdmittedWork)
+	if w == nil {
+		return func() error { return nil }
+	}
+	w.mu.Lock()
+	holding := w.mu.holding
+	w.mu.holding = false
+	w.mu.Unlock()
+	if !holding {
+		// The slot is already released, e.g. by a concurrent caller.
+		return func() error { return nil }
+	}
+	w.q.AdmittedWorkDone()
+	return func() error {
+		if err := w.q.Admit(ctx, w.info); err != nil {
+			return err
+		}
+		w.mu.Lock()
+		w.mu.holding = true
+		w.mu.Unlock()
+		return nil
+	}
+}
+
+// hasWaitingWork returns whether any work is waiting for admission.
+func (q *WorkQueue) hasWaitingWork() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.mu.waiting) > 0
+}
+
+// grantHead hands a grant acquired by the granter to the waiting work that
+// goes first. It returns false if no work is waiting.
+func (q *WorkQueue) grantHead() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.mu.waiting) == 0 {
+		return false
+	}
+	w := heap.Pop(&q.mu.waiting).(*waitingWork)
+	w.grantCh <- struct{}{}
+	return true
+}
+
+// grantWaiting hands grants to waiting work for as long as the granter has
+// grants available.
+func grantWaiting(g granter, q *WorkQueue) {
+	for {
+		if !g.tryGet() {
+			return
+		}
+		if q.grantHead() {
+			continue
+		}
+		g.putBack()
+		// Work may have been queued after grantHead found the queue empty, but
+		// before the grant was returned, in which case it wasn't able to
+		// acquire the grant itself.
+		if !q.hasWaitingWork() {
+			return
+		}
+	}
+}
+
+// waitingWork is work waiting for admission.
+type waitingWork struct {
+	info        WorkInfo
+	enqueueTime time.Time
+	// grantCh receives a value once the work has been admitted.
+	grantCh chan struct{}
+	// heapIndex is the index of the work in the waitingWorkHeap, or -1 once
+	// it has been removed from it.
+	heapIndex int
+}
+
+// waitingWorkHeap is a heap of waiting work, ordered by descending priority
+// and ascending creation time.
+type waitingWorkHeap []*waitingWork
+
+var _ heap.Interface = (*waitingWorkHeap)(nil)
+
+func (h waitingWorkHeap) Len() int { return len(h) }
+
+func (h waitingWorkHeap) Less(i, j int) bool {
+	if h[i].info.Priority != h[j].info.Priority {
+		return h[i].info.Priority > h[j].info.Priority
+	}
+	return h[i].info.CreateTime < h[j].info.CreateTime
+}
+
+func (h waitingWorkHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].heapIndex = i
+	h[j].heapIndex = j
+}
+
+func (h *waitingWorkHeap) Push(x interface{}) {
+	w := x.(*waitingWork)
+	w.heapIndex = len(*h)
+	*h = append(*h, w)
+}
+
+func (h *waitingWorkHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	w := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	w.heapIndex = -1
+	return w
+}
+
+// WorkQueueMetrics are the metrics of a WorkQueue.
+type WorkQueueMetrics struct {
+	Requested       *metric.Counter
+	Admitted        *metric.Counter
+	Errored         *metric.Counter
+	WaitDurations   *metric.Histogram
+	WaitQueueLength *metric.Gauge
+}
+
+// MetricStruct implements the metric.Struct interface.
+func (WorkQueueMetrics) MetricStruct() {}
+
+func makeWorkQueueMetrics(name string, histogramWindow time.Duration) WorkQueueMetrics {
+	return WorkQueueMetrics{
+		Requested: metric.NewCounter(metric.Metadata{
+			Name:        "admission.requested." + name,
+			Help:        "Number of requests for admission to the " + name + " queue",
+			Measurement: "Requests",
+			Unit:        metric.Unit_COUNT,
+		}),
+		Admitted: metric.NewCounter(metric.Metadata{
+			Name:        "admission.admitted." + name,
+			Help:        "Number of requests admitted by the " + name + " queue",
+			Measurement: "Requests",
+			Unit:        metric.Unit_COUNT,
+		}),
+		Errored: metric.NewCounter(metric.Metadata{
+			Name:        "admission.errored." + name,
+			Help:        "Number of requests canceled while waiting in the " + name + " queue",
+			Measurement: "Requests",
+			Unit:        metric.Unit_COUNT,
+		}),
+		WaitDurations: metric.NewLatency(metric.Metadata{
+			Name:        "admission.wait_durations." + name,
+			Help:        "Wait time of requests that waited in the " + name + " queue",
+			Measurement: "Wait Time",
+			Unit:        metric.Unit_NANOSECONDS,
+		}, histogramWindow),
+		WaitQueueLength: metric.NewGauge(metric.Metadata{
+			Name:        "admission.wait_queue_length." + name,
+			Help:        "Number of requests waiting in the " + name + " queue",
+			Measurement: "Requests",
+			Unit:        metric.Unit_COUNT,
+		}),
+	}
+}
@@ -0,0 +1,294 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License included
+// in the file licenses/BSL.txt and at www.mariadb.com/bsl11.
+//
+// Change Date: 2022-10-01
+//
+// On the date above, in accordance with the Business Source License, use
+// of this software will be governed by the Apache License, Version 2.0,
+// included in the file licenses/APL.txt and at
+// https://www.apache.org/licenses/LICENSE-2.0
+
+package admission
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/metric"
+	"github.com/pkg/errors"
+)
+
+func newTestSlotQueue(slots int) (*WorkQueue, *slotGranter) {
+	g := &slotGranter{
+		minSlots:         1,
+		maxSlots:         slots * 4,
+		totalSlotsMetric: metric.NewGauge(metaTotalSlots),
+		usedSlotsMetric:  metric.NewGauge(metaUsedSlots),
+	}
+	g.mu.total = slots
+	metrics := makeWorkQueueMetrics("test", time.Minute)
+	q := newWorkQueue("test", g, true /* usesSlots */, &metrics)
+	g.q = q
+	return q, g
+}
+
+func (q *WorkQueue) waitingLen() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.mu.waiting)
+}
+
+func TestWorkQueueOrdering(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+
+	q, _ := newTestSlotQueue(1)
+	if err := q.Admit(ctx, WorkInfo{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Queue work while the only slot is held, out of order.
+	infos := []WorkInfo{
+		{Priority: NormalPri, CreateTime: 2},
+		{Priority: LowPri, CreateTime: 1},
+		{Priority: HighPri, CreateTime: 3},
+		{Priority: NormalPri, CreateTime: 1},
+	}
+	admitted := make(chan WorkInfo, len(infos))
+	for i, info := range infos {
+		go func(info WorkInfo) {
+			if err := q.Admit(ctx, info); err != nil {
+				t.Error(err)
+			}
+			admitted <- info
+		}(info)
+		testutils.SucceedsSoon(t, func() error {
+			if n := q.waitingLen(); n != i+1 {
+				return errors.Errorf("expected %d waiting, got %d", i+1, n)
+			}
+			return nil
+		})
+	}
+
+	expected := []WorkInfo{infos[2], infos[3], infos[0], infos[1]}
+	for _, exp := range expected {
+		q.AdmittedWorkDone()
+		if info := <-admitted; info != exp {
+			t.Fatalf("expected %+v to be admitted, got %+v", exp, info)
+		}
+	}
+	q.AdmittedWorkDone()
+}
+
+func TestWorkQueueCancellation(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	q, g := newTestSlotQueue(1)
+	if err := q.Admit(context.Background(), WorkInfo{}); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- q.Admit(ctx, WorkInfo{})
+	}()
+	testutils.SucceedsSoon(t, func() error {
+		if q.waitingLen() != 1 {
+			return errors.New("work not queued yet")
+		}
+		return nil
+	})
+	cancel()
+	if err := <-errCh; !testutils.IsError(err, "context canceled") {
+		t.Fatalf("expected cancellation, got %v", err)
+	}
+	if n := q.waitingLen(); n != 0 {
+		t.Fatalf("expected no waiting work, got %d", n)
+	}
+	q.AdmittedWorkDone()
+	if used := g.mu.used; used != 0 {
+		t.Fatalf("expected no used slots, got %d", used)
+	}
+
+	// Work bypassing admission is admitted even if no slot is available.
+	if err := q.Admit(context.Background(), WorkInfo{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Admit(context.Background(), WorkInfo{BypassAdmission: true}); err != nil {
+		t.Fatal(err)
+	}
+	if used := g.mu.used; used != 2 {
+		t.Fatalf("expected 2 used slots, got %d", used)
+	}
+}
+
+func TestReleaseSlot(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	q, g := newTestSlotQueue(1)
+	w, err := q.AdmitWork(context.Background(), WorkInfo{CreateTime: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := ContextWithAdmittedWork(context.Background(), w)
+
+	// Work blocked on other work releases its slot, allowing the work it waits
+	// for to be admitted.
+	reacquire := ReleaseSlot(ctx)
+	if used := g.mu.used; used != 0 {
+		t.Fatalf("expected no used slots, got %d", used)
+	}
+	if err := ReleaseSlot(ctx)(); err != nil {
+		t.Fatal(err)
+	}
+	other, err := q.AdmitWork(context.Background(), WorkInfo{CreateTime: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Once unblocked, the work reacquires its slot ahead of work created after
+	// it.
+	reacquired := make(chan error, 1)
+	go func() {
+		reacquired <- reacquire()
+	}()
+	testutils.SucceedsSoon(t, func() error {
+		if q.waitingLen() != 1 {
+			return errors.New("work not queued yet")
+		}
+		return nil
+	})
+	admitted := make(chan struct{})
+	go func() {
+		if err := q.Admit(context.Background(), WorkInfo{CreateTime: 2}); err != nil {
+			t.Error(err)
+		}
+		close(admitted)
+	}()
+	testutils.SucceedsSoon(t, func() error {
+		if q.waitingLen() != 2 {
+			return errors.New("work not queued yet")
+		}
+		return nil
+	})
+	other.Done()
+	if err := <-reacquired; err != nil {
+		t.Fatal(err)
+	}
+	w.Done()
+	<-admitted
+	q.AdmittedWorkDone()
+	if used := g.mu.used; used != 0 {
+		t.Fatalf("expected no used slots, got %d", used)
+	}
+}
+
+func TestSlotGranterAdjust(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	q, g := newTestSlotQueue(8)
+	// Slots are removed while the CPU is overloaded, down to the minimum.
+	g.adjust(1.0 /* utilization */, 0.9 /* target */)
+	if total := g.mu.total; total != 7 {
+		t.Fatalf("expected 7 slots, got %d", total)
+	}
+	for i := 0; i < 10; i++ {
+		g.adjust(1.0, 0.9)
+	}
+	if total := g.mu.total; total != 1 {
+		t.Fatalf("expected 1 slot, got %d", total)
+	}
+	// Slots are not added while they aren't all used.
+	g.adjust(0.5, 0.9)
+	if total := g.mu.total; total != 1 {
+		t.Fatalf("expected 1 slot, got %d", total)
+	}
+	// Slots are added while they are all used and the CPU isn't saturated,
+	// and waiting work is granted the new slots.
+	if err := q.Admit(context.Background(), WorkInfo{}); err != nil {
+		t.Fatal(err)
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- q.Admit(context.Background(), WorkInfo{})
+	}()
+	testutils.SucceedsSoon(t, func() error {
+		if q.waitingLen() != 1 {
+			return errors.New("work not queued yet")
+		}
+		return nil
+	})
+	g.adjust(0.5, 0.9)
+	if err := <-errCh; err != nil {
+		t.Fatal(err)
+	}
+	if total := g.mu.total; total != 2 {
+		t.Fatalf("expected 2 slots, got %d", total)
+	}
+	q.AdmittedWorkDone()
+	q.AdmittedWorkDone()
+}
+
+func TestTokenGranterAdjust(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+
+	l0FileCount := int64(0)
+	g := &tokenGranter{l0FileCount: func() int64 { return l0FileCount }}
+	g.mu.unlimited = true
+	metrics := makeWorkQueueMetrics("test", time.Minute)
+	q := newWorkQueue("test", g, false /* usesSlots */, &metrics)
+	g.q = q
+
+	// Writes are admitted without limit while the store isn't overloaded.
+	for i := 0; i < 100; i++ {
+		if err := q.Admit(ctx, WorkInfo{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	g.adjust(10 /* threshold */)
+	if !g.mu.unlimited {
+		t.Fatal("expected unlimited tokens")
+	}
+
+	// Once overloaded, the rate of writes is scaled down by the overload.
+	for i := 0; i < 100; i++ {
+		if err := q.Admit(ctx, WorkInfo{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	l0FileCount = 40
+	g.adjust(10)
+	if g.mu.unlimited || g.mu.available != 25 {
+		t.Fatalf("expected 25 tokens, got %d (unlimited: %t)", g.mu.available, g.mu.unlimited)
+	}
+	for i := 0; i < 25; i++ {
+		if err := q.Admit(ctx, WorkInfo{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := q.Admit(ctx, WorkInfo{}); !testutils.IsError(err, "deadline exceeded") {
+		t.Fatalf("expected write to be throttled, got %v", err)
+	}
+
+	// Work bypassing admission incurs a debt carried over to the next interval.
+	if err := q.Admit(context.Background(), WorkInfo{BypassAdmission: true}); err != nil {
+		t.Fatal(err)
+	}
+	g.adjust(10)
+	if g.mu.available != 5 {
+		t.Fatalf("expected 5 tokens, got %d", g.mu.available)
+	}
+	l0FileCount = 5
+	g.adjust(10)
+	if !g.mu.unlimited {
+		t.Fatal("expected unlimited tokens")
+	}
+}